@@ -17,6 +17,13 @@ const applicationEndpoints: { [action: string]: EndpointConfig } = {
   "runningDeployments": { "url": "/apps/{identifier}/deployments/running", "method": "GET" },
   "releases": { "url": "/apps/{uid}/releases", "method": "GET" },
   "latestRelease": { "url": "/apps/{uid}/releases/latest", "method": "GET" },
+  "buildCache": { "url": "/apps/{uid}/build-cache", "method": "GET" },
+  "buildCachePurge": { "url": "/apps/{uid}/build-cache", "method": "DELETE" },
+  "retentionRun": { "url": "/apps/{uid}/retention/run", "method": "POST" },
+  "freezeWindows": { "url": "/apps/{uid}/freeze-windows", "method": "GET" },
+  "scheduledDeployments": { "url": "/apps/{uid}/scheduled-deployments", "method": "GET" },
+  "scheduledDeploymentCreate": { "url": "/apps/{uid}/scheduled-deployments", "method": "POST" },
+  "scheduledDeploymentCancel": { "url": "/scheduled-deployments/{scheduleId}", "method": "DELETE" },
   "configurations": { "url": "/apps/{uid}/configurations", "method": "GET" },
   "routings": { "url": "/apps/{uid}/routings", "method": "GET" },
   "tokens": { "url": "/apps/{uid}/tokens", "method": "GET" },
@@ -26,7 +33,19 @@ const applicationEndpoints: { [action: string]: EndpointConfig } = {
   "environmentVariables": { "url": "/apps/{uid}/environment-variables", "method": "GET" },
   "environmentVariableCreate": { "url": "/apps/{uid}/environment-variables", "method": "POST" },
   "environmentVariableUpdate": { "url": "/environment-variables/{envVarId}", "method": "PUT" },
-  "environmentVariableDelete": { "url": "/environment-variables/{envVarId}", "method": "DELETE" }
+  "environmentVariableDelete": { "url": "/environment-variables/{envVarId}", "method": "DELETE" },
+  "deployKey": { "url": "/apps/{uid}/deploy-key", "method": "GET" },
+  "deployKeyGenerate": { "url": "/apps/{uid}/deploy-key", "method": "POST" },
+  "deployKeyDelete": { "url": "/apps/{uid}/deploy-key", "method": "DELETE" },
+  "deployKeyKnownHosts": { "url": "/apps/{uid}/deploy-key/known-hosts", "method": "PUT" },
+  "buildSecrets": { "url": "/apps/{uid}/build-secrets", "method": "GET" },
+  "buildSecretCreate": { "url": "/apps/{uid}/build-secrets", "method": "POST" },
+  "buildSecretUpdate": { "url": "/build-secrets/{secretId}", "method": "PUT" },
+  "buildSecretDelete": { "url": "/build-secrets/{secretId}", "method": "DELETE" },
+  "registryImage": { "url": "/apps/{uid}/registry-image", "method": "GET" },
+  "registryImageUpdate": { "url": "/apps/{uid}/registry-image", "method": "PUT" },
+  "registryImageDelete": { "url": "/apps/{uid}/registry-image", "method": "DELETE" },
+  "registryImageCheck": { "url": "/apps/{uid}/registry-image/check", "method": "POST" }
 };
 
 // 2. 立即调用注册函数
@@ -120,4 +139,80 @@ export function updateEnvironmentVariableEndpoint(envVarId: string): ApiEndpoint
 
 export function deleteEnvironmentVariableEndpoint(envVarId: string): ApiEndpoint<'DELETE'> {
   return getApiEndpoint('applications', 'environmentVariableDelete', { envVarId });
-}
\ No newline at end of file
+}
+
+export function getDeployKeyEndpoint(uid: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('applications', 'deployKey', { uid });
+}
+
+export function generateDeployKeyEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('applications', 'deployKeyGenerate', { uid });
+}
+
+export function deleteDeployKeyEndpoint(uid: string): ApiEndpoint<'DELETE'> {
+  return getApiEndpoint('applications', 'deployKeyDelete', { uid });
+}
+
+export function pinKnownHostsEndpoint(uid: string): ApiEndpoint<'PUT'> {
+  return getApiEndpoint('applications', 'deployKeyKnownHosts', { uid });
+}
+
+export function getBuildSecretsEndpoint(uid: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('applications', 'buildSecrets', { uid });
+}
+
+export function createBuildSecretEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('applications', 'buildSecretCreate', { uid });
+}
+
+export function updateBuildSecretEndpoint(secretId: string): ApiEndpoint<'PUT'> {
+  return getApiEndpoint('applications', 'buildSecretUpdate', { secretId });
+}
+
+export function deleteBuildSecretEndpoint(secretId: string): ApiEndpoint<'DELETE'> {
+  return getApiEndpoint('applications', 'buildSecretDelete', { secretId });
+}
+
+export function getRegistryImageEndpoint(uid: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('applications', 'registryImage', { uid });
+}
+
+export function updateRegistryImageEndpoint(uid: string): ApiEndpoint<'PUT'> {
+  return getApiEndpoint('applications', 'registryImageUpdate', { uid });
+}
+
+export function deleteRegistryImageEndpoint(uid: string): ApiEndpoint<'DELETE'> {
+  return getApiEndpoint('applications', 'registryImageDelete', { uid });
+}
+
+export function checkRegistryImageEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('applications', 'registryImageCheck', { uid });
+}
+
+export function getBuildCacheEndpoint(uid: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('applications', 'buildCache', { uid });
+}
+
+export function purgeBuildCacheEndpoint(uid: string): ApiEndpoint<'DELETE'> {
+  return getApiEndpoint('applications', 'buildCachePurge', { uid });
+}
+
+export function runRetentionEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('applications', 'retentionRun', { uid });
+}
+
+export function getApplicationFreezeWindowsEndpoint(uid: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('applications', 'freezeWindows', { uid });
+}
+
+export function getScheduledDeploymentsEndpoint(uid: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('applications', 'scheduledDeployments', { uid });
+}
+
+export function createScheduledDeploymentEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('applications', 'scheduledDeploymentCreate', { uid });
+}
+
+export function cancelScheduledDeploymentEndpoint(scheduleId: string): ApiEndpoint<'DELETE'> {
+  return getApiEndpoint('applications', 'scheduledDeploymentCancel', { scheduleId });
+}
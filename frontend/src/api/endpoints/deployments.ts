@@ -7,6 +7,8 @@ const deploymentsEndpoints: { [action: string]: EndpointConfig } = {
   "logs": { "url": "/deployments/{uid}/logs", "method": "GET" },
   "logsData": { "url": "/deployments/{uid}/logs-data", "method": "GET" },
   "restart": { "url": "/deployments/{uid}/restart", "method": "POST" },
+  "approve": { "url": "/deployments/{uid}/approve", "method": "POST" },
+  "reject": { "url": "/deployments/{uid}/reject", "method": "POST" },
   "status": { "url": "/deployments/{uid}/status", "method": "GET" }
 };
 
@@ -37,6 +39,14 @@ export function restartDeploymentEndpoint(uid: string): ApiEndpoint<'POST'> {
   return getApiEndpoint('deployments', 'restart', { uid });
 }
 
+export function approveDeploymentEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('deployments', 'approve', { uid });
+}
+
+export function rejectDeploymentEndpoint(uid: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('deployments', 'reject', { uid });
+}
+
 export function getDeploymentStatusEndpoint(uid: string): ApiEndpoint<'GET'> {
   return getApiEndpoint('deployments', 'status', { uid });
 }
@@ -10,7 +10,10 @@ const projectsEndpoints: { [action: string]: EndpointConfig } = {
   "createApp": { "url": "/projects/{projectId}/apps", "method": "POST" },
   "listApps": { "url": "/projects/{projectId}/apps", "method": "GET" },
   "listAppsByName": { "url": "/projects/by-name/{name}/apps", "method": "GET" },
-  "getAppByName": { "url": "/projects/by-name/{projectName}/apps/by-name/{appName}", "method": "GET" }
+  "getAppByName": { "url": "/projects/by-name/{projectName}/apps/by-name/{appName}", "method": "GET" },
+  "freezeWindows": { "url": "/projects/{projectId}/freeze-windows", "method": "GET" },
+  "freezeWindowCreate": { "url": "/projects/{projectId}/freeze-windows", "method": "POST" },
+  "freezeWindowDelete": { "url": "/freeze-windows/{windowId}", "method": "DELETE" }
 };
 
 registerEndpoints('projects', projectsEndpoints);
@@ -48,3 +51,15 @@ export function listAppsByNameEndpoint(name: string): ApiEndpoint<'GET'> {
 export function getAppByNameEndpoint(projectName: string, appName: string): ApiEndpoint<'GET'> {
   return getApiEndpoint('projects', 'getAppByName', { projectName, appName });
 }
+
+export function getFreezeWindowsEndpoint(projectId: string): ApiEndpoint<'GET'> {
+  return getApiEndpoint('projects', 'freezeWindows', { projectId });
+}
+
+export function createFreezeWindowEndpoint(projectId: string): ApiEndpoint<'POST'> {
+  return getApiEndpoint('projects', 'freezeWindowCreate', { projectId });
+}
+
+export function deleteFreezeWindowEndpoint(windowId: string): ApiEndpoint<'DELETE'> {
+  return getApiEndpoint('projects', 'freezeWindowDelete', { windowId });
+}
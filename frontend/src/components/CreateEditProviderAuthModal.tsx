@@ -99,6 +99,19 @@ const CreateEditProviderAuthModal: Component<CreateEditProviderAuthModalProps> =
                 />
               </div>
 
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">实例地址</span>
+                </label>
+                <input
+                  type="url"
+                  placeholder="自建实例填写，如 https://gitlab.example.com，留空使用公共实例"
+                  class="input input-bordered"
+                  value={props.formData.baseUrl || ''}
+                  onInput={(e) => props.setFormData({ ...props.formData, baseUrl: e.target.value })}
+                />
+              </div>
+
               <div class="form-control">
                 <label class="label">
                   <span class="label-text">Scopes</span>
@@ -144,6 +157,20 @@ const CreateEditProviderAuthModal: Component<CreateEditProviderAuthModalProps> =
               </div>
             </Show>
 
+            {/* Webhook secret，用于校验仓库推送事件的签名 */}
+            <div class="form-control">
+              <label class="label">
+                <span class="label-text">Webhook Secret</span>
+              </label>
+              <input
+                type="password"
+                placeholder={props.editingAuth ? '留空则保持不变' : '留空则自动生成'}
+                class="input input-bordered"
+                value={props.formData.webhookSecret || ''}
+                onInput={(e) => props.setFormData({ ...props.formData, webhookSecret: e.target.value })}
+              />
+            </div>
+
             {/* Is active toggle */}
             <div class="form-control">
               <label class="label cursor-pointer">
@@ -1,8 +1,17 @@
 import { Component, Show, For, createSignal } from 'solid-js'
-import type { DeploymentHistory, Application } from '../../types/project'
+import { useQueryClient } from '@tanstack/solid-query'
+import type { DeploymentHistory, Application, ScheduledDeployment } from '../../types/project'
 import type { Deployment } from '../../types/deployment'
 import { useApiQuery } from '../../api/apiHooksW.ts'
-import { getApplicationByIdEndpoint, getApplicationDeploymentsEndpoint } from '../../api/endpoints'
+import { apiMutate } from '../../api/apiClient'
+import {
+  getApplicationByIdEndpoint,
+  getApplicationDeploymentsEndpoint,
+  approveDeploymentEndpoint,
+  rejectDeploymentEndpoint,
+  getScheduledDeploymentsEndpoint,
+  cancelScheduledDeploymentEndpoint
+} from '../../api/endpoints'
 import CreateDeploymentModal from './CreateDeploymentModal.tsx'
 import DeploymentLogsModal from './DeploymentLogsModal.tsx'
 
@@ -14,6 +23,9 @@ const ApplicationDeploymentsTab: Component<ApplicationDeploymentsTabProps> = (pr
   const [showCreateModal, setShowCreateModal] = createSignal(false)
   const [showLogsModal, setShowLogsModal] = createSignal(false)
   const [selectedDeployment, setSelectedDeployment] = createSignal<Deployment | null>(null)
+  const [reviewingUid, setReviewingUid] = createSignal<string | null>(null)
+  const [reviewError, setReviewError] = createSignal('')
+  const queryClient = useQueryClient()
 
   // Fetch application data
   const appQuery = useApiQuery<Application>(
@@ -29,6 +41,26 @@ const ApplicationDeploymentsTab: Component<ApplicationDeploymentsTabProps> = (pr
     { enabled: () => !!props.applicationUid }
   )
 
+  // Fetch scheduled deployments，只显示等待执行的计划
+  const scheduledQuery = useApiQuery<ScheduledDeployment[]>(
+    () => ['applications', props.applicationUid, 'scheduled-deployments'],
+    () => getScheduledDeploymentsEndpoint(props.applicationUid).url,
+    { enabled: () => !!props.applicationUid }
+  )
+  const pendingSchedules = () => (scheduledQuery.data || []).filter(s => s.status === 'pending')
+
+  // 取消尚未开始的定时部署
+  const cancelSchedule = async (scheduled: ScheduledDeployment) => {
+    if (!confirm(`确定取消计划于 ${new Date(scheduled.scheduledAt).toLocaleString()} 的部署吗？`)) return
+    setReviewError('')
+    try {
+      await apiMutate(cancelScheduledDeploymentEndpoint(scheduled.uid).url, { method: 'DELETE' })
+      await queryClient.invalidateQueries({ queryKey: ['applications', props.applicationUid, 'scheduled-deployments'] })
+    } catch (err: any) {
+      setReviewError(err.message || '取消定时部署失败')
+    }
+  }
+
   // 打开日志模态框
   const openLogsModal = (deployment: DeploymentHistory) => {
     // 转换 DeploymentHistory 到 Deployment 类型
@@ -50,6 +82,31 @@ const ApplicationDeploymentsTab: Component<ApplicationDeploymentsTabProps> = (pr
     setShowLogsModal(true)
   }
 
+  // 批准或拒绝受保护应用等待审批的部署，审批意见记录在部署历史中
+  const reviewDeployment = async (deployment: DeploymentHistory, action: 'approve' | 'reject') => {
+    const comment = prompt(action === 'approve' ? '批准部署，可填写审批意见：' : '拒绝部署，请填写原因：')
+    if (comment === null) return
+    setReviewingUid(deployment.uid)
+    setReviewError('')
+    try {
+      const endpoint = action === 'approve' ? approveDeploymentEndpoint(deployment.uid) : rejectDeploymentEndpoint(deployment.uid)
+      try {
+        await apiMutate(endpoint.url, { method: 'POST', body: { comment } })
+      } catch (err: any) {
+        // 批准时处于冻结窗口，需要填写强制部署的原因
+        if (action !== 'approve' || !String(err.message).includes('部署冻结中')) throw err
+        const freezeOverrideReason = prompt(`${err.message}\n\n填写强制部署的原因以继续批准：`)
+        if (!freezeOverrideReason?.trim()) throw err
+        await apiMutate(endpoint.url, { method: 'POST', body: { comment, freezeOverrideReason } })
+      }
+      await queryClient.invalidateQueries({ queryKey: ['applications', props.applicationUid, 'deployments'] })
+    } catch (err: any) {
+      setReviewError(err.message || '审批失败')
+    } finally {
+      setReviewingUid(null)
+    }
+  }
+
   return (
     <div class="space-y-4">
       <div class="flex justify-between items-center">
@@ -62,6 +119,39 @@ const ApplicationDeploymentsTab: Component<ApplicationDeploymentsTabProps> = (pr
         </button>
       </div>
 
+      <Show when={reviewError()}>
+        <div class="alert alert-error">
+          <span>{reviewError()}</span>
+        </div>
+      </Show>
+
+      <Show when={pendingSchedules().length > 0}>
+        <div class="card bg-base-200">
+          <div class="card-body p-4">
+            <h4 class="font-semibold">定时部署</h4>
+            <ul class="space-y-2">
+              <For each={pendingSchedules()}>
+                {(scheduled) => (
+                  <li class="flex items-center justify-between gap-4 text-sm">
+                    <div>
+                      <span class="font-medium">{new Date(scheduled.scheduledAt).toLocaleString()}</span>
+                      <span class="text-base-content/70">
+                        {' '}· {scheduled.releaseUid ? `发布版本 ${scheduled.releaseUid}` : `重新构建${scheduled.gitRef ? ` ${scheduled.gitRef}` : ''}`}
+                        <Show when={scheduled.requestedBy}> · 发起：{scheduled.requestedBy}</Show>
+                        <Show when={scheduled.freezeOverrideReason}> · 强制部署：{scheduled.freezeOverrideReason}</Show>
+                      </span>
+                    </div>
+                    <button class="btn btn-xs btn-ghost text-error" onClick={() => cancelSchedule(scheduled)}>
+                      取消
+                    </button>
+                  </li>
+                )}
+              </For>
+            </ul>
+          </div>
+        </div>
+      </Show>
+
       <Show
         when={!deploymentsQuery.isPending}
         fallback={
@@ -103,15 +193,48 @@ const ApplicationDeploymentsTab: Component<ApplicationDeploymentsTabProps> = (pr
                           deployment.status === 'success' ? 'badge-success' :
                           deployment.status === 'failed' ? 'badge-error' :
                           deployment.status === 'running' ? 'badge-warning' :
+                          deployment.status === 'awaiting_approval' ? 'badge-warning' :
+                          deployment.status === 'rejected' || deployment.status === 'expired' ? 'badge-ghost' :
                           'badge-info'
                         }`}>
                           {deployment.status}
                         </span>
+                        <Show when={deployment.requestedBy}>
+                          <div class="text-xs text-base-content/70 mt-1">发起：{deployment.requestedBy}</div>
+                        </Show>
+                        <Show when={deployment.status === 'awaiting_approval' && deployment.approvalExpiresAt}>
+                          <div class="text-xs text-base-content/70">审批截止：{new Date(deployment.approvalExpiresAt!).toLocaleString()}</div>
+                        </Show>
+                        <Show when={deployment.reviewedBy}>
+                          <div class="text-xs text-base-content/70" title={deployment.reviewComment}>
+                            {deployment.status === 'rejected' ? '拒绝' : '批准'}：{deployment.reviewedBy}
+                            <Show when={deployment.reviewComment}>（{deployment.reviewComment}）</Show>
+                          </div>
+                        </Show>
+                        <Show when={deployment.freezeOverrideReason}>
+                          <div class="text-xs text-warning" title={deployment.freezeOverrideReason}>冻结期强制部署：{deployment.freezeOverrideReason}</div>
+                        </Show>
                       </td>
                       <td>{new Date(deployment.startedAt).toLocaleString()}</td>
                       <td>{deployment.finishedAt ? new Date(deployment.finishedAt).toLocaleString() : '-'}</td>
                       <td>
                         <div class="flex gap-1">
+                          <Show when={deployment.status === 'awaiting_approval'}>
+                            <button
+                              class="btn btn-xs btn-success"
+                              disabled={reviewingUid() === deployment.uid}
+                              onClick={() => reviewDeployment(deployment, 'approve')}
+                            >
+                              批准
+                            </button>
+                            <button
+                              class="btn btn-xs btn-error btn-outline"
+                              disabled={reviewingUid() === deployment.uid}
+                              onClick={() => reviewDeployment(deployment, 'reject')}
+                            >
+                              拒绝
+                            </button>
+                          </Show>
                           <button 
                             class="btn btn-xs btn-outline"
                             onClick={() => openLogsModal(deployment)}
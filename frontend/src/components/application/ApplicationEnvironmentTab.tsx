@@ -9,6 +9,7 @@ import {
   deleteEnvironmentVariableEndpoint 
 } from '../../api/endpoints'
 import type { EnvironmentVariable, CreateEnvironmentVariableRequest } from '../../types/project'
+import BuildSecretsCard from './BuildSecretsCard'
 
 interface ApplicationEnvironmentTabProps {
   applicationUid: string
@@ -18,6 +19,7 @@ interface VariableFormData {
   key: string
   value: string
   isEncrypted: boolean
+  isBuildTime?: boolean
 }
 
 const ApplicationEnvironmentTab: Component<ApplicationEnvironmentTabProps> = (props) => {
@@ -62,7 +64,8 @@ const ApplicationEnvironmentTab: Component<ApplicationEnvironmentTabProps> = (pr
       body: (variables: { uid: string } & CreateEnvironmentVariableRequest) => ({ 
         key: variables.key, 
         value: variables.value, 
-        isEncrypted: variables.isEncrypted 
+        isEncrypted: variables.isEncrypted,
+        isBuildTime: variables.isBuildTime ?? false
       }),
       onSuccess: () => {
         setEditingVariable(null)
@@ -111,7 +114,8 @@ const ApplicationEnvironmentTab: Component<ApplicationEnvironmentTabProps> = (pr
     setEditingValue({ 
       key: variable.key, 
       value: variable.value, 
-      isEncrypted: variable.isEncrypted 
+      isEncrypted: variable.isEncrypted,
+      isBuildTime: variable.isBuildTime ?? false
     })
   }
 
@@ -193,6 +197,16 @@ const ApplicationEnvironmentTab: Component<ApplicationEnvironmentTabProps> = (pr
                   />
                 </div>
               </div>
+              <label class="label cursor-pointer justify-start mt-2">
+                <input
+                  type="checkbox"
+                  checked={newVariable().isBuildTime ?? false}
+                  onChange={(e) => setNewVariable(prev => ({ ...prev, isBuildTime: e.currentTarget.checked }))}
+                  class="checkbox checkbox-primary checkbox-sm"
+                  disabled={isMutating()}
+                />
+                <span class="label-text ml-2">Build-time (passed as --build-arg)</span>
+              </label>
               <div class="flex justify-end space-x-2 mt-4">
                 <button
                   onClick={cancelAdding}
@@ -275,11 +289,26 @@ const ApplicationEnvironmentTab: Component<ApplicationEnvironmentTabProps> = (pr
                                 />
                                 <span class="label-text ml-2">Encrypted</span>
                               </label>
+                              <label class="label cursor-pointer">
+                                <input
+                                  type="checkbox"
+                                  checked={editingValue().isBuildTime ?? false}
+                                  onChange={(e) => setEditingValue(prev => ({ ...prev, isBuildTime: e.currentTarget.checked }))}
+                                  class="checkbox checkbox-primary"
+                                  disabled={isMutating()}
+                                />
+                                <span class="label-text ml-2">Build-time</span>
+                              </label>
                             </div>
                           ) : (
-                            <span class={`badge ${variable.isEncrypted ? 'badge-warning' : 'badge-ghost'}`}>
-                              {variable.isEncrypted ? 'Encrypted' : 'Normal'}
-                            </span>
+                            <div class="flex gap-1">
+                              <span class={`badge ${variable.isEncrypted ? 'badge-warning' : 'badge-ghost'}`}>
+                                {variable.isEncrypted ? 'Encrypted' : 'Normal'}
+                              </span>
+                              <Show when={variable.isBuildTime}>
+                                <span class="badge badge-info">Build-time</span>
+                              </Show>
+                            </div>
                           )}
                         </td>
                         <td>
@@ -336,6 +365,8 @@ const ApplicationEnvironmentTab: Component<ApplicationEnvironmentTabProps> = (pr
           </Show>
         </div>
       </div>
+
+      <BuildSecretsCard applicationUid={props.applicationUid} />
     </div>
   )
 }
@@ -1,8 +1,12 @@
 import { Component, Show, For, createSignal, createEffect } from 'solid-js'
-import type { Application, VolumeMount } from '../../types/project'
+import type { Application, VolumeMount, BuildCacheUsage, RetentionRunResult } from '../../types/project'
 import { useI18n } from '../../i18n'
+import DeployKeyCard from './DeployKeyCard'
+import RegistryImageCard from './RegistryImageCard'
+import FreezeWindowCard from './FreezeWindowCard'
 import { useApiMutation } from '../../api/apiHooksW.ts'
-import { updateApplicationEndpoint } from '../../api/endpoints'
+import { apiGet, apiMutate } from '../../api/apiClient'
+import { updateApplicationEndpoint, getBuildCacheEndpoint, purgeBuildCacheEndpoint, runRetentionEndpoint } from '../../api/endpoints'
 import { useNavigate } from '@solidjs/router'
 import DeleteApplicationModal from '../DeleteApplicationModal'
 
@@ -24,6 +28,21 @@ const ApplicationSettingsTab: Component<ApplicationSettingsTabProps> = (props) =
   const [repoUrl, setRepoUrl] = createSignal<string>('')
   const [buildDir, setBuildDir] = createSignal<string>('')
   const [buildType, setBuildType] = createSignal<string>('')
+  const [dockerfilePath, setDockerfilePath] = createSignal<string>('')
+  const [buildTarget, setBuildTarget] = createSignal<string>('')
+  const [buildCacheEnabled, setBuildCacheEnabled] = createSignal(true)
+  const [pullAlways, setPullAlways] = createSignal(true)
+  const [autoDeployOnPush, setAutoDeployOnPush] = createSignal(false)
+  const [autoDeployOnTag, setAutoDeployOnTag] = createSignal(false)
+  const [autoDeployOnImagePush, setAutoDeployOnImagePush] = createSignal(false)
+  const [cacheUsage, setCacheUsage] = createSignal<BuildCacheUsage | null>(null)
+  const [isPurging, setIsPurging] = createSignal(false)
+  const [retentionKeepLast, setRetentionKeepLast] = createSignal(0)
+  const [retentionKeepDays, setRetentionKeepDays] = createSignal(0)
+  const [isRunningRetention, setIsRunningRetention] = createSignal(false)
+  const [approvalProtected, setApprovalProtected] = createSignal(false)
+  const [approvalTimeoutHours, setApprovalTimeoutHours] = createSignal(24)
+  const [approvalApprovers, setApprovalApprovers] = createSignal('')
   const [providerAuthId, setProviderAuthId] = createSignal<number | undefined>()
   const [isSaving, setIsSaving] = createSignal(false)
   const [error, setError] = createSignal('')
@@ -52,6 +71,18 @@ const ApplicationSettingsTab: Component<ApplicationSettingsTabProps> = (props) =
       setRepoUrl(props.currentApp.repoUrl || '')
       setBuildDir(props.currentApp.buildDir || '/')
       setBuildType(props.currentApp.buildType || 'dockerfile')
+      setDockerfilePath(props.currentApp.dockerfilePath || 'Dockerfile')
+      setBuildTarget(props.currentApp.buildTarget || '')
+      setBuildCacheEnabled(props.currentApp.buildCache?.enabled ?? true)
+      setPullAlways(props.currentApp.buildCache?.pullAlways ?? true)
+      setAutoDeployOnPush(props.currentApp.autoDeploy?.onPush ?? false)
+      setAutoDeployOnTag(props.currentApp.autoDeploy?.onTag ?? false)
+      setAutoDeployOnImagePush(props.currentApp.autoDeploy?.onImagePush ?? false)
+      setRetentionKeepLast(props.currentApp.retention?.keepLast ?? 0)
+      setRetentionKeepDays(props.currentApp.retention?.keepDays ?? 0)
+      setApprovalProtected(props.currentApp.approval?.protected ?? false)
+      setApprovalTimeoutHours(props.currentApp.approval?.timeoutHours || 24)
+      setApprovalApprovers((props.currentApp.approval?.approvers ?? []).join(', '))
     }
   })
 
@@ -126,14 +157,88 @@ const ApplicationSettingsTab: Component<ApplicationSettingsTabProps> = (props) =
     branch: branch().trim(),
     buildDir: buildDir().trim(),
     buildType: buildType().trim(),
+    dockerfilePath: dockerfilePath().trim(),
+    buildTarget: buildTarget().trim(),
+    buildCache: {
+      enabled: buildCacheEnabled(),
+      pullAlways: pullAlways()
+    },
+    autoDeploy: {
+      onPush: autoDeployOnPush(),
+      onTag: autoDeployOnTag(),
+      onImagePush: autoDeployOnImagePush()
+    },
     providerAuthId: providerAuthId()
   })
 
+  // 构建缓存占用情况
+  const formatBytes = (bytes: number) => {
+    if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`
+    if (bytes < 1024 * 1024 * 1024) return `${(bytes / 1024 / 1024).toFixed(1)} MB`
+    return `${(bytes / 1024 / 1024 / 1024).toFixed(2)} GB`
+  }
+
+  const loadCacheUsage = async () => {
+    if (!props.currentApp) return
+    try {
+      setCacheUsage(await apiGet<BuildCacheUsage>(getBuildCacheEndpoint(props.currentApp.uid).url))
+    } catch (err: any) {
+      setError(err.message || '获取构建缓存失败')
+    }
+  }
+
+  const handlePurgeCache = async (pruneLayers: boolean) => {
+    if (!props.currentApp) return
+    if (pruneLayers && !confirm('清理悬空镜像层会影响所有应用的下一次构建速度，确定继续吗？')) return
+    setIsPurging(true)
+    try {
+      const url = purgeBuildCacheEndpoint(props.currentApp.uid).url + (pruneLayers ? '?layers=true' : '')
+      const result = await apiMutate<{ reclaimedBytes: number }>(url, { method: 'DELETE' })
+      setSuccessMessage(`已清理构建缓存，释放 ${formatBytes(result?.reclaimedBytes || 0)}`)
+      setTimeout(() => setSuccessMessage(''), 3000)
+      await loadCacheUsage()
+    } catch (err: any) {
+      setError(err.message || '清理构建缓存失败')
+    } finally {
+      setIsPurging(false)
+    }
+  }
+
   const handleSaveRuntime = () => handlePartialSave({
     execCommand: execCommand().trim() || null,
     autoUpdatePolicy: autoUpdatePolicy() || null
   })
 
+  const handleSaveRetention = () => handlePartialSave({
+    retention: {
+      keepLast: retentionKeepLast(),
+      keepDays: retentionKeepDays()
+    }
+  })
+
+  const handleSaveApproval = () => handlePartialSave({
+    approval: {
+      protected: approvalProtected(),
+      timeoutHours: approvalTimeoutHours(),
+      approvers: approvalApprovers().split(',').map((name) => name.trim()).filter((name) => name !== '')
+    }
+  })
+
+  const handleRunRetention = async () => {
+    if (!props.currentApp) return
+    setIsRunningRetention(true)
+    setError('')
+    try {
+      const result = await apiMutate<RetentionRunResult>(runRetentionEndpoint(props.currentApp.uid).url, { method: 'POST' })
+      setSuccessMessage(`已删除 ${result?.removedReleases || 0} 个版本、${result?.removedImages || 0} 个镜像、${result?.removedUnits || 0} 个服务，释放 ${formatBytes(result?.reclaimedBytes || 0)}`)
+      setTimeout(() => setSuccessMessage(''), 3000)
+    } catch (err: any) {
+      setError(err.message || '执行保留策略失败')
+    } finally {
+      setIsRunningRetention(false)
+    }
+  }
+
   const handleSaveStorage = () => handlePartialSave({
     volumes: volumeMounts()
   })
@@ -244,7 +349,7 @@ const ApplicationSettingsTab: Component<ApplicationSettingsTabProps> = (props) =
                   class="input input-bordered"
                   value={repoUrl()}
                   onInput={(e) => setRepoUrl(e.currentTarget.value)}
-                  placeholder="https://github.com/user/repo"
+                  placeholder="https://github.com/user/repo 或 git@github.com:user/repo.git"
                 />
               </div>
 
@@ -288,10 +393,120 @@ const ApplicationSettingsTab: Component<ApplicationSettingsTabProps> = (props) =
                   <option value="nixpacks">Nixpacks</option> */}
                 </select>
               </div>
+
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">Dockerfile 路径</span>
+                </label>
+                <input
+                  type="text"
+                  class="input input-bordered"
+                  value={dockerfilePath()}
+                  onInput={(e) => setDockerfilePath(e.currentTarget.value)}
+                  placeholder="相对于构建目录，默认 Dockerfile"
+                />
+              </div>
+
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">构建目标阶段</span>
+                </label>
+                <input
+                  type="text"
+                  class="input input-bordered"
+                  value={buildTarget()}
+                  onInput={(e) => setBuildTarget(e.currentTarget.value)}
+                  placeholder="多阶段构建的 --target，留空构建最终阶段"
+                />
+              </div>
+
+              <div class="form-control">
+                <label class="label cursor-pointer justify-start gap-2">
+                  <input
+                    type="checkbox"
+                    class="checkbox checkbox-sm"
+                    checked={buildCacheEnabled()}
+                    onChange={(e) => setBuildCacheEnabled(e.currentTarget.checked)}
+                  />
+                  <span class="label-text">启用构建缓存（复用克隆目录和镜像层）</span>
+                </label>
+                <label class="label cursor-pointer justify-start gap-2">
+                  <input
+                    type="checkbox"
+                    class="checkbox checkbox-sm"
+                    checked={pullAlways()}
+                    onChange={(e) => setPullAlways(e.currentTarget.checked)}
+                  />
+                  <span class="label-text">每次构建拉取最新的基础镜像 (--pull-always)</span>
+                </label>
+              </div>
+
+              <div class="form-control">
+                <label class="label cursor-pointer justify-start gap-2">
+                  <input
+                    type="checkbox"
+                    class="checkbox checkbox-sm"
+                    checked={autoDeployOnPush()}
+                    onChange={(e) => setAutoDeployOnPush(e.currentTarget.checked)}
+                  />
+                  <span class="label-text">推送到部署分支时自动部署</span>
+                </label>
+                <label class="label cursor-pointer justify-start gap-2">
+                  <input
+                    type="checkbox"
+                    class="checkbox checkbox-sm"
+                    checked={autoDeployOnTag()}
+                    onChange={(e) => setAutoDeployOnTag(e.currentTarget.checked)}
+                  />
+                  <span class="label-text">推送标签时自动部署该标签</span>
+                </label>
+                <label class="label">
+                  <span class="label-text-alt">需要在仓库中配置授权管理页面提供的 Webhook 地址</span>
+                </label>
+                <label class="label cursor-pointer justify-start gap-2">
+                  <input
+                    type="checkbox"
+                    class="checkbox checkbox-sm"
+                    checked={autoDeployOnImagePush()}
+                    onChange={(e) => setAutoDeployOnImagePush(e.currentTarget.checked)}
+                  />
+                  <span class="label-text">推送镜像到内置仓库后自动部署</span>
+                </label>
+                <label class="label">
+                  <span class="label-text-alt">使用应用令牌登录：podman login {window.location.host} -u {props.currentApp?.name} -p &lt;令牌&gt;</span>
+                </label>
+              </div>
+
+              <div class="form-control md:col-span-2">
+                <div class="flex flex-wrap items-center gap-2">
+                  <button class="btn btn-sm btn-ghost" onClick={loadCacheUsage}>查看缓存占用</button>
+                  <Show when={cacheUsage()}>
+                    {(usage) => (
+                      <span class="text-sm text-base-content/70">
+                        克隆目录 {formatBytes(usage().repoCacheBytes)}，镜像 {usage().imageCount} 个共 {formatBytes(usage().imageBytes)}
+                      </span>
+                    )}
+                  </Show>
+                  <button class="btn btn-sm btn-outline" disabled={isPurging()} onClick={() => handlePurgeCache(false)}>
+                    清理构建缓存
+                  </button>
+                  <button class="btn btn-sm btn-outline btn-warning" disabled={isPurging()} onClick={() => handlePurgeCache(true)}>
+                    同时清理悬空镜像层
+                  </button>
+                </div>
+              </div>
             </div>
           </div>
         </div>
 
+        <Show when={props.currentApp}>
+          {(app) => <DeployKeyCard applicationUid={app().uid} repoUrl={repoUrl()} />}
+        </Show>
+
+        <Show when={props.currentApp}>
+          {(app) => <RegistryImageCard applicationUid={app().uid} />}
+        </Show>
+
         {/* Runtime Configuration */}
         <div class="card bg-base-100 shadow-xl">
           <div class="card-body">
@@ -342,6 +557,132 @@ const ApplicationSettingsTab: Component<ApplicationSettingsTabProps> = (props) =
           </div>
         </div>
 
+        {/* Release Retention */}
+        <div class="card bg-base-100 shadow-xl">
+          <div class="card-body">
+            <div class="flex items-center justify-between">
+              <h4 class="card-title">版本保留策略</h4>
+              <div class="flex gap-2">
+                <button
+                  class="btn btn-outline btn-sm btn-warning"
+                  onClick={handleRunRetention}
+                  disabled={isRunningRetention() || !props.currentApp?.retention || (props.currentApp.retention.keepLast === 0 && props.currentApp.retention.keepDays === 0)}
+                >
+                  立即清理
+                </button>
+                <button
+                  class="btn btn-outline btn-sm"
+                  onClick={handleSaveRetention}
+                  disabled={isSaving() || !props.currentApp}
+                >
+                  保存
+                </button>
+              </div>
+            </div>
+            <div class="grid grid-cols-1 md:grid-cols-2 gap-4">
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">保留最近的版本数</span>
+                </label>
+                <input
+                  type="number"
+                  min="0"
+                  class="input input-bordered"
+                  value={retentionKeepLast()}
+                  onInput={(e) => setRetentionKeepLast(parseInt(e.currentTarget.value) || 0)}
+                />
+              </div>
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">保留最近多少天内的版本</span>
+                </label>
+                <input
+                  type="number"
+                  min="0"
+                  class="input input-bordered"
+                  value={retentionKeepDays()}
+                  onInput={(e) => setRetentionKeepDays(parseInt(e.currentTarget.value) || 0)}
+                />
+              </div>
+            </div>
+            <div class="label">
+              <span class="label-text-alt">
+                满足任一条件的版本会保留，均为 0 时不清理。当前版本和回滚目标始终保留；过期版本的镜像、Quadlet 文件和服务每 6 小时清理一次
+              </span>
+            </div>
+            <Show when={props.currentApp?.retention?.lastRunAt}>
+              <div class="text-sm text-base-content/70">
+                上次清理：{new Date(props.currentApp!.retention!.lastRunAt!).toLocaleString()}，释放 {formatBytes(props.currentApp!.retention!.reclaimedBytes)}
+              </div>
+            </Show>
+          </div>
+        </div>
+
+        {/* Deployment Approval */}
+        <div class="card bg-base-100 shadow-xl">
+          <div class="card-body">
+            <div class="flex items-center justify-between">
+              <h4 class="card-title">部署审批</h4>
+              <button
+                class="btn btn-outline btn-sm"
+                onClick={handleSaveApproval}
+                disabled={isSaving() || !props.currentApp}
+              >
+                保存
+              </button>
+            </div>
+            <div class="grid grid-cols-1 md:grid-cols-2 gap-4">
+              <div class="form-control">
+                <label class="label cursor-pointer justify-start gap-2">
+                  <input
+                    type="checkbox"
+                    class="checkbox checkbox-sm"
+                    checked={approvalProtected()}
+                    onChange={(e) => setApprovalProtected(e.currentTarget.checked)}
+                  />
+                  <span class="label-text">受保护应用：部署需要审批后才会执行</span>
+                </label>
+              </div>
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">审批时限（小时）</span>
+                </label>
+                <input
+                  type="number"
+                  min="1"
+                  max="720"
+                  class="input input-bordered"
+                  value={approvalTimeoutHours()}
+                  disabled={!approvalProtected()}
+                  onInput={(e) => setApprovalTimeoutHours(parseInt(e.currentTarget.value) || 24)}
+                />
+              </div>
+              <div class="form-control md:col-span-2">
+                <label class="label">
+                  <span class="label-text">审批人（用户名，逗号分隔）</span>
+                </label>
+                <input
+                  type="text"
+                  class="input input-bordered"
+                  placeholder="为空时任何登录用户都可以审批"
+                  value={approvalApprovers()}
+                  disabled={!approvalProtected()}
+                  onInput={(e) => setApprovalApprovers(e.currentTarget.value)}
+                />
+              </div>
+            </div>
+            <div class="label">
+              <span class="label-text-alt">
+                包括 CLI、应用令牌和自动部署在内的所有部署都会等待登录用户在部署历史中批准，发起人不能审批自己的部署；超过时限未批准的部署自动过期，审批结果通过 Webhook 通知
+              </span>
+            </div>
+          </div>
+        </div>
+
+        <Show when={props.currentApp}>
+          {(app) => <FreezeWindowCard projectUid={app().projectUid} applicationUid={app().uid} />}
+        </Show>
+
         {/* Volume Configuration */}
         <div class="card bg-base-100 shadow-xl">
           <div class="card-body">
@@ -0,0 +1,244 @@
+import { Component, createSignal, Show, For } from 'solid-js'
+import { useQueryClient } from '@tanstack/solid-query'
+import { toast } from 'solid-toast'
+import { useApiQuery, useApiMutation } from '../../api/apiHooksW.ts'
+import {
+  getBuildSecretsEndpoint,
+  createBuildSecretEndpoint,
+  updateBuildSecretEndpoint,
+  deleteBuildSecretEndpoint
+} from '../../api/endpoints'
+import type { BuildSecret } from '../../types/project'
+
+interface BuildSecretsCardProps {
+  applicationUid: string
+}
+
+// 构建密钥通过 podman build --secret 挂载，不会出现在镜像层和 podman history 中
+const BuildSecretsCard: Component<BuildSecretsCardProps> = (props) => {
+  const queryClient = useQueryClient()
+
+  const [isAdding, setIsAdding] = createSignal(false)
+  const [newName, setNewName] = createSignal('')
+  const [newValue, setNewValue] = createSignal('')
+  const [editingSecret, setEditingSecret] = createSignal<string | null>(null)
+  const [editingValue, setEditingValue] = createSignal('')
+
+  const secretsQuery = useApiQuery<BuildSecret[]>(
+    () => ['applications', props.applicationUid, 'build-secrets'],
+    () => getBuildSecretsEndpoint(props.applicationUid).url,
+    { enabled: () => !!props.applicationUid }
+  )
+
+  const secrets = () => secretsQuery.data || []
+
+  const refreshData = async () => {
+    await queryClient.invalidateQueries({ queryKey: ['applications', props.applicationUid, 'build-secrets'] })
+  }
+
+  const createSecretMutation = useApiMutation<unknown, { name: string; value: string }>(
+    createBuildSecretEndpoint(props.applicationUid),
+    {
+      onSuccess: () => {
+        cancelAdding()
+        toast.success('Build secret created successfully')
+        void refreshData()
+      },
+    }
+  )
+
+  const updateSecretMutation = useApiMutation<unknown, { uid: string; value: string }>(
+    (variables: { uid: string; value: string }) => updateBuildSecretEndpoint(variables.uid),
+    {
+      body: (variables: { uid: string; value: string }) => ({ value: variables.value }),
+      onSuccess: () => {
+        setEditingSecret(null)
+        setEditingValue('')
+        toast.success('Build secret updated successfully')
+        void refreshData()
+      },
+    }
+  )
+
+  const deleteSecretMutation = useApiMutation<unknown, { uid: string }>(
+    (variables: { uid: string }) => deleteBuildSecretEndpoint(variables.uid),
+    {
+      onSuccess: () => {
+        toast.success('Build secret deleted successfully')
+        void refreshData()
+      },
+    }
+  )
+
+  const isMutating = () => createSecretMutation.isPending || updateSecretMutation.isPending || deleteSecretMutation.isPending
+
+  function cancelAdding() {
+    setNewName('')
+    setNewValue('')
+    setIsAdding(false)
+  }
+
+  function createSecret() {
+    if (!newName().trim() || !newValue()) return
+    createSecretMutation.mutate({ name: newName().trim(), value: newValue() })
+  }
+
+  function updateSecret(uid: string) {
+    if (!editingValue()) return
+    updateSecretMutation.mutate({ uid, value: editingValue() })
+  }
+
+  function deleteSecret(secret: BuildSecret) {
+    if (!confirm(`Are you sure you want to delete build secret ${secret.name}?`)) return
+    deleteSecretMutation.mutate({ uid: secret.uid })
+  }
+
+  return (
+    <div class="card bg-base-100 shadow">
+      <div class="card-body">
+        <div class="flex justify-between items-center">
+          <h4 class="card-title">Build Secrets ({secrets().length})</h4>
+          <button
+            onClick={() => setIsAdding(true)}
+            disabled={isMutating() || isAdding()}
+            class="btn btn-primary btn-sm"
+          >
+            Add Build Secret
+          </button>
+        </div>
+        <p class="text-sm text-base-content/70">
+          Mounted with <code>podman build --secret</code> and never stored in image layers. Use them in your Dockerfile with{' '}
+          <code>RUN --mount=type=secret,id=NAME cat /run/secrets/NAME</code>. Values are write-only and redacted from build logs.
+        </p>
+
+        <Show when={isAdding()}>
+          <div class="border rounded p-4 bg-base-200">
+            <div class="grid grid-cols-1 md:grid-cols-2 gap-4">
+              <div>
+                <label class="label">
+                  <span class="label-text">Name (secret id)</span>
+                </label>
+                <input
+                  type="text"
+                  placeholder="NPM_TOKEN"
+                  value={newName()}
+                  onInput={(e) => setNewName(e.currentTarget.value)}
+                  class="input input-bordered w-full font-mono"
+                  disabled={isMutating()}
+                />
+              </div>
+              <div>
+                <label class="label">
+                  <span class="label-text">Value</span>
+                </label>
+                <input
+                  type="password"
+                  placeholder="Secret value"
+                  value={newValue()}
+                  onInput={(e) => setNewValue(e.currentTarget.value)}
+                  class="input input-bordered w-full"
+                  disabled={isMutating()}
+                />
+              </div>
+            </div>
+            <div class="flex justify-end space-x-2 mt-4">
+              <button onClick={cancelAdding} disabled={isMutating()} class="btn btn-ghost btn-sm">
+                Cancel
+              </button>
+              <button
+                onClick={createSecret}
+                disabled={isMutating() || !newName().trim() || !newValue()}
+                class="btn btn-primary btn-sm"
+              >
+                Add Secret
+              </button>
+            </div>
+          </div>
+        </Show>
+
+        <Show when={secrets().length > 0}>
+          <div class="overflow-x-auto">
+            <table class="table">
+              <thead>
+                <tr>
+                  <th>Name</th>
+                  <th>Value</th>
+                  <th>Actions</th>
+                </tr>
+              </thead>
+              <tbody>
+                <For each={secrets()}>
+                  {(secret) => (
+                    <tr>
+                      <td>
+                        <span class="font-mono">{secret.name}</span>
+                      </td>
+                      <td>
+                        {editingSecret() === secret.uid ? (
+                          <input
+                            type="password"
+                            placeholder="New value"
+                            value={editingValue()}
+                            onInput={(e) => setEditingValue(e.currentTarget.value)}
+                            class="input input-bordered input-sm w-full"
+                            disabled={isMutating()}
+                          />
+                        ) : (
+                          <span class="font-mono">{'*'.repeat(8)}</span>
+                        )}
+                      </td>
+                      <td>
+                        <div class="flex items-center gap-2">
+                          {editingSecret() === secret.uid ? (
+                            <>
+                              <button
+                                class="btn btn-sm btn-success"
+                                onClick={() => updateSecret(secret.uid)}
+                                disabled={isMutating() || !editingValue()}
+                              >
+                                Save
+                              </button>
+                              <button
+                                class="btn btn-sm btn-ghost"
+                                onClick={() => setEditingSecret(null)}
+                                disabled={isMutating()}
+                              >
+                                Cancel
+                              </button>
+                            </>
+                          ) : (
+                            <>
+                              <button
+                                class="btn btn-sm btn-ghost"
+                                onClick={() => {
+                                  setEditingValue('')
+                                  setEditingSecret(secret.uid)
+                                }}
+                                disabled={isMutating()}
+                              >
+                                Replace
+                              </button>
+                              <button
+                                class="btn btn-sm btn-ghost text-error"
+                                onClick={() => deleteSecret(secret)}
+                                disabled={isMutating()}
+                              >
+                                Delete
+                              </button>
+                            </>
+                          )}
+                        </div>
+                      </td>
+                    </tr>
+                  )}
+                </For>
+              </tbody>
+            </table>
+          </div>
+        </Show>
+      </div>
+    </div>
+  )
+}
+
+export default BuildSecretsCard
@@ -3,7 +3,7 @@ import type { Component } from 'solid-js'
 import { useI18n } from '../../i18n'
 import type { Application } from '../../types/project'
 import { useApiMutation } from '../../api/apiHooksW.ts'
-import { createAppEndpoint, listProviderAuthsEndpoint, getProviderAuthRepositoriesEndpoint } from '../../api/endpoints'
+import { createAppEndpoint, listProviderAuthsEndpoint, getProviderAuthRepositoriesEndpoint, getProviderAuthBranchesEndpoint } from '../../api/endpoints'
 
 interface CreateApplicationModalProps {
   isOpen: boolean
@@ -57,47 +57,81 @@ const CreateApplicationModal: Component<CreateApplicationModalProps> = (props) =
 
   const [providerAuths] = createResource(fetchProviderAuths)
 
+  // 仓库和分支列表支持搜索和分页，加载更多时追加到已有列表
+  const [repoSearch, setRepoSearch] = createSignal('')
+  const [repoPage, setRepoPage] = createSignal(1)
+  const [repoList, setRepoList] = createSignal<any[]>([])
+  const [repoHasMore, setRepoHasMore] = createSignal(false)
+  const [branchSearch, setBranchSearch] = createSignal('')
+  const [branchPage, setBranchPage] = createSignal(1)
+  const [branchList, setBranchList] = createSignal<any[]>([])
+  const [branchHasMore, setBranchHasMore] = createSignal(false)
+
+  const listQuery = (page: number, search: string) => {
+    const params = new URLSearchParams({ page: String(page), perPage: '50' })
+    if (search) params.set('search', search)
+    return params.toString()
+  }
+
   // Function to fetch branches
-  const fetchBranches = async ({ providerAuthUid, repo }: { providerAuthUid?: string; repo?: string }) => {
-    if (!providerAuthUid || !repo) return []
-    const response = await fetch(`/api/provider-auths/${providerAuthUid}/repositories/branches?repo=${encodeURIComponent(repo)}`, {
+  const fetchBranches = async ({ providerAuthUid, repoUrl, search, page }: { providerAuthUid: string; repoUrl: string; search: string; page: number }) => {
+    const response = await fetch(`${getProviderAuthBranchesEndpoint(providerAuthUid).url}?repoUrl=${encodeURIComponent(repoUrl)}&${listQuery(page, search)}`, {
       headers: { Authorization: `Bearer ${localStorage.getItem('access_token')}` },
     })
     if (!response.ok) throw new Error('Failed to fetch branches')
     const data = await response.json()
-    return data.data || []
+    return { page, ...(data.data || { branches: [], hasMore: false }) }
   }
 
   // Resource for branches
-  const [branches] = createResource(() => ({ providerAuthUid: newApplication().providerAuthUid, repo: newApplication().repoFullName }), fetchBranches)
+  const [branches] = createResource(() => {
+    const { providerAuthUid, repoUrl, repoFullName } = newApplication()
+    if (!providerAuthUid || !repoUrl || !repoFullName) return undefined
+    return { providerAuthUid, repoUrl, search: branchSearch(), page: branchPage() }
+  }, fetchBranches)
+
+  createEffect(() => {
+    const result = branches()
+    if (!result) return
+    setBranchList(prev => result.page > 1 ? [...prev, ...result.branches] : result.branches)
+    setBranchHasMore(result.hasMore)
+  })
 
   // Fetch repositories for selected provider auth
-  const fetchRepositories = async (providerAuthUid: string) => {
-    const response = await fetch(getProviderAuthRepositoriesEndpoint(providerAuthUid).url, {
+  const fetchRepositories = async ({ providerAuthUid, search, page }: { providerAuthUid: string; search: string; page: number }) => {
+    const response = await fetch(`${getProviderAuthRepositoriesEndpoint(providerAuthUid).url}?${listQuery(page, search)}`, {
       headers: { Authorization: `Bearer ${localStorage.getItem('access_token')}` },
     })
     if (!response.ok) throw new Error('Failed to fetch repositories')
     const data = await response.json()
-    return data.data || []
+    return { page, ...(data.data || { repositories: [], hasMore: false }) }
   }
 
-  const [repositories] = createResource(() => newApplication().providerAuthUid, (uid) => uid ? fetchRepositories(uid) : Promise.resolve([]))
+  const [repositories] = createResource(() => {
+    const providerAuthUid = newApplication().providerAuthUid
+    return providerAuthUid ? { providerAuthUid, search: repoSearch(), page: repoPage() } : undefined
+  }, fetchRepositories)
 
-  // Helper to get base URL for platform
-  const getBaseUrl = (platform: string) => {
-    switch (platform) {
-      case 'github':
-        return 'https://github.com/'
-      case 'gitlab':
-        return 'https://gitlab.com/'
-      case 'bitbucket':
-        return 'https://bitbucket.org/'
-      case 'gitea':
-        // For gitea, assume full URL is provided or use redirectURI, but for now, require manual input
-        return ''
-      default:
-        return ''
-    }
+  createEffect(() => {
+    const result = repositories()
+    if (!result) return
+    setRepoList(prev => result.page > 1 ? [...prev, ...result.repositories] : result.repositories)
+    setRepoHasMore(result.hasMore)
+  })
+
+  const resetRepoBrowsing = () => {
+    setRepoSearch('')
+    setRepoPage(1)
+    setRepoList([])
+    setRepoHasMore(false)
+    resetBranchBrowsing()
+  }
+
+  const resetBranchBrowsing = () => {
+    setBranchSearch('')
+    setBranchPage(1)
+    setBranchList([])
+    setBranchHasMore(false)
   }
 
   // Create application mutation
@@ -184,6 +218,7 @@ const CreateApplicationModal: Component<CreateApplicationModalProps> = (props) =
       providerAuthUid: undefined,
       repoFullName: '',
     })
+    resetRepoBrowsing()
   }
 
   return (
@@ -222,7 +257,8 @@ const CreateApplicationModal: Component<CreateApplicationModalProps> = (props) =
               value={newApplication().providerAuthUid || ''}
               onInput={(e) => {
                 const val = e.currentTarget.value
-                setNewApplication(p => ({ ...p, providerAuthUid: val ? val : undefined, repoUrl: '' }))
+                resetRepoBrowsing()
+                setNewApplication(p => ({ ...p, providerAuthUid: val ? val : undefined, repoUrl: '', repoFullName: '' }))
               }}
             >
               <option value="">无（支持CLI推送）</option>
@@ -233,21 +269,40 @@ const CreateApplicationModal: Component<CreateApplicationModalProps> = (props) =
           <div>
             <label class="label"><span class="label-text">仓库URL</span></label>
             {newApplication().providerAuthUid ? (
-              <select 
-                class="select select-bordered w-full"
-                value={newApplication().repoFullName || ''}
-                onInput={(e) => {
-                  const selectedRepo = e.currentTarget.value
-                  const providerAuth = providerAuths()?.find((auth: any) => auth.uid === newApplication().providerAuthUid)
-                  const baseUrl = providerAuth ? getBaseUrl(providerAuth.platform) : ''
-                  const fullUrl = baseUrl ? baseUrl + selectedRepo : selectedRepo
-                  setNewApplication(p => ({ ...p, repoUrl: fullUrl, repoFullName: selectedRepo }))
-                }}
-                disabled={repositories.loading}
-              >
-                <option value="">选择仓库</option>
-                {repositories()?.map((repo: any) => <option value={repo.fullName}>{repo.fullName}</option>)}
-              </select>
+              <div class="space-y-2">
+                <input
+                  class="input input-bordered input-sm w-full"
+                  value={repoSearch()}
+                  onChange={(e) => {
+                    setRepoPage(1)
+                    setRepoSearch(e.currentTarget.value.trim())
+                  }}
+                  placeholder="搜索仓库，回车确认"
+                />
+                <select 
+                  class="select select-bordered w-full"
+                  value={newApplication().repoFullName || ''}
+                  onInput={(e) => {
+                    const selectedRepo = repoList().find((repo: any) => repo.fullName === e.currentTarget.value)
+                    resetBranchBrowsing()
+                    setNewApplication(p => ({
+                      ...p,
+                      repoUrl: selectedRepo ? selectedRepo.url : '',
+                      repoFullName: selectedRepo ? selectedRepo.fullName : '',
+                      branch: selectedRepo?.defaultBranch || p.branch,
+                    }))
+                  }}
+                  disabled={repositories.loading}
+                >
+                  <option value="">选择仓库</option>
+                  {repoList().map((repo: any) => <option value={repo.fullName}>{repo.fullName}{repo.private ? '（私有）' : ''}</option>)}
+                </select>
+                <Show when={repoHasMore()}>
+                  <button type="button" class="btn btn-ghost btn-xs" disabled={repositories.loading} onClick={() => setRepoPage(p => p + 1)}>
+                    加载更多仓库
+                  </button>
+                </Show>
+              </div>
             ) : (
               <input 
                 class="input input-bordered w-full" 
@@ -260,16 +315,32 @@ const CreateApplicationModal: Component<CreateApplicationModalProps> = (props) =
           
           <div>
             <label class="label"><span class="label-text">分支</span></label>
-            {newApplication().providerAuthUid && newApplication().repoUrl ? (
-              <select 
-                class="select select-bordered w-full"
-                value={newApplication().branch || ''}
-                onInput={(e) => setNewApplication(p => ({ ...p, branch: e.currentTarget.value }))}
-                disabled={branches.loading}
-              >
-                <option value="">选择分支</option>
-                {branches()?.map((branch: any) => <option value={branch.name}>{branch.name}</option>)}
-              </select>
+            {newApplication().providerAuthUid && newApplication().repoFullName ? (
+              <div class="space-y-2">
+                <input
+                  class="input input-bordered input-sm w-full"
+                  value={branchSearch()}
+                  onChange={(e) => {
+                    setBranchPage(1)
+                    setBranchSearch(e.currentTarget.value.trim())
+                  }}
+                  placeholder="搜索分支，回车确认"
+                />
+                <select 
+                  class="select select-bordered w-full"
+                  value={newApplication().branch || ''}
+                  onInput={(e) => setNewApplication(p => ({ ...p, branch: e.currentTarget.value }))}
+                  disabled={branches.loading}
+                >
+                  <option value="">选择分支</option>
+                  {branchList().map((branch: any) => <option value={branch.name}>{branch.name}</option>)}
+                </select>
+                <Show when={branchHasMore()}>
+                  <button type="button" class="btn btn-ghost btn-xs" disabled={branches.loading} onClick={() => setBranchPage(p => p + 1)}>
+                    加载更多分支
+                  </button>
+                </Show>
+              </div>
             ) : (
               <input 
                 class="input input-bordered w-full" 
@@ -3,7 +3,8 @@ import type { Application } from '../../types/project'
 import { useApiQuery, useApiMutation } from '../../api/apiHooksW.ts'
 import { 
   getApplicationReleasesEndpoint, 
-  createApplicationDeploymentEndpoint 
+  createApplicationDeploymentEndpoint,
+  createScheduledDeploymentEndpoint
 } from '../../api/endpoints'
 import { useQueryClient } from '@tanstack/solid-query'
 import { toast } from 'solid-toast'
@@ -22,6 +23,10 @@ const CreateDeploymentModal: Component<CreateDeploymentModalProps> = (props) =>
   const queryClient = useQueryClient()
   const [selectedOption, setSelectedOption] = createSignal<string>('')
   const [isRebuild, setIsRebuild] = createSignal(true)
+  const [gitRef, setGitRef] = createSignal('')
+  const [isScheduled, setIsScheduled] = createSignal(false)
+  const [scheduledAt, setScheduledAt] = createSignal('')
+  const [freezeOverrideReason, setFreezeOverrideReason] = createSignal('')
 
   // Query to get available releases for this application
   const releasesQuery = useApiQuery<Release[]>(
@@ -37,6 +42,8 @@ const CreateDeploymentModal: Component<CreateDeploymentModalProps> = (props) =>
   // Mutation to create deployment
   const createDeploymentMutation = useApiMutation<any, {
     releaseId: string | null
+    gitRef?: string
+    freezeOverrideReason?: string
   }>(
     () => {
       if (!props.application?.uid) throw new Error('No application ID')
@@ -54,7 +61,31 @@ const CreateDeploymentModal: Component<CreateDeploymentModalProps> = (props) =>
     }
   )
 
+  // Mutation to schedule a deployment at a future time
+  const scheduleDeploymentMutation = useApiMutation<any, {
+    releaseUid?: string
+    gitRef?: string
+    scheduledAt: string
+    freezeOverrideReason?: string
+  }>(
+    () => {
+      if (!props.application?.uid) throw new Error('No application ID')
+      return createScheduledDeploymentEndpoint(props.application.uid)
+    },
+    {
+      onSuccess: () => {
+        toast.success(t('create_deployment_modal.schedule_success_toast'))
+        queryClient.invalidateQueries({ queryKey: ['applications', props.application?.uid, 'scheduled-deployments'] })
+        handleClose()
+      },
+      onError: (error: Error) => {
+        toast.error(`${t('create_deployment_modal.error_toast_prefix')} ${error.message}`)
+      }
+    }
+  )
+
   const releases = () => releasesQuery.data || []
+  const isPending = () => createDeploymentMutation.isPending || scheduleDeploymentMutation.isPending
 
   const handleSubmit = () => {
     const releaseId = isRebuild() ? null : selectedOption()
@@ -63,14 +94,35 @@ const CreateDeploymentModal: Component<CreateDeploymentModalProps> = (props) =>
       return
     }
 
+    const overrideReason = freezeOverrideReason().trim() || undefined
+    if (isScheduled()) {
+      if (!scheduledAt()) {
+        toast.error(t('create_deployment_modal.error_no_schedule_time'))
+        return
+      }
+      scheduleDeploymentMutation.mutate({
+        releaseUid: releaseId || undefined,
+        gitRef: isRebuild() ? gitRef().trim() : undefined,
+        scheduledAt: new Date(scheduledAt()).toISOString(),
+        freezeOverrideReason: overrideReason
+      })
+      return
+    }
+
     createDeploymentMutation.mutate({
-      releaseId
+      releaseId,
+      gitRef: isRebuild() ? gitRef().trim() : undefined,
+      freezeOverrideReason: overrideReason
     })
   }
 
   const handleClose = () => {
     setSelectedOption('')
     setIsRebuild(true)
+    setGitRef('')
+    setIsScheduled(false)
+    setScheduledAt('')
+    setFreezeOverrideReason('')
     props.onClose()
   }
 
@@ -108,9 +160,21 @@ const CreateDeploymentModal: Component<CreateDeploymentModalProps> = (props) =>
               <Show 
                 when={!isRebuild()}
                 fallback={
-                  <div class="alert alert-info">
-                    <span>{t('create_deployment_modal.info_rebuild_selected')}</span>
-                  </div>
+                  <>
+                    <div class="alert alert-info">
+                      <span>{t('create_deployment_modal.info_rebuild_selected')}</span>
+                    </div>
+                    <label class="label">
+                      <span class="label-text">{t('create_deployment_modal.git_ref_label')}</span>
+                    </label>
+                    <input
+                      type="text"
+                      class="input input-bordered w-full font-mono"
+                      value={gitRef()}
+                      onInput={(e) => setGitRef(e.currentTarget.value)}
+                      placeholder={t('create_deployment_modal.git_ref_placeholder')}
+                    />
+                  </>
                 }
               >
                 <Show
@@ -140,30 +204,82 @@ const CreateDeploymentModal: Component<CreateDeploymentModalProps> = (props) =>
                       <For each={releases()}>
                         {(release) => (
                           <option value={release.uid}>
-                            {`${release.imageName} (${t('create_deployment_modal.status_label')}: ${release.status}) - ${release.createdAt}`}
+                            {`${release.imageName}${release.commitSha ? ` @ ${release.commitSha.slice(0, 7)}` : ''} (${t('create_deployment_modal.status_label')}: ${release.status}) - ${release.createdAt}`}
                           </option>
                         )}
                       </For>
                     </select>
+                    <Show when={releases().find(r => r.uid === selectedOption())?.commitSha}>
+                      {(() => {
+                        const release = releases().find(r => r.uid === selectedOption())!
+                        return (
+                          <p class="text-sm mt-2 text-base-content/70">
+                            <span class="font-mono">{release.commitSha!.slice(0, 7)}</span>
+                            {release.commitMessage ? ` ${release.commitMessage}` : ''}
+                            <Show when={release.commitUrl}>
+                              {' '}
+                              <a class="link link-primary" href={release.commitUrl} target="_blank" rel="noopener noreferrer">
+                                {t('create_deployment_modal.view_commit')}
+                              </a>
+                            </Show>
+                          </p>
+                        )
+                      })()}
+                    </Show>
                   </Show>
                 </Show>
               </Show>
             </div>
+
+            {/* Schedule */}
+            <div class="form-control">
+              <label class="label cursor-pointer">
+                <span class="label-text">{t('create_deployment_modal.schedule_option')}</span>
+                <input
+                  type="checkbox"
+                  class="checkbox"
+                  checked={isScheduled()}
+                  onChange={(e) => setIsScheduled(e.target.checked)}
+                />
+              </label>
+              <Show when={isScheduled()}>
+                <input
+                  type="datetime-local"
+                  class="input input-bordered w-full"
+                  value={scheduledAt()}
+                  onInput={(e) => setScheduledAt(e.currentTarget.value)}
+                />
+              </Show>
+            </div>
+
+            {/* Freeze Override */}
+            <div class="form-control">
+              <label class="label">
+                <span class="label-text">{t('create_deployment_modal.freeze_override_label')}</span>
+              </label>
+              <input
+                type="text"
+                class="input input-bordered w-full"
+                value={freezeOverrideReason()}
+                onInput={(e) => setFreezeOverrideReason(e.currentTarget.value)}
+                placeholder={t('create_deployment_modal.freeze_override_placeholder')}
+              />
+            </div>
           </div>
 
           <div class="modal-action">
             <button 
               class="btn btn-primary"
               onClick={handleSubmit}
-              disabled={createDeploymentMutation.isPending || (!isRebuild() && !selectedOption())}
+              disabled={isPending() || (!isRebuild() && !selectedOption())}
             >
-              {createDeploymentMutation.isPending ? (
+              {isPending() ? (
                 <>
                   <span class="loading loading-spinner loading-sm"></span>
                   {t('create_deployment_modal.creating_button')}
                 </> 
               ) : (
-                t('create_deployment_modal.create_button')
+                isScheduled() ? t('create_deployment_modal.schedule_button') : t('create_deployment_modal.create_button')
               )}
             </button>
             <button class="btn" onClick={handleClose}>
@@ -0,0 +1,177 @@
+import { Component, createSignal, Show, For } from 'solid-js'
+import { useQueryClient } from '@tanstack/solid-query'
+import { toast } from 'solid-toast'
+import { useApiQuery, useApiMutation } from '../../api/apiHooksW.ts'
+import {
+  getDeployKeyEndpoint,
+  generateDeployKeyEndpoint,
+  deleteDeployKeyEndpoint,
+  pinKnownHostsEndpoint
+} from '../../api/endpoints'
+import type { DeployKey } from '../../types/project'
+
+interface DeployKeyCardProps {
+  applicationUid: string
+  repoUrl: string
+}
+
+const isSSHRepoUrl = (url: string) => url.startsWith('ssh://') || /^[^/:\s]+@[^/:\s]+:.+/.test(url)
+
+// SSH 部署密钥：私钥由 OrbitDeploy 保存，公钥粘贴到 GitHub/GitLab/Gitea 的 Deploy Keys 中
+const DeployKeyCard: Component<DeployKeyCardProps> = (props) => {
+  const queryClient = useQueryClient()
+  const [manualKnownHosts, setManualKnownHosts] = createSignal('')
+  const [showManual, setShowManual] = createSignal(false)
+
+  const deployKeyQuery = useApiQuery<DeployKey>(
+    () => ['applications', props.applicationUid, 'deploy-key'],
+    () => getDeployKeyEndpoint(props.applicationUid).url,
+    { enabled: () => !!props.applicationUid }
+  )
+
+  const deployKey = () => deployKeyQuery.data
+
+  const refreshData = async () => {
+    await queryClient.invalidateQueries({ queryKey: ['applications', props.applicationUid, 'deploy-key'] })
+  }
+
+  const generateMutation = useApiMutation<unknown, void>(
+    generateDeployKeyEndpoint(props.applicationUid),
+    {
+      onSuccess: () => {
+        toast.success('部署密钥已生成，请将公钥添加到仓库的 Deploy Keys 中')
+        void refreshData()
+      },
+    }
+  )
+
+  const deleteMutation = useApiMutation<unknown, void>(
+    deleteDeployKeyEndpoint(props.applicationUid),
+    {
+      onSuccess: () => {
+        toast.success('部署密钥已删除')
+        void refreshData()
+      },
+    }
+  )
+
+  const pinMutation = useApiMutation<unknown, { knownHosts: string }>(
+    pinKnownHostsEndpoint(props.applicationUid),
+    {
+      onSuccess: () => {
+        setManualKnownHosts('')
+        setShowManual(false)
+        toast.success('主机密钥已固定，请核对指纹')
+        void refreshData()
+      },
+    }
+  )
+
+  const isMutating = () => generateMutation.isPending || deleteMutation.isPending || pinMutation.isPending
+
+  function generateKey() {
+    if (deployKey() && !confirm('重新生成后旧密钥立即失效，需要在仓库中替换公钥。确定继续吗？')) return
+    generateMutation.mutate()
+  }
+
+  function deleteKey() {
+    if (!confirm('删除部署密钥后将无法拉取 SSH 仓库，确定继续吗？')) return
+    deleteMutation.mutate()
+  }
+
+  async function copyPublicKey() {
+    const key = deployKey()
+    if (!key) return
+    await navigator.clipboard.writeText(key.publicKey)
+    toast.success('公钥已复制')
+  }
+
+  return (
+    <div class="card bg-base-100 shadow-xl">
+      <div class="card-body">
+        <div class="flex items-center justify-between">
+          <h4 class="card-title">SSH 部署密钥</h4>
+          <div class="space-x-2">
+            <Show when={deployKey()}>
+              <button class="btn btn-ghost btn-sm text-error" onClick={deleteKey} disabled={isMutating()}>
+                删除
+              </button>
+            </Show>
+            <button class="btn btn-outline btn-sm" onClick={generateKey} disabled={isMutating()}>
+              {deployKey() ? '重新生成' : '生成密钥'}
+            </button>
+          </div>
+        </div>
+        <p class="text-sm text-base-content/70">
+          仓库地址为 <code>git@host:owner/repo.git</code> 或 <code>ssh://</code> 形式时使用部署密钥拉取代码，适用于任何 Git 服务。
+        </p>
+        <Show when={props.repoUrl && !isSSHRepoUrl(props.repoUrl)}>
+          <div class="text-sm text-warning">当前仓库地址不是 SSH 地址，构建时不会使用部署密钥。</div>
+        </Show>
+
+        <Show when={deployKey()}>
+          {(key) => (
+            <div class="space-y-4">
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">公钥（只读，添加到仓库的 Deploy Keys）</span>
+                  <button class="btn btn-ghost btn-xs" onClick={copyPublicKey}>复制</button>
+                </label>
+                <textarea class="textarea textarea-bordered font-mono text-xs" rows={3} readOnly value={key().publicKey} />
+                <label class="label">
+                  <span class="label-text-alt font-mono">{key().fingerprint}</span>
+                </label>
+              </div>
+
+              <div class="form-control">
+                <label class="label">
+                  <span class="label-text">固定的主机密钥</span>
+                </label>
+                <Show
+                  when={key().hostFingerprints.length > 0}
+                  fallback={<div class="text-sm text-warning">尚未固定仓库主机密钥，构建前需要先获取并核对。</div>}
+                >
+                  <ul class="text-xs font-mono space-y-1">
+                    <For each={key().hostFingerprints}>{(fp) => <li>{fp}</li>}</For>
+                  </ul>
+                </Show>
+                <div class="flex flex-wrap gap-2 mt-2">
+                  <button
+                    class="btn btn-sm btn-outline"
+                    onClick={() => pinMutation.mutate({ knownHosts: '' })}
+                    disabled={isMutating() || !isSSHRepoUrl(props.repoUrl)}
+                  >
+                    从仓库主机获取
+                  </button>
+                  <button class="btn btn-sm btn-ghost" onClick={() => setShowManual(!showManual())} disabled={isMutating()}>
+                    手动粘贴 known_hosts
+                  </button>
+                </div>
+                <Show when={showManual()}>
+                  <textarea
+                    class="textarea textarea-bordered font-mono text-xs mt-2"
+                    rows={4}
+                    placeholder="github.com ssh-ed25519 AAAA..."
+                    value={manualKnownHosts()}
+                    onInput={(e) => setManualKnownHosts(e.currentTarget.value)}
+                  />
+                  <div class="flex justify-end mt-2">
+                    <button
+                      class="btn btn-sm btn-primary"
+                      onClick={() => pinMutation.mutate({ knownHosts: manualKnownHosts() })}
+                      disabled={isMutating() || !manualKnownHosts().trim()}
+                    >
+                      保存
+                    </button>
+                  </div>
+                </Show>
+              </div>
+            </div>
+          )}
+        </Show>
+      </div>
+    </div>
+  )
+}
+
+export default DeployKeyCard
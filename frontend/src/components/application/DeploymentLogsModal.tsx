@@ -328,6 +328,9 @@ const DeploymentLogsModal: Component<DeploymentLogsModalProps> = (props) => {
                         <span class={`ml-2 ${getLevelColor(log.level)}`}>
                           [{log.level}]
                         </span>
+                        <Show when={log.source === 'BUILD'}>
+                          <span class="ml-2 text-info">[BUILD]</span>
+                        </Show>
                         <span class="ml-2 text-base-content">
                           {log.message}
                         </span>
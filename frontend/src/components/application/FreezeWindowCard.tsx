@@ -0,0 +1,242 @@
+import { Component, createSignal, Show, For } from 'solid-js'
+import { useQueryClient } from '@tanstack/solid-query'
+import { toast } from 'solid-toast'
+import { useApiQuery, useApiMutation } from '../../api/apiHooksW.ts'
+import { apiMutate } from '../../api/apiClient'
+import {
+  getFreezeWindowsEndpoint,
+  createFreezeWindowEndpoint,
+  deleteFreezeWindowEndpoint
+} from '../../api/endpoints'
+import type { FreezeWindow } from '../../types/project'
+
+interface FreezeWindowCardProps {
+  projectUid: string
+  applicationUid: string
+}
+
+interface CreateFreezeWindowRequest {
+  name: string
+  reason: string
+  applicationUid: string
+  startsAt?: string
+  endsAt?: string
+  cron?: string
+  durationMinutes?: number
+  timezone?: string
+}
+
+// 部署冻结窗口：窗口内的部署（包括自动部署和定时部署）需要填写强制部署的原因
+const FreezeWindowCard: Component<FreezeWindowCardProps> = (props) => {
+  const queryClient = useQueryClient()
+  const [showForm, setShowForm] = createSignal(false)
+  const [name, setName] = createSignal('')
+  const [reason, setReason] = createSignal('')
+  const [appOnly, setAppOnly] = createSignal(false)
+  const [recurring, setRecurring] = createSignal(false)
+  const [startsAt, setStartsAt] = createSignal('')
+  const [endsAt, setEndsAt] = createSignal('')
+  const [cron, setCron] = createSignal('0 18 * * 5')
+  const [durationHours, setDurationHours] = createSignal(62)
+  const [timezone, setTimezone] = createSignal(Intl.DateTimeFormat().resolvedOptions().timeZone)
+
+  const windowsQuery = useApiQuery<FreezeWindow[]>(
+    () => ['projects', props.projectUid, 'freeze-windows'],
+    () => getFreezeWindowsEndpoint(props.projectUid).url,
+    { enabled: () => !!props.projectUid }
+  )
+
+  // 只显示对当前应用生效的窗口：项目级窗口和当前应用的窗口
+  const windows = () => (windowsQuery.data || []).filter(w => !w.applicationUid || w.applicationUid === props.applicationUid)
+
+  const refreshData = async () => {
+    await queryClient.invalidateQueries({ queryKey: ['projects', props.projectUid, 'freeze-windows'] })
+  }
+
+  const resetForm = () => {
+    setShowForm(false)
+    setName('')
+    setReason('')
+    setAppOnly(false)
+    setStartsAt('')
+    setEndsAt('')
+  }
+
+  const createMutation = useApiMutation<FreezeWindow, CreateFreezeWindowRequest>(
+    () => createFreezeWindowEndpoint(props.projectUid),
+    {
+      onSuccess: () => {
+        toast.success('冻结窗口已创建')
+        resetForm()
+        void refreshData()
+      },
+    }
+  )
+
+  function createWindow() {
+    if (!name().trim()) {
+      toast.error('请填写名称')
+      return
+    }
+    const req: CreateFreezeWindowRequest = {
+      name: name().trim(),
+      reason: reason().trim(),
+      applicationUid: appOnly() ? props.applicationUid : '',
+    }
+    if (recurring()) {
+      req.cron = cron().trim()
+      req.durationMinutes = Math.round(durationHours() * 60)
+      req.timezone = timezone().trim()
+    } else {
+      if (!startsAt() || !endsAt()) {
+        toast.error('请选择开始和结束时间')
+        return
+      }
+      req.startsAt = new Date(startsAt()).toISOString()
+      req.endsAt = new Date(endsAt()).toISOString()
+    }
+    createMutation.mutate(req)
+  }
+
+  async function deleteWindow(w: FreezeWindow) {
+    if (!confirm(`确定删除冻结窗口「${w.name}」吗？`)) return
+    try {
+      await apiMutate(deleteFreezeWindowEndpoint(w.uid).url, { method: 'DELETE' })
+      toast.success('冻结窗口已删除')
+      void refreshData()
+    } catch (error) {
+      toast.error(`删除失败: ${(error as Error).message}`)
+    }
+  }
+
+  const describeWindow = (w: FreezeWindow) => {
+    if (w.cron) {
+      return `${w.cron}（${w.timezone || '服务器时区'}）起持续 ${((w.durationMinutes || 0) / 60).toFixed(1).replace(/\.0$/, '')} 小时`
+    }
+    return `${w.startsAt ? new Date(w.startsAt).toLocaleString() : ''} 至 ${w.endsAt ? new Date(w.endsAt).toLocaleString() : ''}`
+  }
+
+  return (
+    <div class="card bg-base-100 shadow-xl">
+      <div class="card-body">
+        <div class="flex items-center justify-between">
+          <h4 class="card-title">部署冻结窗口</h4>
+          <button class="btn btn-outline btn-sm" onClick={() => setShowForm(!showForm())}>
+            {showForm() ? '取消' : '添加窗口'}
+          </button>
+        </div>
+        <p class="text-sm text-base-content/70">
+          冻结期间的部署需要填写强制部署的原因，原因会记录在部署中并发送 Webhook 通知。项目级窗口对项目下的所有应用生效。
+        </p>
+
+        <Show when={showForm()}>
+          <div class="space-y-3 border border-base-300 rounded p-4">
+            <div class="grid grid-cols-1 md:grid-cols-2 gap-3">
+              <input
+                type="text"
+                class="input input-bordered input-sm"
+                placeholder="名称，如 双十一封版"
+                value={name()}
+                onInput={(e) => setName(e.currentTarget.value)}
+              />
+              <input
+                type="text"
+                class="input input-bordered input-sm"
+                placeholder="冻结原因（可选）"
+                value={reason()}
+                onInput={(e) => setReason(e.currentTarget.value)}
+              />
+            </div>
+            <div class="flex flex-wrap gap-6">
+              <label class="label cursor-pointer gap-2">
+                <input type="checkbox" class="checkbox checkbox-sm" checked={appOnly()} onChange={(e) => setAppOnly(e.currentTarget.checked)} />
+                <span class="label-text">仅对当前应用生效</span>
+              </label>
+              <label class="label cursor-pointer gap-2">
+                <input type="checkbox" class="checkbox checkbox-sm" checked={recurring()} onChange={(e) => setRecurring(e.currentTarget.checked)} />
+                <span class="label-text">周期性窗口（cron）</span>
+              </label>
+            </div>
+            <Show
+              when={recurring()}
+              fallback={
+                <div class="grid grid-cols-1 md:grid-cols-2 gap-3">
+                  <label class="form-control">
+                    <span class="label-text text-sm">开始时间</span>
+                    <input type="datetime-local" class="input input-bordered input-sm" value={startsAt()} onInput={(e) => setStartsAt(e.currentTarget.value)} />
+                  </label>
+                  <label class="form-control">
+                    <span class="label-text text-sm">结束时间</span>
+                    <input type="datetime-local" class="input input-bordered input-sm" value={endsAt()} onInput={(e) => setEndsAt(e.currentTarget.value)} />
+                  </label>
+                </div>
+              }
+            >
+              <div class="grid grid-cols-1 md:grid-cols-3 gap-3">
+                <label class="form-control">
+                  <span class="label-text text-sm">开始时间（cron）</span>
+                  <input type="text" class="input input-bordered input-sm font-mono" value={cron()} onInput={(e) => setCron(e.currentTarget.value)} />
+                </label>
+                <label class="form-control">
+                  <span class="label-text text-sm">持续小时数</span>
+                  <input
+                    type="number"
+                    min="0.5"
+                    step="0.5"
+                    class="input input-bordered input-sm"
+                    value={durationHours()}
+                    onInput={(e) => setDurationHours(parseFloat(e.currentTarget.value) || 0)}
+                  />
+                </label>
+                <label class="form-control">
+                  <span class="label-text text-sm">时区</span>
+                  <input type="text" class="input input-bordered input-sm" value={timezone()} onInput={(e) => setTimezone(e.currentTarget.value)} />
+                </label>
+              </div>
+              <p class="text-xs text-base-content/60">如 <code>0 18 * * 5</code> 持续 62 小时：每周五 18:00 至周一 08:00 冻结</p>
+            </Show>
+            <div class="flex justify-end">
+              <button class="btn btn-sm btn-primary" onClick={createWindow} disabled={createMutation.isPending}>
+                保存
+              </button>
+            </div>
+          </div>
+        </Show>
+
+        <Show
+          when={windows().length > 0}
+          fallback={<div class="text-sm text-base-content/60">暂无冻结窗口</div>}
+        >
+          <ul class="divide-y divide-base-300">
+            <For each={windows()}>
+              {(w) => (
+                <li class="py-2 flex items-start justify-between gap-4">
+                  <div class="space-y-1">
+                    <div class="flex items-center gap-2">
+                      <span class="font-medium">{w.name}</span>
+                      <span class="badge badge-sm badge-ghost">{w.applicationUid ? '当前应用' : '整个项目'}</span>
+                      <Show when={w.active}>
+                        <span class="badge badge-sm badge-warning">
+                          冻结中{w.activeUntil ? `，至 ${new Date(w.activeUntil).toLocaleString()}` : ''}
+                        </span>
+                      </Show>
+                    </div>
+                    <div class="text-xs text-base-content/70">{describeWindow(w)}</div>
+                    <Show when={w.reason}>
+                      <div class="text-xs text-base-content/60">{w.reason}</div>
+                    </Show>
+                  </div>
+                  <button class="btn btn-ghost btn-xs text-error" onClick={() => deleteWindow(w)}>
+                    删除
+                  </button>
+                </li>
+              )}
+            </For>
+          </ul>
+        </Show>
+      </div>
+    </div>
+  )
+}
+
+export default FreezeWindowCard
@@ -0,0 +1,173 @@
+import { Component, createSignal, createEffect, Show } from 'solid-js'
+import { useQueryClient } from '@tanstack/solid-query'
+import { toast } from 'solid-toast'
+import { useApiQuery, useApiMutation } from '../../api/apiHooksW.ts'
+import {
+  getRegistryImageEndpoint,
+  updateRegistryImageEndpoint,
+  deleteRegistryImageEndpoint,
+  checkRegistryImageEndpoint
+} from '../../api/endpoints'
+import type { RegistryImage, RegistryImageCheckResult } from '../../types/project'
+
+interface RegistryImageCardProps {
+  applicationUid: string
+}
+
+// 镜像仓库来源：部署时不构建代码，而是拉取 Docker Hub、GHCR 或私有仓库中的镜像并固定摘要
+const RegistryImageCard: Component<RegistryImageCardProps> = (props) => {
+  const queryClient = useQueryClient()
+  const [imageRef, setImageRef] = createSignal('')
+  const [username, setUsername] = createSignal('')
+  const [password, setPassword] = createSignal('')
+  const [lastCheck, setLastCheck] = createSignal<RegistryImageCheckResult | null>(null)
+
+  const registryImageQuery = useApiQuery<RegistryImage>(
+    () => ['applications', props.applicationUid, 'registry-image'],
+    () => getRegistryImageEndpoint(props.applicationUid).url,
+    { enabled: () => !!props.applicationUid }
+  )
+
+  const registryImage = () => registryImageQuery.data
+
+  createEffect(() => {
+    const current = registryImage()
+    if (current) {
+      setImageRef(current.imageRef)
+      setUsername(current.username)
+      setPassword('')
+    }
+  })
+
+  const refreshData = async () => {
+    await queryClient.invalidateQueries({ queryKey: ['applications', props.applicationUid, 'registry-image'] })
+    await queryClient.invalidateQueries({ queryKey: ['applications', props.applicationUid, 'releases'] })
+  }
+
+  const updateMutation = useApiMutation<unknown, { imageRef: string; username: string; password: string }>(
+    updateRegistryImageEndpoint(props.applicationUid),
+    {
+      onSuccess: () => {
+        toast.success('镜像仓库来源已保存，下次部署时拉取该镜像')
+        void refreshData()
+      },
+    }
+  )
+
+  const deleteMutation = useApiMutation<unknown, void>(
+    deleteRegistryImageEndpoint(props.applicationUid),
+    {
+      onSuccess: () => {
+        setLastCheck(null)
+        toast.success('已移除镜像仓库来源，部署时将从代码仓库构建')
+        void refreshData()
+      },
+    }
+  )
+
+  const checkMutation = useApiMutation<RegistryImageCheckResult, { deploy: boolean }>(
+    checkRegistryImageEndpoint(props.applicationUid),
+    {
+      onSuccess: (result: RegistryImageCheckResult) => {
+        setLastCheck(result)
+        if (!result.changed) {
+          toast.success('镜像没有更新')
+        } else if (result.deploymentUid) {
+          toast.success('发现新镜像，已创建 Release 并开始部署')
+        } else {
+          toast.success('发现新镜像，已创建 Release')
+        }
+        void refreshData()
+      },
+    }
+  )
+
+  const isMutating = () => updateMutation.isPending || deleteMutation.isPending || checkMutation.isPending
+
+  function save() {
+    if (!imageRef().trim()) {
+      toast.error('请填写镜像引用')
+      return
+    }
+    updateMutation.mutate({ imageRef: imageRef().trim(), username: username().trim(), password: password() })
+  }
+
+  function remove() {
+    if (!confirm('移除后部署将从代码仓库构建，已拉取的 Release 仍可回滚。确定继续吗？')) return
+    deleteMutation.mutate()
+  }
+
+  return (
+    <div class="card bg-base-100 shadow-xl">
+      <div class="card-body">
+        <div class="flex items-center justify-between">
+          <h4 class="card-title">镜像仓库来源</h4>
+          <Show when={registryImage()?.imageRef}>
+            <div class="space-x-2">
+              <button class="btn btn-ghost btn-sm text-error" onClick={remove} disabled={isMutating()}>
+                移除
+              </button>
+              <button class="btn btn-outline btn-sm" onClick={() => checkMutation.mutate({ deploy: false })} disabled={isMutating()}>
+                检查更新
+              </button>
+              <button class="btn btn-primary btn-sm" onClick={() => checkMutation.mutate({ deploy: true })} disabled={isMutating()}>
+                检查并部署
+              </button>
+            </div>
+          </Show>
+        </div>
+        <p class="text-sm text-base-content/70">
+          设置后部署不再构建代码，而是拉取镜像并将 Release 固定到镜像摘要。版本标签（如 <code>1.2.0</code>）检查更新时会查找更高的版本，其他标签比较摘要。
+        </p>
+
+        <div class="grid grid-cols-1 md:grid-cols-3 gap-4">
+          <div class="form-control md:col-span-3">
+            <label class="label"><span class="label-text">镜像引用</span></label>
+            <input
+              class="input input-bordered font-mono"
+              placeholder="ghcr.io/acme/web:1.2.0"
+              value={imageRef()}
+              onInput={(e) => setImageRef(e.currentTarget.value)}
+            />
+          </div>
+          <div class="form-control">
+            <label class="label"><span class="label-text">用户名</span></label>
+            <input
+              class="input input-bordered"
+              placeholder="公开镜像留空"
+              value={username()}
+              onInput={(e) => setUsername(e.currentTarget.value)}
+            />
+          </div>
+          <div class="form-control md:col-span-2">
+            <label class="label"><span class="label-text">密码或访问令牌</span></label>
+            <input
+              type="password"
+              class="input input-bordered"
+              placeholder={registryImage()?.hasPassword ? '留空则保持不变' : ''}
+              value={password()}
+              onInput={(e) => setPassword(e.currentTarget.value)}
+            />
+          </div>
+        </div>
+
+        <div class="card-actions justify-end">
+          <button class="btn btn-primary btn-sm" onClick={save} disabled={isMutating()}>
+            保存
+          </button>
+        </div>
+
+        <Show when={lastCheck()}>
+          {(result) => (
+            <div class="text-sm space-y-1">
+              <div>镜像：<code>{result().imageRef}</code></div>
+              <div>摘要：<code class="text-xs">{result().digest}</code></div>
+            </div>
+          )}
+        </Show>
+      </div>
+    </div>
+  )
+}
+
+export default RegistryImageCard
@@ -382,10 +382,10 @@ export const dict: Dictionary = {
       volume_mounts: "Volume Mounts",
       add_mount: "Add Mount",
       host_path: "Host Path",
-      host_path_placeholder: "e.g., my-blog/content",
+      host_path_placeholder: "e.g., my-blog-data or /var/lib/orbitdeploy/volumes/my-blog",
       container_path: "Container Path",
       container_path_placeholder: "e.g., /var/lib/mysql",
-      host_path_help: "This is the actual storage location on the server. Ensure the path is secure and on a backed-up partition. For multi-server high availability, use network filesystems like NFS. The platform will prefix with VOLUMES_BASE_PATH to restrict within sandbox.",
+      host_path_help: "This is the actual storage location on the server. Ensure the path is secure and on a backed-up partition. For multi-server high availability, use network filesystems like NFS. Only named volumes or absolute paths under the ORBIT_VOLUME_ROOTS directories (default /var/lib/orbitdeploy/volumes) are allowed.",
       remove_mount: "Remove Mount"
     },
     modals: {
@@ -574,6 +574,10 @@ export const dict: Dictionary = {
     associatedApp: 'Associated App',
     status: 'Status',
     creationTime: 'Creation Time',
+    copyWebhookUrl: 'Copy Webhook URL',
+    webhookHint: 'Add this URL and the webhook secret to the repository webhook settings to deploy on push or tag',
+    webhookUrlCopied: 'Webhook URL copied, secret: {{secret}}',
+    webhookSecretMissing: 'This authorization has no webhook secret. Edit it and set one first',
     actions: 'Actions',
     notAssociated: 'Not Associated',
     enabled: 'Enabled',
@@ -720,6 +724,9 @@ export const dict: Dictionary = {
     select_release_label: "Select a release *",
     loading_releases: "Loading releases...",
     info_rebuild_selected: "You have selected to build a new version.",
+    git_ref_label: "Git ref (optional)",
+    git_ref_placeholder: "Branch, tag or commit SHA; leave empty to build the latest commit of the app branch",
+    view_commit: "View commit",
     warn_no_releases: "No available releases. You can choose to rebuild the version.",
     select_placeholder: "Please select a release",
     status_label: "Status",
@@ -729,6 +736,12 @@ export const dict: Dictionary = {
     success_toast: "Deployment created successfully",
     error_toast_prefix: "Failed to create deployment:",
     error_no_release_selected: "Please select a release",
+    schedule_option: "Deploy at a scheduled time",
+    schedule_button: "Schedule Deployment",
+    schedule_success_toast: "Deployment scheduled",
+    error_no_schedule_time: "Please select the scheduled time",
+    freeze_override_label: "Freeze override reason (optional)",
+    freeze_override_placeholder: "Required when deploying inside a freeze window; recorded and sent to the webhook",
   },
 
   // Logs Modal
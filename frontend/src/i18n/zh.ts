@@ -301,10 +301,10 @@ export const dict = {
       volume_mounts: "卷挂载",
       add_mount: "添加挂载",
       host_path: "主机路径",
-      host_path_placeholder: "例如：my-blog/content",
+      host_path_placeholder: "例如：my-blog-data 或 /var/lib/orbitdeploy/volumes/my-blog",
       container_path: "容器路径",
       container_path_placeholder: "例如：/var/lib/mysql",
-      host_path_help: "这是数据在服务器上的真实存储位置。请确保路径安全且位于一个有备份分区。如需多服务器高可用，请使用 NFS 等网络文件系统挂载点。平台将以 VOLUMES_BASE_PATH 为前缀拼接，限制在沙箱内。",
+      host_path_help: "这是数据在服务器上的真实存储位置。请确保路径安全且位于一个有备份分区。如需多服务器高可用，请使用 NFS 等网络文件系统挂载点。只能填写命名卷，或 ORBIT_VOLUME_ROOTS 允许目录（默认 /var/lib/orbitdeploy/volumes）下的绝对路径。",
       remove_mount: "删除挂载"
     },
     modals: {
@@ -494,6 +494,10 @@ export const dict = {
     associatedApp: '关联应用',
     status: '状态',
     creationTime: '创建时间',
+    copyWebhookUrl: '复制 Webhook 地址',
+    webhookHint: '在仓库的 Webhook 设置中填写此地址和 Webhook Secret，推送代码或标签后自动部署',
+    webhookUrlCopied: 'Webhook 地址已复制，Secret: {{secret}}',
+    webhookSecretMissing: '该授权未配置 Webhook Secret，请先编辑授权填写',
     actions: '操作',
     notAssociated: '未关联',
     enabled: '启用',
@@ -640,6 +644,9 @@ export const dict = {
     select_release_label: "选择发布版本 *",
     loading_releases: "加载发布版本...",
     info_rebuild_selected: "你已选择构建新版本",
+    git_ref_label: "Git 引用（可选）",
+    git_ref_placeholder: "分支、标签或提交 SHA，留空构建应用分支的最新提交",
+    view_commit: "查看提交",
     warn_no_releases: "暂无可用的发布版本。你可选择重新构建版本。",
     select_placeholder: "请选择发布版本",
     status_label: "状态",
@@ -649,6 +656,12 @@ export const dict = {
     success_toast: "部署创建成功",
     error_toast_prefix: "部署创建失败:",
     error_no_release_selected: "请选择一个发布版本",
+    schedule_option: "定时部署",
+    schedule_button: "创建定时部署",
+    schedule_success_toast: "定时部署已创建",
+    error_no_schedule_time: "请选择计划时间",
+    freeze_override_label: "强制部署的原因（可选）",
+    freeze_override_placeholder: "处于冻结窗口时必填，原因会记录在部署中并发送 Webhook 通知",
   },
 
   // Logs Modal
@@ -128,9 +128,11 @@ const ProviderAuthManagementPage: Component = () => {
       clientId: '',
       clientSecret: '',
       redirectUri: '',
+      baseUrl: '',
       username: '',
       appPassword: '',
       scopes: '',
+      webhookSecret: '',
       isActive: true
     })
     setSelectedPlatform('github')
@@ -152,9 +154,11 @@ const ProviderAuthManagementPage: Component = () => {
       clientId: auth.clientId,
       clientSecret: '', // 不填充敏感信息
       redirectUri: auth.redirectUri,
+      baseUrl: auth.baseUrl || '',
       username: auth.username || '', // 处理 null
       appPassword: '', // 不填充敏感信息
       scopes: auth.scopes || '', // 处理 null
+      webhookSecret: '', // 留空保持原值
       isActive: auth.isActive
     })
     setSelectedPlatform(auth.platform as 'github' | 'gitlab' | 'bitbucket' | 'gitea')
@@ -187,6 +191,16 @@ const ProviderAuthManagementPage: Component = () => {
     }
   }
 
+  // 复制 Webhook 地址，填写到 GitHub/GitLab/Gitea/Bitbucket 仓库的 Webhook 设置中，签名密钥使用授权的 Webhook Secret
+  const copyWebhookUrl = async (auth: ProviderAuth) => {
+    if (!auth.webhookSecret) {
+      showMessage(t('providerAuth.webhookSecretMissing'), 'error')
+      return
+    }
+    await navigator.clipboard.writeText(`${window.location.origin}${auth.webhookPath}`)
+    showMessage(t('providerAuth.webhookUrlCopied', { secret: auth.webhookSecret }), 'success')
+  }
+
   onMount(() => {
     // Check if we're returning from GitHub App creation
     const urlParams = new URLSearchParams(window.location.search)
@@ -313,6 +327,13 @@ const ProviderAuthManagementPage: Component = () => {
                             >
                               {t('providerAuth.edit')}
                             </button>
+                            <button
+                              class="btn btn-sm btn-ghost"
+                              title={auth.webhookSecret ? t('providerAuth.webhookHint') : t('providerAuth.webhookSecretMissing')}
+                              onClick={() => copyWebhookUrl(auth)}
+                            >
+                              {t('providerAuth.copyWebhookUrl')}
+                            </button>
                             {/* 新增：GitHub Apps安装按钮 */}
                             <Show when={auth.platform === 'github' && auth.slug}>
                               <button
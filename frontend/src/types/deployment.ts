@@ -24,6 +24,13 @@ export interface Deployment {
   version?: string  // Version from Release
   imageName?: string
   releaseStatus?: string
+  // 受保护应用的审批记录
+  requestedBy?: string
+  approvalExpiresAt?: string
+  reviewedBy?: string
+  reviewedAt?: string
+  reviewComment?: string
+  freezeOverrideReason?: string  // 在冻结窗口内强制部署的原因
 }
 
 // DeploymentLog 结构化日志条目
@@ -35,6 +42,23 @@ export interface DeploymentLog {
   message: string
 }
 
+// CanaryRelease 金丝雀发布：新旧版本同时运行，按权重分配流量
+export interface CanaryRelease {
+  applicationUid: string
+  deploymentUid: string
+  baselineDeploymentUid: string
+  status: 'pending' | 'running' | 'promoted' | 'aborted'
+  weight: number  // 新版本流量百分比
+  stepWeight: number
+  maxErrorRate: number
+  healthPath: string
+  baselinePort: number
+  canaryPort?: number
+  abortReason?: string
+  createdAt: string
+  finishedAt?: string
+}
+
 // Release 相关类型定义
 export interface Release {
   uid: string
@@ -42,6 +66,9 @@ export interface Release {
   version?: string  // Version field for release
   imageName: string
   buildSourceInfo: Record<string, any>  // JSONB type
+  commitSha?: string      // 构建所用的提交
+  commitMessage?: string
+  commitUrl?: string
   status: string
   systemPort?: number  // 系统分配的端口，可选字段
   createdAt?: string
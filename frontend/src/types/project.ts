@@ -21,16 +21,110 @@ export interface Application {
   repoUrl?: string
   buildDir: string
   buildType: string
+  dockerfilePath?: string  // 相对于 buildDir
+  buildTarget?: string     // 多阶段构建的目标阶段
   targetPort: number
   status: string
   volumes?: VolumeMount[]  // Changed from Record<string, any> to VolumeMount[]
   execCommand?: string
   autoUpdatePolicy?: string
   branch?: string
+  generatedHostname?: string  // 基于系统基础域名自动生成的主机名
+  resources?: ResourceLimits
+  buildCache?: BuildCacheSettings
+  autoDeploy?: AutoDeploySettings
+  retention?: RetentionPolicy
+  approval?: ApprovalSettings
   createdAt?: string
   updatedAt?: string
 }
 
+// 应用资源限制，0 或空字符串表示不限制，下次部署时生效
+export interface ResourceLimits {
+  cpuQuota: number  // CPU 核数
+  memoryLimitMb: number
+  memoryReservationMb: number
+  pidsLimit: number
+  oomPolicy: '' | 'continue' | 'stop' | 'kill'
+}
+
+// 应用构建缓存设置，下次构建时生效
+export interface BuildCacheSettings {
+  enabled: boolean            // 复用克隆目录和 podman 层缓存
+  pullAlways: boolean         // 每次构建都拉取基础镜像
+}
+
+// 收到仓库 Webhook 推送事件或镜像推送后自动部署
+export interface AutoDeploySettings {
+  onPush: boolean       // 推送到应用分支时部署
+  onTag: boolean        // 推送标签时部署该标签
+  onImagePush: boolean  // 向内置镜像仓库推送镜像后部署
+}
+
+// Release 保留策略，均为 0 时不清理；当前版本和回滚目标始终保留
+export interface RetentionPolicy {
+  keepLast: number        // 保留最近的 Release 个数
+  keepDays: number        // 保留最近多少天内创建的 Release
+  lastRunAt?: string
+  reclaimedBytes: number  // 上次清理回收的磁盘空间
+}
+
+// 受保护应用的部署审批设置，部署需要批准后才会执行
+export interface ApprovalSettings {
+  protected: boolean
+  timeoutHours: number  // 等待审批的时限，超时后部署过期
+  approvers?: string[]  // 可以审批的用户名，为空时任何登录用户都可以审批（发起人除外）
+}
+
+// 部署冻结窗口：startsAt/endsAt 固定时间段，或 cron + durationMinutes 周期性窗口
+export interface FreezeWindow {
+  uid: string
+  projectUid: string
+  applicationUid?: string  // 为空时对项目下的所有应用生效
+  name: string
+  reason?: string
+  startsAt?: string
+  endsAt?: string
+  cron?: string
+  durationMinutes?: number
+  timezone?: string
+  createdBy?: string
+  createdAt: string
+  active: boolean
+  activeUntil?: string
+}
+
+// 定时部署，到达计划时间后由服务端创建部署
+export interface ScheduledDeployment {
+  uid: string
+  applicationUid: string
+  releaseUid?: string
+  gitRef?: string
+  strategy?: string
+  scheduledAt: string
+  status: 'pending' | 'started' | 'failed' | 'cancelled'
+  requestedBy?: string
+  freezeOverrideReason?: string
+  deploymentUid?: string
+  error?: string
+  startedAt?: string
+  createdAt: string
+}
+
+export interface RetentionRunResult {
+  removedReleases: number
+  removedImages: number
+  removedUnits: number
+  reclaimedBytes: number
+}
+
+export interface BuildCacheUsage {
+  path: string
+  repoCacheBytes: number
+  imageCount: number
+  imageBytes: number
+}
+
 export interface ApiListResponse<T> {
   success: boolean
   message?: string
@@ -68,6 +162,13 @@ export interface DeploymentHistory {
   createdAt?: string
   updatedAt?: string
   releaseStatus?: string
+  // 受保护应用的审批记录
+  requestedBy?: string
+  approvalExpiresAt?: string
+  reviewedBy?: string
+  reviewedAt?: string
+  reviewComment?: string
+  freezeOverrideReason?: string  // 在冻结窗口内强制部署的原因
   // New fields for running deployments overview
   domains?: string[]
   hostPort?: number
@@ -98,6 +199,7 @@ export interface EnvironmentVariable {
   key: string
   value: string
   isEncrypted: boolean
+  isBuildTime?: boolean  // 作为构建参数传入 podman build
   createdAt?: string
   updatedAt?: string
 }
@@ -114,10 +216,46 @@ export interface ConfigurationWithVariables {
 }
 
 // Request types for environment variables
+// SSH 部署密钥，只返回公钥
+export interface DeployKey {
+  publicKey: string
+  fingerprint: string
+  knownHosts: string
+  hostFingerprints: string[]
+  createdAt?: string
+  updatedAt?: string
+}
+
+// 镜像仓库来源，密码只写不读
+export interface RegistryImage {
+  imageRef: string
+  registry: string
+  username: string
+  hasPassword: boolean
+}
+
+export interface RegistryImageCheckResult {
+  imageRef: string
+  digest: string
+  changed: boolean
+  releaseUid?: string
+  deploymentUid?: string
+}
+
+// 构建密钥，值只写不读
+export interface BuildSecret {
+  uid: string
+  applicationUid: string
+  name: string
+  createdAt?: string
+  updatedAt?: string
+}
+
 export interface CreateEnvironmentVariableRequest {
   key: string
   value: string
   isEncrypted: boolean
+  isBuildTime?: boolean
 }
 
 export interface CreateConfigurationWithVariablesRequest {
@@ -5,10 +5,12 @@ export interface ProviderAuth {
   applicationId?: number
   clientId: string
   redirectUri: string
+  baseUrl: string
   username: string | null
   appId: string | null
   slug: string | null
   webhookSecret: string | null
+  webhookPath: string
   installationId: number
   scopes: string | null
   isActive: boolean
@@ -23,6 +25,7 @@ export interface CreateProviderAuthRequest {
   clientId: string  // For non-GitHub platforms
   clientSecret: string  // For non-GitHub platforms  
   redirectUri: string
+  baseUrl?: string  // 自建 GitLab、Gitea、GitHub Enterprise 的实例地址
   username: string  // For Bitbucket platform
   appPassword: string  // For Bitbucket platform
   appId: string  // For GitHub Apps (required for GitHub platform)
@@ -39,6 +42,7 @@ export interface UpdateProviderAuthRequest {
   clientId: string  // For non-GitHub platforms
   clientSecret: string  // For non-GitHub platforms
   redirectUri: string
+  baseUrl?: string  // 自建 GitLab、Gitea、GitHub Enterprise 的实例地址
   username: string  // For Bitbucket platform
   appPassword: string  // For Bitbucket platform
   appId: string  // For GitHub Apps (required for GitHub platform)
@@ -5,6 +5,11 @@ export interface RoutingResponse {
   domainName: string
   hostPort: number
   isActive: boolean
+  tlsMode?: 'auto' | 'custom' | 'acme'
+  certificateExpiresAt?: string
+  dnsStatus: 'pending' | 'verified' | 'mismatch' | 'unresolved' | 'unverified' | 'unknown'
+  dnsStatusMessage?: string
+  dnsCheckedAt?: string
   createdAt: string
   updatedAt: string
 }
@@ -16,6 +21,20 @@ export interface RoutingRequest {
   isActive: boolean
 }
 
+// 域名 DNS 预检结果
+export interface DomainPreflightResponse {
+  domainName: string
+  status: RoutingResponse['dnsStatus']
+  message: string
+  cname?: string
+  resolvedAddresses: string[]
+  serverAddresses: string[]
+  verificationRequired: boolean
+  verificationRecord: string
+  verificationToken: string
+  ownershipVerified: boolean
+}
+
 // Add response wrapper for list API
 export interface RoutingsResponse {
   data: {
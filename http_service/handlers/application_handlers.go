@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 )
@@ -31,36 +35,85 @@ func CreateApplicationHandler(c echo.Context) error {
 		providerAuthID = &id
 	}
 
+	var limits utils.ResourceLimits
+	if req.Resources != nil {
+		limits = toResourceLimits(req.Resources)
+		if err := limits.Validate(utils.DetectHostCapacity()); err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	}
+	if err := validateBuildSettings(req.BuildDir, req.DockerfilePath, req.BuildTarget); err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if err := validateVolumes(req.Volumes); err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	var retention utils.RetentionPolicy
+	if req.Retention != nil {
+		retention = toRetentionPolicy(req.Retention)
+		if err := retention.Validate(); err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	}
+	var approval models.ApprovalSettings
+	if req.Approval != nil {
+		if approval, err = toApprovalSettings(req.Approval); err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	}
+
 	application, err := models.CreateApplication(projectID, req.Name, req.Description, req.RepoURL, req.TargetPort, models.JSONB{Data: req.Volumes}, req.ExecCommand, req.AutoUpdatePolicy, req.Branch, req.BuildDir, req.BuildType, providerAuthID)
 	if err != nil {
 		return SendError(c, http.StatusInternalServerError, "Failed to create application")
 	}
 
-	var activeReleaseUID *string
-	if application.ActiveReleaseID != nil {
-		uid := EncodeFriendlyID(PrefixRelease, *application.ActiveReleaseID)
-		activeReleaseUID = &uid
+	if req.Resources != nil {
+		if err := models.UpdateApplicationResourceLimits(application.ID, limits); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save resource limits")
+		}
+		application.Resources = limits
 	}
 
-	response := ApplicationDetailResponse{
-		Uid:              EncodeFriendlyID(PrefixApplication, application.ID),
-		ProjectUid:       EncodeFriendlyID(PrefixProject, application.ProjectID),
-		Name:             application.Name,
-		Description:      application.Description,
-		RepoURL:          application.RepoURL,
-		ActiveReleaseUid: activeReleaseUID,
-		TargetPort:       application.TargetPort,
-		Status:           application.Status,
-		Volumes:          application.Volumes,
-		ExecCommand:      application.ExecCommand,
-		AutoUpdatePolicy: application.AutoUpdatePolicy,
-		Branch:           application.Branch,
-		BuildDir:         application.BuildDir,
-		BuildType:        application.BuildType,
-		CreatedAt:        application.CreatedAt,
-		UpdatedAt:        application.UpdatedAt,
+	if err := saveBuildSettings(application, req.DockerfilePath, req.BuildTarget); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to save build settings")
+	}
+
+	if req.BuildCache != nil {
+		settings := toBuildCacheSettings(req.BuildCache)
+		if err := models.UpdateApplicationBuildCacheSettings(application.ID, settings); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save build cache settings")
+		}
+		application.BuildCache = settings
+	}
+
+	if req.AutoDeploy != nil {
+		settings := models.AutoDeploySettings{
+			AutoDeployOnPush:      req.AutoDeploy.OnPush,
+			AutoDeployOnTag:       req.AutoDeploy.OnTag,
+			AutoDeployOnImagePush: req.AutoDeploy.OnImagePush,
+		}
+		if err := models.UpdateApplicationAutoDeploySettings(application.ID, settings); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save auto deploy settings")
+		}
+		application.AutoDeploy = settings
+	}
+
+	if req.Retention != nil {
+		if err := models.UpdateApplicationRetentionPolicy(application.ID, retention); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save retention policy")
+		}
+		application.Retention = retention
 	}
 
+	if req.Approval != nil {
+		if err := models.UpdateApplicationApprovalSettings(application.ID, approval); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save approval settings")
+		}
+		application.Approval = approval
+	}
+
+	response := toApplicationDetailResponse(application)
+
 	return SendCreated(c, response)
 }
 
@@ -76,30 +129,7 @@ func GetApplicationHandler(c echo.Context) error {
 		return SendError(c, http.StatusNotFound, "Application not found")
 	}
 
-	var activeReleaseUID *string
-	if application.ActiveReleaseID != nil {
-		uid := EncodeFriendlyID(PrefixRelease, *application.ActiveReleaseID)
-		activeReleaseUID = &uid
-	}
-
-	response := ApplicationDetailResponse{
-		Uid:              EncodeFriendlyID(PrefixApplication, application.ID),
-		ProjectUid:       EncodeFriendlyID(PrefixProject, application.ProjectID),
-		Name:             application.Name,
-		Description:      application.Description,
-		RepoURL:          application.RepoURL,
-		ActiveReleaseUid: activeReleaseUID,
-		TargetPort:       application.TargetPort,
-		Status:           application.Status,
-		Volumes:          application.Volumes,
-		ExecCommand:      application.ExecCommand,
-		AutoUpdatePolicy: application.AutoUpdatePolicy,
-		Branch:           application.Branch,
-		BuildDir:         application.BuildDir,
-		BuildType:        application.BuildType,
-		CreatedAt:        application.CreatedAt,
-		UpdatedAt:        application.UpdatedAt,
-	}
+	response := toApplicationDetailResponse(application)
 
 	return SendSuccess(c, response)
 }
@@ -116,30 +146,7 @@ func GetApplicationByNameHandler(c echo.Context) error {
 		return SendError(c, http.StatusNotFound, "Application not found")
 	}
 
-	var activeReleaseUID *string
-	if application.ActiveReleaseID != nil {
-		uid := EncodeFriendlyID(PrefixRelease, *application.ActiveReleaseID)
-		activeReleaseUID = &uid
-	}
-
-	response := ApplicationDetailResponse{
-		Uid:              EncodeFriendlyID(PrefixApplication, application.ID),
-		ProjectUid:       EncodeFriendlyID(PrefixProject, application.ProjectID),
-		Name:             application.Name,
-		Description:      application.Description,
-		RepoURL:          application.RepoURL,
-		ActiveReleaseUid: activeReleaseUID,
-		TargetPort:       application.TargetPort,
-		Status:           application.Status,
-		Volumes:          application.Volumes,
-		ExecCommand:      application.ExecCommand,
-		AutoUpdatePolicy: application.AutoUpdatePolicy,
-		Branch:           application.Branch,
-		BuildDir:         application.BuildDir,
-		BuildType:        application.BuildType,
-		CreatedAt:        application.CreatedAt,
-		UpdatedAt:        application.UpdatedAt,
-	}
+	response := toApplicationDetailResponse(application)
 
 	return SendSuccess(c, response)
 }
@@ -161,30 +168,7 @@ func GetApplicationByProjectNameAndAppNameHandler(c echo.Context) error {
 		return SendError(c, http.StatusNotFound, "Application not found")
 	}
 
-	var activeReleaseUID *string
-	if application.ActiveReleaseID != nil {
-		uid := EncodeFriendlyID(PrefixRelease, *application.ActiveReleaseID)
-		activeReleaseUID = &uid
-	}
-
-	response := ApplicationDetailResponse{
-		Uid:              EncodeFriendlyID(PrefixApplication, application.ID),
-		ProjectUid:       EncodeFriendlyID(PrefixProject, application.ProjectID),
-		Name:             application.Name,
-		Description:      application.Description,
-		RepoURL:          application.RepoURL,
-		ActiveReleaseUid: activeReleaseUID,
-		TargetPort:       application.TargetPort,
-		Status:           application.Status,
-		Volumes:          application.Volumes,
-		ExecCommand:      application.ExecCommand,
-		AutoUpdatePolicy: application.AutoUpdatePolicy,
-		Branch:           application.Branch,
-		BuildDir:         application.BuildDir,
-		BuildType:        application.BuildType,
-		CreatedAt:        application.CreatedAt,
-		UpdatedAt:        application.UpdatedAt,
-	}
+	response := toApplicationDetailResponse(application)
 
 	return SendSuccess(c, response)
 }
@@ -210,35 +194,265 @@ func UpdateApplicationHandler(c echo.Context) error {
 		providerAuthID = &id
 	}
 
+	var limits utils.ResourceLimits
+	if req.Resources != nil {
+		limits = toResourceLimits(req.Resources)
+		if err := limits.Validate(utils.DetectHostCapacity()); err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	}
+	if err := validateBuildSettings(req.BuildDir, req.DockerfilePath, req.BuildTarget); err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if err := validateVolumes(req.Volumes); err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	var retention utils.RetentionPolicy
+	if req.Retention != nil {
+		retention = toRetentionPolicy(req.Retention)
+		if err := retention.Validate(); err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	}
+	var approval models.ApprovalSettings
+	if req.Approval != nil {
+		if approval, err = toApprovalSettings(req.Approval); err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	}
+
 	application, err := models.UpdateApplicationFromFrontend(appID, req.Description, req.RepoURL, req.TargetPort, req.Status, models.JSONB{Data: req.Volumes}, req.ExecCommand, req.AutoUpdatePolicy, req.Branch, req.BuildDir, req.BuildType, providerAuthID)
 	if err != nil {
 		return SendError(c, http.StatusInternalServerError, "Failed to update application")
 	}
 
-	var activeReleaseUID *string
+	// 资源限制在下次部署生成 Quadlet 时生效
+	if req.Resources != nil {
+		if err := models.UpdateApplicationResourceLimits(appID, limits); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save resource limits")
+		}
+		application.Resources = limits
+	}
+
+	if err := saveBuildSettings(application, req.DockerfilePath, req.BuildTarget); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to save build settings")
+	}
+
+	if req.BuildCache != nil {
+		settings := toBuildCacheSettings(req.BuildCache)
+		if err := models.UpdateApplicationBuildCacheSettings(application.ID, settings); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save build cache settings")
+		}
+		application.BuildCache = settings
+	}
+
+	if req.AutoDeploy != nil {
+		settings := models.AutoDeploySettings{
+			AutoDeployOnPush:      req.AutoDeploy.OnPush,
+			AutoDeployOnTag:       req.AutoDeploy.OnTag,
+			AutoDeployOnImagePush: req.AutoDeploy.OnImagePush,
+		}
+		if err := models.UpdateApplicationAutoDeploySettings(application.ID, settings); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save auto deploy settings")
+		}
+		application.AutoDeploy = settings
+	}
+
+	if req.Retention != nil {
+		if err := models.UpdateApplicationRetentionPolicy(application.ID, retention); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save retention policy")
+		}
+		application.Retention = retention
+	}
+
+	if req.Approval != nil {
+		if err := models.UpdateApplicationApprovalSettings(application.ID, approval); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to save approval settings")
+		}
+		application.Approval = approval
+	}
+
+	response := toApplicationDetailResponse(application)
+
+	return SendSuccess(c, response)
+}
+
+// validateBuildSettings 校验构建目录和 Dockerfile 路径不会指向仓库之外
+func validateBuildSettings(buildDir, dockerfilePath, buildTarget *string) error {
+	if buildDir != nil {
+		if _, err := utils.CleanRepoPath("buildDir", *buildDir); err != nil {
+			return err
+		}
+	}
+	if dockerfilePath != nil {
+		if _, err := utils.CleanRepoPath("dockerfilePath", *dockerfilePath); err != nil {
+			return err
+		}
+	}
+	if buildTarget != nil && strings.ContainsAny(strings.TrimSpace(*buildTarget), " \t/") {
+		return fmt.Errorf("buildTarget 不是有效的构建阶段名称: %s", *buildTarget)
+	}
+	return nil
+}
+
+// validateVolumes 校验请求中的卷挂载，源只能是命名卷或允许目录下的路径
+func validateVolumes(volumes interface{}) error {
+	for _, mount := range models.ParseVolumeMounts(models.JSONB{Data: volumes}) {
+		if err := utils.ValidateVolumeMount(mount.HostPath, mount.ContainerPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveBuildSettings 保存请求中指定的 Dockerfile 路径和目标阶段，未指定的字段保持不变
+func saveBuildSettings(application *models.Application, dockerfilePath, buildTarget *string) error {
+	if dockerfilePath == nil && buildTarget == nil {
+		return nil
+	}
+
+	newDockerfile, newTarget := application.DockerfilePath, application.BuildTarget
+	if dockerfilePath != nil {
+		cleaned, _ := utils.CleanRepoPath("dockerfilePath", *dockerfilePath)
+		if cleaned == "" {
+			cleaned = utils.DefaultDockerfilePath
+		}
+		newDockerfile = &cleaned
+	}
+	if buildTarget != nil {
+		newTarget = nil
+		if target := strings.TrimSpace(*buildTarget); target != "" {
+			newTarget = &target
+		}
+	}
+
+	if err := models.UpdateApplicationBuildSettings(application.ID, newDockerfile, newTarget); err != nil {
+		return err
+	}
+	application.DockerfilePath, application.BuildTarget = newDockerfile, newTarget
+	return nil
+}
+
+// toApplicationDetailResponse converts an application model to its API response
+func toApplicationDetailResponse(application *models.Application) ApplicationDetailResponse {
+	response := ApplicationDetailResponse{
+		Uid:               EncodeFriendlyID(PrefixApplication, application.ID),
+		ProjectUid:        EncodeFriendlyID(PrefixProject, application.ProjectID),
+		Name:              application.Name,
+		Description:       application.Description,
+		RepoURL:           application.RepoURL,
+		TargetPort:        application.TargetPort,
+		Status:            application.Status,
+		Volumes:           application.Volumes,
+		ExecCommand:       application.ExecCommand,
+		AutoUpdatePolicy:  application.AutoUpdatePolicy,
+		Branch:            application.Branch,
+		BuildDir:          application.BuildDir,
+		BuildType:         application.BuildType,
+		DockerfilePath:    application.DockerfilePath,
+		BuildTarget:       application.BuildTarget,
+		GeneratedHostname: application.GeneratedHostname,
+		Resources: ResourceLimitsResponse{
+			CPUQuota:            application.Resources.CPUQuota,
+			MemoryLimitMB:       application.Resources.MemoryLimitMB,
+			MemoryReservationMB: application.Resources.MemoryReservationMB,
+			PidsLimit:           application.Resources.PidsLimit,
+			OOMPolicy:           application.Resources.OOMPolicy,
+		},
+		BuildCache: BuildCacheSettingsResponse{
+			Enabled:    !application.BuildCache.DisableBuildCache,
+			PullAlways: !application.BuildCache.DisablePullAlways,
+		},
+		AutoDeploy: AutoDeploySettings{
+			OnPush:      application.AutoDeploy.AutoDeployOnPush,
+			OnTag:       application.AutoDeploy.AutoDeployOnTag,
+			OnImagePush: application.AutoDeploy.AutoDeployOnImagePush,
+		},
+		Retention: RetentionPolicyResponse{
+			KeepLast:       application.Retention.RetentionKeepLast,
+			KeepDays:       application.Retention.RetentionKeepDays,
+			LastRunAt:      application.RetentionLastRunAt,
+			ReclaimedBytes: application.RetentionReclaimedBytes,
+		},
+		Approval: ApprovalSettings{
+			Protected:    application.Approval.Protected,
+			TimeoutHours: application.Approval.ApprovalTimeoutHours,
+			Approvers:    application.Approval.ApproverList(),
+		},
+		CreatedAt: application.CreatedAt,
+		UpdatedAt: application.UpdatedAt,
+	}
+
 	if application.ActiveReleaseID != nil {
 		uid := EncodeFriendlyID(PrefixRelease, *application.ActiveReleaseID)
-		activeReleaseUID = &uid
+		response.ActiveReleaseUid = &uid
 	}
 
-	response := ApplicationDetailResponse{
-		Uid:              EncodeFriendlyID(PrefixApplication, application.ID),
-		ProjectUid:       EncodeFriendlyID(PrefixProject, application.ProjectID),
-		Name:             application.Name,
-		Description:      application.Description,
-		RepoURL:          application.RepoURL,
-		ActiveReleaseUid: activeReleaseUID,
-		TargetPort:       application.TargetPort,
-		Status:           application.Status,
-		Volumes:          application.Volumes,
-		ExecCommand:      application.ExecCommand,
-		AutoUpdatePolicy: application.AutoUpdatePolicy,
-		Branch:           application.Branch,
-		BuildDir:         application.BuildDir,
-		BuildType:        application.BuildType,
-		CreatedAt:        application.CreatedAt,
-		UpdatedAt:        application.UpdatedAt,
+	return response
+}
+
+// GetHostCapacityHandler returns the CPU and memory available for application resource limits
+func GetHostCapacityHandler(c echo.Context) error {
+	capacity := utils.DetectHostCapacity()
+	return SendSuccess(c, HostCapacityResponse{
+		CPUs:     capacity.CPUs,
+		MemoryMB: capacity.MemoryMB,
+	})
+}
+
+// toBuildCacheSettings converts a build cache request to the model type
+func toBuildCacheSettings(req *BuildCacheSettingsRequest) models.BuildCacheSettings {
+	return models.BuildCacheSettings{
+		DisableBuildCache: !req.Enabled,
+		DisablePullAlways: !req.PullAlways,
 	}
+}
 
-	return SendSuccess(c, response)
+// toRetentionPolicy converts a retention policy request to the model type
+func toRetentionPolicy(req *RetentionPolicyRequest) utils.RetentionPolicy {
+	return utils.RetentionPolicy{
+		RetentionKeepLast: req.KeepLast,
+		RetentionKeepDays: req.KeepDays,
+	}
+}
+
+// maxApprovalTimeoutHours 等待审批的最长时限
+const maxApprovalTimeoutHours = 720
+
+// toApprovalSettings converts approval settings from a request to the model type, defaulting the timeout to 24 hours
+func toApprovalSettings(req *ApprovalSettings) (models.ApprovalSettings, error) {
+	if req.TimeoutHours < 0 || req.TimeoutHours > maxApprovalTimeoutHours {
+		return models.ApprovalSettings{}, fmt.Errorf("审批时限必须在 1 到 %d 小时之间", maxApprovalTimeoutHours)
+	}
+	approvers := make([]string, 0, len(req.Approvers))
+	for _, name := range req.Approvers {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(approvers, name) {
+			continue
+		}
+		if _, err := models.GetUserByUsername(name); err != nil {
+			return models.ApprovalSettings{}, fmt.Errorf("审批人不存在: %s", name)
+		}
+		approvers = append(approvers, name)
+	}
+	settings := models.ApprovalSettings{
+		Protected:            req.Protected,
+		ApprovalTimeoutHours: req.TimeoutHours,
+		Approvers:            strings.Join(approvers, ","),
+	}
+	if settings.ApprovalTimeoutHours == 0 {
+		settings.ApprovalTimeoutHours = models.DefaultApprovalTimeoutHours
+	}
+	return settings, nil
+}
+
+// toResourceLimits converts a resource limits request to the model type
+func toResourceLimits(req *ResourceLimitsRequest) utils.ResourceLimits {
+	return utils.ResourceLimits{
+		CPUQuota:            req.CPUQuota,
+		MemoryLimitMB:       req.MemoryLimitMB,
+		MemoryReservationMB: req.MemoryReservationMB,
+		PidsLimit:           req.PidsLimit,
+		OOMPolicy:           req.OOMPolicy,
+	}
 }
@@ -1,16 +1,14 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
 	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/labstack/echo/v4"
 )
 
@@ -177,53 +175,35 @@ func GetApplicationLogsHandler(c echo.Context) error {
 	return SendSuccess(c, response)
 }
 
-// GetBranchesHandler fetches branches for a given repo URL (GitHub only for now)
-func GetGitHubBranchesHandler(c echo.Context) error {
+// GetRepositoryBranchesHandler 获取仓库地址的分支列表。使用实例地址匹配的授权访问私有仓库，
+// 没有匹配的授权时匿名访问 GitHub、GitLab、Gitea、Bitbucket 公共实例上的公开仓库
+func GetRepositoryBranchesHandler(c echo.Context) error {
 	repoUrl := c.QueryParam("repoUrl")
 	if repoUrl == "" {
 		return SendError(c, http.StatusBadRequest, "repoUrl is required")
 	}
 
-	// Only support GitHub for now
-	if !strings.Contains(repoUrl, "github.com") {
-		return SendSuccess(c, map[string]interface{}{"branches": []string{}})
-	}
-
-	// Extract owner/repo from URL
-	parts := strings.Split(strings.TrimSuffix(repoUrl, ".git"), "/")
-	if len(parts) < 2 {
-		return SendError(c, http.StatusBadRequest, "Invalid GitHub repo URL")
-	}
-	repoPath := strings.Join(parts[len(parts)-2:], "/")
-
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/branches", repoPath)
-	req, err := http.NewRequest("GET", apiURL, nil)
+	providerAuth, err := services.FindProviderAuthForRepo(repoUrl)
 	if err != nil {
-		return SendError(c, http.StatusInternalServerError, "Failed to create API request")
+		return SendError(c, http.StatusInternalServerError, err.Error())
 	}
-
-	// TODO: Add token support if needed for private repos
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return SendError(c, http.StatusInternalServerError, "API request failed")
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return SendError(c, http.StatusBadRequest, "Failed to fetch branches from GitHub")
+	var client *utils.GitProviderClient
+	if providerAuth != nil {
+		client, err = services.NewProviderAuthClient(providerAuth)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+	} else {
+		platform := utils.PublicGitPlatform(repoUrl)
+		if platform == "" {
+			return SendSuccess(c, map[string]interface{}{"branches": []string{}})
+		}
+		client = utils.NewGitProviderClient(platform, "", "")
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	branches, hasMore, err := client.ListBranches(repoUrl, gitListOptionsFromQuery(c))
 	if err != nil {
-		return SendError(c, http.StatusInternalServerError, "Failed to read API response")
-	}
-
-	var branches []struct {
-		Name string `json:"name"`
-	}
-	if err := json.Unmarshal(body, &branches); err != nil {
-		return SendError(c, http.StatusInternalServerError, "Failed to parse API response")
+		return SendError(c, http.StatusBadGateway, "Failed to fetch branches: "+err.Error())
 	}
 
 	branchNames := make([]string, len(branches))
@@ -231,7 +211,7 @@ func GetGitHubBranchesHandler(c echo.Context) error {
 		branchNames[i] = branch.Name
 	}
 
-	return SendSuccess(c, map[string]interface{}{"branches": branchNames})
+	return SendSuccess(c, map[string]interface{}{"branches": branchNames, "hasMore": hasMore})
 }
 
 // NewDeleteApplicationHandler 是一个工厂函数，它接收依赖，返回真正的 Handler
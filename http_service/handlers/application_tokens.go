@@ -42,7 +42,8 @@ func CreateApplicationToken(c echo.Context) error {
 	}
 
 	// 创建token
-	token, plainToken, err := models.CreateApplicationToken(app.ID, req.Name, req.ExpiresAt)
+	username, _ := c.Get("username").(string)
+	token, plainToken, err := models.CreateApplicationToken(app.ID, req.Name, username, req.ExpiresAt)
 	if err != nil {
 		logman.Error("Failed to create application token", err)
 		return SendError(c, http.StatusInternalServerError, "Failed to create token")
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Build Cache Handlers
+
+// GetBuildCacheHandler returns the disk usage of an application's build cache
+// Endpoint: GET /api/apps/:appId/build-cache
+func GetBuildCacheHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application UID")
+	}
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	usage, err := services.GetBuildCacheUsage(application)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return SendSuccess(c, BuildCacheUsageResponse{
+		Path:           usage.Path,
+		RepoCacheBytes: usage.RepoCacheBytes,
+		ImageCount:     usage.ImageCount,
+		ImageBytes:     usage.ImageBytes,
+	})
+}
+
+// PurgeBuildCacheHandler removes an application's build cache; ?layers=true also prunes dangling podman layers
+// Endpoint: DELETE /api/apps/:appId/build-cache
+func PurgeBuildCacheHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application UID")
+	}
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	pruneLayers := c.QueryParam("layers") == "true"
+	purged, err := services.PurgeBuildCache(application, pruneLayers)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return SendSuccess(c, PurgeBuildCacheResponse{
+		ReclaimedBytes: purged.RepoCacheBytes,
+		PrunedLayers:   pruneLayers,
+	})
+}
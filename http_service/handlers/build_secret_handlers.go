@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Build Secret Handlers
+// 构建密钥通过 podman build --secret 挂载，API 不返回密钥的值
+
+// CreateBuildSecretHandler creates a new build secret
+func CreateBuildSecretHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	if _, err := models.GetApplicationByID(appID); err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	var req CreateBuildSecretRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := utils.ValidateBuildSecretID(req.Name); err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if req.Value == "" {
+		return SendError(c, http.StatusBadRequest, "Value is required")
+	}
+
+	secret, err := models.CreateBuildSecret(appID, req.Name, req.Value)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return SendError(c, http.StatusConflict, "Build secret already exists: "+req.Name)
+		}
+		return SendError(c, http.StatusInternalServerError, "Failed to create build secret")
+	}
+
+	return SendCreated(c, toBuildSecretResponse(secret))
+}
+
+// ListBuildSecretsHandler lists the build secrets of an application
+func ListBuildSecretsHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+
+	secrets, err := models.ListBuildSecretsByApplicationID(appID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list build secrets")
+	}
+
+	responses := make([]BuildSecretResponse, len(secrets))
+	for i, secret := range secrets {
+		responses[i] = *toBuildSecretResponse(secret)
+	}
+	return SendSuccess(c, responses)
+}
+
+// UpdateBuildSecretHandler replaces the value of a build secret
+func UpdateBuildSecretHandler(c echo.Context) error {
+	secretID, err := DecodeFriendlyID(PrefixBuildSecret, c.Param("secretId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid build secret ID format")
+	}
+
+	var req UpdateBuildSecretRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+	if req.Value == "" {
+		return SendError(c, http.StatusBadRequest, "Value is required")
+	}
+
+	secret, err := models.UpdateBuildSecretValue(secretID, req.Value)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to update build secret")
+	}
+
+	return SendSuccess(c, toBuildSecretResponse(secret))
+}
+
+// DeleteBuildSecretHandler deletes a build secret
+func DeleteBuildSecretHandler(c echo.Context) error {
+	secretID, err := DecodeFriendlyID(PrefixBuildSecret, c.Param("secretId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid build secret ID format")
+	}
+
+	if err := models.DeleteBuildSecret(secretID); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to delete build secret")
+	}
+
+	return SendSuccess(c, map[string]string{"message": "Build secret deleted"})
+}
+
+func toBuildSecretResponse(secret *models.BuildSecret) *BuildSecretResponse {
+	return &BuildSecretResponse{
+		Uid:            EncodeFriendlyID(PrefixBuildSecret, secret.ID),
+		ApplicationUid: EncodeFriendlyID(PrefixApplication, secret.ApplicationID),
+		Name:           secret.Name,
+		CreatedAt:      secret.CreatedAt,
+		UpdatedAt:      secret.UpdatedAt,
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+	"gorm.io/gorm"
+)
+
+// Canary Handlers
+// 同时注册在 /apps/:appId/canary（Web）和 /cli/apps/by-name/:appName/canary（CLI，支持应用令牌）下
+
+// NewGetCanaryHandler returns the active or most recent canary release of an application
+func NewGetCanaryHandler(do *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := resolveCanaryApplication(c)
+		if err != nil {
+			return err
+		}
+
+		canary, err := do.GetCanaryRelease(appID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return SendError(c, http.StatusNotFound, "No canary release found")
+		}
+		if err != nil {
+			logman.Error("获取金丝雀发布失败", "app_id", appID, "error", err)
+			return SendError(c, http.StatusInternalServerError, "Failed to get canary release")
+		}
+
+		return SendSuccess(c, toCanaryReleaseResponse(canary))
+	}
+}
+
+// NewStepCanaryHandler shifts more traffic to the canary; reaching 100% promotes it
+func NewStepCanaryHandler(do *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := resolveCanaryApplication(c)
+		if err != nil {
+			return err
+		}
+
+		var req CanaryStepRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+
+		canary, err := do.StepCanary(appID, req.Weight)
+		if err != nil {
+			logman.Error("调整金丝雀流量失败", "app_id", appID, "error", err)
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+
+		return SendSuccess(c, toCanaryReleaseResponse(canary))
+	}
+}
+
+// NewPromoteCanaryHandler sends all traffic to the canary and stops the previous version
+func NewPromoteCanaryHandler(do *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := resolveCanaryApplication(c)
+		if err != nil {
+			return err
+		}
+
+		canary, err := do.PromoteCanary(appID)
+		if err != nil {
+			logman.Error("Promote 金丝雀发布失败", "app_id", appID, "error", err)
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+
+		return SendSuccess(c, toCanaryReleaseResponse(canary))
+	}
+}
+
+// NewAbortCanaryHandler sends all traffic back to the previous version and stops the canary
+func NewAbortCanaryHandler(do *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := resolveCanaryApplication(c)
+		if err != nil {
+			return err
+		}
+
+		var req CanaryAbortRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+
+		canary, err := do.AbortCanary(appID, req.Reason)
+		if err != nil {
+			logman.Error("中止金丝雀发布失败", "app_id", appID, "error", err)
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+
+		return SendSuccess(c, toCanaryReleaseResponse(canary))
+	}
+}
+
+// resolveCanaryApplication 从 :appId 或 :appName 参数解析应用，按名称访问时校验应用令牌权限
+func resolveCanaryApplication(c echo.Context) (uuid.UUID, error) {
+	if c.Param("appName") != "" {
+		app, err := getCLIApplication(c)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return app.ID, nil
+	}
+
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid application ID format")
+	}
+	return appID, nil
+}
+
+func toCanaryReleaseResponse(canary *models.CanaryRelease) *CanaryReleaseResponse {
+	return &CanaryReleaseResponse{
+		ApplicationUid:        EncodeFriendlyID(PrefixApplication, canary.ApplicationID),
+		DeploymentUid:         EncodeFriendlyID(PrefixDeployment, canary.DeploymentID),
+		BaselineDeploymentUid: EncodeFriendlyID(PrefixDeployment, canary.BaselineDeploymentID),
+		Status:                canary.Status,
+		Weight:                canary.Weight,
+		StepWeight:            canary.StepWeight,
+		MaxErrorRate:          canary.MaxErrorRate,
+		HealthPath:            canary.HealthPath,
+		BaselinePort:          canary.BaselinePort,
+		CanaryPort:            canary.CanaryPort,
+		AbortReason:           canary.AbortReason,
+		CreatedAt:             canary.CreatedAt,
+		FinishedAt:            canary.FinishedAt,
+	}
+}
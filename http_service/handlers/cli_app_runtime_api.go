@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// CLI Application Runtime Handlers
+// 供 orbitctl status/logs/inspect 使用，支持应用令牌认证，可在 CI 中使用
+
+// GetCLIApplicationStatus returns the active release, unit state, routings and last deployment of an application
+// Endpoint: GET /api/cli/apps/by-name/:appName/status
+func GetCLIApplicationStatus(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	project, err := models.GetProjectByID(app.ProjectID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get project")
+	}
+
+	response := CLIApplicationStatusResponse{
+		AppName:           app.Name,
+		AppUid:            EncodeFriendlyID(PrefixApplication, app.ID),
+		Project:           project.Name,
+		Status:            app.Status,
+		GeneratedHostname: app.GeneratedHostname,
+		Routings:          []CLIRoutingStatus{},
+	}
+
+	if app.ActiveReleaseID != nil {
+		if release, err := models.GetReleaseByID(*app.ActiveReleaseID); err == nil {
+			response.ActiveRelease = &CLIReleaseSummary{
+				Uid:       EncodeFriendlyID(PrefixRelease, release.ID),
+				Version:   release.Version,
+				ImageName: release.ImageName,
+				CreatedAt: release.CreatedAt,
+			}
+		}
+	}
+
+	activeDeployment, err := services.GetActiveDeployment(app)
+	if err != nil {
+		logman.Warn("获取活跃部署失败", "app_name", app.Name, "error", err)
+	}
+	if activeDeployment != nil {
+		service := &CLIServiceStatus{
+			Name:        activeDeployment.ServiceName,
+			ActiveState: "unknown",
+			SystemPort:  activeDeployment.SystemPort,
+		}
+		if state, err := services.GetUnitState(activeDeployment.ServiceName, project); err != nil {
+			logman.Warn("查询服务状态失败", "service", activeDeployment.ServiceName, "error", err)
+		} else {
+			service.ActiveState = state.ActiveState
+			service.SubState = state.SubState
+		}
+		response.Service = service
+	}
+
+	routings, err := models.ListRoutingsByAppID(app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list routings")
+	}
+	for _, routing := range routings {
+		response.Routings = append(response.Routings, CLIRoutingStatus{
+			Domain:    routing.DomainName,
+			HostPort:  routing.HostPort,
+			IsActive:  routing.IsActive,
+			DNSStatus: routing.DNSStatus,
+		})
+	}
+
+	deployments, err := models.ListDeploymentsByAppID(app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list deployments")
+	}
+	if len(deployments) > 0 {
+		last := deployments[0]
+		response.LastDeployment = &CLIDeploymentSummary{
+			Uid:        EncodeFriendlyID(PrefixDeployment, last.ID),
+			ReleaseUid: EncodeFriendlyID(PrefixRelease, last.ReleaseID),
+			Status:     last.Status,
+			StartedAt:  last.StartedAt,
+			FinishedAt: last.FinishedAt,
+		}
+	}
+
+	if canary, err := models.GetActiveCanaryReleaseByAppID(app.ID); err == nil && canary != nil {
+		response.CanaryStatus = canary.Status
+		response.CanaryWeight = canary.Weight
+	}
+
+	return SendSuccess(c, response)
+}
+
+// StreamCLIApplicationLogs streams the container logs of the active deployment as plain text
+// Endpoint: GET /api/cli/apps/by-name/:appName/logs?lines=100&follow=true
+func StreamCLIApplicationLogs(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	lines := 100
+	if linesStr := c.QueryParam("lines"); linesStr != "" {
+		if parsed, err := strconv.Atoi(linesStr); err == nil && parsed > 0 {
+			lines = parsed
+		}
+	}
+	follow := c.QueryParam("follow") == "true"
+
+	deployment, err := services.GetActiveDeployment(app)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get active deployment")
+	}
+	if deployment == nil {
+		return SendError(c, http.StatusNotFound, "Application has no running deployment")
+	}
+
+	project, err := models.GetProjectByID(app.ProjectID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get project")
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	// 客户端断开时 request context 被取消，journalctl 随之退出
+	err = services.StreamUnitLogs(c.Request().Context(), deployment.ServiceName, project, lines, follow, func(line string) {
+		_, _ = res.Write([]byte(line + "\n"))
+		res.Flush()
+	})
+	if err != nil {
+		logman.Warn("读取应用日志失败", "app_name", app.Name, "service", deployment.ServiceName, "error", err)
+		_, _ = res.Write([]byte("error: " + err.Error() + "\n"))
+	}
+	return nil
+}
+
+// InspectCLIApplication returns the effective server-side configuration of an application
+// Endpoint: GET /api/cli/apps/by-name/:appName/inspect
+func InspectCLIApplication(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	project, err := models.GetProjectByID(app.ProjectID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get project")
+	}
+
+	response := CLIApplicationInspectResponse{
+		AppName:    app.Name,
+		Project:    project.Name,
+		TargetPort: app.TargetPort,
+		Volumes:    []CLIVolumeMount{},
+		Resources: CLIResourceLimits{
+			CPUQuota:            app.Resources.CPUQuota,
+			MemoryLimitMB:       app.Resources.MemoryLimitMB,
+			MemoryReservationMB: app.Resources.MemoryReservationMB,
+			PidsLimit:           app.Resources.PidsLimit,
+			OOMPolicy:           app.Resources.OOMPolicy,
+		},
+		Domains: []string{},
+	}
+	if app.RepoURL != nil {
+		response.RepoURL = *app.RepoURL
+	}
+	if app.Branch != nil {
+		response.Branch = *app.Branch
+	}
+	if app.BuildDir != nil {
+		response.BuildDir = *app.BuildDir
+	}
+	if app.BuildType != nil {
+		response.BuildType = *app.BuildType
+	}
+	if app.ExecCommand != nil {
+		response.ExecCommand = *app.ExecCommand
+	}
+	if app.AutoUpdatePolicy != nil {
+		response.AutoUpdatePolicy = *app.AutoUpdatePolicy
+	}
+
+	if app.ActiveReleaseID != nil {
+		if release, err := models.GetReleaseByID(*app.ActiveReleaseID); err == nil {
+			response.Image = release.ImageName
+		}
+	}
+
+	for _, mount := range models.ParseVolumeMounts(app.Volumes) {
+		response.Volumes = append(response.Volumes, CLIVolumeMount{Source: mount.HostPath, Target: mount.ContainerPath, ReadOnly: mount.ReadOnly})
+	}
+
+	routings, err := models.GetActiveRoutingsByApplicationID(app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list routings")
+	}
+	for _, routing := range routings {
+		response.Domains = append(response.Domains, routing.DomainName)
+	}
+
+	envVars, err := models.ListEnvironmentVariablesByApplicationID(app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list environment variables")
+	}
+	if response.Env, err = toCLIEnvironmentVariables(envVars, false); err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return SendSuccess(c, response)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+	"gorm.io/gorm"
+)
+
+// CLI Environment Variable Handlers
+// 供 orbitctl env 使用，支持应用令牌认证
+
+// ListCLIEnvironmentVariables lists the environment variables of an application, masking secrets unless ?reveal=true
+// Revealing secrets requires a user login; app tokens only get masked values
+// Endpoint: GET /api/cli/apps/by-name/:appName/environment-variables
+func ListCLIEnvironmentVariables(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+	reveal := c.QueryParam("reveal") == "true"
+	if reveal && c.Get("auth_type") != "jwt" {
+		return SendError(c, http.StatusForbidden, "Revealing secrets requires a user login")
+	}
+
+	envVars, err := models.ListEnvironmentVariablesByApplicationID(app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list environment variables")
+	}
+
+	variables, err := toCLIEnvironmentVariables(envVars, reveal)
+	if err != nil {
+		logman.Error("解密环境变量失败", "app_name", app.Name, "error", err)
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return SendSuccess(c, CLIEnvironmentVariablesResponse{Variables: variables})
+}
+
+// NewSetCLIEnvironmentVariablesHandler returns the handler that bulk sets and unsets environment variables
+// Endpoint: PUT /api/cli/apps/by-name/:appName/environment-variables
+func NewSetCLIEnvironmentVariablesHandler(do *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		app, err := getCLIApplication(c)
+		if err != nil {
+			return err
+		}
+
+		var req CLISetEnvironmentVariablesRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+		if len(req.Variables) == 0 && len(req.Unset) == 0 {
+			return SendError(c, http.StatusBadRequest, "No environment variables to set or unset")
+		}
+
+		// 同一个 key 出现多次时以最后一次为准
+		inputs := make([]models.EnvironmentVariableInput, 0, len(req.Variables))
+		index := make(map[string]int)
+		for _, v := range req.Variables {
+			if err := utils.ValidateEnvKey(v.Key); err != nil {
+				return SendError(c, http.StatusBadRequest, err.Error())
+			}
+			input := models.EnvironmentVariableInput{Key: v.Key, Value: v.Value, IsEncrypted: v.Secret}
+			if i, ok := index[v.Key]; ok {
+				inputs[i] = input
+				continue
+			}
+			index[v.Key] = len(inputs)
+			inputs = append(inputs, input)
+		}
+		for _, key := range req.Unset {
+			if _, ok := index[key]; ok {
+				return SendError(c, http.StatusBadRequest, "Environment variable "+key+" cannot be both set and unset")
+			}
+		}
+
+		if req.Redeploy && app.ActiveReleaseID == nil {
+			return SendError(c, http.StatusBadRequest, "Application has no active release to redeploy")
+		}
+
+		created, updated, deleted, err := models.SetEnvironmentVariables(app.ID, inputs, req.Unset)
+		if err != nil {
+			logman.Error("批量设置环境变量失败", "app_name", app.Name, "error", err)
+			return SendError(c, http.StatusInternalServerError, "Failed to set environment variables")
+		}
+		logman.Info("批量设置环境变量成功", "app_name", app.Name, "created", created, "updated", updated, "deleted", deleted)
+
+		response := CLISetEnvironmentVariablesResponse{Created: created, Updated: updated, Deleted: deleted}
+
+		// 以当前运行的版本重新部署，使新的环境变量生效
+		if req.Redeploy {
+			deployment, err := do.CreateDeployment(app.ID, services.CreateDeploymentRequest{
+				ReleaseID:   app.ActiveReleaseID,
+				RequestedBy: getDeploymentRequester(c),
+			})
+			if err != nil {
+				logman.Error("重新部署失败", "app_name", app.Name, "error", err)
+				return SendError(c, deploymentErrorStatus(err), "Environment variables saved, but failed to redeploy: "+err.Error())
+			}
+			response.DeploymentUid = EncodeFriendlyID(PrefixDeployment, deployment.ID)
+		}
+
+		return SendSuccess(c, response)
+	}
+}
+
+// DiffCLIEnvironmentVariables compares the current environment variables with the snapshot of the last successful deployment
+// Endpoint: GET /api/cli/apps/by-name/:appName/environment-variables/diff
+func DiffCLIEnvironmentVariables(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	deployment, err := models.GetLatestSuccessfulDeploymentByAppID(app.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return SendError(c, http.StatusNotFound, "Application has no successful deployment")
+	}
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get latest deployment")
+	}
+
+	snapshot, err := models.ParseEnvironmentSnapshot(deployment.Snapshot)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to parse deployment snapshot")
+	}
+
+	envVars, err := models.ListEnvironmentVariablesByApplicationID(app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list environment variables")
+	}
+
+	before := make(map[string]string, len(snapshot))
+	secrets := make(map[string]bool)
+	for _, entry := range snapshot {
+		before[entry.Key] = entry.Value
+		secrets[entry.Key] = secrets[entry.Key] || entry.IsEncrypted
+	}
+	after := make(map[string]string, len(envVars))
+	for _, envVar := range envVars {
+		value, err := envVar.GetDecryptedValue()
+		if err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to decrypt environment variable "+envVar.Key)
+		}
+		after[envVar.Key] = value
+		secrets[envVar.Key] = secrets[envVar.Key] || envVar.IsEncrypted
+	}
+
+	added, removed, changed := toCLIEnvironmentDiffEntries(utils.DiffEnvVars(before, after), before, after, secrets)
+	return SendSuccess(c, CLIEnvironmentDiffResponse{
+		DeploymentUid: EncodeFriendlyID(PrefixDeployment, deployment.ID),
+		DeployedAt:    deployment.CreatedAt,
+		Added:         added,
+		Removed:       removed,
+		Changed:       changed,
+	})
+}
+
+// toCLIEnvironmentDiffEntries converts an environment diff to CLI entries; secrets only show which keys changed, never values
+func toCLIEnvironmentDiffEntries(diff utils.EnvDiff, before, after map[string]string, secrets map[string]bool) (added, removed, changed []CLIEnvironmentDiffEntry) {
+	entry := func(key string) CLIEnvironmentDiffEntry {
+		if secrets[key] {
+			e := CLIEnvironmentDiffEntry{Key: key, Secret: true}
+			if _, ok := before[key]; ok {
+				e.Old = utils.MaskedValue
+			}
+			if _, ok := after[key]; ok {
+				e.New = utils.MaskedValue
+			}
+			return e
+		}
+		return CLIEnvironmentDiffEntry{Key: key, Old: before[key], New: after[key]}
+	}
+
+	added, removed, changed = []CLIEnvironmentDiffEntry{}, []CLIEnvironmentDiffEntry{}, []CLIEnvironmentDiffEntry{}
+	for _, key := range diff.Added {
+		added = append(added, entry(key))
+	}
+	for _, key := range diff.Removed {
+		removed = append(removed, entry(key))
+	}
+	for _, key := range diff.Changed {
+		changed = append(changed, entry(key))
+	}
+	return added, removed, changed
+}
+
+// toCLIEnvironmentVariables converts environment variables to CLI responses, masking secrets unless reveal is set
+func toCLIEnvironmentVariables(envVars []*models.EnvironmentVariable, reveal bool) ([]CLIEnvironmentVariableResponse, error) {
+	variables := make([]CLIEnvironmentVariableResponse, 0, len(envVars))
+	for _, envVar := range envVars {
+		value := utils.MaskedValue
+		if !envVar.IsEncrypted || reveal {
+			var err error
+			if value, err = envVar.GetDecryptedValue(); err != nil {
+				return nil, fmt.Errorf("Failed to decrypt environment variable %s", envVar.Key)
+			}
+		}
+		variables = append(variables, CLIEnvironmentVariableResponse{
+			Key:       envVar.Key,
+			Value:     value,
+			Secret:    envVar.IsEncrypted,
+			UpdatedAt: envVar.UpdatedAt,
+		})
+	}
+	return variables, nil
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// CLI Image Upload Handlers
+// 可续传的分块上传：创建上传后按 offset 逐块 PUT，每块可附带 sha256 校验，
+// 全部接收后 complete 校验整个文件并导入镜像。进度通过上传进度 WebSocket 推送
+
+const (
+	imageUploadDir       = "/var/lib/orbitdeploy/uploads"
+	imageUploadChunkSize = 8 << 20
+	imageUploadMaxChunk  = 64 << 20
+	imageUploadMaxAge    = 7 * 24 * time.Hour
+)
+
+var sha256HexPattern = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// imageUploadLocks 同一上传的分块写入、完成和删除串行执行，避免并发请求交错写入文件或重复导入
+var imageUploadLocks = struct {
+	sync.Mutex
+	locks map[uuid.UUID]*imageUploadLock
+}{locks: make(map[uuid.UUID]*imageUploadLock)}
+
+type imageUploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lockImageUpload 锁定上传，返回解锁函数；没有请求持有时释放锁对象
+func lockImageUpload(id uuid.UUID) func() {
+	imageUploadLocks.Lock()
+	lock := imageUploadLocks.locks[id]
+	if lock == nil {
+		lock = &imageUploadLock{}
+		imageUploadLocks.locks[id] = lock
+	}
+	lock.refs++
+	imageUploadLocks.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		imageUploadLocks.Lock()
+		if lock.refs--; lock.refs == 0 {
+			delete(imageUploadLocks.locks, id)
+		}
+		imageUploadLocks.Unlock()
+	}
+}
+
+func imageUploadPath(upload *models.ImageUpload) string {
+	return filepath.Join(imageUploadDir, upload.ID.String()+".part")
+}
+
+func toCLIImageUploadResponse(upload *models.ImageUpload) *CLIImageUploadResponse {
+	response := &CLIImageUploadResponse{
+		UploadId:  EncodeFriendlyID(PrefixImageUpload, upload.ID),
+		FileName:  upload.FileName,
+		TotalSize: upload.TotalSize,
+		Offset:    upload.ReceivedSize,
+		ChunkSize: imageUploadChunkSize,
+		Status:    upload.Status,
+	}
+	if upload.ReleaseID != nil {
+		response.ReleaseUid = EncodeFriendlyID(PrefixRelease, *upload.ReleaseID)
+	}
+	return response
+}
+
+// getLockedCLIImageUpload 与 getCLIImageUpload 相同，并锁定上传；上传记录在取得锁之后读取，调用方负责解锁
+func getLockedCLIImageUpload(c echo.Context) (*models.Application, *models.ImageUpload, func(), error) {
+	_, upload, err := getCLIImageUpload(c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	unlock := lockImageUpload(upload.ID)
+	// 等待锁期间上传可能已完成或被删除
+	app, upload, err := getCLIImageUpload(c)
+	if err != nil {
+		unlock()
+		return nil, nil, nil, err
+	}
+	return app, upload, unlock, nil
+}
+
+// getCLIImageUpload 解析 :uploadId 并确认上传属于 :appName 对应的应用
+func getCLIImageUpload(c echo.Context) (*models.Application, *models.ImageUpload, error) {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	uploadID, err := DecodeFriendlyID(PrefixImageUpload, c.Param("uploadId"))
+	if err != nil {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid upload ID format")
+	}
+	upload, err := models.GetImageUploadByID(uploadID)
+	if err != nil || upload.ApplicationID != app.ID {
+		return nil, nil, echo.NewHTTPError(http.StatusNotFound, "Upload not found")
+	}
+	return app, upload, nil
+}
+
+// syncImageUploadSize 以磁盘上已接收的数据为准，修正中断时没来得及记录的大小
+func syncImageUploadSize(upload *models.ImageUpload) {
+	size := int64(0)
+	if info, err := os.Stat(imageUploadPath(upload)); err == nil {
+		size = info.Size()
+	}
+	if size != upload.ReceivedSize {
+		upload.ReceivedSize = size
+		if err := models.UpdateImageUploadReceivedSize(upload.ID, size); err != nil {
+			logman.Warn("更新上传进度失败", "upload_id", upload.ID, "error", err)
+		}
+	}
+}
+
+// removeImageUpload 删除上传记录和已接收的数据
+func removeImageUpload(upload *models.ImageUpload) {
+	os.Remove(imageUploadPath(upload))
+	if err := models.DeleteImageUpload(upload.ID); err != nil {
+		logman.Warn("删除上传记录失败", "upload_id", upload.ID, "error", err)
+	}
+}
+
+// cleanupExpiredImageUploads 删除长时间没有继续的上传
+func cleanupExpiredImageUploads() {
+	before := time.Now().Add(-imageUploadMaxAge)
+	uploads, err := models.ListExpiredImageUploads(before)
+	if err != nil {
+		logman.Warn("查询过期的上传失败", "error", err)
+		return
+	}
+	for i := range uploads {
+		unlock := lockImageUpload(uploads[i].ID)
+		// 等待锁期间上传可能已继续或完成
+		if upload, err := models.GetImageUploadByID(uploads[i].ID); err == nil &&
+			upload.Status == models.ImageUploadStatusUploading && upload.UpdatedAt.Before(before) {
+			logman.Info("清理过期的镜像上传", "upload_id", upload.ID, "file_name", upload.FileName)
+			removeImageUpload(upload)
+		}
+		unlock()
+	}
+}
+
+// CreateCLIImageUpload creates a chunked image upload, or resumes the unfinished upload of the same file
+// Endpoint: POST /api/cli/apps/by-name/:appName/uploads
+func CreateCLIImageUpload(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	var req CLIImageUploadRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+	req.FileName = filepath.Base(strings.TrimSpace(req.FileName))
+	if !strings.HasSuffix(req.FileName, ".tar") && !strings.HasSuffix(req.FileName, ".tar.gz") {
+		return SendError(c, http.StatusBadRequest, "Only .tar and .tar.gz files are supported")
+	}
+	if req.TotalSize <= 0 {
+		return SendError(c, http.StatusBadRequest, "total_size must be positive")
+	}
+	if !sha256HexPattern.MatchString(req.SHA256) {
+		return SendError(c, http.StatusBadRequest, "sha256 must be a hex encoded SHA-256 checksum")
+	}
+	req.SHA256 = strings.ToLower(req.SHA256)
+
+	cleanupExpiredImageUploads()
+
+	existing, err := models.FindResumableImageUpload(app.ID, req.SHA256, req.TotalSize)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to query uploads")
+	}
+	if existing != nil {
+		syncImageUploadSize(existing)
+		logman.Info("续传镜像上传", "app_name", app.Name, "upload_id", existing.ID, "offset", existing.ReceivedSize)
+		response := toCLIImageUploadResponse(existing)
+		response.Resumed = true
+		return SendSuccess(c, response)
+	}
+
+	if err := os.MkdirAll(imageUploadDir, 0755); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to create upload directory")
+	}
+	version := strings.TrimSpace(req.Version)
+	if version == "" {
+		version = time.Now().Format("20060102150405")
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		description = "CLI upload " + version
+	}
+	upload := &models.ImageUpload{
+		ApplicationID: app.ID,
+		FileName:      req.FileName,
+		TotalSize:     req.TotalSize,
+		SHA256:        req.SHA256,
+		Version:       version,
+		Description:   description,
+	}
+	if err := models.CreateImageUpload(upload); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to create upload")
+	}
+
+	logman.Info("创建镜像分块上传", "app_name", app.Name, "upload_id", upload.ID, "file_name", upload.FileName, "total_size", upload.TotalSize)
+	return SendCreated(c, toCLIImageUploadResponse(upload))
+}
+
+// GetCLIImageUpload returns the status and next offset of a chunked upload
+// Endpoint: GET /api/cli/apps/by-name/:appName/uploads/:uploadId
+func GetCLIImageUpload(c echo.Context) error {
+	_, upload, err := getCLIImageUpload(c)
+	if err != nil {
+		return err
+	}
+	if upload.Status == models.ImageUploadStatusUploading {
+		syncImageUploadSize(upload)
+	}
+	return SendSuccess(c, toCLIImageUploadResponse(upload))
+}
+
+// PutCLIImageUploadChunk appends a chunk at ?offset=, verified with the X-Chunk-Sha256 header when present
+// Endpoint: PUT /api/cli/apps/by-name/:appName/uploads/:uploadId
+func PutCLIImageUploadChunk(c echo.Context) error {
+	_, upload, unlock, err := getLockedCLIImageUpload(c)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if upload.Status != models.ImageUploadStatusUploading {
+		return SendError(c, http.StatusConflict, "Upload is already completed")
+	}
+	offset, err := strconv.ParseInt(c.QueryParam("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return SendError(c, http.StatusBadRequest, "offset is required")
+	}
+	maxChunk := min(int64(imageUploadMaxChunk), upload.TotalSize-offset)
+
+	uploadUid := EncodeFriendlyID(PrefixImageUpload, upload.ID)
+	size, err := utils.AppendFileChunk(imageUploadPath(upload), offset, c.Request().Body, c.Request().Header.Get("X-Chunk-Sha256"), maxChunk)
+	if size != upload.ReceivedSize {
+		if updateErr := models.UpdateImageUploadReceivedSize(upload.ID, size); updateErr != nil {
+			logman.Warn("更新上传进度失败", "upload_id", upload.ID, "error", updateErr)
+		}
+	}
+	switch {
+	case errors.Is(err, utils.ErrChunkOffsetMismatch):
+		return SendError(c, http.StatusConflict, fmt.Sprintf("offset mismatch, expected %d", size))
+	case errors.Is(err, utils.ErrChunkChecksumMismatch):
+		return SendError(c, http.StatusUnprocessableEntity, "Chunk checksum mismatch")
+	case errors.Is(err, utils.ErrChunkTooLarge):
+		return SendError(c, http.StatusRequestEntityTooLarge, err.Error())
+	case err != nil:
+		logman.Error("写入上传分块失败", "upload_id", upload.ID, "offset", offset, "error", err)
+		return SendError(c, http.StatusInternalServerError, "Failed to save chunk")
+	}
+
+	upload.ReceivedSize = size
+	progress := int(size * 100 / upload.TotalSize)
+	SendUploadProgress(uploadUid, UploadStageUploading, fmt.Sprintf("已接收 %d/%d 字节", size, upload.TotalSize), progress, size, upload.TotalSize)
+	return SendSuccess(c, toCLIImageUploadResponse(upload))
+}
+
+// CompleteCLIImageUpload verifies the whole file and imports it as a release
+// Endpoint: POST /api/cli/apps/by-name/:appName/uploads/:uploadId/complete
+func CompleteCLIImageUpload(c echo.Context) error {
+	app, upload, unlock, err := getLockedCLIImageUpload(c)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if upload.Status == models.ImageUploadStatusCompleted {
+		return SendSuccess(c, toCLIImageUploadResponse(upload))
+	}
+	syncImageUploadSize(upload)
+	if upload.ReceivedSize != upload.TotalSize {
+		return SendError(c, http.StatusConflict, fmt.Sprintf("Upload is incomplete: received %d of %d bytes", upload.ReceivedSize, upload.TotalSize))
+	}
+
+	uploadUid := EncodeFriendlyID(PrefixImageUpload, upload.ID)
+	path := imageUploadPath(upload)
+	SendUploadProgress(uploadUid, UploadStageSaving, "校验文件", 100, upload.ReceivedSize, upload.TotalSize)
+	checksum, err := utils.FileSHA256(path)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to verify upload")
+	}
+	if checksum != upload.SHA256 {
+		// 数据已损坏，清空后让客户端从头上传
+		SendUploadError(uploadUid, "文件校验失败", fmt.Errorf("sha256 为 %s，与 %s 不一致", checksum, upload.SHA256))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logman.Error("删除校验失败的上传数据失败", "upload_id", upload.ID, "error", err)
+			return SendError(c, http.StatusInternalServerError, "Checksum mismatch, failed to reset upload")
+		}
+		if err := models.UpdateImageUploadReceivedSize(upload.ID, 0); err != nil {
+			logman.Error("重置上传进度失败", "upload_id", upload.ID, "error", err)
+			return SendError(c, http.StatusInternalServerError, "Checksum mismatch, failed to reset upload")
+		}
+		return SendError(c, http.StatusUnprocessableEntity, "Checksum mismatch, upload has been reset")
+	}
+
+	SendUploadProgress(uploadUid, UploadStageSaving, "导入镜像", 100, upload.ReceivedSize, upload.TotalSize)
+	release, err := importImageArchive(app, path, upload.FileName, upload.Version, upload.Description)
+	if err != nil {
+		SendUploadError(uploadUid, "导入镜像失败", err)
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+	if err := models.CompleteImageUpload(upload.ID, release.ID); err != nil {
+		logman.Error("更新上传状态失败", "upload_id", upload.ID, "error", err)
+	}
+	os.Remove(path)
+
+	upload.Status = models.ImageUploadStatusCompleted
+	upload.ReleaseID = &release.ID
+	SendUploadProgress(uploadUid, UploadStageCompleted, "镜像已导入，Release 已创建", 100, upload.ReceivedSize, upload.TotalSize)
+	return SendSuccess(c, toCLIImageUploadResponse(upload))
+}
+
+// DeleteCLIImageUpload cancels a chunked upload and removes the received data
+// Endpoint: DELETE /api/cli/apps/by-name/:appName/uploads/:uploadId
+func DeleteCLIImageUpload(c echo.Context) error {
+	_, upload, unlock, err := getLockedCLIImageUpload(c)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	removeImageUpload(upload)
+	return SendSuccess(c, nil)
+}
@@ -38,6 +38,24 @@ func validateApplicationTokenPermission(c echo.Context, applicationID uuid.UUID)
 	return nil
 }
 
+// getCLIApplication resolves the :appName route parameter and checks the application token permission
+func getCLIApplication(c echo.Context) (*models.Application, error) {
+	appName := c.Param("appName")
+	if appName == "" {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "appName is required")
+	}
+
+	app, err := models.GetApplicationByName(appName)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Application not found: "+appName)
+	}
+
+	if err := validateApplicationTokenPermission(c, app.ID); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
 // UploadProjectImage handles image upload for projects
 // Endpoint: POST /api/projects/{project_id}/images
 func UploadProjectImage(c echo.Context) error {
@@ -149,8 +167,11 @@ func CreateProjectDeployment(c echo.Context) error {
 	var req struct {
 		ImageID string                 `json:"image_id"`
 		AppName string                 `json:"app_name"`
+		Ref     string                 `json:"ref"` // 分支、标签或提交 SHA，为空时构建应用分支的最新提交
 		Source  string                 `json:"source"`
 		Config  map[string]interface{} `json:"config"`
+
+		FreezeOverrideReason string `json:"freeze_override_reason"` // 处于冻结窗口时强制部署的原因
 	}
 
 	if err := c.Bind(&req); err != nil {
@@ -184,7 +205,10 @@ func CreateProjectDeployment(c echo.Context) error {
 
 	// Create deployment request (for new build, set ReleaseID to nil)
 	deployReq := services.CreateDeploymentRequest{
-		ReleaseID: nil, // This will trigger a new build from latest
+		ReleaseID:            nil, // This will trigger a new build
+		GitRef:               req.Ref,
+		RequestedBy:          getDeploymentRequester(c),
+		FreezeOverrideReason: req.FreezeOverrideReason,
 	}
 
 	logman.Info("Creating deployment for project application", "project_id", projectID, "app_name", req.AppName, "app_id", app.ID)
@@ -193,7 +217,7 @@ func CreateProjectDeployment(c echo.Context) error {
 	deployment, err := deploymentOrchestrator.CreateDeployment(app.ID, deployReq)
 	if err != nil {
 		logman.Error("Failed to create deployment", "project_id", projectID, "app_name", req.AppName, "error", err)
-		return SendError(c, http.StatusInternalServerError, "Failed to create deployment: "+err.Error())
+		return SendError(c, deploymentErrorStatus(err), "Failed to create deployment: "+err.Error())
 	}
 
 	logman.Info("Deployment created successfully", "project_id", projectID, "app_name", req.AppName, "deployment_id", deployment.ID)
@@ -278,15 +302,28 @@ func GetDeploymentResult(c echo.Context) error {
 	switch deployment.Status {
 	case "success":
 		status = "SUCCESS"
-	case "failed":
+	case "failed", models.DeploymentStatusRejected, models.DeploymentStatusExpired:
 		status = "FAILED"
 	case "in_progress":
 		status = "RUNNING"
+	case models.DeploymentStatusAwaitingApproval:
+		status = "AWAITING_APPROVAL"
 	default:
 		status = "PENDING"
 	}
 
 	var errorMessage *string
+	switch deployment.Status {
+	case models.DeploymentStatusRejected:
+		message := fmt.Sprintf("Deployment rejected by %s", deployment.ReviewedBy)
+		if deployment.ReviewComment != "" {
+			message += ": " + deployment.ReviewComment
+		}
+		errorMessage = &message
+	case models.DeploymentStatusExpired:
+		message := "Deployment approval expired"
+		errorMessage = &message
+	}
 	if deployment.Status == "failed" {
 		// Extract error from log text if available
 		lines := strings.Split(deployment.LogText, "\n")
@@ -318,6 +355,14 @@ func GetDeploymentResult(c echo.Context) error {
 	if deployment.FinishedAt != nil {
 		response["finished_at"] = deployment.FinishedAt.Format(time.RFC3339)
 	}
+	if deployment.ApprovalExpiresAt != nil {
+		response["requested_by"] = deployment.RequestedBy
+		response["approval_expires_at"] = deployment.ApprovalExpiresAt.Format(time.RFC3339)
+	}
+	if deployment.ReviewedBy != "" {
+		response["reviewed_by"] = deployment.ReviewedBy
+		response["review_comment"] = deployment.ReviewComment
+	}
 
 	return SendSuccess(c, response)
 }
@@ -638,13 +683,38 @@ func UploadApplicationImage(c echo.Context) error {
 		return SendError(c, http.StatusInternalServerError, "Failed to save uploaded file")
 	}
 
+	release, err := importImageArchive(app, tempFilePath, file.Filename, version, description)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	response := map[string]interface{}{
+		"release_id":  EncodeFriendlyID(PrefixRelease, release.ID),
+		"release_uid": EncodeFriendlyID(PrefixRelease, release.ID),
+		"version":     version,
+		"description": description,
+		"image_size":  file.Size,
+		"image_name":  release.ImageName,
+		"status":      "success",
+		"app_name":    appName,
+		"app_id":      EncodeFriendlyID(PrefixApplication, app.ID),
+		"created_at":  release.CreatedAt.Format(time.RFC3339),
+	}
+
+	return SendSuccess(c, response)
+}
+
+// importImageArchive 用 podman load 导入镜像 tar 包，打上 <应用名>:<版本> 标签并创建 Release
+func importImageArchive(app *models.Application, tarPath, fileName, version, description string) (*models.Release, error) {
+	appName := app.Name
+
 	// Load image with podman
 	tempImageName := fmt.Sprintf("%s:%s", appName, version)
-	cmd := exec.Command("podman", "load", "-i", tempFilePath)
+	cmd := exec.Command("podman", "load", "-i", tarPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		logman.Error("Failed to load image", "error", err, "output", string(output))
-		return SendError(c, http.StatusInternalServerError, fmt.Sprintf("Failed to load image: %s", string(output)))
+		return nil, fmt.Errorf("Failed to load image: %s", string(output))
 	}
 
 	// Parse loaded image name
@@ -668,7 +738,7 @@ func UploadApplicationImage(c echo.Context) error {
 	buildSourceInfo := models.JSONB{
 		Data: map[string]interface{}{
 			"type":        "cli_upload",
-			"filename":    file.Filename,
+			"filename":    fileName,
 			"description": description,
 			"uploaded_at": time.Now().Format(time.RFC3339),
 		},
@@ -677,24 +747,11 @@ func UploadApplicationImage(c echo.Context) error {
 	release, err := models.CreateReleaseWithVersion(app.ID, version, finalImageName, buildSourceInfo, "success")
 	if err != nil {
 		logman.Error("Failed to create release", "app_id", app.ID, "error", err)
-		return SendError(c, http.StatusInternalServerError, "Failed to create release record")
+		return nil, fmt.Errorf("Failed to create release record")
 	}
 
 	logman.Info("Release created successfully", "app_name", appName, "release_id", release.ID, "image_name", finalImageName)
-
-	response := map[string]interface{}{
-		"release_id":  fmt.Sprintf("rel-%d", release.ID),
-		"version":     version,
-		"description": description,
-		"image_size":  file.Size,
-		"image_name":  finalImageName,
-		"status":      "success",
-		"app_name":    appName,
-		"app_id":      app.ID,
-		"created_at":  release.CreatedAt.Format(time.RFC3339),
-	}
-
-	return SendSuccess(c, response)
+	return release, nil
 }
 
 // CreateApplicationDeployment handles deployment creation for applications by name
@@ -721,6 +778,15 @@ func CreateApplicationDeployment(c echo.Context) error {
 		ReleaseUid string                 `json:"release_uid"`
 		Source     string                 `json:"source"`
 		Metadata   map[string]interface{} `json:"metadata"`
+
+		// 部署策略，参见 services.CreateDeploymentRequest
+		Strategy           string  `json:"strategy"`
+		CanaryWeight       int     `json:"canary_weight"`
+		CanaryStep         int     `json:"canary_step"`
+		CanaryMaxErrorRate float64 `json:"canary_max_error_rate"`
+		CanaryHealthPath   string  `json:"canary_health_path"`
+
+		FreezeOverrideReason string `json:"freeze_override_reason"` // 处于冻结窗口时强制部署的原因
 	}
 
 	if err := c.Bind(&req); err != nil {
@@ -749,7 +815,15 @@ func CreateApplicationDeployment(c echo.Context) error {
 
 	// Create deployment request with the decoded UUID
 	deployReq := services.CreateDeploymentRequest{
-		ReleaseID: &releaseID,
+		ReleaseID:          &releaseID,
+		Strategy:           req.Strategy,
+		CanaryWeight:       req.CanaryWeight,
+		CanaryStep:         req.CanaryStep,
+		CanaryMaxErrorRate: req.CanaryMaxErrorRate,
+		CanaryHealthPath:   req.CanaryHealthPath,
+		RequestedBy:        getDeploymentRequester(c),
+
+		FreezeOverrideReason: req.FreezeOverrideReason,
 	}
 
 	logman.Info("Creating deployment for application", "app_name", appName, "app_id", app.ID, "release_uid", req.ReleaseUid)
@@ -758,7 +832,7 @@ func CreateApplicationDeployment(c echo.Context) error {
 	deployment, err := deploymentOrchestrator.CreateDeployment(app.ID, deployReq)
 	if err != nil {
 		logman.Error("Failed to create deployment", "app_name", appName, "error", err)
-		return SendError(c, http.StatusInternalServerError, "Failed to create deployment: "+err.Error())
+		return SendError(c, deploymentErrorStatus(err), "Failed to create deployment: "+err.Error())
 	}
 
 	logman.Info("Deployment created successfully", "app_name", appName, "deployment_id", deployment.ID)
@@ -776,58 +850,12 @@ func CreateApplicationDeployment(c echo.Context) error {
 		UpdatedAt:      deployment.UpdatedAt,
 		SystemPort:     deployment.SystemPort,
 	}
+	setDeploymentApprovalFields(&response, deployment)
 
 	// Return 202 Accepted for async deployment
 	return c.JSON(http.StatusAccepted, response)
 }
 
-// ExportApplicationConfig exports application configuration as TOML
-// Endpoint: GET /api/apps/by-name/:appName/config/export
-func ExportApplicationConfig(c echo.Context) error {
-	appName := c.Param("appName")
-	if appName == "" {
-		return SendError(c, http.StatusBadRequest, "appName is required")
-	}
-
-	// Get application by name
-	app, err := models.GetApplicationByName(appName)
-	if err != nil {
-		return SendError(c, http.StatusNotFound, "Application not found: "+appName)
-	}
-
-	// Validate application token permission if using app token
-	if err := validateApplicationTokenPermission(c, app.ID); err != nil {
-		return err
-	}
-
-	// Generate TOML configuration
-	tomlConfig := fmt.Sprintf(`api_version = "webdeploy.io/v1"
-kind = "DeploymentSpec"
-
-# Application Configuration
-app_name = "%s"
-project = "project-%d"
-environment = "production"
-
-[container]
-publish_port = %d
-
-# Generated from Web UI configuration at %s
-# Modify as needed for your deployment requirements
-`,
-		app.Name,
-		app.ProjectID,
-		app.TargetPort,
-		time.Now().Format("2006-01-02 15:04:05"),
-	)
-
-	// Set response headers for file download
-	c.Response().Header().Set("Content-Type", "text/plain; charset=utf-8")
-	c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-orbitdeploy.toml"`, appName))
-
-	return c.String(http.StatusOK, tomlConfig)
-}
-
 // parseDeploymentID extracts numeric ID from CLI deployment ID format (deploy-123 -> 123)
 func parseDeploymentID(deploymentIDParam string) (uint, error) {
 	if deploymentIDParam == "" {
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// CLI Spec Handlers
+// 服务端应用 orbitdeploy.toml：校验、计算执行计划并使应用配置与文件一致
+
+// ApplyCLIApplicationSpec validates orbitdeploy.toml and reconciles the application to match it
+// Endpoint: POST /api/cli/apps/by-name/:appName/apply
+func ApplyCLIApplicationSpec(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	var req CLIApplySpecRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+	if strings.TrimSpace(req.Spec) == "" {
+		return SendError(c, http.StatusBadRequest, "spec is required")
+	}
+
+	spec, err := utils.ParseDeploymentSpec(req.Spec)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	project, err := models.GetProjectByID(app.ProjectID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get project")
+	}
+
+	// 卷挂载决定容器能访问主机上的哪些数据，应用令牌不能修改
+	if c.Get("auth_type") != "jwt" {
+		if plan, err := services.PlanApplicationSpec(app, project, spec); err == nil && plan.HasChanges("volume") {
+			return SendError(c, http.StatusForbidden, "Changing volumes requires a user login")
+		}
+	}
+
+	var plan *services.SpecPlan
+	if req.DryRun {
+		plan, err = services.PlanApplicationSpec(app, project, spec)
+	} else {
+		plan, err = services.ApplyApplicationSpec(app, project, spec)
+	}
+	if err != nil {
+		logman.Warn("应用 orbitdeploy.toml 失败", "app_name", app.Name, "dry_run", req.DryRun, "error", err)
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	response := CLIApplySpecResponse{
+		Changes:          make([]CLISpecChange, 0, len(plan.Changes)),
+		Warnings:         plan.Warnings,
+		Applied:          !req.DryRun,
+		RedeployRequired: plan.RedeployRequired,
+	}
+	for _, change := range plan.Changes {
+		response.Changes = append(response.Changes, CLISpecChange{
+			Resource: change.Resource,
+			Action:   change.Action,
+			Name:     change.Name,
+			Old:      change.Old,
+			New:      change.New,
+		})
+	}
+	return SendSuccess(c, response)
+}
+
+// ExportApplicationConfig exports the application configuration as orbitdeploy.toml
+// Endpoint: GET /api/cli/apps/by-name/:appName/config/export
+func ExportApplicationConfig(c echo.Context) error {
+	app, err := getCLIApplication(c)
+	if err != nil {
+		return err
+	}
+
+	spec, secretKeys, err := services.ExportApplicationSpec(app)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+	content, err := utils.MarshalDeploymentSpec(spec)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	// 密钥变量不导出，apply 也不会删除它们
+	if len(secretKeys) > 0 {
+		content = fmt.Sprintf("# 密钥变量未导出，请使用 orbitctl env set --secret 管理: %s\n\n", strings.Join(secretKeys, ", ")) + content
+	}
+
+	c.Response().Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-orbitdeploy.toml"`, app.Name))
+	return c.String(http.StatusOK, content)
+}
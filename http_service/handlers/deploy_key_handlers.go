@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Deploy Key Handlers
+// 部署密钥用于拉取 git@host:owner/repo.git 形式的仓库，API 只返回公钥
+
+// GetDeployKeyHandler returns the public half of an application's deploy key
+func GetDeployKeyHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+
+	key, err := models.GetDeployKeyByApplicationID(appID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return SendError(c, http.StatusNotFound, "Deploy key not found")
+		}
+		return SendError(c, http.StatusInternalServerError, "Failed to get deploy key")
+	}
+
+	return SendSuccess(c, toDeployKeyResponse(key))
+}
+
+// GenerateDeployKeyHandler generates or rotates an application's deploy key
+func GenerateDeployKeyHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	key, err := services.GenerateApplicationDeployKey(application)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return SendCreated(c, toDeployKeyResponse(key))
+}
+
+// DeleteDeployKeyHandler deletes an application's deploy key
+func DeleteDeployKeyHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+
+	if err := models.DeleteDeployKey(appID); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to delete deploy key")
+	}
+
+	return SendSuccess(c, map[string]string{"message": "Deploy key deleted"})
+}
+
+// PinKnownHostsHandler pins the SSH host keys of the application's repository host
+func PinKnownHostsHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	var req PinKnownHostsRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	key, err := services.PinApplicationKnownHosts(application, req.KnownHosts)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	return SendSuccess(c, toDeployKeyResponse(key))
+}
+
+func toDeployKeyResponse(key *models.DeployKey) *DeployKeyResponse {
+	fingerprints := []string{}
+	if key.KnownHosts != "" {
+		if parsed, err := utils.KnownHostsFingerprints(key.KnownHosts); err == nil {
+			fingerprints = parsed
+		}
+	}
+	return &DeployKeyResponse{
+		PublicKey:        key.PublicKey,
+		Fingerprint:      key.Fingerprint,
+		KnownHosts:       key.KnownHosts,
+		HostFingerprints: fingerprints,
+		CreatedAt:        key.CreatedAt,
+		UpdatedAt:        key.UpdatedAt,
+	}
+}
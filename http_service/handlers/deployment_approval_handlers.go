@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Deployment Approval Handlers
+// 受保护应用的部署创建后处于 awaiting_approval 状态，需要登录用户批准后才会执行；应用令牌不能审批，
+// 发起人不能审批自己的部署，应用设置了审批人时只有审批人可以批准或拒绝
+
+// setDeploymentApprovalFields copies the approval record of a deployment to its response
+func setDeploymentApprovalFields(resp *DeploymentResponse, d *models.Deployment) {
+	resp.RequestedBy = d.RequestedBy
+	resp.ApprovalExpiresAt = d.ApprovalExpiresAt
+	resp.ReviewedBy = d.ReviewedBy
+	resp.ReviewedAt = d.ReviewedAt
+	resp.ReviewComment = d.ReviewComment
+	resp.FreezeOverrideReason = d.FreezeOverrideReason
+}
+
+// reviewDeploymentFunc 批准或拒绝部署
+type reviewDeploymentFunc func(deploymentID uuid.UUID, reviewer string, req *ReviewDeploymentRequest) (*models.Deployment, error)
+
+// NewApproveDeploymentHandler approves a deployment awaiting approval and starts it
+// Endpoint: POST /api/deployments/:deploymentId/approve
+func NewApproveDeploymentHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return reviewDeploymentHandlerImpl(c, func(deploymentID uuid.UUID, reviewer string, req *ReviewDeploymentRequest) (*models.Deployment, error) {
+			return deploymentOrchestrator.ApproveDeployment(deploymentID, reviewer, req.Comment, req.FreezeOverrideReason)
+		})
+	}
+}
+
+// NewRejectDeploymentHandler rejects a deployment awaiting approval
+// Endpoint: POST /api/deployments/:deploymentId/reject
+func NewRejectDeploymentHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return reviewDeploymentHandlerImpl(c, func(deploymentID uuid.UUID, reviewer string, req *ReviewDeploymentRequest) (*models.Deployment, error) {
+			return deploymentOrchestrator.RejectDeployment(deploymentID, reviewer, req.Comment)
+		})
+	}
+}
+
+// reviewDeploymentHandlerImpl 是批准和拒绝部署共用的实现，审批人取自登录用户
+func reviewDeploymentHandlerImpl(c echo.Context, review reviewDeploymentFunc) error {
+	deploymentID, err := DecodeFriendlyID(PrefixDeployment, c.Param("deploymentId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid deployment UID")
+	}
+	reviewer, _ := c.Get("username").(string)
+	if reviewer == "" {
+		return SendError(c, http.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req ReviewDeploymentRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	deployment, err := review(deploymentID, reviewer, &req)
+	switch {
+	case errors.Is(err, services.ErrDeploymentNotAwaitingApproval), errors.Is(err, services.ErrDeploymentApprovalExpired),
+		errors.Is(err, services.ErrDeploymentFrozen):
+		return SendError(c, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrDeploymentSelfReview), errors.Is(err, services.ErrDeploymentReviewerNotAllowed):
+		return SendError(c, http.StatusForbidden, err.Error())
+	case err != nil:
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	resp := DeploymentResponse{
+		Uid:            EncodeFriendlyID(PrefixDeployment, deployment.ID),
+		ApplicationUid: EncodeFriendlyID(PrefixApplication, deployment.ApplicationID),
+		ReleaseUid:     EncodeFriendlyID(PrefixRelease, deployment.ReleaseID),
+		Status:         deployment.Status,
+		LogText:        deployment.LogText,
+		StartedAt:      deployment.StartedAt,
+		FinishedAt:     deployment.FinishedAt,
+		CreatedAt:      deployment.CreatedAt,
+		UpdatedAt:      deployment.UpdatedAt,
+		SystemPort:     deployment.SystemPort,
+	}
+	setDeploymentApprovalFields(&resp, deployment)
+	return SendSuccess(c, resp)
+}
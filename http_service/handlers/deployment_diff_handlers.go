@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Deployment Diff Handlers
+// 比较同一应用的两次部署，供 orbitctl diff 排查“这次部署改了什么”
+
+// resolveDiffDeployment 解析比较的部署：部署 UID、Release UID（该 Release 最近一次部署）、
+// latest（最近一次成功部署）或 previous（再前一次成功部署）
+func resolveDiffDeployment(app *models.Application, ref string) (*models.Deployment, error) {
+	ref = strings.TrimSpace(ref)
+	switch {
+	case ref == "latest" || ref == "previous":
+		deployments, err := models.ListSuccessfulDeploymentsByAppID(app.ID, 2)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to list deployments")
+		}
+		index := 0
+		if ref == "previous" {
+			index = 1
+		}
+		if len(deployments) <= index {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "No "+ref+" successful deployment")
+		}
+		return deployments[index], nil
+	case strings.HasPrefix(ref, PrefixRelease):
+		releaseID, err := DecodeFriendlyID(PrefixRelease, ref)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid release UID: "+ref)
+		}
+		deployment, err := models.GetLatestDeploymentOfRelease(releaseID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "Release has never been deployed: "+ref)
+		}
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to get deployment of release")
+		}
+		if deployment.ApplicationID != app.ID {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "Release not found: "+ref)
+		}
+		return deployment, nil
+	default:
+		deploymentID, err := DecodeFriendlyID(PrefixDeployment, ref)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid deployment UID: "+ref)
+		}
+		deployment, err := models.GetDeploymentByID(deploymentID)
+		if err != nil || deployment.ApplicationID != app.ID {
+			return nil, echo.NewHTTPError(http.StatusNotFound, "Deployment not found: "+ref)
+		}
+		return deployment, nil
+	}
+}
+
+// toCLIDeploymentDiffTarget converts one side of a deployment diff to its response
+func toCLIDeploymentDiffTarget(deployment *models.Deployment, release *models.Release, commitSHA string) CLIDeploymentDiffTarget {
+	return CLIDeploymentDiffTarget{
+		DeploymentUid: EncodeFriendlyID(PrefixDeployment, deployment.ID),
+		ReleaseUid:    EncodeFriendlyID(PrefixRelease, release.ID),
+		Version:       release.Version,
+		ImageName:     release.ImageName,
+		ImageDigest:   release.ImageDigest,
+		CommitSHA:     commitSHA,
+		Status:        deployment.Status,
+		DeployedAt:    deployment.CreatedAt,
+	}
+}
+
+// NewDiffCLIDeploymentsHandler compares two deployments of an application
+// Endpoint: GET /api/cli/apps/by-name/:appName/deployments/diff?from=&to=
+func NewDiffCLIDeploymentsHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		app, err := getCLIApplication(c)
+		if err != nil {
+			return err
+		}
+		fromRef, toRef := c.QueryParam("from"), c.QueryParam("to")
+		if fromRef == "" {
+			fromRef = "previous"
+		}
+		if toRef == "" {
+			toRef = "latest"
+		}
+		from, err := resolveDiffDeployment(app, fromRef)
+		if err != nil {
+			return err
+		}
+		to, err := resolveDiffDeployment(app, toRef)
+		if err != nil {
+			return err
+		}
+
+		diff, err := deploymentOrchestrator.DiffDeployments(app, from, to)
+		if err != nil {
+			return SendError(c, http.StatusInternalServerError, err.Error())
+		}
+
+		response := CLIDeploymentDiffResponse{
+			From:            toCLIDeploymentDiffTarget(diff.From, diff.FromRelease, diff.FromCommit),
+			To:              toCLIDeploymentDiffTarget(diff.To, diff.ToRelease, diff.ToCommit),
+			ImageChanged:    diff.FromRelease.ImageName != diff.ToRelease.ImageName || diff.FromRelease.ImageDigest != diff.ToRelease.ImageDigest,
+			QuadletRecorded: diff.QuadletRecorded,
+			QuadletChanged:  utils.LineDiffChanged(diff.Quadlet),
+			Quadlet:         make([]CLIDeploymentDiffLine, 0, len(diff.Quadlet)),
+			ConfigRecorded:  diff.ConfigRecorded,
+			Settings:        make([]CLIDeploymentDiffSetting, 0, len(diff.Settings)),
+			RoutingsAdded:   make([]CLIDeploymentDiffRouting, 0, len(diff.RoutingsAdded)),
+			RoutingsRemoved: make([]CLIDeploymentDiffRouting, 0, len(diff.RoutingsRemoved)),
+			Commits: CLIDeploymentDiffCommits{
+				Rollback:   diff.Rollback,
+				Total:      diff.TotalCommits,
+				Commits:    make([]CLIDeploymentDiffCommit, 0, len(diff.Commits)),
+				CompareURL: diff.CompareURL,
+				Error:      diff.CommitsError,
+			},
+		}
+		for _, commit := range diff.Commits {
+			response.Commits.Commits = append(response.Commits.Commits, CLIDeploymentDiffCommit{SHA: commit.SHA, Message: commit.Message, Author: commit.Author, URL: commit.URL})
+		}
+		response.EnvAdded, response.EnvRemoved, response.EnvChanged = toCLIEnvironmentDiffEntries(diff.Env, diff.EnvBefore, diff.EnvAfter, diff.EnvSecrets)
+		for _, line := range diff.Quadlet {
+			response.Quadlet = append(response.Quadlet, CLIDeploymentDiffLine{Op: line.Op, Text: line.Text})
+		}
+		for _, setting := range diff.Settings {
+			response.Settings = append(response.Settings, CLIDeploymentDiffSetting{Field: setting.Field, Old: setting.Old, New: setting.New})
+		}
+		for _, routing := range diff.RoutingsAdded {
+			response.RoutingsAdded = append(response.RoutingsAdded, CLIDeploymentDiffRouting{DomainName: routing.DomainName, HostPort: routing.HostPort})
+		}
+		for _, routing := range diff.RoutingsRemoved {
+			response.RoutingsRemoved = append(response.RoutingsRemoved, CLIDeploymentDiffRouting{DomainName: routing.DomainName, HostPort: routing.HostPort})
+		}
+
+		return SendSuccess(c, response)
+	}
+}
@@ -42,6 +42,7 @@ func createDeploymentHandlerImpl(c echo.Context, deploymentOrchestrator *service
 		return SendError(c, http.StatusBadRequest, "无效的请求体")
 	}
 	logman.Info("请求体绑定成功")
+	req.RequestedBy = getDeploymentRequester(c)
 
 	// 直接使用从外部注入的、早已创建好的 deploymentOrchestrator 实例
 	// 不再需要在这里 New()
@@ -49,7 +50,7 @@ func createDeploymentHandlerImpl(c echo.Context, deploymentOrchestrator *service
 	deployment, err := deploymentOrchestrator.CreateDeployment(appID, req)
 	if err != nil {
 		logman.Error("创建部署失败", "app_id", appID, "error", err)
-		return SendError(c, http.StatusInternalServerError, "创建部署失败: "+err.Error())
+		return SendError(c, deploymentErrorStatus(err), "创建部署失败: "+err.Error())
 	}
 	logman.Info("部署创建成功", "deployment_id", deployment.ID)
 
@@ -95,6 +96,7 @@ func ListDeploymentsByAppHandler(c echo.Context) error {
 			UpdatedAt:      d.UpdatedAt,
 			SystemPort:     d.SystemPort,
 		}
+		setDeploymentApprovalFields(&resp, d)
 
 		if d.Release.ID != uuid.Nil {
 			if d.Release.Version != "" {
@@ -228,6 +230,7 @@ func GetDeploymentHandler(c echo.Context) error {
 		CreatedAt:      deployment.CreatedAt,
 		UpdatedAt:      deployment.UpdatedAt,
 	}
+	setDeploymentApprovalFields(&resp, &deployment)
 
 	// Populate version and other fields from Release
 	if deployment.Release.ID != uuid.Nil {
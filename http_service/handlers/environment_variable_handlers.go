@@ -31,6 +31,12 @@ func CreateEnvironmentVariableHandler(c echo.Context) error {
 	if err != nil {
 		return SendError(c, http.StatusInternalServerError, "Failed to create environment variable")
 	}
+	if envVar.IsBuildTime != req.IsBuildTime {
+		if err := models.SetEnvironmentVariableBuildTime(envVar.ID, req.IsBuildTime); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to create environment variable")
+		}
+		envVar.IsBuildTime = req.IsBuildTime
+	}
 
 	return SendCreated(c, toEnvironmentVariableResponse(envVar))
 }
@@ -78,6 +84,12 @@ func UpdateEnvironmentVariableHandler(c echo.Context) error {
 	if err != nil {
 		return SendError(c, http.StatusInternalServerError, "Failed to update environment variable")
 	}
+	if envVar.IsBuildTime != req.IsBuildTime {
+		if err := models.SetEnvironmentVariableBuildTime(envVar.ID, req.IsBuildTime); err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to update environment variable")
+		}
+		envVar.IsBuildTime = req.IsBuildTime
+	}
 
 	return SendSuccess(c, toEnvironmentVariableResponse(envVar))
 }
@@ -114,6 +126,7 @@ func toEnvironmentVariableResponse(envVar *models.EnvironmentVariable) *Environm
 		Key:            envVar.Key,
 		Value:          value,
 		IsEncrypted:    envVar.IsEncrypted,
+		IsBuildTime:    envVar.IsBuildTime,
 		CreatedAt:      envVar.CreatedAt,
 		UpdatedAt:      envVar.UpdatedAt,
 	}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Deploy Freeze Window Handlers
+// 冻结窗口内创建部署（包括自动部署和定时部署）需要填写强制部署的原因，否则返回 409
+
+// deploymentErrorStatus 处于冻结窗口时返回 409，其他错误为 500
+func deploymentErrorStatus(err error) int {
+	if errors.Is(err, services.ErrDeploymentFrozen) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// toFreezeWindowResponse converts a freeze window to its response, computing whether it is active at now
+func toFreezeWindowResponse(w *models.DeployFreezeWindow, now time.Time) FreezeWindowResponse {
+	resp := FreezeWindowResponse{
+		Uid:             EncodeFriendlyID(PrefixFreezeWindow, w.ID),
+		ProjectUid:      EncodeFriendlyID(PrefixProject, w.ProjectID),
+		Name:            w.Name,
+		Reason:          w.Reason,
+		StartsAt:        w.Window.StartsAt,
+		EndsAt:          w.Window.EndsAt,
+		Cron:            w.Window.Cron,
+		DurationMinutes: w.Window.DurationMinutes,
+		Timezone:        w.Window.Timezone,
+		CreatedBy:       w.CreatedBy,
+		CreatedAt:       w.CreatedAt,
+	}
+	if w.ApplicationID != nil {
+		resp.ApplicationUid = EncodeFriendlyID(PrefixApplication, *w.ApplicationID)
+	}
+	if active, until := w.Window.ActiveAt(now); active {
+		resp.Active = true
+		resp.ActiveUntil = &until
+	}
+	return resp
+}
+
+// toFreezeWindowResponses converts a list of freeze windows to responses
+func toFreezeWindowResponses(windows []*models.DeployFreezeWindow) []FreezeWindowResponse {
+	now := time.Now()
+	responses := make([]FreezeWindowResponse, 0, len(windows))
+	for _, w := range windows {
+		responses = append(responses, toFreezeWindowResponse(w, now))
+	}
+	return responses
+}
+
+// ListFreezeWindowsHandler lists the freeze windows of a project, including application-level ones
+// Endpoint: GET /api/projects/:projectId/freeze-windows
+func ListFreezeWindowsHandler(c echo.Context) error {
+	projectID, err := DecodeFriendlyID(PrefixProject, c.Param("projectId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid project ID format")
+	}
+	windows, err := models.ListDeployFreezeWindowsByProjectID(projectID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list freeze windows")
+	}
+	return SendSuccess(c, toFreezeWindowResponses(windows))
+}
+
+// CreateFreezeWindowHandler creates a freeze window for a project, or for one of its applications
+// Endpoint: POST /api/projects/:projectId/freeze-windows
+func CreateFreezeWindowHandler(c echo.Context) error {
+	projectID, err := DecodeFriendlyID(PrefixProject, c.Param("projectId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid project ID format")
+	}
+	if _, err := models.GetProjectByID(projectID); err != nil {
+		return SendError(c, http.StatusNotFound, "Project not found")
+	}
+
+	var req FreezeWindowRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return SendError(c, http.StatusBadRequest, "name is required")
+	}
+
+	window := &models.DeployFreezeWindow{
+		ProjectID: projectID,
+		Name:      req.Name,
+		Reason:    strings.TrimSpace(req.Reason),
+		Window: utils.FreezeWindow{
+			StartsAt:        req.StartsAt,
+			EndsAt:          req.EndsAt,
+			Cron:            strings.TrimSpace(req.Cron),
+			DurationMinutes: req.DurationMinutes,
+			Timezone:        strings.TrimSpace(req.Timezone),
+		},
+	}
+	if err := window.Window.Validate(); err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if req.ApplicationUid != "" {
+		appID, err := DecodeFriendlyID(PrefixApplication, req.ApplicationUid)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid applicationUid")
+		}
+		app, err := models.GetApplicationByID(appID)
+		if err != nil || app.ProjectID != projectID {
+			return SendError(c, http.StatusNotFound, "Application not found in project")
+		}
+		window.ApplicationID = &app.ID
+	}
+	window.CreatedBy, _ = c.Get("username").(string)
+
+	if err := models.CreateDeployFreezeWindow(window); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to create freeze window")
+	}
+	return SendCreated(c, toFreezeWindowResponse(window, time.Now()))
+}
+
+// DeleteFreezeWindowHandler deletes a freeze window
+// Endpoint: DELETE /api/freeze-windows/:windowId
+func DeleteFreezeWindowHandler(c echo.Context) error {
+	windowID, err := DecodeFriendlyID(PrefixFreezeWindow, c.Param("windowId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid freeze window ID format")
+	}
+	if _, err := models.GetDeployFreezeWindowByID(windowID); err != nil {
+		return SendError(c, http.StatusNotFound, "Freeze window not found")
+	}
+	if err := models.DeleteDeployFreezeWindow(windowID); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to delete freeze window")
+	}
+	return SendSuccess(c, map[string]string{"message": "Freeze window deleted"})
+}
+
+// ListApplicationFreezeWindowsHandler lists the freeze windows that apply to an application
+// Endpoint: GET /api/apps/:appId/freeze-windows
+func ListApplicationFreezeWindowsHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	app, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+	windows, err := models.ListDeployFreezeWindowsForApplication(app.ProjectID, app.ID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list freeze windows")
+	}
+	return SendSuccess(c, toFreezeWindowResponses(windows))
+}
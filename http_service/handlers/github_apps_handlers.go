@@ -1,9 +1,6 @@
 package handlers
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -13,7 +10,10 @@ import (
 	"time"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
 )
 
 // GitHubAppManifest represents the GitHub App manifest structure
@@ -105,6 +105,8 @@ func GenerateGitHubAppManifest(c echo.Context) error {
 			"contents":      "read",
 			"metadata":      "read",
 			"pull_requests": "read",
+			"statuses":      "write", // 回写构建和部署的提交状态
+			"deployments":   "write", // 创建 GitHub Deployment 并更新部署状态
 		},
 		DefaultEvents: []string{
 			"push",
@@ -181,7 +183,7 @@ func HandleGitHubAppCallback(c echo.Context) error {
 		appCredentials.PEM,                   // PrivateKey
 		appCredentials.WebhookSecret,         // WebhookSecret
 		installationID,                       // InstallationID (if provided)
-		"contents:read,metadata:read,pull_requests:read", // Scopes
+		"contents:read,metadata:read,pull_requests:read,statuses:write,deployments:write", // Scopes
 	)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save GitHub App credentials: "+err.Error())
@@ -297,112 +299,86 @@ func InstallGitHubApp(c echo.Context) error {
 	})
 }
 
-// HandleGitHubWebhook handles incoming webhooks from GitHub
-func HandleGitHubWebhook(c echo.Context) error {
-	// Get the webhook payload
-	body, err := io.ReadAll(c.Request().Body)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
-	}
+// NewGitHubWebhookHandler handles webhooks sent to the shared GitHub App URL configured by the manifest.
+// The provider auth is the GitHub App whose webhook secret verifies the signature.
+func NewGitHubWebhookHandler(orchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := readWebhookBody(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read request body")
+		}
 
-	// Get the signature from headers
-	signature := c.Request().Header.Get("X-Hub-Signature-256")
-	if signature == "" {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Missing webhook signature")
-	}
+		signature := c.Request().Header.Get("X-Hub-Signature-256")
+		if signature == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Missing webhook signature")
+		}
 
-	// Get the event type
-	eventType := c.Request().Header.Get("X-GitHub-Event")
-	if eventType == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Missing event type")
-	}
+		providerAuths, err := models.ListProviderAuthsByPlatform("github")
+		if err != nil || len(providerAuths) == 0 {
+			return echo.NewHTTPError(http.StatusInternalServerError, "No GitHub provider auth found")
+		}
 
-	// Find the provider auth by platform (assuming only one GitHub app for now)
-	providerAuths, err := models.ListProviderAuthsByPlatform("github")
-	if err != nil || len(providerAuths) == 0 {
-		return echo.NewHTTPError(http.StatusInternalServerError, "No GitHub provider auth found")
-	}
-	providerAuth := providerAuths[0] // Use the first one
+		// 多个 GitHub App 共用同一个地址，以签名匹配发送方
+		var providerAuth *models.ProviderAuth
+		for _, pa := range providerAuths {
+			if utils.VerifyHMACSHA256(body, signature, pa.WebhookSecret) {
+				providerAuth = pa
+				break
+			}
+		}
+		if providerAuth == nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid webhook signature")
+		}
 
-	// Verify the signature using WebhookSecret
-	if !verifyWebhookSignature(body, signature, providerAuth.WebhookSecret) {
-		return echo.NewHTTPError(http.StatusUnauthorized, "Invalid webhook signature")
+		return handleVerifiedWebhook(c, orchestrator, providerAuth, body)
 	}
+}
 
-	// Parse the payload
+// handleGitHubInstallationEvent records the installation ID when the GitHub App is installed
+func handleGitHubInstallationEvent(providerAuth *models.ProviderAuth, body []byte) error {
 	var payload map[string]interface{}
 	if err := json.Unmarshal(body, &payload); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "Failed to parse payload")
 	}
 
-	// Handle installation event
-	if eventType == "installation" {
-		action, ok := payload["action"].(string)
-		if !ok {
-			return echo.NewHTTPError(http.StatusBadRequest, "Invalid installation payload")
-		}
-
-		fmt.Printf("Webhook event: action=%s, providerAuth ID=%d\n", action, providerAuth.ID)
-
-		if action == "created" || action == "new_permissions_accepted" {
-			installation, ok := payload["installation"].(map[string]interface{})
-			if !ok {
-				return echo.NewHTTPError(http.StatusBadRequest, "Invalid installation data")
-			}
-
-			installationIDFloat, ok := installation["id"].(float64)
-			if !ok {
-				return echo.NewHTTPError(http.StatusBadRequest, "Invalid installation ID")
-			}
-			installationID := uint(installationIDFloat)
-
-			fmt.Printf("Extracted InstallationID=%d from webhook\n", installationID)
-
-			// Update the InstallationID in database
-			_, err := models.UpdateProviderAuth(
-				providerAuth.ID,
-				providerAuth.ClientID,
-				providerAuth.ClientSecret,
-				providerAuth.RedirectURI,
-				providerAuth.Username,
-				providerAuth.AppPassword,
-				providerAuth.AppID,
-				providerAuth.Slug,
-				providerAuth.PrivateKey,
-				providerAuth.WebhookSecret,
-				installationID,
-				providerAuth.Scopes,
-				providerAuth.IsActive,
-			)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update installation ID: "+err.Error())
-			}
-
-			fmt.Printf("Updated InstallationID to %d for provider auth ID %d\n", installationID, providerAuth.ID)
-		}
+	action, ok := payload["action"].(string)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid installation payload")
 	}
-
-	// TODO: Handle other events like push, pull_request if needed
-
-	return SendSuccess(c, map[string]string{"message": "Webhook processed"})
-}
-
-// verifyWebhookSignature verifies the HMAC signature of the webhook
-func verifyWebhookSignature(payload []byte, signature, secret string) bool {
-	if secret == "" {
-		return false
+	if action != "created" && action != "new_permissions_accepted" {
+		return nil
 	}
 
-	// Remove "sha256=" prefix if present
-	if len(signature) > 7 && signature[:7] == "sha256=" {
-		signature = signature[7:]
+	installation, ok := payload["installation"].(map[string]interface{})
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid installation data")
 	}
+	installationIDFloat, ok := installation["id"].(float64)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid installation ID")
+	}
+	installationID := uint(installationIDFloat)
 
-	// Compute HMAC
-	mac := hmac.New(sha256.New, []byte(secret))
-	mac.Write(payload)
-	expectedMAC := hex.EncodeToString(mac.Sum(nil))
-
-	return hmac.Equal([]byte(signature), []byte(expectedMAC))
+	// Update the InstallationID in database
+	_, err := models.UpdateProviderAuth(
+		providerAuth.ID,
+		providerAuth.ClientID,
+		providerAuth.ClientSecret,
+		providerAuth.RedirectURI,
+		providerAuth.Username,
+		providerAuth.AppPassword,
+		providerAuth.AppID,
+		providerAuth.Slug,
+		providerAuth.PrivateKey,
+		providerAuth.WebhookSecret,
+		installationID,
+		providerAuth.Scopes,
+		providerAuth.IsActive,
+	)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update installation ID: "+err.Error())
+	}
 
+	logman.Info("已更新 GitHub App 安装 ID", "provider_auth_id", providerAuth.ID, "installation_id", installationID)
+	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/OrbitDeploy/OrbitDeploy/models"
 	"github.com/OrbitDeploy/OrbitDeploy/services"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -64,3 +65,15 @@ func GetUserIDFromContext(c echo.Context) (uuid.UUID, error) {
 	}
 	return userID, nil
 }
+
+// getDeploymentRequester returns who triggers a deployment: the username for JWT, or the token name and its creator for application tokens
+func getDeploymentRequester(c echo.Context) string {
+	if c.Get("auth_type") == "app_token" {
+		if token, ok := c.Get("appToken").(*models.ApplicationToken); ok {
+			return token.Requester()
+		}
+		return "token"
+	}
+	username, _ := c.Get("username").(string)
+	return username
+}
@@ -100,20 +100,24 @@ func decodeBase58(s string) ([]byte, error) {
 
 // Prefix mapping per type
 const (
-	PrefixProject      = "prj_"
-	PrefixApplication  = "app_"
-	PrefixRelease      = "rel_"
-	PrefixDeployment   = "dpl_"
-	PrefixEnvVar       = "env_"
-	PrefixRouting      = "rtg_"
-	PrefixBuildTask    = "bld_"
-	PrefixProviderAuth = "pav_"
-	PrefixAppToken     = "tok_"
-	PrefixGitHubToken  = "ght_"
-	PrefixUser         = "usr_"
-	PrefixSSHHost      = "ssh_"
-	PrefixDatabase     = "db_"
-	PrefixExample      = "ex_"
+	PrefixProject             = "prj_"
+	PrefixApplication         = "app_"
+	PrefixRelease             = "rel_"
+	PrefixDeployment          = "dpl_"
+	PrefixEnvVar              = "env_"
+	PrefixBuildSecret         = "bsc_"
+	PrefixRouting             = "rtg_"
+	PrefixBuildTask           = "bld_"
+	PrefixProviderAuth        = "pav_"
+	PrefixAppToken            = "tok_"
+	PrefixGitHubToken         = "ght_"
+	PrefixUser                = "usr_"
+	PrefixSSHHost             = "ssh_"
+	PrefixDatabase            = "db_"
+	PrefixExample             = "ex_"
+	PrefixImageUpload         = "upl_"
+	PrefixFreezeWindow        = "frz_"
+	PrefixScheduledDeployment = "sch_"
 )
 
 // EncodeFriendlyID returns prefix+base58(uuid_bytes)
@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// OCI Registry Handlers
+// 兼容 OCI Distribution 规范的推送接口：仓库名即应用名，使用应用令牌认证，
+// 例如 podman login orbit.example.com -u web -p <令牌> 后 podman push orbit.example.com/web:1.0。
+// 客户端先 HEAD 检查 blob，只上传缺少的层；推送标签的清单后自动创建 Release
+
+const (
+	ociMaxManifestSize = 4 << 20
+	ociUploadMaxAge    = 24 * time.Hour
+)
+
+// sendOCIError 按 OCI Distribution 规范返回错误。不返回 echo.HTTPError，避免 404 被静态文件中间件改写为前端页面
+func sendOCIError(c echo.Context, status int, code, message string) error {
+	return c.JSON(status, map[string]interface{}{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
+
+// sendOCIUnauthorized 返回 401 和 Basic 认证质询，客户端据此提示 podman login
+func sendOCIUnauthorized(c echo.Context, message string) error {
+	c.Response().Header().Set("WWW-Authenticate", `Basic realm="OrbitDeploy"`)
+	return sendOCIError(c, http.StatusUnauthorized, "UNAUTHORIZED", message)
+}
+
+// OCIRegistryAuthMiddleware 校验 Basic 认证的密码（或 Bearer 令牌）为应用令牌，
+// 令牌只能访问所属应用的仓库
+func OCIRegistryAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+
+		var token string
+		if _, password, ok := c.Request().BasicAuth(); ok {
+			token = password
+		} else if parts := strings.SplitN(c.Request().Header.Get("Authorization"), " ", 2); len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+			token = parts[1]
+		}
+		if token == "" {
+			return sendOCIUnauthorized(c, "需要使用应用令牌登录")
+		}
+
+		app, appToken, err := models.ValidateApplicationToken(token)
+		if err != nil {
+			return sendOCIUnauthorized(c, "应用令牌无效或已过期")
+		}
+		if name := c.Param("name"); name != "" && name != app.Name {
+			return sendOCIError(c, http.StatusForbidden, "DENIED", "令牌无权访问仓库 "+name)
+		}
+
+		c.Set("applicationID", app.ID)
+		c.Set("application", app)
+		c.Set("appToken", appToken)
+		c.Set("auth_type", "app_token")
+		return next(c)
+	}
+}
+
+// ociLayoutFromContext 打开当前应用的镜像存储
+func ociLayoutFromContext(c echo.Context) (*models.Application, *utils.OCILayout, error) {
+	app := c.Get("application").(*models.Application)
+	layout, err := services.OpenApplicationImageLayout(app)
+	if err != nil {
+		logman.Error("打开镜像存储失败", "app_name", app.Name, "error", err)
+		return nil, nil, err
+	}
+	return app, layout, nil
+}
+
+// sendOCIStorageError 将镜像存储的错误转换为 OCI 错误码
+func sendOCIStorageError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, utils.ErrDigestInvalid):
+		return sendOCIError(c, http.StatusBadRequest, "DIGEST_INVALID", err.Error())
+	case errors.Is(err, utils.ErrBlobUnknown):
+		return sendOCIError(c, http.StatusNotFound, "BLOB_UNKNOWN", err.Error())
+	case errors.Is(err, utils.ErrUploadUnknown):
+		return sendOCIError(c, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", err.Error())
+	case errors.Is(err, utils.ErrManifestUnknown):
+		return sendOCIError(c, http.StatusNotFound, "MANIFEST_UNKNOWN", err.Error())
+	case errors.Is(err, utils.ErrManifestBlobUnknown):
+		return sendOCIError(c, http.StatusBadRequest, "MANIFEST_BLOB_UNKNOWN", err.Error())
+	case errors.Is(err, utils.ErrManifestInvalid):
+		return sendOCIError(c, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+	case errors.Is(err, utils.ErrTagInvalid):
+		return sendOCIError(c, http.StatusBadRequest, "TAG_INVALID", err.Error())
+	default:
+		return sendOCIError(c, http.StatusInternalServerError, "UNKNOWN", err.Error())
+	}
+}
+
+// OCIBaseHandler GET /v2/，客户端用来检查接口版本和登录凭据
+func OCIBaseHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]interface{}{})
+}
+
+// OCIGetBlobHandler HEAD/GET /v2/:name/blobs/:digest
+func OCIGetBlobHandler(c echo.Context) error {
+	_, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	digest := c.Param("digest")
+	file, size, err := layout.OpenBlob(digest)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	defer file.Close()
+
+	header := c.Response().Header()
+	header.Set("Docker-Content-Digest", digest)
+	header.Set("Content-Length", strconv.FormatInt(size, 10))
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(http.StatusOK)
+	}
+	return c.Stream(http.StatusOK, "application/octet-stream", file)
+}
+
+func ociUploadLocation(name, id string) string {
+	return fmt.Sprintf("/v2/%s/blobs/uploads/%s", name, id)
+}
+
+// sendOCIUploadStatus 返回上传会话的地址和已接收的范围
+func sendOCIUploadStatus(c echo.Context, status int, id string, size int64) error {
+	header := c.Response().Header()
+	header.Set("Location", ociUploadLocation(c.Param("name"), id))
+	header.Set("Docker-Upload-UUID", id)
+	end := size - 1
+	if end < 0 {
+		end = 0
+	}
+	header.Set("Range", fmt.Sprintf("0-%d", end))
+	header.Set("Content-Length", "0")
+	return c.NoContent(status)
+}
+
+// sendOCIBlobCreated 返回已保存的 blob 地址
+func sendOCIBlobCreated(c echo.Context, digest string) error {
+	header := c.Response().Header()
+	header.Set("Location", fmt.Sprintf("/v2/%s/blobs/%s", c.Param("name"), digest))
+	header.Set("Docker-Content-Digest", digest)
+	header.Set("Content-Length", "0")
+	return c.NoContent(http.StatusCreated)
+}
+
+// OCIStartBlobUploadHandler POST /v2/:name/blobs/uploads/，带 digest 参数时为单次上传整个 blob。
+// 不支持跨仓库挂载，mount 参数被忽略并按普通上传处理
+func OCIStartBlobUploadHandler(c echo.Context) error {
+	app, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	if removed := layout.CleanupUploads(ociUploadMaxAge); removed > 0 {
+		logman.Info("清理过期的镜像上传会话", "app_name", app.Name, "count", removed)
+	}
+
+	id, err := layout.StartUpload()
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	digest := c.QueryParam("digest")
+	if digest == "" {
+		return sendOCIUploadStatus(c, http.StatusAccepted, id, 0)
+	}
+
+	if _, err := layout.AppendUpload(id, c.Request().Body); err != nil {
+		layout.CancelUpload(id)
+		return sendOCIStorageError(c, err)
+	}
+	if _, err := layout.CommitUpload(id, digest); err != nil {
+		layout.CancelUpload(id)
+		return sendOCIStorageError(c, err)
+	}
+	return sendOCIBlobCreated(c, digest)
+}
+
+// OCIGetBlobUploadHandler GET /v2/:name/blobs/uploads/:uuid，查询上传进度以便续传
+func OCIGetBlobUploadHandler(c echo.Context) error {
+	_, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	id := c.Param("uuid")
+	size, err := layout.UploadSize(id)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	return sendOCIUploadStatus(c, http.StatusNoContent, id, size)
+}
+
+// OCIPatchBlobUploadHandler PATCH /v2/:name/blobs/uploads/:uuid，追加一段数据。
+// 带 Content-Range 时起始位置必须等于已接收的字节数
+func OCIPatchBlobUploadHandler(c echo.Context) error {
+	_, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	id := c.Param("uuid")
+	current, err := layout.UploadSize(id)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	if contentRange := c.Request().Header.Get("Content-Range"); contentRange != "" {
+		start, _, _ := strings.Cut(contentRange, "-")
+		if offset, err := strconv.ParseInt(start, 10, 64); err != nil || offset != current {
+			c.Response().Header().Set("Range", fmt.Sprintf("0-%d", max(current-1, 0)))
+			return sendOCIError(c, http.StatusRequestedRangeNotSatisfiable, "BLOB_UPLOAD_INVALID", "上传范围与已接收的数据不连续")
+		}
+	}
+
+	size, err := layout.AppendUpload(id, c.Request().Body)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	return sendOCIUploadStatus(c, http.StatusAccepted, id, size)
+}
+
+// OCIFinishBlobUploadHandler PUT /v2/:name/blobs/uploads/:uuid?digest=，追加最后一段数据并校验摘要
+func OCIFinishBlobUploadHandler(c echo.Context) error {
+	_, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	id := c.Param("uuid")
+	digest := c.QueryParam("digest")
+	if err := utils.ValidateDigest(digest); err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	if _, err := layout.AppendUpload(id, c.Request().Body); err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	if _, err := layout.CommitUpload(id, digest); err != nil {
+		if errors.Is(err, utils.ErrDigestInvalid) {
+			layout.CancelUpload(id)
+		}
+		return sendOCIStorageError(c, err)
+	}
+	return sendOCIBlobCreated(c, digest)
+}
+
+// OCICancelBlobUploadHandler DELETE /v2/:name/blobs/uploads/:uuid
+func OCICancelBlobUploadHandler(c echo.Context) error {
+	_, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	if err := layout.CancelUpload(c.Param("uuid")); err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// OCIGetManifestHandler HEAD/GET /v2/:name/manifests/:reference
+func OCIGetManifestHandler(c echo.Context) error {
+	_, layout, err := ociLayoutFromContext(c)
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+	content, mediaType, digest, err := layout.GetManifest(c.Param("reference"))
+	if err != nil {
+		return sendOCIStorageError(c, err)
+	}
+
+	header := c.Response().Header()
+	header.Set("Docker-Content-Digest", digest)
+	header.Set("Content-Length", strconv.Itoa(len(content)))
+	if c.Request().Method == http.MethodHead {
+		header.Set(echo.HeaderContentType, mediaType)
+		return c.NoContent(http.StatusOK)
+	}
+	return c.Blob(http.StatusOK, mediaType, content)
+}
+
+// NewOCIPutManifestHandler PUT /v2/:name/manifests/:reference。推送标签时导入镜像并创建 Release，
+// 应用启用了推送自动部署时同时创建部署。响应头中附带 Release 和部署的 ID 方便 CI 查询
+func NewOCIPutManifestHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		app, layout, err := ociLayoutFromContext(c)
+		if err != nil {
+			return sendOCIStorageError(c, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(c.Request().Body, ociMaxManifestSize+1))
+		if err != nil {
+			return sendOCIError(c, http.StatusBadRequest, "MANIFEST_INVALID", "读取镜像清单失败")
+		}
+		if len(content) > ociMaxManifestSize {
+			return sendOCIError(c, http.StatusRequestEntityTooLarge, "SIZE_INVALID", "镜像清单过大")
+		}
+
+		reference := c.Param("reference")
+		digest, err := layout.PutManifest(reference, c.Request().Header.Get(echo.HeaderContentType), content)
+		if err != nil {
+			return sendOCIStorageError(c, err)
+		}
+
+		header := c.Response().Header()
+		if !utils.IsDigestReference(reference) {
+			result, err := deploymentOrchestrator.HandlePushedImage(app, layout, reference, digest)
+			if err != nil {
+				return sendOCIError(c, http.StatusInternalServerError, "UNKNOWN", err.Error())
+			}
+			header.Set("OrbitDeploy-Release-Uid", EncodeFriendlyID(PrefixRelease, result.Release.ID))
+			if result.Deployment != nil {
+				header.Set("OrbitDeploy-Deployment-Uid", EncodeFriendlyID(PrefixDeployment, result.Deployment.ID))
+			}
+		}
+
+		header.Set("Location", fmt.Sprintf("/v2/%s/manifests/%s", c.Param("name"), digest))
+		header.Set("Docker-Content-Digest", digest)
+		header.Set("Content-Length", "0")
+		return c.NoContent(http.StatusCreated)
+	}
+}
@@ -186,28 +186,7 @@ func ListApplicationsByProjectHandler(c echo.Context) error {
 	// 使用新的 ApplicationDetailResponse 来构建返回结果
 	result := make([]ApplicationDetailResponse, 0, len(applications))
 	for _, app := range applications {
-		dto := ApplicationDetailResponse{
-			Uid:              EncodeFriendlyID(PrefixApplication, app.ID),
-			ProjectUid:       EncodeFriendlyID(PrefixProject, app.ProjectID),
-			Name:             app.Name,
-			Description:      app.Description,
-			RepoURL:          app.RepoURL,
-			TargetPort:       app.TargetPort,
-			Status:           app.Status,
-			Volumes:          app.Volumes,
-			ExecCommand:      app.ExecCommand,
-			AutoUpdatePolicy: app.AutoUpdatePolicy,
-			Branch:           app.Branch,
-			BuildDir:         app.BuildDir,  // Added mapping
-			BuildType:        app.BuildType, // Added mapping
-			CreatedAt:        app.CreatedAt,
-			UpdatedAt:        app.UpdatedAt,
-		}
-
-		if app.ActiveReleaseID != nil {
-			uid := EncodeFriendlyID(PrefixRelease, *app.ActiveReleaseID)
-			dto.ActiveReleaseUid = &uid
-		}
+		dto := toApplicationDetailResponse(&app)
 
 		if app.ActiveRelease != nil && app.ActiveRelease.ID != uuid.Nil {
 			dto.ActiveReleaseInfo = &ReleaseInfo{
@@ -236,28 +215,7 @@ func ListApplicationsByProjectNameHandler(c echo.Context) error {
 	// 使用 ApplicationDetailResponse 来构建返回结果
 	result := make([]ApplicationDetailResponse, 0, len(applications))
 	for _, app := range applications {
-		dto := ApplicationDetailResponse{
-			Uid:              EncodeFriendlyID(PrefixApplication, app.ID),
-			ProjectUid:       EncodeFriendlyID(PrefixProject, app.ProjectID),
-			Name:             app.Name,
-			Description:      app.Description,
-			RepoURL:          app.RepoURL,
-			TargetPort:       app.TargetPort,
-			Status:           app.Status,
-			Volumes:          app.Volumes,
-			ExecCommand:      app.ExecCommand,
-			AutoUpdatePolicy: app.AutoUpdatePolicy,
-			Branch:           app.Branch,
-			BuildDir:         app.BuildDir,  // Added mapping
-			BuildType:        app.BuildType, // Added mapping
-			CreatedAt:        app.CreatedAt,
-			UpdatedAt:        app.UpdatedAt,
-		}
-
-		if app.ActiveReleaseID != nil {
-			uid := EncodeFriendlyID(PrefixRelease, *app.ActiveReleaseID)
-			dto.ActiveReleaseUid = &uid
-		}
+		dto := toApplicationDetailResponse(app)
 
 		// 如果需要预加载 ActiveRelease，可以在这里添加类似逻辑
 		// 但为简化，直接使用模型数据
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/labstack/echo/v4"
 )
 
@@ -13,6 +14,7 @@ type ProviderAuthRequest struct {
 	ClientID       string `json:"clientId"`
 	ClientSecret   string `json:"clientSecret"`
 	RedirectURI    string `json:"redirectUri"`
+	BaseURL        string `json:"baseUrl"` // 自建 GitLab、Gitea、GitHub Enterprise 的地址，为空时使用公共实例
 	Username       string `json:"username"`
 	AppPassword    string `json:"appPassword"`
 	AppID          string `json:"appId"` // For GitHub Apps (required for GitHub platform)
@@ -31,10 +33,12 @@ type ProviderAuthResponse struct {
 	ClientID string `json:"clientId"`
 	// 注意：不返回敏感信息ClientSecret、AppPassword、PrivateKey
 	RedirectURI    string               `json:"redirectUri"`
+	BaseURL        string               `json:"baseUrl"`
 	Username       string               `json:"username"`
 	AppID          string               `json:"appId"`
 	Slug           string               `json:"slug"`
 	WebhookSecret  string               `json:"webhookSecret"`
+	WebhookPath    string               `json:"webhookPath"` // 在代码平台中配置的 webhook 地址（拼接服务地址）
 	InstallationID uint                 `json:"installationId"`
 	Scopes         string               `json:"scopes"`
 	IsActive       bool                 `json:"isActive"`
@@ -74,6 +78,23 @@ func CreateProviderAuthHandler(c echo.Context) error {
 		return SendError(c, http.StatusBadRequest, "不支持的平台类型: "+req.Platform)
 	}
 
+	baseURL, err := utils.NormalizeGitBaseURL(req.BaseURL)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if baseURL != "" && req.Platform == "bitbucket" {
+		return SendError(c, http.StatusBadRequest, "Bitbucket仅支持bitbucket.org，不能设置实例地址")
+	}
+
+	// 未指定 webhook 密钥时自动生成，用于校验该授权独立 webhook 地址收到的请求
+	if req.WebhookSecret == "" {
+		secret, err := models.GenerateRandomToken()
+		if err != nil {
+			return SendError(c, http.StatusInternalServerError, "生成Webhook密钥失败: "+err.Error())
+		}
+		req.WebhookSecret = secret
+	}
+
 	// 创建ProviderAuth
 	providerAuth, err := models.CreateProviderAuth(
 		req.Platform,
@@ -92,6 +113,10 @@ func CreateProviderAuthHandler(c echo.Context) error {
 	if err != nil {
 		return SendError(c, http.StatusInternalServerError, "创建ProviderAuth失败: "+err.Error())
 	}
+	if err := models.UpdateProviderAuthBaseURL(providerAuth.ID, baseURL); err != nil {
+		return SendError(c, http.StatusInternalServerError, "保存实例地址失败: "+err.Error())
+	}
+	providerAuth.BaseURL = baseURL
 
 	// 构造响应
 	response := &ProviderAuthResponse{
@@ -99,10 +124,12 @@ func CreateProviderAuthHandler(c echo.Context) error {
 		Platform:       providerAuth.Platform,
 		ClientID:       providerAuth.ClientID,
 		RedirectURI:    providerAuth.RedirectURI,
+		BaseURL:        providerAuth.BaseURL,
 		Username:       providerAuth.Username,
 		AppID:          providerAuth.AppID,
 		Slug:           providerAuth.Slug,
 		WebhookSecret:  providerAuth.WebhookSecret,
+		WebhookPath:    providerWebhookPath(providerAuth),
 		InstallationID: providerAuth.InstallationID,
 		Scopes:         providerAuth.Scopes,
 		IsActive:       providerAuth.IsActive,
@@ -138,10 +165,12 @@ func ListProviderAuthsHandler(c echo.Context) error {
 			Platform:       pa.Platform,
 			ClientID:       pa.ClientID,
 			RedirectURI:    pa.RedirectURI,
+			BaseURL:        pa.BaseURL,
 			Username:       pa.Username,
 			AppID:          pa.AppID,
 			Slug:           pa.Slug,
 			WebhookSecret:  pa.WebhookSecret,
+			WebhookPath:    providerWebhookPath(pa),
 			InstallationID: pa.InstallationID,
 			Scopes:         pa.Scopes,
 			IsActive:       pa.IsActive,
@@ -174,10 +203,12 @@ func GetProviderAuthHandler(c echo.Context) error {
 		Platform:       providerAuth.Platform,
 		ClientID:       providerAuth.ClientID,
 		RedirectURI:    providerAuth.RedirectURI,
+		BaseURL:        providerAuth.BaseURL,
 		Username:       providerAuth.Username,
 		AppID:          providerAuth.AppID,
 		Slug:           providerAuth.Slug,
 		WebhookSecret:  providerAuth.WebhookSecret,
+		WebhookPath:    providerWebhookPath(providerAuth),
 		InstallationID: providerAuth.InstallationID,
 		Scopes:         providerAuth.Scopes,
 		IsActive:       providerAuth.IsActive,
@@ -201,6 +232,20 @@ func UpdateProviderAuthHandler(c echo.Context) error {
 		return SendError(c, http.StatusBadRequest, "无效的请求格式: "+err.Error())
 	}
 
+	baseURL, err := utils.NormalizeGitBaseURL(req.BaseURL)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+	if baseURL != "" {
+		existing, err := models.GetProviderAuthByID(id)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "ProviderAuth不存在")
+		}
+		if existing.Platform == "bitbucket" {
+			return SendError(c, http.StatusBadRequest, "Bitbucket仅支持bitbucket.org，不能设置实例地址")
+		}
+	}
+
 	// 更新ProviderAuth
 	providerAuth, err := models.UpdateProviderAuth(
 		id,
@@ -220,6 +265,10 @@ func UpdateProviderAuthHandler(c echo.Context) error {
 	if err != nil {
 		return SendError(c, http.StatusInternalServerError, "更新ProviderAuth失败: "+err.Error())
 	}
+	if err := models.UpdateProviderAuthBaseURL(providerAuth.ID, baseURL); err != nil {
+		return SendError(c, http.StatusInternalServerError, "保存实例地址失败: "+err.Error())
+	}
+	providerAuth.BaseURL = baseURL
 
 	// 构造响应
 	response := &ProviderAuthResponse{
@@ -227,10 +276,12 @@ func UpdateProviderAuthHandler(c echo.Context) error {
 		Platform:       providerAuth.Platform,
 		ClientID:       providerAuth.ClientID,
 		RedirectURI:    providerAuth.RedirectURI,
+		BaseURL:        providerAuth.BaseURL,
 		Username:       providerAuth.Username,
 		AppID:          providerAuth.AppID,
 		Slug:           providerAuth.Slug,
 		WebhookSecret:  providerAuth.WebhookSecret,
+		WebhookPath:    providerWebhookPath(providerAuth),
 		InstallationID: providerAuth.InstallationID,
 		Scopes:         providerAuth.Scopes,
 		IsActive:       providerAuth.IsActive,
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// maxWebhookBodySize webhook 请求体的大小上限
+const maxWebhookBodySize = 25 << 20
+
+// ProviderWebhookResponse webhook 处理结果
+type ProviderWebhookResponse struct {
+	Event       string                   `json:"event"`
+	Deployments []WebhookDeploymentEntry `json:"deployments"`
+}
+
+// WebhookDeploymentEntry 单个应用对 webhook 事件的处理结果
+type WebhookDeploymentEntry struct {
+	ApplicationUid string `json:"applicationUid"`
+	Application    string `json:"application"`
+	DeploymentUid  string `json:"deploymentUid,omitempty"`
+	Skipped        string `json:"skipped,omitempty"`
+}
+
+// providerWebhookPath 返回 ProviderAuth 的 webhook 地址（相对于服务地址）
+func providerWebhookPath(providerAuth *models.ProviderAuth) string {
+	return "/api/providers/" + EncodeFriendlyID(PrefixProviderAuth, providerAuth.ID) + "/webhook"
+}
+
+// NewProviderWebhookHandler handles webhooks sent to a provider auth's own URL.
+// GitHub and Gitea sign the body with HMAC-SHA256, GitLab sends the secret as a token and
+// Bitbucket signs with X-Hub-Signature.
+func NewProviderWebhookHandler(orchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, err := DecodeFriendlyID(PrefixProviderAuth, c.Param("providerAuthId"))
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Provider auth not found")
+		}
+		providerAuth, err := models.GetProviderAuthByID(id)
+		if err != nil || !providerAuth.IsActive {
+			return SendError(c, http.StatusNotFound, "Provider auth not found")
+		}
+		if providerAuth.WebhookSecret == "" {
+			return SendError(c, http.StatusForbidden, "Webhook secret is not configured for this provider auth")
+		}
+
+		body, err := readWebhookBody(c)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Failed to read request body")
+		}
+
+		header := c.Request().Header
+		var verified bool
+		switch providerAuth.Platform {
+		case "github":
+			verified = utils.VerifyHMACSHA256(body, header.Get("X-Hub-Signature-256"), providerAuth.WebhookSecret)
+		case "gitea":
+			verified = utils.VerifyHMACSHA256(body, header.Get("X-Gitea-Signature"), providerAuth.WebhookSecret)
+		case "gitlab":
+			verified = utils.VerifyWebhookToken(header.Get("X-Gitlab-Token"), providerAuth.WebhookSecret)
+		case "bitbucket":
+			verified = utils.VerifyHMACSHA256(body, header.Get("X-Hub-Signature"), providerAuth.WebhookSecret)
+		default:
+			return SendError(c, http.StatusBadRequest, "Unsupported platform: "+providerAuth.Platform)
+		}
+		if !verified {
+			return SendError(c, http.StatusUnauthorized, "Invalid webhook signature")
+		}
+
+		return handleVerifiedWebhook(c, orchestrator, providerAuth, body)
+	}
+}
+
+// handleVerifiedWebhook 将已验证的 webhook 归一化为 GitEvent 并交给自动部署流程
+func handleVerifiedWebhook(c echo.Context, orchestrator *services.DeploymentOrchestrator, providerAuth *models.ProviderAuth, body []byte) error {
+	header := c.Request().Header
+
+	var eventName string
+	var events []*utils.GitEvent
+	var err error
+	switch providerAuth.Platform {
+	case "github":
+		eventName = header.Get("X-GitHub-Event")
+		if eventName == "installation" {
+			if err := handleGitHubInstallationEvent(providerAuth, body); err != nil {
+				return err
+			}
+			return SendSuccess(c, ProviderWebhookResponse{Event: eventName, Deployments: []WebhookDeploymentEntry{}})
+		}
+		events, err = singleGitEvent(utils.ParseGitHubEvent("github", eventName, body))
+	case "gitea":
+		eventName = header.Get("X-Gitea-Event")
+		events, err = singleGitEvent(utils.ParseGitHubEvent("gitea", eventName, body))
+	case "gitlab":
+		eventName = header.Get("X-Gitlab-Event")
+		events, err = singleGitEvent(utils.ParseGitLabEvent(eventName, body))
+	case "bitbucket":
+		eventName = header.Get("X-Event-Key")
+		events, err = utils.ParseBitbucketEvent(eventName, body)
+	}
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	response := ProviderWebhookResponse{Event: eventName, Deployments: []WebhookDeploymentEntry{}}
+	if orchestrator == nil {
+		return SendSuccess(c, response)
+	}
+
+	for _, event := range events {
+		results, err := orchestrator.HandleGitEvent(providerAuth, event)
+		if err != nil {
+			logman.Error("处理 webhook 事件失败", "provider", event.Provider, "error", err)
+			return SendError(c, http.StatusInternalServerError, "Failed to process webhook")
+		}
+		for _, result := range results {
+			entry := WebhookDeploymentEntry{
+				ApplicationUid: EncodeFriendlyID(PrefixApplication, result.ApplicationID),
+				Application:    result.ApplicationName,
+				Skipped:        result.Skipped,
+			}
+			if result.DeploymentID != nil {
+				entry.DeploymentUid = EncodeFriendlyID(PrefixDeployment, *result.DeploymentID)
+			}
+			response.Deployments = append(response.Deployments, entry)
+		}
+	}
+	return SendSuccess(c, response)
+}
+
+// singleGitEvent 将单个事件的解析结果转换为列表，忽略的事件返回空列表
+func singleGitEvent(event *utils.GitEvent, err error) ([]*utils.GitEvent, error) {
+	if err != nil || event == nil {
+		return nil, err
+	}
+	return []*utils.GitEvent{event}, nil
+}
+
+// readWebhookBody 读取 webhook 请求体，签名校验需要原始字节
+func readWebhookBody(c echo.Context) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(c.Request().Body, maxWebhookBodySize))
+}
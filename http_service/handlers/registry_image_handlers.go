@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// Registry Image Handlers
+// 应用可以从外部镜像仓库拉取镜像作为 Release，API 不返回镜像仓库密码
+
+// GetRegistryImageHandler returns the registry image source of an application
+func GetRegistryImageHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	return SendSuccess(c, toRegistryImageResponse(application.RegistryImage))
+}
+
+// UpdateRegistryImageHandler sets the registry image source and credentials of an application
+func UpdateRegistryImageHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Application not found")
+	}
+
+	var req RegistryImageRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+	ref, err := utils.ParseImageReference(req.ImageRef)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	// 密码留空时保留原值，清空用户名时一并清除密码；更换镜像仓库时必须重新输入，避免把原仓库的密码发给新仓库
+	username := strings.TrimSpace(req.Username)
+	var password *string
+	if req.Password != "" || username == "" {
+		password = &req.Password
+	} else if application.RegistryImage.RegistryPassword != "" {
+		if previous, err := utils.ParseImageReference(application.RegistryImage.ImageRef); err != nil || previous.Registry != ref.Registry {
+			return SendError(c, http.StatusBadRequest, "Password is required when changing the image registry")
+		}
+	}
+	if err := models.UpdateApplicationRegistryImage(appID, ref.String(), username, password); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to update registry image")
+	}
+
+	application, err = models.GetApplicationByID(appID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to get application")
+	}
+	return SendSuccess(c, toRegistryImageResponse(application.RegistryImage))
+}
+
+// DeleteRegistryImageHandler removes the registry image source, the application builds from its repository again
+func DeleteRegistryImageHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+
+	empty := ""
+	if err := models.UpdateApplicationRegistryImage(appID, "", "", &empty); err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to delete registry image")
+	}
+
+	return SendSuccess(c, map[string]string{"message": "Registry image source removed"})
+}
+
+// NewCheckRegistryImageHandler 检查镜像仓库是否有新的标签或摘要，有更新时创建 Release，deploy 为 true 时立即部署
+func NewCheckRegistryImageHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+		}
+		application, err := models.GetApplicationByID(appID)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Application not found")
+		}
+
+		var req CheckRegistryImageRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+
+		result, err := services.CheckRegistryImage(application)
+		if err != nil {
+			return SendError(c, http.StatusBadGateway, "检查镜像更新失败: "+err.Error())
+		}
+
+		response := &RegistryImageCheckResponse{
+			ImageRef: result.ImageRef,
+			Digest:   result.Digest,
+			Changed:  result.Changed,
+		}
+		if result.Release != nil {
+			response.ReleaseUid = EncodeFriendlyID(PrefixRelease, result.Release.ID)
+			if req.Deploy {
+				deployment, err := deploymentOrchestrator.CreateDeployment(appID, services.CreateDeploymentRequest{
+					ReleaseID:   &result.Release.ID,
+					RequestedBy: getDeploymentRequester(c),
+				})
+				if err != nil {
+					return SendError(c, deploymentErrorStatus(err), "创建部署失败: "+err.Error())
+				}
+				response.DeploymentUid = EncodeFriendlyID(PrefixDeployment, deployment.ID)
+			}
+		}
+		return SendSuccess(c, response)
+	}
+}
+
+func toRegistryImageResponse(settings models.RegistryImageSettings) *RegistryImageResponse {
+	response := &RegistryImageResponse{
+		ImageRef:    settings.ImageRef,
+		Username:    settings.RegistryUsername,
+		HasPassword: settings.RegistryPassword != "",
+	}
+	if ref, err := utils.ParseImageReference(settings.ImageRef); err == nil {
+		response.Registry = ref.Registry
+	}
+	return response
+}
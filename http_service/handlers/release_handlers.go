@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 )
@@ -66,6 +67,21 @@ func GetReleaseHandler(c echo.Context) error {
 	return SendSuccess(c, toReleaseResponse(release))
 }
 
+// GetReleaseBuildLogHandler returns the full build output of a release as plain text
+func GetReleaseBuildLogHandler(c echo.Context) error {
+	releaseID, err := DecodeFriendlyID(PrefixRelease, c.Param("releaseId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid release ID format")
+	}
+
+	release, err := models.GetReleaseByID(releaseID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Release not found")
+	}
+
+	return c.String(http.StatusOK, release.BuildLog)
+}
+
 // GetLastReleaseHandler gets the latest release for an application
 func GetLatestReleaseHandler(c echo.Context) error {
 	appIDStr := c.Param("appId")
@@ -93,13 +109,22 @@ func toReleaseResponse(r *models.Release) *ReleaseResponse {
 		}
 	}
 
-	return &ReleaseResponse{
+	response := &ReleaseResponse{
 		Uid:             EncodeFriendlyID(PrefixRelease, r.ID),
 		ApplicationUid:  EncodeFriendlyID(PrefixApplication, r.ApplicationID),
 		ImageName:       r.ImageName,
+		ImageDigest:     r.ImageDigest,
 		BuildSourceInfo: buildSourceInfo,
 		Status:          r.Status,
 		CreatedAt:       r.CreatedAt,
 		UpdatedAt:       r.UpdatedAt,
 	}
+
+	// 旧版本记录中的 commit_sha 可能是占位值 "latest"，只展示真实的提交
+	if sha, _ := buildSourceInfo["commit_sha"].(string); utils.IsCommitSHA(sha) {
+		response.CommitSHA = sha
+		response.CommitMessage, _ = buildSourceInfo["commit_message"].(string)
+		response.CommitURL, _ = buildSourceInfo["commit_url"].(string)
+	}
+	return response
 }
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Release Promotion Handlers
+// 在同一项目的应用之间（如 staging -> prod）提升 Release：目标应用直接使用同一个镜像，不重新构建
+
+// sendPromoteError 把提升的校验错误映射为 4xx，其他错误为 500
+func sendPromoteError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrPromoteSameApplication), errors.Is(err, services.ErrPromoteOtherProject):
+		return SendError(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrPromoteReleaseNotReady), errors.Is(err, services.ErrDeploymentFrozen):
+		return SendError(c, http.StatusConflict, err.Error())
+	default:
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// NewPromoteReleaseHandler creates a release on the target application with the same image, optionally deploying it
+// Endpoint: POST /api/releases/:releaseId/promote
+func NewPromoteReleaseHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		releaseID, err := DecodeFriendlyID(PrefixRelease, c.Param("releaseId"))
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid release ID format")
+		}
+		release, err := models.GetReleaseByID(releaseID)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Release not found")
+		}
+
+		var req PromoteReleaseRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+		targetID, err := DecodeFriendlyID(PrefixApplication, req.TargetApplicationUid)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid targetApplicationUid")
+		}
+		target, err := models.GetApplicationByID(targetID)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Target application not found")
+		}
+
+		result, err := deploymentOrchestrator.PromoteRelease(release, target, req.Deploy, getDeploymentRequester(c))
+		if err != nil {
+			return sendPromoteError(c, err)
+		}
+
+		response := PromoteReleaseResponse{
+			Release: toReleaseResponse(result.Release),
+			Created: result.Created,
+		}
+		if result.Deployment != nil {
+			response.DeploymentUid = EncodeFriendlyID(PrefixDeployment, result.Deployment.ID)
+		}
+		if result.Created {
+			return SendCreated(c, response)
+		}
+		return SendSuccess(c, response)
+	}
+}
+
+// NewPromoteCLIReleaseHandler promotes a release of another application in the same project to :appName
+// Endpoint: POST /api/cli/apps/by-name/:appName/promote
+func NewPromoteCLIReleaseHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// 应用令牌需要属于目标应用，来源应用只读取 Release
+		target, err := getCLIApplication(c)
+		if err != nil {
+			return err
+		}
+
+		var req CLIPromoteReleaseRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+		if req.From == "" {
+			return SendError(c, http.StatusBadRequest, "from is required")
+		}
+		// 只在目标应用所在的项目中查找来源应用，其他项目的同名应用不可见
+		source, err := models.GetApplicationByProjectIDAndName(target.ProjectID, req.From)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Application not found: "+req.From)
+		}
+
+		var release *models.Release
+		if req.ReleaseUid != "" {
+			releaseID, err := DecodeFriendlyID(PrefixRelease, req.ReleaseUid)
+			if err != nil {
+				return SendError(c, http.StatusBadRequest, "Invalid release_uid format")
+			}
+			release, err = models.GetReleaseByID(releaseID)
+			if err != nil || release.ApplicationID != source.ID {
+				return SendError(c, http.StatusNotFound, "Release not found in "+source.Name)
+			}
+		} else {
+			if source.ActiveReleaseID == nil {
+				return SendError(c, http.StatusConflict, source.Name+" has no active release to promote")
+			}
+			release, err = models.GetReleaseByID(*source.ActiveReleaseID)
+			if err != nil {
+				return SendError(c, http.StatusNotFound, "Release not found")
+			}
+		}
+
+		result, err := deploymentOrchestrator.PromoteRelease(release, target, req.Deploy, getDeploymentRequester(c))
+		if err != nil {
+			return sendPromoteError(c, err)
+		}
+
+		response := CLIPromoteReleaseResponse{
+			ReleaseUid:       EncodeFriendlyID(PrefixRelease, result.Release.ID),
+			SourceReleaseUid: EncodeFriendlyID(PrefixRelease, release.ID),
+			ImageName:        result.Release.ImageName,
+			Created:          result.Created,
+		}
+		if result.Deployment != nil {
+			response.DeploymentUid = EncodeFriendlyID(PrefixDeployment, result.Deployment.ID)
+		}
+		return SendSuccess(c, response)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Retention Handlers
+
+// NewRunRetentionHandler 立即按应用的保留策略清理过期的 Release、镜像和 unit，返回回收的磁盘空间
+// Endpoint: POST /api/apps/:appId/retention/run
+func NewRunRetentionHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid application UID")
+		}
+		application, err := models.GetApplicationByID(appID)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Application not found")
+		}
+
+		result, err := deploymentOrchestrator.ApplyRetention(application)
+		if errors.Is(err, services.ErrRetentionPolicyNotSet) {
+			return SendError(c, http.StatusBadRequest, err.Error())
+		}
+		if err != nil {
+			return SendError(c, http.StatusInternalServerError, err.Error())
+		}
+
+		return SendSuccess(c, RetentionRunResponse{
+			RemovedReleases: result.RemovedReleases,
+			RemovedImages:   result.RemovedImages,
+			RemovedUnits:    result.RemovedUnits,
+			ReclaimedBytes:  result.ReclaimedBytes,
+		})
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"log"
 	"net/http"
+	"sort"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
 	"github.com/OrbitDeploy/OrbitDeploy/services"
@@ -25,31 +26,17 @@ func CreateRoutingHandler(c echo.Context) error {
 		return SendError(c, http.StatusBadRequest, "Invalid request body")
 	}
 
-	// 先调用域名处理服务进行冲突检查和 Caddy 配置
-	message, cleanDomain, httpErr := services.ManageRouting(appID, req.DomainName, req.HostPort, "add")
+	// 冲突检查、DNS 预检和 Caddy 配置都成功后再创建数据库记录，失败时服务层回滚 Caddy 配置
+	routing, message, httpErr := services.CreateRouting(appID, req.DomainName, req.HostPort, req.IsActive)
 	if httpErr != nil {
-		log.Printf("域名处理服务失败，应用ID: %s, 域名: %s, 错误: %v", appID, req.DomainName, httpErr)
+		log.Printf("添加路由失败，应用ID: %s, 域名: %s, 错误: %v", appID, req.DomainName, httpErr)
 		return SendError(c, httpErr.Code, httpErr.Message.(string))
 	}
 	log.Printf("域名处理服务成功: %s", message)
 
-	// 所有检查和配置都成功后，再创建数据库记录
-	routing, err := models.CreateRouting(appID, cleanDomain, req.HostPort, req.IsActive)
-	if err != nil {
-		log.Printf("创建路由记录失败，应用ID: %s, 域名: %s, 错误: %v", appID, cleanDomain, err)
-		// 如果数据库创建失败，需要回滚 Caddy 配置
-		rollbackMessage, _, rollbackErr := services.ManageRouting(appID, cleanDomain, req.HostPort, "remove")
-		if rollbackErr != nil {
-			log.Printf("回滚 Caddy 配置失败: %v", rollbackErr)
-		} else {
-			log.Printf("回滚成功: %s", rollbackMessage)
-		}
-		return SendError(c, http.StatusInternalServerError, "Failed to create routing record")
-	}
-
 	return SendCreated(c, map[string]interface{}{
 		"routing":     toRoutingResponse(routing),
-		"cleanDomain": cleanDomain,
+		"cleanDomain": routing.DomainName,
 		"message":     message,
 	})
 }
@@ -73,6 +60,8 @@ func ListRoutingsByAppHandler(c echo.Context) error {
 	for _, r := range routings {
 		routingResponses = append(routingResponses, toRoutingResponse(r))
 	}
+	// 返回保存的证书过期时间，过期的记录在后台刷新，下次查询时生效
+	services.RefreshRoutingCertificateExpiries(routings)
 
 	log.Printf("成功获取应用路由列表，应用ID: %s, 路由数量: %d", appID, len(routings))
 	return SendSuccess(c, map[string]interface{}{
@@ -128,14 +117,188 @@ func DeleteRoutingHandler(c echo.Context) error {
 	})
 }
 
+// PreflightDomainHandler checks a domain's DNS records before a routing is added
+func PreflightDomainHandler(c echo.Context) error {
+	var req DomainPreflightRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	result, err := services.PreflightDomain(req.DomainName)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	return SendSuccess(c, toDomainPreflightResponse(result))
+}
+
+// CheckRoutingDNSHandler re-runs the DNS pre-flight check of an existing routing
+func CheckRoutingDNSHandler(c echo.Context) error {
+	routingIDStr := c.Param("routingId")
+	routingID, err := DecodeFriendlyID(PrefixRouting, routingIDStr)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid routing ID format")
+	}
+
+	routing, err := models.GetRoutingByID(routingID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Routing not found")
+	}
+
+	result, err := services.VerifyRoutingDNS(routing)
+	if err != nil {
+		log.Printf("DNS 预检失败，路由ID: %s, 错误: %v", routingID, err)
+		return SendError(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return SendSuccess(c, map[string]interface{}{
+		"routing":   toRoutingResponse(routing),
+		"preflight": toDomainPreflightResponse(result),
+	})
+}
+
+// GetRoutingTLSHandler returns the TLS settings of a routing
+func GetRoutingTLSHandler(c echo.Context) error {
+	routingIDStr := c.Param("routingId")
+	routingID, err := DecodeFriendlyID(PrefixRouting, routingIDStr)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid routing ID format")
+	}
+
+	routing, err := models.GetRoutingByID(routingID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Routing not found")
+	}
+
+	config, err := models.FindRoutingTLSConfig(routing.ID)
+	if err != nil {
+		log.Printf("获取证书设置失败，路由ID: %s, 错误: %v", routingID, err)
+		return SendError(c, http.StatusInternalServerError, "Failed to get TLS settings")
+	}
+
+	return SendSuccess(c, toRoutingTLSResponse(routing, config))
+}
+
+// UpdateRoutingTLSHandler uploads a custom certificate or configures ACME settings for a routing
+func UpdateRoutingTLSHandler(c echo.Context) error {
+	routingIDStr := c.Param("routingId")
+	routingID, err := DecodeFriendlyID(PrefixRouting, routingIDStr)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid routing ID format")
+	}
+
+	var req RoutingTLSRequest
+	if err := c.Bind(&req); err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid request body")
+	}
+
+	routing, err := models.GetRoutingByID(routingID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Routing not found")
+	}
+
+	config, err := services.ConfigureRoutingTLS(routing.ID, services.RoutingTLSSettings{
+		Mode:           req.Mode,
+		CertificatePEM: req.Certificate,
+		PrivateKeyPEM:  req.PrivateKey,
+		ACMEEmail:      req.ACMEEmail,
+		ACMEStaging:    req.ACMEStaging,
+		DNSProvider:    req.DNSProvider,
+		DNSCredentials: req.DNSCredentials,
+	})
+	if err != nil {
+		log.Printf("配置证书失败，路由ID: %s, 域名: %s, 错误: %v", routingID, routing.DomainName, err)
+		return SendError(c, http.StatusBadRequest, err.Error())
+	}
+
+	return SendSuccess(c, toRoutingTLSResponse(routing, config))
+}
+
+// DeleteRoutingTLSHandler resets a routing to automatic TLS
+func DeleteRoutingTLSHandler(c echo.Context) error {
+	routingIDStr := c.Param("routingId")
+	routingID, err := DecodeFriendlyID(PrefixRouting, routingIDStr)
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid routing ID format")
+	}
+
+	routing, err := models.GetRoutingByID(routingID)
+	if err != nil {
+		return SendError(c, http.StatusNotFound, "Routing not found")
+	}
+
+	if err := services.RemoveRoutingTLS(routing); err != nil {
+		log.Printf("删除证书设置失败，路由ID: %s, 错误: %v", routingID, err)
+		return SendError(c, http.StatusInternalServerError, "Failed to reset TLS settings")
+	}
+
+	return SendSuccess(c, toRoutingTLSResponse(routing, nil))
+}
+
 func toRoutingResponse(r *models.Routing) *RoutingResponse {
-	return &RoutingResponse{
+	resp := &RoutingResponse{
 		Uid:            EncodeFriendlyID(PrefixRouting, r.ID),
 		ApplicationUid: EncodeFriendlyID(PrefixApplication, r.ApplicationID),
 		DomainName:     r.DomainName,
 		HostPort:       r.HostPort,
 		IsActive:       r.IsActive,
+		TLSMode:        models.RoutingTLSModeAuto,
+		DNSStatus:      r.DNSStatus,
+		DNSCheckedAt:   r.DNSCheckedAt,
 		CreatedAt:      r.CreatedAt,
 		UpdatedAt:      r.UpdatedAt,
 	}
+
+	config, err := models.FindRoutingTLSConfig(r.ID)
+	if err != nil {
+		log.Printf("获取证书设置失败，路由ID: %s, 错误: %v", r.ID, err)
+	}
+	if config != nil {
+		resp.TLSMode = config.Mode
+	}
+	// 列表不连接 Caddy 读取证书，避免逐个路由阻塞，使用保存的过期时间
+	resp.CertificateExpiresAt = services.StoredRoutingCertificateExpiry(r, config)
+	resp.DNSStatusMessage = r.DNSStatusMessage
+
+	return resp
+}
+
+func toDomainPreflightResponse(result *services.DomainPreflightResult) *DomainPreflightResponse {
+	return &DomainPreflightResponse{
+		DomainName:           result.Domain,
+		Status:               result.Status,
+		Message:              result.Message,
+		CNAME:                result.CNAME,
+		ResolvedAddresses:    result.ResolvedAddresses,
+		ServerAddresses:      result.ServerAddresses,
+		VerificationRequired: result.VerificationRequired,
+		VerificationRecord:   result.VerificationRecord,
+		VerificationToken:    result.VerificationToken,
+		OwnershipVerified:    result.OwnershipVerified,
+	}
+}
+
+func toRoutingTLSResponse(r *models.Routing, config *models.RoutingTLSConfig) *RoutingTLSResponse {
+	resp := &RoutingTLSResponse{
+		RoutingUid: EncodeFriendlyID(PrefixRouting, r.ID),
+		DomainName: r.DomainName,
+		Mode:       models.RoutingTLSModeAuto,
+	}
+
+	if config != nil {
+		resp.Mode = config.Mode
+		resp.HasCustomCertificate = config.CertificatePEM != ""
+		resp.ACMEEmail = config.ACMEEmail
+		resp.ACMEStaging = config.ACMEStaging
+		resp.DNSProvider = config.DNSProvider
+		if credentials, err := config.GetDecryptedDNSCredentials(); err == nil {
+			for key := range credentials {
+				resp.DNSCredentialKeys = append(resp.DNSCredentialKeys, key)
+			}
+			sort.Strings(resp.DNSCredentialKeys)
+		}
+	}
+	resp.CertificateExpiresAt = services.GetRoutingCertificateExpiry(r, config)
+
+	return resp
 }
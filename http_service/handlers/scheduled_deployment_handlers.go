@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/services"
+	"github.com/labstack/echo/v4"
+)
+
+// Scheduled Deployment Handlers
+// 定时部署保存在数据库中，由 StartDeploymentScheduler 在计划时间创建部署
+
+// toScheduledDeploymentResponse converts a scheduled deployment to its response
+func toScheduledDeploymentResponse(s *models.ScheduledDeployment) ScheduledDeploymentResponse {
+	resp := ScheduledDeploymentResponse{
+		Uid:                  EncodeFriendlyID(PrefixScheduledDeployment, s.ID),
+		ApplicationUid:       EncodeFriendlyID(PrefixApplication, s.ApplicationID),
+		GitRef:               s.GitRef,
+		Strategy:             s.Strategy,
+		ScheduledAt:          s.ScheduledAt,
+		Status:               s.Status,
+		RequestedBy:          s.RequestedBy,
+		FreezeOverrideReason: s.FreezeOverrideReason,
+		Error:                s.Error,
+		StartedAt:            s.StartedAt,
+		CreatedAt:            s.CreatedAt,
+	}
+	if s.ReleaseID != nil {
+		resp.ReleaseUid = EncodeFriendlyID(PrefixRelease, *s.ReleaseID)
+	}
+	if s.DeploymentID != nil {
+		resp.DeploymentUid = EncodeFriendlyID(PrefixDeployment, *s.DeploymentID)
+	}
+	return resp
+}
+
+// NewCreateScheduledDeploymentHandler schedules a deployment of an application at a future time
+// Endpoint: POST /api/apps/:appId/scheduled-deployments
+func NewCreateScheduledDeploymentHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+		}
+		app, err := models.GetApplicationByID(appID)
+		if err != nil {
+			return SendError(c, http.StatusNotFound, "Application not found")
+		}
+
+		var req ScheduledDeploymentRequest
+		if err := c.Bind(&req); err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid request body")
+		}
+		scheduled := &models.ScheduledDeployment{
+			GitRef:               req.GitRef,
+			Strategy:             req.Strategy,
+			ScheduledAt:          req.ScheduledAt,
+			RequestedBy:          getDeploymentRequester(c),
+			FreezeOverrideReason: req.FreezeOverrideReason,
+		}
+		if req.ReleaseUid != "" {
+			releaseID, err := DecodeFriendlyID(PrefixRelease, req.ReleaseUid)
+			if err != nil {
+				return SendError(c, http.StatusBadRequest, "Invalid releaseUid")
+			}
+			release, err := models.GetReleaseByID(releaseID)
+			if err != nil || release.ApplicationID != app.ID {
+				return SendError(c, http.StatusNotFound, "Release not found")
+			}
+			scheduled.ReleaseID = &release.ID
+		}
+
+		if err := deploymentOrchestrator.ScheduleDeployment(app, scheduled); err != nil {
+			if errors.Is(err, services.ErrScheduleInPast) {
+				return SendError(c, http.StatusBadRequest, err.Error())
+			}
+			return SendError(c, deploymentErrorStatus(err), err.Error())
+		}
+		return SendCreated(c, toScheduledDeploymentResponse(scheduled))
+	}
+}
+
+// ListScheduledDeploymentsHandler lists the scheduled deployments of an application
+// Endpoint: GET /api/apps/:appId/scheduled-deployments
+func ListScheduledDeploymentsHandler(c echo.Context) error {
+	appID, err := DecodeFriendlyID(PrefixApplication, c.Param("appId"))
+	if err != nil {
+		return SendError(c, http.StatusBadRequest, "Invalid application ID format")
+	}
+	scheduled, err := models.ListScheduledDeploymentsByAppID(appID)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to list scheduled deployments")
+	}
+	responses := make([]ScheduledDeploymentResponse, 0, len(scheduled))
+	for _, s := range scheduled {
+		responses = append(responses, toScheduledDeploymentResponse(s))
+	}
+	return SendSuccess(c, responses)
+}
+
+// NewCancelScheduledDeploymentHandler cancels a scheduled deployment that has not started yet
+// Endpoint: DELETE /api/scheduled-deployments/:scheduleId
+func NewCancelScheduledDeploymentHandler(deploymentOrchestrator *services.DeploymentOrchestrator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		scheduleID, err := DecodeFriendlyID(PrefixScheduledDeployment, c.Param("scheduleId"))
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Invalid scheduled deployment ID format")
+		}
+		if _, err := models.GetScheduledDeploymentByID(scheduleID); err != nil {
+			return SendError(c, http.StatusNotFound, "Scheduled deployment not found")
+		}
+		if err := deploymentOrchestrator.CancelScheduledDeployment(scheduleID); err != nil {
+			if errors.Is(err, services.ErrScheduledDeploymentNotPending) {
+				return SendError(c, http.StatusConflict, err.Error())
+			}
+			return SendError(c, http.StatusInternalServerError, err.Error())
+		}
+		scheduled, err := models.GetScheduledDeploymentByID(scheduleID)
+		if err != nil {
+			return SendError(c, http.StatusInternalServerError, "Failed to load scheduled deployment")
+		}
+		return SendSuccess(c, toScheduledDeploymentResponse(scheduled))
+	}
+}
@@ -36,9 +36,55 @@ func UpdateSystemSettingHandler(c echo.Context) error {
 		}
 	}
 
+	// 应用基础域名和证书模式由服务层负责校验、规范化并同步 Caddy 配置
+	switch key {
+	case services.AppsBaseDomainSettingKey:
+		baseDomain, err := services.NewAppHostnameService().UpdateBaseDomain(payload.Value)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Failed to update apps base domain: "+err.Error())
+		}
+		return SendSuccess(c, map[string]string{"key": key, "value": baseDomain})
+	case services.AppsTLSModeSettingKey:
+		if err := services.NewAppHostnameService().UpdateTLSMode(payload.Value); err != nil {
+			return SendError(c, http.StatusBadRequest, "Failed to update apps TLS mode: "+err.Error())
+		}
+		return SendSuccess(c, map[string]string{"key": key, "value": payload.Value})
+	case services.ServerPublicIPsSettingKey:
+		addresses, err := services.UpdateServerPublicAddresses(payload.Value)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Failed to update server public IPs: "+err.Error())
+		}
+		return SendSuccess(c, map[string]string{"key": key, "value": addresses})
+	case services.BuildTimeoutSettingKey:
+		minutes, err := services.UpdateBuildTimeout(payload.Value)
+		if err != nil {
+			return SendError(c, http.StatusBadRequest, "Failed to update build timeout: "+err.Error())
+		}
+		return SendSuccess(c, map[string]string{"key": key, "value": minutes})
+	}
+
 	if err := models.SetSystemSetting(key, payload.Value); err != nil {
 		return SendError(c, http.StatusInternalServerError, "Failed to update system setting")
 	}
 
 	return SendSuccess(c, map[string]string{"key": key, "value": payload.Value})
 }
+
+// CaddyTLSAskHandler is queried by Caddy before issuing an on-demand certificate.
+// Only domains registered as active routings are allowed.
+func CaddyTLSAskHandler(c echo.Context) error {
+	domain := c.QueryParam("domain")
+	if domain == "" {
+		return SendError(c, http.StatusBadRequest, "domain is required")
+	}
+
+	allowed, err := services.IsKnownRoutingDomain(domain)
+	if err != nil {
+		return SendError(c, http.StatusInternalServerError, "Failed to check domain")
+	}
+	if !allowed {
+		return SendError(c, http.StatusNotFound, "Domain not managed")
+	}
+
+	return SendSuccess(c, map[string]string{"domain": domain})
+}
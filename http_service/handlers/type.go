@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/google/uuid"
 )
 
@@ -27,23 +28,117 @@ type ApplicationResponse struct {
 }
 
 type ApplicationDetailResponse struct {
-	Uid               string       `json:"uid"`
-	ProjectUid        string       `json:"projectUid"`
-	Name              string       `json:"name"`
-	Description       string       `json:"description"`
-	RepoURL           *string      `json:"repoUrl,omitempty"`
-	ActiveReleaseUid  *string      `json:"activeReleaseUid,omitempty"`
-	TargetPort        int          `json:"targetPort"`
-	Status            string       `json:"status"`
-	Volumes           models.JSONB `json:"volumes,omitempty"`
-	ExecCommand       *string      `json:"execCommand,omitempty"`
-	AutoUpdatePolicy  *string      `json:"autoUpdatePolicy,omitempty"`
-	Branch            *string      `json:"branch,omitempty"`
-	BuildDir          *string      `json:"buildDir,omitempty"`
-	BuildType         *string      `json:"buildType,omitempty"`
-	CreatedAt         time.Time    `json:"createdAt"`
-	UpdatedAt         time.Time    `json:"updatedAt"`
-	ActiveReleaseInfo *ReleaseInfo `json:"activeReleaseInfo,omitempty"`
+	Uid               string                     `json:"uid"`
+	ProjectUid        string                     `json:"projectUid"`
+	Name              string                     `json:"name"`
+	Description       string                     `json:"description"`
+	RepoURL           *string                    `json:"repoUrl,omitempty"`
+	ActiveReleaseUid  *string                    `json:"activeReleaseUid,omitempty"`
+	TargetPort        int                        `json:"targetPort"`
+	Status            string                     `json:"status"`
+	Volumes           models.JSONB               `json:"volumes,omitempty"`
+	ExecCommand       *string                    `json:"execCommand,omitempty"`
+	AutoUpdatePolicy  *string                    `json:"autoUpdatePolicy,omitempty"`
+	Branch            *string                    `json:"branch,omitempty"`
+	BuildDir          *string                    `json:"buildDir,omitempty"`
+	BuildType         *string                    `json:"buildType,omitempty"`
+	DockerfilePath    *string                    `json:"dockerfilePath,omitempty"`
+	BuildTarget       *string                    `json:"buildTarget,omitempty"`
+	GeneratedHostname string                     `json:"generatedHostname,omitempty"` // 基于系统基础域名自动生成的主机名
+	Resources         ResourceLimitsResponse     `json:"resources"`
+	BuildCache        BuildCacheSettingsResponse `json:"buildCache"`
+	AutoDeploy        AutoDeploySettings         `json:"autoDeploy"`
+	Retention         RetentionPolicyResponse    `json:"retention"`
+	Approval          ApprovalSettings           `json:"approval"`
+	CreatedAt         time.Time                  `json:"createdAt"`
+	UpdatedAt         time.Time                  `json:"updatedAt"`
+	ActiveReleaseInfo *ReleaseInfo               `json:"activeReleaseInfo,omitempty"`
+}
+
+// ResourceLimitsResponse 应用的资源限制，0 或空字符串表示不限制
+type ResourceLimitsResponse struct {
+	CPUQuota            float64 `json:"cpuQuota"`
+	MemoryLimitMB       int     `json:"memoryLimitMb"`
+	MemoryReservationMB int     `json:"memoryReservationMb"`
+	PidsLimit           int     `json:"pidsLimit"`
+	OOMPolicy           string  `json:"oomPolicy"` // continue, stop, kill
+}
+
+// BuildCacheSettingsResponse 应用的构建缓存设置
+type BuildCacheSettingsResponse struct {
+	Enabled    bool `json:"enabled"`    // 复用克隆目录和 podman 层缓存
+	PullAlways bool `json:"pullAlways"` // 每次构建都拉取基础镜像
+}
+
+// BuildCacheSettingsRequest 设置应用的构建缓存，在下次构建时生效
+type BuildCacheSettingsRequest struct {
+	Enabled    bool `json:"enabled"`
+	PullAlways bool `json:"pullAlways"`
+}
+
+// AutoDeploySettings 收到代码仓库 webhook 或镜像推送时的自动部署设置，请求和响应共用
+type AutoDeploySettings struct {
+	OnPush      bool `json:"onPush"`      // 推送到应用分支时自动部署
+	OnTag       bool `json:"onTag"`       // 推送标签时自动部署
+	OnImagePush bool `json:"onImagePush"` // 向内置镜像仓库推送镜像后自动部署
+}
+
+// ApprovalSettings 受保护应用的部署审批设置，请求和响应共用
+type ApprovalSettings struct {
+	Protected    bool     `json:"protected"`           // 部署需要审批后才会执行
+	TimeoutHours int      `json:"timeoutHours"`        // 等待审批的时限（小时），为 0 时使用默认的 24 小时
+	Approvers    []string `json:"approvers,omitempty"` // 可以审批的用户名，为空时任何登录用户都可以审批（发起人除外）
+}
+
+// RetentionPolicyRequest 设置应用的 Release 保留策略，均为 0 时不清理
+type RetentionPolicyRequest struct {
+	KeepLast int `json:"keepLast"` // 保留最近的 Release 个数
+	KeepDays int `json:"keepDays"` // 保留最近多少天内创建的 Release
+}
+
+// RetentionPolicyResponse 应用的 Release 保留策略及上次清理的结果
+type RetentionPolicyResponse struct {
+	KeepLast       int        `json:"keepLast"`
+	KeepDays       int        `json:"keepDays"`
+	LastRunAt      *time.Time `json:"lastRunAt,omitempty"`
+	ReclaimedBytes int64      `json:"reclaimedBytes"` // 上次清理回收的磁盘空间
+}
+
+// RetentionRunResponse 立即执行保留策略的结果
+type RetentionRunResponse struct {
+	RemovedReleases int   `json:"removedReleases"`
+	RemovedImages   int   `json:"removedImages"`
+	RemovedUnits    int   `json:"removedUnits"`
+	ReclaimedBytes  int64 `json:"reclaimedBytes"`
+}
+
+// BuildCacheUsageResponse 应用构建缓存的占用情况
+type BuildCacheUsageResponse struct {
+	Path           string `json:"path"`
+	RepoCacheBytes int64  `json:"repoCacheBytes"`
+	ImageCount     int    `json:"imageCount"`
+	ImageBytes     int64  `json:"imageBytes"`
+}
+
+// PurgeBuildCacheResponse 清理构建缓存的结果
+type PurgeBuildCacheResponse struct {
+	ReclaimedBytes int64 `json:"reclaimedBytes"` // 删除的克隆目录大小，不含清理的悬空层
+	PrunedLayers   bool  `json:"prunedLayers"`
+}
+
+// ResourceLimitsRequest 设置应用的资源限制，在下次部署时生效
+type ResourceLimitsRequest struct {
+	CPUQuota            float64 `json:"cpuQuota"`
+	MemoryLimitMB       int     `json:"memoryLimitMb"`
+	MemoryReservationMB int     `json:"memoryReservationMb"`
+	PidsLimit           int     `json:"pidsLimit"`
+	OOMPolicy           string  `json:"oomPolicy"`
+}
+
+// HostCapacityResponse 主机可分配的资源总量，用于校验资源限制
+type HostCapacityResponse struct {
+	CPUs     int `json:"cpus"`
+	MemoryMB int `json:"memoryMb"`
 }
 type RunningDeploymentResponse struct {
 	DeploymentResponse
@@ -56,17 +151,24 @@ type ReleaseInfo struct {
 	ImageName *string `json:"imageName,omitempty"`
 }
 type UpdateApplicationRequest struct {
-	Description      string      `json:"description"`
-	RepoURL          *string     `json:"repoUrl,omitempty"`
-	TargetPort       int         `json:"targetPort"`
-	Status           string      `json:"status"`
-	Volumes          interface{} `json:"volumes"`
-	ExecCommand      *string     `json:"execCommand"`
-	AutoUpdatePolicy *string     `json:"autoUpdatePolicy"`
-	Branch           *string     `json:"branch"`
-	BuildDir         *string     `json:"buildDir,omitempty"`
-	BuildType        *string     `json:"buildType,omitempty"`
-	ProviderAuthUid  *string     `json:"providerAuthUid,omitempty"`
+	Description      string                     `json:"description"`
+	RepoURL          *string                    `json:"repoUrl,omitempty"`
+	TargetPort       int                        `json:"targetPort"`
+	Status           string                     `json:"status"`
+	Volumes          interface{}                `json:"volumes"`
+	ExecCommand      *string                    `json:"execCommand"`
+	AutoUpdatePolicy *string                    `json:"autoUpdatePolicy"`
+	Branch           *string                    `json:"branch"`
+	BuildDir         *string                    `json:"buildDir,omitempty"`
+	BuildType        *string                    `json:"buildType,omitempty"`
+	DockerfilePath   *string                    `json:"dockerfilePath,omitempty"` // 相对于 buildDir，为空时保持不变
+	BuildTarget      *string                    `json:"buildTarget,omitempty"`    // 多阶段构建的目标阶段，为空时保持不变
+	ProviderAuthUid  *string                    `json:"providerAuthUid,omitempty"`
+	Resources        *ResourceLimitsRequest     `json:"resources,omitempty"`  // 为空时保持不变
+	BuildCache       *BuildCacheSettingsRequest `json:"buildCache,omitempty"` // 为空时保持不变
+	AutoDeploy       *AutoDeploySettings        `json:"autoDeploy,omitempty"` // 为空时保持不变
+	Retention        *RetentionPolicyRequest    `json:"retention,omitempty"`  // 为空时保持不变
+	Approval         *ApprovalSettings          `json:"approval,omitempty"`   // 为空时保持不变
 }
 type CreateReleaseRequest struct {
 	ImageName       string                 `json:"imageName"`
@@ -79,11 +181,28 @@ type ReleaseResponse struct {
 	ApplicationUid  string                 `json:"applicationUid"`
 	ImageName       string                 `json:"imageName"`
 	BuildSourceInfo map[string]interface{} `json:"buildSourceInfo"`
+	CommitSHA       string                 `json:"commitSha,omitempty"` // 构建所用的提交，取自 buildSourceInfo
+	CommitMessage   string                 `json:"commitMessage,omitempty"`
+	CommitURL       string                 `json:"commitUrl,omitempty"`
+	ImageDigest     string                 `json:"imageDigest,omitempty"` // 来自镜像仓库的 Release 固定的摘要
 	Status          string                 `json:"status"`
 	CreatedAt       time.Time              `json:"createdAt"`
 	UpdatedAt       time.Time              `json:"updatedAt"`
 }
 
+// PromoteReleaseRequest 把 Release 提升到同一项目的另一个应用
+type PromoteReleaseRequest struct {
+	TargetApplicationUid string `json:"targetApplicationUid"`
+	Deploy               bool   `json:"deploy"` // 创建 Release 后立即部署
+}
+
+// PromoteReleaseResponse 提升的结果，created 为 false 时目标应用已有同一镜像的 Release
+type PromoteReleaseResponse struct {
+	Release       *ReleaseResponse `json:"release"`
+	Created       bool             `json:"created"`
+	DeploymentUid string           `json:"deploymentUid,omitempty"`
+}
+
 type RoutingRequest struct {
 	DomainName string `json:"domainName"`
 	HostPort   int    `json:"hostPort"`
@@ -91,13 +210,61 @@ type RoutingRequest struct {
 }
 
 type RoutingResponse struct {
-	Uid            string    `json:"uid"`
-	ApplicationUid string    `json:"applicationUid"`
-	DomainName     string    `json:"domainName"`
-	HostPort       int       `json:"hostPort"`
-	IsActive       bool      `json:"isActive"`
-	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+	Uid                  string     `json:"uid"`
+	ApplicationUid       string     `json:"applicationUid"`
+	DomainName           string     `json:"domainName"`
+	HostPort             int        `json:"hostPort"`
+	IsActive             bool       `json:"isActive"`
+	TLSMode              string     `json:"tlsMode"`                        // auto, custom, acme
+	CertificateExpiresAt *time.Time `json:"certificateExpiresAt,omitempty"` // 自定义证书的过期时间，其它模式为最近一次从 Caddy 读取的结果
+	DNSStatus            string     `json:"dnsStatus"`                      // pending, verified, mismatch, unresolved, unverified, unknown
+	DNSStatusMessage     string     `json:"dnsStatusMessage,omitempty"`
+	DNSCheckedAt         *time.Time `json:"dnsCheckedAt,omitempty"`
+	CreatedAt            time.Time  `json:"createdAt"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+}
+
+// DomainPreflightRequest 域名 DNS 预检请求
+type DomainPreflightRequest struct {
+	DomainName string `json:"domainName"`
+}
+
+// DomainPreflightResponse 域名 DNS 预检结果
+type DomainPreflightResponse struct {
+	DomainName           string   `json:"domainName"`
+	Status               string   `json:"status"`
+	Message              string   `json:"message"`
+	CNAME                string   `json:"cname,omitempty"`
+	ResolvedAddresses    []string `json:"resolvedAddresses"`
+	ServerAddresses      []string `json:"serverAddresses"`
+	VerificationRequired bool     `json:"verificationRequired"`
+	VerificationRecord   string   `json:"verificationRecord"` // TXT 记录名
+	VerificationToken    string   `json:"verificationToken"`  // TXT 记录值
+	OwnershipVerified    bool     `json:"ownershipVerified"`
+}
+
+// RoutingTLSRequest 路由证书设置请求
+type RoutingTLSRequest struct {
+	Mode           string            `json:"mode"`           // auto, custom, acme
+	Certificate    string            `json:"certificate"`    // PEM 证书链（custom）
+	PrivateKey     string            `json:"privateKey"`     // PEM 私钥（custom）
+	ACMEEmail      string            `json:"acmeEmail"`      // ACME 账户邮箱（acme）
+	ACMEStaging    bool              `json:"acmeStaging"`    // 使用 staging 环境（acme）
+	DNSProvider    string            `json:"dnsProvider"`    // DNS-01 提供商, e.g., "cloudflare"（acme）
+	DNSCredentials map[string]string `json:"dnsCredentials"` // 提供商参数, e.g., {"api_token": "..."}（acme）
+}
+
+// RoutingTLSResponse 路由证书设置，不返回私钥和凭据内容
+type RoutingTLSResponse struct {
+	RoutingUid           string     `json:"routingUid"`
+	DomainName           string     `json:"domainName"`
+	Mode                 string     `json:"mode"`
+	HasCustomCertificate bool       `json:"hasCustomCertificate"`
+	CertificateExpiresAt *time.Time `json:"certificateExpiresAt,omitempty"`
+	ACMEEmail            string     `json:"acmeEmail,omitempty"`
+	ACMEStaging          bool       `json:"acmeStaging"`
+	DNSProvider          string     `json:"dnsProvider,omitempty"`
+	DNSCredentialKeys    []string   `json:"dnsCredentialKeys,omitempty"`
 }
 
 type ConfigurationResponse struct {
@@ -130,6 +297,103 @@ type DeploymentResponse struct {
 	Version       *string `json:"version,omitempty"`
 	ImageName     *string `json:"imageName,omitempty"`
 	ReleaseStatus string  `json:"releaseStatus,omitempty"`
+	// 受保护应用的审批记录
+	RequestedBy       string     `json:"requestedBy,omitempty"`
+	ApprovalExpiresAt *time.Time `json:"approvalExpiresAt,omitempty"`
+	ReviewedBy        string     `json:"reviewedBy,omitempty"` // 批准或拒绝部署的用户
+	ReviewedAt        *time.Time `json:"reviewedAt,omitempty"`
+	ReviewComment     string     `json:"reviewComment,omitempty"`
+	// 在冻结窗口内强制部署的原因
+	FreezeOverrideReason string `json:"freezeOverrideReason,omitempty"`
+}
+
+// FreezeWindowRequest 创建部署冻结窗口：设置 startsAt/endsAt 固定时间段，或 cron + durationMinutes 周期性窗口
+type FreezeWindowRequest struct {
+	Name            string     `json:"name"`
+	Reason          string     `json:"reason"`
+	ApplicationUid  string     `json:"applicationUid"` // 为空时对项目下的所有应用生效
+	StartsAt        *time.Time `json:"startsAt"`
+	EndsAt          *time.Time `json:"endsAt"`
+	Cron            string     `json:"cron"`            // 5 段 cron 表达式，e.g., "0 18 * * 5"
+	DurationMinutes int        `json:"durationMinutes"` // cron 触发后持续冻结的分钟数
+	Timezone        string     `json:"timezone"`        // 解释 cron 的时区，为空时使用服务器本地时区
+}
+
+// FreezeWindowResponse 部署冻结窗口
+type FreezeWindowResponse struct {
+	Uid             string     `json:"uid"`
+	ProjectUid      string     `json:"projectUid"`
+	ApplicationUid  string     `json:"applicationUid,omitempty"`
+	Name            string     `json:"name"`
+	Reason          string     `json:"reason,omitempty"`
+	StartsAt        *time.Time `json:"startsAt,omitempty"`
+	EndsAt          *time.Time `json:"endsAt,omitempty"`
+	Cron            string     `json:"cron,omitempty"`
+	DurationMinutes int        `json:"durationMinutes,omitempty"`
+	Timezone        string     `json:"timezone,omitempty"`
+	CreatedBy       string     `json:"createdBy,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	Active          bool       `json:"active"`                // 当前是否处于冻结期
+	ActiveUntil     *time.Time `json:"activeUntil,omitempty"` // 当前冻结期结束的时间
+}
+
+// ScheduledDeploymentRequest 创建定时部署，releaseUid 为空时到达计划时间后重新构建
+type ScheduledDeploymentRequest struct {
+	ReleaseUid           string    `json:"releaseUid"`
+	GitRef               string    `json:"gitRef"`
+	Strategy             string    `json:"strategy"`
+	ScheduledAt          time.Time `json:"scheduledAt"`
+	FreezeOverrideReason string    `json:"freezeOverrideReason"` // 计划时间处于冻结窗口时强制部署的原因
+}
+
+// ScheduledDeploymentResponse 定时部署
+type ScheduledDeploymentResponse struct {
+	Uid                  string     `json:"uid"`
+	ApplicationUid       string     `json:"applicationUid"`
+	ReleaseUid           string     `json:"releaseUid,omitempty"`
+	GitRef               string     `json:"gitRef,omitempty"`
+	Strategy             string     `json:"strategy,omitempty"`
+	ScheduledAt          time.Time  `json:"scheduledAt"`
+	Status               string     `json:"status"` // pending, started, failed, cancelled
+	RequestedBy          string     `json:"requestedBy,omitempty"`
+	FreezeOverrideReason string     `json:"freezeOverrideReason,omitempty"`
+	DeploymentUid        string     `json:"deploymentUid,omitempty"`
+	Error                string     `json:"error,omitempty"`
+	StartedAt            *time.Time `json:"startedAt,omitempty"`
+	CreatedAt            time.Time  `json:"createdAt"`
+}
+
+// ReviewDeploymentRequest 批准或拒绝等待审批的部署
+type ReviewDeploymentRequest struct {
+	Comment              string `json:"comment"`
+	FreezeOverrideReason string `json:"freezeOverrideReason"` // 批准时处于冻结窗口，且发起时的原因不适用当前窗口时必填
+}
+
+// CanaryReleaseResponse 金丝雀发布状态
+type CanaryReleaseResponse struct {
+	ApplicationUid        string     `json:"applicationUid"`
+	DeploymentUid         string     `json:"deploymentUid"`
+	BaselineDeploymentUid string     `json:"baselineDeploymentUid"`
+	Status                string     `json:"status"` // pending, running, promoted, aborted
+	Weight                int        `json:"weight"` // 新版本流量百分比
+	StepWeight            int        `json:"stepWeight"`
+	MaxErrorRate          float64    `json:"maxErrorRate"`
+	HealthPath            string     `json:"healthPath"`
+	BaselinePort          int        `json:"baselinePort"`
+	CanaryPort            int        `json:"canaryPort,omitempty"`
+	AbortReason           string     `json:"abortReason,omitempty"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	FinishedAt            *time.Time `json:"finishedAt,omitempty"`
+}
+
+// CanaryStepRequest 调整金丝雀流量，weight 为 0 时按步长增加
+type CanaryStepRequest struct {
+	Weight int `json:"weight"`
+}
+
+// CanaryAbortRequest 中止金丝雀发布
+type CanaryAbortRequest struct {
+	Reason string `json:"reason"`
 }
 
 type DeploymentLogResponse struct {
@@ -153,6 +417,7 @@ type EnvironmentVariableResponse struct {
 	Key            string    `json:"key"`
 	Value          string    `json:"value"`
 	IsEncrypted    bool      `json:"isEncrypted"`
+	IsBuildTime    bool      `json:"isBuildTime"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
@@ -161,12 +426,346 @@ type CreateEnvironmentVariableRequest struct {
 	Key         string `json:"key" validate:"required"`
 	Value       string `json:"value"`
 	IsEncrypted bool   `json:"isEncrypted"`
+	IsBuildTime bool   `json:"isBuildTime"` // 作为构建参数传入 podman build
 }
 
 type UpdateEnvironmentVariableRequest struct {
 	Key         string `json:"key" validate:"required"`
 	Value       string `json:"value"`
 	IsEncrypted bool   `json:"isEncrypted"`
+	IsBuildTime bool   `json:"isBuildTime"` // 作为构建参数传入 podman build
+}
+
+// Build Secret API Types (值只写不读)
+
+type BuildSecretResponse struct {
+	Uid            string    `json:"uid"`
+	ApplicationUid string    `json:"applicationUid"`
+	Name           string    `json:"name"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type CreateBuildSecretRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Value string `json:"value"`
+}
+
+type UpdateBuildSecretRequest struct {
+	Value string `json:"value"`
+}
+
+// Deploy Key API Types (私钥不会通过 API 返回)
+
+type DeployKeyResponse struct {
+	PublicKey        string    `json:"publicKey"`
+	Fingerprint      string    `json:"fingerprint"`
+	KnownHosts       string    `json:"knownHosts"`
+	HostFingerprints []string  `json:"hostFingerprints"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// PinKnownHostsRequest knownHosts 为空时从仓库主机获取主机密钥
+type PinKnownHostsRequest struct {
+	KnownHosts string `json:"knownHosts"`
+}
+
+// Registry Image API Types (密码只写不读)
+
+type RegistryImageResponse struct {
+	ImageRef    string `json:"imageRef"` // 为空时应用从代码仓库构建
+	Registry    string `json:"registry"`
+	Username    string `json:"username"`
+	HasPassword bool   `json:"hasPassword"`
+}
+
+// RegistryImageRequest password 留空时保留已保存的密码
+type RegistryImageRequest struct {
+	ImageRef string `json:"imageRef" validate:"required"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type CheckRegistryImageRequest struct {
+	Deploy bool `json:"deploy"` // 有更新时立即部署新的 Release
+}
+
+type RegistryImageCheckResponse struct {
+	ImageRef      string `json:"imageRef"`
+	Digest        string `json:"digest"`
+	Changed       bool   `json:"changed"`
+	ReleaseUid    string `json:"releaseUid,omitempty"`
+	DeploymentUid string `json:"deploymentUid,omitempty"`
+}
+
+// Repository Browsing API Types (分页参数 page、perPage、search)
+
+type RepositoryListResponse struct {
+	Repositories []utils.GitRepository `json:"repositories"`
+	Page         int                   `json:"page"`
+	PerPage      int                   `json:"perPage"`
+	HasMore      bool                  `json:"hasMore"`
+}
+
+type BranchListResponse struct {
+	Branches []utils.GitBranch `json:"branches"`
+	Page     int               `json:"page"`
+	PerPage  int               `json:"perPage"`
+	HasMore  bool              `json:"hasMore"`
+}
+
+// CLI Environment Variable API Types (snake_case, used by orbitctl)
+
+// CLIEnvironmentVariableResponse 密钥变量的值默认以 utils.MaskedValue 代替
+type CLIEnvironmentVariableResponse struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	Secret    bool      `json:"secret"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CLIEnvironmentVariablesResponse struct {
+	Variables []CLIEnvironmentVariableResponse `json:"variables"`
+}
+
+type CLIEnvironmentVariableInput struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret"`
+}
+
+// CLISetEnvironmentVariablesRequest 批量设置和删除环境变量，redeploy 为 true 时立即以当前版本重新部署
+type CLISetEnvironmentVariablesRequest struct {
+	Variables []CLIEnvironmentVariableInput `json:"variables"`
+	Unset     []string                      `json:"unset"`
+	Redeploy  bool                          `json:"redeploy"`
+}
+
+type CLISetEnvironmentVariablesResponse struct {
+	Created       int    `json:"created"`
+	Updated       int    `json:"updated"`
+	Deleted       int    `json:"deleted"`
+	DeploymentUid string `json:"deployment_uid,omitempty"`
+}
+
+type CLIEnvironmentDiffEntry struct {
+	Key    string `json:"key"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+	Secret bool   `json:"secret"`
+}
+
+// CLIEnvironmentDiffResponse 当前环境变量与最近一次成功部署时快照的差异
+type CLIEnvironmentDiffResponse struct {
+	DeploymentUid string                    `json:"deployment_uid"`
+	DeployedAt    time.Time                 `json:"deployed_at"`
+	Added         []CLIEnvironmentDiffEntry `json:"added"`
+	Removed       []CLIEnvironmentDiffEntry `json:"removed"`
+	Changed       []CLIEnvironmentDiffEntry `json:"changed"`
+}
+
+// CLIDeploymentDiffTarget 比较的一次部署
+type CLIDeploymentDiffTarget struct {
+	DeploymentUid string    `json:"deployment_uid"`
+	ReleaseUid    string    `json:"release_uid"`
+	Version       string    `json:"version"`
+	ImageName     string    `json:"image_name"`
+	ImageDigest   string    `json:"image_digest,omitempty"`
+	CommitSHA     string    `json:"commit_sha,omitempty"`
+	Status        string    `json:"status"`
+	DeployedAt    time.Time `json:"deployed_at"`
+}
+
+type CLIDeploymentDiffCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// CLIDeploymentDiffCommits 两次部署之间的提交，rollback 时为回滚掉的提交
+type CLIDeploymentDiffCommits struct {
+	Rollback   bool                      `json:"rollback"`
+	Total      int                       `json:"total"`
+	Commits    []CLIDeploymentDiffCommit `json:"commits"`
+	CompareURL string                    `json:"compare_url,omitempty"`
+	Error      string                    `json:"error,omitempty"` // 无法获取提交列表的原因
+}
+
+// CLIDeploymentDiffLine Quadlet 内容的一行，op 为 " "、"+" 或 "-"
+type CLIDeploymentDiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type CLIDeploymentDiffSetting struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type CLIDeploymentDiffRouting struct {
+	DomainName string `json:"domain_name"`
+	HostPort   int    `json:"host_port"`
+}
+
+// CLIDeploymentDiffResponse 同一应用两次部署之间的差异，密钥变量只显示变化的 key。
+// 早期的部署没有记录 Quadlet 内容和配置快照，此时对应的 *_recorded 为 false
+type CLIDeploymentDiffResponse struct {
+	From            CLIDeploymentDiffTarget    `json:"from"`
+	To              CLIDeploymentDiffTarget    `json:"to"`
+	ImageChanged    bool                       `json:"image_changed"`
+	Commits         CLIDeploymentDiffCommits   `json:"commits"`
+	EnvAdded        []CLIEnvironmentDiffEntry  `json:"env_added"`
+	EnvRemoved      []CLIEnvironmentDiffEntry  `json:"env_removed"`
+	EnvChanged      []CLIEnvironmentDiffEntry  `json:"env_changed"`
+	QuadletRecorded bool                       `json:"quadlet_recorded"`
+	QuadletChanged  bool                       `json:"quadlet_changed"`
+	Quadlet         []CLIDeploymentDiffLine    `json:"quadlet"`
+	ConfigRecorded  bool                       `json:"config_recorded"`
+	Settings        []CLIDeploymentDiffSetting `json:"settings"`
+	RoutingsAdded   []CLIDeploymentDiffRouting `json:"routings_added"`
+	RoutingsRemoved []CLIDeploymentDiffRouting `json:"routings_removed"`
+}
+
+// CLI Application Runtime API Types (snake_case, used by orbitctl)
+
+type CLIReleaseSummary struct {
+	Uid       string    `json:"uid"`
+	Version   string    `json:"version"`
+	ImageName string    `json:"image_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CLIServiceStatus struct {
+	Name        string `json:"name"`
+	ActiveState string `json:"active_state"` // systemd ActiveState，查询失败时为 unknown
+	SubState    string `json:"sub_state"`
+	SystemPort  *int   `json:"system_port,omitempty"`
+}
+
+type CLIRoutingStatus struct {
+	Domain    string `json:"domain"`
+	HostPort  int    `json:"host_port"`
+	IsActive  bool   `json:"is_active"`
+	DNSStatus string `json:"dns_status"`
+}
+
+type CLIDeploymentSummary struct {
+	Uid        string     `json:"uid"`
+	ReleaseUid string     `json:"release_uid"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// CLIApplicationStatusResponse orbitctl status 的返回值
+type CLIApplicationStatusResponse struct {
+	AppName           string                `json:"app_name"`
+	AppUid            string                `json:"app_uid"`
+	Project           string                `json:"project"`
+	Status            string                `json:"status"`
+	GeneratedHostname string                `json:"generated_hostname,omitempty"`
+	ActiveRelease     *CLIReleaseSummary    `json:"active_release,omitempty"`
+	Service           *CLIServiceStatus     `json:"service,omitempty"`
+	Routings          []CLIRoutingStatus    `json:"routings"`
+	LastDeployment    *CLIDeploymentSummary `json:"last_deployment,omitempty"`
+	CanaryStatus      string                `json:"canary_status,omitempty"`
+	CanaryWeight      int                   `json:"canary_weight,omitempty"`
+}
+
+type CLIVolumeMount struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"read_only,omitempty"`
+}
+
+type CLIResourceLimits struct {
+	CPUQuota            float64 `json:"cpu_quota"`
+	MemoryLimitMB       int     `json:"memory_limit_mb"`
+	MemoryReservationMB int     `json:"memory_reservation_mb"`
+	PidsLimit           int     `json:"pids_limit"`
+	OOMPolicy           string  `json:"oom_policy"`
+}
+
+// CLIApplicationInspectResponse 服务端生效的应用配置，orbitctl inspect 用来与本地 orbitdeploy.toml 比较
+type CLIApplicationInspectResponse struct {
+	AppName          string                           `json:"app_name"`
+	Project          string                           `json:"project"`
+	RepoURL          string                           `json:"repo_url,omitempty"`
+	Branch           string                           `json:"branch,omitempty"`
+	BuildDir         string                           `json:"build_dir,omitempty"`
+	BuildType        string                           `json:"build_type,omitempty"`
+	TargetPort       int                              `json:"target_port"`
+	ExecCommand      string                           `json:"exec_command,omitempty"`
+	AutoUpdatePolicy string                           `json:"auto_update_policy,omitempty"`
+	Image            string                           `json:"image,omitempty"` // 当前活跃版本的镜像
+	Volumes          []CLIVolumeMount                 `json:"volumes"`
+	Resources        CLIResourceLimits                `json:"resources"`
+	Domains          []string                         `json:"domains"`
+	Env              []CLIEnvironmentVariableResponse `json:"env"`
+}
+
+// CLIImageUploadRequest 创建分块上传，同一应用未完成的相同文件（sha256 和大小一致）会续传
+type CLIImageUploadRequest struct {
+	FileName    string `json:"file_name"`
+	TotalSize   int64  `json:"total_size"`
+	SHA256      string `json:"sha256"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+}
+
+// CLIImageUploadResponse 分块上传的状态，offset 为下一个分块的起始位置。
+// upload_id 同时是上传进度 WebSocket 的 upload_id
+type CLIImageUploadResponse struct {
+	UploadId   string `json:"upload_id"`
+	FileName   string `json:"file_name"`
+	TotalSize  int64  `json:"total_size"`
+	Offset     int64  `json:"offset"`
+	ChunkSize  int64  `json:"chunk_size"` // 建议的分块大小
+	Status     string `json:"status"`
+	Resumed    bool   `json:"resumed,omitempty"`
+	ReleaseUid string `json:"release_uid,omitempty"`
+}
+
+// CLIPromoteReleaseRequest 把 from 应用的 Release 提升到 URL 中的应用，release_uid 为空时提升 from 当前运行的版本
+type CLIPromoteReleaseRequest struct {
+	From       string `json:"from"`
+	ReleaseUid string `json:"release_uid"`
+	Deploy     bool   `json:"deploy"`
+}
+
+// CLIPromoteReleaseResponse 提升的结果
+type CLIPromoteReleaseResponse struct {
+	ReleaseUid       string `json:"release_uid"`
+	SourceReleaseUid string `json:"source_release_uid"`
+	ImageName        string `json:"image_name"`
+	Created          bool   `json:"created"`
+	DeploymentUid    string `json:"deployment_uid,omitempty"`
+}
+
+// CLI Spec Apply API Types (snake_case, used by orbitctl)
+
+// CLIApplySpecRequest 提交 orbitdeploy.toml 原文，dry_run 为 true 时只返回执行计划
+type CLIApplySpecRequest struct {
+	Spec   string `json:"spec"`
+	DryRun bool   `json:"dry_run"`
+}
+
+type CLISpecChange struct {
+	Resource string `json:"resource"` // application, volume, routing, env
+	Action   string `json:"action"`   // create, update, delete
+	Name     string `json:"name"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+}
+
+type CLIApplySpecResponse struct {
+	Changes          []CLISpecChange `json:"changes"`
+	Warnings         []string        `json:"warnings"`
+	Applied          bool            `json:"applied"`
+	RedeployRequired bool            `json:"redeploy_required"`
 }
 
 type CreateConfigurationWithVariablesRequest struct {
@@ -236,17 +835,24 @@ type NodeDeploymentResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 type CreateApplicationRequest struct {
-	Name             string      `json:"name"`
-	Description      string      `json:"description"`
-	RepoURL          *string     `json:"repoUrl,omitempty"`
-	TargetPort       int         `json:"targetPort"`
-	Volumes          interface{} `json:"volumes"`
-	ExecCommand      *string     `json:"execCommand"`
-	AutoUpdatePolicy *string     `json:"autoUpdatePolicy"`
-	Branch           *string     `json:"branch"`
-	BuildDir         *string     `json:"buildDir,omitempty"`
-	BuildType        *string     `json:"buildType,omitempty"`
-	ProviderAuthUid  *string     `json:"providerAuthUid,omitempty"`
+	Name             string                     `json:"name"`
+	Description      string                     `json:"description"`
+	RepoURL          *string                    `json:"repoUrl,omitempty"`
+	TargetPort       int                        `json:"targetPort"`
+	Volumes          interface{}                `json:"volumes"`
+	ExecCommand      *string                    `json:"execCommand"`
+	AutoUpdatePolicy *string                    `json:"autoUpdatePolicy"`
+	Branch           *string                    `json:"branch"`
+	BuildDir         *string                    `json:"buildDir,omitempty"`
+	BuildType        *string                    `json:"buildType,omitempty"`
+	DockerfilePath   *string                    `json:"dockerfilePath,omitempty"` // 相对于 buildDir，为空时保持不变
+	BuildTarget      *string                    `json:"buildTarget,omitempty"`    // 多阶段构建的目标阶段，为空时保持不变
+	ProviderAuthUid  *string                    `json:"providerAuthUid,omitempty"`
+	Resources        *ResourceLimitsRequest     `json:"resources,omitempty"`
+	BuildCache       *BuildCacheSettingsRequest `json:"buildCache,omitempty"`
+	AutoDeploy       *AutoDeploySettings        `json:"autoDeploy,omitempty"`
+	Retention        *RetentionPolicyRequest    `json:"retention,omitempty"`
+	Approval         *ApprovalSettings          `json:"approval,omitempty"`
 }
 
 // ApplicationTokenResponse represents the response for an application token
@@ -3,6 +3,7 @@ package http_service
 import (
 	"embed"
 	"log"
+	"net"
 	"net/http"
 	"strings"
 
@@ -69,7 +70,11 @@ func createEchoServerWithRoutes(assets embed.FS, appService *services.Applicatio
 	// Add public GET route for GitHub App callback (handles redirect from GitHub)
 	api.GET("/providers/github/app-callback", handlers.HandleGitHubAppCallback)
 	// Add public POST route for GitHub webhooks (GitHub sends unauthenticated requests)
-	api.POST("/providers/github/webhook", handlers.HandleGitHubWebhook)
+	api.POST("/providers/github/webhook", handlers.NewGitHubWebhookHandler(deploymentOrchestrator))
+	// 每个 ProviderAuth 独立的 webhook 地址，以各平台的签名或令牌校验
+	api.POST("/providers/:providerAuthId/webhook", handlers.NewProviderWebhookHandler(deploymentOrchestrator))
+	// Caddy 按需签发证书前的确认接口（Caddy 从本机发起，无认证，只接受本机的直接请求）
+	api.GET("/caddy/tls-ask", handlers.CaddyTLSAskHandler, loopbackOnlyMiddleware)
 
 	// Setup routes
 	setup := api.Group("/setup")
@@ -106,8 +111,20 @@ func createEchoServerWithRoutes(assets embed.FS, appService *services.Applicatio
 
 	// CLI Application Management routes (support both JWT and application token authentication)
 	cli.POST("/apps/by-name/:appName/releases", handlers.UploadApplicationImage, echoAppTokenOrAuthMiddleware)
+	// 可续传的分块镜像上传
+	cli.POST("/apps/by-name/:appName/uploads", handlers.CreateCLIImageUpload, echoAppTokenOrAuthMiddleware)
+	cli.GET("/apps/by-name/:appName/uploads/:uploadId", handlers.GetCLIImageUpload, echoAppTokenOrAuthMiddleware)
+	cli.PUT("/apps/by-name/:appName/uploads/:uploadId", handlers.PutCLIImageUploadChunk, echoAppTokenOrAuthMiddleware)
+	cli.POST("/apps/by-name/:appName/uploads/:uploadId/complete", handlers.CompleteCLIImageUpload, echoAppTokenOrAuthMiddleware)
+	cli.DELETE("/apps/by-name/:appName/uploads/:uploadId", handlers.DeleteCLIImageUpload, echoAppTokenOrAuthMiddleware)
 	cli.POST("/apps/by-name/:appName/deployments", handlers.CreateApplicationDeployment, echoAppTokenOrAuthMiddleware)
 	cli.GET("/apps/by-name/:appName/config/export", handlers.ExportApplicationConfig, echoAppTokenOrAuthMiddleware)
+	cli.POST("/apps/by-name/:appName/apply", handlers.ApplyCLIApplicationSpec, echoAppTokenOrAuthMiddleware)
+	cli.GET("/apps/by-name/:appName/environment-variables", handlers.ListCLIEnvironmentVariables, echoAppTokenOrAuthMiddleware)
+	cli.GET("/apps/by-name/:appName/environment-variables/diff", handlers.DiffCLIEnvironmentVariables, echoAppTokenOrAuthMiddleware)
+	cli.GET("/apps/by-name/:appName/status", handlers.GetCLIApplicationStatus, echoAppTokenOrAuthMiddleware)
+	cli.GET("/apps/by-name/:appName/logs", handlers.StreamCLIApplicationLogs, echoAppTokenOrAuthMiddleware)
+	cli.GET("/apps/by-name/:appName/inspect", handlers.InspectCLIApplication, echoAppTokenOrAuthMiddleware)
 	cli.GET("/deployments/:deployment_id", handlers.GetDeploymentResult, echoAppTokenOrAuthMiddleware)
 	cli.GET("/deployments/:deployment_id/logs", handlers.DeploymentLogsSSEEnhanced, echoAppTokenOrAuthMiddleware)
 
@@ -123,6 +140,7 @@ func createEchoServerWithRoutes(assets embed.FS, appService *services.Applicatio
 	protected.GET("/system/monitor", handlers.SystemMonitorHandler)
 
 	protected.GET("/system/running-deployments", handlers.RunningDeploymentsHandler)
+	protected.GET("/system/capacity", handlers.GetHostCapacityHandler)
 
 	// Upload progress WebSocket
 	protected.GET("/docker-images/upload/progress", handlers.UploadProgressHandler)
@@ -188,12 +206,65 @@ func createEchoServerWithRoutes(assets embed.FS, appService *services.Applicatio
 	protected.GET("/apps/:appId/releases", handlers.ListReleasesHandler)
 	protected.GET("/apps/:appId/releases/latest", handlers.GetLatestReleaseHandler)
 	protected.GET("/releases/:releaseId", handlers.GetReleaseHandler)
+	protected.GET("/releases/:releaseId/build-log", handlers.GetReleaseBuildLogHandler)
+	protected.GET("/apps/:appId/build-cache", handlers.GetBuildCacheHandler)
+	protected.DELETE("/apps/:appId/build-cache", handlers.PurgeBuildCacheHandler)
 
 	// Deployment routes
 	if deploymentOrchestrator != nil {
 		// 设置SSE日志发送函数
 		deploymentOrchestrator.SetSSELogSender(handlers.SendDeploymentLogSSE)
 		protected.POST("/apps/:appId/deployments", handlers.NewCreateDeploymentHandler(deploymentOrchestrator))
+
+		// 受保护应用的部署审批，只接受登录用户，应用令牌不能审批
+		protected.POST("/deployments/:deploymentId/approve", handlers.NewApproveDeploymentHandler(deploymentOrchestrator))
+		protected.POST("/deployments/:deploymentId/reject", handlers.NewRejectDeploymentHandler(deploymentOrchestrator))
+
+		// 定时部署，到达计划时间后由 StartDeploymentScheduler 创建部署
+		protected.POST("/apps/:appId/scheduled-deployments", handlers.NewCreateScheduledDeploymentHandler(deploymentOrchestrator))
+		protected.DELETE("/scheduled-deployments/:scheduleId", handlers.NewCancelScheduledDeploymentHandler(deploymentOrchestrator))
+
+		// Canary release routes
+		protected.GET("/apps/:appId/canary", handlers.NewGetCanaryHandler(deploymentOrchestrator))
+		protected.POST("/apps/:appId/canary/step", handlers.NewStepCanaryHandler(deploymentOrchestrator))
+		protected.POST("/apps/:appId/canary/promote", handlers.NewPromoteCanaryHandler(deploymentOrchestrator))
+		protected.POST("/apps/:appId/canary/abort", handlers.NewAbortCanaryHandler(deploymentOrchestrator))
+		cli.GET("/apps/by-name/:appName/canary", handlers.NewGetCanaryHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
+		cli.POST("/apps/by-name/:appName/canary/step", handlers.NewStepCanaryHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
+		cli.POST("/apps/by-name/:appName/canary/promote", handlers.NewPromoteCanaryHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
+		cli.POST("/apps/by-name/:appName/canary/abort", handlers.NewAbortCanaryHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
+
+		// 检查镜像仓库更新，有更新时创建 Release 并可立即部署
+		protected.POST("/apps/:appId/registry-image/check", handlers.NewCheckRegistryImageHandler(deploymentOrchestrator))
+
+		// 在同一项目的应用之间提升 Release，目标应用使用同一个镜像
+		protected.POST("/releases/:releaseId/promote", handlers.NewPromoteReleaseHandler(deploymentOrchestrator))
+		cli.POST("/apps/by-name/:appName/promote", handlers.NewPromoteCLIReleaseHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
+
+		// 立即执行应用的 Release 保留策略，定时清理由 StartRetentionScheduler 负责
+		protected.POST("/apps/:appId/retention/run", handlers.NewRunRetentionHandler(deploymentOrchestrator))
+
+		// 内置镜像仓库（OCI Distribution 推送接口），OCI 规范要求挂载在根路径 /v2/，使用应用令牌认证
+		registry := e.Group("/v2", handlers.OCIRegistryAuthMiddleware)
+		registry.GET("", handlers.OCIBaseHandler)
+		registry.GET("/", handlers.OCIBaseHandler)
+		registry.HEAD("/:name/blobs/:digest", handlers.OCIGetBlobHandler)
+		registry.GET("/:name/blobs/:digest", handlers.OCIGetBlobHandler)
+		registry.POST("/:name/blobs/uploads", handlers.OCIStartBlobUploadHandler)
+		registry.POST("/:name/blobs/uploads/", handlers.OCIStartBlobUploadHandler)
+		registry.GET("/:name/blobs/uploads/:uuid", handlers.OCIGetBlobUploadHandler)
+		registry.PATCH("/:name/blobs/uploads/:uuid", handlers.OCIPatchBlobUploadHandler)
+		registry.PUT("/:name/blobs/uploads/:uuid", handlers.OCIFinishBlobUploadHandler)
+		registry.DELETE("/:name/blobs/uploads/:uuid", handlers.OCICancelBlobUploadHandler)
+		registry.HEAD("/:name/manifests/:reference", handlers.OCIGetManifestHandler)
+		registry.GET("/:name/manifests/:reference", handlers.OCIGetManifestHandler)
+		registry.PUT("/:name/manifests/:reference", handlers.NewOCIPutManifestHandler(deploymentOrchestrator))
+
+		// 批量设置环境变量，支持设置后立即重新部署
+		cli.PUT("/apps/by-name/:appName/environment-variables", handlers.NewSetCLIEnvironmentVariablesHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
+
+		// 比较两次部署的镜像、提交范围、环境变量、Quadlet 内容、路由和资源配置
+		cli.GET("/apps/by-name/:appName/deployments/diff", handlers.NewDiffCLIDeploymentsHandler(deploymentOrchestrator), echoAppTokenOrAuthMiddleware)
 	} else {
 		// Fallback for backward compatibility (when no dependency injection)
 		protected.POST("/apps/:appId/deployments", func(c echo.Context) error {
@@ -205,6 +276,13 @@ func createEchoServerWithRoutes(assets embed.FS, appService *services.Applicatio
 	protected.GET("/deployments/:deploymentId", handlers.GetDeploymentHandler)
 	protected.GET("/deployments/:deploymentId/logs", handlers.DeploymentLogsSSEEnhanced)
 	protected.GET("/deployments/:deploymentId/logs-data", handlers.GetDeploymentLogsHandler)
+	protected.GET("/apps/:appId/scheduled-deployments", handlers.ListScheduledDeploymentsHandler)
+
+	// 部署冻结窗口，可作用于整个项目或单个应用
+	protected.GET("/projects/:projectId/freeze-windows", handlers.ListFreezeWindowsHandler)
+	protected.POST("/projects/:projectId/freeze-windows", handlers.CreateFreezeWindowHandler)
+	protected.DELETE("/freeze-windows/:windowId", handlers.DeleteFreezeWindowHandler)
+	protected.GET("/apps/:appId/freeze-windows", handlers.ListApplicationFreezeWindowsHandler)
 
 	// Environment Variable routes (simplified - directly associated with applications)
 	protected.POST("/apps/:appId/environment-variables", handlers.CreateEnvironmentVariableHandler)
@@ -212,18 +290,40 @@ func createEchoServerWithRoutes(assets embed.FS, appService *services.Applicatio
 	protected.PUT("/environment-variables/:envVarId", handlers.UpdateEnvironmentVariableHandler)
 	protected.DELETE("/environment-variables/:envVarId", handlers.DeleteEnvironmentVariableHandler)
 
+	// Deploy Key routes
+	protected.GET("/apps/:appId/deploy-key", handlers.GetDeployKeyHandler)
+	protected.POST("/apps/:appId/deploy-key", handlers.GenerateDeployKeyHandler)
+	protected.DELETE("/apps/:appId/deploy-key", handlers.DeleteDeployKeyHandler)
+	protected.PUT("/apps/:appId/deploy-key/known-hosts", handlers.PinKnownHostsHandler)
+
+	// Registry Image routes
+	protected.GET("/apps/:appId/registry-image", handlers.GetRegistryImageHandler)
+	protected.PUT("/apps/:appId/registry-image", handlers.UpdateRegistryImageHandler)
+	protected.DELETE("/apps/:appId/registry-image", handlers.DeleteRegistryImageHandler)
+
+	// Build Secret routes
+	protected.POST("/apps/:appId/build-secrets", handlers.CreateBuildSecretHandler)
+	protected.GET("/apps/:appId/build-secrets", handlers.ListBuildSecretsHandler)
+	protected.PUT("/build-secrets/:secretId", handlers.UpdateBuildSecretHandler)
+	protected.DELETE("/build-secrets/:secretId", handlers.DeleteBuildSecretHandler)
+
 	// Routing routes
 	protected.POST("/apps/:appId/routings", handlers.CreateRoutingHandler)
 	protected.GET("/apps/:appId/routings", handlers.ListRoutingsByAppHandler)
+	protected.POST("/routings/preflight", handlers.PreflightDomainHandler)
 	protected.PUT("/routings/:routingId", handlers.UpdateRoutingHandler)
 	protected.DELETE("/routings/:routingId", handlers.DeleteRoutingHandler)
+	protected.GET("/routings/:routingId/tls", handlers.GetRoutingTLSHandler)
+	protected.PUT("/routings/:routingId/tls", handlers.UpdateRoutingTLSHandler)
+	protected.DELETE("/routings/:routingId/tls", handlers.DeleteRoutingTLSHandler)
+	protected.POST("/routings/:routingId/dns-check", handlers.CheckRoutingDNSHandler)
 
 	// Application operations routes
 	protected.GET("/apps/:appId/status", handlers.GetAppRuntimeStatusHandler)
 	protected.GET("/apps/:appId/logs", handlers.GetApplicationLogsHandler)
 	protected.POST("/apps/:appId/actions/restart", handlers.RestartAppHandler)
 	protected.POST("/apps/:appId/actions/override-deploy", handlers.OverrideDeployHandler)
-	protected.GET("/projects/:projectId/branches", handlers.GetGitHubBranchesHandler)
+	protected.GET("/projects/:projectId/branches", handlers.GetRepositoryBranchesHandler)
 
 	// GitHub token management routes
 	protected.POST("/github-tokens", handlers.CreateGitHubToken)
@@ -344,6 +444,25 @@ func customHTTPErrorHandler(err error, c echo.Context) {
 	}
 }
 
+// loopbackOnlyMiddleware 只允许本机直接发起的请求。经 Caddy 反向代理转发的外部请求同样来自本机，
+// 以代理添加的转发头区分，不使用 RealIP 以免被伪造的 X-Forwarded-For 绕过
+func loopbackOnlyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		host, _, err := net.SplitHostPort(req.RemoteAddr)
+		ip := net.ParseIP(host)
+		if err != nil || ip == nil || !ip.IsLoopback() {
+			return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+		}
+		for _, header := range []string{"X-Forwarded-For", "X-Real-Ip", "Forwarded"} {
+			if req.Header.Get(header) != "" {
+				return echo.NewHTTPError(http.StatusForbidden, "Forbidden")
+			}
+		}
+		return next(c)
+	}
+}
+
 // echoAuthMiddleware converts the existing auth middleware to Echo format using JWT
 func echoAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
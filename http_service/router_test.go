@@ -95,3 +95,37 @@ func TestProtectedRoutesRequireAuth(t *testing.T) {
 		})
 	}
 }
+
+// TestCaddyTLSAskIsLoopbackOnly verifies that the on-demand TLS ask endpoint rejects remote and proxied requests
+func TestCaddyTLSAskIsLoopbackOnly(t *testing.T) {
+	e := NewEchoServer(testAssets)
+
+	testCases := []struct {
+		name          string
+		remoteAddr    string
+		forwardedFor  string
+		expectBlocked bool
+	}{
+		{name: "remote client", remoteAddr: "203.0.113.5:40000", expectBlocked: true},
+		{name: "proxied through local Caddy", remoteAddr: "127.0.0.1:40000", forwardedFor: "203.0.113.5", expectBlocked: true},
+		{name: "local Caddy", remoteAddr: "127.0.0.1:40000"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/caddy/tls-ask", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if tc.expectBlocked {
+				assert.Equal(t, http.StatusForbidden, rec.Code)
+			} else {
+				// 没有 domain 参数，通过中间件后由 handler 返回 400
+				assert.Equal(t, http.StatusBadRequest, rec.Code)
+			}
+		})
+	}
+}
@@ -56,6 +56,14 @@ func main() {
 	buildService := services.NewBuildService()
 	envService := services.NewDeploymentEnvironmentService()
 	deploymentOrchestrator := services.NewDeploymentOrchestrator(buildService, envService, podmanService)
+	// 恢复重启前仍在进行中的金丝雀发布的健康监控
+	deploymentOrchestrator.ResumeCanaryMonitors()
+	// 按各应用的保留策略定期清理过期的 Release、镜像和 unit
+	deploymentOrchestrator.StartRetentionScheduler()
+	// 定时把超过审批时限的部署标记为过期
+	deploymentOrchestrator.StartApprovalExpiryMonitor()
+	// 执行到期的定时部署，包括服务停止期间错过的
+	deploymentOrchestrator.StartDeploymentScheduler()
 
 	http_service.SetInstallationScripts(
 		func() string { return podmanInstallScript },
@@ -151,10 +159,17 @@ func initDB(dbPath string) error {
 		&models.Application{},
 		&models.ProviderAuth{},
 		&models.EnvironmentVariable{},
+		&models.BuildSecret{},
+		&models.DeployKey{},
 		&models.Deployment{},
 		&models.DeploymentLog{},
+		&models.CanaryRelease{},
+		&models.DeployFreezeWindow{},
+		&models.ScheduledDeployment{},
 		&models.Release{},
+		&models.ImageUpload{},
 		&models.Routing{},
+		&models.RoutingTLSConfig{},
 
 		&models.GitHubToken{},
 		&models.ProjectCredential{},
@@ -168,6 +183,9 @@ func initDB(dbPath string) error {
 	if err := dborm.Db.AutoMigrate(modelsToMigrate...); err != nil {
 		return fmt.Errorf("failed to auto migrate models: %w", err)
 	}
+	if err := models.MigrateRoutingHostPortIndex(dborm.Db); err != nil {
+		return fmt.Errorf("failed to migrate routing host port index: %w", err)
+	}
 
 	return nil
 }
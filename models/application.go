@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/google/uuid"
 	"github.com/opentdp/go-helper/dborm"
 	"gorm.io/gorm"
@@ -58,6 +59,121 @@ func (j *JSONB) UnmarshalJSON(data []byte) error {
 	return json.Unmarshal(data, &j.Data)
 }
 
+// VolumeMount 应用卷挂载，对应 Volumes 中的一项
+type VolumeMount struct {
+	HostPath      string `json:"host_path"`
+	ContainerPath string `json:"container_path"`
+	ReadOnly      bool   `json:"read_only,omitempty"`
+}
+
+// QuadletValue 返回 Quadlet Volume= 的值
+func (v VolumeMount) QuadletValue() string {
+	value := v.HostPath + ":" + v.ContainerPath
+	if v.ReadOnly {
+		value += ":ro"
+	}
+	return value
+}
+
+// ParseVolumeMounts 解析 Volumes 字段，忽略格式不正确的项
+func ParseVolumeMounts(volumes JSONB) []VolumeMount {
+	var mounts []VolumeMount
+	items, ok := volumes.Data.([]interface{})
+	if !ok {
+		return mounts
+	}
+	for _, item := range items {
+		vol, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hostPath, hok := vol["host_path"].(string)
+		containerPath, cok := vol["container_path"].(string)
+		if !hok || !cok {
+			continue
+		}
+		readOnly, _ := vol["read_only"].(bool)
+		mounts = append(mounts, VolumeMount{HostPath: hostPath, ContainerPath: containerPath, ReadOnly: readOnly})
+	}
+	return mounts
+}
+
+// NewVolumesJSONB 将卷挂载列表转换为 Volumes 字段的值
+func NewVolumesJSONB(mounts []VolumeMount) JSONB {
+	items := make([]interface{}, 0, len(mounts))
+	for _, m := range mounts {
+		item := map[string]interface{}{"host_path": m.HostPath, "container_path": m.ContainerPath}
+		if m.ReadOnly {
+			item["read_only"] = true
+		}
+		items = append(items, item)
+	}
+	return JSONB{Data: items}
+}
+
+// BuildCacheSettings 应用的构建缓存设置，零值即默认行为：复用克隆目录和层缓存，每次构建拉取基础镜像
+type BuildCacheSettings struct {
+	DisableBuildCache bool `gorm:"not null;default:false"` // 每次构建重新克隆仓库，并以 --no-cache 构建
+	DisablePullAlways bool `gorm:"not null;default:false"` // 不使用 --pull-always，本地已有的基础镜像不再拉取
+}
+
+// AutoDeploySettings 收到代码仓库 webhook 或镜像推送时的自动部署设置
+type AutoDeploySettings struct {
+	AutoDeployOnPush      bool `gorm:"not null;default:false"` // 推送到应用分支时自动构建并部署该提交
+	AutoDeployOnTag       bool `gorm:"not null;default:false"` // 推送标签时自动构建并部署该标签
+	AutoDeployOnImagePush bool `gorm:"not null;default:false"` // 向内置镜像仓库推送镜像后自动部署新的 Release
+}
+
+// RegistryImageSettings 从外部镜像仓库拉取镜像作为 Release 来源的设置，ImageRef 为空时从代码仓库构建
+type RegistryImageSettings struct {
+	ImageRef         string `gorm:"size:500;not null;default:''"` // 镜像引用, e.g., "ghcr.io/acme/web:1.2.0"
+	RegistryUsername string `gorm:"size:255;not null;default:''"`
+	RegistryPassword string `gorm:"type:text"` // 加密后的密码或访问令牌
+}
+
+// DefaultApprovalTimeoutHours 受保护应用的部署默认等待审批的时限
+const DefaultApprovalTimeoutHours = 24
+
+// ApprovalSettings 受保护应用的部署审批设置，Protected 为 true 时部署需要审批后才会执行
+type ApprovalSettings struct {
+	Protected            bool   `gorm:"not null;default:false"`
+	ApprovalTimeoutHours int    `gorm:"not null;default:24"` // 等待审批的时限，超时后部署过期
+	Approvers            string `gorm:"size:1000"`           // 可以审批部署的用户名，逗号分隔；为空时任何登录用户都可以审批
+}
+
+// ApproverList 返回可以审批部署的用户名列表
+func (s ApprovalSettings) ApproverList() []string {
+	var approvers []string
+	for _, name := range strings.Split(s.Approvers, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			approvers = append(approvers, name)
+		}
+	}
+	return approvers
+}
+
+// CanApprove 判断用户是否可以审批应用的部署
+func (s ApprovalSettings) CanApprove(username string) bool {
+	approvers := s.ApproverList()
+	if len(approvers) == 0 {
+		return true
+	}
+	for _, name := range approvers {
+		if name == username {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalTimeout 返回等待审批的时限，未设置时使用默认值
+func (s ApprovalSettings) ApprovalTimeout() time.Duration {
+	if s.ApprovalTimeoutHours <= 0 {
+		return DefaultApprovalTimeoutHours * time.Hour
+	}
+	return time.Duration(s.ApprovalTimeoutHours) * time.Hour
+}
+
 // Application 代表一个实际运行的环境实例 (e.g., my-app-prod, my-app-staging).
 // 这是系统的核心模型，存储了应用的"意图状态"。
 type Application struct {
@@ -73,6 +189,17 @@ type Application struct {
 	Branch    *string `gorm:"size:255;default:'main'"`      // 可选的分支名称，用于GitHub部署，默认main
 	BuildDir  *string `gorm:"size:255;default:'/'"`         // 可选的构建目录，默认根目录
 	BuildType *string `gorm:"size:50;default:'dockerfile'"` // 可选的构建类型，如 dockerfile, railpack, nixpacks 等，默认dockerfile
+	// Dockerfile 路径（相对于 BuildDir）及多阶段构建的目标阶段
+	DockerfilePath *string `gorm:"size:255;default:'Dockerfile'"`
+	BuildTarget    *string `gorm:"size:255"`
+	// 构建缓存设置
+	BuildCache BuildCacheSettings `gorm:"embedded"`
+	// 推送自动部署设置
+	AutoDeploy AutoDeploySettings `gorm:"embedded"`
+	// 镜像仓库来源设置
+	RegistryImage RegistryImageSettings `gorm:"embedded"`
+	// 部署审批设置
+	Approval ApprovalSettings `gorm:"embedded"`
 
 	ActiveReleaseID *uuid.UUID `gorm:"type:char(36);index"` // 指向当前线上运行的版本, 使用指针以允许为空
 	TargetPort      int        `gorm:"not null"`              // 容器内部监听的端口
@@ -84,6 +211,16 @@ type Application struct {
 	ExecCommand      *string `gorm:"size:255"`   // 可选的容器启动命令 (override image's default command)
 	AutoUpdatePolicy *string `gorm:"size:50"`    // 可选的自动更新策略 (e.g., "registry")"
 
+	// 资源限制，生成 Quadlet 时渲染为 PodmanArgs 和 [Service] 设置
+	Resources utils.ResourceLimits `gorm:"embedded"`
+	// Release 保留策略，定期清理过期的镜像、Quadlet 文件和 unit
+	Retention               utils.RetentionPolicy `gorm:"embedded"`
+	RetentionLastRunAt      *time.Time            // 上次执行清理的时间
+	RetentionReclaimedBytes int64                 `gorm:"not null;default:0"` // 上次清理回收的磁盘空间
+
+	// 基于系统基础域名自动生成的主机名, e.g., "web.shop.apps.example.com"，首次部署成功时生成
+	GeneratedHostname string `gorm:"size:255;not null;default:''"`
+
 	// 关联关系 (GORM Associations)
 	ActiveRelease        *Release              `gorm:"foreignKey:ActiveReleaseID"`
 	Releases             []Release             `gorm:"foreignKey:ApplicationID"`
@@ -214,6 +351,109 @@ func UpdateApplicationFromFrontend(id uuid.UUID, description string, repoURL *st
 	return application, nil
 }
 
+// UpdateApplicationResourceLimits updates the resource limits of an application
+func UpdateApplicationResourceLimits(id uuid.UUID, limits utils.ResourceLimits) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(
+		"cpu_quota", "memory_limit_mb", "memory_reservation_mb", "pids_limit", "oom_policy",
+	).Updates(&Application{Resources: limits}).Error
+}
+
+// UpdateApplicationRetentionPolicy updates the release retention policy of an application
+func UpdateApplicationRetentionPolicy(id uuid.UUID, policy utils.RetentionPolicy) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(
+		"retention_keep_last", "retention_keep_days",
+	).Updates(&Application{Retention: policy}).Error
+}
+
+// UpdateApplicationRetentionResult records when retention last ran and how much disk space it reclaimed
+func UpdateApplicationRetentionResult(id uuid.UUID, ranAt time.Time, reclaimedBytes int64) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"retention_last_run_at":     ranAt,
+		"retention_reclaimed_bytes": reclaimedBytes,
+	}).Error
+}
+
+// ListApplicationsWithRetention retrieves applications that have a retention policy
+func ListApplicationsWithRetention() ([]*Application, error) {
+	var applications []*Application
+	if err := dborm.Db.Where("retention_keep_last > 0 OR retention_keep_days > 0").Find(&applications).Error; err != nil {
+		return nil, err
+	}
+	return applications, nil
+}
+
+// UpdateApplicationBuildSettings updates the Dockerfile path and build target of an application
+func UpdateApplicationBuildSettings(id uuid.UUID, dockerfilePath, buildTarget *string) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(
+		"dockerfile_path", "build_target",
+	).Updates(&Application{DockerfilePath: dockerfilePath, BuildTarget: buildTarget}).Error
+}
+
+// UpdateApplicationBuildCacheSettings updates the build cache settings of an application
+func UpdateApplicationBuildCacheSettings(id uuid.UUID, settings BuildCacheSettings) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(
+		"disable_build_cache", "disable_pull_always", "cache_from_previous",
+	).Updates(&Application{BuildCache: settings}).Error
+}
+
+// UpdateApplicationAutoDeploySettings updates the push-to-deploy settings of an application
+func UpdateApplicationAutoDeploySettings(id uuid.UUID, settings AutoDeploySettings) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(
+		"auto_deploy_on_push", "auto_deploy_on_tag", "auto_deploy_on_image_push",
+	).Updates(&Application{AutoDeploy: settings}).Error
+}
+
+// UpdateApplicationApprovalSettings updates the deployment approval settings of an application
+func UpdateApplicationApprovalSettings(id uuid.UUID, settings ApprovalSettings) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(
+		"protected", "approval_timeout_hours", "approvers",
+	).Updates(&Application{Approval: settings}).Error
+}
+
+// UpdateApplicationRegistryImage updates the registry image source of an application.
+// password 为 nil 时保留已保存的密码，密码在保存前加密
+func UpdateApplicationRegistryImage(id uuid.UUID, imageRef, username string, password *string) error {
+	columns := []string{"image_ref", "registry_username"}
+	settings := RegistryImageSettings{ImageRef: imageRef, RegistryUsername: username}
+	if password != nil {
+		if *password != "" {
+			encrypted, err := utils.EncryptValue(*password)
+			if err != nil {
+				return err
+			}
+			settings.RegistryPassword = encrypted
+		}
+		columns = append(columns, "registry_password")
+	}
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Select(columns).Updates(&Application{RegistryImage: settings}).Error
+}
+
+// AdvanceApplicationRegistryImageRef replaces the registry image reference with a newer tag,
+// unless it was changed since it was read
+func AdvanceApplicationRegistryImageRef(id uuid.UUID, currentRef, newRef string) error {
+	return dborm.Db.Model(&Application{}).Where("id = ? AND image_ref = ?", id, currentRef).Update("image_ref", newRef).Error
+}
+
+// DecryptedPassword returns the decrypted registry password
+func (s RegistryImageSettings) DecryptedPassword() (string, error) {
+	if s.RegistryPassword == "" {
+		return "", nil
+	}
+	return utils.DecryptValue(s.RegistryPassword)
+}
+
+// UpdateApplicationRuntimeSettingsInTx updates the settings reconciled from orbitdeploy.toml within a transaction
+func UpdateApplicationRuntimeSettingsInTx(tx *gorm.DB, id uuid.UUID, targetPort int, volumes JSONB, limits utils.ResourceLimits) error {
+	return tx.Model(&Application{}).Where("id = ?", id).Select(
+		"target_port", "volumes", "cpu_quota", "memory_limit_mb", "memory_reservation_mb", "pids_limit", "oom_policy",
+	).Updates(&Application{TargetPort: targetPort, Volumes: volumes, Resources: limits}).Error
+}
+
+// UpdateApplicationGeneratedHostname updates the auto-generated hostname of an application
+func UpdateApplicationGeneratedHostname(id uuid.UUID, hostname string) error {
+	return dborm.Db.Model(&Application{}).Where("id = ?", id).Update("generated_hostname", hostname).Error
+}
+
 // DeleteApplication deletes an application by its ID
 func DeleteApplication(id uuid.UUID) error {
 	return dborm.Db.Where("id = ?", id).Delete(&Application{}).Error
@@ -228,6 +468,15 @@ func GetApplicationByName(name string) (*Application, error) {
 	return &application, nil
 }
 
+// GetApplicationByProjectIDAndName retrieves an application by name within a project
+func GetApplicationByProjectIDAndName(projectID uuid.UUID, name string) (*Application, error) {
+	var application Application
+	if err := dborm.Db.Where("project_id = ? AND name = ?", projectID, name).First(&application).Error; err != nil {
+		return nil, err
+	}
+	return &application, nil
+}
+
 // ListApplicationsByProjectName retrieves applications by project name
 func ListApplicationsByProjectName(projectName string) ([]*Application, error) {
 	var applications []*Application
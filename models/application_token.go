@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/OrbitDeploy/OrbitDeploy/utils"
@@ -21,6 +22,7 @@ type ApplicationToken struct {
 	ExpiresAt     *time.Time `json:"expires_at"`                             // 令牌过期时间（可选）
 	LastUsedAt    *time.Time `json:"last_used_at"`                           // 最后使用时间
 	IsActive      bool       `json:"is_active" gorm:"default:true;not null"` // 是否激活
+	CreatedBy     string     `json:"created_by" gorm:"size:255"`             // 创建令牌的用户，令牌发起的部署视为该用户发起
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 
@@ -60,8 +62,28 @@ func GenerateRandomToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
+// Requester returns how deployments triggered with the token are recorded, e.g. "token:ci (alice)"
+func (t *ApplicationToken) Requester() string {
+	if t.CreatedBy == "" {
+		return "token:" + t.Name
+	}
+	return fmt.Sprintf("token:%s (%s)", t.Name, t.CreatedBy)
+}
+
+// RequesterUsername returns the user behind a recorded requester: the user itself, or the creator of the token
+func RequesterUsername(requestedBy string) string {
+	if !strings.HasPrefix(requestedBy, "token:") {
+		return requestedBy
+	}
+	i := strings.LastIndex(requestedBy, " (")
+	if i < 0 || !strings.HasSuffix(requestedBy, ")") {
+		return ""
+	}
+	return requestedBy[i+2 : len(requestedBy)-1]
+}
+
 // CreateApplicationToken creates a new application token record
-func CreateApplicationToken(applicationID uuid.UUID, name string, expiresAt *time.Time) (*ApplicationToken, string, error) {
+func CreateApplicationToken(applicationID uuid.UUID, name, createdBy string, expiresAt *time.Time) (*ApplicationToken, string, error) {
 	if name == "" {
 		return nil, "", fmt.Errorf("name is required")
 	}
@@ -84,6 +106,7 @@ func CreateApplicationToken(applicationID uuid.UUID, name string, expiresAt *tim
 		TokenHash:     encryptedToken,
 		ExpiresAt:     expiresAt,
 		IsActive:      true,
+		CreatedBy:     createdBy,
 	}
 
 	if err := dborm.Db.Create(appToken).Error; err != nil {
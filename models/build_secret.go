@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// BuildSecret 构建时使用的密钥，通过 podman build --secret 挂载，不会写入镜像层。
+// 值始终加密存储，API 只返回名称
+type BuildSecret struct {
+	ID            uuid.UUID `gorm:"type:char(36);primary_key"`
+	ApplicationID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_build_secret_app_name"`
+	Name          string    `gorm:"not null;size:255;uniqueIndex:idx_build_secret_app_name"` // 即 --secret id=<Name>
+	Value         string    `gorm:"type:text"`                                               // 加密后的值
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (bs *BuildSecret) BeforeCreate(tx *gorm.DB) (err error) {
+	bs.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the BuildSecret model
+func (BuildSecret) TableName() string {
+	return "build_secrets"
+}
+
+// CreateBuildSecret creates a new build secret with an encrypted value
+func CreateBuildSecret(applicationID uuid.UUID, name, value string) (*BuildSecret, error) {
+	storedValue, err := utils.EncryptValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &BuildSecret{
+		ApplicationID: applicationID,
+		Name:          name,
+		Value:         storedValue,
+	}
+	if err := dborm.Db.Create(secret).Error; err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// GetBuildSecretByID retrieves a build secret by its ID
+func GetBuildSecretByID(id uuid.UUID) (*BuildSecret, error) {
+	var secret BuildSecret
+	if err := dborm.Db.Where("id = ?", id).First(&secret).Error; err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// ListBuildSecretsByApplicationID retrieves all build secrets for an application
+func ListBuildSecretsByApplicationID(applicationID uuid.UUID) ([]*BuildSecret, error) {
+	var secrets []*BuildSecret
+	if err := dborm.Db.Where("application_id = ?", applicationID).Order("name").Find(&secrets).Error; err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// UpdateBuildSecretValue replaces the value of a build secret
+func UpdateBuildSecretValue(id uuid.UUID, value string) (*BuildSecret, error) {
+	secret, err := GetBuildSecretByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	storedValue, err := utils.EncryptValue(value)
+	if err != nil {
+		return nil, err
+	}
+	secret.Value = storedValue
+	if err := dborm.Db.Save(secret).Error; err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// DeleteBuildSecret deletes a build secret by its ID
+func DeleteBuildSecret(id uuid.UUID) error {
+	return dborm.Db.Where("id = ?", id).Delete(&BuildSecret{}).Error
+}
+
+// GetBuildSecrets returns the decrypted build secrets of an application, keyed by name
+func GetBuildSecrets(applicationID uuid.UUID) (map[string]string, error) {
+	secrets, err := ListBuildSecretsByApplicationID(applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		value, err := utils.DecryptValue(secret.Value)
+		if err != nil {
+			return nil, err
+		}
+		values[secret.Name] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// 金丝雀发布状态
+const (
+	CanaryStatusPending  = "pending"  // 新版本尚未启动
+	CanaryStatusRunning  = "running"  // 新旧版本同时运行，按权重分配流量
+	CanaryStatusPromoted = "promoted" // 新版本已接管全部流量
+	CanaryStatusAborted  = "aborted"  // 已中止，流量回到旧版本
+)
+
+// CanaryRelease 记录单机金丝雀发布：新旧两个 unit 同时运行，Caddy 按权重分配流量
+type CanaryRelease struct {
+	ID                   uuid.UUID `gorm:"type:char(36);primary_key"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	ApplicationID        uuid.UUID `gorm:"type:char(36);not null;index"`
+	DeploymentID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex"` // 新版本的部署
+	BaselineDeploymentID uuid.UUID `gorm:"type:char(36);not null"`             // 旧版本的部署
+	BaselinePort         int       `gorm:"not null"`
+	CanaryPort           int       `gorm:"not null;default:0"`
+	Weight               int       `gorm:"not null;default:10"` // 新版本的流量百分比 (0-100)
+	StepWeight           int       `gorm:"not null;default:10"` // 每次 step 增加的百分比
+	MaxErrorRate         float64   `gorm:"not null;default:5"`  // 新版本探测 HealthPath 的错误率比旧版本高出该百分点时自动中止
+	HealthPath           string    `gorm:"size:255;not null;default:'/'"`
+	Status               string    `gorm:"size:20;not null;default:'pending'"`
+	AbortReason          string    `gorm:"type:text"`
+	FinishedAt           *time.Time
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (c *CanaryRelease) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the CanaryRelease model
+func (CanaryRelease) TableName() string {
+	return "canary_releases"
+}
+
+// CreateCanaryRelease creates a new canary release record
+func CreateCanaryRelease(canary *CanaryRelease) error {
+	canary.Status = CanaryStatusPending
+	return dborm.Db.Create(canary).Error
+}
+
+// GetCanaryReleaseByID retrieves a canary release by its ID
+func GetCanaryReleaseByID(id uuid.UUID) (*CanaryRelease, error) {
+	var canary CanaryRelease
+	if err := dborm.Db.Where("id = ?", id).First(&canary).Error; err != nil {
+		return nil, err
+	}
+	return &canary, nil
+}
+
+// GetCanaryReleaseByDeploymentID retrieves the canary release of a deployment, or nil if the deployment is not a canary
+func GetCanaryReleaseByDeploymentID(deploymentID uuid.UUID) (*CanaryRelease, error) {
+	var canary CanaryRelease
+	if err := dborm.Db.Where("deployment_id = ?", deploymentID).First(&canary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &canary, nil
+}
+
+// GetActiveCanaryReleaseByAppID retrieves the pending or running canary release of an application, or nil if none
+func GetActiveCanaryReleaseByAppID(appID uuid.UUID) (*CanaryRelease, error) {
+	var canary CanaryRelease
+	err := dborm.Db.
+		Where("application_id = ? AND status IN ?", appID, []string{CanaryStatusPending, CanaryStatusRunning}).
+		Order("created_at DESC").
+		First(&canary).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &canary, nil
+}
+
+// GetLatestCanaryReleaseByAppID retrieves the most recent canary release of an application
+func GetLatestCanaryReleaseByAppID(appID uuid.UUID) (*CanaryRelease, error) {
+	var canary CanaryRelease
+	if err := dborm.Db.Where("application_id = ?", appID).Order("created_at DESC").First(&canary).Error; err != nil {
+		return nil, err
+	}
+	return &canary, nil
+}
+
+// ListRunningCanaryReleases retrieves all running canary releases
+func ListRunningCanaryReleases() ([]*CanaryRelease, error) {
+	var canaries []*CanaryRelease
+	if err := dborm.Db.Where("status = ?", CanaryStatusRunning).Find(&canaries).Error; err != nil {
+		return nil, err
+	}
+	return canaries, nil
+}
+
+// ListPendingCanaryReleases retrieves all canary releases that have not started yet
+func ListPendingCanaryReleases() ([]*CanaryRelease, error) {
+	var canaries []*CanaryRelease
+	if err := dborm.Db.Where("status = ?", CanaryStatusPending).Find(&canaries).Error; err != nil {
+		return nil, err
+	}
+	return canaries, nil
+}
+
+// StartCanaryRelease marks a canary release as running on the given port
+func StartCanaryRelease(id uuid.UUID, canaryPort int) error {
+	return dborm.Db.Model(&CanaryRelease{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"canary_port": canaryPort,
+		"status":      CanaryStatusRunning,
+	}).Error
+}
+
+// UpdateCanaryReleaseWeight updates the traffic percentage of a canary release
+func UpdateCanaryReleaseWeight(id uuid.UUID, weight int) error {
+	return dborm.Db.Model(&CanaryRelease{}).Where("id = ?", id).Update("weight", weight).Error
+}
+
+// FinishCanaryRelease marks a canary release as promoted or aborted
+func FinishCanaryRelease(id uuid.UUID, status, reason string) error {
+	now := time.Now()
+	return dborm.Db.Model(&CanaryRelease{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       status,
+		"abort_reason": reason,
+		"finished_at":  &now,
+	}).Error
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// DeployKey 应用拉取 SSH 仓库使用的部署密钥，每个应用最多一个。
+// 私钥加密存储，KnownHosts 为固定的仓库主机密钥，构建时严格校验
+type DeployKey struct {
+	ID            uuid.UUID `gorm:"type:char(36);primary_key"`
+	ApplicationID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex"`
+	PublicKey     string    `gorm:"type:text;not null"`
+	PrivateKey    string    `gorm:"type:text;not null"` // 加密后的私钥
+	Fingerprint   string    `gorm:"size:255"`
+	KnownHosts    string    `gorm:"type:text"` // known_hosts 格式
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (dk *DeployKey) BeforeCreate(tx *gorm.DB) (err error) {
+	dk.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the DeployKey model
+func (DeployKey) TableName() string {
+	return "deploy_keys"
+}
+
+// GetDeployKeyByApplicationID retrieves the deploy key of an application
+func GetDeployKeyByApplicationID(applicationID uuid.UUID) (*DeployKey, error) {
+	var key DeployKey
+	if err := dborm.Db.Where("application_id = ?", applicationID).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// SaveDeployKey 创建或轮换应用的部署密钥，轮换时保留已固定的主机密钥
+func SaveDeployKey(applicationID uuid.UUID, pair *utils.DeployKeyPair) (*DeployKey, error) {
+	encrypted, err := utils.EncryptValue(pair.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := GetDeployKeyByApplicationID(applicationID)
+	if err != nil {
+		key = &DeployKey{ApplicationID: applicationID}
+	}
+	key.PublicKey = pair.PublicKey
+	key.PrivateKey = encrypted
+	key.Fingerprint = pair.Fingerprint
+
+	if err := dborm.Db.Save(key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// UpdateDeployKeyKnownHosts updates the pinned host keys of an application's deploy key
+func UpdateDeployKeyKnownHosts(applicationID uuid.UUID, knownHosts string) error {
+	return dborm.Db.Model(&DeployKey{}).Where("application_id = ?", applicationID).Update("known_hosts", knownHosts).Error
+}
+
+// DeleteDeployKey deletes the deploy key of an application
+func DeleteDeployKey(applicationID uuid.UUID) error {
+	return dborm.Db.Where("application_id = ?", applicationID).Delete(&DeployKey{}).Error
+}
+
+// GetDecryptedPrivateKey returns the decrypted private key
+func (dk *DeployKey) GetDecryptedPrivateKey() (string, error) {
+	return utils.DecryptValue(dk.PrivateKey)
+}
@@ -1,13 +1,23 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
 	"github.com/google/uuid"
 	"github.com/opentdp/go-helper/dborm"
 	"gorm.io/gorm"
 )
 
+// 部署状态
+const (
+	DeploymentStatusInProgress       = "in_progress"
+	DeploymentStatusAwaitingApproval = "awaiting_approval" // 受保护应用的部署等待审批
+	DeploymentStatusRejected         = "rejected"          // 审批被拒绝，未执行
+	DeploymentStatusExpired          = "expired"           // 超过审批时限，未执行
+)
+
 // Deployment 记录将一个 Release 应用到 Application 的过程，是纯粹的日志。
 // 用于追踪部署历史和排查问题。
 type Deployment struct {
@@ -25,6 +35,22 @@ type Deployment struct {
 	Snapshot      string `gorm:"type:text"`    // JSON snapshot of environment variables at deployment time
 	SystemPort    *int   `gorm:"default:null"` // 系统分配的端口，可选字段（仅在部署成功时分配）
 
+	CommitSHA            string `gorm:"size:64;not null;default:''"` // 部署的提交，用于向代码托管平台回报状态
+	ProviderDeploymentID int64  `gorm:"not null;default:0"`          // 对应的 GitHub Deployment ID，0 表示未创建
+
+	// 受保护应用的审批记录
+	RequestedBy       string     `gorm:"size:255;not null;default:''"` // 发起部署的用户或应用令牌，自动部署时为空
+	ApprovalExpiresAt *time.Time // 等待审批的截止时间
+	ReviewedBy        string     `gorm:"size:255;not null;default:''"` // 批准或拒绝部署的用户
+	ReviewedAt        *time.Time
+	ReviewComment     string `gorm:"type:text"`
+
+	FreezeOverrideReason string `gorm:"type:text"` // 在冻结窗口内强制部署的原因
+
+	// 部署时生成的 Quadlet 内容和路由、资源配置（DeploymentConfigSnapshot 的 JSON），用于比较两次部署
+	QuadletContent string `gorm:"type:text"`
+	ConfigSnapshot string `gorm:"type:text"`
+
 	Release     Release     `gorm:"foreignKey:ReleaseID"`
 	Application Application `gorm:"foreignKey:ApplicationID"`
 }
@@ -76,6 +102,55 @@ func GetDeploymentByID(id uuid.UUID) (*Deployment, error) {
 	return &deployment, nil
 }
 
+// GetLatestDeploymentByRelease retrieves the latest successful deployment of a release that has a system port
+func GetLatestDeploymentByRelease(appID, releaseID uuid.UUID) (*Deployment, error) {
+	var deployment Deployment
+	if err := dborm.Db.
+		Where("application_id = ? AND release_id = ? AND status = ? AND system_port IS NOT NULL", appID, releaseID, "success").
+		Order("created_at DESC").
+		First(&deployment).Error; err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// GetLatestDeploymentOfRelease retrieves the most recent deployment of a release, preferring successful ones
+func GetLatestDeploymentOfRelease(releaseID uuid.UUID) (*Deployment, error) {
+	var deployment Deployment
+	if err := dborm.Db.
+		Where("release_id = ?", releaseID).
+		Order("CASE WHEN status = 'success' THEN 0 ELSE 1 END, created_at DESC").
+		First(&deployment).Error; err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// ListSuccessfulDeploymentsByAppID retrieves the latest successful deployments of an application, most recent first
+func ListSuccessfulDeploymentsByAppID(appID uuid.UUID, limit int) ([]*Deployment, error) {
+	var deployments []*Deployment
+	if err := dborm.Db.
+		Where("application_id = ? AND status = ?", appID, "success").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deployments).Error; err != nil {
+		return nil, err
+	}
+	return deployments, nil
+}
+
+// GetLatestSuccessfulDeploymentByAppID retrieves the most recent successful deployment of an application
+func GetLatestSuccessfulDeploymentByAppID(appID uuid.UUID) (*Deployment, error) {
+	var deployment Deployment
+	if err := dborm.Db.
+		Where("application_id = ? AND status = ?", appID, "success").
+		Order("created_at DESC").
+		First(&deployment).Error; err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
 // ListDeployments retrieves all deployments
 func ListDeployments() ([]*Deployment, error) {
 	var deployments []*Deployment
@@ -173,6 +248,110 @@ func IsPortInUse(port int) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// UpdateDeploymentCommit 记录部署的提交以及在代码托管平台上创建的 Deployment ID
+func UpdateDeploymentCommit(deploymentID uuid.UUID, commitSHA string, providerDeploymentID int64) error {
+	return dborm.Db.Model(&Deployment{}).Where("id = ?", deploymentID).Updates(map[string]interface{}{
+		"commit_sha":             commitSHA,
+		"provider_deployment_id": providerDeploymentID,
+	}).Error
+}
+
+// RequestDeploymentApproval 记录部署的发起人和审批截止时间
+func RequestDeploymentApproval(deploymentID uuid.UUID, requestedBy string, expiresAt time.Time) error {
+	return dborm.Db.Model(&Deployment{}).Where("id = ?", deploymentID).Updates(map[string]interface{}{
+		"requested_by":        requestedBy,
+		"approval_expires_at": expiresAt,
+	}).Error
+}
+
+// ReviewDeployment 记录审批结果并把部署切换到 status，只更新仍在等待审批的部署；
+// 返回 false 表示部署已被其他请求处理（并发审批或已过期）
+func ReviewDeployment(deploymentID uuid.UUID, status, reviewedBy, comment string) (bool, error) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":         status,
+		"reviewed_by":    reviewedBy,
+		"reviewed_at":    now,
+		"review_comment": comment,
+	}
+	if status != DeploymentStatusInProgress {
+		updates["finished_at"] = now
+	} else {
+		updates["started_at"] = now
+	}
+	result := dborm.Db.Model(&Deployment{}).
+		Where("id = ? AND status = ?", deploymentID, DeploymentStatusAwaitingApproval).
+		Updates(updates)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateDeploymentFreezeOverride records why a deployment was allowed during a freeze window
+func UpdateDeploymentFreezeOverride(deploymentID uuid.UUID, reason string) error {
+	return dborm.Db.Model(&Deployment{}).Where("id = ?", deploymentID).Update("freeze_override_reason", reason).Error
+}
+
+// ListExpiredDeploymentApprovals retrieves deployments still awaiting approval after their deadline
+func ListExpiredDeploymentApprovals(now time.Time) ([]*Deployment, error) {
+	var deployments []*Deployment
+	if err := dborm.Db.
+		Where("status = ? AND approval_expires_at IS NOT NULL AND approval_expires_at < ?", DeploymentStatusAwaitingApproval, now).
+		Find(&deployments).Error; err != nil {
+		return nil, err
+	}
+	return deployments, nil
+}
+
 func UpdateDeploymentSystemPort(deploymentID uuid.UUID, systemPort int) error {
 	return dborm.Db.Model(&Deployment{}).Where("id = ?", deploymentID).Update("system_port", systemPort).Error
 }
+
+// DeploymentRoutingSnapshot 部署时生效的路由
+type DeploymentRoutingSnapshot struct {
+	DomainName string `json:"domainName"`
+	HostPort   int    `json:"hostPort"`
+}
+
+// DeploymentConfigSnapshot 部署时应用的端口、路由和资源配置
+type DeploymentConfigSnapshot struct {
+	TargetPort int                         `json:"targetPort"`
+	Routings   []DeploymentRoutingSnapshot `json:"routings"`
+	Resources  utils.ResourceLimits        `json:"resources"`
+}
+
+// NewDeploymentConfigSnapshot 根据应用和生效的路由生成配置快照
+func NewDeploymentConfigSnapshot(application *Application, routings []*Routing) DeploymentConfigSnapshot {
+	snapshot := DeploymentConfigSnapshot{
+		TargetPort: application.TargetPort,
+		Routings:   make([]DeploymentRoutingSnapshot, 0, len(routings)),
+		Resources:  application.Resources,
+	}
+	for _, routing := range routings {
+		snapshot.Routings = append(snapshot.Routings, DeploymentRoutingSnapshot{DomainName: routing.DomainName, HostPort: routing.HostPort})
+	}
+	return snapshot
+}
+
+// ParseDeploymentConfigSnapshot 解析部署的配置快照，旧的部署没有快照时返回 nil
+func ParseDeploymentConfigSnapshot(snapshot string) (*DeploymentConfigSnapshot, error) {
+	if snapshot == "" {
+		return nil, nil
+	}
+	var config DeploymentConfigSnapshot
+	if err := json.Unmarshal([]byte(snapshot), &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateDeploymentRuntimeSnapshot records the generated quadlet content and configuration of a deployment
+func UpdateDeploymentRuntimeSnapshot(deploymentID uuid.UUID, quadletContent string, config DeploymentConfigSnapshot) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return dborm.Db.Model(&Deployment{}).Where("id = ?", deploymentID).Updates(map[string]interface{}{
+		"quadlet_content": quadletContent,
+		"config_snapshot": string(data),
+	}).Error
+}
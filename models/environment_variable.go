@@ -17,6 +17,7 @@ type EnvironmentVariable struct {
 	Key           string    `gorm:"not null;size:255"`
 	Value         string    `gorm:"type:text"`              // 存储加密后的值
 	IsEncrypted   bool      `gorm:"not null;default:false"` // 是否加密
+	IsBuildTime   bool      `gorm:"not null;default:false"` // 是否作为构建参数传入 podman build
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 
@@ -147,6 +148,29 @@ func DeleteEnvironmentVariablesByApplicationID(applicationID uuid.UUID) error {
 	return dborm.Db.Where("application_id = ?", applicationID).Delete(&EnvironmentVariable{}).Error
 }
 
+// SetEnvironmentVariableBuildTime marks whether an environment variable is passed as a build arg
+func SetEnvironmentVariableBuildTime(id uuid.UUID, isBuildTime bool) error {
+	return dborm.Db.Model(&EnvironmentVariable{}).Where("id = ?", id).Update("is_build_time", isBuildTime).Error
+}
+
+// GetBuildArgs returns the decrypted build-time variables of an application
+func GetBuildArgs(applicationID uuid.UUID) (map[string]string, error) {
+	var envVars []*EnvironmentVariable
+	if err := dborm.Db.Where("application_id = ? AND is_build_time = ?", applicationID, true).Find(&envVars).Error; err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]string, len(envVars))
+	for _, envVar := range envVars {
+		value, err := envVar.GetDecryptedValue()
+		if err != nil {
+			return nil, err
+		}
+		args[envVar.Key] = value
+	}
+	return args, nil
+}
+
 // GetDecryptedValue returns the decrypted value of an environment variable
 func (env *EnvironmentVariable) GetDecryptedValue() (string, error) {
 	if !env.IsEncrypted {
@@ -202,3 +226,78 @@ func CreateSnapshotForDeployment(applicationID uuid.UUID) (string, error) {
 
 	return string(jsonBytes), nil
 }
+
+// EnvironmentVariableInput 批量设置环境变量时的单个变量
+type EnvironmentVariableInput struct {
+	Key         string
+	Value       string
+	IsEncrypted bool
+}
+
+// SetEnvironmentVariables 在同一事务中按 key 新增或更新变量，并删除 unset 中的变量
+func SetEnvironmentVariables(applicationID uuid.UUID, vars []EnvironmentVariableInput, unset []string) (created, updated, deleted int, err error) {
+	err = dborm.Db.Transaction(func(tx *gorm.DB) error {
+		created, updated, deleted, err = SetEnvironmentVariablesInTx(tx, applicationID, vars, unset)
+		return err
+	})
+	return created, updated, deleted, err
+}
+
+// SetEnvironmentVariablesInTx 同 SetEnvironmentVariables，在调用方的事务中执行
+func SetEnvironmentVariablesInTx(tx *gorm.DB, applicationID uuid.UUID, vars []EnvironmentVariableInput, unset []string) (created, updated, deleted int, err error) {
+	for _, v := range vars {
+		storedValue := v.Value
+		if v.IsEncrypted {
+			if storedValue, err = utils.EncryptValue(v.Value); err != nil {
+				return created, updated, deleted, err
+			}
+		}
+
+		result := tx.Model(&EnvironmentVariable{}).
+			Where("application_id = ? AND key = ?", applicationID, v.Key).
+			Updates(map[string]interface{}{
+				"value":        storedValue,
+				"is_encrypted": v.IsEncrypted,
+			})
+		if result.Error != nil {
+			return created, updated, deleted, result.Error
+		}
+		if result.RowsAffected > 0 {
+			updated++
+			continue
+		}
+
+		if _, err := CreateEnvironmentVariableInTx(tx, applicationID, v.Key, v.Value, v.IsEncrypted); err != nil {
+			return created, updated, deleted, err
+		}
+		created++
+	}
+
+	if len(unset) > 0 {
+		result := tx.Where("application_id = ? AND key IN ?", applicationID, unset).Delete(&EnvironmentVariable{})
+		if result.Error != nil {
+			return created, updated, deleted, result.Error
+		}
+		deleted = int(result.RowsAffected)
+	}
+	return created, updated, deleted, nil
+}
+
+// EnvironmentSnapshotEntry 部署快照中的单个环境变量
+type EnvironmentSnapshotEntry struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	IsEncrypted bool   `json:"isEncrypted"`
+}
+
+// ParseEnvironmentSnapshot 解析 CreateSnapshotForDeployment 生成的快照
+func ParseEnvironmentSnapshot(snapshot string) ([]EnvironmentSnapshotEntry, error) {
+	if snapshot == "" || snapshot == "null" {
+		return nil, nil
+	}
+	var entries []EnvironmentSnapshotEntry
+	if err := json.Unmarshal([]byte(snapshot), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// DeployFreezeWindow 部署冻结窗口，窗口内创建部署需要填写强制部署的原因。
+// ApplicationID 为空时对项目下的所有应用生效
+type DeployFreezeWindow struct {
+	ID            uuid.UUID `gorm:"type:char(36);primary_key"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ProjectID     uuid.UUID  `gorm:"type:char(36);not null;index"`
+	ApplicationID *uuid.UUID `gorm:"type:char(36);index"`
+	Name          string     `gorm:"size:255;not null"`
+	Reason        string     `gorm:"type:text"` // 冻结原因，部署被拒绝时提示给用户
+	CreatedBy     string     `gorm:"size:255;not null;default:''"`
+
+	Window utils.FreezeWindow `gorm:"embedded"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (w *DeployFreezeWindow) BeforeCreate(tx *gorm.DB) (err error) {
+	w.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the DeployFreezeWindow model
+func (DeployFreezeWindow) TableName() string {
+	return "deploy_freeze_windows"
+}
+
+// CreateDeployFreezeWindow creates a new deploy freeze window
+func CreateDeployFreezeWindow(window *DeployFreezeWindow) error {
+	return dborm.Db.Create(window).Error
+}
+
+// GetDeployFreezeWindowByID retrieves a deploy freeze window by its ID
+func GetDeployFreezeWindowByID(id uuid.UUID) (*DeployFreezeWindow, error) {
+	var window DeployFreezeWindow
+	if err := dborm.Db.Where("id = ?", id).First(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// ListDeployFreezeWindowsByProjectID retrieves all freeze windows of a project, including application-level ones
+func ListDeployFreezeWindowsByProjectID(projectID uuid.UUID) ([]*DeployFreezeWindow, error) {
+	var windows []*DeployFreezeWindow
+	if err := dborm.Db.Where("project_id = ?", projectID).Order("created_at DESC").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// ListDeployFreezeWindowsForApplication retrieves the project-wide freeze windows and those of the application
+func ListDeployFreezeWindowsForApplication(projectID, applicationID uuid.UUID) ([]*DeployFreezeWindow, error) {
+	var windows []*DeployFreezeWindow
+	if err := dborm.Db.
+		Where("project_id = ? AND (application_id IS NULL OR application_id = ?)", projectID, applicationID).
+		Order("created_at DESC").
+		Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// DeleteDeployFreezeWindow deletes a deploy freeze window by its ID
+func DeleteDeployFreezeWindow(id uuid.UUID) error {
+	return dborm.Db.Where("id = ?", id).Delete(&DeployFreezeWindow{}).Error
+}
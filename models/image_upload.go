@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// 分块上传的状态
+const (
+	ImageUploadStatusUploading = "uploading"
+	ImageUploadStatusCompleted = "completed"
+)
+
+// ImageUpload 分块上传的镜像 tar 包。已接收的数据保存在磁盘上，
+// 连接中断或服务重启后客户端可以从 ReceivedSize 继续上传
+type ImageUpload struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primary_key"`
+	ApplicationID uuid.UUID  `gorm:"type:char(36);not null;index"`
+	FileName      string     `gorm:"size:255;not null"`
+	TotalSize     int64      `gorm:"not null"`
+	SHA256        string     `gorm:"size:64;not null;index"` // 整个文件的 sha256，完成时校验，也用于匹配可续传的上传
+	Version       string     `gorm:"size:100"`
+	Description   string     `gorm:"size:255"`
+	ReceivedSize  int64      `gorm:"not null;default:0"`
+	Status        string     `gorm:"size:20;not null;default:'uploading'"`
+	ReleaseID     *uuid.UUID `gorm:"type:char(36)"` // 完成后创建的 Release
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (u *ImageUpload) BeforeCreate(tx *gorm.DB) (err error) {
+	u.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the ImageUpload model
+func (ImageUpload) TableName() string {
+	return "image_uploads"
+}
+
+// CreateImageUpload creates a new chunked image upload
+func CreateImageUpload(upload *ImageUpload) error {
+	upload.Status = ImageUploadStatusUploading
+	upload.ReceivedSize = 0
+	return dborm.Db.Create(upload).Error
+}
+
+// GetImageUploadByID retrieves a chunked image upload by its ID
+func GetImageUploadByID(id uuid.UUID) (*ImageUpload, error) {
+	var upload ImageUpload
+	if err := dborm.Db.Where("id = ?", id).First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// FindResumableImageUpload returns the unfinished upload of the same file for an application, nil if there is none
+func FindResumableImageUpload(applicationID uuid.UUID, sha256 string, totalSize int64) (*ImageUpload, error) {
+	var uploads []ImageUpload
+	if err := dborm.Db.Where("application_id = ? AND sha256 = ? AND total_size = ? AND status = ?",
+		applicationID, sha256, totalSize, ImageUploadStatusUploading).Order("updated_at DESC").Limit(1).Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	if len(uploads) == 0 {
+		return nil, nil
+	}
+	return &uploads[0], nil
+}
+
+// ListExpiredImageUploads returns unfinished uploads that have not received data since the given time
+func ListExpiredImageUploads(before time.Time) ([]ImageUpload, error) {
+	var uploads []ImageUpload
+	if err := dborm.Db.Where("status = ? AND updated_at < ?", ImageUploadStatusUploading, before).Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// UpdateImageUploadReceivedSize records how many bytes of an upload have been received
+func UpdateImageUploadReceivedSize(id uuid.UUID, size int64) error {
+	return dborm.Db.Model(&ImageUpload{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"received_size": size,
+		"updated_at":    time.Now(),
+	}).Error
+}
+
+// CompleteImageUpload marks an upload as completed with the release created from it
+func CompleteImageUpload(id, releaseID uuid.UUID) error {
+	return dborm.Db.Model(&ImageUpload{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     ImageUploadStatusCompleted,
+		"release_id": releaseID,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// DeleteImageUpload deletes a chunked image upload record
+func DeleteImageUpload(id uuid.UUID) error {
+	return dborm.Db.Where("id = ?", id).Delete(&ImageUpload{}).Error
+}
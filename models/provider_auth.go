@@ -28,6 +28,7 @@ type ProviderAuth struct {
 	ClientID     string `gorm:"size:255"` // OAuth2客户端ID
 	ClientSecret string `gorm:"size:255"` // OAuth2客户端密钥（加密存储）
 	RedirectURI  string `gorm:"size:500"` // OAuth回调URI
+	BaseURL      string `gorm:"size:500"` // 自建实例地址，如 https://gitea.example.com，为空时使用公共实例
 
 	// Bitbucket App Password专用字段
 	Username    string `gorm:"size:255"` // Bitbucket专用用户名
@@ -260,7 +261,10 @@ func UpdateProviderAuth(id uuid.UUID, clientID, clientSecret, redirectURI, usern
 	providerAuth.AppID = appID
 	providerAuth.Slug = slug
 	providerAuth.PrivateKey = privateKey
-	providerAuth.WebhookSecret = webhookSecret
+	// Webhook Secret 留空时保持不变，避免编辑授权后仓库中已配置的 Webhook 失效
+	if webhookSecret != "" {
+		providerAuth.WebhookSecret = webhookSecret
+	}
 	providerAuth.InstallationID = installationID
 	providerAuth.Scopes = scopes
 	providerAuth.IsActive = isActive
@@ -275,6 +279,11 @@ func UpdateProviderAuth(id uuid.UUID, clientID, clientSecret, redirectURI, usern
 	return providerAuth, nil
 }
 
+// UpdateProviderAuthBaseURL 更新授权的平台实例地址
+func UpdateProviderAuthBaseURL(id uuid.UUID, baseURL string) error {
+	return dborm.Db.Model(&ProviderAuth{}).Where("id = ?", id).Update("base_url", baseURL).Error
+}
+
 // DeleteProviderAuth 删除授权记录
 func DeleteProviderAuth(id uuid.UUID) error {
 	return dborm.Db.Where("id = ?", id).Delete(&ProviderAuth{}).Error
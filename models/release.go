@@ -19,6 +19,8 @@ type Release struct {
 	ImageName       string         `gorm:"size:255;not null"`                  // 最终的镜像名称和标签
 	BuildSourceInfo JSONB          `gorm:"type:jsonb"`                         // 构建源信息, e.g., {"commit_sha": "...", "branch": "main"}
 	Status          string         `gorm:"size:50;not null;default:'pending'"` // 构建状态 (pending, building, success, failed)
+	ImageDigest     string         `gorm:"size:100;not null;default:''"`       // 来自镜像仓库的 Release 固定的清单摘要，ImageName 为 <name>@<digest>
+	BuildLog        string         `gorm:"type:text"`                          // 完整的构建输出（git 和 podman build）
 	// SystemPort      *int   `gorm:"default:null"`                       // 系统分配的端口，可选字段
 }
 
@@ -66,6 +68,23 @@ func CreateReleaseWithVersion(applicationID uuid.UUID, version, imageName string
 	return release, nil
 }
 
+// CreateRegistryRelease creates a ready release pinned to an image digest pulled from an external registry
+func CreateRegistryRelease(applicationID uuid.UUID, imageName, digest string, buildSourceInfo JSONB) (*Release, error) {
+	release := &Release{
+		ApplicationID:   applicationID,
+		ImageName:       imageName,
+		ImageDigest:     digest,
+		BuildSourceInfo: buildSourceInfo,
+		Status:          "success",
+	}
+
+	if err := dborm.Db.Create(release).Error; err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
 // GetReleaseByID retrieves a release by its ID
 func GetReleaseByID(id uuid.UUID) (*Release, error) {
 	var release Release
@@ -83,6 +102,30 @@ func GetLatestRelease(appID uuid.UUID) (*Release, error) {
 	return &release, nil
 }
 
+// FindReleaseByImageName returns the successful release of an application that uses the image, nil if there is none
+func FindReleaseByImageName(appID uuid.UUID, imageName string) (*Release, error) {
+	var releases []Release
+	if err := dborm.Db.Omit("build_log").Where("application_id = ? AND image_name = ? AND status = ?", appID, imageName, "success").Order("created_at DESC").Limit(1).Find(&releases).Error; err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, nil
+	}
+	return &releases[0], nil
+}
+
+// GetLatestRegistryRelease returns the most recent release pulled from an external registry, nil if there is none
+func GetLatestRegistryRelease(appID uuid.UUID) (*Release, error) {
+	var releases []Release
+	if err := dborm.Db.Omit("build_log").Where("application_id = ? AND image_digest <> ''", appID).Order("created_at DESC").Limit(1).Find(&releases).Error; err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, nil
+	}
+	return &releases[0], nil
+}
+
 // ListReleases retrieves all releases
 func ListReleases() ([]*Release, error) {
 	var releases []*Release
@@ -95,7 +138,8 @@ func ListReleases() ([]*Release, error) {
 // ListReleasesByAppID retrieves all releases for a specific application
 func ListReleasesByAppID(appID uuid.UUID) ([]*Release, error) {
 	var releases []*Release
-	if err := dborm.Db.Where("application_id = ?", appID).Order("created_at desc").Find(&releases).Error; err != nil {
+	// 列表不需要构建日志，避免加载大量文本
+	if err := dborm.Db.Omit("build_log").Where("application_id = ?", appID).Order("created_at desc").Find(&releases).Error; err != nil {
 		return nil, err
 	}
 	return releases, nil
@@ -123,11 +167,41 @@ func UpdateReleaseVersion(id uuid.UUID, version string) error {
 	return dborm.Db.Model(&Release{}).Where("id = ?", id).Update("version", version).Error
 }
 
+// UpdateReleaseStatus updates the status of an existing release
+func UpdateReleaseStatus(id uuid.UUID, status string) error {
+	return dborm.Db.Model(&Release{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// UpdateReleaseImageDigest records the pinned image and digest of a release pulled from a registry
+func UpdateReleaseImageDigest(id uuid.UUID, imageName, digest string, buildSourceInfo JSONB) error {
+	return dborm.Db.Model(&Release{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"image_name":        imageName,
+		"image_digest":      digest,
+		"build_source_info": buildSourceInfo,
+		"status":            "success",
+	}).Error
+}
+
+// UpdateReleaseBuildLog stores the full build output of a release
+func UpdateReleaseBuildLog(id uuid.UUID, buildLog string) error {
+	return dborm.Db.Model(&Release{}).Where("id = ?", id).Update("build_log", buildLog).Error
+}
+
 // DeleteRelease deletes a release by its ID
 func DeleteRelease(id uuid.UUID) error {
 	return dborm.Db.Where("id = ?", id).Delete(&Release{}).Error
 }
 
+// DeleteReleaseWithDeployments deletes a release together with the deployment records that reference it
+func DeleteReleaseWithDeployments(id uuid.UUID) error {
+	return dborm.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("release_id = ?", id).Delete(&Deployment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&Release{}).Error
+	})
+}
+
 // IsPortInUse checks if the given port is already assigned to any Release
 // func IsPortInUse(port int) (bool, error) {
 // 	var count int64
@@ -16,10 +16,29 @@ type Routing struct {
 	DeletedAt     gorm.DeletedAt `gorm:"index"`
 	ApplicationID uuid.UUID      `gorm:"type:char(36);not null;index"`
 	DomainName    string         `gorm:"size:255;not null;uniqueIndex"`
-	HostPort      int            `gorm:"not null;uniqueIndex"` // 主机上暴露的端口，必须唯一
+	HostPort      int            `gorm:"not null;index"` // 反向代理的本机端口，同一应用的多个域名可以指向同一个端口
 	IsActive      bool           `gorm:"not null;default:true"`
+
+	// DNS 预检结果，见 services.VerifyRoutingDNS
+	DNSStatus        string `gorm:"size:20;not null;default:'pending'"`
+	DNSStatusMessage string `gorm:"type:text"`
+	DNSCheckedAt     *time.Time
+
+	// 最近一次从本机 Caddy 读取到的证书过期时间，见 services.GetRoutingCertificateExpiry
+	CertificateExpiresAt *time.Time
+	CertificateCheckedAt *time.Time
 }
 
+// 路由 DNS 预检状态
+const (
+	RoutingDNSStatusPending    = "pending"    // 尚未检查
+	RoutingDNSStatusVerified   = "verified"   // 域名解析到本服务器
+	RoutingDNSStatusMismatch   = "mismatch"   // 域名解析到其它地址（可能经过 CDN 代理）
+	RoutingDNSStatusUnresolved = "unresolved" // 域名没有 A/AAAA 记录
+	RoutingDNSStatusUnverified = "unverified" // 要求 TXT 所有权验证但未找到验证记录
+	RoutingDNSStatusUnknown    = "unknown"    // 无法确定服务器公网地址
+)
+
 // BeforeCreate will set a UUID rather than numeric ID.
 func (r *Routing) BeforeCreate(tx *gorm.DB) (err error) {
 	r.ID = uuid.New()
@@ -48,6 +67,40 @@ func CreateRouting(applicationID uuid.UUID, domainName string, hostPort int, isA
 	return routing, nil
 }
 
+// CreateRoutingInTx creates a new routing record within a transaction
+func CreateRoutingInTx(tx *gorm.DB, applicationID uuid.UUID, domainName string, hostPort int, isActive bool) (*Routing, error) {
+	routing := &Routing{
+		ApplicationID: applicationID,
+		DomainName:    domainName,
+		HostPort:      hostPort,
+		IsActive:      isActive,
+	}
+	if err := tx.Create(routing).Error; err != nil {
+		return nil, err
+	}
+	return routing, nil
+}
+
+// MigrateRoutingHostPortIndex 早期版本要求 host_port 全局唯一，AutoMigrate 不会修改已存在的索引，
+// 这里将唯一索引替换为普通索引
+func MigrateRoutingHostPortIndex(db *gorm.DB) error {
+	indexes, err := db.Migrator().GetIndexes(&Routing{})
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		columns := index.Columns()
+		if unique, _ := index.Unique(); !unique || len(columns) != 1 || columns[0] != "host_port" {
+			continue
+		}
+		if err := db.Migrator().DropIndex(&Routing{}, index.Name()); err != nil {
+			return err
+		}
+		return db.Migrator().CreateIndex(&Routing{}, "HostPort")
+	}
+	return nil
+}
+
 // GetRoutingByID retrieves a routing by its ID
 func GetRoutingByID(id uuid.UUID) (*Routing, error) {
 	var routing Routing
@@ -92,9 +145,32 @@ func UpdateRouting(id uuid.UUID, domainName string, hostPort int, isActive bool)
 	return routing, nil
 }
 
+// UpdateRoutingDNSStatus saves the result of the DNS pre-flight check of a routing
+func UpdateRoutingDNSStatus(id uuid.UUID, status, message string, checkedAt time.Time) error {
+	return dborm.Db.Model(&Routing{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"dns_status":         status,
+		"dns_status_message": message,
+		"dns_checked_at":     checkedAt,
+	}).Error
+}
+
+// UpdateRoutingCertificateExpiry saves the certificate expiry probed from Caddy, a nil expiresAt keeps the last known value
+func UpdateRoutingCertificateExpiry(id uuid.UUID, expiresAt *time.Time, checkedAt time.Time) error {
+	updates := map[string]interface{}{"certificate_checked_at": checkedAt}
+	if expiresAt != nil {
+		updates["certificate_expires_at"] = *expiresAt
+	}
+	return dborm.Db.Model(&Routing{}).Where("id = ?", id).Updates(updates).Error
+}
+
 // DeleteRouting deletes a routing by its ID
 func DeleteRouting(id uuid.UUID) error {
-	return dborm.Db.Where("id = ?", id).Delete(&Routing{}).Error
+	return DeleteRoutingInTx(dborm.Db, id)
+}
+
+// DeleteRoutingInTx deletes a routing by its ID within a transaction
+func DeleteRoutingInTx(tx *gorm.DB, id uuid.UUID) error {
+	return tx.Where("id = ?", id).Delete(&Routing{}).Error
 }
 
 func GetActiveRoutingsByApplicationID(applicationID uuid.UUID) ([]*Routing, error) {
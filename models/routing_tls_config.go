@@ -0,0 +1,143 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// 路由证书模式
+const (
+	RoutingTLSModeAuto   = "auto"   // 由 Caddy 默认策略自动签发
+	RoutingTLSModeCustom = "custom" // 使用上传的证书和私钥
+	RoutingTLSModeACME   = "acme"   // 使用自定义的 ACME 参数（DNS-01 提供商、staging 等）
+)
+
+// RoutingTLSConfig 存储单个路由域名的证书设置，私钥和 DNS 提供商凭据均加密存储
+type RoutingTLSConfig struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	RoutingID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex"`
+	Mode      string    `gorm:"size:20;not null;default:'auto'"`
+
+	// 自定义证书
+	CertificatePEM       string     `gorm:"type:text"` // 证书链（公开信息，明文存储）
+	PrivateKeyPEM        string     `gorm:"type:text"` // 私钥，使用 utils.EncryptValue 加密
+	CertificateExpiresAt *time.Time // 自定义证书的过期时间
+
+	// ACME 设置
+	ACMEEmail      string `gorm:"size:255"`
+	ACMEStaging    bool   `gorm:"not null;default:false"` // 是否使用 Let's Encrypt staging 环境
+	DNSProvider    string `gorm:"size:50"`                // DNS-01 挑战提供商, e.g., "cloudflare"，为空时使用 HTTP/TLS-ALPN 挑战
+	DNSCredentials string `gorm:"type:text"`              // 提供商凭据（JSON），使用 utils.EncryptValue 加密
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (c *RoutingTLSConfig) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the RoutingTLSConfig model
+func (RoutingTLSConfig) TableName() string {
+	return "routing_tls_configs"
+}
+
+// GetDecryptedPrivateKey returns the decrypted private key of a custom certificate
+func (c *RoutingTLSConfig) GetDecryptedPrivateKey() (string, error) {
+	return utils.DecryptValue(c.PrivateKeyPEM)
+}
+
+// GetDecryptedDNSCredentials returns the decrypted DNS provider credentials
+func (c *RoutingTLSConfig) GetDecryptedDNSCredentials() (map[string]string, error) {
+	credentials := map[string]string{}
+	if c.DNSCredentials == "" {
+		return credentials, nil
+	}
+
+	decrypted, err := utils.DecryptValue(c.DNSCredentials)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(decrypted), &credentials); err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// GetRoutingTLSConfigByRoutingID retrieves the TLS config of a routing
+func GetRoutingTLSConfigByRoutingID(routingID uuid.UUID) (*RoutingTLSConfig, error) {
+	var config RoutingTLSConfig
+	if err := dborm.Db.Where("routing_id = ?", routingID).First(&config).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// FindRoutingTLSConfig returns the TLS config of a routing, or nil if none is set
+func FindRoutingTLSConfig(routingID uuid.UUID) (*RoutingTLSConfig, error) {
+	config, err := GetRoutingTLSConfigByRoutingID(routingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return config, err
+}
+
+// UpsertRoutingTLSConfig creates or replaces the TLS config of a routing.
+// privateKeyPEM and dnsCredentials are plaintext and will be encrypted before saving.
+func UpsertRoutingTLSConfig(routingID uuid.UUID, mode, certificatePEM, privateKeyPEM string, expiresAt *time.Time, acmeEmail string, acmeStaging bool, dnsProvider string, dnsCredentials map[string]string) (*RoutingTLSConfig, error) {
+	encryptedKey, err := utils.EncryptValue(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedCredentials := ""
+	if len(dnsCredentials) > 0 {
+		data, err := json.Marshal(dnsCredentials)
+		if err != nil {
+			return nil, err
+		}
+		encryptedCredentials, err = utils.EncryptValue(string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config, err := FindRoutingTLSConfig(routingID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = &RoutingTLSConfig{RoutingID: routingID}
+	}
+
+	config.Mode = mode
+	config.CertificatePEM = certificatePEM
+	config.PrivateKeyPEM = encryptedKey
+	config.CertificateExpiresAt = expiresAt
+	config.ACMEEmail = acmeEmail
+	config.ACMEStaging = acmeStaging
+	config.DNSProvider = dnsProvider
+	config.DNSCredentials = encryptedCredentials
+
+	if err := dborm.Db.Save(config).Error; err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// DeleteRoutingTLSConfigByRoutingID deletes the TLS config of a routing
+func DeleteRoutingTLSConfigByRoutingID(routingID uuid.UUID) error {
+	return DeleteRoutingTLSConfigByRoutingIDInTx(dborm.Db, routingID)
+}
+
+// DeleteRoutingTLSConfigByRoutingIDInTx deletes the TLS settings of a routing within a transaction
+func DeleteRoutingTLSConfigByRoutingIDInTx(tx *gorm.DB, routingID uuid.UUID) error {
+	return tx.Where("routing_id = ?", routingID).Delete(&RoutingTLSConfig{}).Error
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"gorm.io/gorm"
+)
+
+// 定时部署状态
+const (
+	ScheduledDeploymentStatusPending   = "pending"   // 等待到达计划时间
+	ScheduledDeploymentStatusStarted   = "started"   // 已创建部署，结果见 DeploymentID 对应的部署
+	ScheduledDeploymentStatusFailed    = "failed"    // 到达计划时间后创建部署失败（如处于冻结窗口）
+	ScheduledDeploymentStatusCancelled = "cancelled" // 已取消
+)
+
+// ScheduledDeployment 在指定时间由服务端创建的部署。记录保存在数据库中，服务重启后继续等待；
+// 重启期间错过的计划在启动后立即执行
+type ScheduledDeployment struct {
+	ID            uuid.UUID `gorm:"type:char(36);primary_key"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ApplicationID uuid.UUID  `gorm:"type:char(36);not null;index"`
+	ReleaseID     *uuid.UUID `gorm:"type:char(36)"`                // 为空时到达计划时间后重新构建
+	GitRef        string     `gorm:"size:255;not null;default:''"` // 重新构建时检出的分支、标签或提交
+	Strategy      string     `gorm:"size:20;not null;default:''"`
+	ScheduledAt   time.Time  `gorm:"not null;index"`
+	Status        string     `gorm:"size:20;not null;default:'pending';index"`
+	RequestedBy   string     `gorm:"size:255;not null;default:''"`
+
+	FreezeOverrideReason string     `gorm:"type:text"` // 计划时间处于冻结窗口时强制部署的原因
+	DeploymentID         *uuid.UUID `gorm:"type:char(36)"`
+	Error                string     `gorm:"type:text"`
+	StartedAt            *time.Time
+}
+
+// BeforeCreate will set a UUID rather than numeric ID.
+func (s *ScheduledDeployment) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
+
+// TableName specifies the table name for the ScheduledDeployment model
+func (ScheduledDeployment) TableName() string {
+	return "scheduled_deployments"
+}
+
+// CreateScheduledDeployment creates a new pending scheduled deployment
+func CreateScheduledDeployment(scheduled *ScheduledDeployment) error {
+	scheduled.Status = ScheduledDeploymentStatusPending
+	return dborm.Db.Create(scheduled).Error
+}
+
+// GetScheduledDeploymentByID retrieves a scheduled deployment by its ID
+func GetScheduledDeploymentByID(id uuid.UUID) (*ScheduledDeployment, error) {
+	var scheduled ScheduledDeployment
+	if err := dborm.Db.Where("id = ?", id).First(&scheduled).Error; err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+// ListScheduledDeploymentsByAppID retrieves the scheduled deployments of an application, latest schedule first
+func ListScheduledDeploymentsByAppID(appID uuid.UUID) ([]*ScheduledDeployment, error) {
+	var scheduled []*ScheduledDeployment
+	if err := dborm.Db.Where("application_id = ?", appID).Order("scheduled_at DESC").Find(&scheduled).Error; err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+// ListDueScheduledDeployments retrieves pending scheduled deployments whose time has come
+func ListDueScheduledDeployments(now time.Time) ([]*ScheduledDeployment, error) {
+	var scheduled []*ScheduledDeployment
+	if err := dborm.Db.
+		Where("status = ? AND scheduled_at <= ?", ScheduledDeploymentStatusPending, now).
+		Order("scheduled_at ASC").
+		Find(&scheduled).Error; err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+// ClaimScheduledDeployment 把等待中的定时部署切换到 status，只有一个调用方能成功，返回是否成功
+func ClaimScheduledDeployment(id uuid.UUID, status string) (bool, error) {
+	result := dborm.Db.Model(&ScheduledDeployment{}).
+		Where("id = ? AND status = ?", id, ScheduledDeploymentStatusPending).
+		Update("status", status)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FinishScheduledDeployment records the deployment created for a schedule, or the error that prevented it
+func FinishScheduledDeployment(id uuid.UUID, status string, deploymentID *uuid.UUID, errMsg string) error {
+	return dborm.Db.Model(&ScheduledDeployment{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":        status,
+		"deployment_id": deploymentID,
+		"error":         errMsg,
+		"started_at":    time.Now(),
+	}).Error
+}
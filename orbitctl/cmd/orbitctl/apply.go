@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// 服务端应用 orbitdeploy.toml 相关结构
+type applySpecReq struct {
+	Spec   string `json:"spec"`
+	DryRun bool   `json:"dry_run"`
+}
+
+type specChange struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+	Name     string `json:"name"`
+	Old      string `json:"old"`
+	New      string `json:"new"`
+}
+
+type applySpecResp struct {
+	Changes          []specChange `json:"changes"`
+	Warnings         []string     `json:"warnings"`
+	Applied          bool         `json:"applied"`
+	RedeployRequired bool         `json:"redeploy_required"`
+}
+
+// applySpecFile 将本地 orbitdeploy.toml 提交到服务端，由服务端校验并使应用配置与文件一致
+func applySpecFile(appName, filename string, dryRun bool) (*applySpecResp, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	resp, err := httpPostJSON(apiURL("apps.by_name.apply", appName), applySpecReq{Spec: string(content), DryRun: dryRun}, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var applyResp apiResponse[applySpecResp]
+	if err := decodeAPIResponse(resp.Body, &applyResp); err != nil {
+		return nil, err
+	}
+	return &applyResp.Data, nil
+}
+
+// printSpecPlan 打印执行计划
+func printSpecPlan(plan *applySpecResp) {
+	for _, w := range plan.Warnings {
+		fmt.Printf("   ⚠️  %s\n", w)
+	}
+	if len(plan.Changes) == 0 {
+		fmt.Println("   ✅ 服务端配置已与 orbitdeploy.toml 一致")
+		return
+	}
+
+	labels := map[string]string{"application": "应用", "volume": "卷", "routing": "域名", "env": "环境变量"}
+	for _, c := range plan.Changes {
+		label := getOrDefault(labels[c.Resource], c.Resource)
+		switch c.Action {
+		case "create":
+			fmt.Printf("   + %s %s %s\n", label, c.Name, c.New)
+		case "delete":
+			fmt.Printf("   - %s %s %s\n", label, c.Name, c.Old)
+		default:
+			fmt.Printf("   ~ %s %s: %s -> %s\n", label, c.Name, getOrDefault(c.Old, "(空)"), getOrDefault(c.New, "(空)"))
+		}
+	}
+}
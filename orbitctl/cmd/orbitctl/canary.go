@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// canaryResp 金丝雀发布状态
+type canaryResp struct {
+	ApplicationUid        string     `json:"applicationUid"`
+	DeploymentUid         string     `json:"deploymentUid"`
+	BaselineDeploymentUid string     `json:"baselineDeploymentUid"`
+	Status                string     `json:"status"`
+	Weight                int        `json:"weight"`
+	StepWeight            int        `json:"stepWeight"`
+	MaxErrorRate          float64    `json:"maxErrorRate"`
+	HealthPath            string     `json:"healthPath"`
+	BaselinePort          int        `json:"baselinePort"`
+	CanaryPort            int        `json:"canaryPort"`
+	AbortReason           string     `json:"abortReason"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	FinishedAt            *time.Time `json:"finishedAt"`
+}
+
+// cmdCanary 查看或控制应用当前的金丝雀发布
+func cmdCanary(action, app string, weight int, reason string) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	var canary *canaryResp
+	switch action {
+	case "status":
+		canary, err = getCanary(appName)
+	case "step":
+		canary, err = postCanary(appName, "apps.by_name.canary.step", map[string]any{"weight": weight})
+	case "promote":
+		canary, err = postCanary(appName, "apps.by_name.canary.promote", map[string]any{})
+	case "abort":
+		canary, err = postCanary(appName, "apps.by_name.canary.abort", map[string]any{"reason": reason})
+	default:
+		return fmt.Errorf("未知的 canary 子命令: %s", action)
+	}
+	if err != nil {
+		return err
+	}
+
+	printCanary(appName, canary)
+	return nil
+}
+
+func getCanary(appName string) (*canaryResp, error) {
+	resp, err := httpGetJSON(apiURL("apps.by_name.canary", appName), true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result apiResponse[canaryResp]
+	if err := decodeAPIResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+func postCanary(appName, endpoint string, body map[string]any) (*canaryResp, error) {
+	resp, err := httpPostJSON(apiURL(endpoint, appName), body, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result apiResponse[canaryResp]
+	if err := decodeAPIResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+func printCanary(appName string, canary *canaryResp) {
+	icon := map[string]string{
+		"pending":  "⏳",
+		"running":  "🐤",
+		"promoted": "✅",
+		"aborted":  "❌",
+	}[canary.Status]
+
+	fmt.Printf("%s 金丝雀发布: %s\n", icon, appName)
+	fmt.Printf("   状态: %s\n", canary.Status)
+	fmt.Printf("   新版本部署: %s\n", canary.DeploymentUid)
+	fmt.Printf("   旧版本部署: %s\n", canary.BaselineDeploymentUid)
+	fmt.Printf("   流量分配: 新版本 %d%% / 旧版本 %d%%\n", canary.Weight, 100-canary.Weight)
+	fmt.Printf("   步长: %d%%  探测错误率阈值: %.1f%%  探测路径: %s\n", canary.StepWeight, canary.MaxErrorRate, canary.HealthPath)
+	if canary.CanaryPort > 0 {
+		fmt.Printf("   端口: 旧版本 %d, 新版本 %d\n", canary.BaselinePort, canary.CanaryPort)
+	}
+	if canary.AbortReason != "" {
+		fmt.Printf("   中止原因: %s\n", canary.AbortReason)
+	}
+	fmt.Printf("   开始时间: %s\n", canary.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if canary.FinishedAt != nil {
+		fmt.Printf("   结束时间: %s\n", canary.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+}
@@ -55,16 +55,6 @@ type deploymentStatusResp struct {
 }
 
 // CLI 专用类型定义
-type releaseUploadResp struct {
-	ReleaseID   string `json:"release_id"`
-	Version     string `json:"version"`
-	Description string `json:"description"`
-	ImageSize   int64  `json:"image_size"`
-	Status      string `json:"status"`
-	AppName     string `json:"app_name"`
-	AppID       uint   `json:"app_id"`
-}
-
 type applicationInfo struct {
 	ID              uint   `json:"id"`
 	Name            string `json:"name"`
@@ -75,22 +65,6 @@ type applicationInfo struct {
 	ActiveReleaseID *uint  `json:"active_release_id"`
 }
 
-// 环境变量相关结构
-type envVariable struct {
-	Key       string `json:"key"`
-	Value     string `json:"value,omitempty"`
-	Secret    bool   `json:"secret"`
-	UpdatedAt string `json:"updated_at,omitempty"`
-}
-
-type envVariablesResp struct {
-	Variables []envVariable `json:"variables"`
-}
-
-type envUpdateResp struct {
-	Message string `json:"message"`
-}
-
 // cmdInit 启动项目初始化，通过配置网页获取服务端配置
 func cmdInit(name, project, env string) error {
 	const filename = "orbitctl.toml"
@@ -177,7 +151,7 @@ func cmdInit(name, project, env string) error {
 }
 
 // cmdDeploy 部署应用
-func cmdDeploy(project, env string, dryRun bool) error {
+func cmdDeploy(project, env string, dryRun bool, freezeOverride string) error {
 	// 读取配置文件
 	spec, err := loadSpecFromFile("orbitdeploy.toml")
 	if err != nil {
@@ -200,23 +174,21 @@ func cmdDeploy(project, env string, dryRun bool) error {
 	fmt.Printf("   副本: %d\n", spec.Replicas)
 
 	if dryRun {
-		fmt.Println("\n📋 部署计划 (--dry-run 模式):")
-		fmt.Println("   [模拟] 1. 验证配置文件...")
-		fmt.Println("   [模拟] 2. 创建项目配置会话...")
-		fmt.Println("   [模拟] 3. 等待用户配置项目...")
-		fmt.Println("   [模拟] 4. 构建并上传镜像...")
-		fmt.Println("   [模拟] 5. 触发部署...")
-		fmt.Println("   [模拟] 6. 监控部署进度...")
-		fmt.Println("\n✨ 部署计划验证通过，使用 --dry-run=false 执行实际部署")
-	} else {
-		return performRealDeployment(spec)
+		fmt.Println("\n📋 配置变更计划 (--dry-run 模式):")
+		plan, err := applySpecFile(spec.Name, "orbitdeploy.toml", true)
+		if err != nil {
+			return fmt.Errorf("计算变更计划失败: %w", err)
+		}
+		printSpecPlan(plan)
+		fmt.Println("\n✨ 配置校验通过，去掉 --dry-run 执行实际部署")
+		return nil
 	}
 
-	return nil
+	return performRealDeployment(spec, freezeOverride)
 }
 
 // performRealDeployment 执行实际部署流程
-func performRealDeployment(spec *specTOML) error {
+func performRealDeployment(spec *specTOML, freezeOverride string) error {
 	// 1. 验证应用存在
 	fmt.Println("\n📋 步骤 1: 验证应用配置...")
 	appName := spec.Name
@@ -230,154 +202,33 @@ func performRealDeployment(spec *specTOML) error {
 	}
 	fmt.Printf("   ✅ 找到应用: %s (ID: %d)\n", app.Name, app.ID)
 
-	// 2. 构建并上传镜像
-	fmt.Println("\n📦 步骤 2: 构建并上传镜像...")
+	// 2. 同步 orbitdeploy.toml 中的端口、卷、资源、域名和环境变量
+	fmt.Println("\n🔧 步骤 2: 应用配置变更...")
+	plan, err := applySpecFile(appName, "orbitdeploy.toml", false)
+	if err != nil {
+		return fmt.Errorf("应用配置失败: %w", err)
+	}
+	printSpecPlan(plan)
+
+	// 3. 构建并上传镜像
+	fmt.Println("\n📦 步骤 3: 构建并上传镜像...")
 	releaseID, err := buildAndUploadImageToApp(appName, spec)
 	if err != nil {
 		return fmt.Errorf("构建上传镜像失败: %w", err)
 	}
 
-	// 3. 触发部署
-	fmt.Println("\n🚀 步骤 3: 触发部署...")
-	deploymentID, err := triggerAppDeployment(appName, releaseID)
+	// 4. 触发部署
+	fmt.Println("\n🚀 步骤 4: 触发部署...")
+	deploymentID, err := triggerAppDeployment(appName, releaseID, spec.Strategy, freezeOverride)
 	if err != nil {
 		return fmt.Errorf("触发部署失败: %w", err)
 	}
 
-	// 4. 监控部署进度
-	fmt.Println("\n📊 步骤 4: 监控部署进度...")
+	// 5. 监控部署进度
+	fmt.Println("\n📊 步骤 5: 监控部署进度...")
 	return monitorDeployment(deploymentID)
 }
 
-// cmdEnvList 列出环境变量
-func cmdEnvList(project, env string) error {
-	projectID := getOrDefault(project, getProjectFromConfig())
-	if projectID == "" {
-		return fmt.Errorf("项目名称不能为空，请使用 --project 参数或在 orbitdeploy.toml 中指定")
-	}
-
-	fmt.Printf("📋 环境变量列表\n")
-	fmt.Printf("   项目: %s\n", projectID)
-	fmt.Printf("   环境: %s\n", env)
-
-	url := apiURL("projects.variables", projectID)
-	resp, err := httpGetJSON(url, true)
-	if err != nil {
-		return fmt.Errorf("获取环境变量失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var envResp apiResponse[envVariablesResp]
-	if err := json.NewDecoder(resp.Body).Decode(&envResp); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !envResp.Success {
-		return fmt.Errorf("获取环境变量失败: %s", envResp.Message)
-	}
-
-	fmt.Println("\n环境变量:")
-	if len(envResp.Data.Variables) == 0 {
-		fmt.Println("   (无环境变量)")
-	} else {
-		for _, v := range envResp.Data.Variables {
-			if v.Secret {
-				fmt.Printf("   %s = [隐藏] (密钥)\n", v.Key)
-			} else {
-				fmt.Printf("   %s = %s\n", v.Key, v.Value)
-			}
-			if v.UpdatedAt != "" {
-				fmt.Printf("     更新时间: %s\n", v.UpdatedAt)
-			}
-		}
-	}
-
-	return nil
-}
-
-// cmdEnvSet 设置环境变量
-func cmdEnvSet(keyValue, project, env string) error {
-	parts := strings.SplitN(keyValue, "=", 2)
-	if len(parts) != 2 {
-		return fmt.Errorf("格式错误，应为 KEY=VALUE")
-	}
-
-	key, value := parts[0], parts[1]
-	projectID := getOrDefault(project, getProjectFromConfig())
-	if projectID == "" {
-		return fmt.Errorf("项目名称不能为空，请使用 --project 参数或在 orbitdeploy.toml 中指定")
-	}
-
-	fmt.Printf("🔧 设置环境变量\n")
-	fmt.Printf("   项目: %s\n", projectID)
-	fmt.Printf("   环境: %s\n", env)
-	fmt.Printf("   变量: %s=%s\n", key, value)
-
-	url := apiURL("projects.variables", projectID)
-	payload := map[string]map[string]string{
-		"variables": {
-			key: value,
-		},
-	}
-
-	resp, err := httpPostJSON(url, payload, true)
-	if err != nil {
-		return fmt.Errorf("设置环境变量失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var updateResp apiResponse[envUpdateResp]
-	if err := json.NewDecoder(resp.Body).Decode(&updateResp); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !updateResp.Success {
-		return fmt.Errorf("设置环境变量失败: %s", updateResp.Message)
-	}
-
-	fmt.Printf("✅ %s\n", updateResp.Data.Message)
-	return nil
-}
-
-// cmdEnvUnset 删除环境变量
-func cmdEnvUnset(key, project, env string) error {
-	projectID := getOrDefault(project, getProjectFromConfig())
-	if projectID == "" {
-		return fmt.Errorf("项目名称不能为空，请使用 --project 参数或在 orbitdeploy.toml 中指定")
-	}
-
-	fmt.Printf("🗑️  删除环境变量\n")
-	fmt.Printf("   项目: %s\n", projectID)
-	fmt.Printf("   环境: %s\n", env)
-	fmt.Printf("   变量: %s\n", key)
-
-	// 通过设置空值来删除环境变量
-	url := apiURL("projects.variables", projectID)
-	payload := map[string]map[string]interface{}{
-		"variables": {
-			key: nil, // 设置为 nil 表示删除
-		},
-	}
-
-	resp, err := httpPostJSON(url, payload, true)
-	if err != nil {
-		return fmt.Errorf("删除环境变量失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var updateResp apiResponse[envUpdateResp]
-	if err := json.NewDecoder(resp.Body).Decode(&updateResp); err != nil {
-		return fmt.Errorf("解析响应失败: %w", err)
-	}
-
-	if !updateResp.Success {
-		return fmt.Errorf("删除环境变量失败: %s", updateResp.Message)
-	}
-
-	fmt.Printf("✅ 环境变量 %s 已删除\n", key)
-	return nil
-}
-
 // cmdScale 扩缩容
 func cmdScale(replicasStr, project, env string) error {
 	replicas, err := strconv.Atoi(replicasStr)
@@ -402,75 +253,6 @@ func cmdScale(replicasStr, project, env string) error {
 	return nil
 }
 
-// cmdStatus 查看状态
-func cmdStatus(project, env string) error {
-	projectID := getOrDefault(project, getProjectFromConfig())
-	if projectID == "" {
-		return fmt.Errorf("项目名称不能为空，请使用 --project 参数或在 orbitdeploy.toml 中指定")
-	}
-
-	fmt.Printf("📊 应用状态\n")
-	fmt.Printf("   项目: %s\n", projectID)
-	fmt.Printf("   环境: %s\n", env)
-	fmt.Println("\n⚠️  状态查询功能需要部署API支持")
-	fmt.Println("   将来会调用后端 API: GET /api/projects/:id/status")
-	return nil
-}
-
-// cmdLogs 查看日志
-func cmdLogs(follow bool, project, env string) error {
-	projectID := getOrDefault(project, getProjectFromConfig())
-	if projectID == "" {
-		return fmt.Errorf("项目名称不能为空，请使用 --project 参数或在 orbitdeploy.toml 中指定")
-	}
-
-	fmt.Printf("📝 应用日志\n")
-	fmt.Printf("   项目: %s\n", projectID)
-	fmt.Printf("   环境: %s\n", env)
-	fmt.Printf("   跟踪模式: %t\n", follow)
-	fmt.Println("\n⚠️  日志查询功能需要部署API支持")
-	fmt.Println("   将来会调用后端 API: GET /api/projects/:id/logs")
-	if follow {
-		fmt.Println("   并支持实时跟踪日志流")
-	}
-	return nil
-}
-
-// cmdInspect 检查配置
-func cmdInspect(project, env string) error {
-	projectID := getOrDefault(project, getProjectFromConfig())
-	if projectID == "" {
-		return fmt.Errorf("项目名称不能为空，请使用 --project 参数或在 orbitdeploy.toml 中指定")
-	}
-
-	fmt.Printf("🔍 配置检查\n")
-	fmt.Printf("   项目: %s\n", projectID)
-	fmt.Printf("   环境: %s\n", env)
-
-	// 显示本地配置
-	fmt.Println("\n📄 本地配置 (orbitdeploy.toml):")
-	spec, err := loadSpecFromFile("orbitdeploy.toml")
-	if err != nil {
-		fmt.Printf("   错误: %v\n", err)
-	} else {
-		fmt.Printf("   项目: %s\n", spec.Project)
-		fmt.Printf("   环境: %s\n", spec.Environment)
-		fmt.Printf("   应用: %s\n", spec.Name)
-		fmt.Printf("   策略: %s\n", spec.Strategy)
-		fmt.Printf("   副本: %d\n", spec.Replicas)
-		if len(spec.Containers) > 0 {
-			fmt.Printf("   容器数: %d\n", len(spec.Containers))
-		}
-	}
-
-	fmt.Println("\n⚠️  远程配置查询功能需要部署API支持")
-	fmt.Println("   将来会显示:")
-	fmt.Println("   1. 后端运行时配置")
-	fmt.Println("   2. 合并后的最终配置")
-	fmt.Println("   3. 部署历史和版本信息")
-	return nil
-}
-
 // getApplicationByName 根据应用名称获取应用信息
 func getApplicationByName(appName string) (*applicationInfo, error) {
 	url := apiURL("apps.by_name.get", appName)
@@ -506,29 +288,30 @@ func buildAndUploadImageToApp(appName string, spec *specTOML) (string, error) {
 	}
 
 	// 构建镜像
+	tool := containerTool()
 	imageName := fmt.Sprintf("%s:cli-upload-%d", appName, time.Now().Unix())
-	fmt.Printf("   构建镜像: %s\n", imageName)
+	fmt.Printf("   构建镜像: %s (%s)\n", imageName, tool)
 
-	buildCmd := exec.Command("docker", "build", "-t", imageName, ".")
+	buildCmd := exec.Command(tool, "build", "-t", imageName, ".")
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
 	if err := buildCmd.Run(); err != nil {
-		return "", fmt.Errorf("Docker构建失败: %w", err)
+		return "", fmt.Errorf("%s 构建失败: %w", tool, err)
 	}
 
 	// 导出镜像为tar包
-	tarPath := fmt.Sprintf("/tmp/%s.tar", appName)
+	tarPath := filepath.Join(os.TempDir(), appName+".tar")
 	fmt.Printf("   导出镜像: %s\n", tarPath)
 
-	saveCmd := exec.Command("docker", "save", "-o", tarPath, imageName)
+	saveCmd := exec.Command(tool, "save", "-o", tarPath, imageName)
 	if err := saveCmd.Run(); err != nil {
 		return "", fmt.Errorf("导出镜像失败: %w", err)
 	}
 	defer os.Remove(tarPath) // 清理临时文件
 
-	// 上传镜像到应用
+	// 分块上传镜像到应用，中断时自动续传
 	fmt.Printf("   上传镜像到应用: %s\n", appName)
-	releaseID, err := uploadImageToApplication(appName, tarPath, imageName)
+	releaseID, err := uploadImageChunked(appName, tarPath, imageName)
 	if err != nil {
 		return "", fmt.Errorf("上传镜像失败: %w", err)
 	}
@@ -537,80 +320,28 @@ func buildAndUploadImageToApp(appName string, spec *specTOML) (string, error) {
 	return releaseID, nil
 }
 
-// uploadImageToApplication 上传镜像文件到应用
-func uploadImageToApplication(appName, filePath, version string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	// 创建multipart请求
-	var b bytes.Buffer
-	writer := multipart.NewWriter(&b)
-
-	// 添加文件字段
-	fileWriter, err := writer.CreateFormFile("image", filepath.Base(filePath))
-	if err != nil {
-		return "", err
-	}
-
-	if _, err := io.Copy(fileWriter, file); err != nil {
-		return "", err
-	}
-
-	// 添加版本信息
-	if err := writer.WriteField("version", version); err != nil {
-		return "", err
-	}
-
-	if err := writer.WriteField("description", "CLI upload"); err != nil {
-		return "", err
-	}
-
-	writer.Close()
-
-	// 发送请求
-	url := apiURL("apps.by_name.releases", appName)
-	req, err := http.NewRequest("POST", url, &b)
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	if token := loadAccessToken(); token != "" {
-		req.Header.Set("Authorization", "Bearer "+token)
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var uploadResp apiResponse[releaseUploadResp]
-	if err := json.NewDecoder(resp.Body).Decode(&uploadResp); err != nil {
-		return "", err
-	}
-
-	if !uploadResp.Success {
-		return "", fmt.Errorf("上传失败: %s", uploadResp.Message)
-	}
-
-	return uploadResp.Data.ReleaseID, nil
-}
-
-// triggerAppDeployment 触发应用部署
-func triggerAppDeployment(appName, releaseID string) (string, error) {
+// triggerAppDeployment 触发应用部署，freezeOverride 为处于冻结窗口时强制部署的原因
+func triggerAppDeployment(appName, releaseID, strategy, freezeOverride string) (string, error) {
 	url := apiURL("apps.by_name.deployments", appName)
 	payload := map[string]interface{}{
-		"release_id": releaseID,
-		"source":     "cli",
+		"release_uid": releaseID,
+		"source":      "cli",
 		"metadata": map[string]interface{}{
 			"cli_version": "v0.1.0",
 			"timestamp":   time.Now().Format(time.RFC3339),
 		},
 	}
+	// 服务端目前支持 direct 和 canary，其余策略按 direct 部署
+	switch strategy {
+	case "direct", "canary":
+		payload["strategy"] = strategy
+	case "":
+	default:
+		fmt.Printf("   ⚠️  服务端暂不支持 %s 策略，将直接部署\n", strategy)
+	}
+	if freezeOverride != "" {
+		payload["freeze_override_reason"] = freezeOverride
+	}
 
 	resp, err := httpPostJSON(url, payload, true)
 	if err != nil {
@@ -645,21 +376,22 @@ func buildAndUploadImage(projectID string, spec *specTOML) (string, error) {
 	}
 
 	// 构建镜像
+	tool := containerTool()
 	imageName := fmt.Sprintf("%s:cli-upload-%d", spec.Name, time.Now().Unix())
-	fmt.Printf("   构建镜像: %s\n", imageName)
+	fmt.Printf("   构建镜像: %s (%s)\n", imageName, tool)
 
-	buildCmd := exec.Command("docker", "build", "-t", imageName, ".")
+	buildCmd := exec.Command(tool, "build", "-t", imageName, ".")
 	buildCmd.Stdout = os.Stdout
 	buildCmd.Stderr = os.Stderr
 	if err := buildCmd.Run(); err != nil {
-		return "", fmt.Errorf("Docker构建失败: %w", err)
+		return "", fmt.Errorf("%s 构建失败: %w", tool, err)
 	}
 
 	// 导出镜像为tar包
 	tarPath := fmt.Sprintf("/tmp/%s.tar", spec.Name)
 	fmt.Printf("   导出镜像: %s\n", tarPath)
 
-	saveCmd := exec.Command("docker", "save", "-o", tarPath, imageName)
+	saveCmd := exec.Command(tool, "save", "-o", tarPath, imageName)
 	if err := saveCmd.Run(); err != nil {
 		return "", fmt.Errorf("导出镜像失败: %w", err)
 	}
@@ -771,6 +503,7 @@ func monitorDeployment(deploymentID string) error {
 	// 轮询部署状态
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
+	awaitingNoticeShown := false
 
 	for {
 		select {
@@ -797,6 +530,13 @@ func monitorDeployment(deploymentID string) error {
 					fmt.Printf("错误信息: %s\n", status.ErrorMessage)
 				}
 				return fmt.Errorf("部署失败")
+			case "AWAITING_APPROVAL":
+				// 受保护应用的部署需要在控制台批准后才会执行
+				if !awaitingNoticeShown {
+					fmt.Println("\n⏳ 应用受保护，部署等待审批，请在控制台批准或拒绝")
+					awaitingNoticeShown = true
+				}
+				continue
 			case "PENDING", "RUNNING":
 				fmt.Print(".")
 				continue
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type deploymentDiffTarget struct {
+	DeploymentUid string    `json:"deployment_uid"`
+	ReleaseUid    string    `json:"release_uid"`
+	Version       string    `json:"version"`
+	ImageName     string    `json:"image_name"`
+	CommitSHA     string    `json:"commit_sha"`
+	Status        string    `json:"status"`
+	DeployedAt    time.Time `json:"deployed_at"`
+}
+
+type deploymentDiffCommit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+}
+
+type deploymentDiffSetting struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type deploymentDiffRouting struct {
+	DomainName string `json:"domain_name"`
+	HostPort   int    `json:"host_port"`
+}
+
+// deploymentDiffResp 两次部署之间的差异
+type deploymentDiffResp struct {
+	From         deploymentDiffTarget `json:"from"`
+	To           deploymentDiffTarget `json:"to"`
+	ImageChanged bool                 `json:"image_changed"`
+	Commits      struct {
+		Rollback   bool                   `json:"rollback"`
+		Total      int                    `json:"total"`
+		Commits    []deploymentDiffCommit `json:"commits"`
+		CompareURL string                 `json:"compare_url"`
+		Error      string                 `json:"error"`
+	} `json:"commits"`
+	EnvAdded        []envDiffEntry `json:"env_added"`
+	EnvRemoved      []envDiffEntry `json:"env_removed"`
+	EnvChanged      []envDiffEntry `json:"env_changed"`
+	QuadletRecorded bool           `json:"quadlet_recorded"`
+	QuadletChanged  bool           `json:"quadlet_changed"`
+	Quadlet         []struct {
+		Op   string `json:"op"`
+		Text string `json:"text"`
+	} `json:"quadlet"`
+	ConfigRecorded  bool                    `json:"config_recorded"`
+	Settings        []deploymentDiffSetting `json:"settings"`
+	RoutingsAdded   []deploymentDiffRouting `json:"routings_added"`
+	RoutingsRemoved []deploymentDiffRouting `json:"routings_removed"`
+}
+
+// cmdDiff 比较应用的两次部署。from/to 可以是部署 ID、Release ID、latest 或 previous，
+// 默认比较上一次与最近一次成功部署
+func cmdDiff(args []string, app string) error {
+	if len(args) > 2 {
+		return fmt.Errorf("最多指定两个部署: orbitctl diff <from> <to>")
+	}
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+	from, to := "previous", "latest"
+	if len(args) > 0 {
+		from = args[0]
+	}
+	if len(args) > 1 {
+		to = args[1]
+	}
+
+	query := url.Values{"from": {from}, "to": {to}}
+	resp, err := httpGetJSON(apiURL("apps.by_name.deployments.diff", appName)+"?"+query.Encode(), true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var diffResp apiResponse[deploymentDiffResp]
+	if err := decodeAPIResponse(resp.Body, &diffResp); err != nil {
+		return err
+	}
+	diff := diffResp.Data
+
+	fmt.Printf("🔍 部署差异: %s\n", appName)
+	printDiffTarget("从", diff.From)
+	printDiffTarget("到", diff.To)
+
+	fmt.Println("\n📦 镜像")
+	if diff.ImageChanged {
+		fmt.Printf("   - %s\n", diff.From.ImageName)
+		fmt.Printf("   + %s\n", diff.To.ImageName)
+	} else {
+		fmt.Println("   未变化")
+	}
+
+	fmt.Println("\n📝 提交")
+	switch {
+	case diff.From.CommitSHA == "" || diff.To.CommitSHA == "":
+		fmt.Println("   未记录提交（非 Git 构建的 Release）")
+	case diff.From.CommitSHA == diff.To.CommitSHA:
+		fmt.Println("   未变化")
+	default:
+		fmt.Printf("   %s -> %s\n", shortSHA(diff.From.CommitSHA), shortSHA(diff.To.CommitSHA))
+		if diff.Commits.Rollback {
+			fmt.Println("   ⚠️  回滚到更早的部署，以下提交将被撤销:")
+		}
+		for _, commit := range diff.Commits.Commits {
+			fmt.Printf("   %s %s (%s)\n", shortSHA(commit.SHA), commit.Message, commit.Author)
+		}
+		if diff.Commits.Total > len(diff.Commits.Commits) {
+			fmt.Printf("   ... 共 %d 个提交\n", diff.Commits.Total)
+		}
+		if diff.Commits.Error != "" {
+			fmt.Printf("   无法获取提交列表: %s\n", diff.Commits.Error)
+		}
+		if diff.Commits.CompareURL != "" {
+			fmt.Printf("   %s\n", diff.Commits.CompareURL)
+		}
+	}
+
+	fmt.Println("\n🔧 环境变量")
+	if len(diff.EnvAdded)+len(diff.EnvRemoved)+len(diff.EnvChanged) == 0 {
+		fmt.Println("   未变化")
+	}
+	for _, e := range diff.EnvAdded {
+		fmt.Printf("   + %s = %s\n", e.Key, e.New)
+	}
+	for _, e := range diff.EnvRemoved {
+		fmt.Printf("   - %s = %s\n", e.Key, e.Old)
+	}
+	for _, e := range diff.EnvChanged {
+		fmt.Printf("   ~ %s: %s -> %s\n", e.Key, e.Old, e.New)
+	}
+
+	fmt.Println("\n⚙️  路由和资源")
+	switch {
+	case !diff.ConfigRecorded:
+		fmt.Println("   早期的部署没有记录配置快照")
+	case len(diff.Settings)+len(diff.RoutingsAdded)+len(diff.RoutingsRemoved) == 0:
+		fmt.Println("   未变化")
+	default:
+		for _, s := range diff.Settings {
+			fmt.Printf("   ~ %s: %s -> %s\n", s.Field, s.Old, s.New)
+		}
+		for _, r := range diff.RoutingsAdded {
+			fmt.Printf("   + %s -> :%d\n", r.DomainName, r.HostPort)
+		}
+		for _, r := range diff.RoutingsRemoved {
+			fmt.Printf("   - %s -> :%d\n", r.DomainName, r.HostPort)
+		}
+	}
+
+	fmt.Println("\n📄 Quadlet")
+	switch {
+	case !diff.QuadletRecorded:
+		fmt.Println("   早期的部署没有记录 Quadlet 内容")
+	case !diff.QuadletChanged:
+		fmt.Println("   未变化")
+	default:
+		for _, line := range diff.Quadlet {
+			if line.Op != " " {
+				fmt.Printf("   %s %s\n", line.Op, line.Text)
+			}
+		}
+	}
+	return nil
+}
+
+func printDiffTarget(label string, t deploymentDiffTarget) {
+	fmt.Printf("   %s: %s (Release %s, %s, %s)\n", label, t.DeploymentUid, t.ReleaseUid, t.Status, t.DeployedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
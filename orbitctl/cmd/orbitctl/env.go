@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// 环境变量相关结构
+type envVariable struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	Secret    bool      `json:"secret"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type envVariablesResp struct {
+	Variables []envVariable `json:"variables"`
+}
+
+type envVariableInput struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret"`
+}
+
+type envSetReq struct {
+	Variables []envVariableInput `json:"variables"`
+	Unset     []string           `json:"unset"`
+	Redeploy  bool               `json:"redeploy"`
+}
+
+type envSetResp struct {
+	Created       int    `json:"created"`
+	Updated       int    `json:"updated"`
+	Deleted       int    `json:"deleted"`
+	DeploymentUid string `json:"deployment_uid"`
+}
+
+type envDiffEntry struct {
+	Key    string `json:"key"`
+	Old    string `json:"old"`
+	New    string `json:"new"`
+	Secret bool   `json:"secret"`
+}
+
+type envDiffResp struct {
+	DeploymentUid string         `json:"deployment_uid"`
+	DeployedAt    time.Time      `json:"deployed_at"`
+	Added         []envDiffEntry `json:"added"`
+	Removed       []envDiffEntry `json:"removed"`
+	Changed       []envDiffEntry `json:"changed"`
+}
+
+// resolveAppName 返回 --app 参数或 orbitdeploy.toml 中的应用名称
+func resolveAppName(app string) (string, error) {
+	appName := getOrDefault(app, getAppNameFromConfig())
+	if appName == "" {
+		return "", fmt.Errorf("应用名称不能为空，请使用 --app 参数或在 orbitdeploy.toml 中指定")
+	}
+	return appName, nil
+}
+
+// cmdEnvList 列出环境变量
+func cmdEnvList(app string, reveal bool) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	url := apiURL("apps.by_name.env", appName)
+	if reveal {
+		url += "?reveal=true"
+	}
+	resp, err := httpGetJSON(url, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envResp apiResponse[envVariablesResp]
+	if err := decodeAPIResponse(resp.Body, &envResp); err != nil {
+		return err
+	}
+
+	fmt.Printf("📋 环境变量列表: %s\n", appName)
+	if len(envResp.Data.Variables) == 0 {
+		fmt.Println("   (无环境变量)")
+		return nil
+	}
+	for _, v := range envResp.Data.Variables {
+		if v.Secret {
+			fmt.Printf("   %s = %s (密钥)\n", v.Key, v.Value)
+		} else {
+			fmt.Printf("   %s = %s\n", v.Key, v.Value)
+		}
+	}
+	return nil
+}
+
+// cmdEnvSet 设置一个或多个环境变量，可从 .env 文件导入
+func cmdEnvSet(pairs []string, app, fromFile string, secret, redeploy bool) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	var variables []envVariableInput
+	if fromFile != "" {
+		fileVars, err := loadDotEnvFile(fromFile)
+		if err != nil {
+			return err
+		}
+		for _, v := range fileVars {
+			variables = append(variables, envVariableInput{Key: v[0], Value: v[1], Secret: secret})
+		}
+	}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("格式错误: %s，应为 KEY=VALUE", pair)
+		}
+		variables = append(variables, envVariableInput{Key: parts[0], Value: parts[1], Secret: secret})
+	}
+	if len(variables) == 0 {
+		return fmt.Errorf("请指定 KEY=VALUE 或使用 --from-file 导入")
+	}
+
+	fmt.Printf("🔧 设置环境变量: %s\n", appName)
+	for _, v := range variables {
+		if v.Secret {
+			fmt.Printf("   %s = ****** (密钥)\n", v.Key)
+		} else {
+			fmt.Printf("   %s = %s\n", v.Key, v.Value)
+		}
+	}
+
+	return putEnvVariables(appName, envSetReq{Variables: variables, Redeploy: redeploy})
+}
+
+// cmdEnvUnset 删除一个或多个环境变量
+func cmdEnvUnset(keys []string, app string, redeploy bool) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("请指定要删除的变量名")
+	}
+
+	fmt.Printf("🗑️  删除环境变量: %s\n", appName)
+	fmt.Printf("   变量: %s\n", strings.Join(keys, ", "))
+
+	return putEnvVariables(appName, envSetReq{Unset: keys, Redeploy: redeploy})
+}
+
+// cmdEnvDiff 比较当前环境变量与最近一次成功部署时的快照
+func cmdEnvDiff(app string) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpGetJSON(apiURL("apps.by_name.env.diff", appName), true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var diffResp apiResponse[envDiffResp]
+	if err := decodeAPIResponse(resp.Body, &diffResp); err != nil {
+		return err
+	}
+	diff := diffResp.Data
+
+	fmt.Printf("🔍 环境变量差异: %s\n", appName)
+	fmt.Printf("   对比部署: %s (%s)\n", diff.DeploymentUid, diff.DeployedAt.Local().Format("2006-01-02 15:04:05"))
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		fmt.Println("\n✅ 当前环境变量与线上部署一致")
+		return nil
+	}
+
+	fmt.Println()
+	for _, e := range diff.Added {
+		fmt.Printf("   + %s = %s\n", e.Key, e.New)
+	}
+	for _, e := range diff.Removed {
+		fmt.Printf("   - %s = %s\n", e.Key, e.Old)
+	}
+	for _, e := range diff.Changed {
+		fmt.Printf("   ~ %s: %s -> %s\n", e.Key, e.Old, e.New)
+	}
+	fmt.Println("\n💡 使用 orbitctl env set --redeploy 或重新部署使更改生效")
+	return nil
+}
+
+func putEnvVariables(appName string, req envSetReq) error {
+	resp, err := httpPutJSON(apiURL("apps.by_name.env", appName), req, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var setResp apiResponse[envSetResp]
+	if err := decodeAPIResponse(resp.Body, &setResp); err != nil {
+		return err
+	}
+
+	result := setResp.Data
+	fmt.Printf("✅ 新增 %d 个，更新 %d 个，删除 %d 个\n", result.Created, result.Updated, result.Deleted)
+	if result.DeploymentUid != "" {
+		fmt.Printf("🚀 已触发重新部署: %s\n", result.DeploymentUid)
+		return monitorDeployment(result.DeploymentUid)
+	}
+	if !req.Redeploy {
+		fmt.Println("💡 更改将在下次部署时生效，可使用 --redeploy 立即重新部署")
+	}
+	return nil
+}
+
+func decodeAPIResponse[T any](body io.Reader, result *apiResponse[T]) error {
+	if err := json.NewDecoder(body).Decode(result); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("%s", result.Message)
+	}
+	return nil
+}
+
+// loadDotEnvFile 解析 .env 文件，保持变量出现的顺序
+func loadDotEnvFile(path string) ([][2]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer f.Close()
+
+	var vars [][2]string
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s 第 %d 行格式错误，应为 KEY=VALUE", path, lineNo)
+		}
+		vars = append(vars, [2]string{key, parseDotEnvValue(strings.TrimSpace(value))})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return vars, nil
+}
+
+// parseDotEnvValue 去掉引号；双引号内支持 \n 等转义，未加引号时去掉行尾注释
+func parseDotEnvValue(value string) string {
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return value[1 : len(value)-1]
+		case value[0] == '"' && value[len(value)-1] == '"':
+			replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+			return replacer.Replace(value[1 : len(value)-1])
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
@@ -25,6 +25,18 @@ func httpPostJSON(url string, body any, withAuth bool) (*http.Response, error) {
 	return doRequest(req)
 }
 
+func httpPutJSON(url string, body any, withAuth bool) (*http.Response, error) {
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPut, url, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if withAuth {
+		if at := loadAccessToken(); at != "" {
+			req.Header.Set("Authorization", "Bearer "+at)
+		}
+	}
+	return doRequest(req)
+}
+
 func httpGetJSON(url string, withAuth bool) (*http.Response, error) {
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	req.Header.Set("Accept", "application/json")
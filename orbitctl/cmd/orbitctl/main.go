@@ -14,15 +14,22 @@ func usage() {
 	fmt.Println("  orbitctl auth refresh")
 	fmt.Println("  orbitctl init          [--name 应用名] [--project 项目名] [--env 环境名]")
 	fmt.Println("  orbitctl spec-validate [-f 文件]")
-	fmt.Println("  orbitctl deploy        [--project 项目名] [--env 环境名] [--dry-run]")
-	fmt.Println("  orbitctl env list      [--project 项目名] [--env 环境名]")
-	fmt.Println("  orbitctl env set       KEY=VALUE [--project 项目名] [--env 环境名]")
-	fmt.Println("  orbitctl env unset     KEY [--project 项目名] [--env 环境名]")
+	fmt.Println("  orbitctl deploy        [--project 项目名] [--env 环境名] [--dry-run] [--freeze-override 原因]")
+	fmt.Println("  orbitctl env list      [--reveal] [--app 应用名]")
+	fmt.Println("  orbitctl env set       KEY=VALUE... [--from-file .env] [--secret] [--redeploy] [--app 应用名]")
+	fmt.Println("  orbitctl env unset     KEY... [--redeploy] [--app 应用名]")
+	fmt.Println("  orbitctl env diff      [--app 应用名]")
 	fmt.Println("  orbitctl scale         副本数 [--project 项目名] [--env 环境名]")
 
-	fmt.Println("  orbitctl status        [--project 项目名] [--env 环境名]")
-	fmt.Println("  orbitctl logs          [-f] [--project 项目名] [--env 环境名]")
-	fmt.Println("  orbitctl inspect       [--project 项目名] [--env 环境名]")
+	fmt.Println("  orbitctl status        [--app 应用名]")
+	fmt.Println("  orbitctl logs          [-f] [-n 行数] [--app 应用名]")
+	fmt.Println("  orbitctl inspect       [--app 应用名]")
+	fmt.Println("  orbitctl canary status [--app 应用名]")
+	fmt.Println("  orbitctl canary step   [--weight 百分比] [--app 应用名]")
+	fmt.Println("  orbitctl canary promote [--app 应用名]")
+	fmt.Println("  orbitctl canary abort  [--reason 原因] [--app 应用名]")
+	fmt.Println("  orbitctl promote       --from 来源应用 --to 目标应用 [--release Release ID] [--deploy]")
+	fmt.Println("  orbitctl diff          [from] [to] [--app 应用名]  (部署 ID、Release ID、latest 或 previous)")
 	fmt.Println("")
 }
 
@@ -94,8 +101,9 @@ func main() {
 		project := deployCmd.String("project", "", "项目名称")
 		env := deployCmd.String("env", "dev", "环境名称")
 		dryRun := deployCmd.Bool("dry-run", false, "仅显示部署计划，不实际执行")
+		freezeOverride := deployCmd.String("freeze-override", "", "处于部署冻结窗口时强制部署的原因")
 		_ = deployCmd.Parse(os.Args[2:])
-		if err := cmdDeploy(*project, *env, *dryRun); err != nil {
+		if err := cmdDeploy(*project, *env, *dryRun, *freezeOverride); err != nil {
 			fmt.Fprintf(os.Stderr, "部署失败: %v\n", err)
 			os.Exit(1)
 		}
@@ -108,39 +116,41 @@ func main() {
 		switch sub {
 		case "list":
 			envCmd := flag.NewFlagSet("env-list", flag.ExitOnError)
-			project := envCmd.String("project", "", "项目名称")
-			env := envCmd.String("env", "dev", "环境名称")
+			app := envCmd.String("app", "", "应用名称")
+			reveal := envCmd.Bool("reveal", false, "显示密钥变量的值（需用户名密码登录，应用令牌不可用）")
 			_ = envCmd.Parse(os.Args[3:])
-			if err := cmdEnvList(*project, *env); err != nil {
+			if err := cmdEnvList(*app, *reveal); err != nil {
 				fmt.Fprintf(os.Stderr, "获取环境变量失败: %v\n", err)
 				os.Exit(1)
 			}
 		case "set":
-			if len(os.Args) < 4 {
-				usage()
-				os.Exit(1)
-			}
 			envCmd := flag.NewFlagSet("env-set", flag.ExitOnError)
-			project := envCmd.String("project", "", "项目名称")
-			env := envCmd.String("env", "dev", "环境名称")
-			_ = envCmd.Parse(os.Args[4:])
-			if err := cmdEnvSet(os.Args[3], *project, *env); err != nil {
+			app := envCmd.String("app", "", "应用名称")
+			fromFile := envCmd.String("from-file", "", "从 .env 文件导入")
+			secret := envCmd.Bool("secret", false, "作为密钥加密存储")
+			redeploy := envCmd.Bool("redeploy", false, "设置后立即重新部署")
+			pairs := parseInterspersed(envCmd, os.Args[3:])
+			if err := cmdEnvSet(pairs, *app, *fromFile, *secret, *redeploy); err != nil {
 				fmt.Fprintf(os.Stderr, "设置环境变量失败: %v\n", err)
 				os.Exit(1)
 			}
 		case "unset":
-			if len(os.Args) < 4 {
-				usage()
-				os.Exit(1)
-			}
 			envCmd := flag.NewFlagSet("env-unset", flag.ExitOnError)
-			project := envCmd.String("project", "", "项目名称")
-			env := envCmd.String("env", "dev", "环境名称")
-			_ = envCmd.Parse(os.Args[4:])
-			if err := cmdEnvUnset(os.Args[3], *project, *env); err != nil {
+			app := envCmd.String("app", "", "应用名称")
+			redeploy := envCmd.Bool("redeploy", false, "删除后立即重新部署")
+			keys := parseInterspersed(envCmd, os.Args[3:])
+			if err := cmdEnvUnset(keys, *app, *redeploy); err != nil {
 				fmt.Fprintf(os.Stderr, "删除环境变量失败: %v\n", err)
 				os.Exit(1)
 			}
+		case "diff":
+			envCmd := flag.NewFlagSet("env-diff", flag.ExitOnError)
+			app := envCmd.String("app", "", "应用名称")
+			_ = envCmd.Parse(os.Args[3:])
+			if err := cmdEnvDiff(*app); err != nil {
+				fmt.Fprintf(os.Stderr, "比较环境变量失败: %v\n", err)
+				os.Exit(1)
+			}
 		default:
 			usage()
 			os.Exit(1)
@@ -160,32 +170,64 @@ func main() {
 		}
 	case "status":
 		statusCmd := flag.NewFlagSet("status", flag.ExitOnError)
-		project := statusCmd.String("project", "", "项目名称")
-		env := statusCmd.String("env", "dev", "环境名称")
+		app := statusCmd.String("app", "", "应用名称")
 		_ = statusCmd.Parse(os.Args[2:])
-		if err := cmdStatus(*project, *env); err != nil {
+		if err := cmdStatus(*app); err != nil {
 			fmt.Fprintf(os.Stderr, "获取状态失败: %v\n", err)
 			os.Exit(1)
 		}
 	case "logs":
 		logsCmd := flag.NewFlagSet("logs", flag.ExitOnError)
 		follow := logsCmd.Bool("f", false, "持续跟踪日志")
-		project := logsCmd.String("project", "", "项目名称")
-		env := logsCmd.String("env", "dev", "环境名称")
+		lines := logsCmd.Int("n", 100, "显示最近的行数")
+		app := logsCmd.String("app", "", "应用名称")
 		_ = logsCmd.Parse(os.Args[2:])
-		if err := cmdLogs(*follow, *project, *env); err != nil {
+		if err := cmdLogs(*app, *lines, *follow); err != nil {
 			fmt.Fprintf(os.Stderr, "获取日志失败: %v\n", err)
 			os.Exit(1)
 		}
 	case "inspect":
 		inspectCmd := flag.NewFlagSet("inspect", flag.ExitOnError)
-		project := inspectCmd.String("project", "", "项目名称")
-		env := inspectCmd.String("env", "dev", "环境名称")
+		app := inspectCmd.String("app", "", "应用名称")
 		_ = inspectCmd.Parse(os.Args[2:])
-		if err := cmdInspect(*project, *env); err != nil {
+		if err := cmdInspect(*app); err != nil {
 			fmt.Fprintf(os.Stderr, "检查配置失败: %v\n", err)
 			os.Exit(1)
 		}
+	case "canary":
+		if len(os.Args) < 3 {
+			usage()
+			os.Exit(1)
+		}
+		sub := os.Args[2]
+		canaryCmd := flag.NewFlagSet("canary-"+sub, flag.ExitOnError)
+		app := canaryCmd.String("app", "", "应用名称")
+		weight := canaryCmd.Int("weight", 0, "新版本流量百分比，0 表示按步长增加")
+		reason := canaryCmd.String("reason", "", "中止原因")
+		_ = canaryCmd.Parse(os.Args[3:])
+		if err := cmdCanary(sub, *app, *weight, *reason); err != nil {
+			fmt.Fprintf(os.Stderr, "金丝雀发布操作失败: %v\n", err)
+			os.Exit(1)
+		}
+	case "promote":
+		promoteCmd := flag.NewFlagSet("promote", flag.ExitOnError)
+		from := promoteCmd.String("from", "", "来源应用名称，如 staging")
+		to := promoteCmd.String("to", "", "目标应用名称，如 prod")
+		release := promoteCmd.String("release", "", "要提升的 Release ID，默认为来源应用当前运行的版本")
+		deploy := promoteCmd.Bool("deploy", false, "提升后立即部署")
+		_ = promoteCmd.Parse(os.Args[2:])
+		if err := cmdPromote(*from, *to, *release, *deploy); err != nil {
+			fmt.Fprintf(os.Stderr, "提升 Release 失败: %v\n", err)
+			os.Exit(1)
+		}
+	case "diff":
+		diffCmd := flag.NewFlagSet("diff", flag.ExitOnError)
+		app := diffCmd.String("app", "", "应用名称")
+		refs := parseInterspersed(diffCmd, os.Args[2:])
+		if err := cmdDiff(refs, *app); err != nil {
+			fmt.Fprintf(os.Stderr, "比较部署失败: %v\n", err)
+			os.Exit(1)
+		}
 	default:
 		usage()
 		os.Exit(1)
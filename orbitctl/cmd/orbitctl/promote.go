@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+)
+
+// promoteResp 提升 Release 的结果
+type promoteResp struct {
+	ReleaseUid       string `json:"release_uid"`
+	SourceReleaseUid string `json:"source_release_uid"`
+	ImageName        string `json:"image_name"`
+	Created          bool   `json:"created"`
+	DeploymentUid    string `json:"deployment_uid"`
+}
+
+// cmdPromote 把 from 应用的 Release 提升到 to 应用，目标应用直接使用同一个镜像，不重新构建。
+// release 为空时提升 from 当前运行的版本
+func cmdPromote(from, to, release string, deploy bool) error {
+	if from == "" || to == "" {
+		return fmt.Errorf("需要同时指定 --from 和 --to")
+	}
+
+	resp, err := httpPostJSON(apiURL("apps.by_name.promote", to), map[string]any{
+		"from":        from,
+		"release_uid": release,
+		"deploy":      deploy,
+	}, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result apiResponse[promoteResp]
+	if err := decodeAPIResponse(resp.Body, &result); err != nil {
+		return err
+	}
+
+	promoted := result.Data
+	fmt.Printf("🚀 %s -> %s\n", from, to)
+	fmt.Printf("   来源 Release: %s\n", promoted.SourceReleaseUid)
+	if promoted.Created {
+		fmt.Printf("   新 Release: %s\n", promoted.ReleaseUid)
+	} else {
+		fmt.Printf("   沿用已有的 Release: %s\n", promoted.ReleaseUid)
+	}
+	fmt.Printf("   镜像: %s\n", promoted.ImageName)
+	if promoted.DeploymentUid != "" {
+		fmt.Printf("   ✅ 部署已触发: %s\n", promoted.DeploymentUid)
+	} else {
+		fmt.Printf("   使用 --deploy 在提升后立即部署\n")
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 应用运行状态相关结构
+type releaseSummary struct {
+	Uid       string    `json:"uid"`
+	Version   string    `json:"version"`
+	ImageName string    `json:"image_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type serviceStatus struct {
+	Name        string `json:"name"`
+	ActiveState string `json:"active_state"`
+	SubState    string `json:"sub_state"`
+	SystemPort  int    `json:"system_port"`
+}
+
+type routingStatus struct {
+	Domain    string `json:"domain"`
+	HostPort  int    `json:"host_port"`
+	IsActive  bool   `json:"is_active"`
+	DNSStatus string `json:"dns_status"`
+}
+
+type deploymentSummary struct {
+	Uid        string     `json:"uid"`
+	ReleaseUid string     `json:"release_uid"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+type appStatusResp struct {
+	AppName           string             `json:"app_name"`
+	AppUid            string             `json:"app_uid"`
+	Project           string             `json:"project"`
+	Status            string             `json:"status"`
+	GeneratedHostname string             `json:"generated_hostname"`
+	ActiveRelease     *releaseSummary    `json:"active_release"`
+	Service           *serviceStatus     `json:"service"`
+	Routings          []routingStatus    `json:"routings"`
+	LastDeployment    *deploymentSummary `json:"last_deployment"`
+	CanaryStatus      string             `json:"canary_status"`
+	CanaryWeight      int                `json:"canary_weight"`
+}
+
+type volumeMount struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type resourceLimits struct {
+	CPUQuota            float64 `json:"cpu_quota"`
+	MemoryLimitMB       int     `json:"memory_limit_mb"`
+	MemoryReservationMB int     `json:"memory_reservation_mb"`
+	PidsLimit           int     `json:"pids_limit"`
+	OOMPolicy           string  `json:"oom_policy"`
+}
+
+type appInspectResp struct {
+	AppName          string         `json:"app_name"`
+	Project          string         `json:"project"`
+	RepoURL          string         `json:"repo_url"`
+	Branch           string         `json:"branch"`
+	BuildDir         string         `json:"build_dir"`
+	BuildType        string         `json:"build_type"`
+	TargetPort       int            `json:"target_port"`
+	ExecCommand      string         `json:"exec_command"`
+	AutoUpdatePolicy string         `json:"auto_update_policy"`
+	Image            string         `json:"image"`
+	Volumes          []volumeMount  `json:"volumes"`
+	Resources        resourceLimits `json:"resources"`
+	Domains          []string       `json:"domains"`
+	Env              []envVariable  `json:"env"`
+}
+
+// cmdStatus 查看应用运行状态
+func cmdStatus(app string) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpGetJSON(apiURL("apps.by_name.status", appName), true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var statusResp apiResponse[appStatusResp]
+	if err := decodeAPIResponse(resp.Body, &statusResp); err != nil {
+		return err
+	}
+	s := statusResp.Data
+
+	fmt.Printf("📊 应用状态: %s\n", s.AppName)
+	fmt.Printf("   项目: %s\n", s.Project)
+	fmt.Printf("   状态: %s\n", s.Status)
+	if s.GeneratedHostname != "" {
+		fmt.Printf("   默认域名: %s\n", s.GeneratedHostname)
+	}
+
+	fmt.Println("\n📦 当前版本:")
+	if s.ActiveRelease == nil {
+		fmt.Println("   (尚未部署)")
+	} else {
+		fmt.Printf("   %s  %s\n", s.ActiveRelease.Uid, s.ActiveRelease.Version)
+		fmt.Printf("   镜像: %s\n", s.ActiveRelease.ImageName)
+	}
+
+	fmt.Println("\n⚙️  服务:")
+	if s.Service == nil {
+		fmt.Println("   (无运行中的服务)")
+	} else {
+		icon := "❌"
+		if s.Service.ActiveState == "active" {
+			icon = "✅"
+		}
+		fmt.Printf("   %s %s  %s/%s\n", icon, s.Service.Name, s.Service.ActiveState, s.Service.SubState)
+		fmt.Printf("   系统端口: %d\n", s.Service.SystemPort)
+	}
+
+	fmt.Println("\n🌐 路由:")
+	if len(s.Routings) == 0 {
+		fmt.Println("   (无路由)")
+	}
+	for _, r := range s.Routings {
+		state := "启用"
+		if !r.IsActive {
+			state = "停用"
+		}
+		line := fmt.Sprintf("   %s -> :%d (%s", r.Domain, r.HostPort, state)
+		if r.DNSStatus != "" {
+			line += ", DNS: " + r.DNSStatus
+		}
+		fmt.Println(line + ")")
+	}
+
+	fmt.Println("\n🚀 最近部署:")
+	if s.LastDeployment == nil {
+		fmt.Println("   (无部署记录)")
+	} else {
+		d := s.LastDeployment
+		fmt.Printf("   %s  %s  版本 %s\n", d.Uid, d.Status, d.ReleaseUid)
+		fmt.Printf("   开始: %s\n", d.StartedAt.Local().Format("2006-01-02 15:04:05"))
+		if d.FinishedAt != nil {
+			fmt.Printf("   结束: %s\n", d.FinishedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	if s.CanaryStatus != "" {
+		fmt.Printf("\n🐤 金丝雀发布: %s (%d%%)\n", s.CanaryStatus, s.CanaryWeight)
+	}
+	return nil
+}
+
+// cmdLogs 查看容器日志，follow 为 true 时持续输出直到 Ctrl+C
+func cmdLogs(app string, lines int, follow bool) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s?lines=%d", apiURL("apps.by_name.logs", appName), lines)
+	if follow {
+		url += "&follow=true"
+	}
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Accept", "text/plain")
+	resp, err := doRequest(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 出错时服务端返回 JSON
+	if resp.StatusCode != http.StatusOK {
+		var errResp apiResponse[any]
+		if err := decodeAPIResponse(resp.Body, &errResp); err != nil {
+			return err
+		}
+		return fmt.Errorf("获取日志失败，状态码: %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintln(os.Stdout, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// cmdInspect 显示服务端生效配置，并与本地 orbitdeploy.toml 对比
+func cmdInspect(app string) error {
+	appName, err := resolveAppName(app)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpGetJSON(apiURL("apps.by_name.inspect", appName), true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var inspectResp apiResponse[appInspectResp]
+	if err := decodeAPIResponse(resp.Body, &inspectResp); err != nil {
+		return err
+	}
+	remote := inspectResp.Data
+
+	fmt.Printf("🔍 服务端配置: %s\n", remote.AppName)
+	fmt.Printf("   项目: %s\n", remote.Project)
+	if remote.RepoURL != "" {
+		fmt.Printf("   仓库: %s (%s)\n", remote.RepoURL, remote.Branch)
+	}
+	if remote.BuildType != "" {
+		fmt.Printf("   构建: %s %s\n", remote.BuildType, remote.BuildDir)
+	}
+	fmt.Printf("   镜像: %s\n", getOrDefault(remote.Image, "(尚未部署)"))
+	fmt.Printf("   端口: %d\n", remote.TargetPort)
+	if remote.ExecCommand != "" {
+		fmt.Printf("   启动命令: %s\n", remote.ExecCommand)
+	}
+	if remote.AutoUpdatePolicy != "" {
+		fmt.Printf("   自动更新: %s\n", remote.AutoUpdatePolicy)
+	}
+	fmt.Printf("   域名: %s\n", joinOrNone(remote.Domains))
+	for _, v := range remote.Volumes {
+		fmt.Printf("   卷: %s -> %s\n", v.Source, v.Target)
+	}
+	if r := remote.Resources; r.CPUQuota > 0 || r.MemoryLimitMB > 0 || r.PidsLimit > 0 {
+		fmt.Printf("   资源: cpu=%g memory=%dMB pids=%d\n", r.CPUQuota, r.MemoryLimitMB, r.PidsLimit)
+	}
+	for _, v := range remote.Env {
+		fmt.Printf("   环境变量: %s = %s\n", v.Key, v.Value)
+	}
+
+	spec, err := loadSpecFromFile("orbitdeploy.toml")
+	if err != nil {
+		fmt.Printf("\n💡 未加载本地配置 (%v)，跳过差异检查\n", err)
+		return nil
+	}
+
+	fmt.Println("\n📄 与本地 orbitdeploy.toml 对比:")
+	drift := 0
+	check := func(field, local, server string) {
+		if local == server {
+			fmt.Printf("   ✅ %s: %s\n", field, getOrDefault(local, "(空)"))
+			return
+		}
+		drift++
+		fmt.Printf("   ⚠️  %s: 本地 %s，服务端 %s\n", field, getOrDefault(local, "(空)"), getOrDefault(server, "(空)"))
+	}
+
+	check("项目", spec.Project, remote.Project)
+
+	var container containerTOML
+	if len(spec.Containers) > 0 {
+		container = spec.Containers[0]
+	}
+	if container.PublishPort != 0 {
+		check("端口", strconv.Itoa(container.PublishPort), strconv.Itoa(remote.TargetPort))
+	}
+
+	localDomains := make([]string, 0, len(container.Domains))
+	for _, d := range container.Domains {
+		localDomains = append(localDomains, d.Host)
+	}
+	check("域名", joinSorted(localDomains), joinSorted(remote.Domains))
+
+	localVolumes := make([]string, 0, len(container.Volumes))
+	for _, v := range container.Volumes {
+		localVolumes = append(localVolumes, v.Source+":"+v.Target)
+	}
+	remoteVolumes := make([]string, 0, len(remote.Volumes))
+	for _, v := range remote.Volumes {
+		remoteVolumes = append(remoteVolumes, v.Source+":"+v.Target)
+	}
+	check("卷", joinSorted(localVolumes), joinSorted(remoteVolumes))
+
+	resources := container.Resources
+	if resources == nil {
+		resources = spec.Resources
+	}
+	if resources != nil {
+		if resources.CPU != "" {
+			check("CPU", formatCPU(resources.CPU), strconv.FormatFloat(remote.Resources.CPUQuota, 'g', -1, 64))
+		}
+		if resources.Memory != "" {
+			check("内存(MB)", formatMemoryMB(resources.Memory), strconv.Itoa(remote.Resources.MemoryLimitMB))
+		}
+	}
+
+	remoteEnv := make(map[string]envVariable, len(remote.Env))
+	for _, v := range remote.Env {
+		remoteEnv[v.Key] = v
+	}
+	keys := make([]string, 0, len(container.ExtraEnv))
+	for k := range container.ExtraEnv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		server, ok := remoteEnv[k]
+		switch {
+		case !ok:
+			check("环境变量 "+k, container.ExtraEnv[k], "")
+		case server.Secret:
+			// 密钥值不返回，无法比较
+			fmt.Printf("   🔒 环境变量 %s: 服务端为密钥，跳过比较\n", k)
+		default:
+			check("环境变量 "+k, container.ExtraEnv[k], server.Value)
+		}
+	}
+
+	if drift == 0 {
+		fmt.Println("\n✅ 本地配置与服务端一致")
+	} else {
+		fmt.Printf("\n⚠️  发现 %d 处差异\n", drift)
+	}
+	return nil
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "(无)"
+	}
+	return strings.Join(items, ", ")
+}
+
+func joinSorted(items []string) string {
+	sorted := append([]string(nil), items...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ", ")
+}
+
+// formatCPU 将 "500m" 或 "0.5" 统一为核数
+func formatCPU(cpu string) string {
+	if milli, ok := strings.CutSuffix(cpu, "m"); ok {
+		if v, err := strconv.ParseFloat(milli, 64); err == nil {
+			return strconv.FormatFloat(v/1000, 'g', -1, 64)
+		}
+	}
+	if v, err := strconv.ParseFloat(cpu, 64); err == nil {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return cpu
+}
+
+// formatMemoryMB 将 "512Mi"、"1Gi"、"512M" 等统一为 MB
+func formatMemoryMB(memory string) string {
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"Gi", 1024}, {"Mi", 1}, {"Ki", 1.0 / 1024},
+		{"G", 1000}, {"M", 1}, {"g", 1024}, {"m", 1},
+	}
+	for _, u := range units {
+		if num, ok := strings.CutSuffix(memory, u.suffix); ok {
+			if v, err := strconv.ParseFloat(num, 64); err == nil {
+				return strconv.Itoa(int(v * u.factor))
+			}
+		}
+	}
+	return memory
+}
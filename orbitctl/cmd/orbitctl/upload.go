@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// uploadChunkRetries 单个分块连续失败的最大重试次数，每次重试前向服务端查询已接收的位置
+const uploadChunkRetries = 5
+
+// chunkedUploadResp 分块上传的状态，Offset 为下一个分块的起始位置
+type chunkedUploadResp struct {
+	UploadID   string `json:"upload_id"`
+	FileName   string `json:"file_name"`
+	TotalSize  int64  `json:"total_size"`
+	Offset     int64  `json:"offset"`
+	ChunkSize  int64  `json:"chunk_size"`
+	Status     string `json:"status"`
+	Resumed    bool   `json:"resumed"`
+	ReleaseUid string `json:"release_uid"`
+}
+
+// containerTool 返回构建和导出镜像使用的工具：ORBIT_CONTAINER_TOOL 指定时使用指定的工具，
+// 否则优先使用 podman，找不到时使用 docker
+func containerTool() string {
+	if tool := os.Getenv("ORBIT_CONTAINER_TOOL"); tool != "" {
+		return tool
+	}
+	if _, err := exec.LookPath("podman"); err == nil {
+		return "podman"
+	}
+	return "docker"
+}
+
+// uploadImageChunked 分块上传镜像 tar 包并创建 Release，返回 Release UID。
+// 分块失败时自动从服务端已接收的位置续传；服务端保留未完成的上传，再次上传相同文件时从中断处继续
+func uploadImageChunked(appName, filePath, version string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("计算文件校验和失败: %w", err)
+	}
+
+	var session apiResponse[chunkedUploadResp]
+	resp, err := httpPostJSON(apiURL("apps.by_name.uploads", appName), map[string]any{
+		"file_name":   filepath.Base(filePath),
+		"total_size":  info.Size(),
+		"sha256":      hex.EncodeToString(hash.Sum(nil)),
+		"version":     version,
+		"description": "CLI upload",
+	}, true)
+	if err != nil {
+		return "", err
+	}
+	err = decodeAPIResponse(resp.Body, &session)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("创建上传失败: %w", err)
+	}
+
+	upload := session.Data
+	if upload.Resumed && upload.Offset > 0 {
+		fmt.Printf("   ↻ 续传上次未完成的上传，已上传 %s\n", formatSize(upload.Offset))
+	}
+	chunkSize := upload.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 8 << 20
+	}
+
+	buf := make([]byte, chunkSize)
+	offset := upload.Offset
+	failures := 0
+	for offset < info.Size() {
+		n, err := file.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		next, err := putUploadChunk(appName, upload.UploadID, offset, buf[:n])
+		if err != nil {
+			failures++
+			if failures > uploadChunkRetries {
+				return "", fmt.Errorf("上传中断（已上传 %s，服务端会保留进度）: %w", formatSize(offset), err)
+			}
+			wait := time.Duration(1<<(failures-1)) * time.Second
+			fmt.Printf("\n   ⚠️  分块上传失败: %v，%s 后重试\n", err, wait)
+			time.Sleep(wait)
+			if status, statusErr := getChunkedUpload(appName, upload.UploadID); statusErr == nil {
+				offset = status.Offset
+			}
+			continue
+		}
+		failures = 0
+		offset = next
+		fmt.Printf("\r   上传中 %3d%% (%s / %s)", offset*100/info.Size(), formatSize(offset), formatSize(info.Size()))
+	}
+	fmt.Println()
+
+	fmt.Println("   校验并导入镜像...")
+	resp, err = httpPostJSON(apiURL("apps.by_name.upload.complete", appName, upload.UploadID), map[string]any{}, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result apiResponse[chunkedUploadResp]
+	if err := decodeAPIResponse(resp.Body, &result); err != nil {
+		return "", fmt.Errorf("完成上传失败: %w", err)
+	}
+	return result.Data.ReleaseUid, nil
+}
+
+// putUploadChunk 上传从 offset 开始的一个分块，返回服务端已接收的字节数
+func putUploadChunk(appName, uploadID string, offset int64, chunk []byte) (int64, error) {
+	sum := sha256.Sum256(chunk)
+	url := fmt.Sprintf("%s?offset=%d", apiURL("apps.by_name.upload", appName, uploadID), offset)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(chunk))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Chunk-Sha256", hex.EncodeToString(sum[:]))
+
+	resp, err := doRequest(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	var result apiResponse[chunkedUploadResp]
+	if err := decodeAPIResponse(resp.Body, &result); err != nil {
+		return 0, err
+	}
+	return result.Data.Offset, nil
+}
+
+// getChunkedUpload 查询上传的状态和服务端已接收的位置
+func getChunkedUpload(appName, uploadID string) (*chunkedUploadResp, error) {
+	resp, err := httpGetJSON(apiURL("apps.by_name.upload", appName, uploadID), true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result apiResponse[chunkedUploadResp]
+	if err := decodeAPIResponse(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// formatSize 以 KB/MB/GB 显示字节数
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	default:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+}
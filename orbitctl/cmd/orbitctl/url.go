@@ -26,21 +26,35 @@ func apiRoot() string {
 
 // 接口注册表：在此定义所有 API 路径，使用 fmt 格式化字符串。
 var endpoints = map[string]string{
-	"cli.configure.initiate":     "cli/configure/initiate",
-	"cli.configure.status":       "cli/configure/status/%s",
-	"auth.refresh_token":         "auth/refresh_token",
-	"cli.device_auth.sessions":   "cli/device-auth/sessions",
-	"cli.device_auth.token":      "cli/device-auth/token/%s",
-	"auth.logout":                "auth/logout",
-	"projects.variables":         "projects/%s/variables",
-	"projects.images":            "projects/%s/images",
-	"projects.deployments":       "projects/%s/deployments",
-	"deployments.logs":           "deployments/%s/logs",
-	"deployments.get":            "deployments/%s",
-	"apps.by_name.get":           "apps/by-name/%s",
-	"apps.by_name.releases":      "cli/apps/by-name/%s/releases",
-	"apps.by_name.deployments":   "cli/apps/by-name/%s/deployments",
-	"apps.by_name.config.export": "cli/apps/by-name/%s/config/export",
+	"cli.configure.initiate":        "cli/configure/initiate",
+	"cli.configure.status":          "cli/configure/status/%s",
+	"auth.refresh_token":            "auth/refresh_token",
+	"cli.device_auth.sessions":      "cli/device-auth/sessions",
+	"cli.device_auth.token":         "cli/device-auth/token/%s",
+	"auth.logout":                   "auth/logout",
+	"projects.images":               "projects/%s/images",
+	"projects.deployments":          "projects/%s/deployments",
+	"deployments.logs":              "deployments/%s/logs",
+	"deployments.get":               "deployments/%s",
+	"apps.by_name.get":              "apps/by-name/%s",
+	"apps.by_name.releases":         "cli/apps/by-name/%s/releases",
+	"apps.by_name.uploads":          "cli/apps/by-name/%s/uploads",
+	"apps.by_name.upload":           "cli/apps/by-name/%s/uploads/%s",
+	"apps.by_name.upload.complete":  "cli/apps/by-name/%s/uploads/%s/complete",
+	"apps.by_name.deployments":      "cli/apps/by-name/%s/deployments",
+	"apps.by_name.deployments.diff": "cli/apps/by-name/%s/deployments/diff",
+	"apps.by_name.config.export":    "cli/apps/by-name/%s/config/export",
+	"apps.by_name.apply":            "cli/apps/by-name/%s/apply",
+	"apps.by_name.env":              "cli/apps/by-name/%s/environment-variables",
+	"apps.by_name.env.diff":         "cli/apps/by-name/%s/environment-variables/diff",
+	"apps.by_name.canary":           "cli/apps/by-name/%s/canary",
+	"apps.by_name.canary.step":      "cli/apps/by-name/%s/canary/step",
+	"apps.by_name.canary.promote":   "cli/apps/by-name/%s/canary/promote",
+	"apps.by_name.canary.abort":     "cli/apps/by-name/%s/canary/abort",
+	"apps.by_name.promote":          "cli/apps/by-name/%s/promote",
+	"apps.by_name.status":           "cli/apps/by-name/%s/status",
+	"apps.by_name.logs":             "cli/apps/by-name/%s/logs",
+	"apps.by_name.inspect":          "cli/apps/by-name/%s/inspect",
 }
 
 // apiURL 根据注册的端点 key 和参数构建完整的 API URL。
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
@@ -67,6 +68,29 @@ func getProjectFromConfig() string {
 	return spec.Project
 }
 
+// getAppNameFromConfig 从配置文件中获取应用名称
+func getAppNameFromConfig() string {
+	spec, err := loadSpecFromFile("orbitdeploy.toml")
+	if err != nil {
+		return ""
+	}
+	return spec.Name
+}
+
+// parseInterspersed 解析允许与位置参数混排的 flag，返回位置参数
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 func loadSpecFromFile(filename string) (*specTOML, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/OrbitDeploy/fastcaddy"
+)
+
+const (
+	// AppsBaseDomainSettingKey 系统设置中应用基础域名的键，值如 "apps.example.com"
+	AppsBaseDomainSettingKey = "apps_base_domain"
+	// AppsTLSModeSettingKey 系统设置中应用子域名证书模式的键
+	AppsTLSModeSettingKey = "apps_tls_mode"
+
+	// AppsTLSModeOnDemand 按需签发：Caddy 在首次 TLS 握手时签发证书，并通过 ask 接口确认域名
+	AppsTLSModeOnDemand = "on_demand"
+	// AppsTLSModeWildcard 通配符证书：通过 Cloudflare DNS 挑战为每个项目签发 *.<project>.<base>
+	AppsTLSModeWildcard = "wildcard"
+
+	caddyTLSPath           = "/apps/tls"
+	caddyTLSAutomationPath = "/apps/tls/automation"
+)
+
+// AppHostnameService 负责基于系统基础域名为应用自动生成子域名及其 Caddy 配置
+type AppHostnameService struct{}
+
+// NewAppHostnameService creates a new AppHostnameService.
+func NewAppHostnameService() *AppHostnameService {
+	return &AppHostnameService{}
+}
+
+// UpdateBaseDomain 更新应用基础域名（支持 "*.apps.example.com" 写法），返回规范化后的域名。
+// 传入空字符串表示关闭自动子域名。
+func (s *AppHostnameService) UpdateBaseDomain(raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", models.SetSystemSetting(AppsBaseDomainSettingKey, "")
+	}
+
+	baseDomain, err := utils.NormalizeBaseDomain(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid base domain format: %w", err)
+	}
+
+	if s.GetTLSMode() == AppsTLSModeOnDemand {
+		if err := ensureOnDemandPermission(fastcaddy.New()); err != nil {
+			return "", fmt.Errorf("failed to configure on-demand TLS: %w", err)
+		}
+	}
+
+	if err := models.SetSystemSetting(AppsBaseDomainSettingKey, baseDomain); err != nil {
+		return "", err
+	}
+
+	log.Printf("Apps base domain updated to: %s", baseDomain)
+	return baseDomain, nil
+}
+
+// UpdateTLSMode 更新应用子域名的证书模式（on_demand 或 wildcard）
+func (s *AppHostnameService) UpdateTLSMode(mode string) error {
+	switch mode {
+	case AppsTLSModeOnDemand:
+		if err := ensureOnDemandPermission(fastcaddy.New()); err != nil {
+			return fmt.Errorf("failed to configure on-demand TLS: %w", err)
+		}
+	case AppsTLSModeWildcard:
+		if os.Getenv("CADDY_CF_TOKEN") == "" {
+			return fmt.Errorf("wildcard TLS requires CADDY_CF_TOKEN for DNS-01 challenges")
+		}
+	default:
+		return fmt.Errorf("invalid TLS mode: %s", mode)
+	}
+	return models.SetSystemSetting(AppsTLSModeSettingKey, mode)
+}
+
+// GetTLSMode 返回当前的证书模式，未设置时默认为 on_demand
+func (s *AppHostnameService) GetTLSMode() string {
+	mode, err := models.GetSystemSetting(AppsTLSModeSettingKey)
+	if err != nil || mode == "" {
+		return AppsTLSModeOnDemand
+	}
+	return mode
+}
+
+// EnsureAppHostname 在部署成功后维护应用的自动子域名。
+// 首次部署时生成 <app>.<project>.<base> 并创建路由；之后的部署只把路由指向新的系统端口。
+// 未配置基础域名时不做任何操作。
+func (s *AppHostnameService) EnsureAppHostname(application *models.Application, systemPort int) (string, error) {
+	if application.GeneratedHostname != "" {
+		return application.GeneratedHostname, s.syncGeneratedRouting(application, systemPort)
+	}
+
+	baseDomain, err := models.GetSystemSetting(AppsBaseDomainSettingKey)
+	if err != nil {
+		return "", fmt.Errorf("获取基础域名失败: %w", err)
+	}
+	if baseDomain == "" {
+		return "", nil
+	}
+	if systemPort == 0 {
+		return "", fmt.Errorf("系统端口未分配，无法生成子域名")
+	}
+
+	project, err := models.GetProjectByID(application.ProjectID)
+	if err != nil {
+		return "", fmt.Errorf("获取项目信息失败: %w", err)
+	}
+
+	hostname, err := utils.BuildAppHostname(application.Name, project.Name, baseDomain)
+	if err != nil {
+		return "", fmt.Errorf("生成子域名失败: %w", err)
+	}
+
+	fmt.Printf("🌐 [自动子域名] 为应用 %s 生成域名: %s\n", application.Name, hostname)
+
+	// 证书策略失败不阻塞路由创建，Caddy 会回退到默认的证书策略
+	projectDomain := hostname[strings.Index(hostname, ".")+1:]
+	if err := s.ensureProjectTLSPolicy(projectDomain); err != nil {
+		log.Printf("Warning: failed to configure TLS policy for %s: %v", projectDomain, err)
+	}
+
+	routing, err := AddRouting(application.ID, hostname, systemPort, true)
+	if err != nil {
+		return "", fmt.Errorf("配置子域名路由失败: %w", err)
+	}
+	cleanDomain := routing.DomainName
+
+	if err := models.UpdateApplicationGeneratedHostname(application.ID, cleanDomain); err != nil {
+		return "", fmt.Errorf("保存子域名失败: %w", err)
+	}
+	application.GeneratedHostname = cleanDomain
+
+	fmt.Printf("🎉 [自动子域名] 完成: %s -> localhost:%d\n", cleanDomain, systemPort)
+	return cleanDomain, nil
+}
+
+// syncGeneratedRouting 将已生成子域名的路由指向最新的系统端口；路由被用户删除时不再重建
+func (s *AppHostnameService) syncGeneratedRouting(application *models.Application, systemPort int) error {
+	if systemPort == 0 {
+		return nil
+	}
+
+	routings, err := models.ListRoutingsByAppID(application.ID)
+	if err != nil {
+		return fmt.Errorf("获取路由信息失败: %w", err)
+	}
+
+	for _, routing := range routings {
+		if routing.DomainName != application.GeneratedHostname {
+			continue
+		}
+		if routing.HostPort == systemPort {
+			return nil
+		}
+		fmt.Printf("🔧 [自动子域名] 更新路由端口: %s -> localhost:%d\n", routing.DomainName, systemPort)
+		_, err := UpdateRouting(routing.ID, routing.DomainName, systemPort, routing.IsActive)
+		return err
+	}
+
+	return nil
+}
+
+// ensureProjectTLSPolicy 为 *.<project>.<base> 添加 TLS 自动化策略（每个项目只添加一次）
+func (s *AppHostnameService) ensureProjectTLSPolicy(projectDomain string) error {
+	fc := fastcaddy.New()
+	subject := "*." + projectDomain
+	policyID := "orbit-tls-" + projectDomain
+
+	if fc.HasID(policyID) {
+		return nil
+	}
+
+	policy := map[string]interface{}{
+		"@id":      policyID,
+		"subjects": []string{subject},
+	}
+
+	mode := s.GetTLSMode()
+	if mode == AppsTLSModeWildcard {
+		token := os.Getenv("CADDY_CF_TOKEN")
+		if token == "" {
+			return fmt.Errorf("wildcard TLS requires CADDY_CF_TOKEN for DNS-01 challenges")
+		}
+		policy["issuers"] = []map[string]interface{}{
+			{
+				"module": "acme",
+				"challenges": map[string]interface{}{
+					"dns": map[string]interface{}{
+						"provider": map[string]interface{}{
+							"name":      "cloudflare",
+							"api_token": token,
+						},
+					},
+				},
+			},
+		}
+	} else {
+		policy["on_demand"] = true
+	}
+
+	if err := prependTLSPolicy(fc, policy); err != nil {
+		return err
+	}
+
+	if mode == AppsTLSModeWildcard {
+		// 主动管理通配符证书，使 Caddy 为该项目下的所有子域名复用同一张证书
+		if err := ensureCaddyObjectPath(fc, caddyTLSPath+"/certificates"); err != nil {
+			return err
+		}
+		return appendCaddyArrayItem(fc, caddyTLSPath+"/certificates", "automate", subject)
+	}
+
+	return nil
+}
+
+// ensureOnDemandPermission 配置 Caddy 按需签发证书前的 ask 接口，只允许已登记的路由域名
+func ensureOnDemandPermission(fc *fastcaddy.FastCaddy) error {
+	if err := ensureCaddyObjectPath(fc, caddyTLSAutomationPath); err != nil {
+		return err
+	}
+
+	onDemand := map[string]interface{}{
+		"permission": map[string]interface{}{
+			"module":   "http",
+			"endpoint": fmt.Sprintf("http://localhost:%d/api/caddy/tls-ask", SystemPort),
+		},
+	}
+	return fc.PutConfig(onDemand, caddyTLSAutomationPath+"/on_demand", "POST")
+}
+
+// ensureCaddyObjectPath 逐级创建不存在的配置对象，已存在的层级保持不变
+func ensureCaddyObjectPath(fc caddyClient, path string) error {
+	current := ""
+	for _, key := range strings.Split(strings.Trim(path, "/"), "/") {
+		current += "/" + key
+		if fc.HasPath(current) {
+			continue
+		}
+		if err := fc.PutConfig(map[string]interface{}{}, current, "POST"); err != nil {
+			return fmt.Errorf("初始化 Caddy 配置路径 %s 失败: %w", current, err)
+		}
+	}
+	return nil
+}
+
+// IsKnownRoutingDomain 判断域名是否为已登记且启用的路由，供 Caddy 按需签发证书时确认
+func IsKnownRoutingDomain(domain string) (bool, error) {
+	cleanDomain, err := utils.NormalizeDomain(domain)
+	if err != nil {
+		return false, nil
+	}
+
+	routings, err := models.ListRoutings()
+	if err != nil {
+		return false, err
+	}
+	for _, routing := range routings {
+		if routing.DomainName == cleanDomain && routing.IsActive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/opentdp/go-helper/command"
+	"gorm.io/gorm"
+)
+
+// 日志行数上限，避免一次返回过多内容
+const maxLogLines = 5000
+
+// UnitState systemd unit 的运行状态
+type UnitState struct {
+	ActiveState string // active, inactive, failed, activating ...
+	SubState    string // running, dead, exited ...
+}
+
+// GetActiveDeployment 返回正在运行当前活跃版本的部署，应用尚未部署时返回 nil
+func GetActiveDeployment(application *models.Application) (*models.Deployment, error) {
+	if application.ActiveReleaseID == nil {
+		return nil, nil
+	}
+	deployment, err := models.GetLatestDeploymentByRelease(application.ID, *application.ActiveReleaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return deployment, err
+}
+
+// GetUnitState 查询服务的 systemd 状态，项目有专属用户时查询用户模式服务
+func GetUnitState(serviceName string, project *models.Project) (*UnitState, error) {
+	cmd := userModeCommand(project, fmt.Sprintf("systemctl %s show -p ActiveState -p SubState %s", systemctlScope(project), serviceName))
+	output, err := command.Exec(&command.ExecPayload{
+		Content:     cmd,
+		CommandType: "SHELL",
+		Timeout:     30,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询服务状态失败: %w, output: %s", err, output)
+	}
+
+	state := &UnitState{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ActiveState":
+			state.ActiveState = value
+		case "SubState":
+			state.SubState = value
+		}
+	}
+	return state, nil
+}
+
+// StreamUnitLogs 通过 journalctl 读取服务（即容器）日志，follow 为 true 时持续输出直到 ctx 取消
+func StreamUnitLogs(ctx context.Context, serviceName string, project *models.Project, lines int, follow bool, onLine func(string)) error {
+	if lines <= 0 || lines > maxLogLines {
+		lines = maxLogLines
+	}
+
+	args := []string{"-u", serviceName, "-n", strconv.Itoa(lines), "-o", "short-iso", "--no-pager"}
+	if project.Username != "" {
+		args = append([]string{"--user"}, args...)
+	}
+	if follow {
+		args = append(args, "-f")
+	}
+
+	// 不经过 sh 直接执行 journalctl；用户模式需要 su，取消时连同子进程一起结束
+	var cmd *exec.Cmd
+	if project.Username == "" {
+		cmd = exec.CommandContext(ctx, "journalctl", args...)
+	} else {
+		cmd = exec.CommandContext(ctx, "su", "-", project.Username, "-c", "journalctl "+strings.Join(args, " "))
+	}
+	killProcessGroupOnCancel(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动 journalctl 失败: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		onLine(scanner.Text())
+	}
+
+	// 客户端断开导致的退出不是错误
+	if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("journalctl 执行失败: %w", err)
+	}
+	return scanner.Err()
+}
+
+// systemctlScope 项目有专属用户时使用 --user
+func systemctlScope(project *models.Project) string {
+	if project.Username != "" {
+		return "--user"
+	}
+	return ""
+}
+
+// userModeCommand 项目有专属用户时通过 su 切换到该用户执行
+func userModeCommand(project *models.Project, cmd string) string {
+	if project.Username == "" {
+		return cmd
+	}
+	return fmt.Sprintf("su - %s -c '%s'", project.Username, cmd)
+}
@@ -99,6 +99,20 @@ func (as *ApplicationService) DeleteApplicationWithCleanup(appID uuid.UUID, appN
 	}
 	logman.Info("已删除 EnvironmentVariable 记录", "app_id", appID)
 
+	// 删除 Build Secrets
+	if err := tx.Where("application_id = ?", appID).Delete(&models.BuildSecret{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("删除 BuildSecret 记录失败: %w", err)
+	}
+	logman.Info("已删除 BuildSecret 记录", "app_id", appID)
+
+	// 删除 Deploy Key
+	if err := tx.Where("application_id = ?", appID).Delete(&models.DeployKey{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("删除 DeployKey 记录失败: %w", err)
+	}
+	logman.Info("已删除 DeployKey 记录", "app_id", appID)
+
 	// 删除 Releases
 	if err := tx.Where("application_id = ?", appID).Delete(&models.Release{}).Error; err != nil {
 		tx.Rollback()
@@ -135,6 +149,11 @@ func (as *ApplicationService) DeleteApplicationWithCleanup(appID uuid.UUID, appN
 		// 不中断流程
 	}
 
+	// 6. 清理构建缓存
+	if _, err := PurgeBuildCache(app, false); err != nil {
+		logman.Warn("清理构建缓存失败", "app_name", app.Name, "error", err)
+	}
+
 	logman.Info("应用删除完成", "app_id", appID, "app_name", appName)
 	return nil
 }
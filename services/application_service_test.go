@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/opentdp/go-helper/dborm"
 	"github.com/stretchr/testify/assert"
 )
@@ -34,7 +35,7 @@ func TestApplicationServiceDependencyInjection(t *testing.T) {
 	
 	// Verify that the service can be used (basic smoke test)
 	// Test with an invalid ID - since table doesn't exist, we expect a database error, not "app not found"
-	err := appService.ValidateApplicationDeletion(999, "non-existent-app")
+	err := appService.ValidateApplicationDeletion(uuid.New(), "non-existent-app")
 	assert.Error(t, err, "Should return error for database operation")
 	// The error could be about missing table or record not found, both are acceptable for this test
 	assert.True(t, 
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// buildCacheLocks 同一应用的构建共用持久化克隆目录，需要串行执行
+var buildCacheLocks sync.Map // map[uuid.UUID]*sync.Mutex
+
+// BuildCacheUsage 应用构建缓存的占用情况
+type BuildCacheUsage struct {
+	Path           string // 持久化克隆目录
+	RepoCacheBytes int64  // 克隆目录占用的磁盘空间
+	ImageCount     int    // 本地该应用的镜像数量
+	ImageBytes     int64  // 镜像大小之和（镜像之间共享的层会被重复计算）
+}
+
+// BuildCacheDir 返回应用的构建缓存目录，位于用户缓存目录下
+func BuildCacheDir(appID uuid.UUID) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("获取缓存目录失败: %w", err)
+	}
+	return filepath.Join(cacheDir, "orbitdeploy", "build-cache", appID.String()), nil
+}
+
+// lockBuildCache 获取应用构建缓存的锁，返回解锁函数
+func lockBuildCache(appID uuid.UUID) func() {
+	value, _ := buildCacheLocks.LoadOrStore(appID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// ApplicationImageRepository 返回应用构建镜像的仓库名（不含标签）
+func ApplicationImageRepository(application *models.Application) string {
+	return utils.SanitizeDNSLabel(application.Name)
+}
+
+// GetBuildCacheUsage 统计应用的克隆目录和本地镜像占用的空间
+func GetBuildCacheUsage(application *models.Application) (*BuildCacheUsage, error) {
+	dir, err := BuildCacheDir(application.ID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &BuildCacheUsage{Path: dir}
+
+	err = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				usage.RepoCacheBytes += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("统计克隆目录大小失败: %w", err)
+	}
+
+	images, err := listApplicationImages(application)
+	if err != nil {
+		// podman 不可用时仍返回克隆目录的大小
+		logman.Warn("获取应用镜像失败", "app_name", application.Name, "error", err)
+		return usage, nil
+	}
+	usage.ImageCount = len(images)
+	for _, image := range images {
+		usage.ImageBytes += image.Size
+	}
+	return usage, nil
+}
+
+// PurgeBuildCache 删除应用的持久化克隆目录；pruneLayers 为 true 时同时清理悬空的中间层镜像，
+// 悬空层不属于某个应用，清理会影响所有应用的下一次构建速度
+func PurgeBuildCache(application *models.Application, pruneLayers bool) (*BuildCacheUsage, error) {
+	before, err := GetBuildCacheUsage(application)
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := lockBuildCache(application.ID)
+	defer unlock()
+
+	if err := os.RemoveAll(before.Path); err != nil {
+		return nil, fmt.Errorf("删除克隆目录失败: %w", err)
+	}
+	logman.Info("已清理构建缓存", "app_name", application.Name, "path", before.Path, "bytes", before.RepoCacheBytes)
+
+	if pruneLayers {
+		if output, err := exec.Command("podman", "image", "prune", "-f").CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("清理悬空镜像失败: %s", string(output))
+		}
+	}
+	return before, nil
+}
+
+type podmanImage struct {
+	ID   string `json:"Id"`
+	Size int64  `json:"Size"`
+}
+
+// listApplicationImages 列出本地属于该应用镜像仓库的镜像
+func listApplicationImages(application *models.Application) ([]podmanImage, error) {
+	output, err := exec.Command("podman", "image", "ls", "--format", "json",
+		"--filter", "reference="+ApplicationImageRepository(application)).Output()
+	if err != nil {
+		return nil, err
+	}
+	var images []podmanImage
+	if err := json.Unmarshal(output, &images); err != nil {
+		return nil, fmt.Errorf("解析 podman 输出失败: %w", err)
+	}
+	return images, nil
+}
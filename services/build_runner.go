@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+)
+
+const (
+	// BuildTimeoutSettingKey 单次构建（拉取代码 + podman build）的超时时间，单位为分钟
+	BuildTimeoutSettingKey = "build_timeout_minutes"
+
+	// DefaultBuildTimeout 未配置时的构建超时时间
+	DefaultBuildTimeout = 30 * time.Minute
+
+	// buildLogTailLines 命令失败时错误信息中附带的输出行数
+	buildLogTailLines = 20
+
+	// minRedactLength 短于该长度的敏感内容不做替换，避免把日志中的普通字符全部替换掉
+	minRedactLength = 4
+)
+
+// BuildLogger 接收构建过程中的输出，每次调用对应一行
+type BuildLogger func(line string)
+
+// GetBuildTimeout 返回配置的构建超时时间
+func GetBuildTimeout() time.Duration {
+	value, err := models.GetSystemSetting(BuildTimeoutSettingKey)
+	if err != nil || value == "" {
+		return DefaultBuildTimeout
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		return DefaultBuildTimeout
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// UpdateBuildTimeout 校验并保存构建超时时间（分钟，1 到 1440）
+func UpdateBuildTimeout(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 1 || minutes > 24*60 {
+		return "", fmt.Errorf("构建超时时间必须是 1 到 1440 之间的分钟数: %s", value)
+	}
+	value = strconv.Itoa(minutes)
+	return value, models.SetSystemSetting(BuildTimeoutSettingKey, value)
+}
+
+// buildRunner 执行构建中的外部命令，将输出逐行转发给 BuildLogger，并记录每个步骤的耗时
+type buildRunner struct {
+	ctx     context.Context
+	timeout time.Duration
+	logger  BuildLogger
+	redact  []string // 需要从输出中隐藏的内容，如带凭据的仓库地址
+	env     []string // 追加到命令环境变量中，如 GIT_SSH_COMMAND
+}
+
+func newBuildRunner(ctx context.Context, timeout time.Duration, logger BuildLogger, redact ...string) *buildRunner {
+	if logger == nil {
+		logger = func(string) {}
+	}
+	return &buildRunner{ctx: ctx, timeout: timeout, logger: logger, redact: redactPatterns(redact)}
+}
+
+// redactPatterns 将敏感内容拆成逐行匹配的片段，多行密钥（如私钥）在日志中逐行输出，需要分别隐藏；
+// 片段按长度从长到短排列，避免较短的片段先替换破坏较长片段的匹配
+func redactPatterns(secrets []string) []string {
+	patterns := make([]string, 0, len(secrets))
+	seen := make(map[string]bool)
+	for _, secret := range secrets {
+		for _, line := range strings.Split(secret, "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) < minRedactLength || seen[line] {
+				continue
+			}
+			seen[line] = true
+			patterns = append(patterns, line)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool { return len(patterns[i]) > len(patterns[j]) })
+	return patterns
+}
+
+// log 输出一行构建日志
+func (r *buildRunner) log(format string, args ...interface{}) {
+	r.logger(r.sanitize(fmt.Sprintf(format, args...)))
+}
+
+// step 执行一个构建步骤并记录耗时
+func (r *buildRunner) step(name string, fn func() error) error {
+	r.log("==> %s", name)
+	start := time.Now()
+	err := fn()
+	elapsed := time.Since(start).Round(100 * time.Millisecond)
+	if err != nil {
+		r.log("<== %s 失败，耗时 %s", name, elapsed)
+		return err
+	}
+	r.log("<== %s 完成，耗时 %s", name, elapsed)
+	return nil
+}
+
+// command 创建在构建上下文中执行的命令，超时或取消时结束整个进程组
+func (r *buildRunner) command(dir, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(r.ctx, name, args...)
+	killProcessGroupOnCancel(cmd)
+	cmd.Dir = dir
+	if len(r.env) > 0 {
+		cmd.Env = append(os.Environ(), r.env...)
+	}
+	return cmd
+}
+
+// output 执行命令并返回 stdout，不转发到构建日志
+func (r *buildRunner) output(dir, name string, args ...string) (string, error) {
+	cmd := r.command(dir, name, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s 失败: %v\n%s", name, r.sanitize(args[0]), err, r.sanitize(strings.TrimSpace(stderr.String())))
+	}
+	return string(out), nil
+}
+
+// run 执行命令并实时转发 stdout/stderr，失败时错误信息附带最后几行输出
+func (r *buildRunner) run(dir, name string, args ...string) error {
+	cmd := r.command(dir, name, args...)
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	tail := make([]string, 0, buildLogTailLines)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(pr)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := r.sanitize(scanner.Text())
+			r.logger(line)
+			if len(tail) == buildLogTailLines {
+				tail = tail[1:]
+			}
+			tail = append(tail, line)
+		}
+		// 读取出错时继续消费输出，避免命令因管道写满而阻塞
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+
+	err := cmd.Start()
+	if err == nil {
+		err = cmd.Wait()
+	}
+	pw.Close()
+	<-done
+
+	if err == nil {
+		return nil
+	}
+	if errors.Is(r.ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("构建超时（超过 %s）", r.timeout)
+	}
+	return fmt.Errorf("%s %s 失败: %v\n%s", name, r.sanitize(args[0]), err, strings.Join(tail, "\n"))
+}
+
+// sanitize 隐藏输出中的敏感内容
+func (r *buildRunner) sanitize(s string) string {
+	for _, secret := range r.redact {
+		s = strings.ReplaceAll(s, secret, "******")
+	}
+	return s
+}
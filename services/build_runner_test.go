@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildRunnerSanitizeRedactsMultiLineSecrets(t *testing.T) {
+	key := "-----BEGIN KEY-----\nAAAAB3NzaC1yc2E\r\nQ0FGRUJBQkU=\n-----END KEY-----\n"
+	runner := newBuildRunner(context.Background(), 0, nil, key, "abc", "")
+
+	assert.Equal(t, "******", runner.sanitize("AAAAB3NzaC1yc2E"))
+	assert.Equal(t, "secret=******", runner.sanitize("secret=Q0FGRUJBQkU="))
+	assert.Equal(t, "abc build", runner.sanitize("abc build"), "过短的内容不应被替换")
+}
+
+func TestBuildRunnerTimeoutKillsChildProcesses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	runner := newBuildRunner(ctx, 200*time.Millisecond, nil)
+
+	// sh 派生的 sleep 持有输出管道，只结束 sh 时 run 会一直等待
+	start := time.Now()
+	err := runner.run("", "sh", "-c", "sleep 30; echo done")
+	assert.ErrorContains(t, err, "构建超时")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
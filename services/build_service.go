@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -31,7 +33,14 @@ type BuildFromGitHubRequest struct {
 	RepoURL     string            `json:"repo_url"`
 	Dockerfile  string            `json:"dockerfile"`
 	ContextPath string            `json:"context_path"`
+	Target      string            `json:"target"`
+	Ref         string            `json:"ref"` // 分支、标签或提交 SHA，为空时使用应用配置的分支
 	BuildArgs   map[string]string `json:"build_args"`
+
+	Secrets  map[string]string          `json:"-"` // 构建密钥，通过 --secret 挂载，值不会出现在日志和镜像历史中
+	Logger   BuildLogger                `json:"-"` // 接收 git/podman 的实时输出
+	Timeout  time.Duration              `json:"-"` // 为空时使用系统设置 build_timeout_minutes
+	OnCommit func(*utils.GitCommitInfo) `json:"-"` // 检出代码后、构建镜像前调用
 }
 
 // BuildFromApplicationRequest 应用构建请求结构
@@ -39,7 +48,22 @@ type BuildFromApplicationRequest struct {
 	ApplicationID uuid.UUID         `json:"application_id"`
 	Dockerfile    string            `json:"dockerfile"`
 	ContextPath   string            `json:"context_path"`
+	Target        string            `json:"target"`
+	Ref           string            `json:"ref"`
 	BuildArgs     map[string]string `json:"build_args"`
+
+	Logger   BuildLogger                `json:"-"`
+	Timeout  time.Duration              `json:"-"`
+	OnCommit func(*utils.GitCommitInfo) `json:"-"`
+}
+
+// BuildResult 构建结果，包含实际构建的提交信息
+type BuildResult struct {
+	ImageName string
+	RepoURL   string
+	Branch    string // 克隆时使用的分支（按标签或 SHA 构建时为空）
+	Ref       string // 实际检出的 ref
+	Commit    *utils.GitCommitInfo
 }
 
 // RepositoryInfo 仓库信息结构，用于从ProviderAuth提取仓库信息
@@ -49,39 +73,63 @@ type RepositoryInfo struct {
 	Platform  string // 平台类型（github, gitlab等）
 	AuthToken string // 认证令牌
 	Username  string // 用户名（某些平台需要）
+
+	SSHPrivateKey string // SSH 仓库使用的部署密钥（Platform 为 ssh 时）
+	KnownHosts    string // 固定的仓库主机密钥
 }
 
 // BuildImageFromApplication 从应用构建镜像的核心逻辑
 // 这个函数与HTTP层完全解耦，可以被其他任何代码复用
-func (bs *BuildService) BuildImageFromApplication(req BuildFromApplicationRequest) (string, error) {
+func (bs *BuildService) BuildImageFromApplication(req BuildFromApplicationRequest) (*BuildResult, error) {
 	logman.Info("开始从应用构建镜像", "application_id", req.ApplicationID)
 
 	// 1. 参数校验和设置默认值
 	if req.ApplicationID == uuid.Nil {
 		logman.Error("应用ID不能为空")
-		return "", fmt.Errorf("应用ID不能为空")
-	}
-	if req.Dockerfile == "" {
-		req.Dockerfile = "Dockerfile"
-	}
-	if req.ContextPath == "" {
-		req.ContextPath = "."
+		return nil, fmt.Errorf("应用ID不能为空")
 	}
-	logman.Info("参数校验完成", "dockerfile", req.Dockerfile, "context_path", req.ContextPath)
 
 	// 2. 获取应用信息
 	application, err := models.GetApplicationByID(req.ApplicationID)
 	if err != nil {
 		logman.Error("获取应用信息失败", "error", err)
-		return "", fmt.Errorf("获取应用信息失败: %w", err)
+		return nil, fmt.Errorf("获取应用信息失败: %w", err)
 	}
 	logman.Info("获取应用信息成功", "application_name", application.Name)
 
+	// 未显式指定时使用应用配置的构建目录、Dockerfile 路径和目标阶段
+	if req.ContextPath == "" && application.BuildDir != nil {
+		req.ContextPath = *application.BuildDir
+	}
+	if req.Dockerfile == "" && application.DockerfilePath != nil {
+		req.Dockerfile = *application.DockerfilePath
+	}
+	if req.Target == "" && application.BuildTarget != nil {
+		req.Target = *application.BuildTarget
+	}
+
+	// 构建参数：标记为构建时的环境变量，请求中的同名参数优先
+	buildArgs, err := models.GetBuildArgs(application.ID)
+	if err != nil {
+		logman.Error("获取构建参数失败", "error", err)
+		return nil, fmt.Errorf("获取构建参数失败: %w", err)
+	}
+	for k, v := range req.BuildArgs {
+		buildArgs[k] = v
+	}
+
+	secrets, err := models.GetBuildSecrets(application.ID)
+	if err != nil {
+		logman.Error("获取构建密钥失败", "error", err)
+		return nil, fmt.Errorf("获取构建密钥失败: %w", err)
+	}
+	logman.Info("参数校验完成", "dockerfile", req.Dockerfile, "context_path", req.ContextPath, "target", req.Target)
+
 	// 3. 获取项目信息
 	project, err := models.GetProjectByID(application.ProjectID)
 	if err != nil {
 		logman.Error("获取项目信息失败", "error", err)
-		return "", fmt.Errorf("获取项目信息失败: %w", err)
+		return nil, fmt.Errorf("获取项目信息失败: %w", err)
 	}
 	logman.Info("获取项目信息成功", "project_name", project.Name)
 
@@ -89,13 +137,13 @@ func (bs *BuildService) BuildImageFromApplication(req BuildFromApplicationReques
 	repoInfo, err := bs.getRepositoryInfo(application)
 	if err != nil {
 		logman.Error("获取仓库信息失败", "error", err)
-		return "", fmt.Errorf("获取仓库信息失败: %w", err)
+		return nil, fmt.Errorf("获取仓库信息失败: %w", err)
 	}
 
-	// 5. 如果没有关联的ProviderAuth，说明是本地CLI推送，不支持构建
+	// 5. 如果没有关联的ProviderAuth或部署密钥，说明是本地CLI推送，不支持构建
 	if repoInfo == nil {
 		logman.Error("应用未关联第三方仓库授权，无法从代码仓库构建")
-		return "", fmt.Errorf("应用未关联第三方仓库授权，无法从代码仓库构建。请先关联ProviderAuth或使用本地CLI推送镜像")
+		return nil, fmt.Errorf("应用未关联第三方仓库授权，无法从代码仓库构建。请先关联ProviderAuth、为 SSH 仓库生成部署密钥或使用本地CLI推送镜像")
 	}
 
 	logman.Info("获取仓库信息成功", "repo_url", repoInfo.URL, "platform", repoInfo.Platform)
@@ -105,21 +153,31 @@ func (bs *BuildService) BuildImageFromApplication(req BuildFromApplicationReques
 		RepoURL:     repoInfo.URL,
 		Dockerfile:  req.Dockerfile,
 		ContextPath: req.ContextPath,
-		BuildArgs:   req.BuildArgs,
+		Target:      req.Target,
+		Ref:         req.Ref,
+		BuildArgs:   buildArgs,
+		Secrets:     secrets,
+		Logger:      req.Logger,
+		Timeout:     req.Timeout,
+		OnCommit:    req.OnCommit,
 	}
 	logman.Info("构建请求结构体完成", "repo_url", repoInfo.URL)
 
 	// 7. 使用统一的认证构建函数
-	imageName, err := bs.buildImageFromRepoWithAuth(buildReq, application, project, repoInfo)
+	result, err := bs.buildImageFromRepoWithAuth(buildReq, application, project, repoInfo)
 	if err != nil {
-		return "", fmt.Errorf("从仓库构建镜像失败: %w", err)
+		return nil, fmt.Errorf("从仓库构建镜像失败: %w", err)
 	}
 
-	return imageName, nil
+	return result, nil
 }
 
-// getRepositoryInfo 从应用的ProviderAuth获取仓库信息
+// getRepositoryInfo 从应用的ProviderAuth获取仓库信息，SSH 地址使用应用的部署密钥
 func (bs *BuildService) getRepositoryInfo(application *models.Application) (*RepositoryInfo, error) {
+	if application.RepoURL != nil && utils.IsSSHRepoURL(*application.RepoURL) {
+		return bs.getSSHRepositoryInfo(application)
+	}
+
 	// 如果应用没有关联ProviderAuth，返回nil（支持本地CLI推送）
 	if application.ProviderAuthID == nil {
 		return nil, nil
@@ -142,7 +200,7 @@ func (bs *BuildService) getRepositoryInfo(application *models.Application) (*Rep
 
 	repoURL := *application.RepoURL
 	if !strings.HasPrefix(repoURL, "http://") && !strings.HasPrefix(repoURL, "https://") {
-		return nil, fmt.Errorf("仓库URL必须是完整的可访问URL，例如 https://github.com/owner/repo 或 https://your-gitea.com/owner/repo")
+		return nil, fmt.Errorf("仓库URL必须是完整的可访问URL，例如 https://github.com/owner/repo 或 git@github.com:owner/repo.git")
 	}
 
 	// 根据平台类型构造仓库信息并生成认证令牌
@@ -152,34 +210,37 @@ func (bs *BuildService) getRepositoryInfo(application *models.Application) (*Rep
 		IsPrivate: true, // 现在所有仓库都通过授权处理
 	}
 
-	switch providerAuth.Platform {
-	case "github":
-		// GitHub Apps方式，生成安装令牌
-		if providerAuth.AppID == "" || providerAuth.PrivateKey == "" || providerAuth.InstallationID == 0 {
-			return nil, fmt.Errorf("GitHub平台需要完整的AppID、PrivateKey和InstallationID")
-		}
-		token, err := utils.GenerateGitHubAppInstallationToken(providerAuth.AppID, providerAuth.PrivateKey, providerAuth.InstallationID)
-		if err != nil {
-			return nil, fmt.Errorf("生成GitHub安装令牌失败: %w", err)
-		}
-		repoInfo.AuthToken = token
-		repoInfo.Username = "x-access-token" // GitHub推荐的用户名
-	case "gitlab":
-		repoInfo.AuthToken = providerAuth.ClientSecret
-		repoInfo.Username = providerAuth.Username
-	case "bitbucket":
-		repoInfo.Username = providerAuth.Username
-		repoInfo.AuthToken = providerAuth.AppPassword
-	case "gitea":
-		repoInfo.AuthToken = providerAuth.ClientSecret
-		repoInfo.Username = providerAuth.Username
-	default:
-		return nil, fmt.Errorf("不支持的平台类型: %s", providerAuth.Platform)
+	repoInfo.AuthToken, repoInfo.Username, err = providerAuthCredentials(providerAuth)
+	if err != nil {
+		return nil, err
 	}
 
 	return repoInfo, nil
 }
 
+// getSSHRepositoryInfo 使用应用的部署密钥访问 SSH 仓库，适用于任何 Git 服务
+func (bs *BuildService) getSSHRepositoryInfo(application *models.Application) (*RepositoryInfo, error) {
+	key, err := models.GetDeployKeyByApplicationID(application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("SSH 仓库地址需要先为应用生成部署密钥")
+	}
+	if strings.TrimSpace(key.KnownHosts) == "" {
+		return nil, fmt.Errorf("尚未固定仓库的 SSH 主机密钥，请在应用设置中获取并确认主机密钥")
+	}
+	privateKey, err := key.GetDecryptedPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("解密部署密钥失败")
+	}
+
+	return &RepositoryInfo{
+		URL:           *application.RepoURL,
+		Platform:      "ssh",
+		IsPrivate:     true,
+		SSHPrivateKey: privateKey,
+		KnownHosts:    key.KnownHosts,
+	}, nil
+}
+
 // RewriteDockerfileShortNames rewrites short image names in the Dockerfile to include docker.io/ prefix
 func (bs *BuildService) RewriteDockerfileShortNames(dockerfilePath string) (string, error) {
 	content, err := os.ReadFile(dockerfilePath)
@@ -335,85 +396,250 @@ func (bs *BuildService) getDefaultBranch(repoURL string, repoInfo *RepositoryInf
 	return defaultBranch
 }
 
+// getDefaultBranchViaGit 通过 git ls-remote 获取远程仓库的默认分支，适用于无法调用平台 API 的 SSH 仓库
+func (bs *BuildService) getDefaultBranchViaGit(runner *buildRunner, repoURL string) string {
+	output, err := runner.output("", "git", "ls-remote", "--symref", repoURL, "HEAD")
+	if err != nil {
+		logman.Warn("获取默认分支失败，使用 'main'", "error", err)
+		return "main"
+	}
+	if branch := utils.ParseLsRemoteSymref(output); branch != "" {
+		return branch
+	}
+	return "main"
+}
+
 // buildImageFromRepoWithAuth 统一的仓库构建函数，使用认证
-func (bs *BuildService) buildImageFromRepoWithAuth(req BuildFromGitHubRequest, application *models.Application, project *models.Project, repoInfo *RepositoryInfo) (string, error) {
+func (bs *BuildService) buildImageFromRepoWithAuth(req BuildFromGitHubRequest, application *models.Application, project *models.Project, repoInfo *RepositoryInfo) (*BuildResult, error) {
 	// 1. 参数校验和设置默认值
 	if strings.TrimSpace(req.RepoURL) == "" {
-		return "", fmt.Errorf("仓库URL不能为空")
+		return nil, fmt.Errorf("仓库URL不能为空")
 	}
-	if req.Dockerfile == "" {
-		req.Dockerfile = "Dockerfile"
-	}
-	if req.ContextPath == "" {
-		req.ContextPath = "."
+	if application.BuildType != nil && *application.BuildType != "dockerfile" {
+		return nil, fmt.Errorf("应用的构建类型不是Dockerfile，当前仅支持Dockerfile构建")
 	}
 
-	// 2. 生成临时目录
-	tempDir, err := os.MkdirTemp("", "github-clone-auth-*")
+	// 2. 准备工作目录：启用缓存时复用应用的持久化克隆目录，否则使用临时目录
+	tempDir, cleanup, err := bs.prepareWorkDir(application)
 	if err != nil {
-		return "", fmt.Errorf("创建临时目录失败: %w", err)
+		return nil, err
 	}
-	defer os.RemoveAll(tempDir) // 确保清理
+	defer cleanup()
 
-	// 3. 使用认证克隆仓库，使用指定的分支
-	branch := "main" // Temporary default, will be overridden
-	if application.Branch != nil && *application.Branch != "" {
-		branch = *application.Branch
-	} else {
-		// Fetch default branch using repoInfo
-		branch = bs.getDefaultBranch(req.RepoURL, repoInfo)
+	// 整个构建共用一个超时，超时后正在执行的 git/podman 进程会被终止
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = GetBuildTimeout()
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	// authenticatedURL construction (req.RepoURL is now full)
-	authenticatedURL := strings.Replace(req.RepoURL, "https://", fmt.Sprintf("https://%s:%s@", repoInfo.Username, repoInfo.AuthToken), 1)
-	cloneCmd := exec.Command("git", "clone", "--depth=1", "--branch", branch, authenticatedURL, tempDir)
-	if output, err := cloneCmd.CombinedOutput(); err != nil {
-		fmt.Println(req.RepoURL, branch, authenticatedURL) // Debug output
-		return "", fmt.Errorf("认证克隆仓库失败 (分支: %s): %s", branch, string(output))
+	// 3. 准备仓库凭据：SSH 仓库通过 GIT_SSH_COMMAND 使用部署密钥，HTTPS 仓库将令牌放入拉取地址
+	cloneURL := req.RepoURL
+	var redact, gitEnv []string
+	if repoInfo.Platform == "ssh" {
+		env, cleanupSSH, err := prepareGitSSHEnv(repoInfo)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanupSSH()
+		gitEnv = env
+	} else {
+		cloneURL = strings.Replace(req.RepoURL, "https://", fmt.Sprintf("https://%s:%s@", repoInfo.Username, repoInfo.AuthToken), 1)
+		redact = append(redact, cloneURL, repoInfo.AuthToken)
+	}
+	for _, value := range req.Secrets {
+		redact = append(redact, value)
+	}
+	runner := newBuildRunner(ctx, timeout, req.Logger, redact...)
+	runner.env = gitEnv
+
+	// 4. 确定要检出的 ref：显式指定的分支/标签/SHA 优先，否则使用应用分支或仓库默认分支
+	ref := strings.TrimSpace(req.Ref)
+	branch := ""
+	if ref == "" {
+		if application.Branch != nil && *application.Branch != "" {
+			branch = *application.Branch
+		} else if repoInfo.Platform == "ssh" {
+			branch = bs.getDefaultBranchViaGit(runner, cloneURL)
+		} else {
+			branch = bs.getDefaultBranch(req.RepoURL, repoInfo)
+		}
+		ref = branch
 	}
 
-	if *application.BuildType != "dockerfile" {
-		return "", fmt.Errorf("应用的构建类型不是Dockerfile，当前仅支持Dockerfile构建")
+	var commit *utils.GitCommitInfo
+	if err := runner.step("拉取代码 ("+ref+")", func() error {
+		if err := bs.checkoutRef(runner, tempDir, cloneURL, ref); err != nil {
+			return err
+		}
+		commit, err = bs.readHeadCommit(tempDir)
+		return err
+	}); err != nil {
+		return nil, err
 	}
-
-	// 4. 验证Dockerfile存在
-	contextDir := filepath.Join(tempDir, req.ContextPath)
-	resolvedDockerfilePath := filepath.Join(contextDir, req.Dockerfile)
-	if _, err := os.Stat(resolvedDockerfilePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("dockerfile不存在: %s", resolvedDockerfilePath)
+	runner.log("提交 %s %s (%s)", commit.ShortSHA(), commit.Message, commit.AuthorName)
+	logman.Info("已检出提交", "ref", ref, "commit", commit.SHA, "author", commit.AuthorName)
+	if req.OnCommit != nil {
+		req.OnCommit(commit)
 	}
 
-	// 4.5. 重写Dockerfile中的镜像短名称
-	resolvedDockerfilePath, err = bs.RewriteDockerfileShortNames(resolvedDockerfilePath)
-	if err != nil {
-		return "", fmt.Errorf("重写Dockerfile失败: %w", err)
+	// 5. 解析构建目录并验证Dockerfile存在（Dockerfile 路径相对于构建目录）
+	var contextDir, resolvedDockerfilePath string
+	if err := runner.step("准备构建上下文", func() error {
+		contextDir, resolvedDockerfilePath, err = utils.ResolveBuildContext(tempDir, req.ContextPath, req.Dockerfile)
+		if err != nil {
+			return err
+		}
+		if req.Target != "" {
+			if err := utils.ValidateBuildTarget(resolvedDockerfilePath, req.Target); err != nil {
+				return err
+			}
+		}
+
+		// 重写Dockerfile中的镜像短名称
+		resolvedDockerfilePath, err = bs.RewriteDockerfileShortNames(resolvedDockerfilePath)
+		if err != nil {
+			return fmt.Errorf("重写Dockerfile失败: %w", err)
+		}
+		return nil
+	}); err != nil {
+		runner.log("%v", err)
+		return nil, err
 	}
 
-	// 5. 生成镜像名称
-	timestamp := time.Now().Format("20060102-150405")
-	repoName := filepath.Base(strings.TrimSuffix(req.RepoURL, ".git"))
-	imageName := fmt.Sprintf("%s:%s-%s", strings.ToLower(repoName), branch, timestamp)
+	// 6. 生成镜像名称，标签为提交短 SHA 加构建时间，同一提交重复构建不会覆盖已有 Release 引用的镜像
+	imageName := fmt.Sprintf("%s:%s-%s", ApplicationImageRepository(application), commit.ShortSHA(), time.Now().Format("20060102150405"))
 
-	// 6. 构造并执行podman build命令
-	args := []string{"build", "--pull-always", "-t", imageName, "-f", resolvedDockerfilePath}
+	// 7. 构造并执行podman build命令
+	args := []string{"build", "-t", imageName, "-f", resolvedDockerfilePath}
+	cache := application.BuildCache
+	if !cache.DisablePullAlways {
+		args = append(args, "--pull-always")
+	}
+	// 不使用 --cache-from：podman 只接受不带标签和摘要的远程仓库，本地镜像名或镜像 ID 都无法作为缓存来源；
+	// 本机构建直接复用 podman 的层缓存，上一个版本的镜像层只要未被清理就会命中
+	if cache.DisableBuildCache {
+		args = append(args, "--no-cache")
+	}
+	if req.Target != "" {
+		args = append(args, "--target", req.Target)
+	}
+	buildArgKeys := make([]string, 0, len(req.BuildArgs))
 	for k, v := range req.BuildArgs {
 		if strings.TrimSpace(k) == "" {
 			continue
 		}
 		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", k, v))
+		buildArgKeys = append(buildArgKeys, k)
+	}
+	// 构建密钥写入构建上下文之外的临时目录，构建结束后删除
+	secretNames := make([]string, 0, len(req.Secrets))
+	if len(req.Secrets) > 0 {
+		secretDir, err := os.MkdirTemp("", "orbit-build-secrets-*")
+		if err != nil {
+			return nil, fmt.Errorf("创建构建密钥目录失败: %w", err)
+		}
+		defer os.RemoveAll(secretDir)
+
+		secretArgs, err := utils.WriteBuildSecretFiles(secretDir, req.Secrets)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, secretArgs...)
+		for name := range req.Secrets {
+			secretNames = append(secretNames, name)
+		}
+		sort.Strings(secretNames)
+		runner.log("挂载构建密钥: %s", strings.Join(secretNames, ", "))
 	}
 	args = append(args, contextDir)
 
-	logman.Info("使用podman构建认证应用镜像", "image", imageName, "dockerfile", resolvedDockerfilePath, "context", contextDir, "branch", branch)
-	buildCmd := exec.Command("podman", args...)
-	if output, err := buildCmd.CombinedOutput(); err != nil {
-		// 构建失败，返回具体的Podman输出信息
-		return "", fmt.Errorf("认证镜像构建失败: %s", string(output))
+	// 构建参数的值可能包含敏感信息，日志中只记录 key
+	logman.Info("使用podman构建认证应用镜像", "image", imageName, "dockerfile", resolvedDockerfilePath, "context", contextDir, "target", req.Target, "build_args", buildArgKeys, "secrets", secretNames, "ref", ref)
+	if err := runner.step("构建镜像 "+imageName, func() error {
+		return runner.run(tempDir, "podman", args...)
+	}); err != nil {
+		return nil, fmt.Errorf("认证镜像构建失败: %w", err)
+	}
+
+	logman.Info("认证应用镜像构建成功", "image", imageName, "commit", commit.ShortSHA())
+	return &BuildResult{ImageName: imageName, RepoURL: req.RepoURL, Branch: branch, Ref: ref, Commit: commit}, nil
+
+}
+
+// prepareWorkDir 返回构建使用的工作目录和清理函数。
+// 启用缓存时使用应用的持久化克隆目录（同一应用的构建串行执行），否则使用构建后删除的临时目录
+func (bs *BuildService) prepareWorkDir(application *models.Application) (string, func(), error) {
+	if application.BuildCache.DisableBuildCache {
+		tempDir, err := os.MkdirTemp("", "github-clone-auth-*")
+		if err != nil {
+			return "", nil, fmt.Errorf("创建临时目录失败: %w", err)
+		}
+		return tempDir, func() { os.RemoveAll(tempDir) }, nil
 	}
 
-	logman.Info("认证应用镜像构建成功", "image", imageName)
-	return imageName, nil
+	cacheDir, err := BuildCacheDir(application.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	repoDir := filepath.Join(cacheDir, "repo")
+	if err := os.MkdirAll(repoDir, 0755); err != nil {
+		return "", nil, fmt.Errorf("创建构建缓存目录失败: %w", err)
+	}
+	return repoDir, lockBuildCache(application.ID), nil
+}
 
+// checkoutRef 浅拉取并检出指定的分支、标签或提交。缩写的 SHA 无法直接 fetch，此时退回到拉取所有分支后再检出。
+// 仓库地址只作为 fetch 参数传入，不写入 .git/config，持久化的克隆目录中不会保存凭据
+func (bs *BuildService) checkoutRef(runner *buildRunner, dir, repoURL, ref string) error {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := runner.run(dir, "git", "init", "-q"); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.ValidateGitRef(ref); err != nil {
+		return err
+	}
+
+	// "--" 之后的仓库地址和引用不会被当作选项解析
+	err := runner.run(dir, "git", "fetch", "-q", "--depth=1", "--", repoURL, ref)
+	if err != nil {
+		if !utils.IsCommitSHA(ref) || runner.ctx.Err() != nil {
+			return err
+		}
+
+		runner.log("无法直接拉取提交 %s，改为拉取完整历史", ref)
+		args := []string{"fetch", "-q"}
+		if _, err := os.Stat(filepath.Join(dir, ".git", "shallow")); err == nil {
+			args = append(args, "--unshallow")
+		}
+		args = append(args, "--", repoURL, "+refs/heads/*:refs/remotes/origin/*")
+		if err := runner.run(dir, "git", args...); err != nil {
+			return err
+		}
+		ref = ref + "^{commit}"
+	} else {
+		ref = "FETCH_HEAD"
+	}
+
+	// 复用克隆目录时清除上一次构建留下的修改和生成的文件
+	if err := runner.run(dir, "git", "checkout", "-q", "--force", ref, "--"); err != nil {
+		return err
+	}
+	return runner.run(dir, "git", "clean", "-q", "-ffdx")
+}
+
+// readHeadCommit 读取当前检出提交的元数据
+func (bs *BuildService) readHeadCommit(dir string) (*utils.GitCommitInfo, error) {
+	cmd := exec.Command("git", "log", "-1", "--format="+utils.GitCommitLogFormat)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取提交信息失败: %w", err)
+	}
+	return utils.ParseGitCommitInfo(string(output))
 }
 
 // // buildImageFromPrivateRepoForApp 处理私有仓库的构建，使用认证（基于应用）
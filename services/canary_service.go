@@ -0,0 +1,560 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/fastcaddy"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/command"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// 部署策略
+const (
+	StrategyDirect = "direct" // 新版本启动后直接接管流量
+	StrategyCanary = "canary" // 新旧版本同时运行，按权重逐步切换流量
+)
+
+const (
+	defaultCanaryWeight       = 10
+	defaultCanaryStep         = 10
+	defaultCanaryMaxErrorRate = 5.0
+
+	canaryProbeInterval   = 15 * time.Second
+	canaryProbesPerTick   = 3
+	canaryProbeTimeout    = 5 * time.Second
+	canaryMinProbeSamples = 10 // 样本数达到该值后才根据错误率判断
+)
+
+// prepareCanary 校验应用能否进行金丝雀发布，并根据当前线上版本生成金丝雀发布记录（尚未保存）
+func (do *DeploymentOrchestrator) prepareCanary(application *models.Application, req CreateDeploymentRequest) (*models.CanaryRelease, error) {
+	if application.ActiveReleaseID == nil {
+		return nil, fmt.Errorf("应用尚无线上版本，首次部署不能使用金丝雀发布")
+	}
+
+	baseline, err := models.GetLatestDeploymentByRelease(application.ID, *application.ActiveReleaseID)
+	if err != nil {
+		return nil, fmt.Errorf("未找到当前线上版本的部署记录: %w", err)
+	}
+
+	routings, err := canaryRoutings(application.ID, *baseline.SystemPort)
+	if err != nil {
+		return nil, err
+	}
+	if len(routings) == 0 {
+		return nil, fmt.Errorf("金丝雀发布需要至少一个指向当前版本 (端口 %d) 的路由", *baseline.SystemPort)
+	}
+
+	canary := &models.CanaryRelease{
+		ApplicationID:        application.ID,
+		BaselineDeploymentID: baseline.ID,
+		BaselinePort:         *baseline.SystemPort,
+		Weight:               req.CanaryWeight,
+		StepWeight:           req.CanaryStep,
+		MaxErrorRate:         req.CanaryMaxErrorRate,
+		HealthPath:           req.CanaryHealthPath,
+	}
+	if canary.Weight == 0 {
+		canary.Weight = defaultCanaryWeight
+	}
+	if canary.StepWeight == 0 {
+		canary.StepWeight = defaultCanaryStep
+	}
+	if canary.MaxErrorRate == 0 {
+		canary.MaxErrorRate = defaultCanaryMaxErrorRate
+	}
+	if canary.HealthPath == "" {
+		canary.HealthPath = "/"
+	}
+
+	if canary.Weight < 1 || canary.Weight > 99 {
+		return nil, fmt.Errorf("金丝雀初始流量百分比必须在 1-99 之间: %d", canary.Weight)
+	}
+	if canary.StepWeight < 1 || canary.StepWeight > 100 {
+		return nil, fmt.Errorf("金丝雀步长必须在 1-100 之间: %d", canary.StepWeight)
+	}
+	if canary.MaxErrorRate < 0 || canary.MaxErrorRate > 100 {
+		return nil, fmt.Errorf("错误率阈值必须在 0-100 之间: %v", canary.MaxErrorRate)
+	}
+
+	return canary, nil
+}
+
+// startCanary 新版本启动后，把部分流量切到新版本并开始健康监控
+func (do *DeploymentOrchestrator) startCanary(canary *models.CanaryRelease, project *models.Project) error {
+	do.canaryMu.Lock()
+	defer do.canaryMu.Unlock()
+
+	deployment, err := models.GetDeploymentByID(canary.DeploymentID)
+	if err != nil {
+		return fmt.Errorf("获取部署记录失败: %w", err)
+	}
+
+	// 构建期间可能已被中止
+	canary, err = models.GetCanaryReleaseByID(canary.ID)
+	if err != nil {
+		return fmt.Errorf("获取金丝雀发布记录失败: %w", err)
+	}
+	if canary.Status != models.CanaryStatusPending {
+		if err := do.stopUserService(deployment.ServiceName, project); err != nil {
+			logman.Warn("停止新版本服务失败", "service", deployment.ServiceName, "error", err)
+		}
+		return fmt.Errorf("金丝雀发布已中止: %s", canary.AbortReason)
+	}
+
+	if deployment.SystemPort == nil {
+		return fmt.Errorf("新版本未分配系统端口")
+	}
+	canary.CanaryPort = *deployment.SystemPort
+
+	if err := applyCanaryWeight(canary, canary.Weight); err != nil {
+		restoreBaselineTraffic(canary)
+		return err
+	}
+	if err := models.StartCanaryRelease(canary.ID, canary.CanaryPort); err != nil {
+		restoreBaselineTraffic(canary)
+		return fmt.Errorf("更新金丝雀发布状态失败: %w", err)
+	}
+
+	do.sendDeploymentLog(deployment.ID, fmt.Sprintf("金丝雀发布已开始: 新版本 (端口 %d) 承接 %d%% 流量，旧版本 (端口 %d) 承接 %d%%",
+		canary.CanaryPort, canary.Weight, canary.BaselinePort, 100-canary.Weight))
+
+	do.startCanaryMonitor(canary.ID)
+	return nil
+}
+
+// GetCanaryRelease 返回应用进行中的金丝雀发布，没有时返回最近一次
+func (do *DeploymentOrchestrator) GetCanaryRelease(appID uuid.UUID) (*models.CanaryRelease, error) {
+	canary, err := models.GetActiveCanaryReleaseByAppID(appID)
+	if err != nil || canary != nil {
+		return canary, err
+	}
+	return models.GetLatestCanaryReleaseByAppID(appID)
+}
+
+// StepCanary 调整新版本的流量百分比；weight 为 0 时按步长增加，达到 100 时自动 promote
+func (do *DeploymentOrchestrator) StepCanary(appID uuid.UUID, weight int) (*models.CanaryRelease, error) {
+	do.canaryMu.Lock()
+	defer do.canaryMu.Unlock()
+
+	canary, err := runningCanary(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	if weight == 0 {
+		weight = canary.Weight + canary.StepWeight
+	}
+	// 持有锁直接 promote，避免与并发的 abort 交错
+	if weight >= 100 {
+		return do.promoteCanary(appID)
+	}
+	if weight < 1 {
+		return nil, fmt.Errorf("流量百分比必须在 1-100 之间: %d", weight)
+	}
+
+	if err := applyCanaryWeight(canary, weight); err != nil {
+		return nil, err
+	}
+	if err := models.UpdateCanaryReleaseWeight(canary.ID, weight); err != nil {
+		return nil, fmt.Errorf("更新金丝雀流量失败: %w", err)
+	}
+	canary.Weight = weight
+
+	do.sendDeploymentLog(canary.DeploymentID, fmt.Sprintf("金丝雀流量调整为 %d%%", weight))
+	return canary, nil
+}
+
+// PromoteCanary 新版本接管全部流量，停止旧版本
+func (do *DeploymentOrchestrator) PromoteCanary(appID uuid.UUID) (*models.CanaryRelease, error) {
+	do.canaryMu.Lock()
+	defer do.canaryMu.Unlock()
+	return do.promoteCanary(appID)
+}
+
+// promoteCanary 执行 promote，调用方需持有 canaryMu；已中止或已 promote 的发布返回错误
+func (do *DeploymentOrchestrator) promoteCanary(appID uuid.UUID) (*models.CanaryRelease, error) {
+	canary, err := runningCanary(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	application, err := models.GetApplicationByID(appID)
+	if err != nil {
+		return nil, fmt.Errorf("获取应用信息失败: %w", err)
+	}
+	deployment, err := models.GetDeploymentByID(canary.DeploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部署记录失败: %w", err)
+	}
+	release, err := models.GetReleaseByID(deployment.ReleaseID)
+	if err != nil {
+		return nil, fmt.Errorf("获取发布版本失败: %w", err)
+	}
+
+	// 路由改为只指向新版本
+	routings, err := canaryRoutings(appID, canary.BaselinePort)
+	if err != nil {
+		return nil, err
+	}
+	for _, routing := range routings {
+		if _, err := UpdateRouting(routing.ID, routing.DomainName, canary.CanaryPort, routing.IsActive); err != nil {
+			return nil, fmt.Errorf("切换路由 %s 失败: %w", routing.DomainName, err)
+		}
+	}
+
+	if err := do.updateActiveRelease(application, release); err != nil {
+		return nil, err
+	}
+	if err := models.FinishCanaryRelease(canary.ID, models.CanaryStatusPromoted, ""); err != nil {
+		return nil, fmt.Errorf("更新金丝雀发布状态失败: %w", err)
+	}
+	canary.Status = models.CanaryStatusPromoted
+	canary.Weight = 100
+
+	do.finishCanaryDeployment(deployment, "success", "金丝雀发布完成，新版本已接管全部流量。")
+
+	// 停止旧版本
+	if baseline, err := models.GetDeploymentByID(canary.BaselineDeploymentID); err == nil {
+		if project, err := models.GetProjectByID(application.ProjectID); err == nil {
+			if err := do.stopUserService(baseline.ServiceName, project); err != nil {
+				logman.Warn("停止旧版本服务失败", "service", baseline.ServiceName, "error", err)
+			}
+		}
+	}
+
+	logman.Info("金丝雀发布已 promote", "app_id", appID, "deployment_id", canary.DeploymentID)
+	return canary, nil
+}
+
+// AbortCanary 流量回到旧版本，停止新版本
+func (do *DeploymentOrchestrator) AbortCanary(appID uuid.UUID, reason string) (*models.CanaryRelease, error) {
+	do.canaryMu.Lock()
+	defer do.canaryMu.Unlock()
+
+	canary, err := models.GetActiveCanaryReleaseByAppID(appID)
+	if err != nil {
+		return nil, fmt.Errorf("查询金丝雀发布失败: %w", err)
+	}
+	if canary == nil {
+		return nil, fmt.Errorf("没有进行中的金丝雀发布")
+	}
+	if reason == "" {
+		reason = "手动中止"
+	}
+
+	if err := models.FinishCanaryRelease(canary.ID, models.CanaryStatusAborted, reason); err != nil {
+		return nil, fmt.Errorf("更新金丝雀发布状态失败: %w", err)
+	}
+	canary.Status = models.CanaryStatusAborted
+	canary.AbortReason = reason
+
+	// 新版本仍在构建或启动中时，由 startCanary 负责停止
+	if canary.CanaryPort == 0 {
+		return canary, nil
+	}
+
+	restoreBaselineTraffic(canary)
+
+	deployment, err := models.GetDeploymentByID(canary.DeploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部署记录失败: %w", err)
+	}
+	if application, err := models.GetApplicationByID(appID); err == nil {
+		if project, err := models.GetProjectByID(application.ProjectID); err == nil {
+			if err := do.stopUserService(deployment.ServiceName, project); err != nil {
+				logman.Warn("停止新版本服务失败", "service", deployment.ServiceName, "error", err)
+			}
+		}
+	}
+
+	do.finishCanaryDeployment(deployment, "failed", "金丝雀发布已中止: "+reason)
+
+	logman.Info("金丝雀发布已中止", "app_id", appID, "deployment_id", canary.DeploymentID, "reason", reason)
+	return canary, nil
+}
+
+// ResumeCanaryMonitors 服务重启后恢复进行中的金丝雀发布的健康监控，
+// 并中止部署已不再进行的待启动金丝雀发布
+func (do *DeploymentOrchestrator) ResumeCanaryMonitors() {
+	abortStalePendingCanaries()
+
+	canaries, err := models.ListRunningCanaryReleases()
+	if err != nil {
+		logman.Warn("查询进行中的金丝雀发布失败", "error", err)
+		return
+	}
+
+	do.canaryMu.Lock()
+	defer do.canaryMu.Unlock()
+	for _, canary := range canaries {
+		do.startCanaryMonitor(canary.ID)
+	}
+}
+
+// abortStalePendingCanaries 中止重启前部署中断而遗留的 pending 金丝雀发布，
+// 否则它会一直占用应用的活动金丝雀位置
+func abortStalePendingCanaries() {
+	canaries, err := models.ListPendingCanaryReleases()
+	if err != nil {
+		logman.Warn("查询待启动的金丝雀发布失败", "error", err)
+		return
+	}
+
+	for _, canary := range canaries {
+		deployment, err := models.GetDeploymentByID(canary.DeploymentID)
+		if err == nil && (deployment.Status == models.DeploymentStatusInProgress || deployment.Status == models.DeploymentStatusAwaitingApproval) {
+			continue
+		}
+		if err := models.FinishCanaryRelease(canary.ID, models.CanaryStatusAborted, "部署已不再进行，服务重启后中止"); err != nil {
+			logman.Warn("中止遗留的金丝雀发布失败", "canary_id", canary.ID, "error", err)
+			continue
+		}
+		logman.Info("已中止遗留的金丝雀发布", "canary_id", canary.ID, "deployment_id", canary.DeploymentID)
+	}
+}
+
+// startCanaryMonitor 启动金丝雀健康监控（每个发布只启动一次），调用方需持有 canaryMu
+func (do *DeploymentOrchestrator) startCanaryMonitor(canaryID uuid.UUID) {
+	if do.canaryMonitors[canaryID] {
+		return
+	}
+	do.canaryMonitors[canaryID] = true
+	go do.monitorCanary(canaryID)
+}
+
+// monitorCanary 定期检查新版本服务状态，并对新旧版本的 HealthPath 发起探测比较错误率，异常时自动中止。
+// 错误率来自监控自身的探测请求，不统计经过 Caddy 的真实用户流量
+func (do *DeploymentOrchestrator) monitorCanary(canaryID uuid.UUID) {
+	defer func() {
+		do.canaryMu.Lock()
+		delete(do.canaryMonitors, canaryID)
+		do.canaryMu.Unlock()
+	}()
+
+	client := &http.Client{Timeout: canaryProbeTimeout}
+	var stats canaryProbeStats
+
+	ticker := time.NewTicker(canaryProbeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		canary, err := models.GetCanaryReleaseByID(canaryID)
+		if err != nil || canary.Status != models.CanaryStatusRunning {
+			return
+		}
+
+		if active, detail := do.isCanaryServiceActive(canary); !active {
+			do.autoAbortCanary(canary, "新版本服务未运行: "+detail)
+			return
+		}
+
+		for i := 0; i < canaryProbesPerTick; i++ {
+			stats.BaselineRequests++
+			if !probeUpstream(client, canary.BaselinePort, canary.HealthPath) {
+				stats.BaselineErrors++
+			}
+			stats.CanaryRequests++
+			if !probeUpstream(client, canary.CanaryPort, canary.HealthPath) {
+				stats.CanaryErrors++
+			}
+		}
+
+		if degraded, reason := evaluateCanaryHealth(stats, canary.MaxErrorRate); degraded {
+			do.autoAbortCanary(canary, reason)
+			return
+		}
+	}
+}
+
+// autoAbortCanary 监控发现异常时中止金丝雀发布
+func (do *DeploymentOrchestrator) autoAbortCanary(canary *models.CanaryRelease, reason string) {
+	logman.Warn("金丝雀发布异常，自动中止", "canary_id", canary.ID, "reason", reason)
+	if _, err := do.AbortCanary(canary.ApplicationID, "自动中止: "+reason); err != nil {
+		logman.Error("自动中止金丝雀发布失败", "canary_id", canary.ID, "error", err)
+	}
+}
+
+// isCanaryServiceActive 检查新版本的 systemd 服务是否处于运行状态
+func (do *DeploymentOrchestrator) isCanaryServiceActive(canary *models.CanaryRelease) (bool, string) {
+	deployment, err := models.GetDeploymentByID(canary.DeploymentID)
+	if err != nil {
+		return true, "" // 无法判断时不中止
+	}
+	application, err := models.GetApplicationByID(canary.ApplicationID)
+	if err != nil {
+		return true, ""
+	}
+	project, err := models.GetProjectByID(application.ProjectID)
+	if err != nil {
+		return true, ""
+	}
+
+	cmd := fmt.Sprintf("systemctl is-active %s", deployment.ServiceName)
+	if project.Username != "" {
+		cmd = fmt.Sprintf("su - %s -c 'systemctl --user is-active %s'", project.Username, deployment.ServiceName)
+	}
+	output, err := command.Exec(&command.ExecPayload{
+		Content:     cmd,
+		CommandType: "SHELL",
+		Timeout:     30,
+	})
+	if err != nil {
+		return false, output
+	}
+	return true, ""
+}
+
+// finishCanaryDeployment 金丝雀发布结束后更新新版本部署记录的状态
+func (do *DeploymentOrchestrator) finishCanaryDeployment(deployment *models.Deployment, status, message string) {
+	do.sendDeploymentLog(deployment.ID, message)
+
+	latest, err := models.GetDeploymentByID(deployment.ID)
+	if err != nil {
+		latest = deployment
+	}
+	now := time.Now()
+	if _, err := models.UpdateDeployment(latest.ID, status, latest.LogText+message+"\n", &now); err != nil {
+		logman.Error("更新部署状态失败", "deployment_id", latest.ID, "error", err)
+	}
+	do.reportDeploymentStatus(latest.ID, status, message)
+}
+
+// deploymentSucceededStatus 返回部署流程成功结束后的状态和日志：金丝雀发布在 promote 前保持 canary 状态
+func (do *DeploymentOrchestrator) deploymentSucceededStatus(deploymentID uuid.UUID) (string, string) {
+	canary, err := models.GetCanaryReleaseByDeploymentID(deploymentID)
+	if err == nil && canary != nil {
+		return "canary", "新版本已启动，金丝雀发布进行中，等待 promote 或 abort。"
+	}
+	return "success", "部署成功，应用已启动。"
+}
+
+// runningCanary 返回应用正在运行的金丝雀发布
+func runningCanary(appID uuid.UUID) (*models.CanaryRelease, error) {
+	canary, err := models.GetActiveCanaryReleaseByAppID(appID)
+	if err != nil {
+		return nil, fmt.Errorf("查询金丝雀发布失败: %w", err)
+	}
+	if canary == nil {
+		return nil, fmt.Errorf("没有进行中的金丝雀发布")
+	}
+	if canary.Status != models.CanaryStatusRunning {
+		return nil, fmt.Errorf("新版本尚未启动，请稍后再试")
+	}
+	return canary, nil
+}
+
+// canaryRoutings 返回指向旧版本端口的启用路由，金丝雀发布只调整这些路由的流量
+func canaryRoutings(appID uuid.UUID, baselinePort int) ([]*models.Routing, error) {
+	routings, err := models.GetActiveRoutingsByApplicationID(appID)
+	if err != nil {
+		return nil, fmt.Errorf("查询路由信息失败: %w", err)
+	}
+
+	var result []*models.Routing
+	for _, routing := range routings {
+		if routing.HostPort == baselinePort {
+			result = append(result, routing)
+		}
+	}
+	return result, nil
+}
+
+// applyCanaryWeight 将路由的反向代理改为新旧两个上游，按权重分配流量
+func applyCanaryWeight(canary *models.CanaryRelease, weight int) error {
+	routings, err := canaryRoutings(canary.ApplicationID, canary.BaselinePort)
+	if err != nil {
+		return err
+	}
+
+	fc := fastcaddy.New()
+	for _, routing := range routings {
+		fmt.Printf("🔀 [金丝雀] %s: 旧版本 localhost:%d %d%%, 新版本 localhost:%d %d%%\n",
+			routing.DomainName, canary.BaselinePort, 100-weight, canary.CanaryPort, weight)
+		handler := weightedReverseProxy([]int{canary.BaselinePort, canary.CanaryPort}, []int{100 - weight, weight})
+		if err := fc.API.PutByID(handler, routing.DomainName+"/handle/0", "PATCH"); err != nil {
+			return fmt.Errorf("通过 Caddy 配置 %s 的流量权重失败: %w", routing.DomainName, err)
+		}
+	}
+	return nil
+}
+
+// restoreBaselineTraffic 将路由恢复为只指向旧版本
+func restoreBaselineTraffic(canary *models.CanaryRelease) {
+	routings, err := canaryRoutings(canary.ApplicationID, canary.BaselinePort)
+	if err != nil {
+		logman.Error("查询路由信息失败", "error", err)
+		return
+	}
+
+	fc := fastcaddy.New()
+	for _, routing := range routings {
+		handler := weightedReverseProxy([]int{canary.BaselinePort}, nil)
+		if err := fc.API.PutByID(handler, routing.DomainName+"/handle/0", "PATCH"); err != nil {
+			logman.Error("恢复旧版本路由失败", "domain", routing.DomainName, "error", err)
+		}
+	}
+}
+
+// weightedReverseProxy 生成 Caddy reverse_proxy 处理器，多个上游时使用加权轮询
+func weightedReverseProxy(ports []int, weights []int) map[string]interface{} {
+	upstreams := make([]map[string]interface{}, 0, len(ports))
+	for _, port := range ports {
+		upstreams = append(upstreams, map[string]interface{}{
+			"dial": fmt.Sprintf("localhost:%d", port),
+		})
+	}
+
+	handler := map[string]interface{}{
+		"handler":   "reverse_proxy",
+		"upstreams": upstreams,
+	}
+	if len(ports) > 1 {
+		handler["load_balancing"] = map[string]interface{}{
+			"selection_policy": map[string]interface{}{
+				"policy":  "weighted_round_robin",
+				"weights": weights,
+			},
+		}
+	}
+	return handler
+}
+
+// canaryProbeStats 金丝雀监控期间新旧版本的探测统计
+type canaryProbeStats struct {
+	BaselineRequests int
+	BaselineErrors   int
+	CanaryRequests   int
+	CanaryErrors     int
+}
+
+// evaluateCanaryHealth 新版本探测错误率比旧版本高出 maxErrorRate 个百分点时判定为退化
+func evaluateCanaryHealth(stats canaryProbeStats, maxErrorRate float64) (bool, string) {
+	if stats.CanaryRequests < canaryMinProbeSamples {
+		return false, ""
+	}
+
+	canaryRate := float64(stats.CanaryErrors) * 100 / float64(stats.CanaryRequests)
+	baselineRate := 0.0
+	if stats.BaselineRequests > 0 {
+		baselineRate = float64(stats.BaselineErrors) * 100 / float64(stats.BaselineRequests)
+	}
+
+	if canaryRate-baselineRate > maxErrorRate {
+		return true, fmt.Sprintf("新版本错误率 %.1f%% 高于旧版本 %.1f%%（阈值 %.1f 个百分点）", canaryRate, baselineRate, maxErrorRate)
+	}
+	return false, ""
+}
+
+// probeUpstream 请求本机上游，连接失败或返回 5xx 视为错误
+func probeUpstream(client *http.Client, port int, path string) bool {
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d%s", port, path))
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
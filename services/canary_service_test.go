@@ -0,0 +1,131 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRunningCanary 创建一个新版本已启动、应用没有路由的金丝雀发布，promote/abort 不需要 Caddy
+func createRunningCanary(t *testing.T) (*models.Application, *models.CanaryRelease) {
+	t.Helper()
+	app, err := models.CreateApplication(uuid.New(), "canary-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	release, err := models.CreateRelease(app.ID, "canary-app:v2", models.JSONB{}, "success")
+	assert.NoError(t, err)
+	baseline, err := models.CreateDeployment(app.ID, release.ID, "success", "", "canary-app-v1", time.Now(), nil)
+	assert.NoError(t, err)
+	deployment, err := models.CreateDeployment(app.ID, release.ID, "canary", "", "canary-app-v2", time.Now(), nil)
+	assert.NoError(t, err)
+
+	canary := &models.CanaryRelease{
+		ApplicationID:        app.ID,
+		DeploymentID:         deployment.ID,
+		BaselineDeploymentID: baseline.ID,
+		BaselinePort:         10001,
+		Weight:               10,
+		StepWeight:           50,
+		MaxErrorRate:         5,
+		HealthPath:           "/",
+	}
+	assert.NoError(t, models.CreateCanaryRelease(canary))
+	assert.NoError(t, models.StartCanaryRelease(canary.ID, 10002))
+	return app, canary
+}
+
+func TestStepCanaryPromotesAtFullWeight(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	app, canary := createRunningCanary(t)
+
+	promoted, err := orchestrator.StepCanary(app.ID, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CanaryStatusPromoted, promoted.Status)
+
+	stored, err := models.GetCanaryReleaseByID(canary.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CanaryStatusPromoted, stored.Status)
+
+	deployment, err := models.GetDeploymentByID(canary.DeploymentID)
+	assert.NoError(t, err)
+	assert.Equal(t, "success", deployment.Status)
+}
+
+func TestStepCanaryDoesNotPromoteAbortedCanary(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	app, canary := createRunningCanary(t)
+
+	_, err := orchestrator.AbortCanary(app.ID, "")
+	assert.NoError(t, err)
+
+	_, err = orchestrator.StepCanary(app.ID, 100)
+	assert.Error(t, err)
+	_, err = orchestrator.PromoteCanary(app.ID)
+	assert.Error(t, err)
+
+	stored, err := models.GetCanaryReleaseByID(canary.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CanaryStatusAborted, stored.Status)
+
+	application, err := models.GetApplicationByID(app.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, application.ActiveReleaseID, "aborted canary must not become the active release")
+}
+
+func TestStepCanaryRejectsPendingCanary(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	app, _ := createRunningCanary(t)
+	// 再创建一个尚未启动的发布不会被当作运行中处理
+	other, err := models.CreateApplication(uuid.New(), "pending-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, models.CreateCanaryRelease(&models.CanaryRelease{
+		ApplicationID:        other.ID,
+		DeploymentID:         uuid.New(),
+		BaselineDeploymentID: uuid.New(),
+		BaselinePort:         10003,
+	}))
+
+	_, err = orchestrator.StepCanary(other.ID, 100)
+	assert.Error(t, err)
+	_, err = orchestrator.StepCanary(app.ID, -1)
+	assert.Error(t, err)
+}
+
+func TestResumeCanaryMonitorsAbortsStalePendingCanaries(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	app, err := models.CreateApplication(uuid.New(), "pending-canary-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	release, err := models.CreateRelease(app.ID, "pending-canary-app:v2", models.JSONB{}, "success")
+	assert.NoError(t, err)
+
+	createPending := func(status string) *models.CanaryRelease {
+		deployment, err := models.CreateDeployment(app.ID, release.ID, status, "", "pending-canary-app-v2", time.Now(), nil)
+		assert.NoError(t, err)
+		canary := &models.CanaryRelease{
+			ApplicationID:        app.ID,
+			DeploymentID:         deployment.ID,
+			BaselineDeploymentID: uuid.New(),
+			BaselinePort:         10001,
+		}
+		assert.NoError(t, models.CreateCanaryRelease(canary))
+		return canary
+	}
+	failed := createPending("failed")
+	inProgress := createPending(models.DeploymentStatusInProgress)
+
+	orchestrator.ResumeCanaryMonitors()
+
+	stored, err := models.GetCanaryReleaseByID(failed.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CanaryStatusAborted, stored.Status)
+
+	stored, err = models.GetCanaryReleaseByID(inProgress.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CanaryStatusPending, stored.Status)
+}
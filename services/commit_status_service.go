@@ -0,0 +1,150 @@
+package services
+
+import (
+	"net/url"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// commitStatusContext 提交状态的名称，同一仓库的多个应用各自显示一条状态
+func commitStatusContext(application *models.Application) string {
+	return "orbitdeploy/" + application.Name
+}
+
+// providerClientForApplication 返回应用仓库所在平台的 API 客户端和仓库地址。
+// SSH 仓库和未关联 ProviderAuth 的应用没有平台 API 凭据，返回 nil
+func (do *DeploymentOrchestrator) providerClientForApplication(application *models.Application) (*utils.GitProviderClient, string) {
+	repoInfo, err := do.buildService.getRepositoryInfo(application)
+	if err != nil {
+		logman.Warn("获取仓库凭据失败，跳过状态回报", "app_name", application.Name, "error", err)
+		return nil, ""
+	}
+	if repoInfo == nil || repoInfo.Platform == "ssh" {
+		return nil, ""
+	}
+	return utils.NewGitProviderClient(repoInfo.Platform, repoInfo.AuthToken, repoInfo.Username), repoInfo.URL
+}
+
+// deploymentLogURL 返回应用部署记录页面的地址，未配置系统域名时返回空字符串
+func deploymentLogURL(application *models.Application) string {
+	domain, err := models.GetSystemSetting("system_domain")
+	if err != nil || domain == "" {
+		return ""
+	}
+	project, err := models.GetProjectByID(application.ProjectID)
+	if err != nil {
+		return ""
+	}
+	return "https://" + domain + "/projects/" + url.PathEscape(project.Name) + "/apps/" + url.PathEscape(application.Name) + "?tab=Deployments"
+}
+
+// applicationURL 返回应用自动生成的访问地址
+func applicationURL(application *models.Application) string {
+	if application.GeneratedHostname == "" {
+		return ""
+	}
+	return "https://" + application.GeneratedHostname
+}
+
+// releaseCommitSHA 返回 Release 构建时记录的提交 SHA
+func releaseCommitSHA(release *models.Release) string {
+	sourceInfo, _ := release.BuildSourceInfo.Data.(map[string]interface{})
+	sha, _ := sourceInfo["commit_sha"].(string)
+	return sha
+}
+
+// reportDeploymentStarted 记录部署的提交，并在代码托管平台上标记为进行中；
+// GitHub 仓库同时创建以应用名为环境的 Deployment。回报失败只记录日志，不影响部署
+func (do *DeploymentOrchestrator) reportDeploymentStarted(deploymentID uuid.UUID, application *models.Application, commitSHA string) {
+	if !utils.IsCommitSHA(commitSHA) {
+		return
+	}
+	if err := models.UpdateDeploymentCommit(deploymentID, commitSHA, 0); err != nil {
+		logman.Error("记录部署提交失败", "deployment_id", deploymentID, "error", err)
+		return
+	}
+
+	client, repoURL := do.providerClientForApplication(application)
+	if client == nil {
+		return
+	}
+	logURL := deploymentLogURL(application)
+	err := client.SetCommitStatus(repoURL, commitSHA, utils.CommitStatus{
+		State:       utils.CommitStatePending,
+		Context:     commitStatusContext(application),
+		Description: "正在部署 " + application.Name,
+		TargetURL:   logURL,
+	})
+	if err != nil {
+		logman.Warn("回报提交状态失败", "deployment_id", deploymentID, "platform", client.Platform, "error", err)
+	}
+
+	if client.Platform != "github" {
+		return
+	}
+	providerDeploymentID, err := client.CreateGitHubDeployment(repoURL, commitSHA, application.Name, "OrbitDeploy 部署 "+application.Name)
+	if err != nil {
+		logman.Warn("创建 GitHub Deployment 失败", "deployment_id", deploymentID, "error", err)
+		return
+	}
+	if err := models.UpdateDeploymentCommit(deploymentID, commitSHA, providerDeploymentID); err != nil {
+		logman.Error("记录 GitHub Deployment 失败", "deployment_id", deploymentID, "error", err)
+	}
+	err = client.SetGitHubDeploymentStatus(repoURL, providerDeploymentID, utils.GitHubDeploymentStatus{
+		State:  "in_progress",
+		LogURL: logURL,
+	})
+	if err != nil {
+		logman.Warn("更新 GitHub Deployment 状态失败", "deployment_id", deploymentID, "error", err)
+	}
+}
+
+// reportDeploymentStatus 按部署状态（success、failed、canary）更新提交状态和 GitHub Deployment。
+// 金丝雀发布在 promote 前仍显示为进行中
+func (do *DeploymentOrchestrator) reportDeploymentStatus(deploymentID uuid.UUID, status, message string) {
+	deployment, err := models.GetDeploymentByID(deploymentID)
+	if err != nil || deployment.CommitSHA == "" {
+		return
+	}
+	application, err := models.GetApplicationByID(deployment.ApplicationID)
+	if err != nil {
+		return
+	}
+	client, repoURL := do.providerClientForApplication(application)
+	if client == nil {
+		return
+	}
+
+	state, githubState := utils.CommitStateFailure, "failure"
+	switch status {
+	case "success":
+		state, githubState = utils.CommitStateSuccess, "success"
+	case "canary":
+		state, githubState = utils.CommitStatePending, "in_progress"
+	}
+
+	logURL := deploymentLogURL(application)
+	err = client.SetCommitStatus(repoURL, deployment.CommitSHA, utils.CommitStatus{
+		State:       state,
+		Context:     commitStatusContext(application),
+		Description: message,
+		TargetURL:   logURL,
+	})
+	if err != nil {
+		logman.Warn("回报提交状态失败", "deployment_id", deploymentID, "platform", client.Platform, "error", err)
+	}
+
+	if client.Platform != "github" || deployment.ProviderDeploymentID == 0 {
+		return
+	}
+	deploymentStatus := utils.GitHubDeploymentStatus{State: githubState, Description: message, LogURL: logURL}
+	if status == "success" {
+		deploymentStatus.EnvironmentURL = applicationURL(application)
+	}
+	if err := client.SetGitHubDeploymentStatus(repoURL, deployment.ProviderDeploymentID, deploymentStatus); err != nil {
+		logman.Warn("更新 GitHub Deployment 状态失败", "deployment_id", deploymentID, "error", err)
+	}
+}
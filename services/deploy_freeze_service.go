@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// ErrDeploymentFrozen 处于部署冻结窗口且没有填写强制部署的原因，调用方据此返回 409
+var ErrDeploymentFrozen = errors.New("部署冻结中")
+
+// ActiveFreezeWindow 返回 at 时刻对应用生效的冻结窗口（项目级或应用级）及其结束时间，没有时返回 nil
+func ActiveFreezeWindow(application *models.Application, at time.Time) (*models.DeployFreezeWindow, time.Time, error) {
+	windows, err := models.ListDeployFreezeWindowsForApplication(application.ProjectID, application.ID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("查询部署冻结窗口失败: %w", err)
+	}
+	for _, window := range windows {
+		if active, until := window.Window.ActiveAt(at); active {
+			return window, until, nil
+		}
+	}
+	return nil, time.Time{}, nil
+}
+
+// checkDeployFreeze 检查 at 时刻是否处于冻结窗口：没有填写原因时返回 ErrDeploymentFrozen，
+// 填写了原因时放行并返回被覆盖的窗口，由调用方记录
+func checkDeployFreeze(application *models.Application, at time.Time, overrideReason string) (*models.DeployFreezeWindow, error) {
+	window, until, err := ActiveFreezeWindow(application, at)
+	if err != nil || window == nil {
+		return nil, err
+	}
+	if strings.TrimSpace(overrideReason) == "" {
+		message := fmt.Sprintf("%s 处于冻结窗口「%s」，持续到 %s", at.Format("2006-01-02 15:04"), window.Name, until.Format("2006-01-02 15:04"))
+		if window.Reason != "" {
+			message += "（" + window.Reason + "）"
+		}
+		return nil, fmt.Errorf("%w: %s，如需部署请填写强制部署的原因", ErrDeploymentFrozen, message)
+	}
+	return window, nil
+}
+
+// carriedFreezeOverride 返回 since 时刻填写的强制部署原因在 now 时刻是否仍然适用：原因只针对填写时所在的冻结窗口，
+// now 处于另一个窗口（或周期窗口的下一次）时返回空字符串，需要重新填写
+func carriedFreezeOverride(application *models.Application, since, now time.Time, reason string) (string, error) {
+	if strings.TrimSpace(reason) == "" {
+		return "", nil
+	}
+	current, currentUntil, err := ActiveFreezeWindow(application, now)
+	if err != nil || current == nil {
+		return reason, err
+	}
+	previous, previousUntil, err := ActiveFreezeWindow(application, since)
+	if err != nil {
+		return "", err
+	}
+	if previous == nil || previous.ID != current.ID || !previousUntil.Equal(currentUntil) {
+		return "", nil
+	}
+	return reason, nil
+}
+
+// recordFreezeOverride 记录在冻结窗口内强制部署的原因，写入部署日志并发送通知
+func (do *DeploymentOrchestrator) recordFreezeOverride(application *models.Application, deployment *models.Deployment, window *models.DeployFreezeWindow, req CreateDeploymentRequest) {
+	reason := strings.TrimSpace(req.FreezeOverrideReason)
+	if err := models.UpdateDeploymentFreezeOverride(deployment.ID, reason); err != nil {
+		logman.Error("记录强制部署原因失败", "deployment_id", deployment.ID, "error", err)
+	}
+	deployment.FreezeOverrideReason = reason
+
+	do.sendDeploymentLog(deployment.ID, fmt.Sprintf("冻结窗口「%s」内强制部署，原因：%s\n", window.Name, reason))
+	logman.Warn("冻结窗口内强制部署", "app_name", application.Name, "deployment_id", deployment.ID, "window", window.Name, "requested_by", req.RequestedBy, "reason", reason)
+
+	go func() {
+		if err := utils.SendWarningNotification("冻结窗口内强制部署",
+			fmt.Sprintf("%s 在冻结窗口「%s」内部署应用 %s，原因：%s", approvalRequester(req.RequestedBy), window.Name, application.Name, reason),
+			utils.WithService(application.Name),
+			utils.WithDetails(map[string]interface{}{
+				"deployment_id": deployment.ID.String(),
+				"window":        window.Name,
+				"requested_by":  req.RequestedBy,
+			}),
+		); err != nil {
+			logman.Warn("发送强制部署通知失败", "app_name", application.Name, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// hostKeyScanTimeout 获取仓库主机密钥的超时时间
+const hostKeyScanTimeout = 10 * time.Second
+
+// GenerateApplicationDeployKey 为应用生成（或轮换）ed25519 部署密钥
+func GenerateApplicationDeployKey(application *models.Application) (*models.DeployKey, error) {
+	pair, err := utils.GenerateDeployKey("orbitdeploy-" + utils.SanitizeDNSLabel(application.Name))
+	if err != nil {
+		return nil, err
+	}
+	key, err := models.SaveDeployKey(application.ID, pair)
+	if err != nil {
+		return nil, fmt.Errorf("保存部署密钥失败: %w", err)
+	}
+	logman.Info("已生成部署密钥", "app_name", application.Name, "fingerprint", pair.Fingerprint)
+	return key, nil
+}
+
+// PinApplicationKnownHosts 固定应用仓库的主机密钥。knownHosts 为空时从仓库地址的主机获取，
+// 调用方应向用户展示主机密钥的指纹以便核对
+func PinApplicationKnownHosts(application *models.Application, knownHosts string) (*models.DeployKey, error) {
+	key, err := models.GetDeployKeyByApplicationID(application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("应用尚未生成部署密钥")
+	}
+
+	knownHosts = strings.TrimSpace(knownHosts)
+	if knownHosts == "" {
+		if application.RepoURL == nil {
+			return nil, fmt.Errorf("应用未设置仓库地址")
+		}
+		host, port, err := utils.ParseSSHRepoURL(*application.RepoURL)
+		if err != nil {
+			return nil, err
+		}
+		if knownHosts, err = utils.ScanSSHHostKeys(host, port, hostKeyScanTimeout); err != nil {
+			return nil, err
+		}
+	}
+
+	fingerprints, err := utils.KnownHostsFingerprints(knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.UpdateDeployKeyKnownHosts(application.ID, knownHosts); err != nil {
+		return nil, fmt.Errorf("保存主机密钥失败: %w", err)
+	}
+	logman.Info("已固定仓库主机密钥", "app_name", application.Name, "fingerprints", fingerprints)
+	key.KnownHosts = knownHosts
+	return key, nil
+}
+
+// prepareGitSSHEnv 将部署密钥和固定的主机密钥写入临时目录，返回 git 使用的环境变量和清理函数
+func prepareGitSSHEnv(repoInfo *RepositoryInfo) ([]string, func(), error) {
+	dir, err := os.MkdirTemp("", "orbit-git-ssh-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建 SSH 临时目录失败: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	keyPath := filepath.Join(dir, "id_ed25519")
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	if err := os.WriteFile(keyPath, []byte(repoInfo.SSHPrivateKey), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("写入部署密钥失败: %w", err)
+	}
+	if err := os.WriteFile(knownHostsPath, []byte(repoInfo.KnownHosts), 0600); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("写入主机密钥失败: %w", err)
+	}
+
+	env := []string{
+		"GIT_SSH_COMMAND=" + utils.GitSSHCommand(keyPath, knownHostsPath),
+		"GIT_TERMINAL_PROMPT=0",
+	}
+	return env, cleanup, nil
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// approvalCheckInterval 检查审批是否超时的间隔
+const approvalCheckInterval = time.Minute
+
+// 审批的校验错误，调用方据此返回 4xx
+var (
+	ErrDeploymentNotAwaitingApproval = errors.New("部署不在等待审批状态")
+	ErrDeploymentApprovalExpired     = errors.New("部署已超过审批时限")
+	ErrDeploymentSelfReview          = errors.New("不能审批自己发起的部署")
+	ErrDeploymentReviewerNotAllowed  = errors.New("没有审批该应用部署的权限")
+)
+
+// requestApproval 受保护应用的部署进入等待审批状态：记录发起人和审批截止时间，并发送通知
+func (do *DeploymentOrchestrator) requestApproval(application *models.Application, deployment *models.Deployment, requestedBy string) error {
+	expiresAt := time.Now().Add(application.Approval.ApprovalTimeout())
+	if err := models.RequestDeploymentApproval(deployment.ID, requestedBy, expiresAt); err != nil {
+		return err
+	}
+	deployment.RequestedBy = requestedBy
+	deployment.ApprovalExpiresAt = &expiresAt
+
+	logman.Info("部署等待审批", "app_name", application.Name, "deployment_id", deployment.ID, "requested_by", requestedBy, "expires_at", expiresAt)
+	notifyApproval(utils.NotificationTypeInfo, application, deployment, "部署等待审批",
+		fmt.Sprintf("%s 请求部署应用 %s，请在 %s 前审批", approvalRequester(requestedBy), application.Name, expiresAt.Format("2006-01-02 15:04")))
+	return nil
+}
+
+// ApproveDeployment 批准等待审批的部署并开始构建和部署，审批人和意见记录在部署历史中。
+// 批准时重新检查冻结窗口：发起时填写的强制部署原因只对当时所在的窗口有效，否则需要审批人填写 freezeOverrideReason
+func (do *DeploymentOrchestrator) ApproveDeployment(deploymentID uuid.UUID, approver, comment, freezeOverrideReason string) (*models.Deployment, error) {
+	deployment, err := do.getAwaitingDeployment(deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDeploymentReviewer(deployment, approver); err != nil {
+		return nil, err
+	}
+	application, err := models.GetApplicationByID(deployment.ApplicationID)
+	if err != nil {
+		return nil, fmt.Errorf("获取应用失败: %w", err)
+	}
+	now := time.Now()
+	overrideReason := strings.TrimSpace(freezeOverrideReason)
+	if overrideReason == "" {
+		if overrideReason, err = carriedFreezeOverride(application, deployment.CreatedAt, now, deployment.FreezeOverrideReason); err != nil {
+			return nil, err
+		}
+	}
+	frozenBy, err := checkDeployFreeze(application, now, overrideReason)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok, err := models.ReviewDeployment(deploymentID, models.DeploymentStatusInProgress, approver, comment); err != nil {
+		return nil, fmt.Errorf("记录审批结果失败: %w", err)
+	} else if !ok {
+		return nil, ErrDeploymentNotAwaitingApproval
+	}
+
+	do.sendDeploymentLog(deploymentID, fmt.Sprintf("%s 批准了部署%s\n", approver, approvalCommentSuffix(comment)))
+	logman.Info("部署已批准", "deployment_id", deploymentID, "approver", approver)
+	if frozenBy != nil && overrideReason != deployment.FreezeOverrideReason {
+		do.recordFreezeOverride(application, deployment, frozenBy, CreateDeploymentRequest{RequestedBy: approver, FreezeOverrideReason: overrideReason})
+	}
+
+	// 需要构建的部署在创建时只生成了 building 状态的 Release
+	if release, err := models.GetReleaseByID(deployment.ReleaseID); err == nil && release.Status == "building" {
+		go do.startBuildAndDeploymentAsync(deploymentID)
+	} else {
+		go do.startDeploymentAsync(deploymentID)
+	}
+
+	notifyApproval(utils.NotificationTypeSuccess, application, deployment, "部署已批准",
+		fmt.Sprintf("%s 批准了应用 %s 的部署%s", approver, application.Name, approvalCommentSuffix(comment)))
+	return models.GetDeploymentByID(deploymentID)
+}
+
+// RejectDeployment 拒绝等待审批的部署，部署不会执行
+func (do *DeploymentOrchestrator) RejectDeployment(deploymentID uuid.UUID, reviewer, comment string) (*models.Deployment, error) {
+	deployment, err := do.getAwaitingDeployment(deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDeploymentReviewer(deployment, reviewer); err != nil {
+		return nil, err
+	}
+	if ok, err := models.ReviewDeployment(deploymentID, models.DeploymentStatusRejected, reviewer, comment); err != nil {
+		return nil, fmt.Errorf("记录审批结果失败: %w", err)
+	} else if !ok {
+		return nil, ErrDeploymentNotAwaitingApproval
+	}
+
+	reason := fmt.Sprintf("%s 拒绝了部署%s", reviewer, approvalCommentSuffix(comment))
+	do.finishUnapprovedDeployment(deployment, reason)
+	logman.Info("部署已拒绝", "deployment_id", deploymentID, "reviewer", reviewer)
+
+	if application, err := models.GetApplicationByID(deployment.ApplicationID); err == nil {
+		notifyApproval(utils.NotificationTypeWarning, application, deployment, "部署已拒绝",
+			fmt.Sprintf("%s 拒绝了应用 %s 的部署%s", reviewer, application.Name, approvalCommentSuffix(comment)))
+	}
+	return models.GetDeploymentByID(deploymentID)
+}
+
+// StartApprovalExpiryMonitor 启动定时任务，把超过审批时限的部署标记为过期
+func (do *DeploymentOrchestrator) StartApprovalExpiryMonitor() {
+	go func() {
+		ticker := time.NewTicker(approvalCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			do.expireDeploymentApprovals()
+		}
+	}()
+}
+
+// expireDeploymentApprovals 处理所有超过审批时限的部署
+func (do *DeploymentOrchestrator) expireDeploymentApprovals() {
+	deployments, err := models.ListExpiredDeploymentApprovals(time.Now())
+	if err != nil {
+		logman.Error("查询审批超时的部署失败", "error", err)
+		return
+	}
+	for _, deployment := range deployments {
+		do.expireDeployment(deployment)
+	}
+}
+
+// expireDeployment 把超过审批时限的部署标记为过期，部署不会执行
+func (do *DeploymentOrchestrator) expireDeployment(deployment *models.Deployment) {
+	ok, err := models.ReviewDeployment(deployment.ID, models.DeploymentStatusExpired, "", "")
+	if err != nil {
+		logman.Error("更新审批超时的部署失败", "deployment_id", deployment.ID, "error", err)
+		return
+	}
+	if !ok {
+		return // 已被批准或拒绝
+	}
+
+	do.finishUnapprovedDeployment(deployment, "超过审批时限，部署已过期")
+	logman.Info("部署审批已过期", "deployment_id", deployment.ID)
+
+	if application, err := models.GetApplicationByID(deployment.ApplicationID); err == nil {
+		notifyApproval(utils.NotificationTypeWarning, application, deployment, "部署审批已过期",
+			fmt.Sprintf("应用 %s 的部署在审批时限内未获批准，已过期", application.Name))
+	}
+}
+
+// getAwaitingDeployment 获取等待审批的部署，已过期但尚未被定时任务处理的部署在这里标记为过期
+func (do *DeploymentOrchestrator) getAwaitingDeployment(deploymentID uuid.UUID) (*models.Deployment, error) {
+	deployment, err := models.GetDeploymentByID(deploymentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部署记录失败: %w", err)
+	}
+	if deployment.Status != models.DeploymentStatusAwaitingApproval {
+		return nil, ErrDeploymentNotAwaitingApproval
+	}
+	if deployment.ApprovalExpiresAt != nil && time.Now().After(*deployment.ApprovalExpiresAt) {
+		do.expireDeployment(deployment)
+		return nil, ErrDeploymentApprovalExpired
+	}
+	return deployment, nil
+}
+
+// checkDeploymentReviewer 校验审批人：不能是部署发起人，应用设置了审批人时必须在其中
+func checkDeploymentReviewer(deployment *models.Deployment, reviewer string) error {
+	// 应用令牌发起的部署也不能由令牌的创建者审批
+	if reviewer == deployment.RequestedBy || reviewer == models.RequesterUsername(deployment.RequestedBy) {
+		return ErrDeploymentSelfReview
+	}
+	application, err := models.GetApplicationByID(deployment.ApplicationID)
+	if err != nil {
+		return fmt.Errorf("获取应用失败: %w", err)
+	}
+	if !application.Approval.CanApprove(reviewer) {
+		return ErrDeploymentReviewerNotAllowed
+	}
+	return nil
+}
+
+// finishUnapprovedDeployment 清理未获批准的部署：中止随部署创建的金丝雀发布，未构建的 Release 标记为失败
+func (do *DeploymentOrchestrator) finishUnapprovedDeployment(deployment *models.Deployment, reason string) {
+	do.sendDeploymentLog(deployment.ID, reason+"\n")
+
+	if canary, err := models.GetCanaryReleaseByDeploymentID(deployment.ID); err == nil && canary != nil && canary.Status == models.CanaryStatusPending {
+		if err := models.FinishCanaryRelease(canary.ID, models.CanaryStatusAborted, reason); err != nil {
+			logman.Error("更新金丝雀发布状态失败", "canary_id", canary.ID, "error", err)
+		}
+	}
+	if release, err := models.GetReleaseByID(deployment.ReleaseID); err == nil && release.Status == "building" {
+		if err := models.UpdateReleaseStatus(release.ID, "failed"); err != nil {
+			logman.Error("更新 Release 状态失败", "release_id", release.ID, "error", err)
+		}
+	}
+}
+
+// notifyApproval 通过 webhook 发送审批通知，发送失败不影响审批流程
+func notifyApproval(notificationType utils.NotificationType, application *models.Application, deployment *models.Deployment, title, message string) {
+	details := map[string]interface{}{
+		"deployment_id": deployment.ID.String(),
+		"release_id":    deployment.ReleaseID.String(),
+		"requested_by":  deployment.RequestedBy,
+	}
+	if deployment.ApprovalExpiresAt != nil {
+		details["expires_at"] = deployment.ApprovalExpiresAt.Format(time.RFC3339)
+	}
+	go func() {
+		if err := utils.SendWebhookNotification(notificationType, title, message,
+			utils.WithService(application.Name),
+			utils.WithDetails(details),
+		); err != nil {
+			logman.Warn("发送审批通知失败", "app_name", application.Name, "error", err)
+		}
+	}()
+}
+
+// approvalRequester 返回通知中显示的发起人，自动部署没有发起人
+func approvalRequester(requestedBy string) string {
+	if requestedBy == "" {
+		return "自动部署"
+	}
+	return requestedBy
+}
+
+// approvalCommentSuffix 把审批意见附加到日志和通知中
+func approvalCommentSuffix(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	return "：" + comment
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// createAwaitingDeployment 创建受保护应用和一个由 requestedBy 发起、等待审批的部署
+func createAwaitingDeployment(t *testing.T, approvers, requestedBy string) *models.Deployment {
+	t.Helper()
+	app, err := models.CreateApplication(uuid.New(), "protected-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, models.UpdateApplicationApprovalSettings(app.ID, models.ApprovalSettings{
+		Protected:            true,
+		ApprovalTimeoutHours: 24,
+		Approvers:            approvers,
+	}))
+	release, err := models.CreateRelease(app.ID, "protected-app:v1", models.JSONB{}, "success")
+	assert.NoError(t, err)
+	deployment, err := models.CreateDeployment(app.ID, release.ID, models.DeploymentStatusAwaitingApproval, "", "", time.Now(), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, models.RequestDeploymentApproval(deployment.ID, requestedBy, time.Now().Add(time.Hour)))
+	return deployment
+}
+
+func TestApproveDeploymentRejectsSelfApproval(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	deployment := createAwaitingDeployment(t, "", "alice")
+
+	_, err := orchestrator.ApproveDeployment(deployment.ID, "alice", "", "")
+	assert.ErrorIs(t, err, ErrDeploymentSelfReview)
+	_, err = orchestrator.RejectDeployment(deployment.ID, "alice", "")
+	assert.ErrorIs(t, err, ErrDeploymentSelfReview)
+
+	stored, err := models.GetDeploymentByID(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeploymentStatusAwaitingApproval, stored.Status)
+}
+
+func TestReviewDeploymentForbidsTokenCreator(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	token := &models.ApplicationToken{Name: "ci", CreatedBy: "alice"}
+	deployment := createAwaitingDeployment(t, "", token.Requester())
+
+	// alice 用自己创建的令牌发起部署后，不能再用自己的账号审批
+	_, err := orchestrator.ApproveDeployment(deployment.ID, "alice", "", "")
+	assert.ErrorIs(t, err, ErrDeploymentSelfReview)
+
+	_, err = orchestrator.ApproveDeployment(deployment.ID, "bob", "", "")
+	assert.NoError(t, err)
+}
+
+func TestRequesterUsername(t *testing.T) {
+	assert.Equal(t, "alice", models.RequesterUsername("alice"))
+	assert.Equal(t, "alice", models.RequesterUsername("token:ci (alice)"))
+	assert.Equal(t, "alice", models.RequesterUsername("token:deploy (staging) (alice)"))
+	assert.Equal(t, "", models.RequesterUsername("token:ci"))
+	assert.Equal(t, "", models.RequesterUsername(""))
+}
+
+func TestReviewDeploymentRequiresConfiguredApprover(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	deployment := createAwaitingDeployment(t, "bob, carol", "alice")
+
+	_, err := orchestrator.ApproveDeployment(deployment.ID, "mallory", "", "")
+	assert.ErrorIs(t, err, ErrDeploymentReviewerNotAllowed)
+	_, err = orchestrator.RejectDeployment(deployment.ID, "mallory", "")
+	assert.ErrorIs(t, err, ErrDeploymentReviewerNotAllowed)
+
+	rejected, err := orchestrator.RejectDeployment(deployment.ID, "carol", "not now")
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeploymentStatusRejected, rejected.Status)
+	assert.Equal(t, "carol", rejected.ReviewedBy)
+}
+
+// createActiveFreezeWindow 为应用创建一个 from 到 to 的冻结窗口
+func createActiveFreezeWindow(t *testing.T, applicationID uuid.UUID, from, to time.Time) *models.DeployFreezeWindow {
+	t.Helper()
+	app, err := models.GetApplicationByID(applicationID)
+	assert.NoError(t, err)
+	window := &models.DeployFreezeWindow{
+		ProjectID:     app.ProjectID,
+		ApplicationID: &app.ID,
+		Name:          "release-freeze",
+		Window:        utils.FreezeWindow{StartsAt: &from, EndsAt: &to},
+	}
+	assert.NoError(t, models.CreateDeployFreezeWindow(window))
+	return window
+}
+
+func TestApproveDeploymentRechecksFreeze(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	deployment := createAwaitingDeployment(t, "", "alice")
+	// 部署等待审批期间才设置的冻结窗口
+	createActiveFreezeWindow(t, deployment.ApplicationID, time.Now().Add(-time.Minute), time.Now().Add(time.Hour))
+
+	_, err := orchestrator.ApproveDeployment(deployment.ID, "bob", "", "")
+	assert.ErrorIs(t, err, ErrDeploymentFrozen)
+
+	stored, err := models.GetDeploymentByID(deployment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeploymentStatusAwaitingApproval, stored.Status)
+}
+
+func TestCarriedFreezeOverrideOnlyCoversOriginalWindow(t *testing.T) {
+	setupTestDB(t)
+	deployment := createAwaitingDeployment(t, "", "alice")
+	app, err := models.GetApplicationByID(deployment.ApplicationID)
+	assert.NoError(t, err)
+	now := time.Now()
+	createActiveFreezeWindow(t, app.ID, now.Add(-time.Hour), now.Add(time.Hour))
+
+	reason, err := carriedFreezeOverride(app, now.Add(-time.Minute), now, "hotfix")
+	assert.NoError(t, err)
+	assert.Equal(t, "hotfix", reason, "同一窗口内继续适用")
+
+	reason, err = carriedFreezeOverride(app, now.Add(-2*time.Hour), now, "hotfix")
+	assert.NoError(t, err)
+	assert.Empty(t, reason, "填写原因时不在该窗口内，不能用于当前窗口")
+}
+
+func TestMissedScheduledDeploymentChecksFreezeAtFireTime(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	app, err := models.CreateApplication(uuid.New(), "scheduled-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	now := time.Now()
+	// 计划时间在冻结窗口开始之前，服务重启后补执行时已处于窗口内
+	createActiveFreezeWindow(t, app.ID, now.Add(-time.Hour), now.Add(time.Hour))
+	scheduled := &models.ScheduledDeployment{
+		ApplicationID:        app.ID,
+		ScheduledAt:          now.Add(-2 * time.Hour),
+		FreezeOverrideReason: "planned maintenance",
+	}
+	assert.NoError(t, models.CreateScheduledDeployment(scheduled))
+
+	orchestrator.startScheduledDeployment(scheduled)
+
+	stored, err := models.GetScheduledDeploymentByID(scheduled.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ScheduledDeploymentStatusFailed, stored.Status)
+	assert.Contains(t, stored.Error, ErrDeploymentFrozen.Error())
+	assert.Nil(t, stored.DeploymentID)
+}
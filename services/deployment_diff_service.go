@@ -0,0 +1,196 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// ConfigChange 部署配置中变化的一项
+type ConfigChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DeploymentDiff 同一应用两次部署之间的差异，From 为比较的起点
+type DeploymentDiff struct {
+	From        *models.Deployment
+	To          *models.Deployment
+	FromRelease *models.Release
+	ToRelease   *models.Release
+
+	// 提交范围：From 的提交之后到 To 的提交为止。Rollback 时 To 早于 From，Commits 为回滚掉的提交
+	FromCommit   string
+	ToCommit     string
+	Rollback     bool
+	Commits      []utils.ComparedCommit
+	TotalCommits int
+	CompareURL   string
+	CommitsError string // 无法通过平台 API 获取提交列表的原因
+
+	// 环境变量来自两次部署的快照，EnvSecrets 中的变量由调用方隐藏值
+	Env        utils.EnvDiff
+	EnvBefore  map[string]string
+	EnvAfter   map[string]string
+	EnvSecrets map[string]bool
+
+	// 早期的部署没有记录 Quadlet 内容和配置快照，此时对应的 Recorded 为 false
+	QuadletRecorded bool
+	Quadlet         []utils.LineDiff
+	ConfigRecorded  bool
+	Settings        []ConfigChange
+	RoutingsAdded   []models.DeploymentRoutingSnapshot
+	RoutingsRemoved []models.DeploymentRoutingSnapshot
+}
+
+// DiffDeployments 比较应用的两次部署：镜像、提交范围、环境变量、Quadlet 内容、路由和资源配置
+func (do *DeploymentOrchestrator) DiffDeployments(application *models.Application, from, to *models.Deployment) (*DeploymentDiff, error) {
+	if from.ApplicationID != application.ID || to.ApplicationID != application.ID {
+		return nil, fmt.Errorf("只能比较同一应用的部署")
+	}
+	fromRelease, err := models.GetReleaseByID(from.ReleaseID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部署 %s 的 Release 失败: %w", from.ID, err)
+	}
+	toRelease, err := models.GetReleaseByID(to.ReleaseID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部署 %s 的 Release 失败: %w", to.ID, err)
+	}
+
+	diff := &DeploymentDiff{
+		From:        from,
+		To:          to,
+		FromRelease: fromRelease,
+		ToRelease:   toRelease,
+		FromCommit:  deploymentCommitSHA(from, fromRelease),
+		ToCommit:    deploymentCommitSHA(to, toRelease),
+	}
+	do.diffCommits(application, diff)
+
+	if err := diffEnvironmentSnapshots(diff); err != nil {
+		return nil, err
+	}
+
+	if from.QuadletContent != "" && to.QuadletContent != "" {
+		diff.QuadletRecorded = true
+		diff.Quadlet = utils.DiffLines(from.QuadletContent, to.QuadletContent)
+	}
+
+	fromConfig, err := models.ParseDeploymentConfigSnapshot(from.ConfigSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("解析部署 %s 的配置快照失败: %w", from.ID, err)
+	}
+	toConfig, err := models.ParseDeploymentConfigSnapshot(to.ConfigSnapshot)
+	if err != nil {
+		return nil, fmt.Errorf("解析部署 %s 的配置快照失败: %w", to.ID, err)
+	}
+	if fromConfig != nil && toConfig != nil {
+		diff.ConfigRecorded = true
+		diffConfigSnapshots(diff, fromConfig, toConfig)
+	}
+	return diff, nil
+}
+
+// deploymentCommitSHA 返回部署的提交，早期的部署没有记录时使用 Release 构建时的提交
+func deploymentCommitSHA(deployment *models.Deployment, release *models.Release) string {
+	if deployment.CommitSHA != "" {
+		return deployment.CommitSHA
+	}
+	return releaseCommitSHA(release)
+}
+
+// diffCommits 通过代码托管平台 API 获取两次部署之间的提交，失败时只记录原因
+func (do *DeploymentOrchestrator) diffCommits(application *models.Application, diff *DeploymentDiff) {
+	if !utils.IsCommitSHA(diff.FromCommit) || !utils.IsCommitSHA(diff.ToCommit) || diff.FromCommit == diff.ToCommit {
+		return
+	}
+	base, head := diff.FromCommit, diff.ToCommit
+	if diff.To.CreatedAt.Before(diff.From.CreatedAt) {
+		diff.Rollback = true
+		base, head = head, base
+	}
+	if application.RepoURL != nil {
+		diff.CompareURL = utils.CompareURL(*application.RepoURL, base, head)
+	}
+
+	client, repoURL := do.providerClientForApplication(application)
+	if client == nil {
+		diff.CommitsError = "应用仓库没有关联代码托管平台的授权，无法获取提交列表"
+		return
+	}
+	commits, total, err := client.CompareCommits(repoURL, base, head)
+	if err != nil {
+		logman.Warn("获取提交范围失败", "app_name", application.Name, "base", base, "head", head, "error", err)
+		diff.CommitsError = err.Error()
+		return
+	}
+	diff.Commits = commits
+	diff.TotalCommits = total
+}
+
+// diffEnvironmentSnapshots 比较两次部署时的环境变量快照
+func diffEnvironmentSnapshots(diff *DeploymentDiff) error {
+	before, err := models.ParseEnvironmentSnapshot(diff.From.Snapshot)
+	if err != nil {
+		return fmt.Errorf("解析部署 %s 的环境变量快照失败: %w", diff.From.ID, err)
+	}
+	after, err := models.ParseEnvironmentSnapshot(diff.To.Snapshot)
+	if err != nil {
+		return fmt.Errorf("解析部署 %s 的环境变量快照失败: %w", diff.To.ID, err)
+	}
+
+	diff.EnvBefore = make(map[string]string, len(before))
+	diff.EnvAfter = make(map[string]string, len(after))
+	diff.EnvSecrets = make(map[string]bool)
+	for _, entry := range before {
+		diff.EnvBefore[entry.Key] = entry.Value
+		diff.EnvSecrets[entry.Key] = diff.EnvSecrets[entry.Key] || entry.IsEncrypted
+	}
+	for _, entry := range after {
+		diff.EnvAfter[entry.Key] = entry.Value
+		diff.EnvSecrets[entry.Key] = diff.EnvSecrets[entry.Key] || entry.IsEncrypted
+	}
+	diff.Env = utils.DiffEnvVars(diff.EnvBefore, diff.EnvAfter)
+	return nil
+}
+
+// diffConfigSnapshots 比较端口、资源配置和路由
+func diffConfigSnapshots(diff *DeploymentDiff, from, to *models.DeploymentConfigSnapshot) {
+	settings := []struct {
+		field    string
+		old, new string
+	}{
+		{"targetPort", strconv.Itoa(from.TargetPort), strconv.Itoa(to.TargetPort)},
+		{"cpuQuota", strconv.FormatFloat(from.Resources.CPUQuota, 'f', -1, 64), strconv.FormatFloat(to.Resources.CPUQuota, 'f', -1, 64)},
+		{"memoryLimitMB", strconv.Itoa(from.Resources.MemoryLimitMB), strconv.Itoa(to.Resources.MemoryLimitMB)},
+		{"memoryReservationMB", strconv.Itoa(from.Resources.MemoryReservationMB), strconv.Itoa(to.Resources.MemoryReservationMB)},
+		{"pidsLimit", strconv.Itoa(from.Resources.PidsLimit), strconv.Itoa(to.Resources.PidsLimit)},
+		{"oomPolicy", from.Resources.OOMPolicy, to.Resources.OOMPolicy},
+	}
+	for _, s := range settings {
+		if s.old != s.new {
+			diff.Settings = append(diff.Settings, ConfigChange{Field: s.field, Old: s.old, New: s.new})
+		}
+	}
+
+	before := make(map[models.DeploymentRoutingSnapshot]bool, len(from.Routings))
+	for _, routing := range from.Routings {
+		before[routing] = true
+	}
+	after := make(map[models.DeploymentRoutingSnapshot]bool, len(to.Routings))
+	for _, routing := range to.Routings {
+		after[routing] = true
+		if !before[routing] {
+			diff.RoutingsAdded = append(diff.RoutingsAdded, routing)
+		}
+	}
+	for _, routing := range from.Routings {
+		if !after[routing] {
+			diff.RoutingsRemoved = append(diff.RoutingsRemoved, routing)
+		}
+	}
+}
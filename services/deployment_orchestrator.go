@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
@@ -21,19 +23,25 @@ type SSELogSender func(deploymentID uuid.UUID, message string)
 
 // DeploymentOrchestrator 部署编排服务，负责协调整个部署流程
 type DeploymentOrchestrator struct {
-	buildService  *BuildService
-	envService    *DeploymentEnvironmentService
-	podmanService *PodmanService
-	sseLogSender  SSELogSender // SSE日志发送函数
+	buildService    *BuildService
+	envService      *DeploymentEnvironmentService
+	podmanService   *PodmanService
+	hostnameService *AppHostnameService
+	sseLogSender    SSELogSender // SSE日志发送函数
+
+	canaryMu       sync.Mutex         // 串行化金丝雀发布的启动、调整、promote 和 abort
+	canaryMonitors map[uuid.UUID]bool // 正在运行健康监控的金丝雀发布
 }
 
 // NewDeploymentOrchestrator 创建新的部署编排服务实例
 func NewDeploymentOrchestrator(buildService *BuildService, envService *DeploymentEnvironmentService, podmanService *PodmanService) *DeploymentOrchestrator {
 	return &DeploymentOrchestrator{
-		buildService:  buildService,
-		envService:    envService,
-		podmanService: podmanService,
-		sseLogSender:  nil, // 将在后续设置
+		buildService:    buildService,
+		envService:      envService,
+		podmanService:   podmanService,
+		hostnameService: NewAppHostnameService(),
+		sseLogSender:    nil, // 将在后续设置
+		canaryMonitors:  make(map[uuid.UUID]bool),
 	}
 }
 
@@ -44,13 +52,18 @@ func (do *DeploymentOrchestrator) SetSSELogSender(sender SSELogSender) {
 
 // sendDeploymentLog 发送部署日志到数据库和SSE客户端
 func (do *DeploymentOrchestrator) sendDeploymentLog(deploymentID uuid.UUID, message string) {
+	do.sendDeploymentLogFrom(deploymentID, message, "SYSTEM")
+}
+
+// sendDeploymentLogFrom 发送指定来源（SYSTEM、BUILD 等）的部署日志
+func (do *DeploymentOrchestrator) sendDeploymentLogFrom(deploymentID uuid.UUID, message string, source string) {
 	// 发送到SSE客户端（实时）
 	if do.sseLogSender != nil {
 		do.sseLogSender(deploymentID, message)
 	}
 
 	// 保存到数据库
-	do.AppendLogToDB(deploymentID, message, "INFO", "SYSTEM")
+	do.AppendLogToDB(deploymentID, message, "INFO", source)
 
 	// 记录到日志
 	logman.Info("部署日志", "deployment_id", deploymentID, "message", message)
@@ -78,6 +91,18 @@ func (do *DeploymentOrchestrator) updateDeploymentLogInDB(deploymentID uuid.UUID
 // CreateDeploymentRequest 创建部署请求结构
 type CreateDeploymentRequest struct {
 	ReleaseID *uuid.UUID `json:"releaseId"` // 如果为nil，表示需要重新构建
+	GitRef    string     `json:"gitRef"`    // 重新构建时检出的分支、标签或提交 SHA，为空时使用应用分支的最新提交
+
+	// 部署策略：为空或 direct 时直接切换；canary 时新旧版本同时运行，按权重逐步切换流量
+	Strategy           string  `json:"strategy"`
+	CanaryWeight       int     `json:"canaryWeight"`       // 新版本初始流量百分比，默认 10
+	CanaryStep         int     `json:"canaryStep"`         // 每次 step 增加的百分比，默认 10
+	CanaryMaxErrorRate float64 `json:"canaryMaxErrorRate"` // 探测 CanaryHealthPath 时新版本错误率超过旧版本的百分点阈值，默认 5
+	CanaryHealthPath   string  `json:"canaryHealthPath"`   // 健康探测路径，默认 "/"
+
+	RequestedBy string `json:"-"` // 发起部署的用户或应用令牌，由 handler 根据认证信息填写，受保护应用的审批记录使用
+
+	FreezeOverrideReason string `json:"freezeOverrideReason"` // 处于冻结窗口时强制部署的原因，为空时拒绝部署
 }
 
 // CreateDeployment 创建部署并启动异步部署流程
@@ -92,6 +117,31 @@ func (do *DeploymentOrchestrator) CreateDeployment(appID uuid.UUID, req CreateDe
 	}
 	logman.Info("获取应用信息成功", "app_name", application.Name)
 
+	// 冻结窗口内只有填写了原因的部署可以继续
+	frozenBy, err := checkDeployFreeze(application, time.Now(), req.FreezeOverrideReason)
+	if err != nil {
+		return nil, err
+	}
+
+	// 进行中的金丝雀发布需要先 promote 或 abort
+	if active, err := models.GetActiveCanaryReleaseByAppID(appID); err != nil {
+		return nil, fmt.Errorf("查询金丝雀发布失败: %w", err)
+	} else if active != nil {
+		return nil, fmt.Errorf("应用有进行中的金丝雀发布，请先 promote 或 abort")
+	}
+
+	var canary *models.CanaryRelease
+	switch req.Strategy {
+	case "", StrategyDirect:
+	case StrategyCanary:
+		canary, err = do.prepareCanary(application, req)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的部署策略: %s", req.Strategy)
+	}
+
 	// 2. 确定要部署的 Release
 	var releaseID uuid.UUID
 	var needsBuild bool
@@ -104,7 +154,7 @@ func (do *DeploymentOrchestrator) CreateDeployment(appID uuid.UUID, req CreateDe
 	} else {
 		// 需要重新构建版本 - 只创建 Release 记录，不执行构建
 		logman.Info("创建新的构建版本记录", "app_id", appID)
-		release, err := do.createNewReleaseRecord(application)
+		release, err := do.createNewReleaseRecord(application, strings.TrimSpace(req.GitRef))
 		if err != nil {
 			logman.Error("创建新版本记录失败", "app_id", appID, "error", err)
 			return nil, fmt.Errorf("创建新版本记录失败: %w", err)
@@ -119,6 +169,12 @@ func (do *DeploymentOrchestrator) CreateDeployment(appID uuid.UUID, req CreateDe
 	if needsBuild {
 		initialLogText = "开始构建 Release...\n"
 	}
+	status := models.DeploymentStatusInProgress
+	if application.Approval.Protected {
+		// 受保护应用的部署先等待审批，批准后再构建和部署
+		status = models.DeploymentStatusAwaitingApproval
+		initialLogText = "应用受保护，等待审批...\n"
+	}
 
 	version := time.Now().Format("060102150405")
 	serviceName := application.Name + "-" + version + ".service"
@@ -132,7 +188,7 @@ func (do *DeploymentOrchestrator) CreateDeployment(appID uuid.UUID, req CreateDe
 	deployment, err := models.CreateDeployment(
 		appID,
 		releaseID,
-		"in_progress",
+		status,
 		initialLogText,
 		serviceName,
 		time.Now(),
@@ -144,6 +200,27 @@ func (do *DeploymentOrchestrator) CreateDeployment(appID uuid.UUID, req CreateDe
 	}
 	logman.Info("部署记录创建成功", "deployment_id", deployment.ID)
 
+	if frozenBy != nil {
+		do.recordFreezeOverride(application, deployment, frozenBy, req)
+	}
+
+	if canary != nil {
+		canary.DeploymentID = deployment.ID
+		if err := models.CreateCanaryRelease(canary); err != nil {
+			do.updateDeploymentFailed(deployment, "创建金丝雀发布记录失败: "+err.Error())
+			return nil, fmt.Errorf("创建金丝雀发布记录失败: %w", err)
+		}
+		logman.Info("金丝雀发布记录创建成功", "deployment_id", deployment.ID, "weight", canary.Weight)
+	}
+
+	if application.Approval.Protected {
+		if err := do.requestApproval(application, deployment, req.RequestedBy); err != nil {
+			do.updateDeploymentFailed(deployment, "记录审批请求失败: "+err.Error())
+			return nil, fmt.Errorf("记录审批请求失败: %w", err)
+		}
+		return deployment, nil
+	}
+
 	// 4. 启动异步构建+部署流程
 	if needsBuild {
 		logman.Info("启动异步构建+部署流程", "deployment_id", deployment.ID)
@@ -156,8 +233,8 @@ func (do *DeploymentOrchestrator) CreateDeployment(appID uuid.UUID, req CreateDe
 	return deployment, nil
 }
 
-// createNewReleaseRecord 创建新的发布版本记录（不执行构建）
-func (do *DeploymentOrchestrator) createNewReleaseRecord(application *models.Application) (*models.Release, error) {
+// createNewReleaseRecord 创建新的发布版本记录（不执行构建），提交信息在构建完成后写入
+func (do *DeploymentOrchestrator) createNewReleaseRecord(application *models.Application, gitRef string) (*models.Release, error) {
 	// 1. 获取项目信息
 	_, err := models.GetProjectByID(application.ProjectID)
 	if err != nil {
@@ -165,17 +242,13 @@ func (do *DeploymentOrchestrator) createNewReleaseRecord(application *models.App
 	}
 
 	// 2. 创建 Release 记录（初始状态为 building）
-	buildSourceInfo := models.JSONB{
-		Data: map[string]interface{}{
-			"branch": func() string {
-				if application.Branch != nil && *application.Branch != "" {
-					return *application.Branch
-				}
-				return "master" // 默认分支
-			}(),
-			"commit_sha": "latest", // 这里可以后续改进为获取实际的 commit SHA
-		},
+	sourceInfo := map[string]interface{}{}
+	if gitRef != "" {
+		sourceInfo["ref"] = gitRef
+	} else if application.Branch != nil && *application.Branch != "" {
+		sourceInfo["branch"] = *application.Branch
 	}
+	buildSourceInfo := models.JSONB{Data: sourceInfo}
 
 	release, err := models.CreateRelease(
 		application.ID,
@@ -191,33 +264,81 @@ func (do *DeploymentOrchestrator) createNewReleaseRecord(application *models.App
 	return release, nil
 }
 
-// buildRelease 执行指定 Release 的构建过程
-func (do *DeploymentOrchestrator) buildRelease(release *models.Release, application *models.Application) error {
-	// 1. 执行构建
+// buildRelease 执行指定 Release 的构建过程，构建输出实时写入部署日志并完整保存在 Release 上
+func (do *DeploymentOrchestrator) buildRelease(release *models.Release, application *models.Application, deploymentID uuid.UUID) error {
+	// 使用镜像仓库来源的应用不构建，只把 Release 固定到镜像当前的摘要
+	if application.RegistryImage.ImageRef != "" {
+		return do.resolveRegistryRelease(release, application, deploymentID)
+	}
+
+	sourceInfo, _ := release.BuildSourceInfo.Data.(map[string]interface{})
+	if sourceInfo == nil {
+		sourceInfo = map[string]interface{}{}
+	}
+	ref, _ := sourceInfo["ref"].(string)
+
+	// 1. 执行构建，构建目录、Dockerfile 和构建参数取自应用配置
+	var buildLog strings.Builder
 	buildReq := BuildFromApplicationRequest{
 		ApplicationID: application.ID,
-		Dockerfile:    "Dockerfile",
-		ContextPath:   ".",
+		Ref:           ref,
 		BuildArgs:     make(map[string]string),
+		Logger: func(line string) {
+			buildLog.WriteString(line)
+			buildLog.WriteByte('\n')
+			do.sendDeploymentLogFrom(deploymentID, line, "BUILD")
+		},
+		OnCommit: func(commit *utils.GitCommitInfo) {
+			do.reportDeploymentStarted(deploymentID, application, commit.SHA)
+		},
 	}
 
-	imageName, err := do.buildService.BuildImageFromApplication(buildReq)
+	start := time.Now()
+	result, err := do.buildService.BuildImageFromApplication(buildReq)
+	do.sendDeploymentLogFrom(deploymentID, fmt.Sprintf("构建总耗时 %s", time.Since(start).Round(time.Second)), "BUILD")
+	if saveErr := models.UpdateReleaseBuildLog(release.ID, buildLog.String()); saveErr != nil {
+		logman.Error("保存构建日志失败", "release_id", release.ID, "error", saveErr)
+	}
 	if err != nil {
 		// 更新 Release 状态为失败
 		models.UpdateRelease(release.ID, "", release.BuildSourceInfo, "failed")
 		return fmt.Errorf("构建镜像失败: %w", err)
 	}
 
-	// 2. 更新 Release 状态为成功
-	_, err = models.UpdateRelease(release.ID, imageName, release.BuildSourceInfo, "success")
+	// 2. 记录实际构建的提交，并更新 Release 状态为成功
+	release.BuildSourceInfo = models.JSONB{Data: buildSourceInfoWithCommit(sourceInfo, result)}
+	_, err = models.UpdateRelease(release.ID, result.ImageName, release.BuildSourceInfo, "success")
 	if err != nil {
 		return fmt.Errorf("更新 Release 状态失败: %w", err)
 	}
 
-	logman.Info("构建完成", "release_id", release.ID, "image_name", imageName)
+	logman.Info("构建完成", "release_id", release.ID, "image_name", result.ImageName, "commit", result.Commit.SHA)
 	return nil
 }
 
+// buildSourceInfoWithCommit 将构建结果中的提交信息合并到 Release 的构建源信息中
+func buildSourceInfoWithCommit(sourceInfo map[string]interface{}, result *BuildResult) map[string]interface{} {
+	info := make(map[string]interface{}, len(sourceInfo)+8)
+	for k, v := range sourceInfo {
+		info[k] = v
+	}
+	if result.Branch != "" {
+		info["branch"] = result.Branch
+	}
+	info["ref"] = result.Ref
+	info["repo_url"] = result.RepoURL
+	info["commit_sha"] = result.Commit.SHA
+	info["commit_short_sha"] = result.Commit.ShortSHA()
+	info["commit_message"] = result.Commit.Message
+	info["commit_author"] = result.Commit.AuthorName
+	info["commit_author_email"] = result.Commit.AuthorEmail
+	info["commit_timestamp"] = result.Commit.Timestamp.Format(time.RFC3339)
+	if url := utils.CommitURL(result.RepoURL, result.Commit.SHA); url != "" {
+		info["commit_url"] = url
+	}
+	return info
+}
+
 // startBuildAndDeploymentAsync 异步执行构建+部署流程
 func (do *DeploymentOrchestrator) startBuildAndDeploymentAsync(deploymentID uuid.UUID) {
 	// 获取部署记录
@@ -253,7 +374,7 @@ func (do *DeploymentOrchestrator) startBuildAndDeploymentAsync(deploymentID uuid
 		do.updateDeploymentLogInDB(deploymentID, buildStartMsg)
 
 		// 执行构建
-		if err := do.buildRelease(release, application); err != nil {
+		if err := do.buildRelease(release, application, deploymentID); err != nil {
 			logman.Error("构建失败", "deployment_id", deploymentID, "error", err)
 			do.updateDeploymentFailed(deployment, "构建失败: "+err.Error())
 			return
@@ -278,6 +399,7 @@ func (do *DeploymentOrchestrator) startBuildAndDeploymentAsync(deploymentID uuid
 		readyMsg := "Release 已就绪，开始部署..."
 		do.sendDeploymentLog(deploymentID, readyMsg)
 		do.updateDeploymentLogInDB(deploymentID, readyMsg)
+		do.reportDeploymentStarted(deploymentID, application, releaseCommitSHA(release))
 	default:
 		logman.Error("Release 状态无效", "deployment_id", deploymentID, "release_id", release.ID, "status", release.Status)
 		do.updateDeploymentFailed(deployment, "Release 状态无效: "+release.Status)
@@ -301,7 +423,7 @@ func (do *DeploymentOrchestrator) startBuildAndDeploymentAsync(deploymentID uuid
 
 	// 部署成功
 	now := time.Now()
-	successMsg := "部署成功，应用已启动。"
+	status, successMsg := do.deploymentSucceededStatus(deploymentID)
 	do.sendDeploymentLog(deploymentID, successMsg)
 
 	// 重新获取部署记录以确保有最新的日志
@@ -313,13 +435,14 @@ func (do *DeploymentOrchestrator) startBuildAndDeploymentAsync(deploymentID uuid
 
 	_, err = models.UpdateDeployment(
 		deployment.ID,
-		"success",
+		status,
 		deployment.LogText+successMsg+"\n",
 		&now,
 	)
 	if err != nil {
 		logman.Error("更新部署状态失败", "deployment_id", deploymentID, "error", err)
 	}
+	do.reportDeploymentStatus(deploymentID, status, successMsg)
 
 	logman.Info("构建+部署完成", "deployment_id", deploymentID)
 }
@@ -359,7 +482,7 @@ func (do *DeploymentOrchestrator) startDeploymentAsync(deploymentID uuid.UUID) {
 		do.updateDeploymentLogInDB(deploymentID, waitingMsg)
 
 		// 执行构建
-		if err := do.buildRelease(release, application); err != nil {
+		if err := do.buildRelease(release, application, deploymentID); err != nil {
 			logman.Error("构建失败", "deployment_id", deploymentID, "error", err)
 			do.updateDeploymentFailed(deployment, "构建失败: "+err.Error())
 			return
@@ -378,7 +501,7 @@ func (do *DeploymentOrchestrator) startDeploymentAsync(deploymentID uuid.UUID) {
 		do.sendDeploymentLog(deploymentID, deployStartMsg)
 		do.updateDeploymentLogInDB(deploymentID, deployStartMsg)
 	case "success":
-		// No additional action needed for success case in this function
+		do.reportDeploymentStarted(deploymentID, application, releaseCommitSHA(release))
 	default:
 		logman.Error("Release 状态无效", "deployment_id", deploymentID, "release_id", release.ID, "status", release.Status)
 		do.updateDeploymentFailed(deployment, "Release 状态无效: "+release.Status)
@@ -402,7 +525,7 @@ func (do *DeploymentOrchestrator) startDeploymentAsync(deploymentID uuid.UUID) {
 
 	// 部署成功
 	now := time.Now()
-	successMsg := "部署成功，应用已启动。"
+	status, successMsg := do.deploymentSucceededStatus(deploymentID)
 	do.sendDeploymentLog(deploymentID, successMsg)
 
 	// 重新获取部署记录以确保有最新的日志
@@ -414,13 +537,14 @@ func (do *DeploymentOrchestrator) startDeploymentAsync(deploymentID uuid.UUID) {
 
 	_, err = models.UpdateDeployment(
 		deployment.ID,
-		"success",
+		status,
 		deployment.LogText+successMsg+"\n",
 		&now,
 	)
 	if err != nil {
 		logman.Error("更新部署状态失败", "deployment_id", deploymentID, "error", err)
 	}
+	do.reportDeploymentStatus(deploymentID, status, successMsg)
 
 	logman.Info("部署完成", "deployment_id", deploymentID)
 }
@@ -429,6 +553,17 @@ func (do *DeploymentOrchestrator) startDeploymentAsync(deploymentID uuid.UUID) {
 func (do *DeploymentOrchestrator) executeDeployment(deployment *models.Deployment, application *models.Application, release *models.Release) error {
 	logman.Info("开始执行部署", "deployment_id", deployment.ID, "app_name", application.Name)
 
+	// 0. 来自镜像仓库的 Release 先以项目用户拉取固定摘要的镜像
+	if isRegistryRelease(release) {
+		project, err := models.GetProjectByID(application.ProjectID)
+		if err != nil {
+			return fmt.Errorf("获取项目信息失败: %w, deployment_id: %s", err, deployment.ID)
+		}
+		if err := do.pullRegistryImage(deployment.ID, application, release, project); err != nil {
+			return fmt.Errorf("%w, deployment_id: %s", err, deployment.ID)
+		}
+	}
+
 	// 1. 生成运行时文件
 	project, err := do.generateRuntimeFiles(deployment, application, release)
 	if err != nil {
@@ -440,14 +575,53 @@ func (do *DeploymentOrchestrator) executeDeployment(deployment *models.Deploymen
 		return fmt.Errorf("系统部署失败: %w, deployment_id: %s", err, deployment.ID)
 	}
 
+	// 金丝雀发布：保留旧版本，按权重把部分流量切到新版本，等待 promote 或 abort
+	canary, err := models.GetCanaryReleaseByDeploymentID(deployment.ID)
+	if err != nil {
+		return fmt.Errorf("查询金丝雀发布失败: %w, deployment_id: %s", err, deployment.ID)
+	}
+	if canary != nil {
+		if err := do.startCanary(canary, project); err != nil {
+			return fmt.Errorf("启动金丝雀发布失败: %w, deployment_id: %s", err, deployment.ID)
+		}
+		return nil
+	}
+
 	// 3. 更新应用的当前发布版本（原子化切换）
 	if err := do.updateActiveRelease(application, release); err != nil {
 		return fmt.Errorf("更新活跃发布版本失败: %w, deployment_id: %s", err, deployment.ID)
 	}
 
+	// 4. 维护基于基础域名的自动子域名（失败不影响部署结果）
+	do.ensureAppHostname(deployment.ID, application)
+
 	return nil
 }
 
+// ensureAppHostname 为应用生成或更新自动子域名路由
+func (do *DeploymentOrchestrator) ensureAppHostname(deploymentID uuid.UUID, application *models.Application) {
+	deployment, err := models.GetDeploymentByID(deploymentID)
+	if err != nil {
+		logman.Warn("获取部署记录失败，跳过自动子域名", "deployment_id", deploymentID, "error", err)
+		return
+	}
+
+	systemPort := 0
+	if deployment.SystemPort != nil {
+		systemPort = *deployment.SystemPort
+	}
+
+	hostname, err := do.hostnameService.EnsureAppHostname(application, systemPort)
+	if err != nil {
+		logman.Warn("配置自动子域名失败", "app_name", application.Name, "error", err)
+		do.sendDeploymentLog(deploymentID, "配置自动子域名失败: "+err.Error())
+		return
+	}
+	if hostname != "" {
+		do.sendDeploymentLog(deploymentID, "应用访问地址: https://"+hostname)
+	}
+}
+
 // generateRuntimeFiles 生成运行时配置文件
 func (do *DeploymentOrchestrator) generateRuntimeFiles(deployment *models.Deployment, application *models.Application, release *models.Release) (*models.Project, error) {
 	logman.Info("生成运行时配置文件", "app_name", application.Name)
@@ -474,12 +648,17 @@ func (do *DeploymentOrchestrator) generateRuntimeFiles(deployment *models.Deploy
 	envFilePath := do.envService.GenerateProjectEnvPath(project.HomeDir, application.Name)
 	fmt.Println("环境文件路径", envFilePath)
 	// 3. 生成 Quadlet 文件内容
-	quadletContent, err := do.generateQuadletContent(application, release, routings, envFilePath)
+	quadletContent, err := do.generateQuadletContent(deployment, application, release, routings, envFilePath)
 	if err != nil {
 		return nil, fmt.Errorf("生成 Quadlet 内容失败: %w, deployment_id: %s", err, deployment.ID)
 	}
 	fmt.Println("生成 Quadlet 内容成功", quadletContent)
 
+	// 记录 Quadlet 内容和配置快照，用于比较两次部署
+	if err := models.UpdateDeploymentRuntimeSnapshot(deployment.ID, quadletContent, models.NewDeploymentConfigSnapshot(application, routings)); err != nil {
+		logman.Warn("记录部署配置快照失败", "deployment_id", deployment.ID, "error", err)
+	}
+
 	// 4. Use the already generated environment content
 
 	// 5. 写入文件到系统
@@ -491,7 +670,7 @@ func (do *DeploymentOrchestrator) generateRuntimeFiles(deployment *models.Deploy
 }
 
 // generateQuadletContent 生成 Quadlet 配置内容
-func (do *DeploymentOrchestrator) generateQuadletContent(application *models.Application, release *models.Release, routings []*models.Routing, envFilePath string) (string, error) {
+func (do *DeploymentOrchestrator) generateQuadletContent(deployment *models.Deployment, application *models.Application, release *models.Release, routings []*models.Routing, envFilePath string) (string, error) {
 	// 使用现有的 quadlet 生成逻辑，参考 services/quadlet_service.go
 	data := QuadletData{
 		Description:      application.Description,
@@ -501,6 +680,8 @@ func (do *DeploymentOrchestrator) generateQuadletContent(application *models.App
 		Volumes:          []string{},
 		ExecCommand:      "",
 		AutoUpdatePolicy: "",
+		PodmanArgs:       application.Resources.PodmanArgs(),
+		ServiceSettings:  application.Resources.ServiceSettings(),
 	}
 
 	// 每次都生成系统端口（不管是否有路由配置）
@@ -512,24 +693,17 @@ func (do *DeploymentOrchestrator) generateQuadletContent(application *models.App
 	publishPort := fmt.Sprintf("%d:%d", systemPort, application.TargetPort)
 	data.PublishPorts = append(data.PublishPorts, publishPort)
 
-	// 更新 Release 的 SystemPort 字段
-	if err := models.UpdateDeploymentSystemPort(release.ID, systemPort); err != nil {
-		logman.Warn("更新 Release SystemPort 失败", "release_id", release.ID, "error", err)
-	}
-
-	// 设置卷挂载
-	if application.Volumes.Data != nil {
-		if volumes, ok := application.Volumes.Data.([]interface{}); ok {
-			for _, v := range volumes {
-				if vol, ok := v.(map[string]interface{}); ok {
-					hostPath, hok := vol["host_path"].(string)
-					containerPath, cok := vol["container_path"].(string)
-					if hok && cok {
-						data.Volumes = append(data.Volumes, hostPath+":"+containerPath)
-					}
-				}
-			}
+	// 更新 Deployment 的 SystemPort 字段
+	if err := models.UpdateDeploymentSystemPort(deployment.ID, systemPort); err != nil {
+		logman.Warn("更新 Deployment SystemPort 失败", "deployment_id", deployment.ID, "error", err)
+	}
+
+	// 设置卷挂载，写入 Quadlet 前再次校验，防止早期保存的非法值注入其他配置项
+	for _, mount := range models.ParseVolumeMounts(application.Volumes) {
+		if err := utils.ValidateVolumeMount(mount.HostPath, mount.ContainerPath); err != nil {
+			return "", err
 		}
+		data.Volumes = append(data.Volumes, mount.QuadletValue())
 	}
 
 	// 设置执行命令
@@ -609,6 +783,19 @@ Image=%s`
 	// 添加环境文件
 	content += fmt.Sprintf("\nEnvironmentFile=%s", data.EnvFilePath)
 
+	// 添加资源限制
+	if len(data.PodmanArgs) > 0 {
+		content += fmt.Sprintf("\nPodmanArgs=%s", strings.Join(data.PodmanArgs, " "))
+	}
+
+	// 添加 Service 段
+	if len(data.ServiceSettings) > 0 {
+		content += "\n\n[Service]"
+		for _, setting := range data.ServiceSettings {
+			content += "\n" + setting
+		}
+	}
+
 	// 添加 Install 段
 	content += "\n\n[Install]\nWantedBy=default.target"
 
@@ -618,15 +805,7 @@ Image=%s`
 // writeRuntimeFiles 写入运行时配置文件
 func (do *DeploymentOrchestrator) writeRuntimeFiles(appName, version, quadletContent, envContent, envFilePath string, project *models.Project) error {
 	// 1. 创建项目专属的 systemd 目录
-	var systemdDir string
-	if project.HomeDir != "" {
-		// 使用项目的 HomeDir 创建 .config/containers/systemd 目录
-		systemdDir = filepath.Join(project.HomeDir, ".config", "containers", "systemd")
-	} else {
-		// 回退到系统目录
-		systemdDir = "/usr/share/containers/systemd"
-	}
-
+	systemdDir := quadletDir(project)
 	if err := os.MkdirAll(systemdDir, 0755); err != nil {
 		return fmt.Errorf("创建 systemd 目录失败: %w", err)
 	}
@@ -646,6 +825,14 @@ func (do *DeploymentOrchestrator) writeRuntimeFiles(appName, version, quadletCon
 	return nil
 }
 
+// quadletDir 返回项目的 Quadlet 文件目录：有 HomeDir 时使用项目用户的 .config/containers/systemd，否则回退到系统目录
+func quadletDir(project *models.Project) string {
+	if project.HomeDir != "" {
+		return filepath.Join(project.HomeDir, ".config", "containers", "systemd")
+	}
+	return "/usr/share/containers/systemd"
+}
+
 // deployToSystem 执行系统级部署操作
 func (do *DeploymentOrchestrator) deployToSystem(application *models.Application, serviceName string, project *models.Project) error {
 	logman.Info("开始系统级部署", "app_name", application.Name)
@@ -801,6 +988,14 @@ func (do *DeploymentOrchestrator) updateDeploymentFailed(deployment *models.Depl
 	if err != nil {
 		logman.Error("更新部署失败状态失败", "deployment_id", latestDeployment.ID, "error", err)
 	}
+	do.reportDeploymentStatus(latestDeployment.ID, "failed", errorMsg)
+
+	// 金丝雀发布在新版本启动前失败时一并中止
+	if canary, err := models.GetCanaryReleaseByDeploymentID(latestDeployment.ID); err == nil && canary != nil && canary.Status == models.CanaryStatusPending {
+		if err := models.FinishCanaryRelease(canary.ID, models.CanaryStatusAborted, errorMsg); err != nil {
+			logman.Error("更新金丝雀发布状态失败", "canary_id", canary.ID, "error", err)
+		}
+	}
 
 	logman.Info("部署状态已更新为失败", "deployment_id", latestDeployment.ID, "error_msg", errorMsg)
 }
@@ -891,6 +1086,29 @@ func (do *DeploymentOrchestrator) startUserService(serviceName string, project *
 	return nil
 }
 
+// stopUserService 停止用户模式服务
+func (do *DeploymentOrchestrator) stopUserService(serviceName string, project *models.Project) error {
+	if project.Username == "" {
+		// 回退到系统模式
+		return do.stopOldService(serviceName)
+	}
+
+	logman.Info("停止用户模式服务", "service", serviceName, "username", project.Username)
+
+	cmd := fmt.Sprintf("su - %s -c 'systemctl --user stop %s'", project.Username, serviceName)
+	_, err := command.Exec(&command.ExecPayload{
+		Content:     cmd,
+		CommandType: "SHELL",
+		Timeout:     30,
+	})
+	if err != nil {
+		return fmt.Errorf("停止用户模式服务失败: %w", err)
+	}
+
+	logman.Info("用户模式服务已停止", "service", serviceName, "username", project.Username)
+	return nil
+}
+
 // checkUserServiceHealth 检查用户模式服务健康状态
 func (do *DeploymentOrchestrator) checkUserServiceHealth(serviceName string, project *models.Project) error {
 	if project.Username == "" {
@@ -2,6 +2,8 @@ package services
 
 import (
 	"testing"
+
+	"github.com/google/uuid"
 )
 
 // TestDeploymentOrchestratorSSELogSender tests that the SSE log sender is properly set and called
@@ -19,14 +21,14 @@ func TestDeploymentOrchestratorSSELogSender(t *testing.T) {
 
 	// Track calls to SSE sender
 	var calledWith []struct {
-		deploymentID uint
+		deploymentID uuid.UUID
 		message      string
 	}
 
 	// Set up mock SSE sender
-	mockSender := func(deploymentID uint, message string) {
+	mockSender := func(deploymentID uuid.UUID, message string) {
 		calledWith = append(calledWith, struct {
-			deploymentID uint
+			deploymentID uuid.UUID
 			message      string
 		}{deploymentID, message})
 	}
@@ -39,7 +41,7 @@ func TestDeploymentOrchestratorSSELogSender(t *testing.T) {
 	}
 
 	// Test sendDeploymentLog function
-	testDeploymentID := uint(123)
+	testDeploymentID := uuid.New()
 	testMessage := "测试部署日志消息"
 
 	orchestrator.sendDeploymentLog(testDeploymentID, testMessage)
@@ -68,5 +70,5 @@ func TestDeploymentOrchestratorLogHelperHandlesNilSender(t *testing.T) {
 	orchestrator := NewDeploymentOrchestrator(buildService, envService, podmanService)
 
 	// This should not panic
-	orchestrator.sendDeploymentLog(uint(123), "Test message")
+	orchestrator.sendDeploymentLog(uuid.New(), "Test message")
 }
\ No newline at end of file
@@ -150,12 +150,12 @@ func (dbqs *DockerBuildQueueService) processDockerBuildTask(task *models.DockerB
 		BuildArgs:     payload.BuildArgs,
 	}
 
-	imageName, err := dbqs.buildService.BuildImageFromApplication(buildReq)
+	result, err := dbqs.buildService.BuildImageFromApplication(buildReq)
 	if err != nil {
 		return fmt.Errorf("docker构建失败: %w", err)
 	}
 
-	log.Printf("Docker镜像构建完成 %s, 镜像名称: %s\n", app.Name, imageName)
+	log.Printf("Docker镜像构建完成 %s, 镜像名称: %s, 提交: %s\n", app.Name, result.ImageName, result.Commit.ShortSHA())
 	return nil
 }
 
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+)
+
+const (
+	// ServerPublicIPsSettingKey 系统设置中服务器公网地址的键，逗号分隔；为空时从网卡自动检测
+	ServerPublicIPsSettingKey = "server_public_ips"
+	// DomainVerificationRequiredSettingKey 为 "true" 时，添加路由前必须通过 TXT 记录验证域名所有权
+	DomainVerificationRequiredSettingKey = "domain_verification_required"
+
+	dnsLookupTimeout = 5 * time.Second
+)
+
+// DomainPreflightResult 域名 DNS 预检结果
+type DomainPreflightResult struct {
+	Domain               string
+	Status               string
+	Message              string
+	CNAME                string
+	ResolvedAddresses    []string
+	ServerAddresses      []string
+	VerificationRequired bool
+	VerificationRecord   string // TXT 记录名
+	VerificationToken    string // TXT 记录值
+	OwnershipVerified    bool
+}
+
+// PreflightDomain 解析域名的 A/AAAA/CNAME 记录并与服务器公网地址比较，
+// 同时检查 TXT 所有权验证记录，给出证书能否签发的提示
+func PreflightDomain(domain string) (*DomainPreflightResult, error) {
+	cleanDomain, err := utils.NormalizeDomain(domain)
+	if err != nil {
+		return nil, fmt.Errorf("无效的域名格式: %v", err)
+	}
+
+	serverAddresses, err := GetServerPublicAddresses()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DomainPreflightResult{
+		Domain:               cleanDomain,
+		ServerAddresses:      serverAddresses,
+		VerificationRequired: isDomainVerificationRequired(cleanDomain),
+		VerificationRecord:   utils.DomainVerificationRecordName(cleanDomain),
+		VerificationToken:    utils.DomainVerificationToken(cleanDomain),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+
+	records, lookupErr := utils.LookupDomainDNS(ctx, nil, cleanDomain)
+	if records != nil {
+		result.CNAME = records.CNAME
+		result.ResolvedAddresses = records.Addresses
+		result.OwnershipVerified = utils.HasVerificationToken(records.TXTRecords, cleanDomain)
+	}
+
+	switch {
+	case result.VerificationRequired && !result.OwnershipVerified:
+		result.Status = models.RoutingDNSStatusUnverified
+		result.Message = fmt.Sprintf("未找到所有权验证记录，请添加 TXT 记录 %s，值为 %s", result.VerificationRecord, result.VerificationToken)
+	case lookupErr != nil || len(result.ResolvedAddresses) == 0:
+		result.Status = models.RoutingDNSStatusUnresolved
+		result.Message = fmt.Sprintf("域名 %s 没有 A/AAAA 记录，证书将无法签发", cleanDomain)
+		if len(serverAddresses) > 0 {
+			result.Message += fmt.Sprintf("，请将其解析到 %s", strings.Join(serverAddresses, ", "))
+		}
+	case len(serverAddresses) == 0:
+		result.Status = models.RoutingDNSStatusUnknown
+		result.Message = fmt.Sprintf("无法确定服务器公网地址，请在系统设置 %s 中配置后重新检查", ServerPublicIPsSettingKey)
+	case len(utils.MatchAddresses(result.ResolvedAddresses, serverAddresses)) == 0:
+		result.Status = models.RoutingDNSStatusMismatch
+		result.Message = fmt.Sprintf("域名解析到 %s，而服务器地址为 %s；如未使用 CDN 代理，HTTP/TLS-ALPN 挑战将失败",
+			strings.Join(result.ResolvedAddresses, ", "), strings.Join(serverAddresses, ", "))
+	default:
+		result.Status = models.RoutingDNSStatusVerified
+		result.Message = "域名已正确解析到本服务器"
+	}
+
+	return result, nil
+}
+
+// VerifyRoutingDNS 对路由域名执行预检并保存状态
+func VerifyRoutingDNS(routing *models.Routing) (*DomainPreflightResult, error) {
+	result, err := PreflightDomain(routing.DomainName)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveRoutingDNSResult(routing, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// saveRoutingDNSResult 保存已完成的预检结果，添加或修改路由时复用校验阶段的结果
+func saveRoutingDNSResult(routing *models.Routing, result *DomainPreflightResult) error {
+	checkedAt := time.Now()
+	if err := models.UpdateRoutingDNSStatus(routing.ID, result.Status, result.Message, checkedAt); err != nil {
+		return fmt.Errorf("保存 DNS 检查结果失败: %v", err)
+	}
+	routing.DNSStatus = result.Status
+	routing.DNSStatusMessage = result.Message
+	routing.DNSCheckedAt = &checkedAt
+
+	fmt.Printf("🔎 [DNS 预检] %s: %s (%s)\n", routing.DomainName, result.Status, result.Message)
+	return nil
+}
+
+// GetServerPublicAddresses 返回服务器公网地址：优先使用系统设置，否则从网卡检测
+func GetServerPublicAddresses() ([]string, error) {
+	configured, err := models.GetSystemSetting(ServerPublicIPsSettingKey)
+	if err != nil {
+		return nil, fmt.Errorf("获取服务器公网地址失败: %v", err)
+	}
+	if strings.TrimSpace(configured) != "" {
+		addresses, err := utils.ParseIPList(configured)
+		if err != nil {
+			log.Printf("Invalid %s setting: %v", ServerPublicIPsSettingKey, err)
+		} else {
+			return addresses, nil
+		}
+	}
+	return utils.DetectPublicAddresses(), nil
+}
+
+// UpdateServerPublicAddresses 校验并保存服务器公网地址，返回规范化后的值
+func UpdateServerPublicAddresses(raw string) (string, error) {
+	addresses, err := utils.ParseIPList(raw)
+	if err != nil {
+		return "", err
+	}
+	value := strings.Join(addresses, ",")
+	return value, models.SetSystemSetting(ServerPublicIPsSettingKey, value)
+}
+
+// isDomainVerificationRequired 判断域名是否需要 TXT 所有权验证；
+// 自动生成的应用子域名位于管理员配置的基础域名下，无需验证
+func isDomainVerificationRequired(domain string) bool {
+	required, err := models.GetSystemSetting(DomainVerificationRequiredSettingKey)
+	if err != nil || required != "true" {
+		return false
+	}
+
+	baseDomain, err := models.GetSystemSetting(AppsBaseDomainSettingKey)
+	if err == nil && baseDomain != "" && strings.HasSuffix(domain, "."+baseDomain) {
+		return false
+	}
+	return true
+}
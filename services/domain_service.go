@@ -14,13 +14,19 @@ import (
 
 // ManageRouting 管理应用路由配置（服务层）
 func ManageRouting(applicationID uuid.UUID, domain string, port int, action string) (message string, cleanDomain string, httpErr *echo.HTTPError) {
+	message, cleanDomain, _, httpErr = manageRouting(applicationID, domain, port, action)
+	return message, cleanDomain, httpErr
+}
+
+// manageRouting 同 ManageRouting，添加路由时额外返回 DNS 预检结果（预检出错时为 nil）
+func manageRouting(applicationID uuid.UUID, domain string, port int, action string) (message string, cleanDomain string, preflight *DomainPreflightResult, httpErr *echo.HTTPError) {
 	var err error
 
 	// 验证并清理域名（去除协议前缀）
 	cleanDomain, err = utils.NormalizeDomain(domain)
 	if err != nil {
 		fmt.Printf("❌ [路由操作] 域名格式无效: %v\n", err)
-		return "", "", echo.NewHTTPError(400, fmt.Sprintf("无效的域名格式: %v", err))
+		return "", "", nil, echo.NewHTTPError(400, fmt.Sprintf("无效的域名格式: %v", err))
 	}
 	fmt.Printf("✅ [路由操作] 域名清理完成: %s -> %s\n", domain, cleanDomain)
 
@@ -30,7 +36,7 @@ func ManageRouting(applicationID uuid.UUID, domain string, port int, action stri
 	switch action {
 	case "add":
 		if port == 0 {
-			return "", "", echo.NewHTTPError(400, "添加路由时端口是必需的")
+			return "", "", nil, echo.NewHTTPError(400, "添加路由时端口是必需的")
 		}
 
 		// 对于 xxx.xxx.com 格式的域名，强制使用 8080 端口
@@ -46,10 +52,10 @@ func ManageRouting(applicationID uuid.UUID, domain string, port int, action stri
 		fmt.Printf("🔍 [路由添加] 检查域名冲突: %s\n", cleanDomain)
 		if exists, err := checkDomainConflict(cleanDomain); err != nil {
 			fmt.Printf("❌ [路由添加] 域名冲突检查失败: %v\n", err)
-			return "", "", echo.NewHTTPError(500, fmt.Sprintf("检查域名冲突时出错: %v", err))
+			return "", "", nil, echo.NewHTTPError(500, fmt.Sprintf("检查域名冲突时出错: %v", err))
 		} else if exists {
 			fmt.Printf("❌ [路由添加] 域名冲突: %s\n", cleanDomain)
-			return "", "", echo.NewHTTPError(400, "域名已存在")
+			return "", "", nil, echo.NewHTTPError(400, "域名已存在")
 		}
 		fmt.Printf("✅ [路由添加] 域名冲突检查通过\n")
 
@@ -57,19 +63,30 @@ func ManageRouting(applicationID uuid.UUID, domain string, port int, action stri
 		fmt.Printf("🔍 [路由添加] 检查端口冲突: %d (应用ID: %s)\n", port, applicationID)
 		if exists, err := checkPortConflict(port, applicationID); err != nil {
 			fmt.Printf("❌ [路由添加] 端口冲突检查失败: %v\n", err)
-			return "", "", echo.NewHTTPError(500, fmt.Sprintf("检查端口冲突时出错: %v", err))
+			return "", "", nil, echo.NewHTTPError(500, fmt.Sprintf("检查端口冲突时出错: %v", err))
 		} else if exists {
 			fmt.Printf("❌ [路由添加] 端口冲突: %d (应用ID: %s)\n", port, applicationID)
-			return "", "", echo.NewHTTPError(400, "该应用下端口已存在")
+			return "", "", nil, echo.NewHTTPError(400, "该应用下端口已存在")
 		}
 		fmt.Printf("✅ [路由添加] 端口冲突检查通过\n")
 
+		// DNS 预检：要求所有权验证时未通过则拒绝，解析不符只提示（可能经过 CDN 代理）
+		fmt.Printf("🔍 [路由添加] DNS 预检: %s\n", cleanDomain)
+		if preflight, err = PreflightDomain(cleanDomain); err != nil {
+			log.Printf("DNS 预检失败: %v", err)
+		} else if preflight.Status == models.RoutingDNSStatusUnverified {
+			fmt.Printf("❌ [路由添加] 域名所有权未验证: %s\n", cleanDomain)
+			return "", "", nil, echo.NewHTTPError(400, preflight.Message)
+		} else {
+			fmt.Printf("✅ [路由添加] DNS 预检结果: %s (%s)\n", preflight.Status, preflight.Message)
+		}
+
 		// 使用 FastCaddy 添加路由
 		fmt.Printf("🚀 [路由添加] 通过 FastCaddy 添加路由配置: %s -> %s\n", cleanDomain, proxyTo)
 		err = fc.AddReverseProxy(cleanDomain, proxyTo)
 		if err != nil {
 			fmt.Printf("❌ [路由添加] FastCaddy 添加失败: %v\n", err)
-			return "", "", echo.NewHTTPError(500, fmt.Sprintf("通过 Caddy 添加路由失败: %v", err))
+			return "", "", nil, echo.NewHTTPError(500, fmt.Sprintf("通过 Caddy 添加路由失败: %v", err))
 		}
 
 		message = fmt.Sprintf("路由 %s 配置成功", cleanDomain)
@@ -83,7 +100,7 @@ func ManageRouting(applicationID uuid.UUID, domain string, port int, action stri
 		err = fc.DeleteRoute(cleanDomain)
 		if err != nil {
 			fmt.Printf("❌ [路由删除] FastCaddy 删除失败: %v\n", err)
-			return "", "", echo.NewHTTPError(500, fmt.Sprintf("通过 Caddy 删除路由失败: %v", err))
+			return "", "", nil, echo.NewHTTPError(500, fmt.Sprintf("通过 Caddy 删除路由失败: %v", err))
 		}
 
 		// 从数据库删除路由记录
@@ -94,6 +111,9 @@ func ManageRouting(applicationID uuid.UUID, domain string, port int, action stri
 		} else {
 			for _, routing := range routings {
 				if routing.DomainName == cleanDomain && routing.ApplicationID == applicationID {
+					if err := RemoveRoutingTLS(routing); err != nil {
+						log.Printf("删除证书设置失败: %v", err)
+					}
 					err := models.DeleteRouting(routing.ID)
 					if err != nil {
 						fmt.Printf("❌ [路由删除] 数据库删除失败: %v\n", err)
@@ -110,10 +130,10 @@ func ManageRouting(applicationID uuid.UUID, domain string, port int, action stri
 		fmt.Printf("🎉 [路由删除] 完成: %s\n", message)
 
 	default:
-		return "", "", echo.NewHTTPError(400, "无效的操作。使用 'add' 或 'remove'")
+		return "", "", nil, echo.NewHTTPError(400, "无效的操作。使用 'add' 或 'remove'")
 	}
 
-	return message, cleanDomain, nil
+	return message, cleanDomain, preflight, nil
 }
 
 // UpdateRouting 更新路由配置（服务层）
@@ -130,6 +150,16 @@ func UpdateRouting(routingID uuid.UUID, newDomain string, newPort int, isActive
 		return nil, fmt.Errorf("域名格式无效: %v", err)
 	}
 
+	// 域名变更时，要求所有权验证的实例需先通过 TXT 验证
+	var preflight *DomainPreflightResult
+	if cleanDomain != oldRouting.DomainName {
+		if preflight, err = PreflightDomain(cleanDomain); err != nil {
+			log.Printf("DNS 预检失败: %v", err)
+		} else if preflight.Status == models.RoutingDNSStatusUnverified {
+			return nil, fmt.Errorf("%s", preflight.Message)
+		}
+	}
+
 	// 初始化 FastCaddy 客户端
 	fc := fastcaddy.New()
 
@@ -180,7 +210,58 @@ func UpdateRouting(routingID uuid.UUID, newDomain string, newPort int, isActive
 
 	// 更新数据库
 	fmt.Printf("💾 [路由更新] 更新数据库\n")
-	return models.UpdateRouting(routingID, cleanDomain, newPort, isActive)
+	routing, err := models.UpdateRouting(routingID, cleanDomain, newPort, isActive)
+	if err != nil {
+		return nil, err
+	}
+
+	// 域名变更时迁移证书设置
+	if cleanDomain != oldRouting.DomainName {
+		if err := MoveRoutingTLS(routing, oldRouting.DomainName); err != nil {
+			log.Printf("迁移证书设置失败: %v", err)
+		}
+		if preflight != nil {
+			if err := saveRoutingDNSResult(routing, preflight); err != nil {
+				log.Printf("DNS 预检失败: %v", err)
+			}
+		}
+	}
+
+	return routing, nil
+}
+
+// CreateRouting 添加路由配置（服务层），先配置 Caddy 再写数据库，写库失败时回滚 Caddy 配置；
+// 添加前的 DNS 预检结果直接保存到路由，不再重复解析
+func CreateRouting(applicationID uuid.UUID, domain string, hostPort int, isActive bool) (*models.Routing, string, *echo.HTTPError) {
+	message, cleanDomain, preflight, httpErr := manageRouting(applicationID, domain, hostPort, "add")
+	if httpErr != nil {
+		return nil, "", httpErr
+	}
+
+	routing, err := models.CreateRouting(applicationID, cleanDomain, hostPort, isActive)
+	if err != nil {
+		log.Printf("创建路由记录失败，应用ID: %s, 域名: %s, 错误: %v", applicationID, cleanDomain, err)
+		if _, _, rollbackErr := ManageRouting(applicationID, cleanDomain, hostPort, "remove"); rollbackErr != nil {
+			log.Printf("回滚 Caddy 配置失败: %v", rollbackErr)
+		}
+		return nil, "", echo.NewHTTPError(500, "创建路由记录失败")
+	}
+
+	if preflight != nil {
+		if err := saveRoutingDNSResult(routing, preflight); err != nil {
+			log.Printf("DNS 预检失败: %v", err)
+		}
+	}
+	return routing, message, nil
+}
+
+// AddRouting 同 CreateRouting，以普通错误返回
+func AddRouting(applicationID uuid.UUID, domain string, hostPort int, isActive bool) (*models.Routing, error) {
+	routing, _, httpErr := CreateRouting(applicationID, domain, hostPort, isActive)
+	if httpErr != nil {
+		return nil, fmt.Errorf("%v", httpErr.Message)
+	}
+	return routing, nil
 }
 
 // DeleteRouting 删除路由配置（服务层）
@@ -201,6 +282,11 @@ func DeleteRouting(routingID uuid.UUID) error {
 		return fmt.Errorf("删除 Caddy 配置失败: %v", err)
 	}
 
+	// 删除证书设置
+	if err := RemoveRoutingTLS(routing); err != nil {
+		log.Printf("删除证书设置失败: %v", err)
+	}
+
 	// 删除数据库记录
 	fmt.Printf("💾 [路由删除] 删除数据库记录\n")
 	return models.DeleteRouting(routingID)
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+)
+
+// providerAuthCredentials 返回访问平台 API 和拉取代码使用的令牌与用户名。
+// GitHub 使用 GitHub App 安装令牌，GitLab 和 Gitea 使用 ClientSecret 中保存的访问令牌，Bitbucket 使用 App Password
+func providerAuthCredentials(providerAuth *models.ProviderAuth) (token, username string, err error) {
+	switch providerAuth.Platform {
+	case "github":
+		if providerAuth.AppID == "" || providerAuth.PrivateKey == "" || providerAuth.InstallationID == 0 {
+			return "", "", fmt.Errorf("GitHub平台需要完整的AppID、PrivateKey和InstallationID")
+		}
+		token, err := utils.GenerateGitHubAppInstallationToken(providerAuth.AppID, providerAuth.PrivateKey, providerAuth.InstallationID)
+		if err != nil {
+			return "", "", fmt.Errorf("生成GitHub安装令牌失败: %w", err)
+		}
+		return token, "x-access-token", nil // GitHub推荐的用户名
+	case "gitlab", "gitea":
+		return providerAuth.ClientSecret, providerAuth.Username, nil
+	case "bitbucket":
+		return providerAuth.AppPassword, providerAuth.Username, nil
+	default:
+		return "", "", fmt.Errorf("不支持的平台类型: %s", providerAuth.Platform)
+	}
+}
+
+// NewProviderAuthClient 使用 ProviderAuth 的凭据创建平台 API 客户端
+func NewProviderAuthClient(providerAuth *models.ProviderAuth) (*utils.GitProviderClient, error) {
+	if !providerAuth.IsActive {
+		return nil, fmt.Errorf("ProviderAuth已禁用")
+	}
+	token, username, err := providerAuthCredentials(providerAuth)
+	if err != nil {
+		return nil, err
+	}
+	client := utils.NewGitProviderClient(providerAuth.Platform, token, username)
+	client.BaseURL = providerAuth.BaseURL
+	return client, nil
+}
+
+// FindProviderAuthForRepo 查找实例地址与仓库地址匹配的启用授权，没有匹配时返回 nil
+func FindProviderAuthForRepo(repoURL string) (*models.ProviderAuth, error) {
+	providerAuths, err := models.ListProviderAuths()
+	if err != nil {
+		return nil, fmt.Errorf("获取授权列表失败: %w", err)
+	}
+	for _, providerAuth := range providerAuths {
+		if !providerAuth.IsActive {
+			continue
+		}
+		probe := utils.NewGitProviderClient(providerAuth.Platform, "", "")
+		probe.BaseURL = providerAuth.BaseURL
+		if probe.MatchesHost(repoURL) {
+			return providerAuth, nil
+		}
+	}
+	return nil, nil
+}
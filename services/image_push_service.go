@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// ImageRegistryRoot 内置镜像仓库的存储目录，每个应用一个 OCI Image Layout
+const ImageRegistryRoot = "/var/lib/orbitdeploy/registry"
+
+// PushReleaseSource 推送到内置镜像仓库的 Release 在 BuildSourceInfo 中的 source 值
+const PushReleaseSource = "push"
+
+// imageLayoutGCMinAge 垃圾回收跳过此时间内修改过的 blob，与未完成上传会话的保留时间一致
+const imageLayoutGCMinAge = 24 * time.Hour
+
+// PushedImageResult 处理一次镜像推送的结果
+type PushedImageResult struct {
+	Release    *models.Release
+	Created    bool               // 为 false 时同一标签和摘要已有 Release，沿用旧的 Release
+	Deployment *models.Deployment // 启用了推送自动部署时创建的部署
+}
+
+// OpenApplicationImageLayout 打开应用在内置镜像仓库中的存储目录
+func OpenApplicationImageLayout(application *models.Application) (*utils.OCILayout, error) {
+	return utils.OpenOCILayout(filepath.Join(ImageRegistryRoot, application.ID.String()))
+}
+
+// pushedImageTag 返回推送创建的 Release 对应的标签和清单摘要，不是推送创建的 Release 时返回 false
+func pushedImageTag(release *models.Release) (string, string, bool) {
+	info, _ := release.BuildSourceInfo.Data.(map[string]interface{})
+	if info["source"] != PushReleaseSource {
+		return "", "", false
+	}
+	tag, _ := info["image_tag"].(string)
+	digest, _ := info["manifest_digest"].(string)
+	return tag, digest, tag != "" && digest != ""
+}
+
+// pruneApplicationImageLayout 删除已清理的推送 Release 的标签，并回收内置镜像仓库中不再被标签引用的清单和 blob。
+// 镜像导入后本地镜像存储中已有完整副本，这里只回收仓库目录的空间，返回回收的字节数
+func pruneApplicationImageLayout(application *models.Application, removed []*models.Release) int64 {
+	root := filepath.Join(ImageRegistryRoot, application.ID.String())
+	if _, err := os.Stat(root); err != nil {
+		return 0 // 应用没有使用过内置镜像仓库
+	}
+	layout, err := utils.OpenOCILayout(root)
+	if err != nil {
+		logman.Warn("打开镜像存储失败", "app_name", application.Name, "error", err)
+		return 0
+	}
+	for _, release := range removed {
+		if tag, digest, ok := pushedImageTag(release); ok {
+			if err := layout.RemoveTag(tag, digest); err != nil {
+				logman.Warn("删除镜像标签失败", "app_name", application.Name, "tag", tag, "error", err)
+			}
+		}
+	}
+	count, reclaimed, err := layout.CollectGarbage(imageLayoutGCMinAge)
+	if err != nil {
+		logman.Warn("回收镜像存储失败", "app_name", application.Name, "error", err)
+		return 0
+	}
+	if count > 0 {
+		logman.Info("回收镜像存储中未引用的 blob", "app_name", application.Name, "count", count, "reclaimed_bytes", reclaimed)
+	}
+	return reclaimed
+}
+
+// pushedImageName 推送镜像导入后的本地名称，标签后附加清单摘要，重复推送同一标签不会覆盖旧 Release 的镜像
+func pushedImageName(application *models.Application, tag, digest string) string {
+	if len(tag) > 115 {
+		tag = tag[:115]
+	}
+	return fmt.Sprintf("%s:%s-%s", ApplicationImageRepository(application), tag, strings.TrimPrefix(digest, "sha256:")[:12])
+}
+
+// HandlePushedImage 将推送到内置仓库的镜像导入本地镜像存储并创建 Release，
+// 应用启用了推送自动部署时随后创建部署。部署失败不影响推送结果
+func (do *DeploymentOrchestrator) HandlePushedImage(application *models.Application, layout *utils.OCILayout, tag, digest string) (*PushedImageResult, error) {
+	imageName := pushedImageName(application, tag, digest)
+	existing, err := models.FindReleaseByImageName(application.ID, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Release 失败: %w", err)
+	}
+	if existing != nil {
+		logman.Info("推送的镜像已有 Release，跳过导入", "app_name", application.Name, "tag", tag, "release_id", existing.ID)
+		return &PushedImageResult{Release: existing}, nil
+	}
+
+	var importLog strings.Builder
+	logger := func(line string) {
+		importLog.WriteString(line)
+		importLog.WriteByte('\n')
+	}
+	timeout := GetBuildTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	runner := newBuildRunner(ctx, timeout, logger)
+
+	err = runner.step("导入镜像 "+tag, func() error {
+		output, err := runner.output("", "podman", "pull", "--quiet", "oci:"+layout.Root+":"+tag)
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(output)
+		if len(fields) == 0 {
+			return fmt.Errorf("podman pull 没有返回镜像 ID")
+		}
+		return runner.run("", "podman", "tag", fields[len(fields)-1], imageName)
+	})
+	if err != nil {
+		logman.Error("导入推送的镜像失败", "app_name", application.Name, "tag", tag, "error", err)
+		return nil, fmt.Errorf("导入镜像失败: %w", err)
+	}
+
+	sourceInfo := map[string]interface{}{
+		"source":          PushReleaseSource,
+		"image_tag":       tag,
+		"manifest_digest": digest,
+	}
+	release, err := models.CreateReleaseWithVersion(application.ID, tag, imageName, models.JSONB{Data: sourceInfo}, "success")
+	if err != nil {
+		return nil, fmt.Errorf("创建 Release 失败: %w", err)
+	}
+	if err := models.UpdateReleaseBuildLog(release.ID, importLog.String()); err != nil {
+		logman.Error("保存导入日志失败", "release_id", release.ID, "error", err)
+	}
+	logman.Info("推送的镜像已创建 Release", "app_name", application.Name, "tag", tag, "digest", digest, "release_id", release.ID)
+
+	// 重新推送同一标签后，旧清单及其独占的 blob 不再被引用
+	pruneApplicationImageLayout(application, nil)
+
+	result := &PushedImageResult{Release: release, Created: true}
+	if application.AutoDeploy.AutoDeployOnImagePush {
+		deployment, err := do.CreateDeployment(application.ID, CreateDeploymentRequest{ReleaseID: &release.ID})
+		if err != nil {
+			logman.Error("镜像推送触发部署失败", "app_name", application.Name, "release_id", release.ID, "error", err)
+		} else {
+			logman.Info("镜像推送触发部署", "app_name", application.Name, "release_id", release.ID, "deployment_id", deployment.ID)
+			result.Deployment = deployment
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// 进程被取消后等待其输出管道关闭的最长时间
+const processWaitDelay = 5 * time.Second
+
+// killProcessGroupOnCancel 让命令运行在独立进程组中，ctx 取消时杀掉整个进程组，
+// 避免 sh、su 派生的子进程（如 journalctl -f、podman build）继续运行并占住输出管道
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = processWaitDelay
+}
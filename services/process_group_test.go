@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKillProcessGroupOnCancelStopsChildren(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// 子进程 sleep 继承了 stdout，只杀 sh 时读取会一直阻塞
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 30; echo done")
+	killProcessGroupOnCancel(cmd)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+
+	start := time.Now()
+	_, _ = io.ReadAll(stdout)
+	assert.Error(t, cmd.Wait())
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
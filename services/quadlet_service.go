@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"strings"
 
 	"github.com/OrbitDeploy/OrbitDeploy/models"
 	"gorm.io/gorm"
@@ -17,6 +18,8 @@ type QuadletData struct {
 	PublishPorts     []string
 	Volumes          []string
 	EnvFilePath      string
+	PodmanArgs       []string // 资源限制等额外的 podman run 参数
+	ServiceSettings  []string // [Service] 段的 systemd 设置，如 OOMPolicy
 }
 
 func GenerateQuadletFileContent(db *gorm.DB, appName string, envFilePath string) (string, error) {
@@ -29,18 +32,8 @@ func GenerateQuadletFileContent(db *gorm.DB, appName string, envFilePath string)
 
 	// 提取Volumes
 	var volumes []string
-	if app.Volumes.Data != nil {
-		if volumesData, ok := app.Volumes.Data.([]interface{}); ok {
-			for _, v := range volumesData {
-				if vol, ok := v.(map[string]interface{}); ok {
-					hostPath, hok := vol["host_path"].(string)
-					containerPath, cok := vol["container_path"].(string)
-					if hok && cok {
-						volumes = append(volumes, hostPath+":"+containerPath)
-					}
-				}
-			}
-		}
+	for _, mount := range models.ParseVolumeMounts(app.Volumes) {
+		volumes = append(volumes, mount.QuadletValue())
 	}
 
 	// 提取PublishPorts
@@ -67,6 +60,8 @@ func GenerateQuadletFileContent(db *gorm.DB, appName string, envFilePath string)
 		PublishPorts:     publishPorts,
 		Volumes:          volumes,
 		EnvFilePath:      envFilePath,
+		PodmanArgs:       app.Resources.PodmanArgs(),
+		ServiceSettings:  app.Resources.ServiceSettings(),
 	}
 
 	// 模板
@@ -87,10 +82,19 @@ PublishPort={{ . }}
 Volume={{ . }}
 {{- end }}
 EnvironmentFile={{ .EnvFilePath }}
+{{- if .PodmanArgs }}
+PodmanArgs={{ join .PodmanArgs " " }}
+{{- end }}
+{{- if .ServiceSettings }}
+[Service]
+{{- range .ServiceSettings }}
+{{ . }}
+{{- end }}
+{{- end }}
 [Install]
 WantedBy=default.target`
 
-	tmpl, err := template.New("quadlet").Parse(quadletTemplate)
+	tmpl, err := template.New("quadlet").Funcs(template.FuncMap{"join": strings.Join}).Parse(quadletTemplate)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// RegistryReleaseSource 来自外部镜像仓库的 Release 在 BuildSourceInfo 中的 source 值
+const RegistryReleaseSource = "registry"
+
+// RegistryImageCheckResult 检查镜像仓库更新的结果
+type RegistryImageCheckResult struct {
+	ImageRef string          // 检查的镜像引用，版本标签有更新时为新的标签
+	Digest   string          // 镜像引用当前指向的摘要
+	Changed  bool            // 与最近一次拉取的 Release 摘要不同
+	Release  *models.Release // Changed 时新建的 Release
+}
+
+// registrySourceInfo 生成镜像仓库 Release 的构建源信息
+func registrySourceInfo(ref *utils.ImageReference, digest string) map[string]interface{} {
+	return map[string]interface{}{
+		"source":       RegistryReleaseSource,
+		"image_ref":    ref.String(),
+		"image_tag":    ref.Tag,
+		"image_digest": digest,
+	}
+}
+
+// isRegistryRelease 判断 Release 是否来自外部镜像仓库
+func isRegistryRelease(release *models.Release) bool {
+	info, _ := release.BuildSourceInfo.Data.(map[string]interface{})
+	source, _ := info["source"].(string)
+	return source == RegistryReleaseSource && release.ImageDigest != ""
+}
+
+// newRegistryClient 创建镜像仓库客户端，测试中替换为访问本地测试仓库的客户端
+var newRegistryClient = utils.NewRegistryClient
+
+// registryClientForApplication 使用应用保存的镜像仓库凭据创建客户端
+func registryClientForApplication(application *models.Application) (*utils.RegistryClient, error) {
+	password, err := application.RegistryImage.DecryptedPassword()
+	if err != nil {
+		return nil, fmt.Errorf("解密镜像仓库密码失败: %w", err)
+	}
+	return newRegistryClient(application.RegistryImage.RegistryUsername, password), nil
+}
+
+// CheckRegistryImage 检查应用镜像在仓库中是否有更新：版本标签（如 1.2.0）会查找更高的同形式标签，
+// 其他标签比较摘要。与最近一次拉取的 Release 不同时创建新的 Release，由调用方决定是否部署
+func CheckRegistryImage(application *models.Application) (*RegistryImageCheckResult, error) {
+	if application.RegistryImage.ImageRef == "" {
+		return nil, fmt.Errorf("应用未设置镜像仓库来源")
+	}
+	ref, err := utils.ParseImageReference(application.RegistryImage.ImageRef)
+	if err != nil {
+		return nil, err
+	}
+	client, err := registryClientForApplication(application)
+	if err != nil {
+		return nil, err
+	}
+
+	target := *ref
+	if ref.Digest == "" {
+		tags, err := client.ListTags(ref)
+		if err != nil {
+			// 部分仓库不开放标签列表，此时只检查当前标签的摘要
+			logman.Warn("列出镜像标签失败", "image", ref.String(), "error", err)
+		} else if newer := utils.NewerVersionTag(ref.Tag, tags); newer != "" {
+			target = ref.WithTag(newer)
+		}
+	}
+
+	digest, err := client.ResolveDigest(&target)
+	if err != nil {
+		return nil, err
+	}
+	result := &RegistryImageCheckResult{ImageRef: target.String(), Digest: digest}
+
+	// 发现更高的版本标签时更新应用的镜像引用，否则部署时仍会解析旧标签而回退版本
+	if target.Tag != ref.Tag {
+		if err := models.AdvanceApplicationRegistryImageRef(application.ID, application.RegistryImage.ImageRef, result.ImageRef); err != nil {
+			return nil, fmt.Errorf("更新应用镜像引用失败: %w", err)
+		}
+		application.RegistryImage.ImageRef = result.ImageRef
+	}
+
+	latest, err := models.GetLatestRegistryRelease(application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询最近的镜像 Release 失败: %w", err)
+	}
+	if latest != nil && latest.ImageDigest == digest {
+		return result, nil
+	}
+
+	release, err := models.CreateRegistryRelease(application.ID, target.Pinned(digest), digest, models.JSONB{Data: registrySourceInfo(&target, digest)})
+	if err != nil {
+		return nil, fmt.Errorf("创建 Release 失败: %w", err)
+	}
+	result.Changed = true
+	result.Release = release
+
+	logman.Info("镜像仓库有更新，已创建 Release", "app_name", application.Name, "image", result.ImageRef, "digest", digest, "release_id", release.ID)
+	return result, nil
+}
+
+// resolveRegistryRelease 将待构建的 Release 解析为应用镜像引用当前指向的摘要，代替从代码仓库构建
+func (do *DeploymentOrchestrator) resolveRegistryRelease(release *models.Release, application *models.Application, deploymentID uuid.UUID) error {
+	ref, err := utils.ParseImageReference(application.RegistryImage.ImageRef)
+	if err != nil {
+		return err
+	}
+	client, err := registryClientForApplication(application)
+	if err != nil {
+		return err
+	}
+
+	do.sendDeploymentLogFrom(deploymentID, "解析镜像摘要 "+ref.String(), "PULL")
+	digest, err := client.ResolveDigest(ref)
+	if err != nil {
+		models.UpdateRelease(release.ID, "", release.BuildSourceInfo, "failed")
+		return fmt.Errorf("解析镜像摘要失败: %w", err)
+	}
+	pinned := ref.Pinned(digest)
+	if err := models.UpdateReleaseImageDigest(release.ID, pinned, digest, models.JSONB{Data: registrySourceInfo(ref, digest)}); err != nil {
+		return fmt.Errorf("更新 Release 失败: %w", err)
+	}
+	do.sendDeploymentLogFrom(deploymentID, "镜像已固定为 "+pinned, "PULL")
+	return nil
+}
+
+// pullRegistryImage 以项目用户拉取固定摘要的镜像，设置了凭据时为本次拉取生成临时的 authfile
+func (do *DeploymentOrchestrator) pullRegistryImage(deploymentID uuid.UUID, application *models.Application, release *models.Release, project *models.Project) error {
+	ref, err := utils.ParseImageReference(release.ImageName)
+	if err != nil {
+		return err
+	}
+
+	var pullLog strings.Builder
+	logger := func(line string) {
+		pullLog.WriteString(line)
+		pullLog.WriteByte('\n')
+		do.sendDeploymentLogFrom(deploymentID, line, "PULL")
+	}
+	password, err := application.RegistryImage.DecryptedPassword()
+	if err != nil {
+		return fmt.Errorf("解密镜像仓库密码失败: %w", err)
+	}
+	timeout := GetBuildTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	runner := newBuildRunner(ctx, timeout, logger, password)
+
+	args := []string{"pull"}
+	username := application.RegistryImage.RegistryUsername
+	// 凭据只用于应用配置的镜像仓库，其他仓库的 Release（如修改来源前创建的）匿名拉取
+	if configured, err := utils.ParseImageReference(application.RegistryImage.ImageRef); err == nil && configured.Registry == ref.Registry && username != "" {
+		authFile, cleanup, err := writeRegistryAuthFile(project, ref.Registry, username, password)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		args = append(args, "--authfile", authFile)
+	}
+	args = append(args, release.ImageName)
+
+	name, cmdArgs := utils.PodmanCommandForUser(project.Username, args...)
+	err = runner.step("拉取镜像 "+release.ImageName, func() error {
+		return runner.run("", name, cmdArgs...)
+	})
+	if saveErr := models.UpdateReleaseBuildLog(release.ID, pullLog.String()); saveErr != nil {
+		logman.Error("保存拉取日志失败", "release_id", release.ID, "error", saveErr)
+	}
+	if err != nil {
+		return fmt.Errorf("拉取镜像失败: %w", err)
+	}
+	return nil
+}
+
+// writeRegistryAuthFile 在项目用户的 containers 配置目录下写入仅该用户可读的 authfile，返回路径和清理函数
+func writeRegistryAuthFile(project *models.Project, registry, username, password string) (string, func(), error) {
+	content, err := utils.RegistryAuthFile(registry, username, password)
+	if err != nil {
+		return "", nil, fmt.Errorf("生成镜像仓库认证文件失败: %w", err)
+	}
+
+	dir := os.TempDir()
+	if project.HomeDir != "" {
+		dir = filepath.Join(project.HomeDir, ".config", "containers")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", nil, fmt.Errorf("创建目录失败 %s: %w", dir, err)
+		}
+	}
+	file, err := os.CreateTemp(dir, "orbit-auth-*.json")
+	if err != nil {
+		return "", nil, fmt.Errorf("创建镜像仓库认证文件失败: %w", err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("写入镜像仓库认证文件失败: %w", err)
+	}
+
+	if project.Username != "" {
+		if err := chownToUser(file.Name(), project.Username); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+	return file.Name(), cleanup, nil
+}
+
+// chownToUser 将文件的属主改为指定的系统用户
+func chownToUser(path, username string) error {
+	u, err := user.Lookup(username)
+	if err != nil {
+		return fmt.Errorf("查找用户 %s 失败: %w", username, err)
+	}
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("修改 %s 属主失败: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestRegistry 启动一个只读的测试镜像仓库，tags 为 acme/web 的标签到摘要，返回仓库地址
+func useTestRegistry(t *testing.T, tags map[string]string) string {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/acme/web/tags/list" {
+			names := make([]string, 0, len(tags))
+			for tag := range tags {
+				names = append(names, `"`+tag+`"`)
+			}
+			fmt.Fprintf(w, `{"tags": [%s]}`, strings.Join(names, ","))
+			return
+		}
+		if digest, ok := tags[strings.TrimPrefix(r.URL.Path, "/v2/acme/web/manifests/")]; ok {
+			w.Header().Set("Docker-Content-Digest", digest)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := newRegistryClient
+	newRegistryClient = func(username, password string) *utils.RegistryClient {
+		client := previous(username, password)
+		client.HTTPClient = server.Client()
+		return client
+	}
+	t.Cleanup(func() { newRegistryClient = previous })
+	return strings.TrimPrefix(server.URL, "https://")
+}
+
+func TestCheckRegistryImageAdvancesToNewerTag(t *testing.T) {
+	setupTestDB(t)
+	digest := "sha256:" + strings.Repeat("b", 64)
+	host := useTestRegistry(t, map[string]string{
+		"1.0.0": "sha256:" + strings.Repeat("a", 64),
+		"1.1.0": digest,
+	})
+	app, err := models.CreateApplication(uuid.New(), "registry-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, models.UpdateApplicationRegistryImage(app.ID, host+"/acme/web:1.0.0", "", nil))
+	app, err = models.GetApplicationByID(app.ID)
+	assert.NoError(t, err)
+
+	result, err := CheckRegistryImage(app)
+	assert.NoError(t, err)
+	assert.True(t, result.Changed)
+	assert.Equal(t, host+"/acme/web:1.1.0", result.ImageRef)
+	assert.Equal(t, host+"/acme/web@"+digest, result.Release.ImageName)
+
+	// 之后的部署解析新标签，不会回退到 1.0.0
+	stored, err := models.GetApplicationByID(app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, host+"/acme/web:1.1.0", stored.RegistryImage.ImageRef)
+
+	result, err = CheckRegistryImage(stored)
+	assert.NoError(t, err)
+	assert.False(t, result.Changed)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// 提升 Release 的校验错误，调用方据此返回 4xx
+var (
+	ErrPromoteSameApplication = errors.New("不能提升到 Release 所属的应用")
+	ErrPromoteOtherProject    = errors.New("只能在同一项目的应用之间提升 Release")
+	ErrPromoteReleaseNotReady = errors.New("只能提升构建成功的 Release")
+)
+
+// PromoteReleaseResult 提升 Release 的结果
+type PromoteReleaseResult struct {
+	Release    *models.Release
+	Created    bool               // 为 false 时目标应用已有同一镜像提升而来的 Release，沿用旧的 Release
+	Deployment *models.Deployment // deploy 为 true 时创建的部署
+}
+
+// PromoteRelease 把 source 提升到同一项目的 target 应用：在 target 上创建指向同一镜像的 Release，不重新构建。
+// 本地镜像按镜像 ID 打上 target 的标签，来自镜像仓库的 Release 沿用固定的摘要；
+// 来源信息记录在 BuildSourceInfo 的 promoted_from_* 中，deploy 为 true 时以 requestedBy 的名义随后部署
+func (do *DeploymentOrchestrator) PromoteRelease(source *models.Release, target *models.Application, deploy bool, requestedBy string) (*PromoteReleaseResult, error) {
+	sourceApp, err := models.GetApplicationByID(source.ApplicationID)
+	if err != nil {
+		return nil, fmt.Errorf("获取来源应用失败: %w", err)
+	}
+	switch {
+	case sourceApp.ID == target.ID:
+		return nil, ErrPromoteSameApplication
+	case sourceApp.ProjectID != target.ProjectID:
+		return nil, ErrPromoteOtherProject
+	case source.Status != "success" || source.ImageName == "":
+		return nil, ErrPromoteReleaseNotReady
+	}
+
+	sourceInfo := map[string]interface{}{}
+	if data, ok := source.BuildSourceInfo.Data.(map[string]interface{}); ok {
+		for key, value := range data {
+			sourceInfo[key] = value
+		}
+	}
+
+	// 来自镜像仓库的 Release 已固定摘要，部署时按摘要拉取；本地镜像以镜像 ID 打上目标应用的标签
+	imageName, imageID := source.ImageName, ""
+	if !isRegistryRelease(source) {
+		imageID, err = localImageID(source.ImageName)
+		if err != nil {
+			return nil, err
+		}
+		imageName = fmt.Sprintf("%s:promoted-%s", ApplicationImageRepository(target), imageID[:12])
+		sourceInfo["image_id"] = "sha256:" + imageID
+	}
+
+	existing, err := models.FindReleaseByImageName(target.ID, imageName)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Release 失败: %w", err)
+	}
+	result := &PromoteReleaseResult{Release: existing}
+	if existing == nil {
+		if imageID != "" {
+			if err := tagLocalImage(imageID, imageName); err != nil {
+				return nil, err
+			}
+		}
+
+		sourceInfo["promoted_from_release_id"] = source.ID.String()
+		sourceInfo["promoted_from_app"] = sourceApp.Name
+		sourceInfo["promoted_from_version"] = source.Version
+		sourceInfo["promoted_at"] = time.Now().Format(time.RFC3339)
+
+		var release *models.Release
+		if imageID == "" {
+			release, err = models.CreateRegistryRelease(target.ID, imageName, source.ImageDigest, models.JSONB{Data: sourceInfo})
+		} else {
+			release, err = models.CreateReleaseWithVersion(target.ID, source.Version, imageName, models.JSONB{Data: sourceInfo}, "success")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("创建 Release 失败: %w", err)
+		}
+		logman.Info("Release 已提升", "from_app", sourceApp.Name, "to_app", target.Name, "source_release_id", source.ID, "release_id", release.ID, "image", imageName)
+		result.Release = release
+		result.Created = true
+	} else {
+		logman.Info("目标应用已有相同镜像的 Release，跳过创建", "to_app", target.Name, "release_id", existing.ID)
+	}
+
+	if deploy {
+		deployment, err := do.CreateDeployment(target.ID, CreateDeploymentRequest{ReleaseID: &result.Release.ID, RequestedBy: requestedBy})
+		if err != nil {
+			return result, fmt.Errorf("部署提升的 Release 失败: %w", err)
+		}
+		result.Deployment = deployment
+	}
+	return result, nil
+}
+
+// 读取和标记本地镜像，测试中替换为不依赖 podman 的实现
+var (
+	localImageID  = podmanImageID
+	tagLocalImage = podmanTagImage
+)
+
+// podmanImageID 返回本地镜像的 ID（不含 sha256: 前缀），同一 ID 的镜像内容完全一致
+func podmanImageID(imageName string) (string, error) {
+	output, err := exec.Command("podman", "image", "inspect", "--format", "{{.Id}}", imageName).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("本地找不到镜像 %s: %s", imageName, strings.TrimSpace(string(output)))
+	}
+	imageID := strings.TrimPrefix(strings.TrimSpace(string(output)), "sha256:")
+	if len(imageID) < 12 {
+		return "", fmt.Errorf("无法解析镜像 ID: %s", imageID)
+	}
+	return imageID, nil
+}
+
+// podmanTagImage 为本地镜像添加标签
+func podmanTagImage(imageID, imageName string) error {
+	if output, err := exec.Command("podman", "tag", imageID, imageName).CombinedOutput(); err != nil {
+		return fmt.Errorf("标记镜像失败: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
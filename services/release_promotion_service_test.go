@@ -0,0 +1,119 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// createPromotionApps 创建同一项目中的 staging 和 production 应用
+func createPromotionApps(t *testing.T) (*models.Application, *models.Application) {
+	t.Helper()
+	projectID := uuid.New()
+	staging, err := models.CreateApplication(projectID, "web-staging", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	production, err := models.CreateApplication(projectID, "web-production", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	return staging, production
+}
+
+// useFakePodmanImages 替换本地镜像操作，返回被标记的镜像名
+func useFakePodmanImages(t *testing.T, imageID string) *[]string {
+	t.Helper()
+	tagged := &[]string{}
+	previousID, previousTag := localImageID, tagLocalImage
+	localImageID = func(imageName string) (string, error) { return imageID, nil }
+	tagLocalImage = func(id, imageName string) error {
+		assert.Equal(t, imageID, id)
+		*tagged = append(*tagged, imageName)
+		return nil
+	}
+	t.Cleanup(func() { localImageID, tagLocalImage = previousID, previousTag })
+	return tagged
+}
+
+func TestPromoteLocalImageRelease(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	imageID := strings.Repeat("0123456789abcdef", 4)
+	tagged := useFakePodmanImages(t, imageID)
+	staging, production := createPromotionApps(t)
+
+	source, err := models.CreateReleaseWithVersion(staging.ID, "v1.2.0", "web-staging:abc1234-20260101000000", models.JSONB{Data: map[string]interface{}{"commit_sha": "abc1234"}}, "success")
+	assert.NoError(t, err)
+
+	result, err := orchestrator.PromoteRelease(source, production, false, "alice")
+	assert.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Nil(t, result.Deployment)
+	assert.Equal(t, "web-production:promoted-0123456789ab", result.Release.ImageName)
+	assert.Equal(t, []string{"web-production:promoted-0123456789ab"}, *tagged)
+	assert.Equal(t, "v1.2.0", result.Release.Version)
+
+	info := result.Release.BuildSourceInfo.Data.(map[string]interface{})
+	assert.Equal(t, source.ID.String(), info["promoted_from_release_id"])
+	assert.Equal(t, "web-staging", info["promoted_from_app"])
+	assert.Equal(t, "v1.2.0", info["promoted_from_version"])
+	assert.Equal(t, "sha256:"+imageID, info["image_id"])
+	assert.Equal(t, "abc1234", info["commit_sha"])
+
+	// 再次提升同一镜像沿用已有的 Release
+	again, err := orchestrator.PromoteRelease(source, production, false, "alice")
+	assert.NoError(t, err)
+	assert.False(t, again.Created)
+	assert.Equal(t, result.Release.ID, again.Release.ID)
+	assert.Len(t, *tagged, 1)
+}
+
+func TestPromoteRegistryRelease(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	tagged := useFakePodmanImages(t, strings.Repeat("f", 64))
+	staging, production := createPromotionApps(t)
+
+	digest := "sha256:" + strings.Repeat("c", 64)
+	pinned := "ghcr.io/acme/web@" + digest
+	source, err := models.CreateRegistryRelease(staging.ID, pinned, digest, models.JSONB{Data: map[string]interface{}{
+		"source":       RegistryReleaseSource,
+		"image_ref":    "ghcr.io/acme/web:1.2.0",
+		"image_digest": digest,
+	}})
+	assert.NoError(t, err)
+
+	result, err := orchestrator.PromoteRelease(source, production, false, "alice")
+	assert.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, pinned, result.Release.ImageName)
+	assert.Equal(t, digest, result.Release.ImageDigest)
+	assert.True(t, isRegistryRelease(result.Release))
+	assert.Empty(t, *tagged, "镜像仓库的 Release 不需要本地标记")
+
+	info := result.Release.BuildSourceInfo.Data.(map[string]interface{})
+	assert.Equal(t, source.ID.String(), info["promoted_from_release_id"])
+	assert.Equal(t, "web-staging", info["promoted_from_app"])
+	assert.Nil(t, info["image_id"])
+}
+
+func TestPromoteReleaseRejectsInvalidTargets(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	useFakePodmanImages(t, strings.Repeat("0123456789abcdef", 4))
+	staging, production := createPromotionApps(t)
+	other, err := models.CreateApplication(uuid.New(), "web-other", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	source, err := models.CreateRelease(staging.ID, "web-staging:abc1234-20260101000000", models.JSONB{}, "success")
+	assert.NoError(t, err)
+	_, err = orchestrator.PromoteRelease(source, staging, false, "alice")
+	assert.ErrorIs(t, err, ErrPromoteSameApplication)
+	_, err = orchestrator.PromoteRelease(source, other, false, "alice")
+	assert.ErrorIs(t, err, ErrPromoteOtherProject)
+
+	failed, err := models.CreateRelease(staging.ID, "web-staging:def5678-20260101000000", models.JSONB{}, "failed")
+	assert.NoError(t, err)
+	_, err = orchestrator.PromoteRelease(failed, production, false, "alice")
+	assert.ErrorIs(t, err, ErrPromoteReleaseNotReady)
+}
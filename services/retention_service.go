@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// retentionInterval 定期执行保留策略的间隔
+const retentionInterval = 6 * time.Hour
+
+// ErrRetentionPolicyNotSet 应用没有设置保留策略
+var ErrRetentionPolicyNotSet = errors.New("应用未设置保留策略")
+
+// retentionLocks 同一应用的清理串行执行，避免定时任务和手动清理同时删除
+var retentionLocks sync.Map // map[uuid.UUID]*sync.Mutex
+
+// RetentionResult 一次清理的结果
+type RetentionResult struct {
+	RemovedReleases int   // 删除的 Release 数量（连同其部署记录）
+	RemovedImages   int   // 删除的镜像数量
+	RemovedUnits    int   // 停止并删除 Quadlet 文件的 unit 数量
+	ReclaimedBytes  int64 // 回收的磁盘空间（镜像与其他镜像共享的层不计入）
+}
+
+// StartRetentionScheduler 启动定时任务，按各应用的保留策略定期清理
+func (do *DeploymentOrchestrator) StartRetentionScheduler() {
+	go func() {
+		ticker := time.NewTicker(retentionInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			do.runScheduledRetention()
+		}
+	}()
+}
+
+// runScheduledRetention 对所有设置了保留策略的应用执行一次清理
+func (do *DeploymentOrchestrator) runScheduledRetention() {
+	applications, err := models.ListApplicationsWithRetention()
+	if err != nil {
+		logman.Error("查询设置了保留策略的应用失败", "error", err)
+		return
+	}
+	for _, application := range applications {
+		if _, err := do.ApplyRetention(application); err != nil {
+			logman.Error("执行保留策略失败", "app_name", application.Name, "error", err)
+		}
+	}
+}
+
+// ApplyRetention 按应用的保留策略删除过期的 Release、镜像以及不再使用的 unit，并回收内置镜像仓库中不再引用的 blob。
+// 当前版本、回滚目标（上一个成功部署的版本）、进行中的部署和金丝雀发布涉及的版本始终保留
+func (do *DeploymentOrchestrator) ApplyRetention(application *models.Application) (*RetentionResult, error) {
+	if !application.Retention.Enabled() {
+		return nil, ErrRetentionPolicyNotSet
+	}
+
+	value, _ := retentionLocks.LoadOrStore(application.ID, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 重新读取，避免使用调用方过期的 ActiveReleaseID
+	application, err := models.GetApplicationByID(application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取应用失败: %w", err)
+	}
+	project, err := models.GetProjectByID(application.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目信息失败: %w", err)
+	}
+	releases, err := models.ListReleasesByAppID(application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Release 失败: %w", err)
+	}
+	deployments, err := models.ListDeploymentsByAppID(application.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询部署记录失败: %w", err)
+	}
+
+	pinnedReleases, liveDeployments, err := retentionPins(application, deployments)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]utils.RetentionCandidate, 0, len(releases))
+	for _, release := range releases {
+		// 构建中的 Release 还没有最终结果，不参与清理
+		building := release.Status != "success" && release.Status != "failed"
+		candidates = append(candidates, utils.RetentionCandidate{
+			ID:        release.ID.String(),
+			CreatedAt: release.CreatedAt,
+			Pinned:    pinnedReleases[release.ID] || building,
+		})
+	}
+	expired := make(map[uuid.UUID]bool)
+	for _, id := range application.Retention.SelectExpired(candidates, time.Now()) {
+		expired[uuid.MustParse(id)] = true
+	}
+
+	result := &RetentionResult{}
+
+	// 1. 停止并删除不再需要的 unit：只保留当前版本、回滚目标和进行中的部署
+	dir := quadletDir(project)
+	for _, deployment := range deployments {
+		if liveDeployments[deployment.ID] || deployment.ServiceName == "" {
+			continue
+		}
+		quadletFile := filepath.Join(dir, strings.TrimSuffix(deployment.ServiceName, ".service")+".container")
+		info, err := os.Stat(quadletFile)
+		if err != nil {
+			continue // 已清理过
+		}
+		if err := do.stopUserService(deployment.ServiceName, project); err != nil {
+			logman.Warn("停止过期的服务失败", "service", deployment.ServiceName, "error", err)
+		}
+		if err := os.Remove(quadletFile); err != nil {
+			logman.Warn("删除 Quadlet 文件失败", "file", quadletFile, "error", err)
+			continue
+		}
+		result.RemovedUnits++
+		result.ReclaimedBytes += info.Size()
+	}
+	if result.RemovedUnits > 0 {
+		if err := do.reloadUserSystemdDaemon(project); err != nil {
+			logman.Warn("重新加载 systemd daemon 失败", "app_name", application.Name, "error", err)
+		}
+	}
+
+	// 2. 删除过期 Release 的镜像和记录；镜像仍被保留的 Release 引用时不删除
+	var removedReleases []*models.Release
+	keptImages := make(map[string]bool)
+	for _, release := range releases {
+		if !expired[release.ID] {
+			keptImages[release.ImageName] = true
+		}
+	}
+	for _, release := range releases {
+		if !expired[release.ID] {
+			continue
+		}
+		if release.ImageName != "" && !keptImages[release.ImageName] {
+			if reclaimed, err := removeReleaseImage(release.ImageName); err != nil {
+				// 镜像可能已被手动删除，记录仍然清理
+				logman.Warn("删除镜像失败", "image", release.ImageName, "error", err)
+			} else {
+				result.RemovedImages++
+				result.ReclaimedBytes += reclaimed
+			}
+			keptImages[release.ImageName] = true // 多个过期 Release 共用一个镜像时只删除一次
+		}
+		if err := models.DeleteReleaseWithDeployments(release.ID); err != nil {
+			logman.Error("删除 Release 记录失败", "release_id", release.ID, "error", err)
+			continue
+		}
+		result.RemovedReleases++
+		removedReleases = append(removedReleases, release)
+	}
+
+	// 3. 删除已清理的推送 Release 在内置镜像仓库中的标签，并回收不再引用的清单和 blob
+	result.ReclaimedBytes += pruneApplicationImageLayout(application, removedReleases)
+
+	if err := models.UpdateApplicationRetentionResult(application.ID, time.Now(), result.ReclaimedBytes); err != nil {
+		logman.Warn("记录清理结果失败", "app_name", application.Name, "error", err)
+	}
+	logman.Info("保留策略执行完成", "app_name", application.Name,
+		"removed_releases", result.RemovedReleases, "removed_images", result.RemovedImages,
+		"removed_units", result.RemovedUnits, "reclaimed_bytes", result.ReclaimedBytes)
+	return result, nil
+}
+
+// retentionPins 返回始终保留的 Release，以及需要继续运行的部署：
+// 当前版本和回滚目标各自最近一次成功的部署、进行中的部署、进行中的金丝雀发布的新旧两个部署；
+// 等待执行的定时部署引用的 Release 同样保留
+func retentionPins(application *models.Application, deployments []*models.Deployment) (map[uuid.UUID]bool, map[uuid.UUID]bool, error) {
+	pinnedReleases := make(map[uuid.UUID]bool)
+	liveDeployments := make(map[uuid.UUID]bool)
+
+	if application.ActiveReleaseID != nil {
+		pinnedReleases[*application.ActiveReleaseID] = true
+	}
+	// deployments 按创建时间倒序，第一个不属于当前版本的成功部署即回滚目标
+	for _, deployment := range deployments {
+		if deployment.Status == "success" && (application.ActiveReleaseID == nil || deployment.ReleaseID != *application.ActiveReleaseID) {
+			pinnedReleases[deployment.ReleaseID] = true
+			break
+		}
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, deployment := range deployments {
+		switch {
+		case deployment.Status == models.DeploymentStatusRejected || deployment.Status == models.DeploymentStatusExpired:
+			// 未获批准，从未运行
+		case deployment.Status != "success" && deployment.Status != "failed":
+			// 进行中或等待审批的部署
+			pinnedReleases[deployment.ReleaseID] = true
+			liveDeployments[deployment.ID] = true
+		case deployment.Status == "success" && pinnedReleases[deployment.ReleaseID] && !seen[deployment.ReleaseID]:
+			seen[deployment.ReleaseID] = true
+			liveDeployments[deployment.ID] = true
+		}
+	}
+
+	scheduled, err := models.ListScheduledDeploymentsByAppID(application.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询定时部署失败: %w", err)
+	}
+	for _, s := range scheduled {
+		// 已认领但尚未创建部署的定时部署也可能马上用到该 Release
+		waiting := s.Status == models.ScheduledDeploymentStatusPending ||
+			(s.Status == models.ScheduledDeploymentStatusStarted && s.DeploymentID == nil)
+		if waiting && s.ReleaseID != nil {
+			pinnedReleases[*s.ReleaseID] = true
+		}
+	}
+
+	canary, err := models.GetActiveCanaryReleaseByAppID(application.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询金丝雀发布失败: %w", err)
+	}
+	if canary != nil {
+		for _, deployment := range deployments {
+			if deployment.ID == canary.DeploymentID || deployment.ID == canary.BaselineDeploymentID {
+				pinnedReleases[deployment.ReleaseID] = true
+				liveDeployments[deployment.ID] = true
+			}
+		}
+	}
+	return pinnedReleases, liveDeployments, nil
+}
+
+// removeReleaseImage 删除 Release 的镜像，返回回收的空间；镜像的其他标签仍存在时只删除标签，不计入回收空间
+func removeReleaseImage(imageName string) (int64, error) {
+	var size int64
+	if output, err := exec.Command("podman", "image", "inspect", "--format", "{{.Size}}", imageName).Output(); err == nil {
+		size, _ = strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	}
+
+	output, err := exec.Command("podman", "rmi", imageName).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	if !strings.Contains(string(output), "Deleted:") {
+		return 0, nil
+	}
+	return size, nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionPinsReleasesOfPendingSchedules(t *testing.T) {
+	setupTestDB(t)
+	app, err := models.CreateApplication(uuid.New(), "retention-app", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+
+	createScheduled := func(status string) *models.Release {
+		release, err := models.CreateRelease(app.ID, "retention-app:"+status, models.JSONB{}, "success")
+		assert.NoError(t, err)
+		scheduled := &models.ScheduledDeployment{ApplicationID: app.ID, ReleaseID: &release.ID, ScheduledAt: time.Now().Add(time.Hour)}
+		assert.NoError(t, models.CreateScheduledDeployment(scheduled))
+		if status != models.ScheduledDeploymentStatusPending {
+			claimed, err := models.ClaimScheduledDeployment(scheduled.ID, status)
+			assert.NoError(t, err)
+			assert.True(t, claimed)
+		}
+		return release
+	}
+	pending := createScheduled(models.ScheduledDeploymentStatusPending)
+	cancelled := createScheduled(models.ScheduledDeploymentStatusCancelled)
+
+	pinned, _, err := retentionPins(app, nil)
+	assert.NoError(t, err)
+	assert.True(t, pinned[pending.ID], "等待执行的定时部署引用的 Release 应保留")
+	assert.False(t, pinned[cancelled.ID])
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/OrbitDeploy/fastcaddy"
+	"github.com/google/uuid"
+)
+
+const (
+	letsEncryptStagingCA = "https://acme-staging-v02.api.letsencrypt.org/directory"
+	// caddyHTTPSAddr 本机 Caddy 的 HTTPS 监听地址，用于读取实际对外提供的证书
+	caddyHTTPSAddr = "127.0.0.1:443"
+	// certificateProbeInterval 列表接口在后台重新读取证书过期时间的最短间隔
+	certificateProbeInterval = time.Hour
+)
+
+// certificateProbes 正在后台读取证书的路由，避免同一路由重复探测
+var certificateProbes sync.Map // map[uuid.UUID]struct{}
+
+// caddyClient 路由和证书同步用到的 Caddy 管理接口，由 *fastcaddy.FastCaddy 实现
+type caddyClient interface {
+	AddReverseProxy(fromHost, toURL string) error
+	DeleteRoute(id string) error
+	HasID(id string) bool
+	HasPath(path string) bool
+	GetConfig(path string) (map[string]interface{}, error)
+	PutConfig(data interface{}, path, method string) error
+}
+
+// newCaddyClient 创建 Caddy 客户端，测试中替换为不依赖本机 Caddy 的实现
+var newCaddyClient = func() caddyClient { return fastcaddy.New() }
+
+// RoutingTLSSettings 路由证书设置（明文），用于校验并推送到 Caddy
+type RoutingTLSSettings struct {
+	Mode           string
+	CertificatePEM string
+	PrivateKeyPEM  string
+	ACMEEmail      string
+	ACMEStaging    bool
+	DNSProvider    string
+	DNSCredentials map[string]string
+}
+
+// ConfigureRoutingTLS 校验并保存路由的证书设置，同时同步到 Caddy 的 TLS 应用配置
+// 模式为 auto 时清除自定义设置，回到 Caddy 默认策略，此时返回 nil 配置
+func ConfigureRoutingTLS(routingID uuid.UUID, settings RoutingTLSSettings) (*models.RoutingTLSConfig, error) {
+	routing, err := models.GetRoutingByID(routingID)
+	if err != nil {
+		return nil, fmt.Errorf("获取路由记录失败: %v", err)
+	}
+
+	var expiresAt *time.Time
+	switch settings.Mode {
+	case models.RoutingTLSModeAuto:
+		return nil, RemoveRoutingTLS(routing)
+	case models.RoutingTLSModeCustom:
+		info, err := utils.ParseCertificateBundle(settings.CertificatePEM, settings.PrivateKeyPEM, routing.DomainName)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt = &info.NotAfter
+	case models.RoutingTLSModeACME:
+		if settings.DNSProvider != "" && len(settings.DNSCredentials) == 0 {
+			return nil, fmt.Errorf("DNS 提供商 %s 需要提供凭据", settings.DNSProvider)
+		}
+		settings.CertificatePEM = ""
+		settings.PrivateKeyPEM = ""
+	default:
+		return nil, fmt.Errorf("无效的证书模式: %s", settings.Mode)
+	}
+
+	previous, err := models.FindRoutingTLSConfig(routing.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取证书设置失败: %v", err)
+	}
+
+	fc := fastcaddy.New()
+	removeRoutingTLSFromCaddy(fc, routing.DomainName)
+
+	fmt.Printf("🔐 [证书设置] 推送 %s 证书设置到 Caddy (模式: %s)\n", routing.DomainName, settings.Mode)
+	if err := applyRoutingTLSToCaddy(fc, routing.DomainName, settings); err != nil {
+		restoreRoutingTLS(fc, routing.DomainName, previous)
+		return nil, fmt.Errorf("通过 Caddy 配置证书失败: %v", err)
+	}
+
+	config, err := models.UpsertRoutingTLSConfig(routing.ID, settings.Mode, settings.CertificatePEM, settings.PrivateKeyPEM, expiresAt, settings.ACMEEmail, settings.ACMEStaging, settings.DNSProvider, settings.DNSCredentials)
+	if err != nil {
+		removeRoutingTLSFromCaddy(fc, routing.DomainName)
+		restoreRoutingTLS(fc, routing.DomainName, previous)
+		return nil, fmt.Errorf("保存证书设置失败: %v", err)
+	}
+
+	return config, nil
+}
+
+// RemoveRoutingTLS 删除路由的证书设置及其 Caddy 配置
+func RemoveRoutingTLS(routing *models.Routing) error {
+	removeRoutingTLSFromCaddy(fastcaddy.New(), routing.DomainName)
+	return models.DeleteRoutingTLSConfigByRoutingID(routing.ID)
+}
+
+// MoveRoutingTLS 路由域名变更后，将已有的证书设置迁移到新域名
+func MoveRoutingTLS(routing *models.Routing, oldDomain string) error {
+	config, err := models.FindRoutingTLSConfig(routing.ID)
+	if err != nil || config == nil {
+		return err
+	}
+
+	fc := fastcaddy.New()
+	removeRoutingTLSFromCaddy(fc, oldDomain)
+
+	settings, err := routingTLSSettingsFromConfig(config)
+	if err != nil {
+		return err
+	}
+	if settings.Mode == models.RoutingTLSModeCustom {
+		if _, err := utils.ParseCertificateBundle(settings.CertificatePEM, settings.PrivateKeyPEM, routing.DomainName); err != nil {
+			// 证书不再覆盖新域名，回到默认策略
+			log.Printf("Custom certificate no longer matches %s, falling back to automatic TLS: %v", routing.DomainName, err)
+			return models.DeleteRoutingTLSConfigByRoutingID(routing.ID)
+		}
+	}
+	return applyRoutingTLSToCaddy(fc, routing.DomainName, settings)
+}
+
+// GetRoutingCertificateExpiry 返回路由域名证书的过期时间：
+// 自定义证书直接使用保存的过期时间，其它模式从本机 Caddy 读取实际提供的证书并保存，读取失败时返回上次的结果
+func GetRoutingCertificateExpiry(routing *models.Routing, config *models.RoutingTLSConfig) *time.Time {
+	if config != nil && config.Mode == models.RoutingTLSModeCustom {
+		return config.CertificateExpiresAt
+	}
+
+	expiresAt, err := utils.ProbeCertificateExpiry(caddyHTTPSAddr, routing.DomainName, 2*time.Second)
+	if err := models.UpdateRoutingCertificateExpiry(routing.ID, expiresAt, time.Now()); err != nil {
+		log.Printf("Failed to save certificate expiry of %s: %v", routing.DomainName, err)
+	}
+	if err != nil {
+		return routing.CertificateExpiresAt
+	}
+	routing.CertificateExpiresAt = expiresAt
+	return expiresAt
+}
+
+// StoredRoutingCertificateExpiry 返回已保存的证书过期时间，不连接 Caddy：
+// 自定义证书使用上传时解析的过期时间，其它模式使用最近一次读取的结果
+func StoredRoutingCertificateExpiry(routing *models.Routing, config *models.RoutingTLSConfig) *time.Time {
+	if config != nil && config.Mode == models.RoutingTLSModeCustom {
+		return config.CertificateExpiresAt
+	}
+	return routing.CertificateExpiresAt
+}
+
+// RefreshRoutingCertificateExpiries 在后台重新读取超过 certificateProbeInterval 未检查的路由证书，不阻塞调用方
+func RefreshRoutingCertificateExpiries(routings []*models.Routing) {
+	for _, routing := range routings {
+		if routing.CertificateCheckedAt != nil && time.Since(*routing.CertificateCheckedAt) < certificateProbeInterval {
+			continue
+		}
+		if _, running := certificateProbes.LoadOrStore(routing.ID, struct{}{}); running {
+			continue
+		}
+		go func(routing *models.Routing) {
+			defer certificateProbes.Delete(routing.ID)
+			config, err := models.FindRoutingTLSConfig(routing.ID)
+			if err != nil || (config != nil && config.Mode == models.RoutingTLSModeCustom) {
+				return
+			}
+			GetRoutingCertificateExpiry(routing, config)
+		}(routing)
+	}
+}
+
+// routingTLSSettingsFromConfig 解密已保存的证书设置
+func routingTLSSettingsFromConfig(config *models.RoutingTLSConfig) (RoutingTLSSettings, error) {
+	privateKey, err := config.GetDecryptedPrivateKey()
+	if err != nil {
+		return RoutingTLSSettings{}, fmt.Errorf("解密私钥失败: %v", err)
+	}
+	credentials, err := config.GetDecryptedDNSCredentials()
+	if err != nil {
+		return RoutingTLSSettings{}, fmt.Errorf("解密 DNS 凭据失败: %v", err)
+	}
+
+	return RoutingTLSSettings{
+		Mode:           config.Mode,
+		CertificatePEM: config.CertificatePEM,
+		PrivateKeyPEM:  privateKey,
+		ACMEEmail:      config.ACMEEmail,
+		ACMEStaging:    config.ACMEStaging,
+		DNSProvider:    config.DNSProvider,
+		DNSCredentials: credentials,
+	}, nil
+}
+
+// restoreRoutingTLS 失败时尽量恢复之前的证书设置
+func restoreRoutingTLS(fc caddyClient, domain string, previous *models.RoutingTLSConfig) {
+	if previous == nil {
+		return
+	}
+	settings, err := routingTLSSettingsFromConfig(previous)
+	if err == nil {
+		err = applyRoutingTLSToCaddy(fc, domain, settings)
+	}
+	if err != nil {
+		log.Printf("CRITICAL: Failed to restore TLS config for %s: %v", domain, err)
+	}
+}
+
+func routingCertificateID(domain string) string {
+	return "orbit-cert-" + domain
+}
+
+func routingTLSPolicyID(domain string) string {
+	return "orbit-tls-route-" + domain
+}
+
+// applyRoutingTLSToCaddy 将证书设置写入 Caddy：自定义证书加入 load_pem，ACME 设置作为针对该域名的自动化策略
+func applyRoutingTLSToCaddy(fc caddyClient, domain string, settings RoutingTLSSettings) error {
+	switch settings.Mode {
+	case models.RoutingTLSModeCustom:
+		// Caddy 不会为已手动加载证书的域名再自动签发
+		if err := ensureCaddyObjectPath(fc, caddyTLSPath+"/certificates"); err != nil {
+			return err
+		}
+		certificate := map[string]interface{}{
+			"@id":         routingCertificateID(domain),
+			"certificate": settings.CertificatePEM,
+			"key":         settings.PrivateKeyPEM,
+			"tags":        []string{domain},
+		}
+		return appendCaddyArrayItem(fc, caddyTLSPath+"/certificates", "load_pem", certificate)
+
+	case models.RoutingTLSModeACME:
+		issuer := map[string]interface{}{
+			"module": "acme",
+		}
+		if settings.ACMEEmail != "" {
+			issuer["email"] = settings.ACMEEmail
+		}
+		if settings.ACMEStaging {
+			issuer["ca"] = letsEncryptStagingCA
+		}
+		if settings.DNSProvider != "" {
+			provider := map[string]interface{}{
+				"name": settings.DNSProvider,
+			}
+			for key, value := range settings.DNSCredentials {
+				provider[key] = value
+			}
+			issuer["challenges"] = map[string]interface{}{
+				"dns": map[string]interface{}{
+					"provider": provider,
+				},
+			}
+		}
+
+		policy := map[string]interface{}{
+			"@id":      routingTLSPolicyID(domain),
+			"subjects": []string{domain},
+			"issuers":  []interface{}{issuer},
+		}
+		return prependTLSPolicy(fc, policy)
+	}
+
+	return nil
+}
+
+// removeRoutingTLSFromCaddy 删除该域名在 Caddy 中的自定义证书和自动化策略
+func removeRoutingTLSFromCaddy(fc caddyClient, domain string) {
+	for _, id := range []string{routingCertificateID(domain), routingTLSPolicyID(domain)} {
+		if !fc.HasID(id) {
+			continue
+		}
+		if err := fc.DeleteRoute(id); err != nil {
+			log.Printf("Warning: Failed to delete %s from Caddy: %v", id, err)
+		}
+	}
+}
+
+// prependTLSPolicy 将 TLS 自动化策略插入到最前面，优先于没有 subjects 的兜底策略
+func prependTLSPolicy(fc caddyClient, policy map[string]interface{}) error {
+	if err := ensureCaddyObjectPath(fc, caddyTLSAutomationPath); err != nil {
+		return err
+	}
+
+	automation, err := fc.GetConfig(caddyTLSAutomationPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := automation["policies"]; !ok {
+		return fc.PutConfig([]interface{}{policy}, caddyTLSAutomationPath+"/policies", "POST")
+	}
+	return fc.PutConfig(policy, caddyTLSAutomationPath+"/policies/0", "PUT")
+}
+
+// appendCaddyArrayItem 向配置对象下的数组追加元素，数组不存在时创建
+func appendCaddyArrayItem(fc caddyClient, parentPath, key string, item interface{}) error {
+	parent, err := fc.GetConfig(parentPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := parent[key]; !ok {
+		return fc.PutConfig([]interface{}{item}, parentPath+"/"+key, "POST")
+	}
+	return fc.PutConfig(item, parentPath+"/"+key, "POST")
+}
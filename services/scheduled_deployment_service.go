@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// scheduledDeploymentInterval 检查到期定时部署的间隔
+const scheduledDeploymentInterval = 30 * time.Second
+
+// 定时部署的校验错误，调用方据此返回 4xx
+var (
+	ErrScheduleInPast                = errors.New("计划时间必须晚于当前时间")
+	ErrScheduledDeploymentNotPending = errors.New("定时部署已开始或已取消")
+)
+
+// ScheduleDeployment 创建定时部署，计划时间处于冻结窗口且没有填写强制部署的原因时返回 ErrDeploymentFrozen
+func (do *DeploymentOrchestrator) ScheduleDeployment(application *models.Application, scheduled *models.ScheduledDeployment) error {
+	if !scheduled.ScheduledAt.After(time.Now()) {
+		return ErrScheduleInPast
+	}
+	switch scheduled.Strategy {
+	case "", StrategyDirect, StrategyCanary:
+	default:
+		return fmt.Errorf("不支持的部署策略: %s", scheduled.Strategy)
+	}
+	if _, err := checkDeployFreeze(application, scheduled.ScheduledAt, scheduled.FreezeOverrideReason); err != nil {
+		return err
+	}
+
+	scheduled.ApplicationID = application.ID
+	if err := models.CreateScheduledDeployment(scheduled); err != nil {
+		return fmt.Errorf("创建定时部署失败: %w", err)
+	}
+	logman.Info("定时部署已创建", "app_name", application.Name, "scheduled_id", scheduled.ID, "scheduled_at", scheduled.ScheduledAt)
+	return nil
+}
+
+// CancelScheduledDeployment 取消尚未开始的定时部署
+func (do *DeploymentOrchestrator) CancelScheduledDeployment(id uuid.UUID) error {
+	ok, err := models.ClaimScheduledDeployment(id, models.ScheduledDeploymentStatusCancelled)
+	if err != nil {
+		return fmt.Errorf("取消定时部署失败: %w", err)
+	}
+	if !ok {
+		return ErrScheduledDeploymentNotPending
+	}
+	logman.Info("定时部署已取消", "scheduled_id", id)
+	return nil
+}
+
+// StartDeploymentScheduler 启动定时任务执行到期的定时部署，启动时立即执行服务停止期间错过的计划
+func (do *DeploymentOrchestrator) StartDeploymentScheduler() {
+	go func() {
+		do.runDueScheduledDeployments()
+		ticker := time.NewTicker(scheduledDeploymentInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			do.runDueScheduledDeployments()
+		}
+	}()
+}
+
+// runDueScheduledDeployments 为所有到期的定时部署创建部署
+func (do *DeploymentOrchestrator) runDueScheduledDeployments() {
+	due, err := models.ListDueScheduledDeployments(time.Now())
+	if err != nil {
+		logman.Error("查询到期的定时部署失败", "error", err)
+		return
+	}
+	for _, scheduled := range due {
+		do.startScheduledDeployment(scheduled)
+	}
+}
+
+// startScheduledDeployment 创建定时部署对应的部署，冻结窗口按实际执行的时刻检查（服务重启后补执行的计划可能已晚于计划时间）。
+// 创建时填写的强制部署原因只对计划时间所在的冻结窗口有效，执行时处于其他窗口则按没有原因处理，部署失败
+func (do *DeploymentOrchestrator) startScheduledDeployment(scheduled *models.ScheduledDeployment) {
+	if ok, err := models.ClaimScheduledDeployment(scheduled.ID, models.ScheduledDeploymentStatusStarted); err != nil || !ok {
+		return // 已被取消
+	}
+
+	deployment, err := do.createScheduledDeployment(scheduled)
+	if err != nil {
+		logman.Error("执行定时部署失败", "scheduled_id", scheduled.ID, "error", err)
+		if err := models.FinishScheduledDeployment(scheduled.ID, models.ScheduledDeploymentStatusFailed, nil, err.Error()); err != nil {
+			logman.Error("更新定时部署状态失败", "scheduled_id", scheduled.ID, "error", err)
+		}
+		if application, appErr := models.GetApplicationByID(scheduled.ApplicationID); appErr == nil {
+			go func() {
+				if err := utils.SendErrorNotification("定时部署失败",
+					fmt.Sprintf("应用 %s 计划于 %s 的部署未能执行：%s", application.Name, scheduled.ScheduledAt.Format("2006-01-02 15:04"), err.Error()),
+					utils.WithService(application.Name),
+				); err != nil {
+					logman.Warn("发送定时部署通知失败", "app_name", application.Name, "error", err)
+				}
+			}()
+		}
+		return
+	}
+
+	if err := models.FinishScheduledDeployment(scheduled.ID, models.ScheduledDeploymentStatusStarted, &deployment.ID, ""); err != nil {
+		logman.Error("更新定时部署状态失败", "scheduled_id", scheduled.ID, "error", err)
+	}
+	logman.Info("定时部署已开始", "scheduled_id", scheduled.ID, "deployment_id", deployment.ID)
+}
+
+// createScheduledDeployment 按执行时刻的冻结窗口为定时部署创建部署
+func (do *DeploymentOrchestrator) createScheduledDeployment(scheduled *models.ScheduledDeployment) (*models.Deployment, error) {
+	application, err := models.GetApplicationByID(scheduled.ApplicationID)
+	if err != nil {
+		return nil, fmt.Errorf("获取应用信息失败: %w", err)
+	}
+	overrideReason, err := carriedFreezeOverride(application, scheduled.ScheduledAt, time.Now(), scheduled.FreezeOverrideReason)
+	if err != nil {
+		return nil, err
+	}
+	return do.CreateDeployment(scheduled.ApplicationID, CreateDeploymentRequest{
+		ReleaseID:            scheduled.ReleaseID,
+		GitRef:               scheduled.GitRef,
+		Strategy:             scheduled.Strategy,
+		RequestedBy:          scheduled.RequestedBy,
+		FreezeOverrideReason: overrideReason,
+	})
+}
@@ -0,0 +1,539 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/opentdp/go-helper/dborm"
+	"github.com/opentdp/go-helper/logman"
+	"gorm.io/gorm"
+)
+
+// 声明式配置变更动作
+const (
+	SpecActionCreate = "create"
+	SpecActionUpdate = "update"
+	SpecActionDelete = "delete"
+)
+
+// 导出 orbitdeploy.toml 时使用的固定值，服务端不区分环境
+const (
+	specAPIVersion  = "webdeploy.io/v1"
+	specEnvironment = "production"
+)
+
+// defaultSpecRoutingPort 应用尚未部署时新路由指向的端口，与界面添加路由的默认值一致
+const defaultSpecRoutingPort = 8080
+
+// SpecChange 执行计划中的一项变更
+type SpecChange struct {
+	Resource string // application, volume, routing, env
+	Action   string // create, update, delete
+	Name     string // 字段名、挂载路径、域名或变量名
+	Old      string
+	New      string
+}
+
+// SpecPlan 将 orbitdeploy.toml 应用到应用上的执行计划
+type SpecPlan struct {
+	Changes          []SpecChange
+	Warnings         []string
+	RedeployRequired bool // 端口、卷、资源或环境变量需要重新部署才能生效，路由立即生效
+}
+
+// HasChanges 判断执行计划是否包含指定资源的变更
+func (p *SpecPlan) HasChanges(resource string) bool {
+	for _, change := range p.Changes {
+		if change.Resource == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// specReconciler 计算 spec 与当前状态的差异，并记录需要写入的目标状态
+type specReconciler struct {
+	app  *models.Application
+	plan *SpecPlan
+
+	appChanged bool
+	targetPort int
+	volumes    []models.VolumeMount
+	limits     utils.ResourceLimits
+
+	envSet   []models.EnvironmentVariableInput
+	envUnset []string
+
+	addDomains     []string
+	routingPort    int // 新路由指向的本机端口
+	removeRoutings []*models.Routing
+}
+
+// PlanApplicationSpec 计算应用 spec 的执行计划，不做任何修改
+func PlanApplicationSpec(app *models.Application, project *models.Project, spec *utils.DeploymentSpec) (*SpecPlan, error) {
+	r, err := newSpecReconciler(app, project, spec)
+	if err != nil {
+		return nil, err
+	}
+	return r.plan, nil
+}
+
+// ApplyApplicationSpec 将 spec 应用到应用上，使应用、卷、路由和环境变量与 spec 一致
+func ApplyApplicationSpec(app *models.Application, project *models.Project, spec *utils.DeploymentSpec) (*SpecPlan, error) {
+	r, err := newSpecReconciler(app, project, spec)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.apply(); err != nil {
+		return nil, err
+	}
+	return r.plan, nil
+}
+
+func newSpecReconciler(app *models.Application, project *models.Project, spec *utils.DeploymentSpec) (*specReconciler, error) {
+	if spec.AppName != app.Name {
+		return nil, fmt.Errorf("app_name %s 与目标应用 %s 不一致", spec.AppName, app.Name)
+	}
+	if spec.Project != "" && spec.Project != project.Name {
+		return nil, fmt.Errorf("project %s 与应用所属项目 %s 不一致，不支持通过配置文件迁移项目", spec.Project, project.Name)
+	}
+
+	r := &specReconciler{app: app, plan: &SpecPlan{Changes: []SpecChange{}, Warnings: []string{}}}
+	r.collectWarnings(spec)
+
+	container := spec.PrimaryContainer()
+	if err := r.diffApplication(container, spec.EffectiveResources()); err != nil {
+		return nil, err
+	}
+	r.diffVolumes(container.Volumes)
+	if err := r.diffRoutings(container.Domains); err != nil {
+		return nil, err
+	}
+	if err := r.diffEnv(container.ExtraEnv); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *specReconciler) change(resource, action, name, old, new string) {
+	r.plan.Changes = append(r.plan.Changes, SpecChange{Resource: resource, Action: action, Name: name, Old: old, New: new})
+	if resource != "routing" {
+		r.plan.RedeployRequired = true
+	}
+}
+
+// collectWarnings 记录 spec 中服务端尚不支持的配置，避免被静默忽略
+func (r *specReconciler) collectWarnings(spec *utils.DeploymentSpec) {
+	warn := func(format string, args ...interface{}) {
+		r.plan.Warnings = append(r.plan.Warnings, fmt.Sprintf(format, args...))
+	}
+	if len(spec.Containers) > 1 {
+		warn("每个应用只运行一个容器，containers[1:] 将被忽略")
+	}
+	if spec.Replicas > 1 {
+		warn("暂不支持多副本，replicas = %d 将被忽略", spec.Replicas)
+	}
+	if spec.Image != nil || spec.PrimaryContainer().Image != nil {
+		warn("image 由 orbitctl deploy 构建上传的版本决定，不通过 apply 修改")
+	}
+	container := spec.PrimaryContainer()
+	if spec.Healthcheck != nil || container.Healthcheck != nil {
+		warn("healthcheck 暂不支持，将被忽略")
+	}
+	if len(container.Labels) > 0 || len(container.Annotations) > 0 {
+		warn("labels 和 annotations 暂不支持，将被忽略")
+	}
+	for i, d := range container.Domains {
+		if d.Path != "" {
+			warn("containers[0].domains[%d].path 暂不支持，%s 将代理整个域名", i, d.Host)
+		}
+	}
+}
+
+func (r *specReconciler) diffApplication(container *utils.SpecContainer, resources *utils.SpecResources) error {
+	r.targetPort = r.app.TargetPort
+	if container.PublishPort != 0 && container.PublishPort != r.app.TargetPort {
+		r.change("application", SpecActionUpdate, "target_port", strconv.Itoa(r.app.TargetPort), strconv.Itoa(container.PublishPort))
+		r.targetPort = container.PublishPort
+		r.appChanged = true
+	}
+
+	// spec 只描述 cpu 和 memory，其他资源限制保持不变；未配置表示不限制
+	r.limits = r.app.Resources
+	r.limits.CPUQuota, r.limits.MemoryLimitMB = 0, 0
+	if resources != nil {
+		var err error
+		if resources.CPU != "" {
+			if r.limits.CPUQuota, err = utils.ParseCPUQuantity(resources.CPU); err != nil {
+				return fmt.Errorf("resources.cpu: %w", err)
+			}
+		}
+		if resources.Memory != "" {
+			if r.limits.MemoryLimitMB, err = utils.ParseMemoryMB(resources.Memory); err != nil {
+				return fmt.Errorf("resources.memory: %w", err)
+			}
+		}
+	}
+	if r.limits.CPUQuota != r.app.Resources.CPUQuota {
+		r.change("application", SpecActionUpdate, "resources.cpu", formatSpecCPU(r.app.Resources.CPUQuota), formatSpecCPU(r.limits.CPUQuota))
+		r.appChanged = true
+	}
+	if r.limits.MemoryLimitMB != r.app.Resources.MemoryLimitMB {
+		r.change("application", SpecActionUpdate, "resources.memory", formatSpecMemory(r.app.Resources.MemoryLimitMB), formatSpecMemory(r.limits.MemoryLimitMB))
+		r.appChanged = true
+	}
+	if err := r.limits.Validate(utils.DetectHostCapacity()); err != nil {
+		return fmt.Errorf("resources: %w", err)
+	}
+	return nil
+}
+
+func (r *specReconciler) diffVolumes(specVolumes []utils.SpecVolume) {
+	current := make(map[string]models.VolumeMount)
+	for _, m := range models.ParseVolumeMounts(r.app.Volumes) {
+		current[m.ContainerPath] = m
+	}
+
+	r.volumes = make([]models.VolumeMount, 0, len(specVolumes))
+	desired := make(map[string]bool)
+	for _, v := range specVolumes {
+		mount := models.VolumeMount{HostPath: v.Source, ContainerPath: v.Target, ReadOnly: v.ReadOnly}
+		r.volumes = append(r.volumes, mount)
+		desired[v.Target] = true
+
+		old, ok := current[v.Target]
+		switch {
+		case !ok:
+			r.change("volume", SpecActionCreate, v.Target, "", mount.QuadletValue())
+			r.appChanged = true
+		case old != mount:
+			r.change("volume", SpecActionUpdate, v.Target, old.QuadletValue(), mount.QuadletValue())
+			r.appChanged = true
+		}
+	}
+	for _, m := range models.ParseVolumeMounts(r.app.Volumes) {
+		if !desired[m.ContainerPath] {
+			r.change("volume", SpecActionDelete, m.ContainerPath, m.QuadletValue(), "")
+			r.appChanged = true
+		}
+	}
+}
+
+// diffRoutings 自动生成的子域名由系统管理，不参与对比
+func (r *specReconciler) diffRoutings(domains []utils.SpecDomain) error {
+	routings, err := models.ListRoutings()
+	if err != nil {
+		return fmt.Errorf("查询路由信息失败: %w", err)
+	}
+
+	owner := make(map[string]*models.Routing)
+	for _, routing := range routings {
+		owner[routing.DomainName] = routing
+	}
+
+	r.routingPort, err = specRoutingPort(r.app)
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]bool)
+	for i, d := range domains {
+		host, err := utils.NormalizeDomain(d.Host)
+		if err != nil {
+			return fmt.Errorf("containers[0].domains[%d].host 无效: %w", i, err)
+		}
+		desired[host] = true
+
+		routing, exists := owner[host]
+		if exists && routing.ApplicationID != r.app.ID {
+			return fmt.Errorf("containers[0].domains[%d].host %s 已被其他应用使用", i, host)
+		}
+		if !exists {
+			r.addDomains = append(r.addDomains, host)
+			r.change("routing", SpecActionCreate, host, "", strconv.Itoa(r.routingPort))
+		}
+	}
+
+	for _, routing := range routings {
+		if routing.ApplicationID != r.app.ID || routing.DomainName == r.app.GeneratedHostname {
+			continue
+		}
+		if !desired[routing.DomainName] {
+			r.removeRoutings = append(r.removeRoutings, routing)
+			r.change("routing", SpecActionDelete, routing.DomainName, strconv.Itoa(routing.HostPort), "")
+		}
+	}
+	return nil
+}
+
+// diffEnv 只管理普通变量；密钥变量通过 orbitctl env set --secret 管理，不会被 apply 删除或覆盖
+func (r *specReconciler) diffEnv(extraEnv map[string]string) error {
+	envVars, err := models.ListEnvironmentVariablesByApplicationID(r.app.ID)
+	if err != nil {
+		return fmt.Errorf("查询环境变量失败: %w", err)
+	}
+
+	current := make(map[string]string)
+	secrets := make(map[string]bool)
+	for _, envVar := range envVars {
+		if envVar.IsEncrypted {
+			secrets[envVar.Key] = true
+			continue
+		}
+		value, err := envVar.GetDecryptedValue()
+		if err != nil {
+			return fmt.Errorf("读取环境变量 %s 失败: %w", envVar.Key, err)
+		}
+		current[envVar.Key] = value
+	}
+
+	keys := make([]string, 0, len(extraEnv))
+	for key := range extraEnv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := extraEnv[key]
+		if secrets[key] {
+			r.plan.Warnings = append(r.plan.Warnings, fmt.Sprintf("extra_env.%s 已是密钥变量，跳过", key))
+			continue
+		}
+		old, ok := current[key]
+		switch {
+		case !ok:
+			r.change("env", SpecActionCreate, key, "", value)
+		case old != value:
+			r.change("env", SpecActionUpdate, key, old, value)
+		default:
+			continue
+		}
+		r.envSet = append(r.envSet, models.EnvironmentVariableInput{Key: key, Value: value})
+	}
+
+	for _, envVar := range envVars {
+		if envVar.IsEncrypted {
+			continue
+		}
+		if _, ok := extraEnv[envVar.Key]; !ok {
+			r.envUnset = append(r.envUnset, envVar.Key)
+			r.change("env", SpecActionDelete, envVar.Key, current[envVar.Key], "")
+		}
+	}
+	return nil
+}
+
+// apply 在一个事务中写入应用配置、环境变量和路由记录。Caddy 配置无法随事务回滚，
+// 在数据库写入之后修改，失败时撤销已做的修改并回滚事务
+func (r *specReconciler) apply() error {
+	// DNS 预检可能较慢，在事务开始前完成；要求所有权验证但未通过的域名直接拒绝
+	preflights := make(map[string]*DomainPreflightResult, len(r.addDomains))
+	for _, domain := range r.addDomains {
+		preflight, err := PreflightDomain(domain)
+		if err != nil {
+			logman.Warn("DNS 预检失败", "domain", domain, "error", err)
+			continue
+		}
+		if preflight.Status == models.RoutingDNSStatusUnverified {
+			return fmt.Errorf("添加路由 %s 失败: %s", domain, preflight.Message)
+		}
+		preflights[domain] = preflight
+	}
+
+	fc := newCaddyClient()
+	var undo []func()
+	var added []*models.Routing
+	err := dborm.Db.Transaction(func(tx *gorm.DB) error {
+		if r.appChanged {
+			if err := models.UpdateApplicationRuntimeSettingsInTx(tx, r.app.ID, r.targetPort, models.NewVolumesJSONB(r.volumes), r.limits); err != nil {
+				return fmt.Errorf("更新应用配置失败: %w", err)
+			}
+		}
+
+		if len(r.envSet) > 0 || len(r.envUnset) > 0 {
+			if _, _, _, err := models.SetEnvironmentVariablesInTx(tx, r.app.ID, r.envSet, r.envUnset); err != nil {
+				return fmt.Errorf("更新环境变量失败: %w", err)
+			}
+		}
+
+		tlsConfigs := make(map[string]*models.RoutingTLSConfig, len(r.removeRoutings))
+		for _, routing := range r.removeRoutings {
+			config, err := models.FindRoutingTLSConfig(routing.ID)
+			if err != nil {
+				return fmt.Errorf("获取路由 %s 的证书设置失败: %w", routing.DomainName, err)
+			}
+			tlsConfigs[routing.DomainName] = config
+			if err := models.DeleteRoutingTLSConfigByRoutingIDInTx(tx, routing.ID); err != nil {
+				return fmt.Errorf("删除路由 %s 的证书设置失败: %w", routing.DomainName, err)
+			}
+			if err := models.DeleteRoutingInTx(tx, routing.ID); err != nil {
+				return fmt.Errorf("删除路由 %s 失败: %w", routing.DomainName, err)
+			}
+		}
+		for _, domain := range r.addDomains {
+			routing, err := models.CreateRoutingInTx(tx, r.app.ID, domain, r.routingPort, true)
+			if err != nil {
+				return fmt.Errorf("添加路由 %s 失败: %w", domain, err)
+			}
+			added = append(added, routing)
+		}
+
+		for _, routing := range r.removeRoutings {
+			routing := routing
+			if err := fc.DeleteRoute(routing.DomainName); err != nil {
+				return fmt.Errorf("删除路由 %s 的 Caddy 配置失败: %w", routing.DomainName, err)
+			}
+			undo = append(undo, func() {
+				if err := fc.AddReverseProxy(routing.DomainName, fmt.Sprintf("localhost:%d", routing.HostPort)); err != nil {
+					logman.Error("恢复路由的 Caddy 配置失败", "domain", routing.DomainName, "error", err)
+				}
+				restoreRoutingTLS(fc, routing.DomainName, tlsConfigs[routing.DomainName])
+			})
+			removeRoutingTLSFromCaddy(fc, routing.DomainName)
+		}
+		for _, routing := range added {
+			routing := routing
+			if err := fc.AddReverseProxy(routing.DomainName, fmt.Sprintf("localhost:%d", routing.HostPort)); err != nil {
+				return fmt.Errorf("添加路由 %s 的 Caddy 配置失败: %w", routing.DomainName, err)
+			}
+			undo = append(undo, func() {
+				if err := fc.DeleteRoute(routing.DomainName); err != nil {
+					logman.Error("撤销路由的 Caddy 配置失败", "domain", routing.DomainName, "error", err)
+				}
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		return err
+	}
+
+	for _, routing := range added {
+		if preflight := preflights[routing.DomainName]; preflight != nil {
+			if err := saveRoutingDNSResult(routing, preflight); err != nil {
+				logman.Warn("保存 DNS 预检结果失败", "domain", routing.DomainName, "error", err)
+			}
+		}
+	}
+
+	logman.Info("应用 orbitdeploy.toml 完成", "app_name", r.app.Name, "changes", len(r.plan.Changes))
+	return nil
+}
+
+// specRoutingPort 返回新路由指向的本机端口：当前线上版本的部署端口，
+// 没有线上版本时使用最近一次成功部署的端口，应用尚未部署时使用默认端口
+func specRoutingPort(app *models.Application) (int, error) {
+	if app.ActiveReleaseID != nil {
+		deployment, err := models.GetLatestDeploymentByRelease(app.ID, *app.ActiveReleaseID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("查询线上版本的部署失败: %w", err)
+		}
+		if deployment != nil && deployment.SystemPort != nil && *deployment.SystemPort > 0 {
+			return *deployment.SystemPort, nil
+		}
+	}
+
+	deployment, err := models.GetLatestSuccessfulDeploymentByAppID(app.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("查询最近一次部署失败: %w", err)
+	}
+	if deployment != nil && deployment.SystemPort != nil && *deployment.SystemPort > 0 {
+		return *deployment.SystemPort, nil
+	}
+	return defaultSpecRoutingPort, nil
+}
+
+// ExportApplicationSpec 导出应用当前配置，返回的 spec 再次 apply 时不会产生变更。
+// 密钥变量不会导出，只返回其名称。
+func ExportApplicationSpec(app *models.Application) (*utils.DeploymentSpec, []string, error) {
+	project, err := models.GetProjectByID(app.ProjectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取项目信息失败: %w", err)
+	}
+
+	containerName := utils.SanitizeDNSLabel(app.Name)
+	if containerName == "" {
+		containerName = "app"
+	}
+	container := utils.SpecContainer{
+		Name:        containerName,
+		PublishPort: app.TargetPort,
+	}
+
+	if app.Resources.CPUQuota > 0 || app.Resources.MemoryLimitMB > 0 {
+		container.Resources = &utils.SpecResources{}
+		if app.Resources.CPUQuota > 0 {
+			container.Resources.CPU = utils.FormatCPUQuantity(app.Resources.CPUQuota)
+		}
+		if app.Resources.MemoryLimitMB > 0 {
+			container.Resources.Memory = utils.FormatMemoryMB(app.Resources.MemoryLimitMB)
+		}
+	}
+
+	for _, m := range models.ParseVolumeMounts(app.Volumes) {
+		container.Volumes = append(container.Volumes, utils.SpecVolume{Source: m.HostPath, Target: m.ContainerPath, ReadOnly: m.ReadOnly})
+	}
+
+	routings, err := models.ListRoutingsByAppID(app.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询路由信息失败: %w", err)
+	}
+	for _, routing := range routings {
+		if routing.DomainName == app.GeneratedHostname {
+			continue
+		}
+		container.Domains = append(container.Domains, utils.SpecDomain{Host: routing.DomainName})
+	}
+	sort.Slice(container.Domains, func(i, j int) bool { return container.Domains[i].Host < container.Domains[j].Host })
+
+	envVars, err := models.ListEnvironmentVariablesByApplicationID(app.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询环境变量失败: %w", err)
+	}
+	var secretKeys []string
+	for _, envVar := range envVars {
+		if envVar.IsEncrypted {
+			secretKeys = append(secretKeys, envVar.Key)
+			continue
+		}
+		value, err := envVar.GetDecryptedValue()
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取环境变量 %s 失败: %w", envVar.Key, err)
+		}
+		if container.ExtraEnv == nil {
+			container.ExtraEnv = make(map[string]string)
+		}
+		container.ExtraEnv[envVar.Key] = value
+	}
+	sort.Strings(secretKeys)
+
+	spec := &utils.DeploymentSpec{
+		APIVersion:  specAPIVersion,
+		Kind:        utils.DeploymentSpecKind,
+		AppName:     app.Name,
+		Project:     project.Name,
+		Environment: specEnvironment,
+		Containers:  []utils.SpecContainer{container},
+	}
+	return spec, secretKeys, nil
+}
+
+func formatSpecCPU(cpu float64) string {
+	if cpu == 0 {
+		return ""
+	}
+	return utils.FormatCPUQuantity(cpu)
+}
+
+func formatSpecMemory(mb int) string {
+	if mb == 0 {
+		return ""
+	}
+	return utils.FormatMemoryMB(mb)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSpec(container utils.SpecContainer) *utils.DeploymentSpec {
+	container.Name = "web"
+	return &utils.DeploymentSpec{
+		Kind:       utils.DeploymentSpecKind,
+		AppName:    "web",
+		Project:    "demo",
+		Containers: []utils.SpecContainer{container},
+	}
+}
+
+func createSpecTestApp(t *testing.T) (*models.Application, *models.Project) {
+	t.Helper()
+	project := &models.Project{ID: uuid.New(), Name: "demo"}
+	app, err := models.CreateApplication(project.ID, "web", "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, nil)
+	assert.NoError(t, err)
+	_, _, _, err = models.SetEnvironmentVariables(app.ID, []models.EnvironmentVariableInput{{Key: "MODE", Value: "old"}}, nil)
+	assert.NoError(t, err)
+	return app, project
+}
+
+func testEnvMap(appID uuid.UUID) (map[string]string, error) {
+	envVars, err := models.ListEnvironmentVariablesByApplicationID(appID)
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(envVars))
+	for _, envVar := range envVars {
+		env[envVar.Key] = envVar.Value
+	}
+	return env, nil
+}
+
+func TestPlanApplicationSpecRejectsInvalidResources(t *testing.T) {
+	setupTestDB(t)
+	app, project := createSpecTestApp(t)
+
+	_, err := PlanApplicationSpec(app, project, newTestSpec(utils.SpecContainer{Resources: &utils.SpecResources{CPU: "lots"}}))
+	assert.ErrorContains(t, err, "resources.cpu")
+
+	_, err = PlanApplicationSpec(app, project, newTestSpec(utils.SpecContainer{Resources: &utils.SpecResources{Memory: "12 bananas"}}))
+	assert.ErrorContains(t, err, "resources.memory")
+}
+
+func TestApplyApplicationSpecUpdatesAppAndEnv(t *testing.T) {
+	setupTestDB(t)
+	app, project := createSpecTestApp(t)
+
+	plan, err := ApplyApplicationSpec(app, project, newTestSpec(utils.SpecContainer{
+		PublishPort: 3000,
+		ExtraEnv:    map[string]string{"MODE": "new", "DEBUG": "1"},
+	}))
+	assert.NoError(t, err)
+	assert.True(t, plan.RedeployRequired)
+
+	stored, err := models.GetApplicationByID(app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3000, stored.TargetPort)
+
+	env, err := testEnvMap(app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"MODE": "new", "DEBUG": "1"}, env)
+}
+
+// fakeCaddyClient 记录路由的 Caddy 客户端，failHosts 中的域名添加失败
+type fakeCaddyClient struct {
+	routes    map[string]string
+	failHosts map[string]bool
+}
+
+func useFakeCaddy(t *testing.T, failHosts ...string) *fakeCaddyClient {
+	t.Helper()
+	fake := &fakeCaddyClient{routes: map[string]string{}, failHosts: map[string]bool{}}
+	for _, host := range failHosts {
+		fake.failHosts[host] = true
+	}
+	previous := newCaddyClient
+	newCaddyClient = func() caddyClient { return fake }
+	t.Cleanup(func() { newCaddyClient = previous })
+	return fake
+}
+
+func (f *fakeCaddyClient) AddReverseProxy(fromHost, toURL string) error {
+	if f.failHosts[fromHost] {
+		return fmt.Errorf("caddy rejected %s", fromHost)
+	}
+	f.routes[fromHost] = toURL
+	return nil
+}
+
+func (f *fakeCaddyClient) DeleteRoute(id string) error {
+	delete(f.routes, id)
+	return nil
+}
+
+func (f *fakeCaddyClient) HasID(id string) bool {
+	_, ok := f.routes[id]
+	return ok
+}
+
+func (f *fakeCaddyClient) HasPath(path string) bool { return false }
+
+func (f *fakeCaddyClient) GetConfig(path string) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (f *fakeCaddyClient) PutConfig(data interface{}, path, method string) error { return nil }
+
+func TestApplyApplicationSpecAddsRoutingToCaddy(t *testing.T) {
+	setupTestDB(t)
+	fake := useFakeCaddy(t)
+	app, project := createSpecTestApp(t)
+
+	_, err := ApplyApplicationSpec(app, project, newTestSpec(utils.SpecContainer{
+		ExtraEnv: map[string]string{"MODE": "old"},
+		Domains:  []utils.SpecDomain{{Host: "web.orbitdeploy.invalid"}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"web.orbitdeploy.invalid": "localhost:8080"}, fake.routes)
+
+	routings, err := models.ListRoutingsByAppID(app.ID)
+	assert.NoError(t, err)
+	assert.Len(t, routings, 1)
+}
+
+// Caddy 拒绝第二个路由时，已添加的路由从 Caddy 撤销，应用配置和环境变量随事务回滚
+func TestApplyApplicationSpecRollsBackWhenRoutingFails(t *testing.T) {
+	setupTestDB(t)
+	fake := useFakeCaddy(t, "b.orbitdeploy.invalid")
+	app, project := createSpecTestApp(t)
+
+	_, err := ApplyApplicationSpec(app, project, newTestSpec(utils.SpecContainer{
+		PublishPort: 3000,
+		ExtraEnv:    map[string]string{"MODE": "new"},
+		Domains:     []utils.SpecDomain{{Host: "a.orbitdeploy.invalid"}, {Host: "b.orbitdeploy.invalid"}},
+	}))
+	assert.ErrorContains(t, err, "b.orbitdeploy.invalid")
+	assert.Empty(t, fake.routes)
+
+	stored, err := models.GetApplicationByID(app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, stored.TargetPort)
+
+	env, err := testEnvMap(app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"MODE": "old"}, env)
+
+	routings, err := models.ListRoutingsByAppID(app.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, routings)
+}
+
+func TestSpecRoutingPortFollowsDeployment(t *testing.T) {
+	setupTestDB(t)
+	app, project := createSpecTestApp(t)
+
+	// 尚未部署时使用默认端口
+	plan, err := PlanApplicationSpec(app, project, newTestSpec(utils.SpecContainer{
+		ExtraEnv: map[string]string{"MODE": "old"},
+		Domains:  []utils.SpecDomain{{Host: "web.orbitdeploy.invalid"}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []SpecChange{{Resource: "routing", Action: SpecActionCreate, Name: "web.orbitdeploy.invalid", New: "8080"}}, plan.Changes)
+
+	release, err := models.CreateRelease(app.ID, "web:v1", models.JSONB{}, "success")
+	assert.NoError(t, err)
+	deployment, err := models.CreateDeployment(app.ID, release.ID, "success", "", "web-v1", time.Now(), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, models.UpdateDeploymentSystemPort(deployment.ID, 12345))
+
+	port, err := specRoutingPort(app)
+	assert.NoError(t, err)
+	assert.Equal(t, 12345, port)
+}
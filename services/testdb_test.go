@@ -0,0 +1,42 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/opentdp/go-helper/dborm"
+)
+
+// setupTestDB 连接临时 SQLite 数据库并迁移服务层测试用到的表
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	config := &dborm.Config{
+		Type:   "sqlite",
+		DbName: filepath.Join(t.TempDir(), "test.db"),
+	}
+	if dborm.Connect(config) == nil {
+		t.Fatal("failed to connect to test database")
+	}
+	t.Cleanup(func() { dborm.Destroy() })
+
+	err := dborm.Db.AutoMigrate(
+		&models.User{},
+		&models.Project{},
+		&models.Application{},
+		&models.ProviderAuth{},
+		&models.EnvironmentVariable{},
+		&models.Deployment{},
+		&models.CanaryRelease{},
+		&models.DeployFreezeWindow{},
+		&models.ScheduledDeployment{},
+		&models.Release{},
+		&models.Routing{},
+		&models.RoutingTLSConfig{},
+		&models.ApplicationToken{},
+		&models.SystemSetting{},
+	)
+	if err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/logman"
+)
+
+// GitEventDeployment 一个应用对 webhook 事件的处理结果
+type GitEventDeployment struct {
+	ApplicationID   uuid.UUID
+	ApplicationName string
+	DeploymentID    *uuid.UUID // 触发了部署时不为空
+	Skipped         string     // 未触发部署的原因
+}
+
+// HandleGitEvent 根据 webhook 事件触发匹配应用的自动部署。
+// 只处理关联到 providerAuth 的应用，以及未关联授权、仓库主机与 providerAuth 实例主机相同的 SSH 仓库应用。
+// 事件中的仓库地址来自请求内容，不能单独作为应用归属的依据
+func (do *DeploymentOrchestrator) HandleGitEvent(providerAuth *models.ProviderAuth, event *utils.GitEvent) ([]GitEventDeployment, error) {
+	if event.Deleted {
+		logman.Info("忽略删除分支或标签的事件", "provider", event.Provider, "branch", event.Branch, "tag", event.Tag)
+		return nil, nil
+	}
+
+	applications, err := models.ListApplications()
+	if err != nil {
+		return nil, err
+	}
+
+	probe := utils.NewGitProviderClient(providerAuth.Platform, "", "")
+	probe.BaseURL = providerAuth.BaseURL
+
+	var results []GitEventDeployment
+	for _, app := range applications {
+		if app.RepoURL == nil || !event.MatchesRepo(*app.RepoURL) {
+			continue
+		}
+		if app.ProviderAuthID != nil {
+			if *app.ProviderAuthID != providerAuth.ID {
+				continue
+			}
+		} else if !probe.MatchesSSHHost(*app.RepoURL) {
+			continue
+		}
+
+		result := GitEventDeployment{ApplicationID: app.ID, ApplicationName: app.Name}
+		if reason := autoDeploySkipReason(app, event); reason != "" {
+			result.Skipped = reason
+			results = append(results, result)
+			continue
+		}
+
+		deployment, err := do.CreateDeployment(app.ID, CreateDeploymentRequest{GitRef: event.Ref()})
+		if err != nil {
+			logman.Error("webhook 触发部署失败", "app_name", app.Name, "error", err)
+			result.Skipped = err.Error()
+		} else {
+			logman.Info("webhook 触发部署", "app_name", app.Name, "provider", event.Provider, "kind", event.Kind, "ref", event.Ref(), "deployment_id", deployment.ID)
+			result.DeploymentID = &deployment.ID
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// autoDeploySkipReason 返回应用不对事件自动部署的原因，为空表示需要部署
+func autoDeploySkipReason(app *models.Application, event *utils.GitEvent) string {
+	switch event.Kind {
+	case utils.GitEventPush:
+		if !app.AutoDeploy.AutoDeployOnPush {
+			return "未启用推送自动部署"
+		}
+		branch := event.DefaultBranch
+		if app.Branch != nil && *app.Branch != "" {
+			branch = *app.Branch
+		}
+		if branch == "" {
+			branch = "main"
+		}
+		if event.Branch != branch {
+			return "推送的分支 " + event.Branch + " 不是应用分支 " + branch
+		}
+		return ""
+	case utils.GitEventTag:
+		if !app.AutoDeploy.AutoDeployOnTag {
+			return "未启用标签自动部署"
+		}
+		return ""
+	default:
+		return "不支持自动部署的事件类型 " + string(event.Kind)
+	}
+}
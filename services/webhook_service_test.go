@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/OrbitDeploy/OrbitDeploy/models"
+	"github.com/OrbitDeploy/OrbitDeploy/utils"
+	"github.com/google/uuid"
+	"github.com/opentdp/go-helper/dborm"
+	"github.com/stretchr/testify/assert"
+)
+
+// createWebhookTestApp 创建一个仓库地址为 repoURL、未启用自动部署的应用，匹配时只返回跳过原因而不会真正部署
+func createWebhookTestApp(t *testing.T, name, repoURL string, providerAuthID *uuid.UUID) *models.Application {
+	t.Helper()
+	app, err := models.CreateApplication(uuid.New(), name, "", nil, 8080, models.JSONB{}, nil, nil, nil, nil, nil, providerAuthID)
+	assert.NoError(t, err)
+	assert.NoError(t, dborm.Db.Model(app).Update("repo_url", repoURL).Error)
+	return app
+}
+
+func webhookTestEvent(repoURL string) *utils.GitEvent {
+	return &utils.GitEvent{Provider: "github", Kind: utils.GitEventPush, Branch: "main", RepoURLs: []string{repoURL}}
+}
+
+func TestHandleGitEventIgnoresSSHAppOnOtherProviderHost(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	createWebhookTestApp(t, "ssh-app", "git@github.com:acme/web.git", nil)
+
+	gitea := &models.ProviderAuth{ID: uuid.New(), Platform: "gitea", BaseURL: "https://git.example.com"}
+	results, err := orchestrator.HandleGitEvent(gitea, webhookTestEvent("git@github.com:acme/web.git"))
+	assert.NoError(t, err)
+	assert.Empty(t, results, "其它平台实例的 webhook 不应匹配 github.com 上的 SSH 仓库应用")
+
+	github := &models.ProviderAuth{ID: uuid.New(), Platform: "github"}
+	results, err = orchestrator.HandleGitEvent(github, webhookTestEvent("git@github.com:acme/web.git"))
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "ssh-app", results[0].ApplicationName)
+		assert.Nil(t, results[0].DeploymentID)
+	}
+}
+
+func TestHandleGitEventRequiresLinkedProviderAuth(t *testing.T) {
+	setupTestDB(t)
+	orchestrator := NewDeploymentOrchestrator(NewBuildService(), NewDeploymentEnvironmentService(), NewPodmanService())
+	linkedID := uuid.New()
+	createWebhookTestApp(t, "linked-app", "https://github.com/acme/api", &linkedID)
+	createWebhookTestApp(t, "https-app", "https://github.com/acme/site", nil)
+
+	other := &models.ProviderAuth{ID: uuid.New(), Platform: "github"}
+	results, err := orchestrator.HandleGitEvent(other, webhookTestEvent("https://github.com/acme/api"))
+	assert.NoError(t, err)
+	assert.Empty(t, results, "关联了其它授权的应用不应被触发")
+
+	results, err = orchestrator.HandleGitEvent(other, webhookTestEvent("https://github.com/acme/site"))
+	assert.NoError(t, err)
+	assert.Empty(t, results, "未关联授权的 https 仓库应用不应被触发")
+
+	linked := &models.ProviderAuth{ID: linkedID, Platform: "github"}
+	results, err = orchestrator.HandleGitEvent(linked, webhookTestEvent("https://github.com/acme/api"))
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
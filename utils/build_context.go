@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultDockerfilePath 未配置时使用构建目录下的 Dockerfile
+const DefaultDockerfilePath = "Dockerfile"
+
+// CleanRepoPath 规范化仓库内的相对路径，"/"、"" 和 "." 都表示仓库根目录；
+// 拒绝跳出仓库的路径。field 用于错误信息中标明是哪个配置项
+func CleanRepoPath(field, p string) (string, error) {
+	p = strings.ReplaceAll(strings.TrimSpace(p), "\\", "/")
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%s 不能指向仓库之外: %s", field, p)
+		}
+	}
+	return strings.TrimPrefix(path.Clean("/"+p), "/"), nil
+}
+
+// ResolveBuildContext 根据构建目录和 Dockerfile 路径（相对于构建目录）解析出构建上下文和 Dockerfile 的绝对路径，
+// 路径不存在时返回的错误中包含仓库内的相对路径
+func ResolveBuildContext(repoRoot, buildDir, dockerfile string) (contextDir, dockerfilePath string, err error) {
+	dir, err := CleanRepoPath("build_dir", buildDir)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.TrimSpace(dockerfile) == "" {
+		dockerfile = DefaultDockerfilePath
+	}
+	file, err := CleanRepoPath("dockerfile_path", dockerfile)
+	if err != nil {
+		return "", "", err
+	}
+	if file == "" {
+		return "", "", fmt.Errorf("dockerfile_path 不能为空")
+	}
+
+	contextDir = filepath.Join(repoRoot, filepath.FromSlash(dir))
+	if info, err := os.Stat(contextDir); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("构建目录不存在: %s", displayRepoPath(dir))
+	}
+
+	dockerfilePath = filepath.Join(contextDir, filepath.FromSlash(file))
+	if info, err := os.Stat(dockerfilePath); err != nil || info.IsDir() {
+		return "", "", fmt.Errorf("dockerfile 不存在: %s (build_dir=%s, dockerfile_path=%s)", path.Join(dir, file), displayRepoPath(dir), file)
+	}
+	return contextDir, dockerfilePath, nil
+}
+
+// DockerfileStages 返回 Dockerfile 中通过 "FROM ... AS name" 命名的构建阶段
+func DockerfileStages(dockerfilePath string) ([]string, error) {
+	f, err := os.Open(dockerfilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var stages []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 4 && strings.EqualFold(fields[0], "FROM") && strings.EqualFold(fields[len(fields)-2], "AS") {
+			stages = append(stages, fields[len(fields)-1])
+		}
+	}
+	return stages, scanner.Err()
+}
+
+// ValidateBuildTarget 检查目标阶段是否在 Dockerfile 中定义
+func ValidateBuildTarget(dockerfilePath, target string) error {
+	stages, err := DockerfileStages(dockerfilePath)
+	if err != nil {
+		return fmt.Errorf("读取 Dockerfile 失败: %w", err)
+	}
+	for _, stage := range stages {
+		if strings.EqualFold(stage, target) {
+			return nil
+		}
+	}
+	return fmt.Errorf("构建阶段 %s 未在 Dockerfile 中定义，可用阶段: %s", target, strings.Join(stages, ", "))
+}
+
+func displayRepoPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanRepoPath(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"/", "", false},
+		{"", "", false},
+		{".", "", false},
+		{"apps/web", "apps/web", false},
+		{"/apps/web/", "apps/web", false},
+		{"./apps//web", "apps/web", false},
+		{"../secret", "", true},
+		{"apps/../../secret", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := CleanRepoPath("build_dir", tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CleanRepoPath(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CleanRepoPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestResolveBuildContext(t *testing.T) {
+	repo := t.TempDir()
+	webDir := filepath.Join(repo, "apps", "web")
+	if err := os.MkdirAll(filepath.Join(webDir, "docker"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(webDir, "docker", "Dockerfile.prod"), []byte("FROM alpine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "Dockerfile"), []byte("FROM alpine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	contextDir, dockerfile, err := ResolveBuildContext(repo, "/apps/web", "docker/Dockerfile.prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contextDir != webDir || dockerfile != filepath.Join(webDir, "docker", "Dockerfile.prod") {
+		t.Errorf("Unexpected paths: %s, %s", contextDir, dockerfile)
+	}
+
+	// 默认使用仓库根目录下的 Dockerfile
+	contextDir, dockerfile, err = ResolveBuildContext(repo, "/", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contextDir != repo || dockerfile != filepath.Join(repo, "Dockerfile") {
+		t.Errorf("Unexpected default paths: %s, %s", contextDir, dockerfile)
+	}
+
+	// 错误信息中包含缺失的仓库内路径
+	if _, _, err := ResolveBuildContext(repo, "apps/api", ""); err == nil || !strings.Contains(err.Error(), "apps/api") {
+		t.Errorf("Expected missing build dir error naming apps/api, got %v", err)
+	}
+	if _, _, err := ResolveBuildContext(repo, "apps/web", "Dockerfile"); err == nil || !strings.Contains(err.Error(), "apps/web/Dockerfile") {
+		t.Errorf("Expected missing dockerfile error naming apps/web/Dockerfile, got %v", err)
+	}
+}
+
+func TestValidateBuildTarget(t *testing.T) {
+	dockerfile := filepath.Join(t.TempDir(), "Dockerfile")
+	content := "FROM golang:1.24 AS builder\nRUN go build\n\nfrom alpine as runtime\nCOPY --from=builder /app /app\n"
+	if err := os.WriteFile(dockerfile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stages, err := DockerfileStages(dockerfile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Join(stages, ",") != "builder,runtime" {
+		t.Errorf("Unexpected stages: %v", stages)
+	}
+
+	if err := ValidateBuildTarget(dockerfile, "runtime"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := ValidateBuildTarget(dockerfile, "test"); err == nil {
+		t.Error("Expected error for undefined stage")
+	}
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+var buildSecretIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]{0,127}$`)
+
+// ValidateBuildSecretID 校验构建密钥名称，即 Dockerfile 中 RUN --mount=type=secret,id=<name> 使用的 id
+func ValidateBuildSecretID(name string) error {
+	if !buildSecretIDPattern.MatchString(name) {
+		return fmt.Errorf("构建密钥名称只能包含字母、数字、下划线、点和短横线，且不能以数字开头: %s", name)
+	}
+	return nil
+}
+
+// WriteBuildSecretFiles 将构建密钥写入 dir 下权限为 0600 的文件，返回对应的 podman build --secret 参数。
+// dir 应位于构建上下文之外，构建结束后由调用方删除
+func WriteBuildSecretFiles(dir string, secrets map[string]string) ([]string, error) {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		if err := ValidateBuildSecretID(name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(secrets[name]), 0600); err != nil {
+			return nil, fmt.Errorf("写入构建密钥 %s 失败: %w", name, err)
+		}
+		args = append(args, "--secret", fmt.Sprintf("id=%s,src=%s", name, path))
+	}
+	return args, nil
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateBuildSecretID(t *testing.T) {
+	tests := map[string]bool{
+		"NPM_TOKEN":       true,
+		"pip.conf":        true,
+		"github-token":    true,
+		"1token":          false,
+		"":                false,
+		"a/b":             false,
+		"id=x,src=/etc/x": false,
+	}
+	for name, valid := range tests {
+		if err := ValidateBuildSecretID(name); (err == nil) != valid {
+			t.Errorf("ValidateBuildSecretID(%q) error = %v, want valid %v", name, err, valid)
+		}
+	}
+}
+
+func TestWriteBuildSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	args, err := WriteBuildSecretFiles(dir, map[string]string{"NPM_TOKEN": "s3cret", "API_KEY": "k"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []string{
+		"--secret", "id=API_KEY,src=" + filepath.Join(dir, "API_KEY"),
+		"--secret", "id=NPM_TOKEN,src=" + filepath.Join(dir, "NPM_TOKEN"),
+	}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Errorf("Unexpected args: %v", args)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "NPM_TOKEN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Unexpected permission: %v", info.Mode().Perm())
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "NPM_TOKEN")); string(content) != "s3cret" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+
+	if _, err := WriteBuildSecretFiles(dir, map[string]string{"../escape": "x"}); err == nil {
+		t.Error("Expected error for invalid secret name")
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// CertificateInfo 证书的基本信息
+type CertificateInfo struct {
+	Subject   string
+	DNSNames  []string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// ParseCertificateBundle 校验 PEM 格式的证书链与私钥是否匹配，并确认证书覆盖指定域名
+func ParseCertificateBundle(certificatePEM, privateKeyPEM, domain string) (*CertificateInfo, error) {
+	if strings.TrimSpace(certificatePEM) == "" || strings.TrimSpace(privateKeyPEM) == "" {
+		return nil, fmt.Errorf("certificate and private key are required")
+	}
+
+	pair, err := tls.X509KeyPair([]byte(certificatePEM), []byte(privateKeyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate or private key: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	if domain != "" {
+		if err := leaf.VerifyHostname(domain); err != nil {
+			return nil, fmt.Errorf("certificate does not cover %s: %w", domain, err)
+		}
+	}
+
+	if time.Now().After(leaf.NotAfter) {
+		return nil, fmt.Errorf("certificate expired at %s", leaf.NotAfter.Format(time.RFC3339))
+	}
+
+	return &CertificateInfo{
+		Subject:   leaf.Subject.CommonName,
+		DNSNames:  leaf.DNSNames,
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}, nil
+}
+
+// ProbeCertificateExpiry 通过 SNI 连接本机 HTTPS 端口，读取实际对外提供的证书过期时间
+func ProbeCertificateExpiry(addr, domain string, timeout time.Duration) (*time.Time, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		ServerName:         domain,
+		InsecureSkipVerify: true, // 只读取过期时间，不做链校验
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate presented for %s", domain)
+	}
+	notAfter := certs[0].NotAfter
+	return &notAfter, nil
+}
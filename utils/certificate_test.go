@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// generateTestCertificate 生成用于测试的自签名证书和私钥（PEM 格式）
+func generateTestCertificate(t *testing.T, dnsNames []string, notAfter time.Time) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestParseCertificateBundle(t *testing.T) {
+	validUntil := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	certPEM, keyPEM := generateTestCertificate(t, []string{"example.com", "*.corp.example.com"}, validUntil)
+	_, otherKeyPEM := generateTestCertificate(t, []string{"other.com"}, validUntil)
+	expiredCertPEM, expiredKeyPEM := generateTestCertificate(t, []string{"example.com"}, time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name     string
+		cert     string
+		key      string
+		domain   string
+		hasError bool
+	}{
+		{"Exact domain", certPEM, keyPEM, "example.com", false},
+		{"Wildcard domain", certPEM, keyPEM, "app.corp.example.com", false},
+		{"Domain not covered", certPEM, keyPEM, "api.example.com", true},
+		{"Mismatched key", certPEM, otherKeyPEM, "example.com", true},
+		{"Expired certificate", expiredCertPEM, expiredKeyPEM, "example.com", true},
+		{"Missing key", certPEM, "", "example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseCertificateBundle(tt.cert, tt.key, tt.domain)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error for %s, but got none", tt.domain)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %v", tt.domain, err)
+			}
+			if !info.NotAfter.Equal(validUntil) {
+				t.Errorf("Expected NotAfter %v, got %v", validUntil, info.NotAfter)
+			}
+		})
+	}
+}
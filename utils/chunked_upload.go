@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	ErrChunkOffsetMismatch   = errors.New("分块偏移与已接收的字节数不一致")
+	ErrChunkChecksumMismatch = errors.New("分块校验和不一致")
+	ErrChunkTooLarge         = errors.New("分块超过允许的大小")
+)
+
+// AppendFileChunk 把从 offset 开始的分块追加到文件末尾，返回文件新的大小。
+// offset 必须等于文件当前大小；checksum 为分块的 sha256（十六进制）时校验内容，
+// 校验失败或分块超过 maxSize 时把文件截断回 offset，已接收的数据不受影响
+func AppendFileChunk(path string, offset int64, r io.Reader, checksum string, maxSize int64) (int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() != offset {
+		return info.Size(), fmt.Errorf("%w: 已接收 %d 字节，分块从 %d 开始", ErrChunkOffsetMismatch, info.Size(), offset)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), io.LimitReader(r, maxSize+1))
+	switch {
+	case err != nil:
+		err = fmt.Errorf("写入分块失败: %w", err)
+	case written > maxSize:
+		err = fmt.Errorf("%w: 最大 %d 字节", ErrChunkTooLarge, maxSize)
+	case checksum != "" && !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), checksum):
+		err = ErrChunkChecksumMismatch
+	}
+	if err != nil {
+		if truncErr := file.Truncate(offset); truncErr != nil {
+			return offset, fmt.Errorf("%v，回滚分块失败: %w", err, truncErr)
+		}
+		return offset, err
+	}
+	return offset + written, nil
+}
+
+// FileSHA256 计算文件内容的 sha256（十六进制）
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendFileChunk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	checksum := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	size, err := AppendFileChunk(path, 0, strings.NewReader("hello "), checksum("hello "), 16)
+	if err != nil || size != 6 {
+		t.Fatalf("Unexpected first chunk: %d %v", size, err)
+	}
+	// 重发已接收的分块
+	if size, err := AppendFileChunk(path, 0, strings.NewReader("hello "), "", 16); !errors.Is(err, ErrChunkOffsetMismatch) || size != 6 {
+		t.Errorf("Expected offset mismatch with current size 6, got %d %v", size, err)
+	}
+	if size, err := AppendFileChunk(path, 6, strings.NewReader("wor1d"), checksum("world"), 16); !errors.Is(err, ErrChunkChecksumMismatch) || size != 6 {
+		t.Errorf("Expected checksum mismatch, got %d %v", size, err)
+	}
+	if _, err := AppendFileChunk(path, 6, strings.NewReader(strings.Repeat("x", 17)), "", 16); !errors.Is(err, ErrChunkTooLarge) {
+		t.Errorf("Expected chunk too large, got %v", err)
+	}
+	size, err = AppendFileChunk(path, 6, strings.NewReader("world"), strings.ToUpper(checksum("world")), 16)
+	if err != nil || size != 11 {
+		t.Fatalf("Unexpected second chunk: %d %v", size, err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if string(content) != "hello world" {
+		t.Errorf("Unexpected content after rollback: %q", content)
+	}
+	if sum, err := FileSHA256(path); err != nil || sum != checksum("hello world") {
+		t.Errorf("Unexpected file checksum: %s %v", sum, err)
+	}
+}
@@ -0,0 +1,290 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// DeploymentSpec orbitdeploy.toml 的服务端表示，字段与 orbitctl 的 specTOML 保持一致
+type DeploymentSpec struct {
+	APIVersion  string           `toml:"api_version"`
+	Kind        string           `toml:"kind"`
+	AppName     string           `toml:"app_name"`
+	Project     string           `toml:"project"`
+	Environment string           `toml:"environment"`
+	Name        string           `toml:"name,omitempty"` // 兼容旧配置，app_name 为空时使用
+	Strategy    string           `toml:"strategy,omitempty"`
+	Replicas    int              `toml:"replicas,omitempty"`
+	Image       *SpecImage       `toml:"image,omitempty"`
+	Resources   *SpecResources   `toml:"resources,omitempty"`
+	Healthcheck *SpecHealthcheck `toml:"healthcheck,omitempty"`
+	Containers  []SpecContainer  `toml:"containers"`
+}
+
+// SpecImage 镜像配置
+type SpecImage struct {
+	Ref    string `toml:"ref,omitempty"`
+	Digest string `toml:"digest,omitempty"`
+}
+
+// SpecResources 资源配置，cpu 支持 "0.5" 或 "500m"，memory 支持 "512Mi"、"1Gi"、"512M" 等
+type SpecResources struct {
+	CPU    string `toml:"cpu,omitempty"`
+	Memory string `toml:"memory,omitempty"`
+}
+
+// SpecHealthcheck 健康检查配置
+type SpecHealthcheck struct {
+	Kind           string `toml:"kind,omitempty"`
+	Path           string `toml:"path,omitempty"`
+	TimeoutSeconds int    `toml:"timeout_seconds,omitempty"`
+	Retries        int    `toml:"retries,omitempty"`
+}
+
+// SpecDomain 域名配置
+type SpecDomain struct {
+	Host string `toml:"host"`
+	Path string `toml:"path,omitempty"`
+}
+
+// SpecVolume 卷挂载配置
+type SpecVolume struct {
+	Source   string `toml:"source"`
+	Target   string `toml:"target"`
+	ReadOnly bool   `toml:"read_only,omitempty"`
+}
+
+// SpecContainer 容器配置
+type SpecContainer struct {
+	Name        string            `toml:"name"`
+	PublishPort int               `toml:"publish_port,omitempty"`
+	Image       *SpecImage        `toml:"image,omitempty"`
+	Resources   *SpecResources    `toml:"resources,omitempty"`
+	Healthcheck *SpecHealthcheck  `toml:"healthcheck,omitempty"`
+	Domains     []SpecDomain      `toml:"domains,omitempty"`
+	Volumes     []SpecVolume      `toml:"volumes,omitempty"`
+	ExtraEnv    map[string]string `toml:"extra_env,omitempty"`
+	Labels      map[string]string `toml:"labels,omitempty"`
+	Annotations map[string]string `toml:"annotations,omitempty"`
+}
+
+const DeploymentSpecKind = "DeploymentSpec"
+
+var (
+	specNamePattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+	specStrategies  = map[string]bool{
+		"direct": true, "blue-green": true, "rolling": true, "canary": true,
+	}
+	specHealthcheckKinds = map[string]bool{
+		"http": true, "tcp": true, "cmd": true,
+	}
+)
+
+// ParseDeploymentSpec 解析并校验 orbitdeploy.toml
+func ParseDeploymentSpec(content string) (*DeploymentSpec, error) {
+	var spec DeploymentSpec
+	if err := toml.Unmarshal([]byte(content), &spec); err != nil {
+		return nil, fmt.Errorf("TOML 解析失败: %w", err)
+	}
+	if spec.AppName == "" {
+		spec.AppName = spec.Name
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// MarshalDeploymentSpec 将 spec 序列化为 TOML
+func MarshalDeploymentSpec(spec *DeploymentSpec) (string, error) {
+	b, err := toml.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("TOML 序列化失败: %w", err)
+	}
+	return string(b), nil
+}
+
+// Validate 校验 spec，错误信息中带上出错字段的路径
+func (s *DeploymentSpec) Validate() error {
+	if s.Kind != DeploymentSpecKind {
+		return fmt.Errorf("kind 必须为 %s", DeploymentSpecKind)
+	}
+	if s.AppName == "" {
+		return fmt.Errorf("app_name 为必填字段")
+	}
+	if s.Strategy != "" && !specStrategies[s.Strategy] {
+		return fmt.Errorf("strategy 无效: %s，支持的策略: direct, blue-green, rolling, canary", s.Strategy)
+	}
+	if s.Replicas < 0 {
+		return fmt.Errorf("replicas 不能为负数")
+	}
+	if err := s.Resources.validate("resources"); err != nil {
+		return err
+	}
+	if err := s.Healthcheck.validate("healthcheck"); err != nil {
+		return err
+	}
+
+	if len(s.Containers) == 0 {
+		return fmt.Errorf("至少需要配置一个容器 (containers)")
+	}
+	for i, c := range s.Containers {
+		path := fmt.Sprintf("containers[%d]", i)
+		if c.Name == "" {
+			return fmt.Errorf("%s.name 为必填字段", path)
+		}
+		if !specNamePattern.MatchString(c.Name) {
+			return fmt.Errorf("%s.name 格式无效，必须为小写字母数字和连字符", path)
+		}
+		if c.PublishPort < 0 || c.PublishPort > 65535 {
+			return fmt.Errorf("%s.publish_port 端口范围无效(1-65535)", path)
+		}
+		if err := c.Resources.validate(path + ".resources"); err != nil {
+			return err
+		}
+		if err := c.Healthcheck.validate(path + ".healthcheck"); err != nil {
+			return err
+		}
+
+		hosts := make(map[string]bool)
+		for j, d := range c.Domains {
+			host, err := NormalizeDomain(d.Host)
+			if err != nil {
+				return fmt.Errorf("%s.domains[%d].host 无效: %v", path, j, err)
+			}
+			if hosts[host] {
+				return fmt.Errorf("%s.domains[%d].host 重复: %s", path, j, host)
+			}
+			hosts[host] = true
+		}
+
+		targets := make(map[string]bool)
+		for j, v := range c.Volumes {
+			if v.Source == "" {
+				return fmt.Errorf("%s.volumes[%d].source 为必填字段", path, j)
+			}
+			if !strings.HasPrefix(v.Target, "/") {
+				return fmt.Errorf("%s.volumes[%d].target 必须为绝对路径", path, j)
+			}
+			if err := ValidateVolumeMount(v.Source, v.Target); err != nil {
+				return fmt.Errorf("%s.volumes[%d]: %v", path, j, err)
+			}
+			if targets[v.Target] {
+				return fmt.Errorf("%s.volumes[%d].target 重复: %s", path, j, v.Target)
+			}
+			targets[v.Target] = true
+		}
+
+		for key := range c.ExtraEnv {
+			if err := ValidateEnvKey(key); err != nil {
+				return fmt.Errorf("%s.extra_env: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// PrimaryContainer 返回应用对应的容器，目前每个应用只运行一个容器
+func (s *DeploymentSpec) PrimaryContainer() *SpecContainer {
+	return &s.Containers[0]
+}
+
+// EffectiveResources 容器级资源配置优先于全局配置
+func (s *DeploymentSpec) EffectiveResources() *SpecResources {
+	if c := s.PrimaryContainer(); c.Resources != nil {
+		return c.Resources
+	}
+	return s.Resources
+}
+
+func (r *SpecResources) validate(path string) error {
+	if r == nil {
+		return nil
+	}
+	if r.CPU != "" {
+		if _, err := ParseCPUQuantity(r.CPU); err != nil {
+			return fmt.Errorf("%s.cpu: %v", path, err)
+		}
+	}
+	if r.Memory != "" {
+		if _, err := ParseMemoryMB(r.Memory); err != nil {
+			return fmt.Errorf("%s.memory: %v", path, err)
+		}
+	}
+	return nil
+}
+
+func (h *SpecHealthcheck) validate(path string) error {
+	if h == nil {
+		return nil
+	}
+	if h.Kind != "" && !specHealthcheckKinds[h.Kind] {
+		return fmt.Errorf("%s.kind 无效: %s", path, h.Kind)
+	}
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("%s.timeout_seconds 不能为负数", path)
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("%s.retries 不能为负数", path)
+	}
+	return nil
+}
+
+// ParseCPUQuantity 解析 CPU 数量，支持 "0.5" 和 "500m" 两种写法
+func ParseCPUQuantity(value string) (float64, error) {
+	number, milli := strings.CutSuffix(strings.TrimSpace(value), "m")
+	cpu, err := strconv.ParseFloat(number, 64)
+	if err != nil || cpu < 0 {
+		return 0, fmt.Errorf("无效的 CPU 数量: %s", value)
+	}
+	if milli {
+		cpu /= 1000
+	}
+	return cpu, nil
+}
+
+// FormatCPUQuantity 将核数格式化为 spec 中的写法
+func FormatCPUQuantity(cpu float64) string {
+	return strconv.FormatFloat(cpu, 'f', -1, 64)
+}
+
+var memoryUnits = []struct {
+	suffix string
+	mb     float64
+}{
+	{"Gi", 1024}, {"Mi", 1}, {"Ki", 1.0 / 1024},
+	{"G", 1e9 / (1 << 20)}, {"M", 1e6 / (1 << 20)}, {"K", 1e3 / (1 << 20)},
+	{"g", 1024}, {"m", 1},
+}
+
+// ParseMemoryMB 解析内存大小并换算为 MB(MiB)，不带单位时按字节处理
+func ParseMemoryMB(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	for _, unit := range memoryUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			size, err := strconv.ParseFloat(number, 64)
+			if err != nil || size < 0 {
+				return 0, fmt.Errorf("无效的内存大小: %s", value)
+			}
+			return int(math.Round(size * unit.mb)), nil
+		}
+	}
+	bytes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || bytes < 0 {
+		return 0, fmt.Errorf("无效的内存大小: %s", value)
+	}
+	return int(bytes / (1024 * 1024)), nil
+}
+
+// FormatMemoryMB 将 MB 格式化为 spec 中的写法
+func FormatMemoryMB(mb int) string {
+	if mb > 0 && mb%1024 == 0 {
+		return fmt.Sprintf("%dGi", mb/1024)
+	}
+	return fmt.Sprintf("%dMi", mb)
+}
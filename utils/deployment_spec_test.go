@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDeploymentSpec = `
+api_version = "webdeploy.io/v1"
+kind = "DeploymentSpec"
+app_name = "my-blog"
+project = "my-project"
+strategy = "canary"
+
+[[containers]]
+name = "web"
+publish_port = 8080
+
+[containers.resources]
+cpu = "500m"
+memory = "1Gi"
+
+[[containers.domains]]
+host = "Blog.Example.com"
+
+[[containers.volumes]]
+source = "/var/lib/orbitdeploy/volumes/blog"
+target = "/app/content"
+read_only = true
+
+[containers.extra_env]
+LOG_LEVEL = "info"
+`
+
+func TestParseDeploymentSpec(t *testing.T) {
+	spec, err := ParseDeploymentSpec(testDeploymentSpec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spec.AppName != "my-blog" || spec.Project != "my-project" {
+		t.Errorf("Unexpected app/project: %s/%s", spec.AppName, spec.Project)
+	}
+
+	c := spec.PrimaryContainer()
+	if c.PublishPort != 8080 {
+		t.Errorf("Expected publish_port 8080, got %d", c.PublishPort)
+	}
+	if !reflect.DeepEqual(c.Volumes, []SpecVolume{{Source: "/var/lib/orbitdeploy/volumes/blog", Target: "/app/content", ReadOnly: true}}) {
+		t.Errorf("Unexpected volumes: %+v", c.Volumes)
+	}
+	if c.ExtraEnv["LOG_LEVEL"] != "info" {
+		t.Errorf("Unexpected extra_env: %v", c.ExtraEnv)
+	}
+	if r := spec.EffectiveResources(); r == nil || r.CPU != "500m" || r.Memory != "1Gi" {
+		t.Errorf("Unexpected resources: %+v", r)
+	}
+}
+
+func TestParseDeploymentSpecLegacyName(t *testing.T) {
+	spec, err := ParseDeploymentSpec("kind = \"DeploymentSpec\"\nname = \"legacy\"\n[[containers]]\nname = \"web\"\n")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if spec.AppName != "legacy" {
+		t.Errorf("Expected app_name to fall back to name, got %q", spec.AppName)
+	}
+}
+
+func TestDeploymentSpecValidateErrorPaths(t *testing.T) {
+	base := "kind = \"DeploymentSpec\"\napp_name = \"app\"\n[[containers]]\nname = \"web\"\n"
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Wrong kind", strings.Replace(base, "DeploymentSpec", "Other", 1), "kind"},
+		{"No containers", "kind = \"DeploymentSpec\"\napp_name = \"app\"\n", "containers"},
+		{"Bad strategy", strings.Replace(base, "[[containers]]", "strategy = \"yolo\"\n[[containers]]", 1), "strategy"},
+		{"Bad port", base + "publish_port = 70000\n", "containers[0].publish_port"},
+		{"Bad domain", base + "[[containers.domains]]\nhost = \"not a domain\"\n", "containers[0].domains[0].host"},
+		{"Duplicate domain", base + "[[containers.domains]]\nhost = \"a.example.com\"\n[[containers.domains]]\nhost = \"A.example.com\"\n", "containers[0].domains[1].host"},
+		{"Relative volume target", base + "[[containers.volumes]]\nsource = \"/data\"\ntarget = \"data\"\n", "containers[0].volumes[0].target"},
+		{"Volume source outside roots", base + "[[containers.volumes]]\nsource = \"/etc\"\ntarget = \"/data\"\n", "containers[0].volumes[0]"},
+		{"Bad memory", base + "[containers.resources]\nmemory = \"lots\"\n", "containers[0].resources.memory"},
+		{"Bad env key", base + "[containers.extra_env]\n\"1BAD\" = \"x\"\n", "containers[0].extra_env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDeploymentSpec(tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDeploymentSpecRoundTrip(t *testing.T) {
+	spec, err := ParseDeploymentSpec(testDeploymentSpec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := MarshalDeploymentSpec(spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parsed, err := ParseDeploymentSpec(content)
+	if err != nil {
+		t.Fatalf("Failed to parse marshalled spec: %v\n%s", err, content)
+	}
+	if !reflect.DeepEqual(spec, parsed) {
+		t.Errorf("Round trip mismatch:\n%+v\n%+v", spec, parsed)
+	}
+}
+
+func TestParseQuantities(t *testing.T) {
+	cpuTests := map[string]float64{"0.5": 0.5, "500m": 0.5, "2": 2, "1500m": 1.5}
+	for input, want := range cpuTests {
+		if got, err := ParseCPUQuantity(input); err != nil || got != want {
+			t.Errorf("ParseCPUQuantity(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseCPUQuantity("-1"); err == nil {
+		t.Error("Expected error for negative CPU")
+	}
+
+	memoryTests := map[string]int{"512Mi": 512, "1Gi": 1024, "512m": 512, "2g": 2048, "1G": 954, "1048576": 1}
+	for input, want := range memoryTests {
+		if got, err := ParseMemoryMB(input); err != nil || got != want {
+			t.Errorf("ParseMemoryMB(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := ParseMemoryMB("lots"); err == nil {
+		t.Error("Expected error for invalid memory")
+	}
+
+	if got := FormatMemoryMB(2048); got != "2Gi" {
+		t.Errorf("FormatMemoryMB(2048) = %s", got)
+	}
+	if got := FormatMemoryMB(768); got != "768Mi" {
+		t.Errorf("FormatMemoryMB(768) = %s", got)
+	}
+	if got := FormatCPUQuantity(0.25); got != "0.25" {
+		t.Errorf("FormatCPUQuantity(0.25) = %s", got)
+	}
+}
@@ -0,0 +1,132 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DomainVerificationPrefix TXT 验证记录的子域名前缀
+const DomainVerificationPrefix = "_orbitdeploy-challenge"
+
+// DNSRecords 域名的解析结果
+type DNSRecords struct {
+	CNAME      string   // 最终的规范名，没有 CNAME 时为空
+	Addresses  []string // A/AAAA 记录
+	TXTRecords []string // 验证子域名下的 TXT 记录
+}
+
+// LookupDomainDNS 查询域名的 CNAME、A/AAAA 记录以及验证子域名下的 TXT 记录
+func LookupDomainDNS(ctx context.Context, resolver *net.Resolver, domain string) (*DNSRecords, error) {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	records := &DNSRecords{}
+
+	if cname, err := resolver.LookupCNAME(ctx, domain); err == nil {
+		cname = strings.TrimSuffix(cname, ".")
+		if !strings.EqualFold(cname, domain) {
+			records.CNAME = cname
+		}
+	}
+
+	ipAddrs, err := resolver.LookupIPAddr(ctx, domain)
+	if err != nil {
+		return records, fmt.Errorf("failed to resolve %s: %w", domain, err)
+	}
+	for _, ipAddr := range ipAddrs {
+		records.Addresses = append(records.Addresses, ipAddr.IP.String())
+	}
+
+	// TXT 记录不存在是正常情况，忽略错误
+	if txt, err := resolver.LookupTXT(ctx, DomainVerificationRecordName(domain)); err == nil {
+		records.TXTRecords = txt
+	}
+
+	return records, nil
+}
+
+// DomainVerificationRecordName 返回域名所有权验证的 TXT 记录名
+func DomainVerificationRecordName(domain string) string {
+	return DomainVerificationPrefix + "." + domain
+}
+
+// DomainVerificationToken 返回域名的所有权验证值。
+// 由加密密钥对域名做 HMAC 得到，同一实例上对同一域名总是相同，因此可以在创建路由前告知用户。
+func DomainVerificationToken(domain string) string {
+	mac := hmac.New(sha256.New, getEncryptionKey())
+	mac.Write([]byte(strings.ToLower(domain)))
+	return "orbitdeploy-verification=" + hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// HasVerificationToken 判断 TXT 记录中是否包含域名的验证值
+func HasVerificationToken(txtRecords []string, domain string) bool {
+	token := DomainVerificationToken(domain)
+	for _, record := range txtRecords {
+		if strings.TrimSpace(record) == token {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAddresses 返回解析地址中属于服务器地址的部分（按 IP 比较，忽略 IPv6 的书写差异）
+func MatchAddresses(resolved, expected []string) []string {
+	var matched []string
+	for _, r := range resolved {
+		rip := net.ParseIP(r)
+		if rip == nil {
+			continue
+		}
+		for _, e := range expected {
+			if eip := net.ParseIP(e); eip != nil && eip.Equal(rip) {
+				matched = append(matched, rip.String())
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// ParseIPList 解析逗号或空白分隔的 IP 列表，返回规范化后的地址
+func ParseIPList(input string) ([]string, error) {
+	var ips []string
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	}) {
+		ip := net.ParseIP(strings.TrimSpace(field))
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address: %s", field)
+		}
+		ips = append(ips, ip.String())
+	}
+	return ips, nil
+}
+
+// DetectPublicAddresses 返回本机网卡上的公网地址（排除回环、链路本地和私有地址）。
+// 位于 NAT 之后的服务器网卡上通常只有私有地址，此时返回空列表。
+func DetectPublicAddresses() []string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	var public []string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+			continue
+		}
+		public = append(public, ip.String())
+	}
+	return public
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolved []string
+		expected []string
+		matched  []string
+	}{
+		{"IPv4 match", []string{"203.0.113.10"}, []string{"203.0.113.10"}, []string{"203.0.113.10"}},
+		{"IPv6 different notation", []string{"2001:db8::1"}, []string{"2001:0db8:0000::0001"}, []string{"2001:db8::1"}},
+		{"Partial match", []string{"203.0.113.10", "198.51.100.7"}, []string{"198.51.100.7"}, []string{"198.51.100.7"}},
+		{"No match", []string{"203.0.113.10"}, []string{"198.51.100.7"}, nil},
+		{"No expected addresses", []string{"203.0.113.10"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := MatchAddresses(tt.resolved, tt.expected)
+			if !reflect.DeepEqual(result, tt.matched) {
+				t.Errorf("Expected %v, got %v", tt.matched, result)
+			}
+		})
+	}
+}
+
+func TestParseIPList(t *testing.T) {
+	ips, err := ParseIPList("203.0.113.10, 2001:0db8::1\n198.51.100.7")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"203.0.113.10", "2001:db8::1", "198.51.100.7"}
+	if !reflect.DeepEqual(ips, expected) {
+		t.Errorf("Expected %v, got %v", expected, ips)
+	}
+
+	if _, err := ParseIPList("203.0.113.10, not-an-ip"); err == nil {
+		t.Error("Expected error for invalid IP, but got none")
+	}
+}
+
+func TestHasVerificationToken(t *testing.T) {
+	token := DomainVerificationToken("App.Example.com")
+	if token != DomainVerificationToken("app.example.com") {
+		t.Error("Expected verification token to be case-insensitive")
+	}
+	if token == DomainVerificationToken("other.example.com") {
+		t.Error("Expected different domains to have different tokens")
+	}
+
+	if !HasVerificationToken([]string{"v=spf1 -all", token}, "app.example.com") {
+		t.Error("Expected token to be found in TXT records")
+	}
+	if HasVerificationToken([]string{token}, "other.example.com") {
+		t.Error("Expected token of another domain to be rejected")
+	}
+}
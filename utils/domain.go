@@ -100,6 +100,52 @@ func NormalizeDomain(input string) (string, error) {
 
 	return clean, nil
 }
+
+// NormalizeBaseDomain 规范化应用基础域名，允许输入 "*.apps.example.com" 形式的通配符写法
+func NormalizeBaseDomain(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	input = strings.TrimPrefix(input, "*.")
+	input = strings.TrimSuffix(input, ".")
+	return NormalizeDomain(input)
+}
+
+// SanitizeDNSLabel 将任意名称转换为合法的 DNS label（小写字母、数字和连字符，最长 63 个字符）
+func SanitizeDNSLabel(name string) string {
+	var b strings.Builder
+	lastHyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastHyphen = false
+		} else if !lastHyphen {
+			b.WriteRune('-')
+			lastHyphen = true
+		}
+	}
+
+	label := strings.Trim(b.String(), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
+
+// BuildAppHostname 根据应用名、项目名和基础域名生成 <app>.<project>.<base> 形式的主机名
+func BuildAppHostname(appName, projectName, baseDomain string) (string, error) {
+	appLabel := SanitizeDNSLabel(appName)
+	projectLabel := SanitizeDNSLabel(projectName)
+	if appLabel == "" || projectLabel == "" {
+		return "", fmt.Errorf("cannot build hostname from app %q and project %q", appName, projectName)
+	}
+
+	base, err := NormalizeBaseDomain(baseDomain)
+	if err != nil {
+		return "", err
+	}
+
+	return NormalizeDomain(appLabel + "." + projectLabel + "." + base)
+}
+
 func sanitizeFilename(p string) string {
 	if p == "/" || p == "" {
 		return "root"
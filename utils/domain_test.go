@@ -163,4 +163,70 @@ func TestValidateDomains(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestBuildAppHostname(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      string
+		project  string
+		base     string
+		expected string
+		hasError bool
+	}{
+		{
+			name:     "Basic hostname",
+			app:      "web",
+			project:  "shop",
+			base:     "apps.example.com",
+			expected: "web.shop.apps.example.com",
+		},
+		{
+			name:     "Wildcard base domain",
+			app:      "api",
+			project:  "shop",
+			base:     "*.apps.example.com",
+			expected: "api.shop.apps.example.com",
+		},
+		{
+			name:     "Names are sanitized",
+			app:      "My_App v2",
+			project:  "Shop.Prod",
+			base:     "Apps.Example.com.",
+			expected: "my-app-v2.shop-prod.apps.example.com",
+		},
+		{
+			name:     "Empty label after sanitizing",
+			app:      "___",
+			project:  "shop",
+			base:     "apps.example.com",
+			hasError: true,
+		},
+		{
+			name:     "Invalid base domain",
+			app:      "web",
+			project:  "shop",
+			base:     "not..valid",
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := BuildAppHostname(tt.app, tt.project, tt.base)
+
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error for %q/%q/%q, but got none", tt.app, tt.project, tt.base)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error for %q/%q/%q: %v", tt.app, tt.project, tt.base, err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// MaskedValue 替代密钥变量值的占位符
+const MaskedValue = "******"
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateEnvKey 校验环境变量名是否可以写入 EnvironmentFile
+func ValidateEnvKey(key string) error {
+	if !envKeyPattern.MatchString(key) {
+		return fmt.Errorf("无效的环境变量名: %q，只能包含字母、数字和下划线，且不能以数字开头", key)
+	}
+	return nil
+}
+
+// EnvDiff 两组环境变量之间的差异，均为排序后的变量名
+type EnvDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty 判断是否没有差异
+func (d EnvDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffEnvVars 比较部署时的变量 before 与当前变量 after
+func DiffEnvVars(before, after map[string]string) EnvDiff {
+	var diff EnvDiff
+	for key, value := range after {
+		old, ok := before[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case old != value:
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
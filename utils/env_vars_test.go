@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateEnvKey(t *testing.T) {
+	valid := []string{"PORT", "_TOKEN", "db_url_2"}
+	for _, key := range valid {
+		if err := ValidateEnvKey(key); err != nil {
+			t.Errorf("Expected %q to be valid, got %v", key, err)
+		}
+	}
+
+	invalid := []string{"", "2FA", "MY-KEY", "A B", "KEY="}
+	for _, key := range invalid {
+		if err := ValidateEnvKey(key); err == nil {
+			t.Errorf("Expected %q to be invalid", key)
+		}
+	}
+}
+
+func TestDiffEnvVars(t *testing.T) {
+	before := map[string]string{"A": "1", "B": "2", "C": "3"}
+	after := map[string]string{"A": "1", "B": "changed", "D": "4", "E": "5"}
+
+	diff := DiffEnvVars(before, after)
+	expected := EnvDiff{
+		Added:   []string{"D", "E"},
+		Removed: []string{"C"},
+		Changed: []string{"B"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, diff)
+	}
+
+	if !DiffEnvVars(before, before).Empty() {
+		t.Error("Expected no difference for identical variables")
+	}
+}
@@ -0,0 +1,194 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFreezeDurationMinutes 周期性冻结窗口的最长持续时间（7 天）
+const maxFreezeDurationMinutes = 7 * 24 * 60
+
+// cronFieldRanges cron 各段的取值范围
+var cronFieldRanges = [5][2]int{
+	{0, 59}, // 分
+	{0, 23}, // 时
+	{1, 31}, // 日
+	{1, 12}, // 月
+	{0, 6},  // 周（0 为周日，7 也表示周日）
+}
+
+// CronSchedule 解析后的 5 段 cron 表达式：分 时 日 月 周
+type CronSchedule struct {
+	fields           [5]map[int]bool
+	domStar, dowStar bool
+	expression       string
+}
+
+// ParseCron 解析 5 段 cron 表达式，支持 *、列表 (1,2)、范围 (1-5) 和步长 (*/15, 1-10/2)
+func ParseCron(expr string) (*CronSchedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron 表达式需要 5 段（分 时 日 月 周）: %q", expr)
+	}
+	schedule := &CronSchedule{expression: expr, domStar: parts[2] == "*", dowStar: parts[4] == "*"}
+	for i, part := range parts {
+		values, err := parseCronField(part, cronFieldRanges[i][0], cronFieldRanges[i][1], i == 4)
+		if err != nil {
+			return nil, fmt.Errorf("cron 表达式第 %d 段无效: %w", i+1, err)
+		}
+		schedule.fields[i] = values
+	}
+	return schedule, nil
+}
+
+// parseCronField 解析 cron 的一段，返回允许的取值
+func parseCronField(field string, min, max int, weekday bool) (map[int]bool, error) {
+	values := make(map[int]bool)
+	upper := max
+	if weekday {
+		upper = 7 // 允许用 7 表示周日
+	}
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			n, err := strconv.Atoi(item[idx+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("无效的步长: %q", item)
+			}
+			rangePart, step = item[:idx], n
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return nil, fmt.Errorf("无效的范围: %q", item)
+			}
+			start, end = a, b
+		default:
+			n, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("无效的取值: %q", item)
+			}
+			start, end = n, n
+			if step > 1 {
+				end = max
+			}
+		}
+		if start < min || end > upper {
+			return nil, fmt.Errorf("取值超出范围 %d-%d: %q", min, upper, item)
+		}
+		for v := start; v <= end; v += step {
+			if weekday && v == 7 {
+				values[0] = true
+				continue
+			}
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Matches 判断 t 所在的分钟是否匹配表达式。与标准 cron 一致，日和周都不是 * 时满足其一即可
+func (s *CronSchedule) Matches(t time.Time) bool {
+	if !s.fields[0][t.Minute()] || !s.fields[1][t.Hour()] || !s.fields[3][int(t.Month())] {
+		return false
+	}
+	dom, dow := s.fields[2][t.Day()], s.fields[4][int(t.Weekday())]
+	if !s.domStar && !s.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// String 返回原始表达式
+func (s *CronSchedule) String() string {
+	return s.expression
+}
+
+// FreezeWindow 禁止部署的时间窗口：固定的起止时间，或由 cron 表达式触发、持续 DurationMinutes 分钟的周期性窗口
+type FreezeWindow struct {
+	StartsAt        *time.Time `gorm:"index"`
+	EndsAt          *time.Time
+	Cron            string `gorm:"size:100;not null;default:''"`
+	DurationMinutes int    `gorm:"not null;default:0"`
+	Timezone        string `gorm:"size:64;not null;default:''"` // 解释 cron 的时区，为空时使用服务器本地时区
+}
+
+// Validate 校验冻结窗口，只能设置固定时间段或 cron 其中一种
+func (w FreezeWindow) Validate() error {
+	hasRange := w.StartsAt != nil || w.EndsAt != nil
+	switch {
+	case hasRange && w.Cron != "":
+		return fmt.Errorf("冻结窗口只能设置固定时间段或 cron 其中一种")
+	case hasRange:
+		if w.StartsAt == nil || w.EndsAt == nil {
+			return fmt.Errorf("固定时间段需要同时设置开始和结束时间")
+		}
+		if !w.EndsAt.After(*w.StartsAt) {
+			return fmt.Errorf("结束时间必须晚于开始时间")
+		}
+	case w.Cron != "":
+		if _, err := ParseCron(w.Cron); err != nil {
+			return err
+		}
+		if w.DurationMinutes <= 0 || w.DurationMinutes > maxFreezeDurationMinutes {
+			return fmt.Errorf("周期性冻结窗口的持续时间必须在 1 到 %d 分钟之间", maxFreezeDurationMinutes)
+		}
+		if _, err := w.location(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("冻结窗口需要设置固定时间段或 cron")
+	}
+	return nil
+}
+
+// ActiveAt 判断 t 是否处于冻结窗口内，处于窗口内时同时返回窗口结束的时间
+func (w FreezeWindow) ActiveAt(t time.Time) (bool, time.Time) {
+	if w.Cron == "" {
+		if w.StartsAt == nil || w.EndsAt == nil {
+			return false, time.Time{}
+		}
+		if !t.Before(*w.StartsAt) && t.Before(*w.EndsAt) {
+			return true, *w.EndsAt
+		}
+		return false, time.Time{}
+	}
+
+	schedule, err := ParseCron(w.Cron)
+	if err != nil {
+		return false, time.Time{}
+	}
+	loc, err := w.location()
+	if err != nil {
+		return false, time.Time{}
+	}
+	// 从当前分钟往前查找最近一次触发，触发后 DurationMinutes 分钟内都处于冻结期
+	current := t.In(loc).Truncate(time.Minute)
+	for i := 0; i < w.DurationMinutes; i++ {
+		start := current.Add(-time.Duration(i) * time.Minute)
+		if schedule.Matches(start) {
+			return true, start.Add(time.Duration(w.DurationMinutes) * time.Minute)
+		}
+	}
+	return false, time.Time{}
+}
+
+// location 返回解释 cron 的时区
+func (w FreezeWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区: %s", w.Timezone)
+	}
+	return loc, nil
+}
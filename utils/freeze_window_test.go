@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	valid := []string{"* * * * *", "0 9-17 * * 1-5", "*/15 0 1,15 * *", "30 22 * * 5-7", "0 0 1-10/3 12 *"}
+	for _, expr := range valid {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("ParseCron(%q) unexpected error: %v", expr, err)
+		}
+	}
+	invalid := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"}
+	for _, expr := range invalid {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) expected error", expr)
+		}
+	}
+}
+
+func TestCronScheduleMatches(t *testing.T) {
+	// 2025-06-27 是周五
+	friday := time.Date(2025, 6, 27, 18, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"30 18 * * 5", friday, true},
+		{"30 18 * * 1-4", friday, false},
+		{"*/15 18 * * *", friday, true},
+		{"*/20 18 * * *", friday, false},
+		{"0 0 * * 7", time.Date(2025, 6, 29, 0, 0, 0, 0, time.UTC), true}, // 7 表示周日
+		// 日和周都指定时满足其一即可
+		{"30 18 1 * 5", friday, true},
+		{"30 18 27 * 1", friday, true},
+		{"30 18 1 * 1", friday, false},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := schedule.Matches(tt.at); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.expr, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestFreezeWindowActiveAt(t *testing.T) {
+	start := time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC)
+	end := start.Add(72 * time.Hour)
+	fixed := FreezeWindow{StartsAt: &start, EndsAt: &end}
+	if active, until := fixed.ActiveAt(start.Add(time.Hour)); !active || !until.Equal(end) {
+		t.Errorf("Expected fixed window active until %v, got %v %v", end, active, until)
+	}
+	if active, _ := fixed.ActiveAt(end); active {
+		t.Error("Expected fixed window to end at EndsAt")
+	}
+
+	// 每周五 18:00 起冻结 2 天
+	weekly := FreezeWindow{Cron: "0 18 * * 5", DurationMinutes: 48 * 60, Timezone: "UTC"}
+	friday := time.Date(2025, 6, 27, 18, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at     time.Time
+		active bool
+	}{
+		{friday.Add(-time.Minute), false},
+		{friday, true},
+		{friday.Add(30 * time.Hour), true},
+		{friday.Add(48 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		active, until := weekly.ActiveAt(tt.at)
+		if active != tt.active {
+			t.Errorf("ActiveAt(%v) = %v, want %v", tt.at, active, tt.active)
+		}
+		if active && !until.Equal(friday.Add(48*time.Hour)) {
+			t.Errorf("Unexpected end of window: %v", until)
+		}
+	}
+}
+
+func TestFreezeWindowValidate(t *testing.T) {
+	start := time.Now()
+	end := start.Add(time.Hour)
+	if err := (FreezeWindow{StartsAt: &start, EndsAt: &end}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := (FreezeWindow{Cron: "0 18 * * 5", DurationMinutes: 60, Timezone: "Asia/Shanghai"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	invalid := []FreezeWindow{
+		{},
+		{StartsAt: &start},
+		{StartsAt: &end, EndsAt: &start},
+		{StartsAt: &start, EndsAt: &end, Cron: "* * * * *", DurationMinutes: 1},
+		{Cron: "0 18 * * 5"},
+		{Cron: "0 18 * * 5", DurationMinutes: 60, Timezone: "Nowhere/City"},
+	}
+	for _, w := range invalid {
+		if err := w.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", w)
+		}
+	}
+}
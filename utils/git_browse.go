@@ -0,0 +1,461 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// gitListDefaultPerPage 未指定时每页返回的数量
+	gitListDefaultPerPage = 30
+	// gitListMaxPerPage 各平台 API 单页的最大数量
+	gitListMaxPerPage = 100
+	// gitListMaxScanPages 平台不支持服务端搜索时，最多扫描的页数
+	gitListMaxScanPages = 10
+)
+
+// GitListOptions 仓库和分支列表的分页与搜索参数
+type GitListOptions struct {
+	Page    int    // 从 1 开始
+	PerPage int    // 默认 30，最大 100
+	Search  string // 按名称模糊匹配，不区分大小写
+}
+
+// normalize 补全默认值并限制范围
+func (o GitListOptions) normalize() GitListOptions {
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PerPage < 1 {
+		o.PerPage = gitListDefaultPerPage
+	}
+	if o.PerPage > gitListMaxPerPage {
+		o.PerPage = gitListMaxPerPage
+	}
+	o.Search = strings.TrimSpace(o.Search)
+	return o
+}
+
+// GitRepository 授权可访问的仓库
+type GitRepository struct {
+	FullName      string `json:"fullName"` // owner/repo，GitLab 可能包含子组
+	URL           string `json:"url"`      // 网页地址，同时作为 https 拉取地址
+	CloneURL      string `json:"cloneUrl"`
+	SSHURL        string `json:"sshUrl"`
+	DefaultBranch string `json:"defaultBranch"`
+	Private       bool   `json:"private"`
+}
+
+// GitBranch 仓库分支
+type GitBranch struct {
+	Name      string `json:"name"`
+	CommitSHA string `json:"commitSha"`
+}
+
+// NormalizeGitBaseURL 校验平台实例地址并返回 scheme://host[:port]，空字符串表示公共实例
+func NormalizeGitBaseURL(baseURL string) (string, error) {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", fmt.Errorf("平台地址必须是 http(s) 地址: %s", baseURL)
+	}
+	return parsed.Scheme + "://" + parsed.Host, nil
+}
+
+// InstanceOrigin 返回平台实例的 scheme://host，BaseURL 为空时使用公共实例
+func (c *GitProviderClient) InstanceOrigin() (string, error) {
+	origin, err := NormalizeGitBaseURL(c.BaseURL)
+	if err != nil || origin != "" {
+		return origin, err
+	}
+	switch c.Platform {
+	case "github":
+		return "https://github.com", nil
+	case "gitlab":
+		return "https://gitlab.com", nil
+	case "gitea":
+		return "https://gitea.com", nil
+	case "bitbucket":
+		return "https://bitbucket.org", nil
+	default:
+		return "", fmt.Errorf("不支持的平台类型: %s", c.Platform)
+	}
+}
+
+// RepositoryURL 返回仓库 owner/repo 在平台实例上的 https 地址
+func (c *GitProviderClient) RepositoryURL(fullName string) (string, error) {
+	fullName = strings.Trim(strings.TrimSpace(fullName), "/")
+	if strings.Count(fullName, "/") < 1 || strings.Contains(fullName, "..") {
+		return "", fmt.Errorf("仓库名称格式应为 owner/repo: %s", fullName)
+	}
+	origin, err := c.InstanceOrigin()
+	if err != nil {
+		return "", err
+	}
+	return origin + "/" + fullName, nil
+}
+
+// MatchesHost 判断仓库地址是否属于该平台实例
+func (c *GitProviderClient) MatchesHost(repoURL string) bool {
+	origin, err := c.InstanceOrigin()
+	if err != nil {
+		return false
+	}
+	repoOrigin, _, err := splitRepoURL(repoURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://"),
+		strings.TrimPrefix(strings.TrimPrefix(repoOrigin, "https://"), "http://"))
+}
+
+// MatchesSSHHost 判断 SSH 仓库地址的主机是否为该平台实例的主机，SSH 端口与网页端口通常不同，只比较主机名
+func (c *GitProviderClient) MatchesSSHHost(repoURL string) bool {
+	origin, err := c.InstanceOrigin()
+	if err != nil {
+		return false
+	}
+	sshHost, _, err := ParseSSHRepoURL(repoURL)
+	if err != nil {
+		return false
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Hostname(), sshHost)
+}
+
+// PublicGitPlatform 根据公共实例的主机名判断仓库所在平台，无法判断时返回空字符串
+func PublicGitPlatform(repoURL string) string {
+	origin, _, err := splitRepoURL(repoURL)
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://")) {
+	case "github.com":
+		return "github"
+	case "gitlab.com":
+		return "gitlab"
+	case "gitea.com":
+		return "gitea"
+	case "bitbucket.org":
+		return "bitbucket"
+	default:
+		return ""
+	}
+}
+
+// hasNextLink 判断 Link 响应头中是否有下一页（GitHub、Gitea）
+func hasNextLink(header http.Header) bool {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		if strings.Contains(link, `rel="next"`) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSearch 判断名称是否包含搜索词，不区分大小写
+func matchesSearch(name, search string) bool {
+	return search == "" || strings.Contains(strings.ToLower(name), strings.ToLower(search))
+}
+
+// scanAndFilter 用于平台不支持服务端搜索的列表：按最大页大小扫描有限的页数，
+// 过滤后再按请求的分页返回。fetch 返回一页结果和是否还有下一页
+func scanAndFilter[T any](opts GitListOptions, name func(T) string, fetch func(page, perPage int) ([]T, bool, error)) ([]T, bool, error) {
+	var matched []T
+	for page := 1; page <= gitListMaxScanPages; page++ {
+		items, more, err := fetch(page, gitListMaxPerPage)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, item := range items {
+			if matchesSearch(name(item), opts.Search) {
+				matched = append(matched, item)
+			}
+		}
+		if !more {
+			break
+		}
+	}
+
+	start := (opts.Page - 1) * opts.PerPage
+	if start >= len(matched) {
+		return []T{}, false, nil
+	}
+	end := start + opts.PerPage
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[start:end], end < len(matched), nil
+}
+
+// ListRepositories 列出授权可访问的仓库，返回当前页和是否还有下一页
+func (c *GitProviderClient) ListRepositories(opts GitListOptions) ([]GitRepository, bool, error) {
+	opts = opts.normalize()
+	origin, err := c.InstanceOrigin()
+	if err != nil {
+		return nil, false, err
+	}
+	base, err := c.apiBaseURL(origin)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch c.Platform {
+	case "github":
+		// GitHub App 的安装令牌只能列出安装的仓库，接口不支持搜索
+		fetch := func(page, perPage int) ([]GitRepository, bool, error) {
+			var resp struct {
+				Repositories []githubRepo `json:"repositories"`
+			}
+			header, err := c.doWithHeaders(http.MethodGet, fmt.Sprintf("%s/installation/repositories?page=%d&per_page=%d", base, page, perPage), nil, &resp)
+			if err != nil {
+				return nil, false, err
+			}
+			repos := make([]GitRepository, len(resp.Repositories))
+			for i, r := range resp.Repositories {
+				repos[i] = r.toRepository()
+			}
+			return repos, hasNextLink(header), nil
+		}
+		if opts.Search == "" {
+			return fetch(opts.Page, opts.PerPage)
+		}
+		return scanAndFilter(opts, func(r GitRepository) string { return r.FullName }, fetch)
+
+	case "gitea":
+		query := url.Values{"page": {strconv.Itoa(opts.Page)}, "limit": {strconv.Itoa(opts.PerPage)}, "q": {opts.Search}}
+		var resp struct {
+			Data []githubRepo `json:"data"`
+		}
+		header, err := c.doWithHeaders(http.MethodGet, base+"/repos/search?"+query.Encode(), nil, &resp)
+		if err != nil {
+			return nil, false, err
+		}
+		repos := make([]GitRepository, len(resp.Data))
+		for i, r := range resp.Data {
+			repos[i] = r.toRepository()
+		}
+		return repos, hasNextLink(header), nil
+
+	case "gitlab":
+		query := url.Values{
+			"membership": {"true"},
+			"simple":     {"true"},
+			"order_by":   {"last_activity_at"},
+			"page":       {strconv.Itoa(opts.Page)},
+			"per_page":   {strconv.Itoa(opts.PerPage)},
+		}
+		if opts.Search != "" {
+			query.Set("search", opts.Search)
+		}
+		var projects []struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+			WebURL            string `json:"web_url"`
+			HTTPURL           string `json:"http_url_to_repo"`
+			SSHURL            string `json:"ssh_url_to_repo"`
+			DefaultBranch     string `json:"default_branch"`
+			Visibility        string `json:"visibility"`
+		}
+		header, err := c.doWithHeaders(http.MethodGet, base+"/projects?"+query.Encode(), nil, &projects)
+		if err != nil {
+			return nil, false, err
+		}
+		repos := make([]GitRepository, len(projects))
+		for i, p := range projects {
+			repos[i] = GitRepository{
+				FullName:      p.PathWithNamespace,
+				URL:           p.WebURL,
+				CloneURL:      p.HTTPURL,
+				SSHURL:        p.SSHURL,
+				DefaultBranch: p.DefaultBranch,
+				Private:       p.Visibility != "public",
+			}
+		}
+		return repos, header.Get("X-Next-Page") != "", nil
+
+	case "bitbucket":
+		query := url.Values{
+			"role":    {"member"},
+			"sort":    {"-updated_on"},
+			"page":    {strconv.Itoa(opts.Page)},
+			"pagelen": {strconv.Itoa(opts.PerPage)},
+		}
+		if opts.Search != "" {
+			query.Set("q", fmt.Sprintf(`name ~ "%s"`, strings.ReplaceAll(opts.Search, `"`, `\"`)))
+		}
+		var resp struct {
+			Values []struct {
+				FullName  string `json:"full_name"`
+				IsPrivate bool   `json:"is_private"`
+				Links     struct {
+					HTML struct {
+						Href string `json:"href"`
+					} `json:"html"`
+					Clone []struct {
+						Name string `json:"name"`
+						Href string `json:"href"`
+					} `json:"clone"`
+				} `json:"links"`
+				MainBranch struct {
+					Name string `json:"name"`
+				} `json:"mainbranch"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := c.do(http.MethodGet, base+"/repositories?"+query.Encode(), nil, &resp); err != nil {
+			return nil, false, err
+		}
+		repos := make([]GitRepository, len(resp.Values))
+		for i, r := range resp.Values {
+			repo := GitRepository{
+				FullName:      r.FullName,
+				URL:           r.Links.HTML.Href,
+				DefaultBranch: r.MainBranch.Name,
+				Private:       r.IsPrivate,
+			}
+			for _, clone := range r.Links.Clone {
+				switch clone.Name {
+				case "https":
+					// 去掉地址中的用户名，拉取时使用授权的凭据
+					if parsed, err := url.Parse(clone.Href); err == nil {
+						parsed.User = nil
+						repo.CloneURL = parsed.String()
+					}
+				case "ssh":
+					repo.SSHURL = clone.Href
+				}
+			}
+			repos[i] = repo
+		}
+		return repos, resp.Next != "", nil
+
+	default:
+		return nil, false, fmt.Errorf("不支持的平台类型: %s", c.Platform)
+	}
+}
+
+// githubRepo GitHub 和 Gitea 的仓库结构相同
+type githubRepo struct {
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+}
+
+func (r githubRepo) toRepository() GitRepository {
+	return GitRepository{
+		FullName:      r.FullName,
+		URL:           r.HTMLURL,
+		CloneURL:      r.CloneURL,
+		SSHURL:        r.SSHURL,
+		DefaultBranch: r.DefaultBranch,
+		Private:       r.Private,
+	}
+}
+
+// ListBranches 列出仓库的分支，repoURL 为仓库的 https 地址
+func (c *GitProviderClient) ListBranches(repoURL string, opts GitListOptions) ([]GitBranch, bool, error) {
+	opts = opts.normalize()
+	repoAPI, err := c.repoAPIURL(repoURL)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch c.Platform {
+	case "github", "gitea":
+		// GitHub 和 Gitea 的分支接口不支持搜索
+		fetch := func(page, perPage int) ([]GitBranch, bool, error) {
+			limitKey := "per_page"
+			if c.Platform == "gitea" {
+				limitKey = "limit"
+			}
+			var branches []struct {
+				Name   string `json:"name"`
+				Commit struct {
+					SHA string `json:"sha"`
+					ID  string `json:"id"`
+				} `json:"commit"`
+			}
+			header, err := c.doWithHeaders(http.MethodGet, fmt.Sprintf("%s/branches?page=%d&%s=%d", repoAPI, page, limitKey, perPage), nil, &branches)
+			if err != nil {
+				return nil, false, err
+			}
+			result := make([]GitBranch, len(branches))
+			for i, b := range branches {
+				sha := b.Commit.SHA
+				if sha == "" {
+					sha = b.Commit.ID // Gitea
+				}
+				result[i] = GitBranch{Name: b.Name, CommitSHA: sha}
+			}
+			more := hasNextLink(header)
+			if c.Platform == "gitea" && header.Get("Link") == "" {
+				// 旧版本 Gitea 没有 Link 头，按是否取满一页判断
+				more = len(branches) == perPage
+			}
+			return result, more, nil
+		}
+		if opts.Search == "" {
+			return fetch(opts.Page, opts.PerPage)
+		}
+		return scanAndFilter(opts, func(b GitBranch) string { return b.Name }, fetch)
+
+	case "gitlab":
+		query := url.Values{"page": {strconv.Itoa(opts.Page)}, "per_page": {strconv.Itoa(opts.PerPage)}}
+		if opts.Search != "" {
+			query.Set("search", opts.Search)
+		}
+		var branches []struct {
+			Name   string `json:"name"`
+			Commit struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+		}
+		header, err := c.doWithHeaders(http.MethodGet, repoAPI+"/repository/branches?"+query.Encode(), nil, &branches)
+		if err != nil {
+			return nil, false, err
+		}
+		result := make([]GitBranch, len(branches))
+		for i, b := range branches {
+			result[i] = GitBranch{Name: b.Name, CommitSHA: b.Commit.ID}
+		}
+		return result, header.Get("X-Next-Page") != "", nil
+
+	case "bitbucket":
+		query := url.Values{"page": {strconv.Itoa(opts.Page)}, "pagelen": {strconv.Itoa(opts.PerPage)}}
+		if opts.Search != "" {
+			query.Set("q", fmt.Sprintf(`name ~ "%s"`, strings.ReplaceAll(opts.Search, `"`, `\"`)))
+		}
+		var resp struct {
+			Values []struct {
+				Name   string `json:"name"`
+				Target struct {
+					Hash string `json:"hash"`
+				} `json:"target"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if err := c.do(http.MethodGet, repoAPI+"/refs/branches?"+query.Encode(), nil, &resp); err != nil {
+			return nil, false, err
+		}
+		result := make([]GitBranch, len(resp.Values))
+		for i, b := range resp.Values {
+			result[i] = GitBranch{Name: b.Name, CommitSHA: b.Target.Hash}
+		}
+		return result, resp.Next != "", nil
+
+	default:
+		return nil, false, fmt.Errorf("不支持的平台类型: %s", c.Platform)
+	}
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitListOptionsNormalize(t *testing.T) {
+	opts := GitListOptions{Page: 0, PerPage: 500, Search: "  web "}.normalize()
+	if opts.Page != 1 || opts.PerPage != gitListMaxPerPage || opts.Search != "web" {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+	if opts := (GitListOptions{}).normalize(); opts.PerPage != gitListDefaultPerPage {
+		t.Errorf("Expected default per page, got %d", opts.PerPage)
+	}
+}
+
+func TestScanAndFilter(t *testing.T) {
+	// 三页数据，每页 100 条
+	fetch := func(page, perPage int) ([]string, bool, error) {
+		items := make([]string, perPage)
+		for i := range items {
+			items[i] = fmt.Sprintf("repo-%d", (page-1)*perPage+i)
+		}
+		return items, page < 3, nil
+	}
+	identity := func(s string) string { return s }
+
+	// repo-1、repo-10..19、repo-100..199 共 111 条
+	items, more, err := scanAndFilter(GitListOptions{Page: 1, PerPage: 100, Search: "REPO-1"}, identity, fetch)
+	if err != nil || len(items) != 100 || !more || items[0] != "repo-1" {
+		t.Fatalf("Unexpected first page: len=%d more=%v err=%v", len(items), more, err)
+	}
+	items, more, _ = scanAndFilter(GitListOptions{Page: 2, PerPage: 100, Search: "repo-1"}, identity, fetch)
+	if len(items) != 11 || more {
+		t.Errorf("Unexpected second page: len=%d more=%v", len(items), more)
+	}
+	items, more, _ = scanAndFilter(GitListOptions{Page: 9, PerPage: 100, Search: "repo-1"}, identity, fetch)
+	if len(items) != 0 || more {
+		t.Errorf("Expected empty page, got len=%d more=%v", len(items), more)
+	}
+}
+
+func TestListRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/installation/repositories":
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("Link", `<https://api.github.com/installation/repositories?page=2>; rel="next"`)
+				fmt.Fprint(w, `{"repositories": [{"full_name": "acme/web", "html_url": "https://github.com/acme/web", "private": true}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"repositories": [{"full_name": "acme/api", "html_url": "https://github.com/acme/api"}]}`)
+		case "/projects":
+			if r.URL.Query().Get("search") != "web" || r.URL.Query().Get("membership") != "true" {
+				t.Errorf("Unexpected GitLab query: %s", r.URL.RawQuery)
+			}
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"path_with_namespace": "group/sub/web", "web_url": "https://gitlab.example/group/sub/web", "visibility": "private", "default_branch": "main"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	github := NewGitProviderClient("github", "tok", "")
+	github.APIBase = server.URL
+	repos, more, err := github.ListRepositories(GitListOptions{Page: 1})
+	if err != nil || len(repos) != 1 || !more || repos[0].FullName != "acme/web" || !repos[0].Private {
+		t.Fatalf("Unexpected GitHub page: %+v more=%v err=%v", repos, more, err)
+	}
+	// 搜索时扫描所有页后过滤
+	repos, more, err = github.ListRepositories(GitListOptions{Search: "API"})
+	if err != nil || len(repos) != 1 || more || repos[0].FullName != "acme/api" {
+		t.Fatalf("Unexpected GitHub search: %+v more=%v err=%v", repos, more, err)
+	}
+
+	gitlab := NewGitProviderClient("gitlab", "tok", "")
+	gitlab.APIBase = server.URL
+	repos, more, err = gitlab.ListRepositories(GitListOptions{Search: "web"})
+	if err != nil || len(repos) != 1 || !more || repos[0].FullName != "group/sub/web" || !repos[0].Private || repos[0].DefaultBranch != "main" {
+		t.Fatalf("Unexpected GitLab page: %+v more=%v err=%v", repos, more, err)
+	}
+}
+
+func TestListBranches(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repositories/team/web/refs/branches":
+			if r.URL.Query().Get("q") != `name ~ "feat"` {
+				t.Errorf("Unexpected Bitbucket query: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"values": [{"name": "feature/a", "target": {"hash": "abc123"}}], "next": "https://api.bitbucket.org/next"}`)
+		case "/repos/acme/web/branches":
+			fmt.Fprint(w, `[{"name": "main", "commit": {"id": "def456"}}, {"name": "dev", "commit": {"id": "0a1b2c"}}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	bitbucket := NewGitProviderClient("bitbucket", "pass", "user")
+	bitbucket.APIBase = server.URL
+	branches, more, err := bitbucket.ListBranches("https://bitbucket.org/team/web", GitListOptions{Search: "feat"})
+	if err != nil || len(branches) != 1 || !more || branches[0].Name != "feature/a" || branches[0].CommitSHA != "abc123" {
+		t.Fatalf("Unexpected Bitbucket branches: %+v more=%v err=%v", branches, more, err)
+	}
+
+	// Gitea 没有 Link 头时按是否取满一页判断
+	gitea := NewGitProviderClient("gitea", "tok", "")
+	gitea.APIBase = server.URL
+	branches, more, err = gitea.ListBranches("https://gitea.example/acme/web", GitListOptions{PerPage: 2})
+	if err != nil || len(branches) != 2 || !more || branches[0].CommitSHA != "def456" {
+		t.Fatalf("Unexpected Gitea branches: %+v more=%v err=%v", branches, more, err)
+	}
+}
+
+func TestInstanceOrigin(t *testing.T) {
+	client := NewGitProviderClient("gitea", "tok", "")
+	if origin, _ := client.InstanceOrigin(); origin != "https://gitea.com" {
+		t.Errorf("Expected public Gitea, got %s", origin)
+	}
+	client.BaseURL = "https://git.example.com:3000/some/path"
+	if origin, _ := client.InstanceOrigin(); origin != "https://git.example.com:3000" {
+		t.Errorf("Unexpected origin: %s", origin)
+	}
+	if repoURL, err := client.RepositoryURL("acme/web"); err != nil || repoURL != "https://git.example.com:3000/acme/web" {
+		t.Errorf("Unexpected repository URL: %s %v", repoURL, err)
+	}
+	if _, err := client.RepositoryURL("web"); err == nil {
+		t.Error("Expected error for repository name without owner")
+	}
+	if !client.MatchesHost("https://GIT.example.com:3000/acme/web.git") || client.MatchesHost("https://github.com/acme/web") {
+		t.Error("Unexpected MatchesHost result")
+	}
+	if !client.MatchesSSHHost("git@git.example.com:acme/web.git") || !client.MatchesSSHHost("ssh://git@git.example.com:2222/acme/web.git") ||
+		client.MatchesSSHHost("git@github.com:acme/web.git") || client.MatchesSSHHost("https://git.example.com:3000/acme/web") {
+		t.Error("Unexpected MatchesSSHHost result")
+	}
+	client.BaseURL = "git.example.com"
+	if _, err := client.InstanceOrigin(); err == nil {
+		t.Error("Expected error for base URL without scheme")
+	}
+
+	if PublicGitPlatform("https://GitLab.com/acme/web.git") != "gitlab" || PublicGitPlatform("https://git.example.com/acme/web") != "" || PublicGitPlatform("git@github.com:acme/web.git") != "" {
+		t.Error("Unexpected PublicGitPlatform result")
+	}
+}
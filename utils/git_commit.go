@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// GitCommitLogFormat 用于 git log -1 --format，字段之间以 NUL 分隔
+const GitCommitLogFormat = "%H%x00%s%x00%an%x00%ae%x00%cI"
+
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// GitCommitInfo 构建所使用的提交信息
+type GitCommitInfo struct {
+	SHA         string
+	Message     string
+	AuthorName  string
+	AuthorEmail string
+	Timestamp   time.Time
+}
+
+// ShortSHA 返回 7 位短 SHA
+func (ci *GitCommitInfo) ShortSHA() string {
+	return ShortSHA(ci.SHA)
+}
+
+// ParseGitCommitInfo 解析 git log -1 --format=GitCommitLogFormat 的输出
+func ParseGitCommitInfo(output string) (*GitCommitInfo, error) {
+	fields := strings.Split(strings.TrimRight(output, "\r\n"), "\x00")
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("无法解析提交信息: %q", output)
+	}
+	if !commitSHAPattern.MatchString(fields[0]) {
+		return nil, fmt.Errorf("无效的提交 SHA: %s", fields[0])
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("无效的提交时间: %s", fields[4])
+	}
+
+	return &GitCommitInfo{
+		SHA:         strings.ToLower(fields[0]),
+		Message:     fields[1],
+		AuthorName:  fields[2],
+		AuthorEmail: fields[3],
+		Timestamp:   timestamp,
+	}, nil
+}
+
+// IsCommitSHA 判断 ref 是否为（可能缩写的）提交 SHA
+func IsCommitSHA(ref string) bool {
+	return commitSHAPattern.MatchString(ref)
+}
+
+// ValidateGitRef 校验要拉取的分支、标签或提交，避免被 git 当作选项或非法 refspec 解析
+func ValidateGitRef(ref string) error {
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("无效的 git 引用: %q", ref)
+	}
+	if IsCommitSHA(ref) {
+		return nil
+	}
+	if err := exec.Command("git", "check-ref-format", "--allow-onelevel", ref).Run(); err != nil {
+		return fmt.Errorf("无效的 git 引用: %q", ref)
+	}
+	return nil
+}
+
+// ShortSHA 截取提交 SHA 的前 7 位
+func ShortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// CommitURL 根据仓库地址生成提交页面链接，无法识别的地址返回空字符串
+func CommitURL(repoURL, sha string) string {
+	base := repoWebURL(repoURL)
+	if base == "" || sha == "" {
+		return ""
+	}
+
+	host := strings.ToLower(strings.SplitN(strings.TrimPrefix(base, "https://"), "/", 2)[0])
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return base + "/-/commit/" + sha
+	case strings.Contains(host, "bitbucket"):
+		return base + "/commits/" + sha
+	default:
+		// GitHub、Gitea 等使用相同的路径
+		return base + "/commit/" + sha
+	}
+}
+
+// CompareURL 根据仓库地址生成两个提交之间的比较页面链接，无法识别的地址返回空字符串
+func CompareURL(repoURL, base, head string) string {
+	repoBase := repoWebURL(repoURL)
+	if repoBase == "" || base == "" || head == "" {
+		return ""
+	}
+
+	host := strings.ToLower(strings.SplitN(strings.TrimPrefix(repoBase, "https://"), "/", 2)[0])
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return repoBase + "/-/compare/" + base + "..." + head
+	case strings.Contains(host, "bitbucket"):
+		return repoBase + "/branches/compare/" + head + "%0D" + base
+	default:
+		return repoBase + "/compare/" + base + "..." + head
+	}
+}
+
+// repoWebURL 将 https、ssh:// 或 git@ 形式的仓库地址转换为网页地址
+func repoWebURL(repoURL string) string {
+	u := strings.TrimSpace(repoURL)
+	switch {
+	case strings.HasPrefix(u, "git@"):
+		hostPath := strings.SplitN(strings.TrimPrefix(u, "git@"), ":", 2)
+		if len(hostPath) != 2 {
+			return ""
+		}
+		u = "https://" + hostPath[0] + "/" + hostPath[1]
+	case strings.HasPrefix(u, "ssh://"):
+		// SSH 端口通常不是网页端口，只保留主机名
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Hostname() == "" {
+			return ""
+		}
+		u = "https://" + parsed.Hostname() + parsed.Path
+	case strings.HasPrefix(u, "http://"), strings.HasPrefix(u, "https://"):
+		// 去掉 URL 中可能携带的凭据
+		scheme, rest, _ := strings.Cut(u, "://")
+		if at := strings.Index(rest, "@"); at >= 0 && at < strings.Index(rest+"/", "/") {
+			rest = rest[at+1:]
+		}
+		u = scheme + "://" + rest
+	default:
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+}
@@ -0,0 +1,411 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CommitState 归一化的提交状态，各平台的取值由 GitProviderClient 转换
+type CommitState string
+
+const (
+	CommitStatePending CommitState = "pending"
+	CommitStateSuccess CommitState = "success"
+	CommitStateFailure CommitState = "failure"
+	CommitStateError   CommitState = "error"
+)
+
+// commitStatusDescriptionLimit GitHub 提交状态描述的最大长度，其他平台限制更宽松
+const commitStatusDescriptionLimit = 140
+
+// compareCommitLimit 比较两个提交时最多返回的提交数
+const compareCommitLimit = 100
+
+// CommitStatus 写入代码托管平台的提交状态
+type CommitStatus struct {
+	State       CommitState
+	Context     string // 状态名称，如 orbitdeploy/web，同名状态会被覆盖
+	Description string
+	TargetURL   string // 点击状态跳转的地址，Bitbucket 要求必填
+}
+
+// GitHubDeploymentStatus GitHub Deployment 的状态
+type GitHubDeploymentStatus struct {
+	State          string // in_progress、success、failure、error、inactive
+	Description    string
+	LogURL         string
+	EnvironmentURL string
+}
+
+// ComparedCommit 两个提交之间的一个提交
+type ComparedCommit struct {
+	SHA     string
+	Message string // 提交说明的第一行
+	Author  string
+	URL     string
+}
+
+// GitProviderClient 调用 GitHub、GitLab、Gitea、Bitbucket 的 REST API。
+// API 地址根据仓库地址推导，支持 GitHub Enterprise 和自建的 GitLab、Gitea
+type GitProviderClient struct {
+	Platform   string // github、gitlab、gitea、bitbucket
+	Token      string // GitHub 安装令牌、GitLab/Gitea 访问令牌或 Bitbucket App Password
+	Username   string // Bitbucket 用户名
+	BaseURL    string // 平台实例地址，如 https://gitea.example.com，浏览仓库时使用，为空时使用公共实例
+	APIBase    string // 为空时根据仓库地址或 BaseURL 推导，如 https://api.github.com
+	HTTPClient *http.Client
+}
+
+// NewGitProviderClient 创建平台 API 客户端
+func NewGitProviderClient(platform, token, username string) *GitProviderClient {
+	return &GitProviderClient{
+		Platform:   platform,
+		Token:      token,
+		Username:   username,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// splitRepoURL 将 https 仓库地址拆分为 scheme://host[:port] 和 owner/repo 路径
+func splitRepoURL(repoURL string) (string, string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return "", "", fmt.Errorf("仓库地址必须是 http(s) 地址: %s", repoURL)
+	}
+	path := strings.TrimSuffix(strings.Trim(parsed.Path, "/"), ".git")
+	if strings.Count(path, "/") < 1 {
+		return "", "", fmt.Errorf("无法从仓库地址解析 owner/repo: %s", repoURL)
+	}
+	return parsed.Scheme + "://" + parsed.Host, path, nil
+}
+
+// apiBaseURL 返回平台实例 origin（scheme://host[:port]）对应的 API 地址
+func (c *GitProviderClient) apiBaseURL(origin string) (string, error) {
+	if c.APIBase != "" {
+		return strings.TrimSuffix(c.APIBase, "/"), nil
+	}
+	host := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://"))
+	switch c.Platform {
+	case "github":
+		if host == "github.com" {
+			return "https://api.github.com", nil
+		}
+		return origin + "/api/v3", nil // GitHub Enterprise
+	case "gitea":
+		return origin + "/api/v1", nil
+	case "gitlab":
+		return origin + "/api/v4", nil
+	case "bitbucket":
+		if host != "bitbucket.org" {
+			return "", fmt.Errorf("仅支持 Bitbucket Cloud: %s", host)
+		}
+		return "https://api.bitbucket.org/2.0", nil
+	default:
+		return "", fmt.Errorf("不支持的平台类型: %s", c.Platform)
+	}
+}
+
+// repoAPIURL 返回仓库在平台 API 中的地址
+func (c *GitProviderClient) repoAPIURL(repoURL string) (string, error) {
+	origin, path, err := splitRepoURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+	base, err := c.apiBaseURL(origin)
+	if err != nil {
+		return "", err
+	}
+	switch c.Platform {
+	case "gitlab":
+		return base + "/projects/" + url.PathEscape(path), nil
+	case "bitbucket":
+		return base + "/repositories/" + path, nil
+	default:
+		return base + "/repos/" + path, nil
+	}
+}
+
+// do 发送 JSON 请求，out 不为 nil 时解析响应
+func (c *GitProviderClient) do(method, endpoint string, body, out interface{}) error {
+	_, err := c.doWithHeaders(method, endpoint, body, out)
+	return err
+}
+
+// doWithHeaders 与 do 相同，同时返回响应头（分页信息在响应头中）
+func (c *GitProviderClient) doWithHeaders(method, endpoint string, body, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.Platform == "github" {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+	// 没有令牌时匿名访问，只能读取公开仓库
+	if c.Token != "" {
+		switch c.Platform {
+		case "github":
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		case "gitea":
+			req.Header.Set("Authorization", "token "+c.Token)
+		case "gitlab":
+			req.Header.Set("PRIVATE-TOKEN", c.Token)
+		case "bitbucket":
+			req.SetBasicAuth(c.Username, c.Token)
+		}
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求 %s API 失败: %w", c.Platform, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s API 返回 %s: %s", c.Platform, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return nil, fmt.Errorf("解析 %s API 响应失败: %w", c.Platform, err)
+		}
+	}
+	return resp.Header, nil
+}
+
+// truncateDescription 截断过长的状态描述
+func truncateDescription(s string) string {
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\n", " "))
+	runes := []rune(s)
+	if len(runes) <= commitStatusDescriptionLimit {
+		return s
+	}
+	return string(runes[:commitStatusDescriptionLimit-3]) + "..."
+}
+
+// platformCommitState 将归一化的状态转换为平台的取值
+func platformCommitState(platform string, state CommitState) string {
+	switch platform {
+	case "gitlab":
+		switch state {
+		case CommitStatePending:
+			return "running"
+		case CommitStateSuccess:
+			return "success"
+		default:
+			return "failed"
+		}
+	case "bitbucket":
+		switch state {
+		case CommitStatePending:
+			return "INPROGRESS"
+		case CommitStateSuccess:
+			return "SUCCESSFUL"
+		case CommitStateError:
+			return "STOPPED"
+		default:
+			return "FAILED"
+		}
+	default:
+		// GitHub 和 Gitea 使用相同的取值
+		return string(state)
+	}
+}
+
+// SetCommitStatus 在提交上写入状态，PR 页面会显示该状态
+func (c *GitProviderClient) SetCommitStatus(repoURL, sha string, status CommitStatus) error {
+	if !IsCommitSHA(sha) {
+		return fmt.Errorf("无效的提交 SHA: %s", sha)
+	}
+	repoAPI, err := c.repoAPIURL(repoURL)
+	if err != nil {
+		return err
+	}
+
+	state := platformCommitState(c.Platform, status.State)
+	description := truncateDescription(status.Description)
+	switch c.Platform {
+	case "gitlab":
+		body := map[string]string{"state": state, "name": status.Context, "description": description}
+		if status.TargetURL != "" {
+			body["target_url"] = status.TargetURL
+		}
+		return c.do(http.MethodPost, repoAPI+"/statuses/"+sha, body, nil)
+	case "bitbucket":
+		targetURL := status.TargetURL
+		if targetURL == "" {
+			targetURL = CommitURL(repoURL, sha)
+		}
+		// Bitbucket 的 key 最长 40 个字符
+		key := status.Context
+		if len(key) > 40 {
+			key = key[:40]
+		}
+		body := map[string]string{"state": state, "key": key, "name": status.Context, "description": description, "url": targetURL}
+		return c.do(http.MethodPost, repoAPI+"/commit/"+sha+"/statuses/build", body, nil)
+	default:
+		body := map[string]string{"state": state, "context": status.Context, "description": description}
+		if status.TargetURL != "" {
+			body["target_url"] = status.TargetURL
+		}
+		return c.do(http.MethodPost, repoAPI+"/statuses/"+sha, body, nil)
+	}
+}
+
+// CreateGitHubDeployment 为提交创建 GitHub Deployment，返回 Deployment ID。
+// 不要求提交的状态检查全部通过，部署是否执行由 OrbitDeploy 决定
+func (c *GitProviderClient) CreateGitHubDeployment(repoURL, sha, environment, description string) (int64, error) {
+	if c.Platform != "github" {
+		return 0, fmt.Errorf("仅 GitHub 支持 Deployments API")
+	}
+	repoAPI, err := c.repoAPIURL(repoURL)
+	if err != nil {
+		return 0, err
+	}
+	body := map[string]interface{}{
+		"ref":               sha,
+		"environment":       environment,
+		"description":       truncateDescription(description),
+		"auto_merge":        false,
+		"required_contexts": []string{},
+	}
+	var resp struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.do(http.MethodPost, repoAPI+"/deployments", body, &resp); err != nil {
+		return 0, err
+	}
+	return resp.ID, nil
+}
+
+// SetGitHubDeploymentStatus 更新 GitHub Deployment 的状态，成功后同一环境之前的部署会被标记为 inactive
+func (c *GitProviderClient) SetGitHubDeploymentStatus(repoURL string, deploymentID int64, status GitHubDeploymentStatus) error {
+	if c.Platform != "github" {
+		return fmt.Errorf("仅 GitHub 支持 Deployments API")
+	}
+	repoAPI, err := c.repoAPIURL(repoURL)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{"state": status.State, "description": truncateDescription(status.Description)}
+	if status.LogURL != "" {
+		body["log_url"] = status.LogURL
+	}
+	if status.EnvironmentURL != "" {
+		body["environment_url"] = status.EnvironmentURL
+	}
+	return c.do(http.MethodPost, fmt.Sprintf("%s/deployments/%d/statuses", repoAPI, deploymentID), body, nil)
+}
+
+// CompareCommits 返回 base 之后到 head 为止的提交（不含 base），最新的提交在前，最多 compareCommitLimit 个。
+// total 为两个提交之间的提交总数，平台不提供时等于返回的提交数
+func (c *GitProviderClient) CompareCommits(repoURL, base, head string) (commits []ComparedCommit, total int, err error) {
+	if !IsCommitSHA(base) || !IsCommitSHA(head) {
+		return nil, 0, fmt.Errorf("无效的提交 SHA: %s...%s", base, head)
+	}
+	repoAPI, err := c.repoAPIURL(repoURL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch c.Platform {
+	case "gitlab":
+		var resp struct {
+			Commits []struct {
+				ID         string `json:"id"`
+				Message    string `json:"message"`
+				AuthorName string `json:"author_name"`
+				WebURL     string `json:"web_url"`
+			} `json:"commits"`
+		}
+		endpoint := repoAPI + "/repository/compare?from=" + url.QueryEscape(base) + "&to=" + url.QueryEscape(head)
+		if err := c.do(http.MethodGet, endpoint, nil, &resp); err != nil {
+			return nil, 0, err
+		}
+		for _, commit := range resp.Commits {
+			commits = append(commits, ComparedCommit{SHA: commit.ID, Message: commit.Message, Author: commit.AuthorName, URL: commit.WebURL})
+		}
+		total = len(commits)
+		reverseCommits(commits) // GitLab 返回的提交由旧到新
+	case "bitbucket":
+		var resp struct {
+			Values []struct {
+				Hash    string `json:"hash"`
+				Message string `json:"message"`
+				Author  struct {
+					Raw string `json:"raw"`
+				} `json:"author"`
+				Links struct {
+					HTML struct {
+						Href string `json:"href"`
+					} `json:"html"`
+				} `json:"links"`
+			} `json:"values"`
+		}
+		endpoint := fmt.Sprintf("%s/commits/%s?exclude=%s&pagelen=%d", repoAPI, head, base, compareCommitLimit)
+		if err := c.do(http.MethodGet, endpoint, nil, &resp); err != nil {
+			return nil, 0, err
+		}
+		for _, commit := range resp.Values {
+			commits = append(commits, ComparedCommit{SHA: commit.Hash, Message: commit.Message, Author: commit.Author.Raw, URL: commit.Links.HTML.Href})
+		}
+		total = len(commits)
+	default:
+		// GitHub 和 Gitea 的比较接口返回相同的结构，提交由旧到新
+		var resp struct {
+			TotalCommits int `json:"total_commits"`
+			Commits      []struct {
+				SHA    string `json:"sha"`
+				Commit struct {
+					Message string `json:"message"`
+					Author  struct {
+						Name string `json:"name"`
+					} `json:"author"`
+				} `json:"commit"`
+				HTMLURL string `json:"html_url"`
+			} `json:"commits"`
+		}
+		if err := c.do(http.MethodGet, repoAPI+"/compare/"+base+"..."+head, nil, &resp); err != nil {
+			return nil, 0, err
+		}
+		for _, commit := range resp.Commits {
+			commits = append(commits, ComparedCommit{SHA: commit.SHA, Message: commit.Commit.Message, Author: commit.Commit.Author.Name, URL: commit.HTMLURL})
+		}
+		total = max(resp.TotalCommits, len(commits))
+		reverseCommits(commits)
+	}
+
+	for i := range commits {
+		commits[i].Message, _, _ = strings.Cut(strings.TrimSpace(commits[i].Message), "\n")
+	}
+	if len(commits) > compareCommitLimit {
+		commits = commits[:compareCommitLimit]
+	}
+	return commits, total, nil
+}
+
+// reverseCommits 原地反转提交顺序
+func reverseCommits(commits []ComparedCommit) {
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+}
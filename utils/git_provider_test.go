@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testSHA = "0123456789abcdef0123456789abcdef01234567"
+
+func TestRepoAPIURL(t *testing.T) {
+	tests := []struct {
+		platform, repoURL, want string
+	}{
+		{"github", "https://github.com/acme/web.git", "https://api.github.com/repos/acme/web"},
+		{"github", "https://git.corp.example/acme/web", "https://git.corp.example/api/v3/repos/acme/web"},
+		{"gitea", "https://gitea.example:3000/acme/web", "https://gitea.example:3000/api/v1/repos/acme/web"},
+		{"gitlab", "https://gitlab.com/group/sub/web.git", "https://gitlab.com/api/v4/projects/group%2Fsub%2Fweb"},
+		{"bitbucket", "https://bitbucket.org/team/web", "https://api.bitbucket.org/2.0/repositories/team/web"},
+	}
+	for _, tt := range tests {
+		got, err := NewGitProviderClient(tt.platform, "t", "").repoAPIURL(tt.repoURL)
+		if err != nil {
+			t.Errorf("repoAPIURL(%s, %s) error: %v", tt.platform, tt.repoURL, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("repoAPIURL(%s, %s) = %q, want %q", tt.platform, tt.repoURL, got, tt.want)
+		}
+	}
+
+	for _, c := range []struct{ platform, repoURL string }{
+		{"github", "git@github.com:acme/web.git"},
+		{"github", "https://github.com/acme"},
+		{"bitbucket", "https://bitbucket.example/team/web"},
+		{"svn", "https://example.com/acme/web"},
+	} {
+		if _, err := NewGitProviderClient(c.platform, "t", "").repoAPIURL(c.repoURL); err == nil {
+			t.Errorf("Expected error for %s %s", c.platform, c.repoURL)
+		}
+	}
+}
+
+func TestPlatformCommitState(t *testing.T) {
+	tests := []struct {
+		platform string
+		state    CommitState
+		want     string
+	}{
+		{"github", CommitStatePending, "pending"},
+		{"gitea", CommitStateFailure, "failure"},
+		{"gitlab", CommitStatePending, "running"},
+		{"gitlab", CommitStateError, "failed"},
+		{"bitbucket", CommitStateSuccess, "SUCCESSFUL"},
+		{"bitbucket", CommitStateFailure, "FAILED"},
+	}
+	for _, tt := range tests {
+		if got := platformCommitState(tt.platform, tt.state); got != tt.want {
+			t.Errorf("platformCommitState(%s, %s) = %q, want %q", tt.platform, tt.state, got, tt.want)
+		}
+	}
+}
+
+// newTestProvider 启动记录请求的测试服务器
+func newTestProvider(t *testing.T, platform string, response string) (*GitProviderClient, *[]*http.Request, *[]map[string]interface{}) {
+	t.Helper()
+	var requests []*http.Request
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewGitProviderClient(platform, "tok", "user")
+	client.APIBase = server.URL
+	return client, &requests, &bodies
+}
+
+func TestSetCommitStatus(t *testing.T) {
+	client, requests, bodies := newTestProvider(t, "github", `{}`)
+	err := client.SetCommitStatus("https://github.com/acme/web", testSHA, CommitStatus{
+		State:       CommitStatePending,
+		Context:     "orbitdeploy/web",
+		Description: strings.Repeat("x", 200),
+		TargetURL:   "https://deploy.example/projects/p/apps/web",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	req, body := (*requests)[0], (*bodies)[0]
+	if req.URL.Path != "/repos/acme/web/statuses/"+testSHA || req.Header.Get("Authorization") != "Bearer tok" {
+		t.Errorf("Unexpected request: %s %v", req.URL.Path, req.Header)
+	}
+	if body["state"] != "pending" || body["context"] != "orbitdeploy/web" || len([]rune(body["description"].(string))) != commitStatusDescriptionLimit {
+		t.Errorf("Unexpected body: %v", body)
+	}
+
+	client, requests, bodies = newTestProvider(t, "gitlab", `{}`)
+	if err := client.SetCommitStatus("https://gitlab.com/acme/web", testSHA, CommitStatus{State: CommitStateFailure, Context: "orbitdeploy/web"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (*requests)[0].Header.Get("PRIVATE-TOKEN") != "tok" || (*bodies)[0]["state"] != "failed" || (*bodies)[0]["name"] != "orbitdeploy/web" {
+		t.Errorf("Unexpected GitLab request: %v %v", (*requests)[0].Header, (*bodies)[0])
+	}
+
+	// Bitbucket 未提供跳转地址时使用提交页面
+	client, requests, bodies = newTestProvider(t, "bitbucket", `{}`)
+	if err := client.SetCommitStatus("https://bitbucket.org/team/web", testSHA, CommitStatus{State: CommitStateSuccess, Context: "orbitdeploy/web"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if user, pass, ok := (*requests)[0].BasicAuth(); !ok || user != "user" || pass != "tok" {
+		t.Error("Expected Bitbucket basic auth")
+	}
+	if (*requests)[0].URL.Path != "/repositories/team/web/commit/"+testSHA+"/statuses/build" || (*bodies)[0]["url"] != "https://bitbucket.org/team/web/commits/"+testSHA {
+		t.Errorf("Unexpected Bitbucket request: %s %v", (*requests)[0].URL.Path, (*bodies)[0])
+	}
+
+	if err := client.SetCommitStatus("https://bitbucket.org/team/web", "main", CommitStatus{State: CommitStateSuccess}); err == nil {
+		t.Error("Expected error for non-SHA ref")
+	}
+}
+
+func TestGitHubDeployment(t *testing.T) {
+	client, requests, bodies := newTestProvider(t, "github", `{"id": 42}`)
+	id, err := client.CreateGitHubDeployment("https://github.com/acme/web", testSHA, "web", "Deploying")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if id != 42 || (*requests)[0].URL.Path != "/repos/acme/web/deployments" || (*bodies)[0]["environment"] != "web" || (*bodies)[0]["ref"] != testSHA {
+		t.Errorf("Unexpected deployment request: id=%d %s %v", id, (*requests)[0].URL.Path, (*bodies)[0])
+	}
+
+	err = client.SetGitHubDeploymentStatus("https://github.com/acme/web", id, GitHubDeploymentStatus{State: "success", EnvironmentURL: "https://web.example"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (*requests)[1].URL.Path != "/repos/acme/web/deployments/42/statuses" || (*bodies)[1]["environment_url"] != "https://web.example" {
+		t.Errorf("Unexpected deployment status request: %s %v", (*requests)[1].URL.Path, (*bodies)[1])
+	}
+
+	if _, err := NewGitProviderClient("gitlab", "t", "").CreateGitHubDeployment("https://gitlab.com/a/b", testSHA, "web", ""); err == nil {
+		t.Error("Expected error for non-GitHub platform")
+	}
+}
+
+func TestCompareCommits(t *testing.T) {
+	base, head := testSHA, "fedcba9876543210fedcba9876543210fedcba98"
+	client, requests, _ := newTestProvider(t, "github", `{"total_commits": 2, "commits": [
+		{"sha": "c1", "commit": {"message": "Fix login\n\nDetails", "author": {"name": "alice"}}, "html_url": "https://github.com/acme/web/commit/c1"},
+		{"sha": "c2", "commit": {"message": "Add cache", "author": {"name": "bob"}}}
+	]}`)
+	commits, total, err := client.CompareCommits("https://github.com/acme/web", base, head)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (*requests)[0].URL.Path != "/repos/acme/web/compare/"+base+"..."+head {
+		t.Errorf("Unexpected request: %s", (*requests)[0].URL.Path)
+	}
+	// 最新的提交在前，只保留提交说明的第一行
+	if total != 2 || len(commits) != 2 || commits[0].SHA != "c2" || commits[1].Message != "Fix login" || commits[1].Author != "alice" {
+		t.Errorf("Unexpected commits: %d %+v", total, commits)
+	}
+
+	client, requests, _ = newTestProvider(t, "gitlab", `{"commits": [{"id": "c1", "message": "Fix", "author_name": "alice", "web_url": "u"}]}`)
+	commits, _, err = client.CompareCommits("https://gitlab.com/acme/web", base, head)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if q := (*requests)[0].URL.Query(); (*requests)[0].URL.Path != "/projects/acme/web/repository/compare" || q.Get("from") != base || q.Get("to") != head {
+		t.Errorf("Unexpected GitLab request: %s", (*requests)[0].URL.String())
+	}
+	if len(commits) != 1 || commits[0].URL != "u" {
+		t.Errorf("Unexpected GitLab commits: %+v", commits)
+	}
+
+	client, requests, _ = newTestProvider(t, "bitbucket", `{"values": [{"hash": "c2", "message": "Add", "author": {"raw": "bob <bob@example.com>"}}]}`)
+	commits, _, err = client.CompareCommits("https://bitbucket.org/team/web", base, head)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if (*requests)[0].URL.Path != "/repositories/team/web/commits/"+head || (*requests)[0].URL.Query().Get("exclude") != base {
+		t.Errorf("Unexpected Bitbucket request: %s", (*requests)[0].URL.String())
+	}
+	if len(commits) != 1 || commits[0].Author != "bob <bob@example.com>" {
+		t.Errorf("Unexpected Bitbucket commits: %+v", commits)
+	}
+
+	if _, _, err := client.CompareCommits("https://bitbucket.org/team/web", "main", head); err == nil {
+		t.Error("Expected error for non-SHA ref")
+	}
+}
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DeployKeyPair OrbitDeploy 生成的 SSH 部署密钥
+type DeployKeyPair struct {
+	PrivateKey  string // OpenSSH 格式的私钥
+	PublicKey   string // authorized_keys 格式的公钥，粘贴到 GitHub/GitLab/Gitea 的 Deploy Keys 中
+	Fingerprint string // SHA256 指纹
+}
+
+// GenerateDeployKey 生成 ed25519 部署密钥，comment 会附加在公钥末尾
+func GenerateDeployKey(comment string) (*DeployKeyPair, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(privateKey, comment)
+	if err != nil {
+		return nil, fmt.Errorf("编码私钥失败: %w", err)
+	}
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("编码公钥失败: %w", err)
+	}
+
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+	if comment != "" {
+		authorizedKey += " " + comment
+	}
+	return &DeployKeyPair{
+		PrivateKey:  string(pem.EncodeToMemory(block)),
+		PublicKey:   authorizedKey,
+		Fingerprint: ssh.FingerprintSHA256(sshPublicKey),
+	}, nil
+}
+
+// IsSSHRepoURL 判断仓库地址是否为 git@host:owner/repo.git 或 ssh:// 形式
+func IsSSHRepoURL(repoURL string) bool {
+	_, _, err := ParseSSHRepoURL(repoURL)
+	return err == nil
+}
+
+// ParseSSHRepoURL 解析 SSH 仓库地址中的主机和端口（默认 22）
+func ParseSSHRepoURL(repoURL string) (host, port string, err error) {
+	u := strings.TrimSpace(repoURL)
+	if strings.HasPrefix(u, "ssh://") {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Hostname() == "" || strings.Trim(parsed.Path, "/") == "" {
+			return "", "", fmt.Errorf("无效的 SSH 仓库地址: %s", repoURL)
+		}
+		port = parsed.Port()
+		if port == "" {
+			port = "22"
+		}
+		return parsed.Hostname(), port, nil
+	}
+
+	// scp 形式：[user@]host:owner/repo.git
+	if strings.Contains(u, "://") {
+		return "", "", fmt.Errorf("不是 SSH 仓库地址: %s", repoURL)
+	}
+	hostPart, path, ok := strings.Cut(u, ":")
+	if !ok || strings.Trim(path, "/") == "" {
+		return "", "", fmt.Errorf("无效的 SSH 仓库地址: %s", repoURL)
+	}
+	if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+		hostPart = hostPart[at+1:]
+	}
+	if hostPart == "" || strings.ContainsAny(hostPart, "/ ") {
+		return "", "", fmt.Errorf("无效的 SSH 仓库地址: %s", repoURL)
+	}
+	return hostPart, "22", nil
+}
+
+// errHostKeyCaptured 获取到主机密钥后中断握手
+var errHostKeyCaptured = errors.New("host key captured")
+
+// ScanSSHHostKeys 连接 SSH 服务器获取主机密钥（相当于 ssh-keyscan），返回 known_hosts 格式的内容
+func ScanSSHHostKeys(host, port string, timeout time.Duration) (string, error) {
+	addr := net.JoinHostPort(host, port)
+	algorithms := []string{ssh.KeyAlgoED25519, ssh.KeyAlgoECDSA256, ssh.KeyAlgoRSASHA512}
+
+	var lines []string
+	var lastErr error
+	for _, algorithm := range algorithms {
+		var captured ssh.PublicKey
+		config := &ssh.ClientConfig{
+			User:              "git",
+			HostKeyAlgorithms: []string{algorithm},
+			HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
+				captured = key
+				return errHostKeyCaptured
+			},
+			Timeout: timeout,
+		}
+		client, err := ssh.Dial("tcp", addr, config)
+		if client != nil {
+			client.Close()
+		}
+		if captured == nil {
+			lastErr = err
+			continue
+		}
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(addr)}, captured))
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("获取 %s 的主机密钥失败: %v", addr, lastErr)
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// KnownHostsFingerprints 校验 known_hosts 内容并返回每个主机密钥的指纹
+func KnownHostsFingerprints(content string) ([]string, error) {
+	rest := []byte(content)
+	var fingerprints []string
+	for len(rest) > 0 {
+		_, hosts, key, _, next, err := ssh.ParseKnownHosts(rest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("无效的 known_hosts 内容: %w", err)
+		}
+		fingerprints = append(fingerprints, fmt.Sprintf("%s %s %s", strings.Join(hosts, ","), key.Type(), ssh.FingerprintSHA256(key)))
+		rest = next
+	}
+	if len(fingerprints) == 0 {
+		return nil, fmt.Errorf("known_hosts 中没有主机密钥")
+	}
+	return fingerprints, nil
+}
+
+// GitSSHCommand 返回 GIT_SSH_COMMAND：只使用指定的私钥，并严格校验固定的主机密钥
+func GitSSHCommand(keyPath, knownHostsPath string) string {
+	return fmt.Sprintf("ssh -F /dev/null -i %s -o IdentitiesOnly=yes -o UserKnownHostsFile=%s -o StrictHostKeyChecking=yes -o BatchMode=yes",
+		shellQuote(keyPath), shellQuote(knownHostsPath))
+}
+
+// ParseLsRemoteSymref 从 git ls-remote --symref <url> HEAD 的输出中解析默认分支
+func ParseLsRemoteSymref(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		ref, name, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if ok && name == "HEAD" && strings.HasPrefix(ref, "ref: refs/heads/") {
+			return strings.TrimPrefix(ref, "ref: refs/heads/")
+		}
+	}
+	return ""
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateDeployKey(t *testing.T) {
+	pair, err := GenerateDeployKey("orbitdeploy-web")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(pair.PublicKey, "ssh-ed25519 ") || !strings.HasSuffix(pair.PublicKey, " orbitdeploy-web") {
+		t.Errorf("Unexpected public key: %s", pair.PublicKey)
+	}
+
+	signer, err := ssh.ParsePrivateKey([]byte(pair.PrivateKey))
+	if err != nil {
+		t.Fatalf("Failed to parse private key: %v", err)
+	}
+	if got := ssh.FingerprintSHA256(signer.PublicKey()); got != pair.Fingerprint {
+		t.Errorf("Fingerprint mismatch: %s != %s", got, pair.Fingerprint)
+	}
+}
+
+func TestParseSSHRepoURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		host     string
+		port     string
+		wantFail bool
+	}{
+		{"git@github.com:acme/web.git", "github.com", "22", false},
+		{"gitea@git.example.com:acme/web", "git.example.com", "22", false},
+		{"ssh://git@git.example.com:2222/acme/web.git", "git.example.com", "2222", false},
+		{"ssh://git@gitlab.com/group/sub/web.git", "gitlab.com", "22", false},
+		{"https://github.com/acme/web.git", "", "", true},
+		{"git@github.com:", "", "", true},
+		{"/local/path", "", "", true},
+	}
+	for _, tt := range tests {
+		host, port, err := ParseSSHRepoURL(tt.url)
+		if (err != nil) != tt.wantFail {
+			t.Errorf("ParseSSHRepoURL(%q) error = %v, wantFail %v", tt.url, err, tt.wantFail)
+			continue
+		}
+		if host != tt.host || port != tt.port {
+			t.Errorf("ParseSSHRepoURL(%q) = %s, %s, want %s, %s", tt.url, host, port, tt.host, tt.port)
+		}
+	}
+}
+
+func TestKnownHostsFingerprints(t *testing.T) {
+	pair, err := GenerateDeployKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "# pinned\n[git.example.com]:2222 " + pair.PublicKey + "\n"
+
+	fingerprints, err := KnownHostsFingerprints(content)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fingerprints) != 1 || !strings.Contains(fingerprints[0], pair.Fingerprint) {
+		t.Errorf("Unexpected fingerprints: %v", fingerprints)
+	}
+
+	if _, err := KnownHostsFingerprints("github.com not-a-key"); err == nil {
+		t.Error("Expected error for invalid known_hosts")
+	}
+	if _, err := KnownHostsFingerprints("\n# empty\n"); err == nil {
+		t.Error("Expected error for empty known_hosts")
+	}
+}
+
+func TestParseLsRemoteSymref(t *testing.T) {
+	output := "ref: refs/heads/develop\tHEAD\n0123456789abcdef0123456789abcdef01234567\tHEAD\n"
+	if got := ParseLsRemoteSymref(output); got != "develop" {
+		t.Errorf("ParseLsRemoteSymref() = %q, want develop", got)
+	}
+	if got := ParseLsRemoteSymref("0123456\tHEAD\n"); got != "" {
+		t.Errorf("ParseLsRemoteSymref() = %q, want empty", got)
+	}
+}
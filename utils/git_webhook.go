@@ -0,0 +1,445 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// GitEventKind 归一化后的代码仓库事件类型
+type GitEventKind string
+
+const (
+	GitEventPush        GitEventKind = "push"         // 推送到分支
+	GitEventTag         GitEventKind = "tag"          // 创建或推送标签
+	GitEventPullRequest GitEventKind = "pull_request" // 合并请求 / Pull Request
+)
+
+// GitEvent GitHub、GitLab、Gitea、Bitbucket 的 webhook 归一化后的事件
+type GitEvent struct {
+	Provider      string
+	Kind          GitEventKind
+	Branch        string // push 时的分支；pull_request 时为源分支
+	Tag           string
+	CommitSHA     string
+	CommitMessage string
+	Author        string
+	Deleted       bool     // 分支或标签被删除
+	RepoURLs      []string // 事件中出现的仓库地址（https、ssh、网页地址），用于匹配应用
+	DefaultBranch string   // 平台提供时为仓库默认分支
+
+	PullRequest *GitPullRequest
+}
+
+// GitPullRequest pull_request 事件的附加信息
+type GitPullRequest struct {
+	Number       int
+	Action       string
+	TargetBranch string
+}
+
+// Ref 返回事件对应的可检出 ref，优先使用提交 SHA
+func (e *GitEvent) Ref() string {
+	switch {
+	case e.CommitSHA != "":
+		return e.CommitSHA
+	case e.Kind == GitEventTag:
+		return e.Tag
+	default:
+		return e.Branch
+	}
+}
+
+// MatchesRepo 判断事件是否来自 repoURL 指向的仓库，忽略协议、凭据、大小写和 .git 后缀
+func (e *GitEvent) MatchesRepo(repoURL string) bool {
+	target := NormalizeRepoURL(repoURL)
+	if target == "" {
+		return false
+	}
+	for _, u := range e.RepoURLs {
+		if NormalizeRepoURL(u) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeRepoURL 将 https、ssh:// 和 git@ 形式的仓库地址归一化为 host/owner/repo
+func NormalizeRepoURL(repoURL string) string {
+	u := strings.TrimSpace(repoURL)
+	if u == "" {
+		return ""
+	}
+	if !strings.Contains(u, "://") {
+		// git@host:owner/repo.git
+		hostPart, path, ok := strings.Cut(u, ":")
+		if !ok {
+			return ""
+		}
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		u = hostPart + "/" + strings.TrimPrefix(path, "/")
+	} else {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Hostname() == "" {
+			return ""
+		}
+		u = parsed.Hostname() + parsed.Path
+	}
+	u = strings.TrimSuffix(strings.TrimSuffix(u, "/"), ".git")
+	return strings.ToLower(u)
+}
+
+// VerifyHMACSHA256 校验 hex 编码的 HMAC-SHA256 签名，signature 可带 "sha256=" 前缀（GitHub、Gitea、Bitbucket）
+func VerifyHMACSHA256(payload []byte, signature, secret string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	signature = strings.TrimPrefix(signature, "sha256=")
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected))
+}
+
+// VerifyWebhookToken 以常量时间比较 GitLab 的 X-Gitlab-Token
+func VerifyWebhookToken(token, secret string) bool {
+	if secret == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// splitGitRef 将 refs/heads/x、refs/tags/x 拆分为事件类型和名称
+func splitGitRef(ref string) (GitEventKind, string) {
+	if tag, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+		return GitEventTag, tag
+	}
+	return GitEventPush, strings.TrimPrefix(ref, "refs/heads/")
+}
+
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// githubLikePush GitHub 和 Gitea 的 push 事件结构相同
+type githubLikePush struct {
+	Ref        string `json:"ref"`
+	After      string `json:"after"`
+	Deleted    bool   `json:"deleted"`
+	HeadCommit *struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	} `json:"head_commit"`
+	Pusher struct {
+		Name  string `json:"name"`
+		Login string `json:"login"`
+	} `json:"pusher"`
+	Repository githubLikeRepo `json:"repository"`
+}
+
+type githubLikeRepo struct {
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+func (r githubLikeRepo) urls() []string {
+	return []string{r.CloneURL, r.SSHURL, r.HTMLURL}
+}
+
+type githubLikePullRequest struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		Title string `json:"title"`
+		User  struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"pull_request"`
+	Repository githubLikeRepo `json:"repository"`
+}
+
+// ParseGitHubEvent 解析 GitHub（eventType 为 X-GitHub-Event）或 Gitea（X-Gitea-Event）的 webhook。
+// 不关心的事件返回 nil, nil
+func ParseGitHubEvent(provider, eventType string, body []byte) (*GitEvent, error) {
+	switch eventType {
+	case "push":
+		var p githubLikePush
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("解析 %s push 事件失败: %w", provider, err)
+		}
+		kind, name := splitGitRef(p.Ref)
+		event := &GitEvent{
+			Provider:      provider,
+			Kind:          kind,
+			CommitSHA:     p.After,
+			Author:        p.Pusher.Name,
+			Deleted:       p.Deleted || p.After == zeroSHA,
+			RepoURLs:      p.Repository.urls(),
+			DefaultBranch: p.Repository.DefaultBranch,
+		}
+		if event.Author == "" {
+			event.Author = p.Pusher.Login
+		}
+		if kind == GitEventTag {
+			event.Tag = name
+		} else {
+			event.Branch = name
+		}
+		if p.HeadCommit != nil {
+			event.CommitMessage = p.HeadCommit.Message
+		}
+		if event.Deleted {
+			event.CommitSHA = ""
+		}
+		return event, nil
+	case "pull_request":
+		var p githubLikePullRequest
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("解析 %s pull_request 事件失败: %w", provider, err)
+		}
+		return &GitEvent{
+			Provider:      provider,
+			Kind:          GitEventPullRequest,
+			Branch:        p.PullRequest.Head.Ref,
+			CommitSHA:     p.PullRequest.Head.SHA,
+			CommitMessage: p.PullRequest.Title,
+			Author:        p.PullRequest.User.Login,
+			RepoURLs:      p.Repository.urls(),
+			DefaultBranch: p.Repository.DefaultBranch,
+			PullRequest: &GitPullRequest{
+				Number:       p.Number,
+				Action:       p.Action,
+				TargetBranch: p.PullRequest.Base.Ref,
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+type gitlabProject struct {
+	GitHTTPURL    string `json:"git_http_url"`
+	GitSSHURL     string `json:"git_ssh_url"`
+	WebURL        string `json:"web_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+func (p gitlabProject) urls() []string {
+	return []string{p.GitHTTPURL, p.GitSSHURL, p.WebURL}
+}
+
+// ParseGitLabEvent 解析 GitLab webhook，eventType 为 X-Gitlab-Event。不关心的事件返回 nil, nil
+func ParseGitLabEvent(eventType string, body []byte) (*GitEvent, error) {
+	switch eventType {
+	case "Push Hook", "Tag Push Hook":
+		var p struct {
+			Ref          string `json:"ref"`
+			After        string `json:"after"`
+			CheckoutSHA  string `json:"checkout_sha"`
+			UserUsername string `json:"user_username"`
+			Commits      []struct {
+				ID      string `json:"id"`
+				Message string `json:"message"`
+			} `json:"commits"`
+			Project gitlabProject `json:"project"`
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("解析 GitLab push 事件失败: %w", err)
+		}
+		kind, name := splitGitRef(p.Ref)
+		sha := p.CheckoutSHA
+		if sha == "" && p.After != zeroSHA {
+			sha = p.After
+		}
+		event := &GitEvent{
+			Provider:      "gitlab",
+			Kind:          kind,
+			CommitSHA:     sha,
+			Author:        p.UserUsername,
+			Deleted:       p.After == zeroSHA,
+			RepoURLs:      p.Project.urls(),
+			DefaultBranch: p.Project.DefaultBranch,
+		}
+		if kind == GitEventTag {
+			event.Tag = name
+		} else {
+			event.Branch = name
+		}
+		for _, commit := range p.Commits {
+			if commit.ID == sha {
+				event.CommitMessage = commit.Message
+			}
+		}
+		return event, nil
+	case "Merge Request Hook":
+		var p struct {
+			User struct {
+				Username string `json:"username"`
+			} `json:"user"`
+			Project          gitlabProject `json:"project"`
+			ObjectAttributes struct {
+				IID          int    `json:"iid"`
+				Action       string `json:"action"`
+				Title        string `json:"title"`
+				SourceBranch string `json:"source_branch"`
+				TargetBranch string `json:"target_branch"`
+				LastCommit   struct {
+					ID string `json:"id"`
+				} `json:"last_commit"`
+			} `json:"object_attributes"`
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("解析 GitLab merge request 事件失败: %w", err)
+		}
+		attrs := p.ObjectAttributes
+		return &GitEvent{
+			Provider:      "gitlab",
+			Kind:          GitEventPullRequest,
+			Branch:        attrs.SourceBranch,
+			CommitSHA:     attrs.LastCommit.ID,
+			CommitMessage: attrs.Title,
+			Author:        p.User.Username,
+			RepoURLs:      p.Project.urls(),
+			DefaultBranch: p.Project.DefaultBranch,
+			PullRequest: &GitPullRequest{
+				Number:       attrs.IID,
+				Action:       attrs.Action,
+				TargetBranch: attrs.TargetBranch,
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+type bitbucketRepository struct {
+	FullName string `json:"full_name"`
+	Links    struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+type bitbucketActor struct {
+	Nickname    string `json:"nickname"`
+	DisplayName string `json:"display_name"`
+}
+
+func (a bitbucketActor) name() string {
+	if a.Nickname != "" {
+		return a.Nickname
+	}
+	return a.DisplayName
+}
+
+// ParseBitbucketEvent 解析 Bitbucket Cloud webhook，eventKey 为 X-Event-Key。
+// 一次推送可能包含多个分支或标签的变更，每个变更对应一个事件
+func ParseBitbucketEvent(eventKey string, body []byte) ([]*GitEvent, error) {
+	switch eventKey {
+	case "repo:push":
+		var p struct {
+			Actor      bitbucketActor      `json:"actor"`
+			Repository bitbucketRepository `json:"repository"`
+			Push       struct {
+				Changes []struct {
+					New *struct {
+						Type   string `json:"type"`
+						Name   string `json:"name"`
+						Target struct {
+							Hash    string `json:"hash"`
+							Message string `json:"message"`
+						} `json:"target"`
+					} `json:"new"`
+					Old *struct {
+						Type string `json:"type"`
+						Name string `json:"name"`
+					} `json:"old"`
+				} `json:"changes"`
+			} `json:"push"`
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("解析 Bitbucket push 事件失败: %w", err)
+		}
+
+		urls := []string{p.Repository.Links.HTML.Href}
+		var events []*GitEvent
+		for _, change := range p.Push.Changes {
+			event := &GitEvent{Provider: "bitbucket", Author: p.Actor.name(), RepoURLs: urls}
+			refType, refName := "", ""
+			if change.New != nil {
+				refType, refName = change.New.Type, change.New.Name
+				event.CommitSHA = change.New.Target.Hash
+				event.CommitMessage = change.New.Target.Message
+			} else if change.Old != nil {
+				refType, refName = change.Old.Type, change.Old.Name
+				event.Deleted = true
+			} else {
+				continue
+			}
+			if refType == "tag" || refType == "annotated_tag" {
+				event.Kind, event.Tag = GitEventTag, refName
+			} else {
+				event.Kind, event.Branch = GitEventPush, refName
+			}
+			events = append(events, event)
+		}
+		return events, nil
+	case "pullrequest:created", "pullrequest:updated", "pullrequest:fulfilled", "pullrequest:rejected":
+		var p struct {
+			Actor       bitbucketActor      `json:"actor"`
+			Repository  bitbucketRepository `json:"repository"`
+			PullRequest struct {
+				ID     int    `json:"id"`
+				Title  string `json:"title"`
+				Source struct {
+					Branch struct {
+						Name string `json:"name"`
+					} `json:"branch"`
+					Commit struct {
+						Hash string `json:"hash"`
+					} `json:"commit"`
+				} `json:"source"`
+				Destination struct {
+					Branch struct {
+						Name string `json:"name"`
+					} `json:"branch"`
+				} `json:"destination"`
+			} `json:"pullrequest"`
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			return nil, fmt.Errorf("解析 Bitbucket pull request 事件失败: %w", err)
+		}
+		pr := p.PullRequest
+		return []*GitEvent{{
+			Provider:      "bitbucket",
+			Kind:          GitEventPullRequest,
+			Branch:        pr.Source.Branch.Name,
+			CommitSHA:     pr.Source.Commit.Hash,
+			CommitMessage: pr.Title,
+			Author:        p.Actor.name(),
+			RepoURLs:      []string{p.Repository.Links.HTML.Href},
+			PullRequest: &GitPullRequest{
+				Number:       pr.ID,
+				Action:       strings.TrimPrefix(eventKey, "pullrequest:"),
+				TargetBranch: pr.Destination.Branch.Name,
+			},
+		}}, nil
+	default:
+		return nil, nil
+	}
+}
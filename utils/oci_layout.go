@@ -0,0 +1,557 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 镜像清单的媒体类型
+const (
+	OCIManifestMediaType        = "application/vnd.oci.image.manifest.v1+json"
+	OCIIndexMediaType           = "application/vnd.oci.image.index.v1+json"
+	DockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+	DockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// ociRefNameAnnotation index.json 中记录标签的注解
+	ociRefNameAnnotation = "org.opencontainers.image.ref.name"
+)
+
+var (
+	ErrDigestInvalid       = errors.New("摘要格式无效")
+	ErrBlobUnknown         = errors.New("blob 不存在")
+	ErrUploadUnknown       = errors.New("上传会话不存在")
+	ErrManifestUnknown     = errors.New("镜像清单不存在")
+	ErrManifestInvalid     = errors.New("镜像清单无效")
+	ErrManifestBlobUnknown = errors.New("镜像清单引用的 blob 不存在")
+	ErrTagInvalid          = errors.New("标签格式无效")
+
+	uploadIDPattern = regexp.MustCompile(`^[a-f0-9]{32}$`)
+
+	// ociIndexMu 串行化 index.json 的读改写
+	ociIndexMu sync.Mutex
+)
+
+// ociDescriptor OCI 内容描述符
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociIndex struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Manifests     []ociDescriptor `json:"manifests"`
+}
+
+// OCILayout 按 OCI Image Layout 规范保存在目录中的镜像：blob 位于 blobs/sha256/<hex>，
+// 标签记录在 index.json，未完成的上传位于 uploads/。目录可直接用 podman pull oci:<目录>:<标签> 导入
+type OCILayout struct {
+	Root string
+}
+
+// OpenOCILayout 打开目录中的 OCI Image Layout，不存在时创建
+func OpenOCILayout(root string) (*OCILayout, error) {
+	layout := &OCILayout{Root: root}
+	for _, dir := range []string{filepath.Join(root, "blobs", "sha256"), filepath.Join(root, "uploads")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建目录失败 %s: %w", dir, err)
+		}
+	}
+
+	files := map[string]string{
+		"oci-layout": `{"imageLayoutVersion": "1.0.0"}`,
+		"index.json": `{"schemaVersion": 2, "manifests": []}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("写入 %s 失败: %w", path, err)
+		}
+	}
+	return layout, nil
+}
+
+// ValidateDigest 校验摘要格式，只支持 sha256
+func ValidateDigest(digest string) error {
+	if !imageDigestPattern.MatchString(digest) {
+		return fmt.Errorf("%w: %s", ErrDigestInvalid, digest)
+	}
+	return nil
+}
+
+// ValidateImageTag 校验镜像标签格式
+func ValidateImageTag(tag string) error {
+	if !imageTagPattern.MatchString(tag) {
+		return fmt.Errorf("%w: %s", ErrTagInvalid, tag)
+	}
+	return nil
+}
+
+// IsDigestReference 判断清单引用是摘要还是标签
+func IsDigestReference(reference string) bool {
+	return strings.Contains(reference, ":")
+}
+
+func (l *OCILayout) blobPath(digest string) (string, error) {
+	if err := ValidateDigest(digest); err != nil {
+		return "", err
+	}
+	return filepath.Join(l.Root, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:")), nil
+}
+
+// BlobSize 返回 blob 的大小，不存在时返回 ErrBlobUnknown。
+// 同时更新 blob 的修改时间：客户端检查到 blob 已存在后会跳过上传直接引用，垃圾回收据此跳过最近使用的 blob
+func (l *OCILayout) BlobSize(digest string) (int64, error) {
+	path, err := l.blobPath(digest)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, ErrBlobUnknown
+	}
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return info.Size(), nil
+}
+
+// OpenBlob 打开 blob 用于读取，调用方负责关闭
+func (l *OCILayout) OpenBlob(digest string) (*os.File, int64, error) {
+	size, err := l.BlobSize(digest)
+	if err != nil {
+		return nil, 0, err
+	}
+	path, _ := l.blobPath(digest)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	return file, size, nil
+}
+
+func (l *OCILayout) uploadPath(id string) (string, error) {
+	if !uploadIDPattern.MatchString(id) {
+		return "", ErrUploadUnknown
+	}
+	return filepath.Join(l.Root, "uploads", id), nil
+}
+
+// StartUpload 创建新的 blob 上传会话，返回会话 ID
+func (l *OCILayout) StartUpload() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf)
+	path, _ := l.uploadPath(id)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return "", fmt.Errorf("创建上传会话失败: %w", err)
+	}
+	return id, nil
+}
+
+// UploadSize 返回上传会话已接收的字节数
+func (l *OCILayout) UploadSize(id string) (int64, error) {
+	path, err := l.uploadPath(id)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return 0, ErrUploadUnknown
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// AppendUpload 把数据追加到上传会话，返回会话已接收的总字节数
+func (l *OCILayout) AppendUpload(id string, r io.Reader) (int64, error) {
+	path, err := l.uploadPath(id)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if os.IsNotExist(err) {
+		return 0, ErrUploadUnknown
+	}
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("写入上传数据失败: %w", err)
+	}
+	return l.UploadSize(id)
+}
+
+// CommitUpload 校验上传会话的内容与摘要一致后将其保存为 blob，返回 blob 大小
+func (l *OCILayout) CommitUpload(id, digest string) (int64, error) {
+	target, err := l.blobPath(digest)
+	if err != nil {
+		return 0, err
+	}
+	path, err := l.uploadPath(id)
+	if err != nil {
+		return 0, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, ErrUploadUnknown
+	}
+	if err != nil {
+		return 0, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("读取上传数据失败: %w", err)
+	}
+	if actual := "sha256:" + hex.EncodeToString(hash.Sum(nil)); actual != digest {
+		return 0, fmt.Errorf("%w: 内容摘要为 %s，与 %s 不一致", ErrDigestInvalid, actual, digest)
+	}
+	if err := os.Rename(path, target); err != nil {
+		return 0, fmt.Errorf("保存 blob 失败: %w", err)
+	}
+	return size, nil
+}
+
+// CancelUpload 删除上传会话
+func (l *OCILayout) CancelUpload(id string) error {
+	path, err := l.uploadPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); os.IsNotExist(err) {
+		return ErrUploadUnknown
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CleanupUploads 删除超过 maxAge 未更新的上传会话，返回删除的数量
+func (l *OCILayout) CleanupUploads(maxAge time.Duration) int {
+	entries, err := os.ReadDir(filepath.Join(l.Root, "uploads"))
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if os.Remove(filepath.Join(l.Root, "uploads", entry.Name())) == nil {
+			removed++
+		}
+	}
+	return removed
+}
+
+// writeBlob 直接写入内容已知的 blob（如镜像清单）
+func (l *OCILayout) writeBlob(content []byte) (string, error) {
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	path, _ := l.blobPath(digest)
+	if _, err := os.Stat(path); err == nil {
+		return digest, nil
+	}
+	tmp, err := os.CreateTemp(filepath.Join(l.Root, "uploads"), "manifest-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("保存镜像清单失败: %w", err)
+	}
+	return digest, nil
+}
+
+// manifestReferences 解析镜像清单的媒体类型和引用的 blob
+func manifestReferences(content []byte, contentType string) (string, []string, error) {
+	var manifest struct {
+		SchemaVersion int             `json:"schemaVersion"`
+		MediaType     string          `json:"mediaType"`
+		Config        *ociDescriptor  `json:"config"`
+		Layers        []ociDescriptor `json:"layers"`
+		Manifests     []ociDescriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrManifestInvalid, err)
+	}
+	if manifest.SchemaVersion != 2 {
+		return "", nil, fmt.Errorf("%w: 只支持 schemaVersion 2", ErrManifestInvalid)
+	}
+
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+	if mediaType == "" {
+		// OCI 清单可以省略 mediaType，按内容判断
+		mediaType = OCIManifestMediaType
+		if manifest.Manifests != nil {
+			mediaType = OCIIndexMediaType
+		}
+	}
+
+	var refs []string
+	switch mediaType {
+	case OCIManifestMediaType, DockerManifestMediaType:
+		if manifest.Config == nil {
+			return "", nil, fmt.Errorf("%w: 缺少 config", ErrManifestInvalid)
+		}
+		refs = append(refs, manifest.Config.Digest)
+		for _, layer := range manifest.Layers {
+			refs = append(refs, layer.Digest)
+		}
+	case OCIIndexMediaType, DockerManifestListMediaType:
+		for _, child := range manifest.Manifests {
+			refs = append(refs, child.Digest)
+		}
+	default:
+		return "", nil, fmt.Errorf("%w: 不支持的媒体类型 %s", ErrManifestInvalid, mediaType)
+	}
+	return mediaType, refs, nil
+}
+
+// PutManifest 保存镜像清单，reference 为标签时更新 index.json 中的标签。
+// 清单引用的 config、layer 或子清单必须已经上传，返回清单摘要
+func (l *OCILayout) PutManifest(reference, contentType string, content []byte) (string, error) {
+	mediaType, refs, err := manifestReferences(content, contentType)
+	if err != nil {
+		return "", err
+	}
+	for _, ref := range refs {
+		if _, err := l.BlobSize(ref); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrManifestBlobUnknown, ref)
+		}
+	}
+
+	isDigest := IsDigestReference(reference)
+	if isDigest {
+		if err := ValidateDigest(reference); err != nil {
+			return "", err
+		}
+	} else if err := ValidateImageTag(reference); err != nil {
+		return "", err
+	}
+
+	digest, err := l.writeBlob(content)
+	if err != nil {
+		return "", err
+	}
+	if isDigest {
+		if digest != reference {
+			return "", fmt.Errorf("%w: 清单摘要为 %s，与 %s 不一致", ErrDigestInvalid, digest, reference)
+		}
+		return digest, nil
+	}
+
+	ociIndexMu.Lock()
+	defer ociIndexMu.Unlock()
+	index, err := l.readIndex()
+	if err != nil {
+		return "", err
+	}
+	manifests := index.Manifests[:0]
+	for _, desc := range index.Manifests {
+		if desc.Annotations[ociRefNameAnnotation] != reference {
+			manifests = append(manifests, desc)
+		}
+	}
+	index.Manifests = append(manifests, ociDescriptor{
+		MediaType:   mediaType,
+		Digest:      digest,
+		Size:        int64(len(content)),
+		Annotations: map[string]string{ociRefNameAnnotation: reference},
+	})
+	if err := l.writeIndex(index); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// GetManifest 按标签或摘要读取镜像清单，返回内容、媒体类型和摘要
+func (l *OCILayout) GetManifest(reference string) ([]byte, string, string, error) {
+	digest := reference
+	if !IsDigestReference(reference) {
+		ociIndexMu.Lock()
+		index, err := l.readIndex()
+		ociIndexMu.Unlock()
+		if err != nil {
+			return nil, "", "", err
+		}
+		digest = ""
+		for _, desc := range index.Manifests {
+			if desc.Annotations[ociRefNameAnnotation] == reference {
+				digest = desc.Digest
+			}
+		}
+		if digest == "" {
+			return nil, "", "", ErrManifestUnknown
+		}
+	}
+
+	path, err := l.blobPath(digest)
+	if err != nil {
+		return nil, "", "", err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, "", "", ErrManifestUnknown
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	mediaType, _, err := manifestReferences(content, "")
+	if err != nil {
+		return nil, "", "", ErrManifestUnknown
+	}
+	return content, mediaType, digest, nil
+}
+
+// RemoveTag 从 index.json 中删除标签，标签已指向其他清单（被重新推送）时不删除
+func (l *OCILayout) RemoveTag(tag, digest string) error {
+	ociIndexMu.Lock()
+	defer ociIndexMu.Unlock()
+	index, err := l.readIndex()
+	if err != nil {
+		return err
+	}
+	manifests := index.Manifests[:0]
+	for _, desc := range index.Manifests {
+		if desc.Annotations[ociRefNameAnnotation] != tag || desc.Digest != digest {
+			manifests = append(manifests, desc)
+		}
+	}
+	if len(manifests) == len(index.Manifests) {
+		return nil
+	}
+	index.Manifests = manifests
+	return l.writeIndex(index)
+}
+
+// CollectGarbage 删除 index.json 中的标签不再引用的清单和 blob，返回删除的数量和大小。
+// 修改时间在 minAge 之内的 blob 可能属于正在进行的推送（已上传但清单还未提交），不删除
+func (l *OCILayout) CollectGarbage(minAge time.Duration) (int, int64, error) {
+	ociIndexMu.Lock()
+	defer ociIndexMu.Unlock()
+	index, err := l.readIndex()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 从标签出发标记清单、子清单以及它们引用的 config 和 layer
+	referenced := make(map[string]bool)
+	pending := make([]string, 0, len(index.Manifests))
+	for _, desc := range index.Manifests {
+		pending = append(pending, desc.Digest)
+	}
+	for len(pending) > 0 {
+		digest := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if referenced[digest] {
+			continue
+		}
+		referenced[digest] = true
+		path, err := l.blobPath(digest)
+		if err != nil {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		mediaType, refs, err := manifestReferences(content, "")
+		if err != nil {
+			continue
+		}
+		if mediaType == OCIIndexMediaType || mediaType == DockerManifestListMediaType {
+			pending = append(pending, refs...)
+			continue
+		}
+		for _, ref := range refs {
+			referenced[ref] = true
+		}
+	}
+
+	dir := filepath.Join(l.Root, "blobs", "sha256")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("读取 blob 目录失败: %w", err)
+	}
+	removed := 0
+	var reclaimed int64
+	for _, entry := range entries {
+		if referenced["sha256:"+entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < minAge {
+			continue
+		}
+		if os.Remove(filepath.Join(dir, entry.Name())) == nil {
+			removed++
+			reclaimed += info.Size()
+		}
+	}
+	return removed, reclaimed, nil
+}
+
+func (l *OCILayout) readIndex() (*ociIndex, error) {
+	content, err := os.ReadFile(filepath.Join(l.Root, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("读取 index.json 失败: %w", err)
+	}
+	var index ociIndex
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, fmt.Errorf("解析 index.json 失败: %w", err)
+	}
+	return &index, nil
+}
+
+func (l *OCILayout) writeIndex(index *ociIndex) error {
+	index.SchemaVersion = 2
+	content, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(l.Root, "index.json.tmp")
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("写入 index.json 失败: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(l.Root, "index.json"))
+}
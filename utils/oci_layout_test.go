@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sha256Digest(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func uploadBlob(t *testing.T, layout *OCILayout, content string) string {
+	t.Helper()
+	id, err := layout.StartUpload()
+	if err != nil {
+		t.Fatalf("StartUpload error: %v", err)
+	}
+	half := len(content) / 2
+	if _, err := layout.AppendUpload(id, strings.NewReader(content[:half])); err != nil {
+		t.Fatalf("AppendUpload error: %v", err)
+	}
+	if size, err := layout.AppendUpload(id, strings.NewReader(content[half:])); err != nil || size != int64(len(content)) {
+		t.Fatalf("Unexpected upload size %d: %v", size, err)
+	}
+	digest := sha256Digest(content)
+	if _, err := layout.CommitUpload(id, digest); err != nil {
+		t.Fatalf("CommitUpload error: %v", err)
+	}
+	return digest
+}
+
+func TestOCILayoutBlobUpload(t *testing.T) {
+	layout, err := OpenOCILayout(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenOCILayout error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(layout.Root, "oci-layout")); err != nil {
+		t.Errorf("Expected oci-layout file: %v", err)
+	}
+
+	digest := uploadBlob(t, layout, "layer-content")
+	if size, err := layout.BlobSize(digest); err != nil || size != int64(len("layer-content")) {
+		t.Errorf("Unexpected blob size %d: %v", size, err)
+	}
+	if _, err := layout.BlobSize(testDigest); !errors.Is(err, ErrBlobUnknown) {
+		t.Errorf("Expected ErrBlobUnknown, got %v", err)
+	}
+
+	id, _ := layout.StartUpload()
+	layout.AppendUpload(id, strings.NewReader("other"))
+	if _, err := layout.CommitUpload(id, digest); !errors.Is(err, ErrDigestInvalid) {
+		t.Errorf("Expected digest mismatch, got %v", err)
+	}
+	if err := layout.CancelUpload(id); err != nil {
+		t.Errorf("CancelUpload error: %v", err)
+	}
+	if _, err := layout.UploadSize(id); !errors.Is(err, ErrUploadUnknown) {
+		t.Errorf("Expected ErrUploadUnknown, got %v", err)
+	}
+	if _, err := layout.UploadSize("../index.json"); !errors.Is(err, ErrUploadUnknown) {
+		t.Errorf("Expected invalid upload ID to be rejected, got %v", err)
+	}
+
+	stale, _ := layout.StartUpload()
+	path, _ := layout.uploadPath(stale)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(path, old, old)
+	if removed := layout.CleanupUploads(24 * time.Hour); removed != 1 {
+		t.Errorf("Expected 1 stale upload removed, got %d", removed)
+	}
+}
+
+func TestOCILayoutManifest(t *testing.T) {
+	layout, _ := OpenOCILayout(t.TempDir())
+	config := uploadBlob(t, layout, `{"architecture":"amd64"}`)
+	layer := uploadBlob(t, layout, "layer")
+	manifest := fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"config":{"digest":%q},"layers":[{"digest":%q}]}`, OCIManifestMediaType, config, layer)
+
+	missing := fmt.Sprintf(`{"schemaVersion":2,"config":{"digest":%q},"layers":[{"digest":%q}]}`, config, testDigest)
+	if _, err := layout.PutManifest("1.0", "", []byte(missing)); !errors.Is(err, ErrManifestBlobUnknown) {
+		t.Errorf("Expected ErrManifestBlobUnknown, got %v", err)
+	}
+	if _, err := layout.PutManifest("1.0", "", []byte(`{"schemaVersion":1}`)); !errors.Is(err, ErrManifestInvalid) {
+		t.Errorf("Expected ErrManifestInvalid, got %v", err)
+	}
+	if _, err := layout.PutManifest("bad tag", "", []byte(manifest)); !errors.Is(err, ErrTagInvalid) {
+		t.Errorf("Expected ErrTagInvalid, got %v", err)
+	}
+
+	digest, err := layout.PutManifest("1.0", "", []byte(manifest))
+	if err != nil || digest != sha256Digest(manifest) {
+		t.Fatalf("Unexpected manifest digest %s: %v", digest, err)
+	}
+	// 重复推送同一标签只保留一条记录
+	if _, err := layout.PutManifest("1.0", "", []byte(manifest)); err != nil {
+		t.Fatalf("PutManifest error: %v", err)
+	}
+	if index, _ := layout.readIndex(); len(index.Manifests) != 1 {
+		t.Errorf("Expected 1 tagged manifest, got %d", len(index.Manifests))
+	}
+
+	content, mediaType, got, err := layout.GetManifest("1.0")
+	if err != nil || string(content) != manifest || mediaType != OCIManifestMediaType || got != digest {
+		t.Errorf("Unexpected manifest by tag: %s %s %v", mediaType, got, err)
+	}
+	if _, _, _, err := layout.GetManifest(digest); err != nil {
+		t.Errorf("Expected manifest by digest: %v", err)
+	}
+	if _, _, _, err := layout.GetManifest(layer); !errors.Is(err, ErrManifestUnknown) {
+		t.Errorf("Expected layer blob not to be served as manifest, got %v", err)
+	}
+	if _, _, _, err := layout.GetManifest("2.0"); !errors.Is(err, ErrManifestUnknown) {
+		t.Errorf("Expected ErrManifestUnknown, got %v", err)
+	}
+	if _, err := layout.PutManifest(testDigest, "", []byte(manifest)); !errors.Is(err, ErrDigestInvalid) {
+		t.Errorf("Expected digest mismatch, got %v", err)
+	}
+}
+
+func TestOCILayoutCollectGarbage(t *testing.T) {
+	layout, _ := OpenOCILayout(t.TempDir())
+	putImage := func(tag, layerContent string) (string, []string) {
+		config := uploadBlob(t, layout, `{"tag":"`+tag+`"}`)
+		layer := uploadBlob(t, layout, layerContent)
+		manifest := fmt.Sprintf(`{"schemaVersion":2,"config":{"digest":%q},"layers":[{"digest":%q}]}`, config, layer)
+		digest, err := layout.PutManifest(tag, "", []byte(manifest))
+		if err != nil {
+			t.Fatalf("PutManifest error: %v", err)
+		}
+		return digest, []string{digest, config, layer}
+	}
+	age := func(digests []string) {
+		old := time.Now().Add(-48 * time.Hour)
+		for _, digest := range digests {
+			path, _ := layout.blobPath(digest)
+			os.Chtimes(path, old, old)
+		}
+	}
+
+	oldDigest, oldBlobs := putImage("1.0", "shared-layer")
+	_, keptBlobs := putImage("2.0", "shared-layer")
+	pending := uploadBlob(t, layout, "pending-layer") // 已上传、清单未提交
+	age(oldBlobs)
+	age(keptBlobs)
+
+	if err := layout.RemoveTag("1.0", testDigest); err != nil {
+		t.Fatalf("RemoveTag error: %v", err)
+	}
+	if index, _ := layout.readIndex(); len(index.Manifests) != 2 {
+		t.Errorf("Expected tag pointing to another manifest to be kept, got %d tags", len(index.Manifests))
+	}
+	if err := layout.RemoveTag("1.0", oldDigest); err != nil {
+		t.Fatalf("RemoveTag error: %v", err)
+	}
+
+	removed, reclaimed, err := layout.CollectGarbage(24 * time.Hour)
+	if err != nil || removed != 2 || reclaimed == 0 {
+		t.Fatalf("Unexpected garbage collection result %d %d: %v", removed, reclaimed, err)
+	}
+	for _, digest := range oldBlobs[:2] {
+		if _, err := layout.BlobSize(digest); !errors.Is(err, ErrBlobUnknown) {
+			t.Errorf("Expected %s to be removed, got %v", digest, err)
+		}
+	}
+	for _, digest := range append(keptBlobs, pending) {
+		if _, err := layout.BlobSize(digest); err != nil {
+			t.Errorf("Expected %s to be kept: %v", digest, err)
+		}
+	}
+}
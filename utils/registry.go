@@ -0,0 +1,477 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DockerHubRegistry 未指定镜像仓库地址时使用的默认仓库
+const DockerHubRegistry = "docker.io"
+
+// dockerHubAPIHost Docker Hub 的 Registry API 地址
+const dockerHubAPIHost = "registry-1.docker.io"
+
+// registryTagsMaxPages 列出标签时最多读取的页数
+const registryTagsMaxPages = 20
+
+// manifestMediaTypes 解析摘要时接受的清单类型，多架构镜像返回清单列表的摘要
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+var (
+	imagePathComponentPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	imageTagPattern           = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	imageDigestPattern        = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	registryHostPattern       = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?(:[0-9]+)?$`)
+)
+
+// ImageReference 解析后的容器镜像引用，Registry 和 Repository 已补全为完整形式
+type ImageReference struct {
+	Registry   string // e.g., "docker.io", "ghcr.io", "registry.example.com:5000"
+	Repository string // e.g., "library/nginx", "acme/web"
+	Tag        string
+	Digest     string // e.g., "sha256:..."，指定时优先于 Tag
+}
+
+// ParseImageReference 解析镜像引用，如 "nginx"、"ghcr.io/acme/web:1.2.0"、"registry.example.com:5000/web@sha256:..."。
+// 省略仓库地址时使用 Docker Hub，省略标签和摘要时使用 latest
+func ParseImageReference(ref string) (*ImageReference, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("镜像引用不能为空")
+	}
+
+	name := ref
+	r := &ImageReference{}
+	if before, digest, found := strings.Cut(name, "@"); found {
+		if !imageDigestPattern.MatchString(digest) {
+			return nil, fmt.Errorf("无效的镜像摘要: %s", digest)
+		}
+		name, r.Digest = before, digest
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		if !imageTagPattern.MatchString(name[i+1:]) {
+			return nil, fmt.Errorf("无效的镜像标签: %s", name[i+1:])
+		}
+		name, r.Tag = name[:i], name[i+1:]
+	}
+
+	r.Registry = DockerHubRegistry
+	if first, rest, found := strings.Cut(name, "/"); found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host := strings.ToLower(first)
+		if !registryHostPattern.MatchString(host) {
+			return nil, fmt.Errorf("无效的镜像仓库地址: %s", first)
+		}
+		if host != "index.docker.io" {
+			r.Registry = host
+		}
+		name = rest
+	}
+	if r.Registry == DockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	for _, component := range strings.Split(name, "/") {
+		if !imagePathComponentPattern.MatchString(component) {
+			return nil, fmt.Errorf("无效的镜像名称: %s", ref)
+		}
+	}
+	r.Repository = name
+
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = "latest"
+	}
+	return r, nil
+}
+
+// Name 返回不带标签和摘要的完整镜像名称
+func (r ImageReference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String 返回完整的镜像引用
+func (r ImageReference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// WithTag 返回使用另一个标签的引用
+func (r ImageReference) WithTag(tag string) ImageReference {
+	r.Tag = tag
+	r.Digest = ""
+	return r
+}
+
+// Pinned 返回固定到指定摘要的镜像引用，拉取和运行时不受标签变化影响
+func (r ImageReference) Pinned(digest string) string {
+	return r.Name() + "@" + digest
+}
+
+// manifestReference 返回 Registry API 中清单的引用，优先使用摘要
+func (r ImageReference) manifestReference() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// RegistryAuthFile 生成 podman --authfile 使用的认证文件内容
+func RegistryAuthFile(registry, username, password string) ([]byte, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return json.MarshalIndent(map[string]interface{}{
+		"auths": map[string]interface{}{
+			registry: map[string]string{"auth": auth},
+		},
+	}, "", "  ")
+}
+
+// PodmanCommandForUser 返回以指定系统用户执行 podman 的命令，username 为空时直接执行
+func PodmanCommandForUser(username string, args ...string) (string, []string) {
+	if username == "" {
+		return "podman", args
+	}
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return "su", []string{"-", username, "-c", "podman " + strings.Join(quoted, " ")}
+}
+
+// RegistryClient 访问镜像仓库的 Registry HTTP API V2，支持 Basic 和 Bearer 令牌认证
+type RegistryClient struct {
+	Username   string
+	Password   string
+	Scheme     string // 默认 https
+	HTTPClient *http.Client
+}
+
+// NewRegistryClient 创建镜像仓库客户端，用户名为空时匿名访问
+func NewRegistryClient(username, password string) *RegistryClient {
+	return &RegistryClient{
+		Username:   username,
+		Password:   password,
+		Scheme:     "https",
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// endpoint 返回镜像仓库 API 的地址
+func (c *RegistryClient) endpoint(ref *ImageReference, path string) string {
+	host := ref.Registry
+	if host == DockerHubRegistry {
+		host = dockerHubAPIHost
+	}
+	return fmt.Sprintf("%s://%s/v2/%s%s", c.Scheme, host, ref.Repository, path)
+}
+
+// do 发送请求，收到 401 时按 WWW-Authenticate 的要求获取凭据后重试一次
+func (c *RegistryClient) do(method, rawURL string, accept []string) (*http.Response, error) {
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(method, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(accept) > 0 {
+			req.Header.Set("Accept", strings.Join(accept, ", "))
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求镜像仓库失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	resp.Body.Close()
+
+	authorization, err := c.authorize(challenge)
+	if err != nil {
+		return nil, err
+	}
+	req, err = newRequest()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+	resp, err = c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求镜像仓库失败: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return nil, fmt.Errorf("镜像仓库认证失败，请检查用户名和密码")
+	}
+	return resp, nil
+}
+
+// authorize 根据认证质询生成 Authorization 头
+func (c *RegistryClient) authorize(challenge string) (string, error) {
+	scheme, params := parseAuthChallenge(challenge)
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(c.Username+":"+c.Password))
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.Username == "" {
+			return "", fmt.Errorf("镜像仓库需要认证，请设置用户名和密码")
+		}
+		return basic, nil
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("镜像仓库的认证质询缺少 realm")
+		}
+		tokenURL, err := url.Parse(realm)
+		if err != nil {
+			return "", fmt.Errorf("无效的认证地址 %s: %w", realm, err)
+		}
+		query := tokenURL.Query()
+		for _, key := range []string{"service", "scope"} {
+			if params[key] != "" {
+				query.Set(key, params[key])
+			}
+		}
+		tokenURL.RawQuery = query.Encode()
+
+		req, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if c.Username != "" {
+			req.Header.Set("Authorization", basic)
+		}
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("获取镜像仓库令牌失败: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("获取镜像仓库令牌失败: HTTP %d", resp.StatusCode)
+		}
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+			return "", fmt.Errorf("解析镜像仓库令牌失败: %w", err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		if token.Token == "" {
+			return "", fmt.Errorf("镜像仓库没有返回令牌")
+		}
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("不支持的镜像仓库认证方式: %s", scheme)
+	}
+}
+
+// parseAuthChallenge 解析 WWW-Authenticate 头，如 Bearer realm="...",service="...",scope="..."
+func parseAuthChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				params[key] = value[1:]
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			params[key], rest, _ = strings.Cut(value, ",")
+		}
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+		rest = strings.TrimSpace(rest)
+	}
+	return scheme, params
+}
+
+// ResolveDigest 查询镜像引用当前指向的清单摘要
+func (c *RegistryClient) ResolveDigest(ref *ImageReference) (string, error) {
+	manifestURL := c.endpoint(ref, "/manifests/"+ref.manifestReference())
+	resp, err := c.do(http.MethodHead, manifestURL, manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := registryStatusError(ref, resp); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); imageDigestPattern.MatchString(digest) {
+		return digest, nil
+	}
+
+	// 部分仓库的 HEAD 响应不带摘要，下载清单自行计算
+	resp, err = c.do(http.MethodGet, manifestURL, manifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := registryStatusError(ref, resp); err != nil {
+		return "", err
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); imageDigestPattern.MatchString(digest) {
+		return digest, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return "", fmt.Errorf("读取镜像清单失败: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// ListTags 列出镜像仓库中的所有标签
+func (c *RegistryClient) ListTags(ref *ImageReference) ([]string, error) {
+	var tags []string
+	next := c.endpoint(ref, "/tags/list?n=1000")
+	for page := 0; next != "" && page < registryTagsMaxPages; page++ {
+		resp, err := c.do(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, err
+		}
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		err = registryStatusError(ref, resp)
+		if err == nil {
+			err = json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&body)
+		}
+		link := resp.Header.Get("Link")
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, body.Tags...)
+
+		next = ""
+		if target := parseNextLink(link); target != "" {
+			base, _ := url.Parse(resp.Request.URL.String())
+			if resolved, err := base.Parse(target); err == nil {
+				next = resolved.String()
+			}
+		}
+	}
+	return tags, nil
+}
+
+// parseNextLink 返回 Link 头中 rel="next" 的地址
+func parseNextLink(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		if !strings.Contains(part, `rel="next"`) {
+			continue
+		}
+		start, end := strings.Index(part, "<"), strings.Index(part, ">")
+		if start >= 0 && end > start {
+			return part[start+1 : end]
+		}
+	}
+	return ""
+}
+
+// registryStatusError 将非 200 的响应转换为错误
+func registryStatusError(ref *ImageReference, resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("镜像不存在: %s", ref.String())
+	case http.StatusForbidden:
+		return fmt.Errorf("没有权限访问镜像: %s", ref.String())
+	default:
+		return fmt.Errorf("镜像仓库返回 HTTP %d: %s", resp.StatusCode, ref.String())
+	}
+}
+
+// parseVersionTag 解析 "1.2.3"、"v1.2" 形式的版本标签，不接受预发布版本
+func parseVersionTag(tag string) ([]int, bool, bool) {
+	prefixed := strings.HasPrefix(tag, "v")
+	parts := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	if len(parts) > 3 {
+		return nil, false, false
+	}
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part == "" || (len(part) > 1 && part[0] == '0') {
+			return nil, false, false
+		}
+		version[i] = n
+	}
+	return version, prefixed, true
+}
+
+// NewerVersionTag 返回 tags 中比 current 更新的最高版本标签，只比较与 current 形式相同的标签
+// （是否带 v 前缀、版本号段数一致），current 不是版本标签或没有更新的版本时返回空字符串
+func NewerVersionTag(current string, tags []string) string {
+	base, prefixed, ok := parseVersionTag(current)
+	if !ok {
+		return ""
+	}
+
+	type candidate struct {
+		tag     string
+		version []int
+	}
+	var candidates []candidate
+	for _, tag := range tags {
+		version, p, ok := parseVersionTag(tag)
+		if !ok || p != prefixed || len(version) != len(base) || compareVersions(version, base) <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag, version})
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return compareVersions(candidates[i].version, candidates[j].version) > 0
+	})
+	return candidates[0].tag
+}
+
+func compareVersions(a, b []int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] > b[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"nginx", "docker.io/library/nginx:latest"},
+		{"acme/web:1.2.0", "docker.io/acme/web:1.2.0"},
+		{"index.docker.io/acme/web", "docker.io/acme/web:latest"},
+		{"ghcr.io/Acme/web:v2", ""},
+		{"ghcr.io/acme/web:v2", "ghcr.io/acme/web:v2"},
+		{"registry.example.com:5000/team/web@" + testDigest, "registry.example.com:5000/team/web@" + testDigest},
+		{"localhost/web:dev", "localhost/web:dev"},
+		{"web:bad tag", ""},
+		{"web@sha256:123", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		ref, err := ParseImageReference(tt.ref)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseImageReference(%q) expected error, got %s", tt.ref, ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseImageReference(%q) error: %v", tt.ref, err)
+			continue
+		}
+		if ref.String() != tt.want {
+			t.Errorf("ParseImageReference(%q) = %s, want %s", tt.ref, ref, tt.want)
+		}
+	}
+
+	ref, _ := ParseImageReference("ghcr.io/acme/web:1.0")
+	if ref.Pinned(testDigest) != "ghcr.io/acme/web@"+testDigest || ref.WithTag("1.1").String() != "ghcr.io/acme/web:1.1" {
+		t.Errorf("Unexpected pinned or retagged reference")
+	}
+}
+
+func TestRegistryAuthFile(t *testing.T) {
+	content, err := RegistryAuthFile("ghcr.io", "bot", "s3cret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var parsed struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(content, &parsed); err != nil {
+		t.Fatalf("Invalid auth file: %v", err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(parsed.Auths["ghcr.io"].Auth)
+	if string(decoded) != "bot:s3cret" {
+		t.Errorf("Unexpected auth: %s", decoded)
+	}
+}
+
+func TestPodmanCommandForUser(t *testing.T) {
+	name, args := PodmanCommandForUser("", "pull", "nginx")
+	if name != "podman" || strings.Join(args, " ") != "pull nginx" {
+		t.Errorf("Unexpected command: %s %v", name, args)
+	}
+	name, args = PodmanCommandForUser("shop", "pull", "--authfile", "/home/shop/a b.json", "nginx")
+	if name != "su" || args[1] != "shop" || args[3] != `podman 'pull' '--authfile' '/home/shop/a b.json' 'nginx'` {
+		t.Errorf("Unexpected command: %s %v", name, args)
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(`Bearer realm="https://auth.example/token",service="registry.example",scope="repository:acme/web:pull,push"`)
+	if scheme != "Bearer" || params["realm"] != "https://auth.example/token" || params["service"] != "registry.example" || params["scope"] != "repository:acme/web:pull,push" {
+		t.Errorf("Unexpected challenge: %s %v", scheme, params)
+	}
+	if scheme, params := parseAuthChallenge(`Basic realm=registry`); scheme != "Basic" || params["realm"] != "registry" {
+		t.Errorf("Unexpected challenge: %s %v", scheme, params)
+	}
+}
+
+func TestNewerVersionTag(t *testing.T) {
+	tags := []string{"latest", "1.2.0", "1.10.0", "1.9.3", "2.0.0-rc1", "v3.0.0", "1.3", "01.99.0"}
+	if got := NewerVersionTag("1.2.0", tags); got != "1.10.0" {
+		t.Errorf("Expected 1.10.0, got %q", got)
+	}
+	if got := NewerVersionTag("1.10.0", tags); got != "" {
+		t.Errorf("Expected no newer tag, got %q", got)
+	}
+	if got := NewerVersionTag("v2.9.0", tags); got != "v3.0.0" {
+		t.Errorf("Expected v3.0.0, got %q", got)
+	}
+	if got := NewerVersionTag("latest", tags); got != "" {
+		t.Errorf("Expected no newer tag for latest, got %q", got)
+	}
+}
+
+func TestRegistryClient(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, ok := r.BasicAuth(); !ok || user != "bot" || pass != "pw" || r.URL.Query().Get("scope") != "repository:acme/web:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "tkn"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tkn" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:acme/web:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/acme/web/manifests/1.0":
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		case r.URL.Path == "/v2/acme/web/tags/list" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/acme/web/tags/list?n=1000&last=1.0>; rel="next"`)
+			fmt.Fprint(w, `{"tags": ["1.0"]}`)
+		case r.URL.Path == "/v2/acme/web/tags/list":
+			fmt.Fprint(w, `{"tags": ["1.1"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	ref, err := ParseImageReference(host + "/acme/web:1.0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	client := NewRegistryClient("bot", "pw")
+	client.Scheme = "http"
+
+	digest, err := client.ResolveDigest(ref)
+	if err != nil || digest != testDigest {
+		t.Fatalf("Unexpected digest: %s %v", digest, err)
+	}
+	tags, err := client.ListTags(ref)
+	if err != nil || strings.Join(tags, ",") != "1.0,1.1" {
+		t.Fatalf("Unexpected tags: %v %v", tags, err)
+	}
+	missing := ref.WithTag("9.9")
+	if _, err := client.ResolveDigest(&missing); err == nil || !strings.Contains(err.Error(), "镜像不存在") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+
+	client.Password = "wrong"
+	if _, err := client.ResolveDigest(ref); err == nil {
+		t.Error("Expected authentication error")
+	}
+}
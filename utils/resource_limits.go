@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+
+	"github.com/shirou/gopsutil/v3/mem"
+)
+
+// podman 允许的最小内存限制为 6MB
+const minMemoryLimitMB = 6
+
+// OOM 策略，对应 systemd 的 OOMPolicy=
+const (
+	OOMPolicyContinue = "continue" // 仅终止触发 OOM 的进程，服务继续运行
+	OOMPolicyStop     = "stop"     // 停止整个服务
+	OOMPolicyKill     = "kill"     // 终止服务中的所有进程
+)
+
+// ResourceLimits 单个应用容器的资源限制，零值表示不限制
+type ResourceLimits struct {
+	CPUQuota            float64 `gorm:"not null;default:0"` // 可使用的 CPU 核数，如 0.5、2
+	MemoryLimitMB       int     `gorm:"not null;default:0"` // 内存硬限制 (MB)
+	MemoryReservationMB int     `gorm:"not null;default:0"` // 内存软限制 (MB)，主机内存紧张时回收到该值
+	PidsLimit           int     `gorm:"not null;default:0"` // 容器内最大进程数
+	OOMPolicy           string  `gorm:"size:20;not null;default:''"`
+}
+
+// HostCapacity 主机可分配的资源总量
+type HostCapacity struct {
+	CPUs     int
+	MemoryMB int
+}
+
+// DetectHostCapacity 返回本机的 CPU 核数和物理内存；内存读取失败时 MemoryMB 为 0，表示不做内存上限校验
+func DetectHostCapacity() HostCapacity {
+	capacity := HostCapacity{CPUs: runtime.NumCPU()}
+	if vm, err := mem.VirtualMemory(); err == nil {
+		capacity.MemoryMB = int(vm.Total / 1024 / 1024)
+	}
+	return capacity
+}
+
+// Validate 校验资源限制本身是否合法，以及是否超出主机容量
+func (l ResourceLimits) Validate(capacity HostCapacity) error {
+	if l.CPUQuota < 0 {
+		return fmt.Errorf("CPU 配额不能为负数")
+	}
+	if capacity.CPUs > 0 && l.CPUQuota > float64(capacity.CPUs) {
+		return fmt.Errorf("CPU 配额 %s 超过主机 CPU 核数 %d", formatCPUQuota(l.CPUQuota), capacity.CPUs)
+	}
+
+	if l.MemoryLimitMB < 0 || l.MemoryReservationMB < 0 {
+		return fmt.Errorf("内存限制不能为负数")
+	}
+	if l.MemoryLimitMB > 0 && l.MemoryLimitMB < minMemoryLimitMB {
+		return fmt.Errorf("内存限制不能小于 %dMB", minMemoryLimitMB)
+	}
+	if capacity.MemoryMB > 0 && l.MemoryLimitMB > capacity.MemoryMB {
+		return fmt.Errorf("内存限制 %dMB 超过主机内存 %dMB", l.MemoryLimitMB, capacity.MemoryMB)
+	}
+	if capacity.MemoryMB > 0 && l.MemoryReservationMB > capacity.MemoryMB {
+		return fmt.Errorf("内存预留 %dMB 超过主机内存 %dMB", l.MemoryReservationMB, capacity.MemoryMB)
+	}
+	if l.MemoryLimitMB > 0 && l.MemoryReservationMB > l.MemoryLimitMB {
+		return fmt.Errorf("内存预留 %dMB 不能大于内存限制 %dMB", l.MemoryReservationMB, l.MemoryLimitMB)
+	}
+
+	if l.PidsLimit < 0 {
+		return fmt.Errorf("进程数限制不能为负数")
+	}
+
+	switch l.OOMPolicy {
+	case "", OOMPolicyContinue, OOMPolicyStop, OOMPolicyKill:
+	default:
+		return fmt.Errorf("无效的 OOM 策略: %s，支持的策略: continue, stop, kill", l.OOMPolicy)
+	}
+	return nil
+}
+
+// PodmanArgs 返回 Quadlet [Container] 段中 PodmanArgs= 的参数
+func (l ResourceLimits) PodmanArgs() []string {
+	var args []string
+	if l.CPUQuota > 0 {
+		args = append(args, "--cpus="+formatCPUQuota(l.CPUQuota))
+	}
+	if l.MemoryLimitMB > 0 {
+		args = append(args, fmt.Sprintf("--memory=%dm", l.MemoryLimitMB))
+	}
+	if l.MemoryReservationMB > 0 {
+		args = append(args, fmt.Sprintf("--memory-reservation=%dm", l.MemoryReservationMB))
+	}
+	if l.PidsLimit > 0 {
+		args = append(args, fmt.Sprintf("--pids-limit=%d", l.PidsLimit))
+	}
+	return args
+}
+
+// ServiceSettings 返回 Quadlet [Service] 段中的 systemd 设置
+func (l ResourceLimits) ServiceSettings() []string {
+	var settings []string
+	if l.OOMPolicy != "" {
+		settings = append(settings, "OOMPolicy="+l.OOMPolicy)
+	}
+	return settings
+}
+
+func formatCPUQuota(quota float64) string {
+	return strconv.FormatFloat(quota, 'f', -1, 64)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResourceLimitsValidate(t *testing.T) {
+	capacity := HostCapacity{CPUs: 4, MemoryMB: 8192}
+
+	tests := []struct {
+		name    string
+		limits  ResourceLimits
+		wantErr bool
+	}{
+		{"No limits", ResourceLimits{}, false},
+		{"Valid limits", ResourceLimits{CPUQuota: 1.5, MemoryLimitMB: 512, MemoryReservationMB: 256, PidsLimit: 200, OOMPolicy: OOMPolicyStop}, false},
+		{"CPU exceeds host", ResourceLimits{CPUQuota: 8}, true},
+		{"Negative CPU", ResourceLimits{CPUQuota: -1}, true},
+		{"Memory exceeds host", ResourceLimits{MemoryLimitMB: 16384}, true},
+		{"Memory below minimum", ResourceLimits{MemoryLimitMB: 4}, true},
+		{"Reservation above limit", ResourceLimits{MemoryLimitMB: 256, MemoryReservationMB: 512}, true},
+		{"Reservation without limit", ResourceLimits{MemoryReservationMB: 512}, false},
+		{"Negative pids", ResourceLimits{PidsLimit: -1}, true},
+		{"Invalid OOM policy", ResourceLimits{OOMPolicy: "restart"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Validate(capacity)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResourceLimitsValidateUnknownMemory(t *testing.T) {
+	// 无法读取主机内存时不校验内存上限
+	limits := ResourceLimits{MemoryLimitMB: 1 << 20}
+	if err := limits.Validate(HostCapacity{CPUs: 2}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestResourceLimitsQuadletSettings(t *testing.T) {
+	limits := ResourceLimits{CPUQuota: 0.5, MemoryLimitMB: 512, MemoryReservationMB: 256, PidsLimit: 100, OOMPolicy: OOMPolicyKill}
+
+	expectedArgs := []string{"--cpus=0.5", "--memory=512m", "--memory-reservation=256m", "--pids-limit=100"}
+	if args := limits.PodmanArgs(); !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("Expected %v, got %v", expectedArgs, args)
+	}
+
+	expectedSettings := []string{"OOMPolicy=kill"}
+	if settings := limits.ServiceSettings(); !reflect.DeepEqual(settings, expectedSettings) {
+		t.Errorf("Expected %v, got %v", expectedSettings, settings)
+	}
+
+	if args := (ResourceLimits{}).PodmanArgs(); args != nil {
+		t.Errorf("Expected no args for empty limits, got %v", args)
+	}
+}
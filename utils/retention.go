@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 保留策略的上限，避免误填过大的值导致永远不清理
+const (
+	maxRetentionKeepLast = 1000
+	maxRetentionKeepDays = 3650
+)
+
+// RetentionPolicy 应用 Release 的保留策略，零值表示不清理。
+// 保留最近 KeepLast 个 Release 以及 KeepDays 天内创建的 Release，满足任一条件即保留
+type RetentionPolicy struct {
+	RetentionKeepLast int `gorm:"not null;default:0"` // 保留最近的 Release 个数
+	RetentionKeepDays int `gorm:"not null;default:0"` // 保留最近多少天内创建的 Release
+}
+
+// RetentionCandidate 参与保留策略计算的 Release，Pinned 为 true 时始终保留（当前版本、回滚目标、进行中的部署等）
+type RetentionCandidate struct {
+	ID        string
+	CreatedAt time.Time
+	Pinned    bool
+}
+
+// Enabled 是否设置了保留策略
+func (p RetentionPolicy) Enabled() bool {
+	return p.RetentionKeepLast > 0 || p.RetentionKeepDays > 0
+}
+
+// Validate 校验保留策略的取值范围
+func (p RetentionPolicy) Validate() error {
+	if p.RetentionKeepLast < 0 || p.RetentionKeepDays < 0 {
+		return fmt.Errorf("保留策略不能为负数")
+	}
+	if p.RetentionKeepLast > maxRetentionKeepLast {
+		return fmt.Errorf("保留的 Release 个数不能超过 %d", maxRetentionKeepLast)
+	}
+	if p.RetentionKeepDays > maxRetentionKeepDays {
+		return fmt.Errorf("保留天数不能超过 %d", maxRetentionKeepDays)
+	}
+	return nil
+}
+
+// SelectExpired 返回按策略应当清理的候选 ID，按创建时间从新到旧排列；策略未设置时不清理任何候选
+func (p RetentionPolicy) SelectExpired(candidates []RetentionCandidate, now time.Time) []string {
+	if !p.Enabled() {
+		return nil
+	}
+
+	sorted := make([]RetentionCandidate, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	cutoff := now.AddDate(0, 0, -p.RetentionKeepDays)
+	var expired []string
+	for i, candidate := range sorted {
+		switch {
+		case candidate.Pinned:
+		case p.RetentionKeepLast > 0 && i < p.RetentionKeepLast:
+		case p.RetentionKeepDays > 0 && candidate.CreatedAt.After(cutoff):
+		default:
+			expired = append(expired, candidate.ID)
+		}
+	}
+	return expired
+}
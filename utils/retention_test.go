@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetentionPolicySelectExpired(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	candidates := []RetentionCandidate{
+		{ID: "r1", CreatedAt: daysAgo(40), Pinned: true}, // 回滚目标
+		{ID: "r2", CreatedAt: daysAgo(30)},
+		{ID: "r3", CreatedAt: daysAgo(20)},
+		{ID: "r4", CreatedAt: daysAgo(10)},
+		{ID: "r5", CreatedAt: daysAgo(5)},
+		{ID: "r6", CreatedAt: daysAgo(1)},
+	}
+
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{"disabled", RetentionPolicy{}, nil},
+		{"keep last", RetentionPolicy{RetentionKeepLast: 2}, []string{"r4", "r3", "r2"}},
+		{"keep days", RetentionPolicy{RetentionKeepDays: 15}, []string{"r3", "r2"}},
+		{"either rule keeps", RetentionPolicy{RetentionKeepLast: 4, RetentionKeepDays: 7}, []string{"r2"}},
+		{"keep all", RetentionPolicy{RetentionKeepLast: 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.SelectExpired(candidates, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionPolicyValidate(t *testing.T) {
+	if err := (RetentionPolicy{RetentionKeepLast: 5, RetentionKeepDays: 30}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	for _, p := range []RetentionPolicy{{RetentionKeepLast: -1}, {RetentionKeepDays: -1}, {RetentionKeepLast: 1001}, {RetentionKeepDays: 3651}} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", p)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package utils
+
+import "strings"
+
+// 行差异的类型
+const (
+	LineEqual   = " "
+	LineAdded   = "+"
+	LineRemoved = "-"
+)
+
+// maxDiffLines 超过该行数时不计算最长公共子序列，整体视为删除后新增
+const maxDiffLines = 5000
+
+// LineDiff 文本差异中的一行
+type LineDiff struct {
+	Op   string // LineEqual、LineAdded 或 LineRemoved
+	Text string
+}
+
+// DiffLines 按行比较 before 与 after，返回包含未变化行在内的完整差异
+func DiffLines(before, after string) []LineDiff {
+	a, b := splitLines(before), splitLines(after)
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		diff := make([]LineDiff, 0, len(a)+len(b))
+		for _, line := range a {
+			diff = append(diff, LineDiff{Op: LineRemoved, Text: line})
+		}
+		for _, line := range b {
+			diff = append(diff, LineDiff{Op: LineAdded, Text: line})
+		}
+		return diff
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]LineDiff, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, LineDiff{Op: LineEqual, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, LineDiff{Op: LineRemoved, Text: a[i]})
+			i++
+		default:
+			diff = append(diff, LineDiff{Op: LineAdded, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, LineDiff{Op: LineRemoved, Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, LineDiff{Op: LineAdded, Text: b[j]})
+	}
+	return diff
+}
+
+// LineDiffChanged 判断差异中是否有新增或删除的行
+func LineDiffChanged(diff []LineDiff) bool {
+	for _, line := range diff {
+		if line.Op != LineEqual {
+			return true
+		}
+	}
+	return false
+}
+
+// splitLines 按行拆分文本，忽略末尾换行，空文本返回 nil
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
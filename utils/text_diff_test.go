@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	before := "[Container]\nImage=web:v1\nPublishPort=10001:8080\nVolume=data:/data\n"
+	after := "[Container]\nImage=web:v2\nPublishPort=10001:8080\nVolume=data:/data\nPodmanArgs=--cpus=1\n"
+
+	diff := DiffLines(before, after)
+	expected := []LineDiff{
+		{LineEqual, "[Container]"},
+		{LineRemoved, "Image=web:v1"},
+		{LineAdded, "Image=web:v2"},
+		{LineEqual, "PublishPort=10001:8080"},
+		{LineEqual, "Volume=data:/data"},
+		{LineAdded, "PodmanArgs=--cpus=1"},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, diff)
+	}
+	if !LineDiffChanged(diff) {
+		t.Error("Expected changes")
+	}
+
+	// 换行符不同视为相同内容
+	if LineDiffChanged(DiffLines("a\r\nb\r\n", "a\nb")) {
+		t.Error("Expected no changes for different line endings")
+	}
+
+	if diff := DiffLines("", "a\nb"); !reflect.DeepEqual(diff, []LineDiff{{LineAdded, "a"}, {LineAdded, "b"}}) {
+		t.Errorf("Unexpected diff from empty text: %+v", diff)
+	}
+	if diff := DiffLines("a", ""); !reflect.DeepEqual(diff, []LineDiff{{LineRemoved, "a"}}) {
+		t.Errorf("Unexpected diff to empty text: %+v", diff)
+	}
+}
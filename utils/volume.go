@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultVolumeRoot 默认允许作为绑定挂载源的主机目录
+const DefaultVolumeRoot = "/var/lib/orbitdeploy/volumes"
+
+// podman 命名卷的名称格式
+var volumeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// VolumeRoots 返回允许作为绑定挂载源的主机目录，可通过 ORBIT_VOLUME_ROOTS 以逗号分隔配置多个
+func VolumeRoots() []string {
+	value := os.Getenv("ORBIT_VOLUME_ROOTS")
+	if value == "" {
+		return []string{DefaultVolumeRoot}
+	}
+	var roots []string
+	for _, root := range strings.Split(value, ",") {
+		root = strings.TrimSpace(root)
+		if strings.HasPrefix(root, "/") && root != "/" {
+			roots = append(roots, path.Clean(root))
+		}
+	}
+	return roots
+}
+
+// ValidateVolumeMount 校验卷挂载能否安全写入 Quadlet 的 Volume=：
+// 源只能是命名卷或允许目录下的绝对路径，源和目标都不能包含控制字符或 ':'
+func ValidateVolumeMount(source, target string) error {
+	if err := validateVolumePath(source); err != nil {
+		return fmt.Errorf("卷挂载源 %q 无效: %w", source, err)
+	}
+	if err := validateVolumePath(target); err != nil {
+		return fmt.Errorf("卷挂载目标 %q 无效: %w", target, err)
+	}
+
+	if !strings.HasPrefix(target, "/") || path.Clean(target) != target {
+		return fmt.Errorf("卷挂载目标 %q 必须为规范的绝对路径", target)
+	}
+
+	if !strings.HasPrefix(source, "/") {
+		if !volumeNamePattern.MatchString(source) {
+			return fmt.Errorf("卷挂载源 %q 无效，命名卷只能包含字母、数字、'_'、'.' 和 '-'，主机目录必须为绝对路径", source)
+		}
+		return nil
+	}
+	if path.Clean(source) != source {
+		return fmt.Errorf("卷挂载源 %q 必须为规范的绝对路径", source)
+	}
+	roots := VolumeRoots()
+	for _, root := range roots {
+		if source == root || strings.HasPrefix(source, root+"/") {
+			return nil
+		}
+	}
+	return fmt.Errorf("卷挂载源 %q 不在允许的目录下 (%s)，可通过 ORBIT_VOLUME_ROOTS 配置", source, strings.Join(roots, ", "))
+}
+
+func validateVolumePath(value string) error {
+	if value == "" {
+		return fmt.Errorf("不能为空")
+	}
+	if strings.Contains(value, ":") {
+		return fmt.Errorf("不能包含 ':'")
+	}
+	if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+		return fmt.Errorf("不能包含控制字符或换行")
+	}
+	return nil
+}
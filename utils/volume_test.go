@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateVolumeMount(t *testing.T) {
+	t.Setenv("ORBIT_VOLUME_ROOTS", "/srv/data, /mnt/nfs/")
+
+	valid := []struct{ source, target string }{
+		{"app-data", "/data"},
+		{"pgdata.volume", "/var/lib/postgresql/data"},
+		{"/srv/data", "/data"},
+		{"/mnt/nfs/blog", "/usr/share/nginx/html"},
+	}
+	for _, v := range valid {
+		assert.NoError(t, ValidateVolumeMount(v.source, v.target), "%s:%s", v.source, v.target)
+	}
+
+	invalid := []struct{ source, target string }{
+		{"", "/data"},
+		{"app-data", ""},
+		{"app-data", "data"},
+		{"app-data", "/data/../etc"},
+		{"app-data", "/data:rw"},
+		{"app-data", "/data\nExec=/bin/sh"},
+		{"app-data\nPodmanArgs=--privileged", "/data"},
+		{"/srv/data:/etc", "/data"},
+		{"my-blog/content", "/data"},
+		{"-v", "/data"},
+		{"/etc", "/data"},
+		{"/srv/database", "/data"},
+		{"/srv/data/../../etc", "/data"},
+	}
+	for _, v := range invalid {
+		assert.Error(t, ValidateVolumeMount(v.source, v.target), "%q:%q", v.source, v.target)
+	}
+}
+
+func TestVolumeRootsDefault(t *testing.T) {
+	t.Setenv("ORBIT_VOLUME_ROOTS", "")
+	assert.Equal(t, []string{DefaultVolumeRoot}, VolumeRoots())
+}